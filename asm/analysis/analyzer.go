@@ -0,0 +1,250 @@
+package analysis
+
+import (
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// Analyzer a semantic bytecode analyzer. This analyzer computes, for each instruction of a method, the
+// {@link Frame} before its execution, using a semantic {@link Interpreter}. The frames of the unreachable
+// instructions are nil. Methods with JSR and RET instructions (subroutines) are not supported.
+type Analyzer[V Value] struct {
+	// OnControlFlowEdge if not nil, is called for each normal control flow edge between two instructions,
+	// given by their index.
+	OnControlFlowEdge func(insn, successor int)
+	// OnControlFlowExceptionEdge if not nil, is called for each exception control flow edge from an instruction
+	// to the handler of a try catch block. The edge is ignored if it returns false.
+	OnControlFlowExceptionEdge func(insn int, tryCatchBlock *tree.TryCatchBlockNode) bool
+	// Universe the table used to intern the types created by the analyzer, which can be shared by several
+	// analyzers. A new one is created by {@link NewAnalyzer}.
+	Universe *Universe
+
+	interpreter Interpreter[V]
+	insns       []tree.AbstractInsnNode
+	indexes     map[tree.AbstractInsnNode]int
+	frames      []*Frame[V]
+	handlers    [][]*tree.TryCatchBlockNode
+	queued      []bool
+	queue       []int
+}
+
+// NewAnalyzer constructs a new {@link Analyzer} using the given interpreter.
+func NewAnalyzer[V Value](interpreter Interpreter[V]) *Analyzer[V] {
+	return &Analyzer[V]{interpreter: interpreter, Universe: NewUniverse()}
+}
+
+// Analyze analyzes the given method of the given class (given by its internal name), and returns the frames
+// before each instruction of the method. Abstract and native methods have no frames.
+func (a *Analyzer[V]) Analyze(owner string, method *tree.MethodNode) ([]*Frame[V], error) {
+	if (method.Access & (opcodes.ACC_ABSTRACT | opcodes.ACC_NATIVE)) != 0 {
+		a.frames = nil
+		return a.frames, nil
+	}
+	if interpreter, ok := a.interpreter.(UniverseInterpreter); ok {
+		interpreter.SetUniverse(a.Universe)
+	}
+	a.insns = method.Instructions
+	n := len(a.insns)
+	a.indexes = make(map[tree.AbstractInsnNode]int, n)
+	for i, insn := range a.insns {
+		a.indexes[insn] = i
+		if insn.GetOpcode() == opcodes.JSR || insn.GetOpcode() == opcodes.RET {
+			return nil, NewAnalyzerError(insn, "JSR/RET are not supported")
+		}
+	}
+	a.frames = make([]*Frame[V], n)
+	a.handlers = make([][]*tree.TryCatchBlockNode, n)
+	a.queued = make([]bool, n)
+	a.queue = a.queue[:0]
+
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		begin, end := a.indexes[tryCatchBlock.Start], a.indexes[tryCatchBlock.End]
+		for i := begin; i < end; i++ {
+			a.handlers[i] = append(a.handlers[i], tryCatchBlock)
+		}
+	}
+	if n == 0 {
+		return a.frames, nil
+	}
+
+	current := NewFrame[V](method.MaxLocals, method.MaxStack)
+	handler := NewFrame[V](method.MaxLocals, method.MaxStack)
+	current.SetReturn(a.interpreter.NewValue(a.Universe.GetReturnType(method.Descriptor)))
+	isInstanceMethod := (method.Access & opcodes.ACC_STATIC) == 0
+	newParameterValue := func(local int, t *asm.Type) V {
+		if interpreter, ok := a.interpreter.(ParameterInterpreter[V]); ok {
+			return interpreter.NewParameterValue(isInstanceMethod, local, t)
+		}
+		return a.interpreter.NewValue(t)
+	}
+	local := 0
+	if isInstanceMethod {
+		if local >= method.MaxLocals {
+			return nil, NewAnalyzerError(nil, "Insufficient maximum number of locals")
+		}
+		current.SetLocal(local, newParameterValue(local, a.Universe.GetObjectType(owner)))
+		local++
+	}
+	for _, argumentType := range a.Universe.GetArgumentTypes(method.Descriptor) {
+		if local+argumentType.GetSize() > method.MaxLocals {
+			return nil, NewAnalyzerError(nil, "Insufficient maximum number of locals")
+		}
+		current.SetLocal(local, newParameterValue(local, argumentType))
+		local++
+		if argumentType.GetSize() == 2 {
+			current.SetLocal(local, a.interpreter.NewValue(nil))
+			local++
+		}
+	}
+	for local < method.MaxLocals {
+		current.SetLocal(local, a.interpreter.NewValue(nil))
+		local++
+	}
+	if _, err := a.merge(0, current); err != nil {
+		return nil, err
+	}
+
+	for len(a.queue) > 0 {
+		insn := a.queue[len(a.queue)-1]
+		a.queue = a.queue[:len(a.queue)-1]
+		a.queued[insn] = false
+		frame := a.frames[insn]
+		if err := a.analyzeInsn(insn, frame, current, handler); err != nil {
+			if analyzerError, ok := err.(*AnalyzerError); ok {
+				node := analyzerError.Node
+				if node == nil {
+					node = a.insns[insn]
+				}
+				err := NewAnalyzerError(node, "Error at instruction "+strconv.Itoa(insn)+": "+analyzerError.Message)
+				err.Expected, err.Found = analyzerError.Expected, analyzerError.Found
+				return nil, err
+			}
+			return nil, err
+		}
+	}
+	return a.frames, nil
+}
+
+func (a *Analyzer[V]) analyzeInsn(insn int, frame, current, handler *Frame[V]) error {
+	insnNode := a.insns[insn]
+	opcode := insnNode.GetOpcode()
+	switch insnType := insnNode.GetType(); {
+	case insnType == tree.LABEL || insnType == tree.LINE || insnType == tree.FRAME:
+		if err := a.mergeNext(insn, frame); err != nil {
+			return err
+		}
+	default:
+		if err := current.Init(frame).Execute(insnNode, a.interpreter); err != nil {
+			return err
+		}
+		switch insnNode := insnNode.(type) {
+		case *tree.JumpInsnNode:
+			if opcode != opcodes.GOTO {
+				if err := a.mergeNext(insn, current); err != nil {
+					return err
+				}
+			}
+			if err := a.mergeJump(insn, insnNode.Label, current); err != nil {
+				return err
+			}
+		case *tree.LookupSwitchInsnNode:
+			if err := a.mergeJump(insn, insnNode.Dflt, current); err != nil {
+				return err
+			}
+			for _, label := range insnNode.Labels {
+				if err := a.mergeJump(insn, label, current); err != nil {
+					return err
+				}
+			}
+		case *tree.TableSwitchInsnNode:
+			if err := a.mergeJump(insn, insnNode.Dflt, current); err != nil {
+				return err
+			}
+			for _, label := range insnNode.Labels {
+				if err := a.mergeJump(insn, label, current); err != nil {
+					return err
+				}
+			}
+		default:
+			if opcode != opcodes.ATHROW && (opcode < opcodes.IRETURN || opcode > opcodes.RETURN) {
+				if err := a.mergeNext(insn, current); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, tryCatchBlock := range a.handlers[insn] {
+		exceptionType := "java/lang/Throwable"
+		if tryCatchBlock.Type != "" {
+			exceptionType = tryCatchBlock.Type
+		}
+		if a.OnControlFlowExceptionEdge != nil && !a.OnControlFlowExceptionEdge(insn, tryCatchBlock) {
+			continue
+		}
+		handler.Init(frame)
+		handler.ClearStack()
+		if err := handler.Push(a.interpreter.NewExceptionValue(tryCatchBlock, a.Universe.GetObjectType(exceptionType))); err != nil {
+			return err
+		}
+		if _, err := a.merge(a.indexes[tryCatchBlock.Handler], handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeNext merges the given frame into the frame of the instruction following insn.
+func (a *Analyzer[V]) mergeNext(insn int, frame *Frame[V]) error {
+	if insn+1 >= len(a.insns) {
+		return NewAnalyzerError(a.insns[insn], "Execution can fall off the end of the code")
+	}
+	if a.OnControlFlowEdge != nil {
+		a.OnControlFlowEdge(insn, insn+1)
+	}
+	_, err := a.merge(insn+1, frame)
+	return err
+}
+
+// mergeJump merges the given frame into the frame of the given jump target.
+func (a *Analyzer[V]) mergeJump(insn int, label *tree.LabelNode, frame *Frame[V]) error {
+	jump := a.indexes[label]
+	if a.OnControlFlowEdge != nil {
+		a.OnControlFlowEdge(insn, jump)
+	}
+	_, err := a.merge(jump, frame)
+	return err
+}
+
+func (a *Analyzer[V]) merge(insn int, frame *Frame[V]) (bool, error) {
+	oldFrame := a.frames[insn]
+	changed := false
+	if oldFrame == nil {
+		a.frames[insn] = CopyFrame(frame)
+		changed = true
+	} else {
+		var err error
+		if changed, err = oldFrame.Merge(frame, a.interpreter); err != nil {
+			return false, err
+		}
+	}
+	if changed && !a.queued[insn] {
+		a.queued[insn] = true
+		a.queue = append(a.queue, insn)
+	}
+	return changed, nil
+}
+
+// GetFrames returns the frames computed by the last call to {@link Analyze}.
+func (a *Analyzer[V]) GetFrames() []*Frame[V] {
+	return a.frames
+}
+
+// GetHandlers returns the try catch blocks covering the given instruction, computed by the last call to
+// {@link Analyze}.
+func (a *Analyzer[V]) GetHandlers(insn int) []*tree.TryCatchBlockNode {
+	return a.handlers[insn]
+}
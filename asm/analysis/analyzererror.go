@@ -0,0 +1,34 @@
+package analysis
+
+import "github.com/leaklessgfy/asm/asm/tree"
+
+// AnalyzerError an error thrown if a problem occurs during the analysis of a method.
+type AnalyzerError struct {
+	// Node the instruction where the problem occurred, or nil.
+	Node    tree.AbstractInsnNode
+	Message string
+	// Expected the expected value, for type errors, or "".
+	Expected string
+	// Found the value found instead of the expected one, for type errors, or "".
+	Found string
+}
+
+// NewAnalyzerError constructs a new {@link AnalyzerError}.
+func NewAnalyzerError(node tree.AbstractInsnNode, message string) *AnalyzerError {
+	return &AnalyzerError{Node: node, Message: message}
+}
+
+// NewAnalyzerTypeError constructs a new {@link AnalyzerError} for a value which does not have the expected
+// type.
+func NewAnalyzerTypeError(node tree.AbstractInsnNode, message, expected, found string) *AnalyzerError {
+	return &AnalyzerError{
+		Node:     node,
+		Message:  message + ": expected " + expected + ", but found " + found,
+		Expected: expected,
+		Found:    found,
+	}
+}
+
+func (a *AnalyzerError) Error() string {
+	return a.Message
+}
@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// BasicValue a {@link Value} used by the {@link BasicInterpreter}, which only distinguishes the primitive types
+// of the values, the references, and the return addresses of the JSR instructions. The basic values are the
+// following singletons, and can be compared with ==.
+type BasicValue struct {
+	t *asm.Type
+}
+
+// The basic values.
+var (
+	// UNINITIALIZED_VALUE the value of the uninitialized local variables, of the second word of the long and
+	// double values, and of the merge of incompatible values.
+	UNINITIALIZED_VALUE = &BasicValue{nil}
+	INT_VALUE           = &BasicValue{asm.INT_TYPE}
+	FLOAT_VALUE         = &BasicValue{asm.FLOAT_TYPE}
+	LONG_VALUE          = &BasicValue{asm.LONG_TYPE}
+	DOUBLE_VALUE        = &BasicValue{asm.DOUBLE_TYPE}
+	REFERENCE_VALUE     = &BasicValue{asm.GetObjectType("java/lang/Object")}
+	RETURNADDRESS_VALUE = &BasicValue{asm.VOID_TYPE}
+)
+
+// GetType returns the type of this value: int, float, long, double, java/lang/Object for the references, void
+// for the return addresses, or nil for {@link UNINITIALIZED_VALUE}.
+func (b *BasicValue) GetType() *asm.Type {
+	return b.t
+}
+
+func (b *BasicValue) GetSize() int {
+	if b == LONG_VALUE || b == DOUBLE_VALUE {
+		return 2
+	}
+	return 1
+}
+
+// IsReference returns whether this value is a reference.
+func (b *BasicValue) IsReference() bool {
+	return b == REFERENCE_VALUE
+}
+
+func (b *BasicValue) String() string {
+	switch b {
+	case UNINITIALIZED_VALUE:
+		return "."
+	case RETURNADDRESS_VALUE:
+		return "A"
+	case REFERENCE_VALUE:
+		return "R"
+	}
+	return b.t.GetDescriptor()
+}
+
+// BasicInterpreter an {@link Interpreter} for {@link BasicValue}s, which computes the kind of the values on the
+// stack and in the local variables (int, float, long, double, reference or return address), without checking
+// that the instructions use them consistently. It is the base of the type inference and reachability analyses:
+// the frames of the unreachable instructions computed by an {@link Analyzer} with this interpreter are nil.
+type BasicInterpreter struct{}
+
+// NewBasicInterpreter constructs a new {@link BasicInterpreter}.
+func NewBasicInterpreter() BasicInterpreter {
+	return BasicInterpreter{}
+}
+
+func basicValueOf(t *asm.Type) *BasicValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.BOOLEAN, typed.CHAR, typed.BYTE, typed.SHORT, typed.INT:
+		return INT_VALUE
+	case typed.FLOAT:
+		return FLOAT_VALUE
+	case typed.LONG:
+		return LONG_VALUE
+	case typed.DOUBLE:
+		return DOUBLE_VALUE
+	}
+	return REFERENCE_VALUE
+}
+
+func (BasicInterpreter) NewValue(t *asm.Type) *BasicValue {
+	if t == nil {
+		return UNINITIALIZED_VALUE
+	}
+	return basicValueOf(t)
+}
+
+func (BasicInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *BasicValue {
+	return REFERENCE_VALUE
+}
+
+func (BasicInterpreter) NewOperation(insn tree.AbstractInsnNode) (*BasicValue, error) {
+	switch opcode := insn.GetOpcode(); {
+	case opcode >= opcodes.ICONST_M1 && opcode <= opcodes.ICONST_5, opcode == opcodes.BIPUSH, opcode == opcodes.SIPUSH:
+		return INT_VALUE, nil
+	case opcode == opcodes.LCONST_0 || opcode == opcodes.LCONST_1:
+		return LONG_VALUE, nil
+	case opcode >= opcodes.FCONST_0 && opcode <= opcodes.FCONST_2:
+		return FLOAT_VALUE, nil
+	case opcode == opcodes.DCONST_0 || opcode == opcodes.DCONST_1:
+		return DOUBLE_VALUE, nil
+	case opcode == opcodes.LDC:
+		switch insn.(*tree.LdcInsnNode).Value.(type) {
+		case int, int32:
+			return INT_VALUE, nil
+		case float32:
+			return FLOAT_VALUE, nil
+		case int64:
+			return LONG_VALUE, nil
+		case float64:
+			return DOUBLE_VALUE, nil
+		case string, *asm.Type, *asm.Handle:
+			return REFERENCE_VALUE, nil
+		}
+		return nil, NewAnalyzerError(insn, "Illegal LDC value")
+	case opcode == opcodes.JSR:
+		return RETURNADDRESS_VALUE, nil
+	case opcode == opcodes.GETSTATIC:
+		return basicValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	}
+	// ACONST_NULL and NEW.
+	return REFERENCE_VALUE, nil
+}
+
+func (BasicInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *BasicValue) (*BasicValue, error) {
+	return value, nil
+}
+
+func (BasicInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *BasicValue) (*BasicValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.INEG, opcodes.IINC, opcodes.L2I, opcodes.F2I, opcodes.D2I, opcodes.I2B, opcodes.I2C, opcodes.I2S,
+		opcodes.ARRAYLENGTH, opcodes.INSTANCEOF:
+		return INT_VALUE, nil
+	case opcodes.FNEG, opcodes.I2F, opcodes.L2F, opcodes.D2F:
+		return FLOAT_VALUE, nil
+	case opcodes.LNEG, opcodes.I2L, opcodes.F2L, opcodes.D2L:
+		return LONG_VALUE, nil
+	case opcodes.DNEG, opcodes.I2D, opcodes.L2D, opcodes.F2D:
+		return DOUBLE_VALUE, nil
+	case opcodes.GETFIELD:
+		return basicValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcodes.NEWARRAY, opcodes.ANEWARRAY, opcodes.CHECKCAST:
+		return REFERENCE_VALUE, nil
+	}
+	// The jumps, switches, returns, PUTSTATIC, ATHROW and the monitor instructions have no result.
+	return nil, nil
+}
+
+func (BasicInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *BasicValue) (*BasicValue, error) {
+	switch opcode := insn.GetOpcode(); {
+	case opcode == opcodes.IALOAD, opcode == opcodes.BALOAD, opcode == opcodes.CALOAD, opcode == opcodes.SALOAD,
+		opcode == opcodes.LCMP, opcode >= opcodes.FCMPL && opcode <= opcodes.DCMPG:
+		return INT_VALUE, nil
+	case opcode == opcodes.AALOAD:
+		return REFERENCE_VALUE, nil
+	case opcode == opcodes.LALOAD:
+		return LONG_VALUE, nil
+	case opcode == opcodes.FALOAD:
+		return FLOAT_VALUE, nil
+	case opcode == opcodes.DALOAD:
+		return DOUBLE_VALUE, nil
+	case opcode >= opcodes.IADD && opcode <= opcodes.LXOR:
+		return []*BasicValue{INT_VALUE, LONG_VALUE, FLOAT_VALUE, DOUBLE_VALUE}[binaryOperandSort(opcode)], nil
+	}
+	// IF_ICMPEQ to IF_ACMPNE and PUTFIELD have no result.
+	return nil, nil
+}
+
+// binaryOperandSort returns the index of the int, long, float or double type of the operands of the given
+// arithmetic or logical instruction (IADD to LXOR).
+func binaryOperandSort(opcode int) int {
+	switch {
+	case opcode <= opcodes.DREM:
+		return (opcode - opcodes.IADD) % 4
+	case opcode <= opcodes.LUSHR:
+		return (opcode - opcodes.ISHL) % 2
+	default:
+		return (opcode - opcodes.IAND) % 2
+	}
+}
+
+func (BasicInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *BasicValue) (*BasicValue, error) {
+	return nil, nil
+}
+
+func (BasicInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*BasicValue) (*BasicValue, error) {
+	switch insn := insn.(type) {
+	case *tree.MultiANewArrayInsnNode:
+		return REFERENCE_VALUE, nil
+	case *tree.MethodInsnNode:
+		return basicValueOf(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.InvokeDynamicInsnNode:
+		return basicValueOf(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	}
+	return nil, NewAnalyzerError(insn, "Illegal n-ary instruction")
+}
+
+func (BasicInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *BasicValue) error {
+	return nil
+}
+
+func (BasicInterpreter) Merge(value1, value2 *BasicValue) *BasicValue {
+	if value1 != value2 {
+		return UNINITIALIZED_VALUE
+	}
+	return value1
+}
@@ -0,0 +1,36 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestBasicInterpreter(t *testing.T) {
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(I)J", "", nil)
+	method.VisitCode()
+	method.VisitVarInsn(opcodes.ILOAD, 0)
+	method.VisitInsn(opcodes.I2L)
+	method.VisitLdcInsn("s")
+	method.VisitInsn(opcodes.POP)
+	method.VisitInsn(opcodes.LRETURN)
+	method.VisitInsn(opcodes.NOP)
+	method.VisitMaxs(3, 1)
+	method.VisitEnd()
+
+	frames, err := analysis.NewAnalyzer[*analysis.BasicValue](analysis.NewBasicInterpreter()).Analyze("A", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames[0].GetLocal(0) != analysis.INT_VALUE {
+		t.Errorf("expected an int parameter, got %v", frames[0].GetLocal(0))
+	}
+	if frame := frames[3]; frame.GetStackSize() != 2 || frame.GetStack(0) != analysis.LONG_VALUE || !frame.GetStack(1).IsReference() {
+		t.Errorf("unexpected stack before POP %v %v", frame.GetStack(0), frame.GetStack(1))
+	}
+	if frames[5] != nil {
+		t.Error("expected no frame for the unreachable NOP")
+	}
+}
@@ -0,0 +1,229 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// Reasons of class initialization dependencies.
+const (
+	// SUPERCLASS_INIT the super class is initialized before its subclasses.
+	SUPERCLASS_INIT = iota
+	// STATIC_FIELD_ACCESS a GETSTATIC or PUTSTATIC instruction initializes the class declaring the field.
+	STATIC_FIELD_ACCESS
+	// STATIC_METHOD_CALL an INVOKESTATIC instruction initializes the class declaring the method.
+	STATIC_METHOD_CALL
+	// INSTANCE_CREATION a NEW instruction initializes the instantiated class.
+	INSTANCE_CREATION
+)
+
+var initReasonNames = []string{"superclass", "static field", "static call", "new"}
+
+// InitDependency a class whose initialization triggers the initialization of another class.
+type InitDependency struct {
+	// From the internal name of the class being initialized.
+	From string
+	// To the internal name of the class initialized by From.
+	To string
+	// Reason {@link SUPERCLASS_INIT}, {@link STATIC_FIELD_ACCESS}, {@link STATIC_METHOD_CALL} or
+	// {@link INSTANCE_CREATION}.
+	Reason int
+	// Method the name and descriptor of the method of From containing the triggering instruction: <clinit>()V
+	// or a static method of From called (directly or not) by <clinit>. Empty for {@link SUPERCLASS_INIT}.
+	Method string
+	// Insn the index of the triggering instruction in this method, or -1.
+	Insn int
+}
+
+func (i InitDependency) String() string {
+	s := i.From + " -> " + i.To + " (" + initReasonNames[i.Reason]
+	if i.Method != "" {
+		s += " in " + i.Method
+	}
+	return s + ")"
+}
+
+// ClassInitAnalyzer an analysis of the class initialization dependencies of a program: for each class, it
+// finds the classes whose initialization is triggered by its static initializer, directly or through the
+// static methods of the class it calls, and detects the initialization cycles, in which a class may observe
+// the default values of the static fields of another class. Compile time constant fields, whose accesses don't
+// trigger any initialization, are ignored. Calls to other classes are not followed: each class only reports its
+// direct dependencies.
+type ClassInitAnalyzer struct {
+	classes map[string]*tree.ClassNode
+}
+
+// NewClassInitAnalyzer constructs a new {@link ClassInitAnalyzer}.
+func NewClassInitAnalyzer() *ClassInitAnalyzer {
+	return &ClassInitAnalyzer{classes: make(map[string]*tree.ClassNode)}
+}
+
+// AddClass adds the given class to the analyzed program.
+func (c *ClassInitAnalyzer) AddClass(class *tree.ClassNode) {
+	c.classes[class.Name] = class
+}
+
+// AddClassFile adds the given class file to the analyzed program.
+func (c *ClassInitAnalyzer) AddClassFile(classFile []byte) error {
+	class, err := tree.ReadClassNode(classFile, 0)
+	if err != nil {
+		return err
+	}
+	c.AddClass(class)
+	return nil
+}
+
+// GetDependencies returns the initialization dependencies of the given class, in instruction order.
+func (c *ClassInitAnalyzer) GetDependencies(name string) []InitDependency {
+	class := c.classes[name]
+	if class == nil {
+		return nil
+	}
+	var dependencies []InitDependency
+	if class.SuperName != "" && (class.Access&opcodes.ACC_INTERFACE) == 0 {
+		dependencies = append(dependencies, InitDependency{name, class.SuperName, SUPERCLASS_INIT, "", -1})
+	}
+	found := make(map[string]bool)
+	visited := make(map[*tree.MethodNode]bool)
+	var scan func(method *tree.MethodNode)
+	scan = func(method *tree.MethodNode) {
+		if method == nil || visited[method] {
+			return
+		}
+		visited[method] = true
+		for i, insn := range method.Instructions {
+			target, reason := "", -1
+			switch insn := insn.(type) {
+			case *tree.FieldInsnNode:
+				if insn.Opcode == opcodes.GETSTATIC || insn.Opcode == opcodes.PUTSTATIC {
+					target, reason = c.fieldOwner(insn.Owner, insn.Name, insn.Descriptor), STATIC_FIELD_ACCESS
+				}
+			case *tree.MethodInsnNode:
+				if insn.Opcode != opcodes.INVOKESTATIC {
+					continue
+				}
+				if insn.Owner == name {
+					scan(class.GetMethod(insn.Name, insn.Descriptor))
+					continue
+				}
+				target, reason = insn.Owner, STATIC_METHOD_CALL
+			case *tree.TypeInsnNode:
+				if insn.Opcode == opcodes.NEW {
+					target, reason = insn.Type, INSTANCE_CREATION
+				}
+			}
+			if target == "" || target == name || found[target] {
+				continue
+			}
+			found[target] = true
+			dependencies = append(dependencies, InitDependency{name, target, reason, method.Name + method.Descriptor, i})
+		}
+	}
+	scan(class.GetMethod("<clinit>", "()V"))
+	return dependencies
+}
+
+// fieldOwner returns the class declaring the given static field, found from the given class and its super
+// classes, or "" if the field is a compile time constant. Returns the given owner if it is unknown.
+func (c *ClassInitAnalyzer) fieldOwner(owner, name, descriptor string) string {
+	for class := c.classes[owner]; class != nil; class = c.classes[class.SuperName] {
+		for _, field := range class.Fields {
+			if field.Name == name && field.Descriptor == descriptor {
+				if field.Value != nil && (field.Access&opcodes.ACC_FINAL) != 0 {
+					return ""
+				}
+				return class.Name
+			}
+		}
+	}
+	return owner
+}
+
+// FindCycles returns the initialization cycles of the analyzed program, i.e. the strongly connected components
+// of the dependency graph between its classes with more than one class. Each cycle is sorted, and the cycles
+// are sorted by their first class.
+func (c *ClassInitAnalyzer) FindCycles() [][]string {
+	names := make([]string, 0, len(c.classes))
+	for name := range c.classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	successors := make(map[string][]string)
+	for _, name := range names {
+		for _, dependency := range c.GetDependencies(name) {
+			if c.classes[dependency.To] != nil {
+				successors[name] = append(successors[name], dependency.To)
+			}
+		}
+	}
+
+	// Tarjan's strongly connected components algorithm.
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	var strongConnect func(name string)
+	strongConnect = func(name string) {
+		index[name], lowLink[name] = len(index), len(index)
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, successor := range successors[name] {
+			if _, ok := index[successor]; !ok {
+				strongConnect(successor)
+				lowLink[name] = min(lowLink[name], lowLink[successor])
+			} else if onStack[successor] {
+				lowLink[name] = min(lowLink[name], index[successor])
+			}
+		}
+		if lowLink[name] != index[name] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, name := range names {
+		if _, ok := index[name]; !ok {
+			strongConnect(name)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// String returns a report of the dependencies and of the cycles of the analyzed program.
+func (c *ClassInitAnalyzer) String() string {
+	names := make([]string, 0, len(c.classes))
+	for name := range c.classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		for _, dependency := range c.GetDependencies(name) {
+			sb.WriteString(dependency.String())
+			sb.WriteString("\n")
+		}
+	}
+	for _, cycle := range c.FindCycles() {
+		sb.WriteString("cycle: ")
+		sb.WriteString(strings.Join(cycle, " <-> "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
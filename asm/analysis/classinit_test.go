@@ -0,0 +1,129 @@
+package analysis_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// initClass returns a class with the given access flags, name and super class, whose static initializer is
+// generated by the given function (if not nil), followed by a RETURN.
+func initClass(access int, name, superName string, clinit func(methodVisitor asm.MethodVisitor)) *tree.ClassNode {
+	class := tree.NewClassNode()
+	class.Visit(opcodes.V1_8, access, name, "", superName, nil)
+	if clinit != nil {
+		methodVisitor := class.VisitMethod(opcodes.ACC_STATIC, "<clinit>", "()V", "", nil)
+		methodVisitor.VisitCode()
+		clinit(methodVisitor)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(2, 0)
+		methodVisitor.VisitEnd()
+	}
+	return class
+}
+
+// classInitProgram returns the classes of the {@link ClassInitAnalyzer} tests, where p/A and p/B, and p/D and
+// p/E, depend on each other.
+func classInitProgram() []*tree.ClassNode {
+	public := opcodes.ACC_PUBLIC | opcodes.ACC_SUPER
+	a := initClass(public, "p/A", "java/lang/Object", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitFieldInsn(opcodes.GETSTATIC, "p/B", "y", "I")
+		methodVisitor.VisitInsn(opcodes.POP)
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/A", "helper", "()V", false)
+	})
+	a.VisitField(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC|opcodes.ACC_FINAL, "K", "I", "", 1)
+	a.VisitField(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC, "f", "I", "", nil)
+	// The dependencies of the static methods called by <clinit> are included, even recursive ones.
+	helper := a.VisitMethod(opcodes.ACC_STATIC, "helper", "()V", "", nil)
+	helper.VisitCode()
+	helper.VisitTypeInsn(opcodes.NEW, "p/C")
+	helper.VisitInsn(opcodes.POP)
+	helper.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/A", "helper", "()V", false)
+	helper.VisitInsn(opcodes.RETURN)
+	helper.VisitMaxs(1, 0)
+	helper.VisitEnd()
+
+	// The field p/B.x is declared in p/Base, and the constant p/A.K doesn't initialize p/A.
+	b := initClass(public, "p/B", "p/Base", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitFieldInsn(opcodes.GETSTATIC, "p/A", "K", "I")
+		methodVisitor.VisitFieldInsn(opcodes.PUTSTATIC, "p/B", "x", "I")
+		methodVisitor.VisitFieldInsn(opcodes.GETSTATIC, "p/A", "f", "I")
+		methodVisitor.VisitInsn(opcodes.POP)
+	})
+	b.VisitField(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC, "y", "I", "", nil)
+	base := initClass(public, "p/Base", "java/lang/Object", nil)
+	base.VisitField(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC, "x", "I", "", nil)
+
+	// Accesses to the fields of the class itself and to unknown classes.
+	c := initClass(public, "p/C", "java/lang/Object", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitInsn(opcodes.ICONST_0)
+		methodVisitor.VisitFieldInsn(opcodes.PUTSTATIC, "p/C", "z", "I")
+		methodVisitor.VisitFieldInsn(opcodes.GETSTATIC, "java/lang/System", "out", "Ljava/io/PrintStream;")
+		methodVisitor.VisitInsn(opcodes.POP)
+	})
+	d := initClass(public, "p/D", "java/lang/Object", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/E", "create", "()Lp/E;", false)
+		methodVisitor.VisitInsn(opcodes.POP)
+	})
+	e := initClass(public, "p/E", "java/lang/Object", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitTypeInsn(opcodes.NEW, "p/D")
+		methodVisitor.VisitInsn(opcodes.POP)
+	})
+	// Interfaces don't initialize their super class.
+	i := initClass(opcodes.ACC_PUBLIC|opcodes.ACC_INTERFACE|opcodes.ACC_ABSTRACT, "p/I", "java/lang/Object", nil)
+	return []*tree.ClassNode{a, b, base, c, d, e, i}
+}
+
+func TestClassInitAnalyzer(t *testing.T) {
+	analyzer := analysis.NewClassInitAnalyzer()
+	for _, class := range classInitProgram() {
+		analyzer.AddClass(class)
+	}
+	assertLines(t, strings.Split(strings.TrimSuffix(analyzer.String(), "\n"), "\n"), []string{
+		`p/A -> java/lang/Object (superclass)`,
+		`p/A -> p/B (static field in <clinit>()V)`,
+		`p/A -> p/C (new in helper()V)`,
+		`p/B -> p/Base (superclass)`,
+		`p/B -> p/Base (static field in <clinit>()V)`,
+		`p/B -> p/A (static field in <clinit>()V)`,
+		`p/Base -> java/lang/Object (superclass)`,
+		`p/C -> java/lang/Object (superclass)`,
+		`p/C -> java/lang/System (static field in <clinit>()V)`,
+		`p/D -> java/lang/Object (superclass)`,
+		`p/D -> p/E (static call in <clinit>()V)`,
+		`p/E -> java/lang/Object (superclass)`,
+		`p/E -> p/D (new in <clinit>()V)`,
+		`cycle: p/A <-> p/B`,
+		`cycle: p/D <-> p/E`,
+	})
+
+	var lines []string
+	for _, dependency := range analyzer.GetDependencies("p/A") {
+		lines = append(lines, dependency.String()+" #"+strconv.Itoa(dependency.Insn))
+	}
+	assertLines(t, lines, []string{
+		`p/A -> java/lang/Object (superclass) #-1`,
+		`p/A -> p/B (static field in <clinit>()V) #0`,
+		`p/A -> p/C (new in helper()V) #0`,
+	})
+	if dependencies := analyzer.GetDependencies("p/Unknown"); dependencies != nil {
+		t.Errorf("unexpected dependencies %v", dependencies)
+	}
+}
+
+func TestClassInitAnalyzerWithoutCycles(t *testing.T) {
+	analyzer := analysis.NewClassInitAnalyzer()
+	for _, class := range classInitProgram() {
+		if class.Name != "p/B" && class.Name != "p/E" {
+			analyzer.AddClass(class)
+		}
+	}
+	if cycles := analyzer.FindCycles(); len(cycles) != 0 {
+		t.Errorf("unexpected cycles %v", cycles)
+	}
+}
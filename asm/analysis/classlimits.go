@@ -0,0 +1,376 @@
+package analysis
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/symbol"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// CheckClassLimits returns the limits of the class file format exceeded by the given class, i.e. its
+// estimated constant pool count (see {@link EstimateConstantPoolCount}), the estimated code size of each of its
+// methods (see {@link EstimateCodeSize}) and the parameter slots of each of its methods. It returns nil if the
+// class can be written.
+func CheckClassLimits(class *tree.ClassNode) []*asm.LimitExceededError {
+	var errs []*asm.LimitExceededError
+	constantPoolCount := EstimateConstantPoolCount(class)
+	if constantPoolCount > asm.MAX_CONSTANT_POOL_ENTRIES {
+		errs = append(errs, &asm.LimitExceededError{
+			Kind:      asm.ErrClassTooLarge,
+			ClassName: class.Name,
+			Value:     constantPoolCount,
+			Limit:     asm.MAX_CONSTANT_POOL_ENTRIES,
+		})
+	}
+	for _, method := range class.Methods {
+		if slots := parameterSlots(method); slots > asm.MAX_PARAMETER_SLOTS {
+			errs = append(errs, &asm.LimitExceededError{
+				Kind:       asm.ErrTooManyParameters,
+				ClassName:  class.Name,
+				MethodName: method.Name,
+				Descriptor: method.Descriptor,
+				Value:      slots,
+				Limit:      asm.MAX_PARAMETER_SLOTS,
+			})
+		}
+		if codeSize := estimateCodeSize(method, constantPoolCount > 256); codeSize > asm.MAX_CODE_SIZE {
+			errs = append(errs, &asm.LimitExceededError{
+				Kind:       asm.ErrMethodTooLarge,
+				ClassName:  class.Name,
+				MethodName: method.Name,
+				Descriptor: method.Descriptor,
+				Value:      codeSize,
+				Limit:      asm.MAX_CODE_SIZE,
+			})
+		}
+	}
+	return errs
+}
+
+// EnforceClassLimits checks the limits of the class file format exceeded by the given class, and calls the
+// given handler (if not nil) for each of them. The handler can change the class to fix it, in which case the
+// class is checked again after all the handler calls. It returns nil if the class can be written, or else the
+// error returned by the handler, or the first limit still exceeded.
+func EnforceClassLimits(class *tree.ClassNode, handler asm.LimitHandler) error {
+	errs := CheckClassLimits(class)
+	if len(errs) == 0 {
+		return nil
+	}
+	if handler == nil {
+		return errs[0]
+	}
+	for _, limitError := range errs {
+		if err := handler(limitError); err != nil {
+			return err
+		}
+	}
+	if errs = CheckClassLimits(class); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// parameterSlots returns the number of local variable slots of the parameters of the given method, including
+// 'this' for instance methods.
+func parameterSlots(method *tree.MethodNode) int {
+	slots := 0
+	if method.Access&opcodes.ACC_STATIC == 0 {
+		slots++
+	}
+	for _, argumentType := range asm.GetMethodType(method.Descriptor).GetArgumentTypes() {
+		slots += argumentType.GetSize()
+	}
+	return slots
+}
+
+// EstimateCodeSize returns the size in bytes of the code of the given method, as it would be written. LDC
+// instructions are assumed to use a one byte constant pool index, and jumps a two bytes offset, so that this
+// is a lower bound of the size for methods with very large constant pools or code.
+func EstimateCodeSize(method *tree.MethodNode) int {
+	return estimateCodeSize(method, false)
+}
+
+// estimateCodeSize returns the size in bytes of the code of the given method. If wideLdc is true, LDC
+// instructions are assumed to use a two bytes constant pool index.
+func estimateCodeSize(method *tree.MethodNode, wideLdc bool) int {
+	size := 0
+	for _, insn := range method.Instructions {
+		switch insn := insn.(type) {
+		case *tree.InsnNode:
+			size++
+		case *tree.IntInsnNode:
+			if insn.Opcode == opcodes.SIPUSH {
+				size += 3
+			} else {
+				size += 2
+			}
+		case *tree.VarInsnNode:
+			if insn.Var < 4 && insn.Opcode != opcodes.RET {
+				size++
+			} else if insn.Var <= math.MaxUint8 {
+				size += 2
+			} else {
+				size += 4
+			}
+		case *tree.IincInsnNode:
+			if insn.Var <= math.MaxUint8 && insn.Increment >= math.MinInt8 && insn.Increment <= math.MaxInt8 {
+				size += 3
+			} else {
+				size += 6
+			}
+		case *tree.LdcInsnNode:
+			switch insn.Value.(type) {
+			case int64, float64:
+				size += 3
+			default:
+				if wideLdc {
+					size += 3
+				} else {
+					size += 2
+				}
+			}
+		case *tree.TypeInsnNode, *tree.FieldInsnNode, *tree.JumpInsnNode:
+			size += 3
+		case *tree.MethodInsnNode:
+			if insn.Opcode == opcodes.INVOKEINTERFACE {
+				size += 5
+			} else {
+				size += 3
+			}
+		case *tree.InvokeDynamicInsnNode:
+			size += 5
+		case *tree.MultiANewArrayInsnNode:
+			size += 4
+		case *tree.TableSwitchInsnNode:
+			size += 1 + (3 - size%4) + 12 + 4*len(insn.Labels)
+		case *tree.LookupSwitchInsnNode:
+			size += 1 + (3 - size%4) + 8 + 8*len(insn.Labels)
+		}
+	}
+	return size
+}
+
+// EstimateConstantPoolCount returns the constant_pool_count of the given class, as it would be written. The
+// entries are counted from the content recorded by the {@link tree.ClassNode}, i.e. without the annotations,
+// inner classes, modules and non standard attributes of the class.
+func EstimateConstantPoolCount(class *tree.ClassNode) int {
+	pool := &poolEstimator{entries: make(map[string]bool), count: 1}
+	pool.addClass(class.Name)
+	if class.SuperName != "" {
+		pool.addClass(class.SuperName)
+	}
+	for _, itf := range class.Interfaces {
+		pool.addClass(itf)
+	}
+	if class.SourceFile != "" {
+		pool.addUTF8("SourceFile")
+		pool.addUTF8(class.SourceFile)
+	}
+	if class.Signature != "" {
+		pool.addUTF8("Signature")
+		pool.addUTF8(class.Signature)
+	}
+	for _, field := range class.Fields {
+		pool.addUTF8(field.Name)
+		pool.addUTF8(field.Descriptor)
+		if field.Signature != "" {
+			pool.addUTF8("Signature")
+			pool.addUTF8(field.Signature)
+		}
+		if field.Value != nil {
+			pool.addUTF8("ConstantValue")
+			pool.addConstant(field.Value)
+		}
+	}
+	for _, method := range class.Methods {
+		pool.addMethod(method)
+	}
+	return pool.count
+}
+
+// poolEstimator counts the distinct constant pool entries of a class.
+type poolEstimator struct {
+	entries map[string]bool
+	count   int
+}
+
+// add adds the entry of the given tag and key, which uses the given number of slots, if it is not already in
+// the constant pool. It returns true if the entry was added.
+func (p *poolEstimator) add(tag int, key string, slots int) bool {
+	key = strconv.Itoa(tag) + ":" + key
+	if p.entries[key] {
+		return false
+	}
+	p.entries[key] = true
+	p.count += slots
+	return true
+}
+
+func (p *poolEstimator) addUTF8(value string) {
+	p.add(symbol.CONSTANT_UTF8_TAG, value, 1)
+}
+
+func (p *poolEstimator) addClass(internalName string) {
+	if p.add(symbol.CONSTANT_CLASS_TAG, internalName, 1) {
+		p.addUTF8(internalName)
+	}
+}
+
+func (p *poolEstimator) addNameAndType(name, descriptor string) {
+	if p.add(symbol.CONSTANT_NAME_AND_TYPE_TAG, name+" "+descriptor, 1) {
+		p.addUTF8(name)
+		p.addUTF8(descriptor)
+	}
+}
+
+func (p *poolEstimator) addMemberRef(tag int, owner, name, descriptor string) {
+	if p.add(tag, owner+"."+name+descriptor, 1) {
+		p.addClass(owner)
+		p.addNameAndType(name, descriptor)
+	}
+}
+
+func (p *poolEstimator) addHandle(handle *asm.Handle) {
+	if !p.add(symbol.CONSTANT_METHOD_HANDLE_TAG, handle.String(), 1) {
+		return
+	}
+	switch {
+	case handle.IsField():
+		p.addMemberRef(symbol.CONSTANT_FIELDREF_TAG, handle.GetOwner(), handle.GetName(), handle.GetDesc())
+	case handle.IsInterface():
+		p.addMemberRef(symbol.CONSTANT_INTERFACE_METHODREF_TAG, handle.GetOwner(), handle.GetName(), handle.GetDesc())
+	default:
+		p.addMemberRef(symbol.CONSTANT_METHODREF_TAG, handle.GetOwner(), handle.GetName(), handle.GetDesc())
+	}
+}
+
+func (p *poolEstimator) addConstant(value interface{}) {
+	switch value := value.(type) {
+	case int, int32, int16, int8, bool:
+		p.add(symbol.CONSTANT_INTEGER_TAG, strconv.Itoa(int(toInt32(value))), 1)
+	case float32:
+		p.add(symbol.CONSTANT_FLOAT_TAG, strconv.FormatUint(uint64(math.Float32bits(value)), 16), 1)
+	case int64:
+		p.add(symbol.CONSTANT_LONG_TAG, strconv.FormatInt(value, 10), 2)
+	case float64:
+		p.add(symbol.CONSTANT_DOUBLE_TAG, strconv.FormatUint(math.Float64bits(value), 16), 2)
+	case string:
+		if p.add(symbol.CONSTANT_STRING_TAG, value, 1) {
+			p.addUTF8(value)
+		}
+	case *asm.Type:
+		switch value.GetSort() {
+		case typed.METHOD:
+			if p.add(symbol.CONSTANT_METHOD_TYPE_TAG, value.GetDescriptor(), 1) {
+				p.addUTF8(value.GetDescriptor())
+			}
+		case typed.OBJECT:
+			p.addClass(value.GetInternalName())
+		default:
+			p.addClass(value.GetDescriptor())
+		}
+	case *asm.Handle:
+		p.addHandle(value)
+	}
+}
+
+func (p *poolEstimator) addMethod(method *tree.MethodNode) {
+	p.addUTF8(method.Name)
+	p.addUTF8(method.Descriptor)
+	if method.Signature != "" {
+		p.addUTF8("Signature")
+		p.addUTF8(method.Signature)
+	}
+	if len(method.Exceptions) > 0 {
+		p.addUTF8("Exceptions")
+		for _, exception := range method.Exceptions {
+			p.addClass(exception)
+		}
+	}
+	if len(method.Parameters) > 0 {
+		p.addUTF8("MethodParameters")
+		for _, parameter := range method.Parameters {
+			if parameter.Name != "" {
+				p.addUTF8(parameter.Name)
+			}
+		}
+	}
+	if len(method.Instructions) == 0 {
+		return
+	}
+	p.addUTF8("Code")
+	for _, insn := range method.Instructions {
+		switch insn := insn.(type) {
+		case *tree.TypeInsnNode:
+			p.addClass(insn.Type)
+		case *tree.MultiANewArrayInsnNode:
+			p.addClass(insn.Descriptor)
+		case *tree.FieldInsnNode:
+			p.addMemberRef(symbol.CONSTANT_FIELDREF_TAG, insn.Owner, insn.Name, insn.Descriptor)
+		case *tree.MethodInsnNode:
+			if insn.IsInterface {
+				p.addMemberRef(symbol.CONSTANT_INTERFACE_METHODREF_TAG, insn.Owner, insn.Name, insn.Descriptor)
+			} else {
+				p.addMemberRef(symbol.CONSTANT_METHODREF_TAG, insn.Owner, insn.Name, insn.Descriptor)
+			}
+		case *tree.InvokeDynamicInsnNode:
+			p.addUTF8("BootstrapMethods")
+			p.add(symbol.CONSTANT_INVOKE_DYNAMIC_TAG, insn.Name+insn.Descriptor, 1)
+			p.addNameAndType(insn.Name, insn.Descriptor)
+			p.addHandle(insn.BootstrapMethodHandle)
+			for _, argument := range insn.BootstrapMethodArguments {
+				p.addConstant(argument)
+			}
+		case *tree.LdcInsnNode:
+			p.addConstant(insn.Value)
+		case *tree.FrameNode:
+			p.addUTF8("StackMapTable")
+			for _, values := range [][]interface{}{insn.Local, insn.Stack} {
+				for _, value := range values {
+					if internalName, ok := value.(string); ok {
+						p.addClass(internalName)
+					}
+				}
+			}
+		case *tree.LineNumberNode:
+			p.addUTF8("LineNumberTable")
+		}
+	}
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		if tryCatchBlock.Type != "" {
+			p.addClass(tryCatchBlock.Type)
+		}
+	}
+	for _, localVariable := range method.LocalVariables {
+		p.addUTF8("LocalVariableTable")
+		p.addUTF8(localVariable.Name)
+		p.addUTF8(localVariable.Descriptor)
+		if localVariable.Signature != "" {
+			p.addUTF8("LocalVariableTypeTable")
+			p.addUTF8(localVariable.Signature)
+		}
+	}
+}
+
+// toInt32 returns the int32 value of the given CONSTANT_Integer value.
+func toInt32(value interface{}) int32 {
+	switch value := value.(type) {
+	case int:
+		return int32(value)
+	case int32:
+		return value
+	case int16:
+		return int32(value)
+	case int8:
+		return int32(value)
+	case bool:
+		if value {
+			return 1
+		}
+	}
+	return 0
+}
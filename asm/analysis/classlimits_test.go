@@ -0,0 +1,52 @@
+package analysis_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestEnforceClassLimits(t *testing.T) {
+	class := tree.NewClassNode()
+	class.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC, "A", "", "java/lang/Object", nil)
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "big", "(J)V", "", nil)
+	method.VisitCode()
+	for i := 0; i <= asm.MAX_CODE_SIZE/2; i++ {
+		method.VisitVarInsn(opcodes.LLOAD, 0)
+		method.VisitInsn(opcodes.POP2)
+	}
+	method.VisitInsn(opcodes.RETURN)
+	class.Methods = append(class.Methods, method)
+	params := tree.NewMethodNode(0, "params", "("+strings.Repeat("D", 128)+")V", "", nil)
+	class.Methods = append(class.Methods, params)
+
+	errs := analysis.CheckClassLimits(class)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 exceeded limits, got %v", errs)
+	}
+	if !errors.Is(errs[0], asm.ErrMethodTooLarge) || errs[0].MethodName != "big" || errs[0].Value != asm.MAX_CODE_SIZE+2 {
+		t.Errorf("unexpected error %v", errs[0])
+	}
+	if !errors.Is(errs[1], asm.ErrTooManyParameters) || errs[1].Value != 257 {
+		t.Errorf("unexpected error %v", errs[1])
+	}
+
+	err := analysis.EnforceClassLimits(class, func(err *asm.LimitExceededError) error {
+		if errors.Is(err, asm.ErrTooManyParameters) {
+			return err
+		}
+		method.Instructions = method.Instructions[len(method.Instructions)-1:]
+		return nil
+	})
+	if !errors.Is(err, asm.ErrTooManyParameters) {
+		t.Errorf("expected the handler error, got %v", err)
+	}
+	if analysis.EstimateCodeSize(method) != 1 {
+		t.Errorf("expected the handler to shrink the method, got %d bytes", analysis.EstimateCodeSize(method))
+	}
+}
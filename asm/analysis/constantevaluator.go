@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// ConstantEvaluator computes the values of the static final fields of a program, the way javadoc reports them:
+// from their ConstantValue attribute if they have one, or otherwise by emulating the static initializer of their
+// class with a {@link ConstantInterpreter}. Static fields of the other classes read by a static initializer are
+// evaluated first, as the JVM would initialize their class, so that constants computed from other constants
+// (e.g. "static final int MASK = Flags.A | Flags.B") are found. Only the primitive and string values are
+// computed, and a field assigned with a value which is not always the same constant has no value.
+type ConstantEvaluator struct {
+	classes map[string]*tree.ClassNode
+	values  map[string]*ConstantValue
+	// states the evaluation state of each class: absent if not evaluated, false if being evaluated, true if done.
+	states map[string]bool
+}
+
+// NewConstantEvaluator constructs a new {@link ConstantEvaluator}.
+func NewConstantEvaluator() *ConstantEvaluator {
+	return &ConstantEvaluator{
+		classes: make(map[string]*tree.ClassNode),
+		values:  make(map[string]*ConstantValue),
+		states:  make(map[string]bool),
+	}
+}
+
+// AddClass adds the given class to the evaluated program.
+func (c *ConstantEvaluator) AddClass(class *tree.ClassNode) {
+	c.classes[class.Name] = class
+}
+
+// AddClassFile adds the given class file to the evaluated program.
+func (c *ConstantEvaluator) AddClassFile(classFile []byte) error {
+	class, err := tree.ReadClassNode(classFile, 0)
+	if err != nil {
+		return err
+	}
+	c.AddClass(class)
+	return nil
+}
+
+// GetFieldValue returns the value of the given static final field, as a value of its descriptor (see
+// {@link ConstantValue#ForField}), or false if it is not a field of the program or its value is unknown.
+func (c *ConstantEvaluator) GetFieldValue(owner, name string) (interface{}, bool) {
+	field, value := c.getField(owner, name)
+	if value == nil || !value.IsConstant() || value.GetValue() == nil {
+		return nil, false
+	}
+	constant := value.GetValue()
+	if intValue, ok := constant.(int32); ok {
+		constant = int(intValue)
+	}
+	constantValue, err := asm.NewConstantValue(constant)
+	if err != nil {
+		return nil, false
+	}
+	fieldValue, err := constantValue.ForField(field.Descriptor)
+	if err != nil {
+		return nil, false
+	}
+	return fieldValue, true
+}
+
+// getField returns the given static final field, looked up in the given class and its super classes, and its
+// value, or nil if it is unknown.
+func (c *ConstantEvaluator) getField(owner, name string) (*tree.FieldNode, *ConstantValue) {
+	for class := c.classes[owner]; class != nil; class = c.classes[class.SuperName] {
+		for _, field := range class.Fields {
+			if field.Name == name {
+				if (field.Access & (opcodes.ACC_STATIC | opcodes.ACC_FINAL)) != (opcodes.ACC_STATIC | opcodes.ACC_FINAL) {
+					return nil, nil
+				}
+				c.evaluate(class)
+				return field, c.values[class.Name+"."+name]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// evaluate computes the values of the static final fields of the given class, if not already done.
+func (c *ConstantEvaluator) evaluate(class *tree.ClassNode) {
+	if _, ok := c.states[class.Name]; ok {
+		// Already evaluated, or being evaluated (a class initialization cycle, whose fields are unknown).
+		return
+	}
+	c.states[class.Name] = false
+	defer func() { c.states[class.Name] = true }()
+	for _, field := range class.Fields {
+		if field.Value == nil || (field.Access&opcodes.ACC_STATIC) == 0 {
+			continue
+		}
+		switch value := field.Value.(type) {
+		case int:
+			c.values[class.Name+"."+field.Name] = NewConstantValue(int32(value))
+		case float32, int64, float64, string:
+			c.values[class.Name+"."+field.Name] = NewConstantValue(value)
+		}
+	}
+	classInit := class.GetMethod("<clinit>", "()V")
+	if classInit == nil {
+		return
+	}
+	frames, err := NewAnalyzer[*ConstantValue](&evaluatorInterpreter{evaluator: c}).Analyze(class.Name, classInit)
+	if err != nil {
+		return
+	}
+	assigned := make(map[string]bool)
+	for i, insn := range classInit.Instructions {
+		fieldInsn, ok := insn.(*tree.FieldInsnNode)
+		if !ok || fieldInsn.Opcode != opcodes.PUTSTATIC || fieldInsn.Owner != class.Name || frames[i] == nil {
+			continue
+		}
+		key := class.Name + "." + fieldInsn.Name
+		value := frames[i].GetStack(frames[i].GetStackSize() - 1)
+		if previous, ok := c.values[key]; assigned[key] || (ok && !previous.equals(value)) || !value.IsConstant() {
+			value = unknownConstantValue
+		}
+		assigned[key] = true
+		c.values[key] = value
+	}
+}
+
+// evaluatorInterpreter a {@link ConstantInterpreter} which gets the values of the static final fields from a
+// {@link ConstantEvaluator}.
+type evaluatorInterpreter struct {
+	ConstantInterpreter
+	evaluator *ConstantEvaluator
+}
+
+func (e *evaluatorInterpreter) NewOperation(insn tree.AbstractInsnNode) (*ConstantValue, error) {
+	if fieldInsn, ok := insn.(*tree.FieldInsnNode); ok && fieldInsn.Opcode == opcodes.GETSTATIC {
+		if _, value := e.evaluator.getField(fieldInsn.Owner, fieldInsn.Name); value != nil && value.IsConstant() &&
+			value.GetValue() != nil {
+			return value, nil
+		}
+	}
+	return e.ConstantInterpreter.NewOperation(insn)
+}
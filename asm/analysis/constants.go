@@ -0,0 +1,566 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// ConstantValue a {@link Value} used by the {@link ConstantInterpreter}. A constant value is either an int32,
+// int64, float32, float64 or string Java constant, or the null reference.
+type ConstantValue struct {
+	size     int
+	constant bool
+	nonNull  bool
+	value    interface{}
+}
+
+var (
+	uninitializedConstantValue = &ConstantValue{size: 1}
+	unknownConstantValue       = &ConstantValue{size: 1}
+	unknownLongConstantValue   = &ConstantValue{size: 2}
+	nonNullConstantValue       = &ConstantValue{size: 1, nonNull: true}
+	nullConstantValue          = &ConstantValue{size: 1, constant: true}
+)
+
+// NewConstantValue returns the {@link ConstantValue} of the given int32, int64, float32, float64 or string
+// constant, or of the null reference if value is nil.
+func NewConstantValue(value interface{}) *ConstantValue {
+	switch value.(type) {
+	case nil:
+		return nullConstantValue
+	case int64, float64:
+		return &ConstantValue{size: 2, constant: true, nonNull: true, value: value}
+	default:
+		return &ConstantValue{size: 1, constant: true, nonNull: true, value: value}
+	}
+}
+
+func (c *ConstantValue) GetSize() int {
+	return c.size
+}
+
+// IsConstant returns whether this value is a known constant.
+func (c *ConstantValue) IsConstant() bool {
+	return c.constant
+}
+
+// IsNull returns whether this value is the null reference.
+func (c *ConstantValue) IsNull() bool {
+	return c == nullConstantValue
+}
+
+// IsNonNull returns whether this value is known to be a non null reference, or a primitive constant.
+func (c *ConstantValue) IsNonNull() bool {
+	return c.nonNull
+}
+
+// GetValue returns the constant of this value: an int32, int64, float32, float64 or string, or nil.
+func (c *ConstantValue) GetValue() interface{} {
+	return c.value
+}
+
+func (c *ConstantValue) equals(value *ConstantValue) bool {
+	if c == value {
+		return true
+	}
+	if c.size != value.size || c.constant != value.constant || c.nonNull != value.nonNull {
+		return false
+	}
+	switch v := c.value.(type) {
+	case float32:
+		w, ok := value.value.(float32)
+		return ok && math.Float32bits(v) == math.Float32bits(w)
+	case float64:
+		w, ok := value.value.(float64)
+		return ok && math.Float64bits(v) == math.Float64bits(w)
+	default:
+		return c.value == value.value
+	}
+}
+
+func (c *ConstantValue) String() string {
+	switch {
+	case c == uninitializedConstantValue:
+		return "."
+	case c == nullConstantValue:
+		return "null"
+	case !c.constant:
+		return "?"
+	case c.value != nil:
+		if s, ok := c.value.(string); ok {
+			return fmt.Sprintf("%q", s)
+		}
+		return fmt.Sprint(c.value)
+	}
+	return "?"
+}
+
+// ConstantInterpreter an {@link Interpreter} for constant propagation and folding. It tracks the int, long,
+// float, double and string constants, and the null reference, through the stack and the local variables, and
+// computes the result of the arithmetic, comparison and conversion instructions on constants.
+type ConstantInterpreter struct{}
+
+// NewConstantInterpreter constructs a new {@link ConstantInterpreter}.
+func NewConstantInterpreter() ConstantInterpreter {
+	return ConstantInterpreter{}
+}
+
+func unknownConstantValueOf(t *asm.Type) *ConstantValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.LONG, typed.DOUBLE:
+		return unknownLongConstantValue
+	default:
+		return unknownConstantValue
+	}
+}
+
+func (ConstantInterpreter) NewValue(t *asm.Type) *ConstantValue {
+	if t == nil {
+		return uninitializedConstantValue
+	}
+	return unknownConstantValueOf(t)
+}
+
+func (ConstantInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *ConstantValue {
+	return nonNullConstantValue
+}
+
+func (ConstantInterpreter) NewOperation(insn tree.AbstractInsnNode) (*ConstantValue, error) {
+	opcode := insn.GetOpcode()
+	switch {
+	case opcode == opcodes.ACONST_NULL:
+		return nullConstantValue, nil
+	case opcode >= opcodes.ICONST_M1 && opcode <= opcodes.ICONST_5:
+		return NewConstantValue(int32(opcode - opcodes.ICONST_0)), nil
+	case opcode == opcodes.LCONST_0 || opcode == opcodes.LCONST_1:
+		return NewConstantValue(int64(opcode - opcodes.LCONST_0)), nil
+	case opcode >= opcodes.FCONST_0 && opcode <= opcodes.FCONST_2:
+		return NewConstantValue(float32(opcode - opcodes.FCONST_0)), nil
+	case opcode == opcodes.DCONST_0 || opcode == opcodes.DCONST_1:
+		return NewConstantValue(float64(opcode - opcodes.DCONST_0)), nil
+	case opcode == opcodes.BIPUSH || opcode == opcodes.SIPUSH:
+		return NewConstantValue(int32(insn.(*tree.IntInsnNode).Operand)), nil
+	case opcode == opcodes.LDC:
+		switch value := insn.(*tree.LdcInsnNode).Value.(type) {
+		case int:
+			return NewConstantValue(int32(value)), nil
+		case int32, int64, float32, float64, string:
+			return NewConstantValue(value), nil
+		default:
+			return nonNullConstantValue, nil
+		}
+	case opcode == opcodes.GETSTATIC:
+		return unknownConstantValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcode == opcodes.NEW:
+		return nonNullConstantValue, nil
+	}
+	return unknownConstantValue, nil
+}
+
+func (ConstantInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *ConstantValue) (*ConstantValue, error) {
+	return value, nil
+}
+
+func (ConstantInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *ConstantValue) (*ConstantValue, error) {
+	opcode := insn.GetOpcode()
+	switch opcode {
+	case opcodes.GETFIELD:
+		return unknownConstantValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcodes.NEWARRAY, opcodes.ANEWARRAY:
+		return nonNullConstantValue, nil
+	case opcodes.CHECKCAST:
+		return value, nil
+	case opcodes.INSTANCEOF:
+		if value.IsNull() {
+			return NewConstantValue(int32(0)), nil
+		}
+		return unknownConstantValue, nil
+	case opcodes.ARRAYLENGTH:
+		return unknownConstantValue, nil
+	}
+	result := foldUnary(insn, value.value)
+	if result != nil && value.constant {
+		return NewConstantValue(result), nil
+	}
+	switch opcode {
+	case opcodes.LNEG, opcodes.DNEG, opcodes.I2L, opcodes.I2D, opcodes.L2D, opcodes.F2L, opcodes.F2D, opcodes.D2L:
+		return unknownLongConstantValue, nil
+	}
+	return unknownConstantValue, nil
+}
+
+// foldUnary returns the result of the given unary instruction on the given constant, or nil.
+func foldUnary(insn tree.AbstractInsnNode, value interface{}) interface{} {
+	switch v := value.(type) {
+	case int32:
+		switch insn.GetOpcode() {
+		case opcodes.INEG:
+			return -v
+		case opcodes.IINC:
+			return v + int32(insn.(*tree.IincInsnNode).Increment)
+		case opcodes.I2L:
+			return int64(v)
+		case opcodes.I2F:
+			return float32(v)
+		case opcodes.I2D:
+			return float64(v)
+		case opcodes.I2B:
+			return int32(int8(v))
+		case opcodes.I2C:
+			return int32(uint16(v))
+		case opcodes.I2S:
+			return int32(int16(v))
+		}
+	case int64:
+		switch insn.GetOpcode() {
+		case opcodes.LNEG:
+			return -v
+		case opcodes.L2I:
+			return int32(v)
+		case opcodes.L2F:
+			return float32(v)
+		case opcodes.L2D:
+			return float64(v)
+		}
+	case float32:
+		switch insn.GetOpcode() {
+		case opcodes.FNEG:
+			return -v
+		case opcodes.F2I:
+			return int32(javaFloatToLong(float64(v), math.MinInt32, math.MaxInt32))
+		case opcodes.F2L:
+			return javaFloatToLong(float64(v), math.MinInt64, math.MaxInt64)
+		case opcodes.F2D:
+			return float64(v)
+		}
+	case float64:
+		switch insn.GetOpcode() {
+		case opcodes.DNEG:
+			return -v
+		case opcodes.D2I:
+			return int32(javaFloatToLong(v, math.MinInt32, math.MaxInt32))
+		case opcodes.D2L:
+			return javaFloatToLong(v, math.MinInt64, math.MaxInt64)
+		case opcodes.D2F:
+			return float32(v)
+		}
+	}
+	return nil
+}
+
+// javaFloatToLong converts the given floating point value to an integer with the Java semantics: NaN is
+// converted to 0, and the values out of [min, max] are clamped.
+func javaFloatToLong(value float64, min, max int64) int64 {
+	switch {
+	case math.IsNaN(value):
+		return 0
+	case value <= float64(min):
+		return min
+	case value >= float64(max):
+		return max
+	}
+	return int64(value)
+}
+
+func (ConstantInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *ConstantValue) (*ConstantValue, error) {
+	opcode := insn.GetOpcode()
+	if value1.constant && value2.constant {
+		if result := foldBinary(opcode, value1.value, value2.value); result != nil {
+			return NewConstantValue(result), nil
+		}
+	}
+	switch opcode {
+	case opcodes.LALOAD, opcodes.DALOAD, opcodes.LADD, opcodes.DADD, opcodes.LSUB, opcodes.DSUB, opcodes.LMUL,
+		opcodes.DMUL, opcodes.LDIV, opcodes.DDIV, opcodes.LREM, opcodes.DREM, opcodes.LSHL, opcodes.LSHR,
+		opcodes.LUSHR, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		return unknownLongConstantValue, nil
+	}
+	return unknownConstantValue, nil
+}
+
+// foldBinary returns the result of the given binary instruction on the given constants, or nil. Integer
+// divisions by zero, which throw an exception, are not folded.
+func foldBinary(opcode int, value1, value2 interface{}) interface{} {
+	switch v := value1.(type) {
+	case int32:
+		w, ok := value2.(int32)
+		if !ok {
+			return nil
+		}
+		switch opcode {
+		case opcodes.IADD:
+			return v + w
+		case opcodes.ISUB:
+			return v - w
+		case opcodes.IMUL:
+			return v * w
+		case opcodes.IDIV:
+			if w != 0 {
+				return v / w
+			}
+		case opcodes.IREM:
+			if w != 0 {
+				return v % w
+			}
+		case opcodes.ISHL:
+			return v << uint(w&0x1F)
+		case opcodes.ISHR:
+			return v >> uint(w&0x1F)
+		case opcodes.IUSHR:
+			return int32(uint32(v) >> uint(w&0x1F))
+		case opcodes.IAND:
+			return v & w
+		case opcodes.IOR:
+			return v | w
+		case opcodes.IXOR:
+			return v ^ w
+		}
+	case int64:
+		if shift, ok := value2.(int32); ok {
+			switch opcode {
+			case opcodes.LSHL:
+				return v << uint(shift&0x3F)
+			case opcodes.LSHR:
+				return v >> uint(shift&0x3F)
+			case opcodes.LUSHR:
+				return int64(uint64(v) >> uint(shift&0x3F))
+			}
+			return nil
+		}
+		w, ok := value2.(int64)
+		if !ok {
+			return nil
+		}
+		switch opcode {
+		case opcodes.LADD:
+			return v + w
+		case opcodes.LSUB:
+			return v - w
+		case opcodes.LMUL:
+			return v * w
+		case opcodes.LDIV:
+			if w != 0 {
+				return v / w
+			}
+		case opcodes.LREM:
+			if w != 0 {
+				return v % w
+			}
+		case opcodes.LAND:
+			return v & w
+		case opcodes.LOR:
+			return v | w
+		case opcodes.LXOR:
+			return v ^ w
+		case opcodes.LCMP:
+			return compare(v < w, v > w)
+		}
+	case float32:
+		w, ok := value2.(float32)
+		if !ok {
+			return nil
+		}
+		switch opcode {
+		case opcodes.FADD:
+			return v + w
+		case opcodes.FSUB:
+			return v - w
+		case opcodes.FMUL:
+			return v * w
+		case opcodes.FDIV:
+			return v / w
+		case opcodes.FREM:
+			return float32(math.Mod(float64(v), float64(w)))
+		case opcodes.FCMPL, opcodes.FCMPG:
+			return compareFloats(float64(v), float64(w), opcode == opcodes.FCMPG)
+		}
+	case float64:
+		w, ok := value2.(float64)
+		if !ok {
+			return nil
+		}
+		switch opcode {
+		case opcodes.DADD:
+			return v + w
+		case opcodes.DSUB:
+			return v - w
+		case opcodes.DMUL:
+			return v * w
+		case opcodes.DDIV:
+			return v / w
+		case opcodes.DREM:
+			return math.Mod(v, w)
+		case opcodes.DCMPL, opcodes.DCMPG:
+			return compareFloats(v, w, opcode == opcodes.DCMPG)
+		}
+	}
+	return nil
+}
+
+func compare(less, greater bool) int32 {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// compareFloats returns the result of FCMPL / DCMPL (or FCMPG / DCMPG if nanGreater is true).
+func compareFloats(v, w float64, nanGreater bool) int32 {
+	if math.IsNaN(v) || math.IsNaN(w) {
+		if nanGreater {
+			return 1
+		}
+		return -1
+	}
+	return compare(v < w, v > w)
+}
+
+func (ConstantInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *ConstantValue) (*ConstantValue, error) {
+	return nil, nil
+}
+
+func (ConstantInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*ConstantValue) (*ConstantValue, error) {
+	switch insn := insn.(type) {
+	case *tree.MethodInsnNode:
+		return unknownConstantValueOf(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.InvokeDynamicInsnNode:
+		return unknownConstantValueOf(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	}
+	return nonNullConstantValue, nil
+}
+
+func (ConstantInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *ConstantValue) error {
+	return nil
+}
+
+func (ConstantInterpreter) Merge(value1, value2 *ConstantValue) *ConstantValue {
+	if value1 == value2 || (value1 != nil && value2 != nil && value1.equals(value2)) {
+		return value1
+	}
+	if value1 == nil || value2 == nil || value1.size != value2.size || value1 == uninitializedConstantValue ||
+		value2 == uninitializedConstantValue {
+		return uninitializedConstantValue
+	}
+	if value1.nonNull && value2.nonNull {
+		if value1.size == 1 {
+			if value1 == nonNullConstantValue {
+				return value1
+			}
+			return nonNullConstantValue
+		}
+	}
+	if value1.size == 2 {
+		if value1 == unknownLongConstantValue {
+			return value1
+		}
+		return unknownLongConstantValue
+	}
+	if value1 == unknownConstantValue {
+		return value1
+	}
+	return unknownConstantValue
+}
+
+// ConstantBranch a conditional jump or switch instruction whose outcome is always the same.
+type ConstantBranch struct {
+	// Insn the index of the instruction in the method.
+	Insn int
+	// Target the only possible successor of the instruction, or nil if it is the next instruction.
+	Target *tree.LabelNode
+}
+
+// FindConstantBranches returns the conditional jump and switch instructions of the given method whose operands
+// are constant, i.e. the always true or always false conditions. The unreachable instructions are ignored.
+func FindConstantBranches(owner string, method *tree.MethodNode) ([]ConstantBranch, error) {
+	frames, err := NewAnalyzer[*ConstantValue](NewConstantInterpreter()).Analyze(owner, method)
+	if err != nil {
+		return nil, err
+	}
+	var branches []ConstantBranch
+	for i, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		stackSize := frame.GetStackSize()
+		switch insn := method.Instructions[i].(type) {
+		case *tree.JumpInsnNode:
+			var taken, known bool
+			switch {
+			case insn.Opcode >= opcodes.IFEQ && insn.Opcode <= opcodes.IFLE:
+				if value, ok := frame.GetStack(stackSize - 1).value.(int32); ok {
+					taken, known = compareToZero(insn.Opcode-opcodes.IFEQ, value), true
+				}
+			case insn.Opcode >= opcodes.IF_ICMPEQ && insn.Opcode <= opcodes.IF_ICMPLE:
+				value1, ok1 := frame.GetStack(stackSize - 2).value.(int32)
+				value2, ok2 := frame.GetStack(stackSize - 1).value.(int32)
+				if ok1 && ok2 {
+					taken, known = compareToZero(insn.Opcode-opcodes.IF_ICMPEQ, int32(compare(value1 < value2, value1 > value2))), true
+				}
+			case insn.Opcode == opcodes.IF_ACMPEQ || insn.Opcode == opcodes.IF_ACMPNE:
+				if frame.GetStack(stackSize-2).IsNull() && frame.GetStack(stackSize-1).IsNull() {
+					taken, known = insn.Opcode == opcodes.IF_ACMPEQ, true
+				}
+			case insn.Opcode == opcodes.IFNULL || insn.Opcode == opcodes.IFNONNULL:
+				value := frame.GetStack(stackSize - 1)
+				if value.IsNull() || value.IsNonNull() {
+					taken, known = value.IsNull() == (insn.Opcode == opcodes.IFNULL), true
+				}
+			}
+			if known {
+				branch := ConstantBranch{Insn: i}
+				if taken {
+					branch.Target = insn.Label
+				}
+				branches = append(branches, branch)
+			}
+		case *tree.TableSwitchInsnNode:
+			if key, ok := frame.GetStack(stackSize - 1).value.(int32); ok {
+				target := insn.Dflt
+				if int(key) >= insn.Min && int(key) <= insn.Max {
+					target = insn.Labels[int(key)-insn.Min]
+				}
+				branches = append(branches, ConstantBranch{i, target})
+			}
+		case *tree.LookupSwitchInsnNode:
+			if key, ok := frame.GetStack(stackSize - 1).value.(int32); ok {
+				target := insn.Dflt
+				for j, k := range insn.Keys {
+					if k == int(key) {
+						target = insn.Labels[j]
+					}
+				}
+				branches = append(branches, ConstantBranch{i, target})
+			}
+		}
+	}
+	return branches, nil
+}
+
+// compareToZero returns the result of the IFEQ, IFNE, IFLT, IFGE, IFGT or IFLE condition (given by its offset
+// from IFEQ) on the given value.
+func compareToZero(condition int, value int32) bool {
+	switch condition {
+	case 0:
+		return value == 0
+	case 1:
+		return value != 0
+	case 2:
+		return value < 0
+	case 3:
+		return value >= 0
+	case 4:
+		return value > 0
+	default:
+		return value <= 0
+	}
+}
@@ -0,0 +1,77 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestFindConstantBranches(t *testing.T) {
+	// static int m() { int i = 300; i += 2; if (i * 2 > 600) return 1; return 0; }
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "()I", "", nil)
+	label := &asm.Label{}
+	method.VisitCode()
+	method.VisitIntInsn(opcodes.SIPUSH, 300)
+	method.VisitVarInsn(opcodes.ISTORE, 0)
+	method.VisitIincInsn(0, 2)
+	method.VisitVarInsn(opcodes.ILOAD, 0)
+	method.VisitInsn(opcodes.ICONST_2)
+	method.VisitInsn(opcodes.IMUL)
+	method.VisitIntInsn(opcodes.SIPUSH, 600)
+	method.VisitJumpInsn(opcodes.IF_ICMPLE, label)
+	method.VisitInsn(opcodes.ICONST_1)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitLabel(label)
+	method.VisitInsn(opcodes.ICONST_0)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitMaxs(2, 1)
+	method.VisitEnd()
+
+	branches, err := analysis.FindConstantBranches("A", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 1 || branches[0].Insn != 7 || branches[0].Target != nil {
+		t.Errorf("unexpected constant branches %v", branches)
+	}
+}
+
+func TestConstantEvaluator(t *testing.T) {
+	// class Flags { static final int A = 1; static final int B = 4; }
+	flags := tree.NewClassNode()
+	flags.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC, "Flags", "", "java/lang/Object", nil)
+	flags.VisitField(opcodes.ACC_STATIC|opcodes.ACC_FINAL, "A", "I", "", 1)
+	flags.VisitField(opcodes.ACC_STATIC|opcodes.ACC_FINAL, "B", "I", "", 4)
+	// class C { static final long MASK = (Flags.A | Flags.B) * 3L; static final boolean ON = true; }
+	class := tree.NewClassNode()
+	class.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC, "C", "", "java/lang/Object", nil)
+	class.VisitField(opcodes.ACC_STATIC|opcodes.ACC_FINAL, "MASK", "J", "", nil)
+	class.VisitField(opcodes.ACC_STATIC|opcodes.ACC_FINAL, "ON", "Z", "", nil)
+	method := class.VisitMethod(opcodes.ACC_STATIC, "<clinit>", "()V", "", nil)
+	method.VisitCode()
+	method.VisitFieldInsn(opcodes.GETSTATIC, "Flags", "A", "I")
+	method.VisitFieldInsn(opcodes.GETSTATIC, "Flags", "B", "I")
+	method.VisitInsn(opcodes.IOR)
+	method.VisitInsn(opcodes.I2L)
+	method.VisitLdcInsn(int64(3))
+	method.VisitInsn(opcodes.LMUL)
+	method.VisitFieldInsn(opcodes.PUTSTATIC, "C", "MASK", "J")
+	method.VisitInsn(opcodes.ICONST_1)
+	method.VisitFieldInsn(opcodes.PUTSTATIC, "C", "ON", "Z")
+	method.VisitInsn(opcodes.RETURN)
+	method.VisitMaxs(4, 0)
+	method.VisitEnd()
+
+	evaluator := analysis.NewConstantEvaluator()
+	evaluator.AddClass(flags)
+	evaluator.AddClass(class)
+	if value, ok := evaluator.GetFieldValue("C", "MASK"); !ok || value != int64(15) {
+		t.Errorf("expected MASK = 15, got %v", value)
+	}
+	if value, ok := evaluator.GetFieldValue("C", "ON"); !ok || value != true {
+		t.Errorf("expected ON = true, got %v", value)
+	}
+}
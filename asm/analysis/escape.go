@@ -0,0 +1,296 @@
+package analysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Escape states of an allocation, from the most to the least precise.
+const (
+	// NO_ESCAPE the allocated object is only used by the analyzed method.
+	NO_ESCAPE = iota
+	// ARG_ESCAPE the allocated object is passed as argument to another method, but is not globally reachable.
+	ARG_ESCAPE
+	// GLOBAL_ESCAPE the allocated object is returned, thrown, or stored in a field or array.
+	GLOBAL_ESCAPE
+)
+
+var escapeNames = []string{"NoEscape", "ArgEscape", "GlobalEscape"}
+
+// Allocation an object allocation (NEW instruction) found by the {@link EscapeAnalyzer}.
+type Allocation struct {
+	// Insn the index of the NEW instruction in the method.
+	Insn int
+	// Type the internal name of the allocated class.
+	Type string
+	// Escape {@link NO_ESCAPE}, {@link ARG_ESCAPE} or {@link GLOBAL_ESCAPE}.
+	Escape int
+	// EscapeInsn the index of the first instruction which makes the object escape, or -1 for {@link NO_ESCAPE}.
+	EscapeInsn int
+}
+
+func (a Allocation) String() string {
+	s := "#" + strconv.Itoa(a.Insn) + " new " + a.Type + ": " + escapeNames[a.Escape]
+	if a.EscapeInsn >= 0 {
+		s += " at #" + strconv.Itoa(a.EscapeInsn)
+	}
+	return s
+}
+
+// EscapeReport the result of an {@link EscapeAnalyzer}.
+type EscapeReport struct {
+	// Method the analyzed method.
+	Method *tree.MethodNode
+	// Allocations the reachable allocations of the method, in instruction order.
+	Allocations []Allocation
+}
+
+// String returns a human readable report of the allocations of the method, one per line.
+func (e *EscapeReport) String() string {
+	var sb strings.Builder
+	for _, allocation := range e.Allocations {
+		sb.WriteString(e.Method.Name)
+		sb.WriteString(e.Method.Descriptor)
+		sb.WriteString(" ")
+		sb.WriteString(allocation.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// EscapeAnalyzer an intraprocedural escape analysis. It tracks the objects allocated with NEW through the stack
+// and the local variables, and classifies each allocation with the most pessimistic way it is used. Objects
+// stored in a field or an array element are considered as globally escaping, even if the container does not
+// escape.
+type EscapeAnalyzer struct {
+	// ConstructorEscapes whether the receiver of a constructor call escapes to the constructor (ARG_ESCAPE).
+	// If false, constructors are assumed not to leak their receiver.
+	ConstructorEscapes bool
+}
+
+// NewEscapeAnalyzer constructs a new {@link EscapeAnalyzer}.
+func NewEscapeAnalyzer() *EscapeAnalyzer {
+	return &EscapeAnalyzer{}
+}
+
+// Analyze analyzes the given method of the given class (given by its internal name).
+func (e *EscapeAnalyzer) Analyze(owner string, method *tree.MethodNode) (*EscapeReport, error) {
+	interpreter := &escapeInterpreter{indexes: make(map[tree.AbstractInsnNode]int)}
+	for i, insn := range method.Instructions {
+		interpreter.indexes[insn] = i
+	}
+	frames, err := NewAnalyzer[*EscapeValue](interpreter).Analyze(owner, method)
+	if err != nil {
+		return nil, err
+	}
+	report := &EscapeReport{Method: method}
+	for i, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		insn := method.Instructions[i]
+		if insn.GetOpcode() == opcodes.NEW {
+			report.Allocations = append(report.Allocations, Allocation{i, insn.(*tree.TypeInsnNode).Type, NO_ESCAPE, -1})
+		}
+	}
+	allocations := make(map[int]*Allocation, len(report.Allocations))
+	for i := range report.Allocations {
+		allocations[report.Allocations[i].Insn] = &report.Allocations[i]
+	}
+	escape := func(value *EscapeValue, level, insn int) {
+		for _, site := range value.sites {
+			if allocation := allocations[site]; allocation != nil && allocation.Escape < level {
+				allocation.Escape, allocation.EscapeInsn = level, insn
+			}
+		}
+	}
+	for i, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		top := frame.GetStackSize() - 1
+		switch insn := method.Instructions[i].(type) {
+		case *tree.MethodInsnNode:
+			numArguments := len(asm.GetMethodType(insn.Descriptor).GetArgumentTypes())
+			for j := top - numArguments + 1; j <= top; j++ {
+				escape(frame.GetStack(j), ARG_ESCAPE, i)
+			}
+			if insn.Opcode != opcodes.INVOKESTATIC && (insn.Name != "<init>" || e.ConstructorEscapes) {
+				escape(frame.GetStack(top-numArguments), ARG_ESCAPE, i)
+			}
+		case *tree.InvokeDynamicInsnNode:
+			numArguments := len(asm.GetMethodType(insn.Descriptor).GetArgumentTypes())
+			for j := top - numArguments + 1; j <= top; j++ {
+				escape(frame.GetStack(j), ARG_ESCAPE, i)
+			}
+		default:
+			switch insn.GetOpcode() {
+			case opcodes.ARETURN, opcodes.ATHROW, opcodes.PUTSTATIC, opcodes.PUTFIELD, opcodes.AASTORE:
+				escape(frame.GetStack(top), GLOBAL_ESCAPE, i)
+			}
+		}
+	}
+	return report, nil
+}
+
+// EscapeValue a {@link Value} used by the {@link EscapeAnalyzer}, which tracks the allocation sites (NEW
+// instruction indices) a reference may come from.
+type EscapeValue struct {
+	size  int
+	sites []int
+}
+
+var (
+	uninitializedEscapeValue = &EscapeValue{size: 1}
+	otherEscapeValue         = &EscapeValue{size: 1}
+	otherLongEscapeValue     = &EscapeValue{size: 2}
+)
+
+func (e *EscapeValue) GetSize() int {
+	return e.size
+}
+
+// GetSites returns the indices of the NEW instructions this value may come from, in increasing order.
+func (e *EscapeValue) GetSites() []int {
+	return e.sites
+}
+
+func (e *EscapeValue) String() string {
+	if e == uninitializedEscapeValue {
+		return "."
+	}
+	sites := make([]string, len(e.sites))
+	for i, site := range e.sites {
+		sites[i] = "#" + strconv.Itoa(site)
+	}
+	return "{" + strings.Join(sites, ",") + "}"
+}
+
+// escapeInterpreter the {@link Interpreter} of the {@link EscapeAnalyzer}.
+type escapeInterpreter struct {
+	indexes map[tree.AbstractInsnNode]int
+}
+
+func otherEscapeValueOf(t *asm.Type) *EscapeValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.LONG, typed.DOUBLE:
+		return otherLongEscapeValue
+	default:
+		return otherEscapeValue
+	}
+}
+
+func (e *escapeInterpreter) NewValue(t *asm.Type) *EscapeValue {
+	if t == nil {
+		return uninitializedEscapeValue
+	}
+	return otherEscapeValueOf(t)
+}
+
+func (e *escapeInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *EscapeValue {
+	return otherEscapeValue
+}
+
+func (e *escapeInterpreter) NewOperation(insn tree.AbstractInsnNode) (*EscapeValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.NEW:
+		return &EscapeValue{size: 1, sites: []int{e.indexes[insn]}}, nil
+	case opcodes.LCONST_0, opcodes.LCONST_1, opcodes.DCONST_0, opcodes.DCONST_1:
+		return otherLongEscapeValue, nil
+	case opcodes.LDC:
+		switch insn.(*tree.LdcInsnNode).Value.(type) {
+		case int64, float64:
+			return otherLongEscapeValue, nil
+		}
+	case opcodes.GETSTATIC:
+		return otherEscapeValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	}
+	return otherEscapeValue, nil
+}
+
+func (e *escapeInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *EscapeValue) (*EscapeValue, error) {
+	return value, nil
+}
+
+func (e *escapeInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *EscapeValue) (*EscapeValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LNEG, opcodes.DNEG, opcodes.I2L, opcodes.I2D, opcodes.L2D, opcodes.F2L, opcodes.F2D, opcodes.D2L:
+		return otherLongEscapeValue, nil
+	case opcodes.GETFIELD:
+		return otherEscapeValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcodes.CHECKCAST:
+		return value, nil
+	}
+	return otherEscapeValue, nil
+}
+
+func (e *escapeInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *EscapeValue) (*EscapeValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LALOAD, opcodes.DALOAD, opcodes.LADD, opcodes.DADD, opcodes.LSUB, opcodes.DSUB, opcodes.LMUL,
+		opcodes.DMUL, opcodes.LDIV, opcodes.DDIV, opcodes.LREM, opcodes.DREM, opcodes.LSHL, opcodes.LSHR,
+		opcodes.LUSHR, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		return otherLongEscapeValue, nil
+	}
+	return otherEscapeValue, nil
+}
+
+func (e *escapeInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *EscapeValue) (*EscapeValue, error) {
+	return nil, nil
+}
+
+func (e *escapeInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*EscapeValue) (*EscapeValue, error) {
+	switch insn := insn.(type) {
+	case *tree.MethodInsnNode:
+		return otherEscapeValueOf(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.InvokeDynamicInsnNode:
+		return otherEscapeValueOf(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	}
+	return otherEscapeValue, nil
+}
+
+func (e *escapeInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *EscapeValue) error {
+	return nil
+}
+
+func (e *escapeInterpreter) Merge(value1, value2 *EscapeValue) *EscapeValue {
+	if value1 == value2 {
+		return value1
+	}
+	if value1 == nil || value2 == nil || value1.size != value2.size || value1 == uninitializedEscapeValue ||
+		value2 == uninitializedEscapeValue {
+		return uninitializedEscapeValue
+	}
+	sites := mergeInts(value1.sites, value2.sites)
+	if len(sites) == len(value1.sites) {
+		return value1
+	}
+	return &EscapeValue{size: 1, sites: sites}
+}
+
+// mergeInts returns the sorted union of the given sorted sets.
+func mergeInts(set1, set2 []int) []int {
+	if len(set2) == 0 {
+		return set1
+	}
+	if len(set1) == 0 {
+		return set2
+	}
+	result := append(append([]int(nil), set1...), set2...)
+	sort.Ints(result)
+	n := 1
+	for i := 1; i < len(result); i++ {
+		if result[i] != result[n-1] {
+			result[n] = result[i]
+			n++
+		}
+	}
+	return result[:n]
+}
@@ -0,0 +1,67 @@
+package analysis_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// escapingMethod returns the method "Object m() { A a = new A(); a.x; this.f = new B(); D d = new D();
+// use(d); return new E(); }".
+func escapingMethod() *tree.MethodNode {
+	method := tree.NewMethodNode(opcodes.ACC_PUBLIC, "m", "()Ljava/lang/Object;", "", nil)
+	newObject := func(name string) {
+		method.VisitTypeInsn(opcodes.NEW, name)
+		method.VisitInsn(opcodes.DUP)
+		method.VisitMethodInsnB(opcodes.INVOKESPECIAL, name, "<init>", "()V", false)
+	}
+	method.VisitCode()
+	newObject("A")
+	method.VisitVarInsn(opcodes.ASTORE, 1)
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitFieldInsn(opcodes.GETFIELD, "A", "x", "I")
+	method.VisitInsn(opcodes.POP)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	newObject("B")
+	method.VisitFieldInsn(opcodes.PUTFIELD, "C", "f", "LB;")
+	newObject("D")
+	method.VisitVarInsn(opcodes.ASTORE, 2)
+	method.VisitVarInsn(opcodes.ALOAD, 2)
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "C", "use", "(Ljava/lang/Object;)V", false)
+	newObject("E")
+	method.VisitInsn(opcodes.ARETURN)
+	method.VisitMaxs(3, 3)
+	method.VisitEnd()
+	return method
+}
+
+func TestEscapeAnalyzer(t *testing.T) {
+	escapeAnalyzer := analysis.NewEscapeAnalyzer()
+	report, err := escapeAnalyzer.Analyze("C", escapingMethod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A stays local, B escapes through a field store, D through a call argument and E through the return.
+	assertLines(t, strings.Split(strings.TrimSuffix(report.String(), "\n"), "\n"), []string{
+		`m()Ljava/lang/Object; #0 new A: NoEscape`,
+		`m()Ljava/lang/Object; #8 new B: GlobalEscape at #11`,
+		`m()Ljava/lang/Object; #12 new D: ArgEscape at #17`,
+		`m()Ljava/lang/Object; #18 new E: GlobalEscape at #21`,
+	})
+
+	// The constructor calls make all the objects escape to their constructor, at least.
+	escapeAnalyzer.ConstructorEscapes = true
+	report, err = escapeAnalyzer.Analyze("C", escapingMethod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertLines(t, strings.Split(strings.TrimSuffix(report.String(), "\n"), "\n"), []string{
+		`m()Ljava/lang/Object; #0 new A: ArgEscape at #2`,
+		`m()Ljava/lang/Object; #8 new B: GlobalEscape at #11`,
+		`m()Ljava/lang/Object; #12 new D: ArgEscape at #14`,
+		`m()Ljava/lang/Object; #18 new E: GlobalEscape at #21`,
+	})
+}
@@ -0,0 +1,249 @@
+package analysis
+
+import (
+	"reflect"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// Kinds of compiler generated duplicated regions.
+const (
+	// FINALLY_REGION a finally block, inlined by the compiler at each exit of its try and catch blocks.
+	FINALLY_REGION = iota
+	// TRY_WITH_RESOURCES_REGION the closing of a try-with-resources resource, inlined by the compiler at each
+	// exit of the try block.
+	TRY_WITH_RESOURCES_REGION
+)
+
+// InsnRange a range of instructions of a method, given by their indices: Start is inclusive, End is exclusive.
+type InsnRange struct {
+	Start int
+	End   int
+}
+
+// Contains returns whether the given instruction index is in this range.
+func (i InsnRange) Contains(insn int) bool {
+	return insn >= i.Start && insn < i.End
+}
+
+// DuplicatedRegion a group of compiler generated copies of the same source code.
+type DuplicatedRegion struct {
+	// Kind {@link FINALLY_REGION} or {@link TRY_WITH_RESOURCES_REGION}.
+	Kind int
+	// Handler the exception handler, from its label to its final ATHROW, which contains the copy executed when
+	// an exception is thrown.
+	Handler InsnRange
+	// Duplicates the copies executed on the normal exits (and, for finally blocks, the catch block exits).
+	Duplicates []InsnRange
+	// ResourceVar the local variable of the closed resource for {@link TRY_WITH_RESOURCES_REGION}, or -1.
+	ResourceVar int
+}
+
+// FindDuplicatedRegions recognizes the compiler generated duplicates of finally blocks and of try-with-resources
+// resource closing in the given method, so that a single source construct can be reported once. A finally
+// block is recognized from its catch-any handler (ASTORE t, body, ALOAD t, ATHROW), whose body is then searched
+// in the rest of the method. A try-with-resources is recognized from its handler calling addSuppressed, whose
+// close() call (or javac 9 and 10 $closeResource call) is then searched in the rest of the method.
+func FindDuplicatedRegions(method *tree.MethodNode) []DuplicatedRegion {
+	insns := method.Instructions
+	indexes := make(map[*tree.LabelNode]int)
+	for i, insn := range insns {
+		if label, ok := insn.(*tree.LabelNode); ok {
+			indexes[label] = i
+		}
+	}
+	var regions []DuplicatedRegion
+	var handlers []InsnRange
+	visited := make(map[*tree.LabelNode]bool)
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		if visited[tryCatchBlock.Handler] {
+			continue
+		}
+		visited[tryCatchBlock.Handler] = true
+		handler, ok := indexes[tryCatchBlock.Handler]
+		if !ok || inRanges(handlers, handler) {
+			continue
+		}
+		region, ok := parseHandler(insns, handler, tryCatchBlock.Type)
+		if !ok {
+			continue
+		}
+		handlers = append(handlers, region.Handler)
+		regions = append(regions, region)
+	}
+	for i := range regions {
+		region := &regions[i]
+		if region.Kind == FINALLY_REGION {
+			region.Duplicates = findCopies(insns, handlers, region.body(insns))
+		} else {
+			region.Duplicates = findCloseCalls(insns, handlers, region.ResourceVar)
+		}
+	}
+	return regions
+}
+
+// parseHandler parses the exception handler starting at the given instruction.
+func parseHandler(insns []tree.AbstractInsnNode, handler int, exceptionType string) (DuplicatedRegion, bool) {
+	region := DuplicatedRegion{Kind: FINALLY_REGION, Handler: InsnRange{handler, handler}, ResourceVar: -1}
+	store := nextRealInsn(insns, handler)
+	if store < 0 || insns[store].GetOpcode() != opcodes.ASTORE {
+		return region, false
+	}
+	exception := insns[store].(*tree.VarInsnNode).Var
+	suppressed := false
+	for i := nextRealInsn(insns, store+1); i >= 0; i = nextRealInsn(insns, i+1) {
+		switch insn := insns[i].(type) {
+		case *tree.MethodInsnNode:
+			if insn.Owner == "java/lang/Throwable" && insn.Name == "addSuppressed" {
+				suppressed = true
+			} else if region.ResourceVar < 0 {
+				region.ResourceVar = closedResource(insns, i)
+			}
+		case *tree.InsnNode:
+			if insn.Opcode != opcodes.ATHROW {
+				continue
+			}
+			load := previousRealInsn(insns, i-1)
+			if load < 0 || insns[load].GetOpcode() != opcodes.ALOAD || insns[load].(*tree.VarInsnNode).Var != exception {
+				return region, false
+			}
+			region.Handler.End = i + 1
+			if suppressed && region.ResourceVar >= 0 {
+				region.Kind = TRY_WITH_RESOURCES_REGION
+				return region, true
+			}
+			region.ResourceVar = -1
+			// A catch-any handler with an empty body is a synchronized block handler, not a finally block.
+			return region, exceptionType == "" && nextRealInsn(insns, store+1) != load
+		}
+	}
+	return region, false
+}
+
+// body returns the real instructions of the finally block of a {@link FINALLY_REGION} handler.
+func (d *DuplicatedRegion) body(insns []tree.AbstractInsnNode) []tree.AbstractInsnNode {
+	var body []tree.AbstractInsnNode
+	store := nextRealInsn(insns, d.Handler.Start)
+	for i := nextRealInsn(insns, store+1); i >= 0 && i < d.Handler.End; i = nextRealInsn(insns, i+1) {
+		body = append(body, insns[i])
+	}
+	// Remove the final ALOAD t, ATHROW.
+	return body[:len(body)-2]
+}
+
+// findCopies returns the ranges of the copies of the given real instructions, outside of the given handlers.
+func findCopies(insns []tree.AbstractInsnNode, handlers []InsnRange, body []tree.AbstractInsnNode) []InsnRange {
+	var copies []InsnRange
+	for start := nextRealInsn(insns, 0); start >= 0; start = nextRealInsn(insns, start+1) {
+		if inRanges(handlers, start) || inRanges(copies, start) {
+			continue
+		}
+		i, matched := start, 0
+		for matched < len(body) && i >= 0 && !inRanges(handlers, i) && sameInsn(insns[i], body[matched]) {
+			matched++
+			if matched < len(body) {
+				i = nextRealInsn(insns, i+1)
+			}
+		}
+		if matched == len(body) {
+			copies = append(copies, InsnRange{start, i + 1})
+		}
+	}
+	return copies
+}
+
+// findCloseCalls returns the ranges of the close() calls on the given resource, outside of the given handlers.
+// The ranges include the null check of the resource, if any.
+func findCloseCalls(insns []tree.AbstractInsnNode, handlers []InsnRange, resource int) []InsnRange {
+	var calls []InsnRange
+	for i, insn := range insns {
+		if insn.GetType() != tree.METHOD_INSN || inRanges(handlers, i) || closedResource(insns, i) != resource {
+			continue
+		}
+		start := previousRealInsn(insns, i-1)
+		if insns[i].(*tree.MethodInsnNode).Name != "close" {
+			start = previousRealInsn(insns, start-1)
+		}
+		if jump := previousRealInsn(insns, start-1); jump >= 0 && insns[jump].GetOpcode() == opcodes.IFNULL {
+			if load := previousRealInsn(insns, jump-1); load >= 0 && isLoad(insns[load], resource) {
+				start = load
+			}
+		}
+		calls = append(calls, InsnRange{start, i + 1})
+	}
+	return calls
+}
+
+// closedResource returns the local variable of the resource closed by the given method call instruction, i.e.
+// ALOAD r, INVOKEVIRTUAL or INVOKEINTERFACE close()V, or X, ALOAD r, INVOKESTATIC $closeResource, or -1.
+func closedResource(insns []tree.AbstractInsnNode, insn int) int {
+	call := insns[insn].(*tree.MethodInsnNode)
+	load := previousRealInsn(insns, insn-1)
+	if load < 0 || insns[load].GetOpcode() != opcodes.ALOAD {
+		return -1
+	}
+	if (call.Name == "close" && call.Descriptor == "()V" && call.Opcode != opcodes.INVOKESTATIC) ||
+		(call.Name == "$closeResource" && call.Opcode == opcodes.INVOKESTATIC) {
+		return insns[load].(*tree.VarInsnNode).Var
+	}
+	return -1
+}
+
+func isLoad(insn tree.AbstractInsnNode, local int) bool {
+	load, ok := insn.(*tree.VarInsnNode)
+	return ok && load.Opcode == opcodes.ALOAD && load.Var == local
+}
+
+func inRanges(ranges []InsnRange, insn int) bool {
+	for _, r := range ranges {
+		if r.Contains(insn) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRealInsn returns whether the given instruction is neither a label, a line number nor a frame.
+func isRealInsn(insn tree.AbstractInsnNode) bool {
+	t := insn.GetType()
+	return t != tree.LABEL && t != tree.LINE && t != tree.FRAME
+}
+
+// nextRealInsn returns the index of the first real instruction at or after the given index, or -1.
+func nextRealInsn(insns []tree.AbstractInsnNode, from int) int {
+	for i := from; i >= 0 && i < len(insns); i++ {
+		if isRealInsn(insns[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// previousRealInsn returns the index of the last real instruction at or before the given index, or -1.
+func previousRealInsn(insns []tree.AbstractInsnNode, from int) int {
+	for i := from; i >= 0 && i < len(insns); i-- {
+		if isRealInsn(insns[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameInsn returns whether the given instructions are copies of each other. Jump and switch targets are not
+// compared, since each copy jumps to its own labels.
+func sameInsn(insn1, insn2 tree.AbstractInsnNode) bool {
+	if insn1.GetOpcode() != insn2.GetOpcode() || insn1.GetType() != insn2.GetType() {
+		return false
+	}
+	switch insn1 := insn1.(type) {
+	case *tree.JumpInsnNode:
+		return true
+	case *tree.TableSwitchInsnNode:
+		insn2 := insn2.(*tree.TableSwitchInsnNode)
+		return insn1.Min == insn2.Min && insn1.Max == insn2.Max
+	case *tree.LookupSwitchInsnNode:
+		return reflect.DeepEqual(insn1.Keys, insn2.(*tree.LookupSwitchInsnNode).Keys)
+	}
+	return reflect.DeepEqual(insn1, insn2)
+}
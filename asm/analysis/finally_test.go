@@ -0,0 +1,124 @@
+package analysis_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// regionLines returns the duplicated regions of the given method, one per line.
+func regionLines(method *tree.MethodNode) []string {
+	var lines []string
+	for _, region := range analysis.FindDuplicatedRegions(method) {
+		lines = append(lines, fmt.Sprintf("%+v", region))
+	}
+	return lines
+}
+
+func TestFindDuplicatedRegionsFinally(t *testing.T) {
+	// void m() { try { a(); } catch (Exception e) { b(); } finally { if (x) f(); } g(); }
+	method := tree.NewMethodNode(opcodes.ACC_PUBLIC, "m", "()V", "", nil)
+	start, end, catchHandler, finallyHandler, exit := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	catchEnd := &asm.Label{}
+	finallyBody := func(line int) {
+		skip := &asm.Label{}
+		lineLabel := &asm.Label{}
+		method.VisitLabel(lineLabel)
+		method.VisitLineNumber(line, lineLabel)
+		method.VisitVarInsn(opcodes.ALOAD, 0)
+		method.VisitFieldInsn(opcodes.GETFIELD, "C", "x", "Z")
+		method.VisitJumpInsn(opcodes.IFEQ, skip)
+		method.VisitVarInsn(opcodes.ALOAD, 0)
+		method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "C", "f", "()V", false)
+		method.VisitLabel(skip)
+	}
+	method.VisitCode()
+	method.VisitTryCatchBlock(start, end, catchHandler, "java/lang/Exception")
+	method.VisitTryCatchBlock(start, end, finallyHandler, "")
+	method.VisitTryCatchBlock(catchHandler, catchEnd, finallyHandler, "")
+	method.VisitLabel(start)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "C", "a", "()V", false)
+	method.VisitLabel(end)
+	// The copies have their own line numbers and jump targets.
+	finallyBody(10)
+	method.VisitJumpInsn(opcodes.GOTO, exit)
+	method.VisitLabel(catchHandler)
+	method.VisitVarInsn(opcodes.ASTORE, 1)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "C", "b", "()V", false)
+	method.VisitLabel(catchEnd)
+	finallyBody(20)
+	method.VisitJumpInsn(opcodes.GOTO, exit)
+	method.VisitLabel(finallyHandler)
+	method.VisitVarInsn(opcodes.ASTORE, 2)
+	finallyBody(30)
+	method.VisitVarInsn(opcodes.ALOAD, 2)
+	method.VisitInsn(opcodes.ATHROW)
+	method.VisitLabel(exit)
+	// A near duplicate of the finally block, calling another method, is not a copy.
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitFieldInsn(opcodes.GETFIELD, "C", "x", "Z")
+	method.VisitJumpInsn(opcodes.IFEQ, exit)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "C", "g", "()V", false)
+	method.VisitInsn(opcodes.RETURN)
+	method.VisitMaxs(1, 3)
+	method.VisitEnd()
+
+	assertLines(t, regionLines(method), []string{
+		`{Kind:0 Handler:{Start:27 End:39} Duplicates:[{Start:6 End:11} {Start:20 End:25}] ResourceVar:-1}`,
+	})
+}
+
+func TestFindDuplicatedRegionsTryWithResources(t *testing.T) {
+	// void m() { try (R r = new R()) { r.use(); } }, as compiled by javac 11.
+	method := tree.NewMethodNode(opcodes.ACC_PUBLIC, "m", "()V", "", nil)
+	start, end, handler, closeStart, closeEnd, closeHandler, rethrow, exit :=
+		&asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	method.VisitCode()
+	method.VisitTryCatchBlock(start, end, handler, "java/lang/Throwable")
+	method.VisitTryCatchBlock(closeStart, closeEnd, closeHandler, "java/lang/Throwable")
+	method.VisitTypeInsn(opcodes.NEW, "R")
+	method.VisitInsn(opcodes.DUP)
+	method.VisitMethodInsnB(opcodes.INVOKESPECIAL, "R", "<init>", "()V", false)
+	method.VisitVarInsn(opcodes.ASTORE, 1)
+	method.VisitLabel(start)
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "R", "use", "()V", false)
+	method.VisitLabel(end)
+	// The normal exit closes the resource after a null check.
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitJumpInsn(opcodes.IFNULL, exit)
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "R", "close", "()V", false)
+	method.VisitJumpInsn(opcodes.GOTO, exit)
+	method.VisitLabel(handler)
+	method.VisitVarInsn(opcodes.ASTORE, 2)
+	method.VisitLabel(closeStart)
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "R", "close", "()V", false)
+	method.VisitLabel(closeEnd)
+	method.VisitJumpInsn(opcodes.GOTO, rethrow)
+	method.VisitLabel(closeHandler)
+	method.VisitVarInsn(opcodes.ASTORE, 3)
+	method.VisitVarInsn(opcodes.ALOAD, 2)
+	method.VisitVarInsn(opcodes.ALOAD, 3)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/Throwable", "addSuppressed", "(Ljava/lang/Throwable;)V", false)
+	method.VisitLabel(rethrow)
+	method.VisitVarInsn(opcodes.ALOAD, 2)
+	method.VisitInsn(opcodes.ATHROW)
+	method.VisitLabel(exit)
+	method.VisitInsn(opcodes.RETURN)
+	method.VisitMaxs(2, 4)
+	method.VisitEnd()
+
+	assertLines(t, regionLines(method), []string{
+		`{Kind:1 Handler:{Start:13 End:28} Duplicates:[{Start:8 End:12}] ResourceVar:1}`,
+	})
+
+}
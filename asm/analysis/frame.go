@@ -0,0 +1,444 @@
+package analysis
+
+import (
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// Frame a symbolic execution stack frame. A stack frame contains a set of local variable slots, and an operand
+// stack. Warning: long and double values are represented with two slots in local variables, and with one slot
+// in the operand stack.
+type Frame[V Value] struct {
+	// values the local variables and the operand stack of this frame, in this order.
+	values      []V
+	numLocals   int
+	numStack    int
+	returnValue V
+}
+
+// NewFrame constructs a new frame with the given size.
+func NewFrame[V Value](numLocals, numStack int) *Frame[V] {
+	return &Frame[V]{
+		values:    make([]V, numLocals+numStack),
+		numLocals: numLocals,
+	}
+}
+
+// CopyFrame constructs a copy of the given frame.
+func CopyFrame[V Value](frame *Frame[V]) *Frame[V] {
+	f := NewFrame[V](frame.numLocals, len(frame.values)-frame.numLocals)
+	f.Init(frame)
+	return f
+}
+
+// Init copies the state of the given frame into this frame, and returns this frame.
+func (f *Frame[V]) Init(frame *Frame[V]) *Frame[V] {
+	f.returnValue = frame.returnValue
+	copy(f.values, frame.values)
+	f.numStack = frame.numStack
+	return f
+}
+
+// SetReturn sets the expected return type of the analyzed method, or the zero value for void methods.
+func (f *Frame[V]) SetReturn(value V) {
+	f.returnValue = value
+}
+
+// GetLocals returns the maximum number of local variables of this frame.
+func (f *Frame[V]) GetLocals() int {
+	return f.numLocals
+}
+
+// GetMaxStackSize returns the maximum stack size of this frame.
+func (f *Frame[V]) GetMaxStackSize() int {
+	return len(f.values) - f.numLocals
+}
+
+// GetLocal returns the value of the given local variable. Panics if the index is out of range.
+func (f *Frame[V]) GetLocal(index int) V {
+	if index >= f.numLocals {
+		panic("Trying to access an inexistant local variable " + strconv.Itoa(index))
+	}
+	return f.values[index]
+}
+
+// SetLocal sets the value of the given local variable. Panics if the index is out of range.
+func (f *Frame[V]) SetLocal(index int, value V) {
+	if index >= f.numLocals {
+		panic("Trying to access an inexistant local variable " + strconv.Itoa(index))
+	}
+	f.values[index] = value
+}
+
+// GetStackSize returns the number of values in the operand stack of this frame. Long and double values are
+// treated as single values.
+func (f *Frame[V]) GetStackSize() int {
+	return f.numStack
+}
+
+// GetStack returns the value of the given operand stack slot, 0 being the bottom of the stack.
+func (f *Frame[V]) GetStack(index int) V {
+	return f.values[f.numLocals+index]
+}
+
+// ClearStack clears the operand stack of this frame.
+func (f *Frame[V]) ClearStack() {
+	var zero V
+	for i := f.numLocals; i < f.numLocals+f.numStack; i++ {
+		f.values[i] = zero
+	}
+	f.numStack = 0
+}
+
+// Pop pops a value from the operand stack of this frame.
+func (f *Frame[V]) Pop() (V, error) {
+	if f.numStack == 0 {
+		var zero V
+		return zero, NewAnalyzerError(nil, "Cannot pop operand off an empty stack.")
+	}
+	f.numStack--
+	return f.values[f.numLocals+f.numStack], nil
+}
+
+// Push pushes a value into the operand stack of this frame.
+func (f *Frame[V]) Push(value V) error {
+	if f.numLocals+f.numStack >= len(f.values) {
+		return NewAnalyzerError(nil, "Insufficient maximum stack size.")
+	}
+	f.values[f.numLocals+f.numStack] = value
+	f.numStack++
+	return nil
+}
+
+func (f *Frame[V]) getLocal(index int) (V, error) {
+	if index >= f.numLocals {
+		var zero V
+		return zero, NewAnalyzerError(nil, "Trying to access an inexistant local variable "+strconv.Itoa(index))
+	}
+	return f.values[index], nil
+}
+
+func (f *Frame[V]) setLocal(index int, value V) error {
+	if index >= f.numLocals {
+		return NewAnalyzerError(nil, "Trying to access an inexistant local variable "+strconv.Itoa(index))
+	}
+	f.values[index] = value
+	return nil
+}
+
+// popN pops n values from the operand stack, and returns them in their push order.
+func (f *Frame[V]) popN(n int) ([]V, error) {
+	values := make([]V, n)
+	for i := n - 1; i >= 0; i-- {
+		value, err := f.Pop()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Execute simulates the execution of the given instruction on this execution stack frame.
+func (f *Frame[V]) Execute(insn tree.AbstractInsnNode, interpreter Interpreter[V]) error {
+	var zero V
+	opcode := insn.GetOpcode()
+	switch {
+	case opcode == opcodes.NOP || opcode == opcodes.GOTO || opcode == opcodes.RET:
+		return nil
+	case opcode >= opcodes.ACONST_NULL && opcode <= opcodes.LDC, opcode == opcodes.JSR, opcode == opcodes.GETSTATIC,
+		opcode == opcodes.NEW:
+		return f.pushResult(interpreter.NewOperation(insn))
+	case opcode >= opcodes.ILOAD && opcode <= opcodes.ALOAD:
+		value, err := f.getLocal(insn.(*tree.VarInsnNode).Var)
+		if err != nil {
+			return err
+		}
+		return f.pushResult(interpreter.CopyOperation(insn, value))
+	case opcode >= opcodes.ISTORE && opcode <= opcodes.ASTORE:
+		value, err := f.Pop()
+		if err != nil {
+			return err
+		}
+		if value, err = interpreter.CopyOperation(insn, value); err != nil {
+			return err
+		}
+		index := insn.(*tree.VarInsnNode).Var
+		if err = f.setLocal(index, value); err != nil {
+			return err
+		}
+		if value.GetSize() == 2 {
+			if err = f.setLocal(index+1, interpreter.NewValue(nil)); err != nil {
+				return err
+			}
+		}
+		if index > 0 {
+			local := f.values[index-1]
+			if local != zero && local.GetSize() == 2 {
+				f.values[index-1] = interpreter.NewValue(nil)
+			}
+		}
+		return nil
+	case opcode >= opcodes.IASTORE && opcode <= opcodes.SASTORE:
+		values, err := f.popN(3)
+		if err != nil {
+			return err
+		}
+		_, err = interpreter.TernaryOperation(insn, values[0], values[1], values[2])
+		return err
+	case opcode == opcodes.POP:
+		value, err := f.Pop()
+		if err != nil {
+			return err
+		}
+		if value.GetSize() == 2 {
+			return NewAnalyzerError(insn, "Illegal use of POP")
+		}
+		return nil
+	case opcode == opcodes.POP2:
+		value, err := f.Pop()
+		if err != nil {
+			return err
+		}
+		if value.GetSize() == 1 {
+			if value, err = f.Pop(); err != nil {
+				return err
+			}
+			if value.GetSize() != 1 {
+				return NewAnalyzerError(insn, "Illegal use of POP2")
+			}
+		}
+		return nil
+	case opcode >= opcodes.DUP && opcode <= opcodes.SWAP:
+		return f.executeDupOrSwap(insn, interpreter)
+	case opcode >= opcodes.IALOAD && opcode <= opcodes.SALOAD, opcode >= opcodes.IADD && opcode <= opcodes.DREM,
+		opcode >= opcodes.ISHL && opcode <= opcodes.LXOR, opcode >= opcodes.LCMP && opcode <= opcodes.DCMPG:
+		values, err := f.popN(2)
+		if err != nil {
+			return err
+		}
+		return f.pushResult(interpreter.BinaryOperation(insn, values[0], values[1]))
+	case opcode >= opcodes.INEG && opcode <= opcodes.DNEG, opcode >= opcodes.I2L && opcode <= opcodes.I2S,
+		opcode == opcodes.GETFIELD, opcode == opcodes.NEWARRAY, opcode == opcodes.ANEWARRAY,
+		opcode == opcodes.ARRAYLENGTH, opcode == opcodes.CHECKCAST, opcode == opcodes.INSTANCEOF:
+		value, err := f.Pop()
+		if err != nil {
+			return err
+		}
+		return f.pushResult(interpreter.UnaryOperation(insn, value))
+	case opcode == opcodes.IINC:
+		index := insn.(*tree.IincInsnNode).Var
+		value, err := f.getLocal(index)
+		if err != nil {
+			return err
+		}
+		if value, err = interpreter.UnaryOperation(insn, value); err != nil {
+			return err
+		}
+		return f.setLocal(index, value)
+	case opcode >= opcodes.IFEQ && opcode <= opcodes.IFLE, opcode == opcodes.TABLESWITCH, opcode == opcodes.LOOKUPSWITCH,
+		opcode == opcodes.PUTSTATIC, opcode == opcodes.ATHROW, opcode == opcodes.MONITORENTER,
+		opcode == opcodes.MONITOREXIT, opcode == opcodes.IFNULL, opcode == opcodes.IFNONNULL:
+		value, err := f.Pop()
+		if err != nil {
+			return err
+		}
+		_, err = interpreter.UnaryOperation(insn, value)
+		return err
+	case opcode >= opcodes.IF_ICMPEQ && opcode <= opcodes.IF_ACMPNE, opcode == opcodes.PUTFIELD:
+		values, err := f.popN(2)
+		if err != nil {
+			return err
+		}
+		_, err = interpreter.BinaryOperation(insn, values[0], values[1])
+		return err
+	case opcode >= opcodes.IRETURN && opcode <= opcodes.ARETURN:
+		value, err := f.Pop()
+		if err != nil {
+			return err
+		}
+		if _, err = interpreter.UnaryOperation(insn, value); err != nil {
+			return err
+		}
+		return interpreter.ReturnOperation(insn, value, f.returnValue)
+	case opcode == opcodes.RETURN:
+		if f.returnValue != zero {
+			return NewAnalyzerError(insn, "Incompatible return type")
+		}
+		return nil
+	case opcode >= opcodes.INVOKEVIRTUAL && opcode <= opcodes.INVOKEDYNAMIC:
+		var descriptor string
+		argumentCount := 0
+		if methodInsn, ok := insn.(*tree.MethodInsnNode); ok {
+			descriptor = methodInsn.Descriptor
+			if opcode != opcodes.INVOKESTATIC {
+				argumentCount = 1
+			}
+		} else {
+			descriptor = insn.(*tree.InvokeDynamicInsnNode).Descriptor
+		}
+		methodType := asm.GetMethodType(descriptor)
+		argumentCount += len(methodType.GetArgumentTypes())
+		values, err := f.popN(argumentCount)
+		if err != nil {
+			return err
+		}
+		if methodType.GetReturnType().GetSize() == 0 {
+			_, err = interpreter.NaryOperation(insn, values)
+			return err
+		}
+		return f.pushResult(interpreter.NaryOperation(insn, values))
+	case opcode == opcodes.MULTIANEWARRAY:
+		values, err := f.popN(insn.(*tree.MultiANewArrayInsnNode).NumDimensions)
+		if err != nil {
+			return err
+		}
+		return f.pushResult(interpreter.NaryOperation(insn, values))
+	default:
+		return NewAnalyzerError(insn, "Illegal opcode "+strconv.Itoa(opcode))
+	}
+}
+
+func (f *Frame[V]) pushResult(value V, err error) error {
+	if err != nil {
+		return err
+	}
+	return f.Push(value)
+}
+
+// executeDupOrSwap simulates the DUP, DUP_X1, DUP_X2, DUP2, DUP2_X1, DUP2_X2 and SWAP instructions.
+func (f *Frame[V]) executeDupOrSwap(insn tree.AbstractInsnNode, interpreter Interpreter[V]) error {
+	opcode := insn.GetOpcode()
+	// Pops the values which may be involved, from the top of the stack, and checks their sizes against the
+	// forms of the instruction (see the JVMS).
+	var popped []V
+	pop := func(sizes ...int) bool {
+		popped = popped[:0]
+		total := 0
+		for _, size := range sizes {
+			total += size
+		}
+		actual := 0
+		for actual < total {
+			value, err := f.Pop()
+			if err != nil {
+				return false
+			}
+			popped = append(popped, value)
+			actual += value.GetSize()
+		}
+		if actual != total || len(popped) != len(sizes) {
+			return false
+		}
+		for i, size := range sizes {
+			if popped[i].GetSize() != size {
+				return false
+			}
+		}
+		return true
+	}
+	snapshot := f.numStack
+	saved := make([]V, f.numStack)
+	copy(saved, f.values[f.numLocals:f.numLocals+f.numStack])
+	restore := func() {
+		copy(f.values[f.numLocals:], saved)
+		f.numStack = snapshot
+	}
+	// forms lists the possible forms of the instruction, as the sizes of the values from the top of the stack,
+	// and the indices (in popped) of the values pushed back, from bottom to top. The values pushed at the
+	// positions listed in copies are pushed with a CopyOperation.
+	type form struct {
+		sizes  []int
+		pushed []int
+		copies []int
+	}
+	var forms []form
+	switch opcode {
+	case opcodes.DUP:
+		forms = []form{{[]int{1}, []int{0, 0}, []int{1}}}
+	case opcodes.DUP_X1:
+		forms = []form{{[]int{1, 1}, []int{0, 1, 0}, []int{0}}}
+	case opcodes.DUP_X2:
+		forms = []form{{[]int{1, 1, 1}, []int{0, 2, 1, 0}, []int{0}}, {[]int{1, 2}, []int{0, 1, 0}, []int{0}}}
+	case opcodes.DUP2:
+		forms = []form{{[]int{1, 1}, []int{1, 0, 1, 0}, []int{2, 3}}, {[]int{2}, []int{0, 0}, []int{1}}}
+	case opcodes.DUP2_X1:
+		forms = []form{{[]int{1, 1, 1}, []int{1, 0, 2, 1, 0}, []int{0, 1}}, {[]int{2, 1}, []int{0, 1, 0}, []int{0}}}
+	case opcodes.DUP2_X2:
+		forms = []form{
+			{[]int{1, 1, 1, 1}, []int{1, 0, 3, 2, 1, 0}, []int{0, 1}},
+			{[]int{1, 1, 2}, []int{1, 0, 2, 1, 0}, []int{0, 1}},
+			{[]int{2, 1, 1}, []int{0, 2, 1, 0}, []int{0}},
+			{[]int{2, 2}, []int{0, 1, 0}, []int{0}},
+		}
+	case opcodes.SWAP:
+		forms = []form{{[]int{1, 1}, []int{0, 1}, []int{0, 1}}}
+	}
+	for _, form := range forms {
+		if !pop(form.sizes...) {
+			restore()
+			continue
+		}
+		values := append([]V(nil), popped...)
+		for i, index := range form.pushed {
+			value := values[index]
+			for _, position := range form.copies {
+				if position == i {
+					var err error
+					if value, err = interpreter.CopyOperation(insn, value); err != nil {
+						return err
+					}
+				}
+			}
+			if err := f.Push(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return NewAnalyzerError(insn, "Illegal use of "+dupOrSwapNames[opcode-opcodes.DUP])
+}
+
+var dupOrSwapNames = []string{"DUP", "DUP_X1", "DUP_X2", "DUP2", "DUP2_X1", "DUP2_X2", "SWAP"}
+
+// Merge merges the given frame into this frame, and returns whether this frame has been changed.
+func (f *Frame[V]) Merge(frame *Frame[V], interpreter Interpreter[V]) (bool, error) {
+	if f.numStack != frame.numStack {
+		return false, NewAnalyzerError(nil, "Incompatible stack heights")
+	}
+	changed := false
+	for i := 0; i < f.numLocals+f.numStack; i++ {
+		value := interpreter.Merge(f.values[i], frame.values[i])
+		if value != f.values[i] {
+			f.values[i] = value
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+func (f *Frame[V]) String() string {
+	result := ""
+	for i := 0; i < f.numLocals; i++ {
+		result += valueString(f.values[i])
+	}
+	result += " "
+	for i := 0; i < f.numStack; i++ {
+		result += valueString(f.GetStack(i))
+	}
+	return result
+}
+
+func valueString[V Value](value V) string {
+	if stringer, ok := any(value).(interface{ String() string }); ok {
+		return stringer.String()
+	}
+	var zero V
+	if value == zero {
+		return "."
+	}
+	return "?"
+}
@@ -0,0 +1,303 @@
+package analysis
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// FrameSlot a local variable or stack slot of a {@link FrameExplanation}.
+type FrameSlot struct {
+	// Local whether this slot is a local variable (or else a stack slot).
+	Local bool
+	// Index the index of the local variable, or the index of the slot in the stack (0 for the bottom).
+	Index int
+	// Declared the type declared by the stack map frame: a class internal name or descriptor, "int", "float",
+	// "long", "double", "null", "uninitializedThis", "uninitialized", or "top".
+	Declared string
+	// Derived the verification type computed by the dataflow analysis: "int", "float", "long", "double",
+	// "reference", or "top".
+	Derived string
+	// Producer the index of the instruction which produced the derived value, or -1 for the parameters, the
+	// caught exceptions, and the values merged from several instructions.
+	Producer int
+	// Mismatch whether the derived value is not assignable to the declared type.
+	Mismatch bool
+}
+
+// FrameExplanation the comparison of the stack map frame declared before an instruction with the frame derived
+// by a dataflow analysis, computed by {@link ExplainFrame}.
+type FrameExplanation struct {
+	// Insn the index of the frame node in the method.
+	Insn int
+	// Line the source line number of the frame, or -1 if unknown.
+	Line  int
+	Slots []FrameSlot
+}
+
+// HasMismatch returns whether a slot of the declared frame does not match the derived frame.
+func (f *FrameExplanation) HasMismatch() bool {
+	for _, slot := range f.Slots {
+		if slot.Mismatch {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns one line per slot, with the declared and derived types and the producer of the derived value,
+// and "<< mismatch" at the end of the mismatching slots.
+func (f *FrameExplanation) String() string {
+	var builder strings.Builder
+	builder.WriteString("frame at instruction " + strconv.Itoa(f.Insn))
+	if f.Line >= 0 {
+		builder.WriteString(" (line " + strconv.Itoa(f.Line) + ")")
+	}
+	for _, slot := range f.Slots {
+		kind := "stack"
+		if slot.Local {
+			kind = "local"
+		}
+		builder.WriteString("\n  " + kind + " " + strconv.Itoa(slot.Index) + ": declared " + slot.Declared +
+			", derived " + slot.Derived)
+		if slot.Producer >= 0 {
+			builder.WriteString(" (from instruction " + strconv.Itoa(slot.Producer) + ")")
+		}
+		if slot.Mismatch {
+			builder.WriteString(" << mismatch")
+		}
+	}
+	return builder.String()
+}
+
+// ExplainFrame compares the stack map frame declared at the given instruction of the given method (the frame
+// node preceding it, separated only by labels and line numbers) with the frame derived by the dataflow analysis
+// of {@link VerifyMethod}, to explain why a method fails the verification. The method must have been read with
+// the {@link ClassReader#EXPAND_FRAMS} option, so that its frames are not compressed. The derived frame is the
+// one computed before the analysis fails, if it does. Returns an error if there is no frame at this instruction,
+// or if it is unreachable or not reached by the analysis before it fails.
+func ExplainFrame(owner string, method *tree.MethodNode, insn int) (*FrameExplanation, error) {
+	if insn < 0 || insn >= len(method.Instructions) {
+		return nil, errors.New("Illegal Argument - invalid instruction index " + strconv.Itoa(insn))
+	}
+	frameInsn := -1
+	for i := insn; i >= 0 && frameInsn < 0; i-- {
+		switch node := method.Instructions[i].(type) {
+		case *tree.FrameNode:
+			if node.Type != opcodes.F_NEW && node.Type != opcodes.F_FULL {
+				return nil, errors.New("Illegal Argument - compressed frame at instruction " + strconv.Itoa(i) +
+					", the class must be read with EXPAND_FRAMS")
+			}
+			frameInsn = i
+		case *tree.LabelNode, *tree.LineNumberNode:
+		default:
+			if i != insn {
+				i = -1
+			}
+		}
+	}
+	if frameInsn < 0 {
+		return nil, errors.New("Illegal Argument - no stack map frame at instruction " + strconv.Itoa(insn))
+	}
+	interpreter := provenanceInterpreter{indexes: make(map[tree.AbstractInsnNode]int)}
+	for i, insn := range method.Instructions {
+		interpreter.indexes[insn] = i
+	}
+	// The frames computed before a verification error are kept, since they explain it.
+	analyzer := NewAnalyzer[*provenanceValue](interpreter)
+	_, err := analyzer.Analyze(owner, method)
+	var frame *Frame[*provenanceValue]
+	if frames := analyzer.GetFrames(); frameInsn < len(frames) {
+		frame = frames[frameInsn]
+	}
+	if frame == nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("Illegal Argument - unreachable frame at instruction " + strconv.Itoa(frameInsn))
+	}
+	explanation := &FrameExplanation{Insn: frameInsn, Line: -1}
+	for i := frameInsn; i >= 0; i-- {
+		if lineNumber, ok := method.Instructions[i].(*tree.LineNumberNode); ok {
+			explanation.Line = lineNumber.Line
+			break
+		}
+	}
+	declared := method.Instructions[frameInsn].(*tree.FrameNode)
+	local := 0
+	for _, t := range declared.Local {
+		explanation.addSlot(true, local, t, frame.GetLocal(local), local < frame.GetLocals())
+		local++
+		if t == opcodes.LONG || t == opcodes.DOUBLE {
+			local++
+		}
+	}
+	for ; local < frame.GetLocals(); local++ {
+		explanation.addSlot(true, local, opcodes.TOP, frame.GetLocal(local), true)
+	}
+	stack := 0
+	for i, t := range declared.Stack {
+		var value *provenanceValue
+		if stack < frame.GetStackSize() {
+			value = frame.GetStack(stack)
+		}
+		explanation.addSlot(false, i, t, value, stack < frame.GetStackSize())
+		stack++
+	}
+	if stack != frame.GetStackSize() {
+		explanation.Slots = append(explanation.Slots, FrameSlot{Index: len(declared.Stack), Declared: "(end of stack)",
+			Derived: "stack size " + strconv.Itoa(frame.GetStackSize()), Producer: -1, Mismatch: true})
+	}
+	return explanation, nil
+}
+
+// addSlot adds the slot of the given declared type, whose derived value is the given one if it exists.
+func (f *FrameExplanation) addSlot(local bool, index int, t interface{}, value *provenanceValue, exists bool) {
+	if !exists {
+		f.Slots = append(f.Slots, FrameSlot{Local: local, Index: index, Declared: frameTypeName(t), Derived: "(none)",
+			Producer: -1, Mismatch: true})
+		return
+	}
+	f.Slots = append(f.Slots, newFrameSlot(local, index, t, value))
+}
+
+func newFrameSlot(local bool, index int, t interface{}, value *provenanceValue) FrameSlot {
+	slot := FrameSlot{Local: local, Index: index, Declared: frameTypeName(t), Derived: "top", Producer: -1}
+	if value != nil && value.value != uninitializedVerifierValue {
+		slot.Derived = value.value.name
+		slot.Producer = value.producer
+	}
+	slot.Mismatch = t != opcodes.TOP && slot.Derived != frameTypeCategory(t)
+	return slot
+}
+
+// frameTypeName returns the name of the given stack map frame type (see {@link MethodVisitor#VisitFrame}).
+func frameTypeName(t interface{}) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case *asm.Label:
+		return "uninitialized"
+	case int:
+		switch t {
+		case opcodes.INTEGER:
+			return "int"
+		case opcodes.FLOAT:
+			return "float"
+		case opcodes.LONG:
+			return "long"
+		case opcodes.DOUBLE:
+			return "double"
+		case opcodes.NULL:
+			return "null"
+		case opcodes.UNINITIALIZED_THIS:
+			return "uninitializedThis"
+		}
+	}
+	return "top"
+}
+
+// frameTypeCategory returns the verification type of {@link VerifyMethod} corresponding to the given stack map
+// frame type.
+func frameTypeCategory(t interface{}) string {
+	switch name := frameTypeName(t); name {
+	case "int", "float", "long", "double", "top":
+		return name
+	}
+	return "reference"
+}
+
+// provenanceValue a value of {@link VerifyMethod}, with the index of the instruction which produced it.
+type provenanceValue struct {
+	value    *verifierValue
+	producer int
+}
+
+func (p *provenanceValue) GetSize() int {
+	return p.value.GetSize()
+}
+
+// provenanceInterpreter an {@link Interpreter} computing the values of {@link VerifyMethod} and their producer.
+type provenanceInterpreter struct {
+	verifierInterpreter
+	indexes map[tree.AbstractInsnNode]int
+}
+
+// newValue returns the given value, produced by the given instruction, or nil for the void value.
+func (p provenanceInterpreter) newValue(value *verifierValue, insn tree.AbstractInsnNode, err error) (*provenanceValue, error) {
+	if value == nil || err != nil {
+		return nil, err
+	}
+	producer, ok := p.indexes[insn]
+	if !ok {
+		producer = -1
+	}
+	return &provenanceValue{value, producer}, nil
+}
+
+func (p provenanceInterpreter) NewValue(t *asm.Type) *provenanceValue {
+	value, _ := p.newValue(p.verifierInterpreter.NewValue(t), nil, nil)
+	return value
+}
+
+func (p provenanceInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *provenanceValue {
+	value, _ := p.newValue(p.verifierInterpreter.NewExceptionValue(tryCatchBlock, exceptionType), nil, nil)
+	return value
+}
+
+func (p provenanceInterpreter) NewOperation(insn tree.AbstractInsnNode) (*provenanceValue, error) {
+	value, err := p.verifierInterpreter.NewOperation(insn)
+	return p.newValue(value, insn, err)
+}
+
+func (p provenanceInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *provenanceValue) (*provenanceValue, error) {
+	// The copied value keeps its producer.
+	_, err := p.verifierInterpreter.CopyOperation(insn, value.value)
+	return value, err
+}
+
+func (p provenanceInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *provenanceValue) (*provenanceValue, error) {
+	result, err := p.verifierInterpreter.UnaryOperation(insn, value.value)
+	return p.newValue(result, insn, err)
+}
+
+func (p provenanceInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *provenanceValue) (*provenanceValue, error) {
+	result, err := p.verifierInterpreter.BinaryOperation(insn, value1.value, value2.value)
+	return p.newValue(result, insn, err)
+}
+
+func (p provenanceInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *provenanceValue) (*provenanceValue, error) {
+	result, err := p.verifierInterpreter.TernaryOperation(insn, value1.value, value2.value, value3.value)
+	return p.newValue(result, insn, err)
+}
+
+func (p provenanceInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*provenanceValue) (*provenanceValue, error) {
+	verifierValues := make([]*verifierValue, len(values))
+	for i, value := range values {
+		verifierValues[i] = value.value
+	}
+	result, err := p.verifierInterpreter.NaryOperation(insn, verifierValues)
+	return p.newValue(result, insn, err)
+}
+
+func (p provenanceInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *provenanceValue) error {
+	if expected == nil {
+		return p.verifierInterpreter.ReturnOperation(insn, value.value, nil)
+	}
+	return p.verifierInterpreter.ReturnOperation(insn, value.value, expected.value)
+}
+
+func (p provenanceInterpreter) Merge(value1, value2 *provenanceValue) *provenanceValue {
+	if value1.value == value2.value && value1.producer == value2.producer {
+		return value1
+	}
+	value := p.verifierInterpreter.Merge(value1.value, value2.value)
+	if value == value1.value && value1.producer == -1 {
+		return value1
+	}
+	return &provenanceValue{value, -1}
+}
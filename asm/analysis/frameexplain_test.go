@@ -0,0 +1,65 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// appendChopClass returns a class with a static method m(J)V, whose StackMapTable has an append frame adding a
+// String and an int local, and a chop frame removing them:
+//
+//	static void m(long l) { String s = null; for (int i = 0; i == 0; i++) {} }
+func appendChopClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	writer := classFile.AddMethod(opcodes.ACC_STATIC, "m", "(J)V", "", nil)
+	loop, exit := &asm.Label{}, &asm.Label{}
+	writer.VisitCode()
+	writer.VisitInsn(opcodes.ACONST_NULL)
+	writer.VisitVarInsn(opcodes.ASTORE, 2)
+	writer.VisitInsn(opcodes.ICONST_0)
+	writer.VisitVarInsn(opcodes.ISTORE, 3)
+	writer.VisitLabel(loop)
+	writer.VisitFrame(opcodes.F_NEW, 3, []interface{}{opcodes.LONG, "java/lang/String", opcodes.INTEGER}, 0, nil)
+	writer.VisitVarInsn(opcodes.ILOAD, 3)
+	writer.VisitJumpInsn(opcodes.IFNE, exit)
+	writer.VisitIincInsn(3, 1)
+	writer.VisitJumpInsn(opcodes.GOTO, loop)
+	writer.VisitLabel(exit)
+	writer.VisitFrame(opcodes.F_NEW, 1, []interface{}{opcodes.LONG}, 0, nil)
+	writer.VisitInsn(opcodes.RETURN)
+	writer.VisitMaxs(1, 4)
+	writer.VisitEnd()
+	return classFile.Bytes()
+}
+
+func TestExplainFrameAppendChop(t *testing.T) {
+	classNode, err := tree.ReadClassNode(appendChopClass(), asm.EXPAND_FRAMS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := classNode.Methods[0]
+	labelNames := tree.GetLabelNames(method)
+	var frames []string
+	for i, insn := range method.Instructions {
+		if _, ok := insn.(*tree.FrameNode); !ok {
+			continue
+		}
+		frames = append(frames, tree.InsnToString(insn, labelNames))
+		explanation, err := analysis.ExplainFrame("A", method, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if explanation.HasMismatch() {
+			t.Errorf("unexpected mismatch:\n%s", explanation)
+		}
+	}
+	expected := []string{"FRAME NEW [J java/lang/String I] []", "FRAME NEW [J] []"}
+	if len(frames) != len(expected) || frames[0] != expected[0] || frames[1] != expected[1] {
+		t.Errorf("unexpected expanded frames %q", frames)
+	}
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// Interpreter a semantic bytecode interpreter. More precisely, this interpreter only manages the computation
+// of values from other values: it does not manage the transfer of values to or from the stack, and to or from
+// the local variables. This separation allows a generic bytecode {@link Analyzer} to work with various
+// semantic interpreters, without needing to duplicate the code to simulate the transfer of values.
+type Interpreter[V Value] interface {
+	// NewValue creates a new value that represents the given type. Called for method parameters, local
+	// variables and return types. The type is nil for uninitialized local variables and for the second word
+	// of long and double values. Must return the zero value for the void type.
+	NewValue(t *asm.Type) V
+
+	// NewExceptionValue creates the value of the exception caught by the given try catch block, at the start of
+	// its handler. The exception type is java/lang/Throwable for "finally" blocks.
+	NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) V
+
+	// NewOperation interprets a bytecode instruction without arguments: ACONST_NULL, ICONST_M1 to ICONST_5,
+	// LCONST_0, LCONST_1, FCONST_0 to FCONST_2, DCONST_0, DCONST_1, BIPUSH, SIPUSH, LDC, JSR, GETSTATIC and NEW.
+	NewOperation(insn tree.AbstractInsnNode) (V, error)
+
+	// CopyOperation interprets a bytecode instruction that moves a value on the stack or to or from local
+	// variables: ILOAD to ALOAD, ISTORE to ASTORE, DUP, DUP_X1, DUP_X2, DUP2, DUP2_X1, DUP2_X2 and SWAP.
+	CopyOperation(insn tree.AbstractInsnNode, value V) (V, error)
+
+	// UnaryOperation interprets a bytecode instruction with a single argument: INEG to DNEG, IINC, I2L to I2S,
+	// IFEQ to IFLE, TABLESWITCH, LOOKUPSWITCH, IRETURN to ARETURN, PUTSTATIC, GETFIELD, NEWARRAY, ANEWARRAY,
+	// ARRAYLENGTH, ATHROW, CHECKCAST, INSTANCEOF, MONITORENTER, MONITOREXIT, IFNULL and IFNONNULL.
+	UnaryOperation(insn tree.AbstractInsnNode, value V) (V, error)
+
+	// BinaryOperation interprets a bytecode instruction with two arguments: IALOAD to SALOAD, IADD to LXOR,
+	// LCMP to DCMPG, IF_ICMPEQ to IF_ACMPNE and PUTFIELD.
+	BinaryOperation(insn tree.AbstractInsnNode, value1, value2 V) (V, error)
+
+	// TernaryOperation interprets a bytecode instruction with three arguments: IASTORE to SASTORE.
+	TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 V) (V, error)
+
+	// NaryOperation interprets a bytecode instruction with a variable number of arguments: INVOKEVIRTUAL,
+	// INVOKESPECIAL, INVOKESTATIC, INVOKEINTERFACE, MULTIANEWARRAY and INVOKEDYNAMIC.
+	NaryOperation(insn tree.AbstractInsnNode, values []V) (V, error)
+
+	// ReturnOperation interprets a bytecode return instruction: IRETURN to ARETURN. The expected value is the
+	// one created with {@link NewValue} for the return type of the method.
+	ReturnOperation(insn tree.AbstractInsnNode, value, expected V) error
+
+	// Merge merges two values. The merge of two values u and v is the least value that is greater than or
+	// equal to both u and v, for the partial order of the values. Must return value1 if it is already greater
+	// than or equal to value2.
+	Merge(value1, value2 V) V
+}
+
+// ParameterInterpreter an {@link Interpreter} which creates specific values for the method parameters. If the
+// interpreter of an {@link Analyzer} implements this interface, NewParameterValue is used instead of NewValue
+// for the receiver and the parameters of the analyzed method.
+type ParameterInterpreter[V Value] interface {
+	// NewParameterValue creates the value of the given local variable, containing the receiver (local 0 of
+	// instance methods) or a parameter of the given type.
+	NewParameterValue(isInstanceMethod bool, local int, t *asm.Type) V
+}
+
+// UniverseInterpreter an {@link Interpreter} which interns the types it creates in a {@link Universe}. If the
+// interpreter of an {@link Analyzer} implements this interface, SetUniverse is called with the universe of the
+// analyzer before each analysis.
+type UniverseInterpreter interface {
+	SetUniverse(universe *Universe)
+}
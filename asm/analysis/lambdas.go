@@ -0,0 +1,184 @@
+package analysis
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// CapturedValue a value captured by a lambda, i.e. an argument of its invokedynamic instruction.
+type CapturedValue struct {
+	// Type the type of the captured value.
+	Type *asm.Type
+	// Local the local variable which is directly loaded to capture the value, or -1 if the value is computed.
+	Local int
+	// Name the name of this local variable in the LocalVariableTable, or "" if unknown ("this" for the receiver).
+	Name string
+}
+
+// LambdaCallSite a lambda or method reference creation site, i.e. an invokedynamic instruction bootstrapped by
+// java/lang/invoke/LambdaMetafactory.
+type LambdaCallSite struct {
+	// Method the method containing the call site.
+	Method *tree.MethodNode
+	// Insn the index of the invokedynamic instruction in this method.
+	Insn int
+	// Line the source line number of the call site, or -1 if unknown.
+	Line int
+	// Interface the internal name of the functional interface implemented by the lambda.
+	Interface string
+	// InterfaceMethod the name of the functional interface method.
+	InterfaceMethod string
+	// Implementation the method handle of the method implementing the lambda body.
+	Implementation *asm.Handle
+	// ImplementationMethod the implementing method if it is a synthetic lambda$ method of the analyzed class, or
+	// nil (for method references).
+	ImplementationMethod *tree.MethodNode
+	// Captured the values captured by the lambda, passed as the first arguments of the implementation method.
+	Captured []CapturedValue
+}
+
+// GetImplementationLines returns the first and last source line numbers of the synthetic lambda body, or -1, -1
+// if unknown.
+func (l *LambdaCallSite) GetImplementationLines() (int, int) {
+	first, last := -1, -1
+	if l.ImplementationMethod == nil {
+		return first, last
+	}
+	for _, insn := range l.ImplementationMethod.Instructions {
+		if line, ok := insn.(*tree.LineNumberNode); ok {
+			if first < 0 || line.Line < first {
+				first = line.Line
+			}
+			last = max(last, line.Line)
+		}
+	}
+	return first, last
+}
+
+func (l *LambdaCallSite) String() string {
+	var sb strings.Builder
+	sb.WriteString(l.Method.Name + l.Method.Descriptor + " #" + strconv.Itoa(l.Insn))
+	if l.Line >= 0 {
+		sb.WriteString(" (line " + strconv.Itoa(l.Line) + ")")
+	}
+	sb.WriteString(" " + l.Interface + "." + l.InterfaceMethod + " -> " + l.Implementation.GetOwner() + "." +
+		l.Implementation.GetName() + l.Implementation.GetDesc())
+	if first, last := l.GetImplementationLines(); first >= 0 {
+		sb.WriteString(" (lines " + strconv.Itoa(first) + "-" + strconv.Itoa(last) + ")")
+	}
+	if len(l.Captured) > 0 {
+		captured := make([]string, len(l.Captured))
+		for i, value := range l.Captured {
+			switch {
+			case value.Name != "":
+				captured[i] = value.Name
+			case value.Local >= 0:
+				captured[i] = "local" + strconv.Itoa(value.Local)
+			default:
+				captured[i] = "?"
+			}
+			captured[i] += " " + value.Type.GetDescriptor()
+		}
+		sb.WriteString(" capturing " + strings.Join(captured, ", "))
+	}
+	return sb.String()
+}
+
+// FindLambdaCallSites returns the lambda and method reference creation sites of the given class, in method
+// and instruction order, with the synthetic methods implementing the lambda bodies, so that the code of these
+// methods can be attributed to the source location of the lambda expression. The captured values are only
+// associated with local variables when they are directly loaded before the invokedynamic instruction, which is
+// what javac and ecj generate.
+func FindLambdaCallSites(class *tree.ClassNode) []LambdaCallSite {
+	var callSites []LambdaCallSite
+	for _, method := range class.Methods {
+		line := -1
+		for i, insn := range method.Instructions {
+			if lineNumber, ok := insn.(*tree.LineNumberNode); ok {
+				line = lineNumber.Line
+				continue
+			}
+			indy, ok := insn.(*tree.InvokeDynamicInsnNode)
+			if !ok || indy.BootstrapMethodHandle.GetOwner() != "java/lang/invoke/LambdaMetafactory" ||
+				len(indy.BootstrapMethodArguments) < 2 {
+				continue
+			}
+			implementation, ok := indy.BootstrapMethodArguments[1].(*asm.Handle)
+			if !ok || implementation.IsField() || implementation.Validate() != nil {
+				continue
+			}
+			callSite := LambdaCallSite{
+				Method:          method,
+				Insn:            i,
+				Line:            line,
+				Interface:       asm.GetMethodType(indy.Descriptor).GetReturnType().GetInternalName(),
+				InterfaceMethod: indy.Name,
+				Implementation:  implementation,
+				Captured:        capturedValues(method, i, asm.GetMethodType(indy.Descriptor).GetArgumentTypes()),
+			}
+			if implementation.GetOwner() == class.Name && strings.HasPrefix(implementation.GetName(), "lambda$") {
+				callSite.ImplementationMethod = class.GetMethod(implementation.GetName(), implementation.GetDesc())
+			}
+			callSites = append(callSites, callSite)
+		}
+	}
+	return callSites
+}
+
+// capturedValues returns the values of the given types captured by the given invokedynamic instruction.
+func capturedValues(method *tree.MethodNode, indy int, types []*asm.Type) []CapturedValue {
+	captured := make([]CapturedValue, len(types))
+	loads := make([]*tree.VarInsnNode, len(types))
+	isDirect := true
+	for i, j := len(types)-1, previousRealInsn(method.Instructions, indy-1); i >= 0; i-- {
+		if j < 0 {
+			isDirect = false
+			break
+		}
+		load, ok := method.Instructions[j].(*tree.VarInsnNode)
+		if !ok || load.Opcode != types[i].GetOpcode(opcodes.ILOAD) {
+			isDirect = false
+			break
+		}
+		loads[i] = load
+		j = previousRealInsn(method.Instructions, j-1)
+	}
+	for i, t := range types {
+		captured[i] = CapturedValue{Type: t, Local: -1}
+		if !isDirect {
+			continue
+		}
+		captured[i].Local = loads[i].Var
+		captured[i].Name = localVariableName(method, loads[i].Var, indy)
+	}
+	return captured
+}
+
+// localVariableName returns the name of the given local variable at the given instruction, from the
+// LocalVariableTable, or "this" for the receiver of instance methods, or "".
+func localVariableName(method *tree.MethodNode, local, insn int) string {
+	for _, localVariable := range method.LocalVariables {
+		if localVariable.Index != local {
+			continue
+		}
+		start, end := -1, -1
+		for i, node := range method.Instructions {
+			if node == localVariable.Start {
+				start = i
+			} else if node == localVariable.End {
+				end = i
+			}
+		}
+		if start <= insn && insn < end {
+			return localVariable.Name
+		}
+	}
+	if local == 0 && method.Access&opcodes.ACC_STATIC == 0 {
+		return "this"
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// metafactory the LambdaMetafactory bootstrap method of the lambdas and method references.
+var metafactory = asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/LambdaMetafactory", "metafactory",
+	"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+		"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)"+
+		"Ljava/lang/invoke/CallSite;", false)
+
+// lambdaClass returns a class p/C with the method "void run(int x)", which creates a lambda capturing this and
+// x, a lambda capturing a computed value, a method reference and a string concatenation, and with the method
+// implementing the first lambda.
+func lambdaClass() *tree.ClassNode {
+	class := tree.NewClassNode()
+	class.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "", "java/lang/Object", nil)
+
+	start, end := &asm.Label{}, &asm.Label{}
+	run := class.VisitMethod(opcodes.ACC_PUBLIC, "run", "(I)V", "", nil)
+	run.VisitCode()
+	run.VisitLabel(start)
+	run.VisitLineNumber(10, start)
+	run.VisitVarInsn(opcodes.ALOAD, 0)
+	run.VisitVarInsn(opcodes.ILOAD, 1)
+	run.VisitInvokeDynamicInsn("run", "(Lp/C;I)Ljava/lang/Runnable;", metafactory, asm.GetMethodType("()V"),
+		asm.NewHandle(opcodes.H_INVOKESPECIAL, "p/C", "lambda$run$0", "(I)V", false), asm.GetMethodType("()V"))
+	run.VisitInsn(opcodes.POP)
+	line := &asm.Label{}
+	run.VisitLabel(line)
+	run.VisitLineNumber(14, line)
+	run.VisitInsn(opcodes.ICONST_1)
+	run.VisitInvokeDynamicInsn("get", "(I)Ljava/util/function/IntSupplier;", metafactory, asm.GetMethodType("()I"),
+		asm.NewHandle(opcodes.H_INVOKESTATIC, "p/C", "lambda$run$1", "(I)I", false), asm.GetMethodType("()I"))
+	run.VisitInsn(opcodes.POP)
+	run.VisitInvokeDynamicInsn("apply", "()Ljava/util/function/Function;", metafactory,
+		asm.GetMethodType("(Ljava/lang/Object;)Ljava/lang/Object;"),
+		asm.NewHandle(opcodes.H_INVOKEVIRTUAL, "java/lang/String", "length", "()I", false),
+		asm.GetMethodType("(Ljava/lang/String;)Ljava/lang/Integer;"))
+	run.VisitInsn(opcodes.POP)
+	// Other bootstrap methods are ignored.
+	run.VisitVarInsn(opcodes.ILOAD, 1)
+	run.VisitInvokeDynamicInsn("makeConcatWithConstants", "(I)Ljava/lang/String;",
+		asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/StringConcatFactory", "makeConcatWithConstants",
+			"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+				"Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/invoke/CallSite;", false), "x=\u0001")
+	run.VisitInsn(opcodes.POP)
+	run.VisitInsn(opcodes.RETURN)
+	run.VisitLabel(end)
+	run.VisitLocalVariable("this", "Lp/C;", "", start, end, 0)
+	run.VisitLocalVariable("x", "I", "", start, end, 1)
+	run.VisitMaxs(2, 2)
+	run.VisitEnd()
+
+	lambda := class.VisitMethod(opcodes.ACC_PRIVATE|opcodes.ACC_SYNTHETIC, "lambda$run$0", "(I)V", "", nil)
+	lambda.VisitCode()
+	for _, line := range []int{12, 11} {
+		label := &asm.Label{}
+		lambda.VisitLabel(label)
+		lambda.VisitLineNumber(line, label)
+		lambda.VisitInsn(opcodes.NOP)
+	}
+	lambda.VisitInsn(opcodes.RETURN)
+	lambda.VisitMaxs(0, 2)
+	lambda.VisitEnd()
+	return class
+}
+
+func TestFindLambdaCallSites(t *testing.T) {
+	var lines []string
+	for _, callSite := range analysis.FindLambdaCallSites(lambdaClass()) {
+		lines = append(lines, callSite.String())
+	}
+	// The second lambda body is not in the class, and the method reference has no synthetic implementation.
+	assertLines(t, lines, []string{
+		`run(I)V #4 (line 10) java/lang/Runnable.run -> p/C.lambda$run$0(I)V (lines 11-12) capturing this Lp/C;, x I`,
+		`run(I)V #9 (line 14) java/util/function/IntSupplier.get -> p/C.lambda$run$1(I)I capturing ? I`,
+		`run(I)V #11 (line 14) java/util/function/Function.apply -> java/lang/String.length()I`,
+	})
+}
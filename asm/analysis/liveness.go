@@ -0,0 +1,292 @@
+package analysis
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// slotSet a set of local variable slots.
+type slotSet []uint64
+
+func newSlotSet(numSlots int) slotSet {
+	return make(slotSet, (numSlots+63)/64)
+}
+
+func (s slotSet) add(slot int) {
+	if slot/64 < len(s) {
+		s[slot/64] |= 1 << uint(slot%64)
+	}
+}
+
+func (s slotSet) remove(slot int) {
+	if slot/64 < len(s) {
+		s[slot/64] &^= 1 << uint(slot%64)
+	}
+}
+
+func (s slotSet) contains(slot int) bool {
+	return slot >= 0 && slot/64 < len(s) && s[slot/64]&(1<<uint(slot%64)) != 0
+}
+
+// union adds the given set to this set, and returns whether this set changed.
+func (s slotSet) union(set slotSet) bool {
+	changed := false
+	for i := range s {
+		if s[i]|set[i] != s[i] {
+			s[i] |= set[i]
+			changed = true
+		}
+	}
+	return changed
+}
+
+// SlotRange a range of instructions during which a local variable slot holds a live value.
+type SlotRange struct {
+	// Slot the local variable index.
+	Slot int
+	// Start the index of the instruction which stores the value (or of the first instruction, for parameters).
+	Start int
+	// End the index following the last instruction which reads the value.
+	End int
+	// Name the name of the variable in the LocalVariableTable, or "" if unknown.
+	Name string
+	// Descriptor the descriptor of the variable in the LocalVariableTable, or "" if unknown.
+	Descriptor string
+}
+
+func (s SlotRange) String() string {
+	name := s.Name
+	if name == "" {
+		name = "?"
+	}
+	return "slot " + strconv.Itoa(s.Slot) + " [" + strconv.Itoa(s.Start) + ", " + strconv.Itoa(s.End) + ") " + name +
+		" " + s.Descriptor
+}
+
+// Liveness the result of {@link AnalyzeLiveness}.
+type Liveness struct {
+	// Method the analyzed method.
+	Method *tree.MethodNode
+	// Ranges the live ranges of the local variable slots, sorted by slot and start instruction.
+	Ranges  []SlotRange
+	liveIn  []slotSet
+	liveOut []slotSet
+	defs    [][]int
+}
+
+// AnalyzeLiveness computes the liveness of the local variable slots of the given method: a slot is live before
+// an instruction if its value may be read on some path starting at this instruction, before being overwritten.
+// Long and double values use their two slots. Exception handlers are assumed to be reachable from every
+// instruction of their try block.
+func AnalyzeLiveness(method *tree.MethodNode) *Liveness {
+	insns := method.Instructions
+	n := len(insns)
+	indexes := make(map[*tree.LabelNode]int)
+	for i, insn := range insns {
+		if label, ok := insn.(*tree.LabelNode); ok {
+			indexes[label] = i
+		}
+	}
+	numSlots := method.MaxLocals
+	uses := make([][]int, n)
+	defs := make([][]int, n)
+	for i, insn := range insns {
+		switch insn := insn.(type) {
+		case *tree.VarInsnNode:
+			slots := []int{insn.Var}
+			if insn.Opcode == opcodes.LLOAD || insn.Opcode == opcodes.DLOAD || insn.Opcode == opcodes.LSTORE ||
+				insn.Opcode == opcodes.DSTORE {
+				slots = append(slots, insn.Var+1)
+			}
+			if insn.Opcode >= opcodes.ISTORE && insn.Opcode <= opcodes.ASTORE {
+				defs[i] = slots
+			} else {
+				uses[i] = slots
+			}
+			numSlots = max(numSlots, slots[len(slots)-1]+1)
+		case *tree.IincInsnNode:
+			uses[i] = []int{insn.Var}
+			defs[i] = []int{insn.Var}
+			numSlots = max(numSlots, insn.Var+1)
+		}
+	}
+
+	successors := make([][]int, n)
+	for i, insn := range insns {
+		switch insn := insn.(type) {
+		case *tree.JumpInsnNode:
+			successors[i] = append(successors[i], indexes[insn.Label])
+		case *tree.TableSwitchInsnNode:
+			successors[i] = append(successors[i], indexes[insn.Dflt])
+			for _, label := range insn.Labels {
+				successors[i] = append(successors[i], indexes[label])
+			}
+		case *tree.LookupSwitchInsnNode:
+			successors[i] = append(successors[i], indexes[insn.Dflt])
+			for _, label := range insn.Labels {
+				successors[i] = append(successors[i], indexes[label])
+			}
+		}
+		switch opcode := insn.GetOpcode(); {
+		case opcode == opcodes.GOTO || opcode == opcodes.ATHROW || opcode == opcodes.TABLESWITCH ||
+			opcode == opcodes.LOOKUPSWITCH || (opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN):
+		default:
+			if i+1 < n {
+				successors[i] = append(successors[i], i+1)
+			}
+		}
+	}
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		handler := indexes[tryCatchBlock.Handler]
+		for i := indexes[tryCatchBlock.Start]; i < indexes[tryCatchBlock.End]; i++ {
+			successors[i] = append(successors[i], handler)
+		}
+	}
+
+	l := &Liveness{Method: method, liveIn: make([]slotSet, n), liveOut: make([]slotSet, n), defs: defs}
+	for i := range insns {
+		l.liveIn[i] = newSlotSet(numSlots)
+		l.liveOut[i] = newSlotSet(numSlots)
+	}
+	live := newSlotSet(numSlots)
+	for changed := true; changed; {
+		changed = false
+		for i := n - 1; i >= 0; i-- {
+			for _, successor := range successors[i] {
+				l.liveOut[i].union(l.liveIn[successor])
+			}
+			copy(live, l.liveOut[i])
+			for _, slot := range defs[i] {
+				live.remove(slot)
+			}
+			for _, slot := range uses[i] {
+				live.add(slot)
+			}
+			if l.liveIn[i].union(live) {
+				changed = true
+			}
+		}
+	}
+	l.computeRanges(numSlots, indexes)
+	return l
+}
+
+// computeRanges computes the live ranges of each slot, from the instructions where a slot is occupied: live
+// before the instruction, or written by the instruction and live after it.
+func (l *Liveness) computeRanges(numSlots int, indexes map[*tree.LabelNode]int) {
+	n := len(l.liveIn)
+	for slot := 0; slot < numSlots; slot++ {
+		start := -1
+		for i := 0; i <= n; i++ {
+			occupied := i < n && (l.liveIn[i].contains(slot) || (l.isDef(i, slot) && l.liveOut[i].contains(slot)))
+			// A store starts a new range, even if the previous value was live until this instruction.
+			if start >= 0 && (!occupied || (l.isDef(i, slot) && !l.liveIn[i].contains(slot))) {
+				l.Ranges = append(l.Ranges, l.newSlotRange(slot, start, i, indexes))
+				start = -1
+			}
+			if occupied && start < 0 {
+				start = i
+			}
+		}
+	}
+}
+
+func (l *Liveness) isDef(insn, slot int) bool {
+	for _, def := range l.defs[insn] {
+		if def == slot {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Liveness) newSlotRange(slot, start, end int, indexes map[*tree.LabelNode]int) SlotRange {
+	slotRange := SlotRange{Slot: slot, Start: start, End: end}
+	for _, localVariable := range l.Method.LocalVariables {
+		if localVariable.Index != slot {
+			continue
+		}
+		// The LocalVariableTable range starts after the store of the value, hence within the live range.
+		if indexes[localVariable.Start] < end && indexes[localVariable.End] > start {
+			slotRange.Name, slotRange.Descriptor = localVariable.Name, localVariable.Descriptor
+			break
+		}
+	}
+	return slotRange
+}
+
+// IsLiveIn returns whether the given slot is live before the given instruction.
+func (l *Liveness) IsLiveIn(insn, slot int) bool {
+	return l.liveIn[insn].contains(slot)
+}
+
+// IsLiveOut returns whether the given slot is live after the given instruction.
+func (l *Liveness) IsLiveOut(insn, slot int) bool {
+	return l.liveOut[insn].contains(slot)
+}
+
+// GetRanges returns the live ranges of the given slot.
+func (l *Liveness) GetRanges(slot int) []SlotRange {
+	var ranges []SlotRange
+	for _, slotRange := range l.Ranges {
+		if slotRange.Slot == slot {
+			ranges = append(ranges, slotRange)
+		}
+	}
+	return ranges
+}
+
+// GetReusedSlots returns the slots holding different named variables (or variables of different types) over
+// the method, in increasing order.
+func (l *Liveness) GetReusedSlots() []int {
+	var reused []int
+	variables := make(map[int]string)
+	for _, slotRange := range l.Ranges {
+		if slotRange.Name == "" {
+			continue
+		}
+		variable := slotRange.Name + " " + slotRange.Descriptor
+		if previous, ok := variables[slotRange.Slot]; !ok {
+			variables[slotRange.Slot] = variable
+		} else if previous != variable && (len(reused) == 0 || reused[len(reused)-1] != slotRange.Slot) {
+			reused = append(reused, slotRange.Slot)
+		}
+	}
+	return reused
+}
+
+// String returns a text visualization of the slots occupied before each instruction: one line per instruction,
+// and one column per slot, with '|' for an occupied slot, 'w' for a written slot, and '.' for a free slot.
+func (l *Liveness) String() string {
+	numSlots := 0
+	for _, slotRange := range l.Ranges {
+		numSlots = max(numSlots, slotRange.Slot+1)
+	}
+	var sb strings.Builder
+	width := len(strconv.Itoa(len(l.liveIn)))
+	for i := range l.liveIn {
+		index := strconv.Itoa(i)
+		sb.WriteString(strings.Repeat(" ", width-len(index)))
+		sb.WriteString(index)
+		sb.WriteString(" ")
+		for slot := 0; slot < numSlots; slot++ {
+			switch {
+			case l.isDef(i, slot) && l.liveOut[i].contains(slot):
+				sb.WriteByte('w')
+			case l.liveIn[i].contains(slot):
+				sb.WriteByte('|')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteString("\n")
+	}
+	for _, slotRange := range l.Ranges {
+		sb.WriteString(slotRange.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
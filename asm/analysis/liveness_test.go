@@ -0,0 +1,107 @@
+package analysis_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// loopMethod returns the method "static int m(int n) { int sum = 0; for (int i = 0; i < n; ++i) { try { sum =
+// sum / i; } catch (ArithmeticException e) { return sum; } } return sum; }".
+func loopMethod() *tree.MethodNode {
+	loop, end, tryStart, tryEnd, handler := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(I)I", "", nil)
+	method.VisitCode()
+	method.VisitTryCatchBlock(tryStart, tryEnd, handler, "java/lang/ArithmeticException")
+	method.VisitInsn(opcodes.ICONST_0)
+	method.VisitVarInsn(opcodes.ISTORE, 1)
+	method.VisitInsn(opcodes.ICONST_0)
+	method.VisitVarInsn(opcodes.ISTORE, 2)
+	method.VisitLabel(loop)
+	method.VisitVarInsn(opcodes.ILOAD, 2)
+	method.VisitVarInsn(opcodes.ILOAD, 0)
+	method.VisitJumpInsn(opcodes.IF_ICMPGE, end)
+	method.VisitLabel(tryStart)
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	method.VisitVarInsn(opcodes.ILOAD, 2)
+	method.VisitInsn(opcodes.IDIV)
+	method.VisitVarInsn(opcodes.ISTORE, 1)
+	method.VisitLabel(tryEnd)
+	method.VisitIincInsn(2, 1)
+	method.VisitJumpInsn(opcodes.GOTO, loop)
+	method.VisitLabel(handler)
+	method.VisitVarInsn(opcodes.ASTORE, 3)
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitLabel(end)
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitMaxs(2, 4)
+	method.VisitEnd()
+	return method
+}
+
+// liveSlots returns the slots, among the first numSlots ones, for which the given function returns true.
+func liveSlots(numSlots int, isLive func(slot int) bool) []int {
+	slots := []int{}
+	for slot := 0; slot < numSlots; slot++ {
+		if isLive(slot) {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+func TestAnalyzeLiveness(t *testing.T) {
+	method := loopMethod()
+	liveness := analysis.AnalyzeLiveness(method)
+	var lines []string
+	for i := range method.Instructions {
+		lines = append(lines, fmt.Sprintf("#%d in %v out %v", i,
+			liveSlots(4, func(slot int) bool { return liveness.IsLiveIn(i, slot) }),
+			liveSlots(4, func(slot int) bool { return liveness.IsLiveOut(i, slot) })))
+	}
+	// n and i are live around the loop, but not in the handler. sum is live after each instruction of the try
+	// block, since the handler reads it. The exception is never read.
+	assertLines(t, lines, []string{
+		`#0 in [0] out [0]`,
+		`#1 in [0] out [0 1]`,
+		`#2 in [0 1] out [0 1]`,
+		`#3 in [0 1] out [0 1 2]`,
+		`#4 in [0 1 2] out [0 1 2]`,
+		`#5 in [0 1 2] out [0 1 2]`,
+		`#6 in [0 1 2] out [0 1 2]`,
+		`#7 in [0 1 2] out [0 1 2]`,
+		`#8 in [0 1 2] out [0 1 2]`,
+		`#9 in [0 1 2] out [0 1 2]`,
+		`#10 in [0 1 2] out [0 1 2]`,
+		`#11 in [0 1 2] out [0 1 2]`,
+		`#12 in [0 2] out [0 1 2]`,
+		`#13 in [0 1 2] out [0 1 2]`,
+		`#14 in [0 1 2] out [0 1 2]`,
+		`#15 in [0 1 2] out [0 1 2]`,
+		`#16 in [1] out [1]`,
+		`#17 in [1] out [1]`,
+		`#18 in [1] out []`,
+		`#19 in [] out []`,
+		`#20 in [1] out [1]`,
+		`#21 in [1] out []`,
+		`#22 in [] out []`,
+	})
+
+	lines = nil
+	for _, slotRange := range liveness.Ranges {
+		lines = append(lines, slotRange.String())
+	}
+	assertLines(t, lines, []string{
+		`slot 0 [0, 16) ? `,
+		`slot 1 [1, 12) ? `,
+		`slot 1 [12, 19) ? `,
+		`slot 1 [20, 22) ? `,
+		`slot 2 [3, 16) ? `,
+	})
+}
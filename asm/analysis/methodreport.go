@@ -0,0 +1,165 @@
+package analysis
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// MethodReport a standalone report of a single method, with its disassembly, the frames computed by
+// {@link VerifyMethod} before each instruction, its try catch blocks and some metrics, for quick inspections and
+// bug reports. A report can be encoded in JSON.
+type MethodReport struct {
+	Owner          string                `json:"owner"`
+	Name           string                `json:"name"`
+	Descriptor     string                `json:"descriptor"`
+	Access         int                   `json:"access"`
+	MaxStack       int                   `json:"maxStack"`
+	MaxLocals      int                   `json:"maxLocals"`
+	Instructions   []ReportInsn          `json:"instructions"`
+	TryCatchBlocks []ReportTryCatchBlock `json:"tryCatchBlocks,omitempty"`
+	Metrics        MethodMetrics         `json:"metrics"`
+	// Error the verification error of the method, or "". The frames are then only computed up to the error.
+	Error string `json:"error,omitempty"`
+}
+
+// ReportInsn an instruction of a {@link MethodReport}.
+type ReportInsn struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+	// Frame the frame before the instruction, or nil for pseudo instructions and unreachable instructions.
+	Frame *ReportFrame `json:"frame,omitempty"`
+}
+
+// ReportFrame the verification types (int, float, long, double, reference or uninitialized) of the local
+// variables and of the stack values of a frame.
+type ReportFrame struct {
+	Locals []string `json:"locals"`
+	Stack  []string `json:"stack"`
+}
+
+// ReportTryCatchBlock a try catch block of a {@link MethodReport}, with the names of its labels. Type is ""
+// for a "finally" block.
+type ReportTryCatchBlock struct {
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Handler string `json:"handler"`
+	Type    string `json:"type,omitempty"`
+}
+
+// MethodMetrics the metrics of a {@link MethodReport}. The pseudo instructions are not counted as instructions.
+type MethodMetrics struct {
+	Instructions   int `json:"instructions"`
+	Unreachable    int `json:"unreachable"`
+	Branches       int `json:"branches"`
+	Invocations    int `json:"invocations"`
+	FieldAccesses  int `json:"fieldAccesses"`
+	TryCatchBlocks int `json:"tryCatchBlocks"`
+	Lines          int `json:"lines"`
+}
+
+// NewMethodReport returns the report of the given method of the given class (given by its internal name).
+func NewMethodReport(owner string, method *tree.MethodNode) *MethodReport {
+	report := &MethodReport{
+		Owner:      owner,
+		Name:       method.Name,
+		Descriptor: method.Descriptor,
+		Access:     method.Access,
+		MaxStack:   method.MaxStack,
+		MaxLocals:  method.MaxLocals,
+	}
+	analyzer := NewAnalyzer[*verifierValue](verifierInterpreter{})
+	frames, err := analyzer.Analyze(owner, method)
+	if err != nil {
+		report.Error = err.Error()
+		frames = analyzer.GetFrames()
+	}
+
+	labelNames := tree.GetLabelNames(method)
+	lines := make(map[int]bool)
+	for i, insn := range method.Instructions {
+		reportInsn := ReportInsn{Index: i, Text: tree.InsnToString(insn, labelNames)}
+		switch insn.GetType() {
+		case tree.LINE:
+			lines[insn.(*tree.LineNumberNode).Line] = true
+		case tree.JUMP_INSN, tree.TABLESWITCH_INSN, tree.LOOKUPSWITCH_INSN:
+			report.Metrics.Branches++
+		case tree.METHOD_INSN, tree.INVOKE_DYNAMIC_INSN:
+			report.Metrics.Invocations++
+		case tree.FIELD_INSN:
+			report.Metrics.FieldAccesses++
+		}
+		if insn.GetOpcode() >= 0 {
+			report.Metrics.Instructions++
+			if i < len(frames) && frames[i] != nil {
+				reportInsn.Frame = newReportFrame(frames[i])
+			} else {
+				report.Metrics.Unreachable++
+			}
+		}
+		report.Instructions = append(report.Instructions, reportInsn)
+	}
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		report.TryCatchBlocks = append(report.TryCatchBlocks, ReportTryCatchBlock{
+			Start:   labelNames[tryCatchBlock.Start],
+			End:     labelNames[tryCatchBlock.End],
+			Handler: labelNames[tryCatchBlock.Handler],
+			Type:    tryCatchBlock.Type,
+		})
+	}
+	report.Metrics.TryCatchBlocks = len(method.TryCatchBlocks)
+	report.Metrics.Lines = len(lines)
+	return report
+}
+
+// newReportFrame returns the {@link ReportFrame} of the given frame.
+func newReportFrame(frame *Frame[*verifierValue]) *ReportFrame {
+	reportFrame := &ReportFrame{Locals: []string{}, Stack: []string{}}
+	for i := 0; i < frame.GetLocals(); i++ {
+		reportFrame.Locals = append(reportFrame.Locals, verifierValueName(frame.GetLocal(i)))
+	}
+	for i := 0; i < frame.GetStackSize(); i++ {
+		reportFrame.Stack = append(reportFrame.Stack, verifierValueName(frame.GetStack(i)))
+	}
+	return reportFrame
+}
+
+func verifierValueName(value *verifierValue) string {
+	if value == nil {
+		return "."
+	}
+	return value.name
+}
+
+// String returns a textual representation of this report, with one instruction per line followed by its
+// frame.
+func (m *MethodReport) String() string {
+	var s strings.Builder
+	s.WriteString(m.Owner + "." + m.Name + m.Descriptor + " access=0x" + strconv.FormatInt(int64(m.Access), 16) +
+		" maxStack=" + strconv.Itoa(m.MaxStack) + " maxLocals=" + strconv.Itoa(m.MaxLocals) + "\n")
+	for _, insn := range m.Instructions {
+		line := strconv.Itoa(insn.Index) + "\t" + insn.Text
+		if insn.Frame != nil {
+			line += "\t// locals [" + strings.Join(insn.Frame.Locals, " ") + "] stack [" +
+				strings.Join(insn.Frame.Stack, " ") + "]"
+		}
+		s.WriteString(line + "\n")
+	}
+	for _, tryCatchBlock := range m.TryCatchBlocks {
+		s.WriteString("TRYCATCHBLOCK " + tryCatchBlock.Start + " " + tryCatchBlock.End + " " +
+			tryCatchBlock.Handler + " " + tryCatchBlock.Type + "\n")
+	}
+	metrics := m.Metrics
+	s.WriteString("instructions=" + strconv.Itoa(metrics.Instructions) +
+		" unreachable=" + strconv.Itoa(metrics.Unreachable) +
+		" branches=" + strconv.Itoa(metrics.Branches) +
+		" invocations=" + strconv.Itoa(metrics.Invocations) +
+		" fieldAccesses=" + strconv.Itoa(metrics.FieldAccesses) +
+		" tryCatchBlocks=" + strconv.Itoa(metrics.TryCatchBlocks) +
+		" lines=" + strconv.Itoa(metrics.Lines) + "\n")
+	if m.Error != "" {
+		s.WriteString("error: " + m.Error + "\n")
+	}
+	return s.String()
+}
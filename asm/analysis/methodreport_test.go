@@ -0,0 +1,177 @@
+package analysis_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// reportedMethod returns the method "static int m(int x) { if (x != 0) return g(C.f); return 0; }", with an
+// unreachable NOP and a try catch block.
+func reportedMethod() *tree.MethodNode {
+	start, zero, handler := &asm.Label{}, &asm.Label{}, &asm.Label{}
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(I)I", "", nil)
+	method.VisitCode()
+	method.VisitTryCatchBlock(start, zero, handler, "java/lang/Exception")
+	method.VisitLabel(start)
+	method.VisitLineNumber(1, start)
+	method.VisitVarInsn(opcodes.ILOAD, 0)
+	method.VisitJumpInsn(opcodes.IFEQ, zero)
+	method.VisitFieldInsn(opcodes.GETSTATIC, "p/C", "f", "I")
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/C", "g", "(I)I", false)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitLabel(zero)
+	method.VisitLineNumber(2, zero)
+	method.VisitInsn(opcodes.ICONST_0)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitInsn(opcodes.NOP)
+	method.VisitLabel(handler)
+	method.VisitInsn(opcodes.POP)
+	method.VisitInsn(opcodes.ICONST_1)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitMaxs(1, 1)
+	method.VisitEnd()
+	return method
+}
+
+func TestMethodReport(t *testing.T) {
+	report := analysis.NewMethodReport("p/C", reportedMethod())
+	assertLines(t, strings.Split(strings.TrimSuffix(report.String(), "\n"), "\n"), []string{
+		`p/C.m(I)I access=0x8 maxStack=1 maxLocals=1`,
+		`0	L0:`,
+		`1	LINENUMBER 1 L0`,
+		`2	ILOAD 0	// locals [int] stack []`,
+		`3	IFEQ L1	// locals [int] stack [int]`,
+		`4	GETSTATIC p/C.f : I	// locals [int] stack []`,
+		`5	INVOKESTATIC p/C.g (I)I	// locals [int] stack [int]`,
+		`6	IRETURN	// locals [int] stack [int]`,
+		`7	L1:`,
+		`8	LINENUMBER 2 L1`,
+		`9	ICONST_0	// locals [int] stack []`,
+		`10	IRETURN	// locals [int] stack [int]`,
+		`11	NOP`,
+		`12	L2:`,
+		`13	POP	// locals [int] stack [reference]`,
+		`14	ICONST_1	// locals [int] stack []`,
+		`15	IRETURN	// locals [int] stack [int]`,
+		`TRYCATCHBLOCK L0 L1 L2 java/lang/Exception`,
+		`instructions=11 unreachable=1 branches=1 invocations=1 fieldAccesses=1 tryCatchBlocks=1 lines=2`,
+	})
+}
+
+func TestMethodReportVerificationError(t *testing.T) {
+	// The frames are only computed up to the error.
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "()I", "", nil)
+	method.VisitCode()
+	method.VisitInsn(opcodes.NOP)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitMaxs(1, 0)
+	method.VisitEnd()
+	report := analysis.NewMethodReport("p/C", method)
+	assertLines(t, strings.Split(strings.TrimSuffix(report.String(), "\n"), "\n"), []string{
+		`p/C.m()I access=0x8 maxStack=1 maxLocals=0`,
+		`0	NOP	// locals [] stack []`,
+		`1	IRETURN	// locals [] stack []`,
+		`instructions=2 unreachable=0 branches=0 invocations=0 fieldAccesses=0 tryCatchBlocks=0 lines=0`,
+		`error: Error at instruction 1: Cannot pop operand off an empty stack.`,
+	})
+}
+
+func TestMethodReportJSON(t *testing.T) {
+	// A method which throws null, with a "finally" handler returning the thrown value, encoded as by the "asm
+	// method -json" command. The empty try catch block type and the error are omitted.
+	start, end, handler := &asm.Label{}, &asm.Label{}, &asm.Label{}
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "()Ljava/lang/Object;", "", nil)
+	method.VisitCode()
+	method.VisitTryCatchBlock(start, end, handler, "")
+	method.VisitLabel(start)
+	method.VisitInsn(opcodes.ACONST_NULL)
+	method.VisitInsn(opcodes.ATHROW)
+	method.VisitLabel(end)
+	method.VisitLabel(handler)
+	method.VisitInsn(opcodes.ARETURN)
+	method.VisitMaxs(1, 0)
+	method.VisitEnd()
+
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(analysis.NewMethodReport("p/C", method)); err != nil {
+		t.Fatal(err)
+	}
+	assertLines(t, strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n"), []string{
+		`{`,
+		`  "owner": "p/C",`,
+		`  "name": "m",`,
+		`  "descriptor": "()Ljava/lang/Object;",`,
+		`  "access": 8,`,
+		`  "maxStack": 1,`,
+		`  "maxLocals": 0,`,
+		`  "instructions": [`,
+		`    {`,
+		`      "index": 0,`,
+		`      "text": "L0:"`,
+		`    },`,
+		`    {`,
+		`      "index": 1,`,
+		`      "text": "ACONST_NULL",`,
+		`      "frame": {`,
+		`        "locals": [],`,
+		`        "stack": []`,
+		`      }`,
+		`    },`,
+		`    {`,
+		`      "index": 2,`,
+		`      "text": "ATHROW",`,
+		`      "frame": {`,
+		`        "locals": [],`,
+		`        "stack": [`,
+		`          "reference"`,
+		`        ]`,
+		`      }`,
+		`    },`,
+		`    {`,
+		`      "index": 3,`,
+		`      "text": "L1:"`,
+		`    },`,
+		`    {`,
+		`      "index": 4,`,
+		`      "text": "L2:"`,
+		`    },`,
+		`    {`,
+		`      "index": 5,`,
+		`      "text": "ARETURN",`,
+		`      "frame": {`,
+		`        "locals": [],`,
+		`        "stack": [`,
+		`          "reference"`,
+		`        ]`,
+		`      }`,
+		`    }`,
+		`  ],`,
+		`  "tryCatchBlocks": [`,
+		`    {`,
+		`      "start": "L0",`,
+		`      "end": "L1",`,
+		`      "handler": "L2"`,
+		`    }`,
+		`  ],`,
+		`  "metrics": {`,
+		`    "instructions": 3,`,
+		`    "unreachable": 0,`,
+		`    "branches": 0,`,
+		`    "invocations": 0,`,
+		`    "fieldAccesses": 0,`,
+		`    "tryCatchBlocks": 1,`,
+		`    "lines": 0`,
+		`  }`,
+		`}`,
+	})
+}
@@ -0,0 +1,547 @@
+package analysis
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// ExtractMethod moves the instructions of the given method in the range [start, end) into a new private static
+// synthetic method of the given class, with the given name, and replaces them with a call to this new method.
+// The local variables which are live before the range and used in it become the parameters of the new method
+// (including 'this', passed as an explicit parameter of the owner type), and the local variable which is
+// written in the range and live after it (if any) becomes its return value. The range must start and end with
+// an empty stack, must not contain return, JSR or RET instructions, must not be the target of jumps from
+// outside (except on its first instruction) and may only jump outside to its end, and each try catch block
+// must either contain the whole range, or be inside it. The stack map frames of the method are removed, so the
+// class must be written with {@link COMPUTE_FRAMES}. The new method is added to the class and returned.
+func ExtractMethod(class *tree.ClassNode, method *tree.MethodNode, start, end int, name string) (*tree.MethodNode, error) {
+	insns := method.Instructions
+	if start < 0 || end > len(insns) || start >= end {
+		return nil, errors.New("Illegal Argument - invalid instruction range")
+	}
+	for _, m := range class.Methods {
+		if m.Name == name {
+			return nil, errors.New("Illegal Argument - a method named " + name + " already exists")
+		}
+	}
+	frames, err := NewAnalyzer[*splitValue](splitInterpreter{}).Analyze(class.Name, method)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make(map[*tree.LabelNode]int)
+	for i, insn := range insns {
+		if label, ok := insn.(*tree.LabelNode); ok {
+			indexes[label] = i
+		}
+	}
+	if err := checkExtractedRange(method, frames, indexes, start, end); err != nil {
+		return nil, err
+	}
+
+	// Find the parameters, the other locals and the result of the extracted code.
+	liveness := AnalyzeLiveness(method)
+	sizes := make(map[int]int)
+	written := make(map[int]bool)
+	for i := start; i < end; i++ {
+		switch insn := insns[i].(type) {
+		case *tree.VarInsnNode:
+			sizes[insn.Var] = 1
+			if insn.Opcode == opcodes.LLOAD || insn.Opcode == opcodes.DLOAD || insn.Opcode == opcodes.LSTORE ||
+				insn.Opcode == opcodes.DSTORE {
+				sizes[insn.Var] = 2
+			}
+			if insn.Opcode >= opcodes.ISTORE && insn.Opcode <= opcodes.ASTORE {
+				written[insn.Var] = true
+			}
+		case *tree.IincInsnNode:
+			sizes[insn.Var] = 1
+			written[insn.Var] = true
+		}
+	}
+	result := -1
+	for slot := range written {
+		if end < len(insns) && frames[end] != nil && liveness.IsLiveIn(end, slot) {
+			if result >= 0 {
+				return nil, errors.New("Illegal Argument - more than one local variable is live after the range")
+			}
+			result = slot
+		}
+	}
+	var parameters []int
+	var parameterTypes []*asm.Type
+	for slot := 0; slot < frames[start].GetLocals(); slot++ {
+		_, used := sizes[slot]
+		if !used || !liveness.IsLiveIn(start, slot) {
+			continue
+		}
+		value := frames[start].GetLocal(slot)
+		if value == nil || value.t == nil || value.imprecise {
+			return nil, errors.New("Illegal Argument - can't infer the type of the local variable " + strconv.Itoa(slot))
+		}
+		parameters = append(parameters, slot)
+		parameterTypes = append(parameterTypes, value.t)
+	}
+	returnType := asm.GetType("V")
+	if result >= 0 {
+		value := frames[end].GetLocal(result)
+		if value == nil || value.t == nil || value.imprecise {
+			return nil, errors.New("Illegal Argument - can't infer the type of the local variable " + strconv.Itoa(result))
+		}
+		returnType = value.t
+	}
+	var descriptor strings.Builder
+	descriptor.WriteString("(")
+	for _, t := range parameterTypes {
+		descriptor.WriteString(t.GetDescriptor())
+	}
+	descriptor.WriteString(")")
+	descriptor.WriteString(returnType.GetDescriptor())
+
+	// Remap the locals: parameters first, then the other locals of the extracted code.
+	remapping := make(map[int]int)
+	nextLocal := 0
+	for i, slot := range parameters {
+		remapping[slot] = nextLocal
+		nextLocal += parameterTypes[i].GetSize()
+	}
+	slots := make([]int, 0, len(sizes))
+	for slot := range sizes {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+	for _, slot := range slots {
+		if _, ok := remapping[slot]; !ok {
+			remapping[slot] = nextLocal
+			nextLocal += sizes[slot]
+		}
+	}
+
+	// Build the extracted method.
+	extracted := tree.NewMethodNode(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC|opcodes.ACC_SYNTHETIC, name,
+		descriptor.String(), "", nil)
+	labels := make(map[*tree.LabelNode]*tree.LabelNode)
+	var keptLabels []tree.AbstractInsnNode
+	for i := start; i < end; i++ {
+		if label, ok := insns[i].(*tree.LabelNode); ok {
+			labels[label] = &tree.LabelNode{Label: &asm.Label{}}
+			keptLabels = append(keptLabels, label)
+		}
+	}
+	exit := &tree.LabelNode{Label: &asm.Label{}}
+	mapLabel := func(label *tree.LabelNode) *tree.LabelNode {
+		if clone, ok := labels[label]; ok {
+			return clone
+		}
+		return exit
+	}
+	for i := start; i < end; i++ {
+		if clone := cloneExtractedInsn(insns[i], remapping, mapLabel); clone != nil {
+			extracted.Instructions = append(extracted.Instructions, clone)
+		}
+	}
+	extracted.Instructions = append(extracted.Instructions, exit)
+	if result >= 0 {
+		extracted.Instructions = append(extracted.Instructions,
+			&tree.VarInsnNode{Opcode: returnType.GetOpcode(opcodes.ILOAD), Var: remapping[result]})
+	}
+	extracted.Instructions = append(extracted.Instructions, &tree.InsnNode{Opcode: returnType.GetOpcode(opcodes.IRETURN)})
+	var tryCatchBlocks []*tree.TryCatchBlockNode
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		if indexes[tryCatchBlock.Start] >= start && indexes[tryCatchBlock.End] < end {
+			extracted.TryCatchBlocks = append(extracted.TryCatchBlocks, &tree.TryCatchBlockNode{
+				Start:   labels[tryCatchBlock.Start],
+				End:     labels[tryCatchBlock.End],
+				Handler: labels[tryCatchBlock.Handler],
+				Type:    tryCatchBlock.Type,
+			})
+		} else {
+			tryCatchBlocks = append(tryCatchBlocks, tryCatchBlock)
+		}
+	}
+	for _, localVariable := range method.LocalVariables {
+		if newLocal, ok := remapping[localVariable.Index]; ok && labels[localVariable.Start] != nil &&
+			labels[localVariable.End] != nil {
+			extracted.LocalVariables = append(extracted.LocalVariables, &tree.LocalVariableNode{
+				Name:       localVariable.Name,
+				Descriptor: localVariable.Descriptor,
+				Signature:  localVariable.Signature,
+				Start:      labels[localVariable.Start],
+				End:        labels[localVariable.End],
+				Index:      newLocal,
+			})
+		}
+	}
+	extracted.MaxStack = max(method.MaxStack, returnType.GetSize())
+	extracted.MaxLocals = nextLocal
+
+	// Replace the range with the call to the extracted method.
+	call := keptLabels
+	for i, slot := range parameters {
+		call = append(call, &tree.VarInsnNode{Opcode: parameterTypes[i].GetOpcode(opcodes.ILOAD), Var: slot})
+	}
+	call = append(call, &tree.MethodInsnNode{
+		Opcode:      opcodes.INVOKESTATIC,
+		Owner:       class.Name,
+		Name:        name,
+		Descriptor:  descriptor.String(),
+		IsInterface: class.Access&opcodes.ACC_INTERFACE != 0,
+	})
+	if result >= 0 {
+		call = append(call, &tree.VarInsnNode{Opcode: returnType.GetOpcode(opcodes.ISTORE), Var: result})
+	}
+	newInsns := make([]tree.AbstractInsnNode, 0, len(insns)-(end-start)+len(call))
+	newInsns = append(newInsns, insns[:start]...)
+	newInsns = append(newInsns, call...)
+	newInsns = append(newInsns, insns[end:]...)
+	// The existing frames may declare locals which are now only initialized by the extracted method.
+	method.Instructions = newInsns[:0]
+	for _, insn := range newInsns {
+		if insn.GetType() != tree.FRAME {
+			method.Instructions = append(method.Instructions, insn)
+		}
+	}
+	method.TryCatchBlocks = tryCatchBlocks
+	method.MaxStack = max(method.MaxStack, parameterSize(parameterTypes), returnType.GetSize())
+	class.Methods = append(class.Methods, extracted)
+	return extracted, nil
+}
+
+// checkExtractedRange checks that the given range of the given method can be extracted in a new method.
+func checkExtractedRange(method *tree.MethodNode, frames []*Frame[*splitValue], indexes map[*tree.LabelNode]int,
+	start, end int) error {
+	insns := method.Instructions
+	if frames[start] == nil {
+		return errors.New("Illegal Argument - the range starts with unreachable code")
+	}
+	if frames[start].GetStackSize() != 0 || (end < len(insns) && frames[end] != nil && frames[end].GetStackSize() != 0) {
+		return errors.New("Illegal Argument - the stack must be empty at the start and at the end of the range")
+	}
+	if method.Name == "<init>" && !isAfterSuperCall(method, frames, start) {
+		return errors.New("Illegal Argument - the range starts before the super constructor call")
+	}
+	first := nextRealInsn(insns, start)
+	inRange := func(insn int) bool { return insn >= start && insn < end }
+	// A jump target outside of the range must be equivalent to the end of the range.
+	isExit := func(target int) bool {
+		if target < end {
+			return false
+		}
+		next := nextRealInsn(insns, end)
+		return nextRealInsn(insns, target) == next
+	}
+	for i, insn := range insns {
+		var targets []*tree.LabelNode
+		switch insn := insn.(type) {
+		case *tree.JumpInsnNode:
+			targets = []*tree.LabelNode{insn.Label}
+		case *tree.TableSwitchInsnNode:
+			targets = append([]*tree.LabelNode{insn.Dflt}, insn.Labels...)
+		case *tree.LookupSwitchInsnNode:
+			targets = append([]*tree.LabelNode{insn.Dflt}, insn.Labels...)
+		}
+		if !inRange(i) {
+			for _, target := range targets {
+				if inRange(indexes[target]) && nextRealInsn(insns, indexes[target]) != first {
+					return errors.New("Illegal Argument - the range is the target of a jump from outside")
+				}
+			}
+			continue
+		}
+		switch opcode := insn.GetOpcode(); {
+		case opcode == opcodes.JSR || opcode == opcodes.RET:
+			return errors.New("Illegal Argument - the range contains a subroutine instruction")
+		case opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN:
+			return errors.New("Illegal Argument - the range contains a return instruction")
+		}
+		for _, target := range targets {
+			if !inRange(indexes[target]) && !isExit(indexes[target]) {
+				return errors.New("Illegal Argument - the range jumps outside of itself")
+			}
+		}
+	}
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		tryStart, tryEnd, handler := indexes[tryCatchBlock.Start], indexes[tryCatchBlock.End], indexes[tryCatchBlock.Handler]
+		inside := tryStart >= start && tryEnd < end && inRange(handler)
+		outside := (tryEnd <= start || tryStart >= end || (tryStart <= start && tryEnd >= end)) && !inRange(handler)
+		if !inside && !outside {
+			return errors.New("Illegal Argument - a try catch block partially overlaps the range")
+		}
+	}
+	return nil
+}
+
+// cloneExtractedInsn returns a copy of the given instruction with the given local variable remapping and label
+// mapping, or nil if the instruction must not be copied.
+func cloneExtractedInsn(insn tree.AbstractInsnNode, remapping map[int]int, mapLabel func(*tree.LabelNode) *tree.LabelNode) tree.AbstractInsnNode {
+	switch insn := insn.(type) {
+	case *tree.VarInsnNode:
+		return &tree.VarInsnNode{Opcode: insn.Opcode, Var: remapping[insn.Var]}
+	case *tree.IincInsnNode:
+		return &tree.IincInsnNode{Var: remapping[insn.Var], Increment: insn.Increment}
+	case *tree.LabelNode:
+		return mapLabel(insn)
+	case *tree.JumpInsnNode:
+		return &tree.JumpInsnNode{Opcode: insn.Opcode, Label: mapLabel(insn.Label)}
+	case *tree.TableSwitchInsnNode:
+		clone := &tree.TableSwitchInsnNode{Min: insn.Min, Max: insn.Max, Dflt: mapLabel(insn.Dflt)}
+		for _, label := range insn.Labels {
+			clone.Labels = append(clone.Labels, mapLabel(label))
+		}
+		return clone
+	case *tree.LookupSwitchInsnNode:
+		clone := &tree.LookupSwitchInsnNode{Dflt: mapLabel(insn.Dflt), Keys: append([]int(nil), insn.Keys...)}
+		for _, label := range insn.Labels {
+			clone.Labels = append(clone.Labels, mapLabel(label))
+		}
+		return clone
+	case *tree.LineNumberNode:
+		return &tree.LineNumberNode{Line: insn.Line, Start: mapLabel(insn.Start)}
+	case *tree.FrameNode:
+		return nil
+	}
+	// The other instructions have no label nor local variable operand, and can be shared.
+	return insn
+}
+
+// isAfterSuperCall returns whether the given instruction of the given constructor follows (in instruction order)
+// the call of the super or this constructor on the receiver.
+func isAfterSuperCall(method *tree.MethodNode, frames []*Frame[*splitValue], insn int) bool {
+	receiver := frames[0].GetLocal(0)
+	for i := 0; i < insn; i++ {
+		call, ok := method.Instructions[i].(*tree.MethodInsnNode)
+		if !ok || call.Opcode != opcodes.INVOKESPECIAL || call.Name != "<init>" || frames[i] == nil {
+			continue
+		}
+		numArguments := len(asm.GetMethodType(call.Descriptor).GetArgumentTypes())
+		if frames[i].GetStack(frames[i].GetStackSize()-numArguments-1) == receiver {
+			return true
+		}
+	}
+	return false
+}
+
+func parameterSize(types []*asm.Type) int {
+	size := 0
+	for _, t := range types {
+		size += t.GetSize()
+	}
+	return size
+}
+
+// splitValue a {@link Value} used by {@link ExtractMethod}, which tracks the type of the values.
+type splitValue struct {
+	// t the type of the value, or nil for uninitialized locals.
+	t *asm.Type
+	// imprecise whether the type is a merge of different reference types, or the type of null.
+	imprecise bool
+}
+
+var (
+	uninitializedSplitValue = &splitValue{}
+	intSplitValue           = &splitValue{t: asm.GetType("I")}
+	floatSplitValue         = &splitValue{t: asm.GetType("F")}
+	longSplitValue          = &splitValue{t: asm.GetType("J")}
+	doubleSplitValue        = &splitValue{t: asm.GetType("D")}
+	nullSplitValue          = &splitValue{t: asm.GetObjectType("java/lang/Object"), imprecise: true}
+	objectSplitValue        = &splitValue{t: asm.GetObjectType("java/lang/Object"), imprecise: true}
+)
+
+func (s *splitValue) GetSize() int {
+	if s.t == nil {
+		return 1
+	}
+	return s.t.GetSize()
+}
+
+// splitInterpreter the {@link Interpreter} of {@link ExtractMethod}.
+type splitInterpreter struct{}
+
+func newSplitValue(t *asm.Type) *splitValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.BOOLEAN, typed.CHAR, typed.BYTE, typed.SHORT, typed.INT:
+		return intSplitValue
+	case typed.FLOAT:
+		return floatSplitValue
+	case typed.LONG:
+		return longSplitValue
+	case typed.DOUBLE:
+		return doubleSplitValue
+	}
+	return &splitValue{t: t}
+}
+
+func (s splitInterpreter) NewValue(t *asm.Type) *splitValue {
+	if t == nil {
+		return uninitializedSplitValue
+	}
+	return newSplitValue(t)
+}
+
+func (s splitInterpreter) NewParameterValue(isInstanceMethod bool, local int, t *asm.Type) *splitValue {
+	return newSplitValue(t)
+}
+
+func (s splitInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *splitValue {
+	return newSplitValue(exceptionType)
+}
+
+func (s splitInterpreter) NewOperation(insn tree.AbstractInsnNode) (*splitValue, error) {
+	switch opcode := insn.GetOpcode(); {
+	case opcode == opcodes.ACONST_NULL:
+		return nullSplitValue, nil
+	case opcode >= opcodes.ICONST_M1 && opcode <= opcodes.ICONST_5, opcode == opcodes.BIPUSH, opcode == opcodes.SIPUSH:
+		return intSplitValue, nil
+	case opcode == opcodes.LCONST_0 || opcode == opcodes.LCONST_1:
+		return longSplitValue, nil
+	case opcode >= opcodes.FCONST_0 && opcode <= opcodes.FCONST_2:
+		return floatSplitValue, nil
+	case opcode == opcodes.DCONST_0 || opcode == opcodes.DCONST_1:
+		return doubleSplitValue, nil
+	case opcode == opcodes.GETSTATIC:
+		return newSplitValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcode == opcodes.NEW:
+		return newSplitValue(asm.GetObjectType(insn.(*tree.TypeInsnNode).Type)), nil
+	case opcode == opcodes.LDC:
+		switch value := insn.(*tree.LdcInsnNode).Value.(type) {
+		case int:
+			return intSplitValue, nil
+		case float32:
+			return floatSplitValue, nil
+		case int64:
+			return longSplitValue, nil
+		case float64:
+			return doubleSplitValue, nil
+		case string:
+			return newSplitValue(asm.GetObjectType("java/lang/String")), nil
+		case *asm.Type:
+			if value.GetSort() == typed.METHOD {
+				return newSplitValue(asm.GetObjectType("java/lang/invoke/MethodType")), nil
+			}
+			return newSplitValue(asm.GetObjectType("java/lang/Class")), nil
+		case *asm.Handle:
+			return newSplitValue(asm.GetObjectType("java/lang/invoke/MethodHandle")), nil
+		}
+	}
+	return objectSplitValue, nil
+}
+
+func (s splitInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *splitValue) (*splitValue, error) {
+	return value, nil
+}
+
+func (s splitInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *splitValue) (*splitValue, error) {
+	switch opcode := insn.GetOpcode(); opcode {
+	case opcodes.INEG, opcodes.IINC, opcodes.L2I, opcodes.F2I, opcodes.D2I, opcodes.I2B, opcodes.I2C, opcodes.I2S,
+		opcodes.ARRAYLENGTH, opcodes.INSTANCEOF:
+		return intSplitValue, nil
+	case opcodes.LNEG, opcodes.I2L, opcodes.F2L, opcodes.D2L:
+		return longSplitValue, nil
+	case opcodes.FNEG, opcodes.I2F, opcodes.L2F, opcodes.D2F:
+		return floatSplitValue, nil
+	case opcodes.DNEG, opcodes.I2D, opcodes.L2D, opcodes.F2D:
+		return doubleSplitValue, nil
+	case opcodes.GETFIELD:
+		return newSplitValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcodes.NEWARRAY:
+		descriptors := map[int]string{opcodes.T_BOOLEAN: "[Z", opcodes.T_CHAR: "[C", opcodes.T_BYTE: "[B",
+			opcodes.T_SHORT: "[S", opcodes.T_INT: "[I", opcodes.T_FLOAT: "[F", opcodes.T_LONG: "[J", opcodes.T_DOUBLE: "[D"}
+		return newSplitValue(asm.GetType(descriptors[insn.(*tree.IntInsnNode).Operand])), nil
+	case opcodes.ANEWARRAY:
+		return newSplitValue(asm.GetType("[" + asm.GetObjectType(insn.(*tree.TypeInsnNode).Type).GetDescriptor())), nil
+	case opcodes.CHECKCAST:
+		return newSplitValue(asm.GetObjectType(insn.(*tree.TypeInsnNode).Type)), nil
+	}
+	return nil, nil
+}
+
+func (s splitInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *splitValue) (*splitValue, error) {
+	switch opcode := insn.GetOpcode(); {
+	case opcode == opcodes.IALOAD || opcode == opcodes.BALOAD || opcode == opcodes.CALOAD || opcode == opcodes.SALOAD:
+		return intSplitValue, nil
+	case opcode == opcodes.LALOAD:
+		return longSplitValue, nil
+	case opcode == opcodes.FALOAD:
+		return floatSplitValue, nil
+	case opcode == opcodes.DALOAD:
+		return doubleSplitValue, nil
+	case opcode == opcodes.AALOAD:
+		if value1.t != nil && !value1.imprecise && value1.t.GetSort() == typed.ARRAY {
+			return newSplitValue(asm.GetType(value1.t.GetDescriptor()[1:])), nil
+		}
+		return objectSplitValue, nil
+	case opcode >= opcodes.IADD && opcode <= opcodes.DREM:
+		return []*splitValue{intSplitValue, longSplitValue, floatSplitValue, doubleSplitValue}[(opcode-opcodes.IADD)%4], nil
+	case opcode >= opcodes.ISHL && opcode <= opcodes.LXOR:
+		if (opcode-opcodes.ISHL)%2 == 0 {
+			return intSplitValue, nil
+		}
+		return longSplitValue, nil
+	case opcode >= opcodes.LCMP && opcode <= opcodes.DCMPG:
+		return intSplitValue, nil
+	}
+	return nil, nil
+}
+
+func (s splitInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *splitValue) (*splitValue, error) {
+	return nil, nil
+}
+
+func (s splitInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*splitValue) (*splitValue, error) {
+	switch insn := insn.(type) {
+	case *tree.MethodInsnNode:
+		if insn.Name == "<init>" {
+			return nil, nil
+		}
+		return newSplitValue(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.InvokeDynamicInsnNode:
+		return newSplitValue(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.MultiANewArrayInsnNode:
+		return newSplitValue(asm.GetType(insn.Descriptor)), nil
+	}
+	return nil, nil
+}
+
+func (s splitInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *splitValue) error {
+	return nil
+}
+
+func (s splitInterpreter) Merge(value1, value2 *splitValue) *splitValue {
+	if value1 == value2 || value2 == nil {
+		return value1
+	}
+	if value1 == nil || value1 == uninitializedSplitValue || value2 == uninitializedSplitValue || value1.t == nil ||
+		value2.t == nil || value1.GetSize() != value2.GetSize() {
+		return uninitializedSplitValue
+	}
+	isReference1 := value1.t.GetSort() == typed.OBJECT || value1.t.GetSort() == typed.ARRAY
+	isReference2 := value2.t.GetSort() == typed.OBJECT || value2.t.GetSort() == typed.ARRAY
+	if isReference1 != isReference2 {
+		return uninitializedSplitValue
+	}
+	if !isReference1 {
+		if value1.t.GetSort() == value2.t.GetSort() {
+			return value1
+		}
+		return uninitializedSplitValue
+	}
+	if value2 == nullSplitValue && value1 != objectSplitValue {
+		// null merged with a precise reference type keeps this type.
+		return value1
+	}
+	if value1 == nullSplitValue && value2 != objectSplitValue {
+		return value2
+	}
+	if !value1.imprecise && !value2.imprecise && value1.t.GetDescriptor() == value2.t.GetDescriptor() {
+		return value1
+	}
+	return objectSplitValue
+}
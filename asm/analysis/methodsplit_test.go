@@ -0,0 +1,189 @@
+package analysis_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// splitClass returns a class A with the given method, whose code is visited by the given function.
+func splitClass(access int, name, descriptor string, code func(methodVisitor asm.MethodVisitor)) (*tree.ClassNode, *tree.MethodNode) {
+	method := tree.NewMethodNode(access, name, descriptor, "", nil)
+	method.VisitCode()
+	code(method)
+	method.VisitEnd()
+	class := tree.NewClassNode()
+	class.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "A", "", "java/lang/Object", nil)
+	class.Methods = append(class.Methods, method)
+	return class, method
+}
+
+// tryCatchMethod visits "try { h(); h(); } catch (Exception e) {} return;" where the instructions are:
+// 0: L0, 1: h(), 2: h(), 3: L1, 4: GOTO L3, 5: L2, 6: POP, 7: L3, 8: RETURN.
+func tryCatchMethod(methodVisitor asm.MethodVisitor) {
+	labels := []*asm.Label{{}, {}, {}, {}}
+	methodVisitor.VisitTryCatchBlock(labels[0], labels[1], labels[2], "java/lang/Exception")
+	methodVisitor.VisitLabel(labels[0])
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "h", "()V", false)
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "h", "()V", false)
+	methodVisitor.VisitLabel(labels[1])
+	methodVisitor.VisitJumpInsn(opcodes.GOTO, labels[3])
+	methodVisitor.VisitLabel(labels[2])
+	methodVisitor.VisitInsn(opcodes.POP)
+	methodVisitor.VisitLabel(labels[3])
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitMaxs(1, 0)
+}
+
+// liveOutMethod visits "static int f(int a) { int b = a + 1; int c = b * a; return c; }" where the
+// instructions are: 0: ILOAD 0, 1: ICONST_1, 2: IADD, 3: ISTORE 1, 4: ILOAD 1, 5: ILOAD 0, 6: IMUL,
+// 7: ISTORE 2, 8: ILOAD 2, 9: IRETURN.
+func liveOutMethod(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+	methodVisitor.VisitInsn(opcodes.ICONST_1)
+	methodVisitor.VisitInsn(opcodes.IADD)
+	methodVisitor.VisitVarInsn(opcodes.ISTORE, 1)
+	methodVisitor.VisitVarInsn(opcodes.ILOAD, 1)
+	methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+	methodVisitor.VisitInsn(opcodes.IMUL)
+	methodVisitor.VisitVarInsn(opcodes.ISTORE, 2)
+	methodVisitor.VisitVarInsn(opcodes.ILOAD, 2)
+	methodVisitor.VisitInsn(opcodes.IRETURN)
+	methodVisitor.VisitMaxs(2, 3)
+}
+
+// branchMethod visits "static void b(int x) { if (x != 0) h(); h(); if (x != 0) { h(); h(); } }" where the
+// instructions are: 0: ILOAD 0, 1: IFEQ L1, 2: h(), 3: L1, 4: h(), 5: ILOAD 0, 6: IFEQ L2, 7: h(), 8: h(),
+// 9: L2, 10: RETURN.
+func branchMethod(methodVisitor asm.MethodVisitor) {
+	labels := []*asm.Label{{}, {}}
+	for _, label := range labels {
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+		methodVisitor.VisitJumpInsn(opcodes.IFEQ, label)
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "h", "()V", false)
+		if label == labels[1] {
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "h", "()V", false)
+		}
+		methodVisitor.VisitLabel(label)
+		if label == labels[0] {
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "h", "()V", false)
+		}
+	}
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitMaxs(1, 1)
+}
+
+// constructorMethod visits "A() { h(); super(); h(); }" where the instructions are: 0: h(), 1: ALOAD 0,
+// 2: INVOKESPECIAL Object.<init>, 3: h(), 4: RETURN.
+func constructorMethod(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "h", "()V", false)
+	methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, "java/lang/Object", "<init>", "()V", false)
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "h", "()V", false)
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitMaxs(1, 1)
+}
+
+// extractAndVerify extracts the given range of the given method, and verifies the resulting methods.
+func extractAndVerify(t *testing.T, class *tree.ClassNode, method *tree.MethodNode, start, end int) *tree.MethodNode {
+	t.Helper()
+	extracted, err := analysis.ExtractMethod(class, method, start, end, "extracted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*tree.MethodNode{method, extracted} {
+		if err := analysis.VerifyMethod(class.Name, m); err != nil {
+			t.Errorf("%s: %v", m.Name, err)
+		}
+	}
+	return extracted
+}
+
+func TestExtractMethodLiveOut(t *testing.T) {
+	class, method := splitClass(opcodes.ACC_STATIC, "f", "(I)I", liveOutMethod)
+	extracted := extractAndVerify(t, class, method, 4, 8)
+	// a and b are the parameters, and c, which is live after the range, the return value.
+	if extracted.Descriptor != "(II)I" || extracted.Access != opcodes.ACC_PRIVATE|opcodes.ACC_STATIC|opcodes.ACC_SYNTHETIC {
+		t.Errorf("unexpected extracted method %d %s", extracted.Access, extracted.Descriptor)
+	}
+	var call *tree.MethodInsnNode
+	for _, insn := range method.Instructions {
+		if insn, ok := insn.(*tree.MethodInsnNode); ok {
+			call = insn
+		}
+	}
+	if call == nil || call.Owner != "A" || call.Name != "extracted" || call.Descriptor != "(II)I" {
+		t.Errorf("unexpected call %v", call)
+	}
+	if len(class.Methods) != 2 {
+		t.Errorf("the extracted method was not added to the class")
+	}
+}
+
+func TestExtractMethodInsideTryBlock(t *testing.T) {
+	class, method := splitClass(opcodes.ACC_STATIC, "g", "()V", tryCatchMethod)
+	extracted := extractAndVerify(t, class, method, 1, 3)
+	if extracted.Descriptor != "()V" || len(extracted.TryCatchBlocks) != 0 || len(method.TryCatchBlocks) != 1 {
+		t.Errorf("unexpected try catch blocks %d %d", len(extracted.TryCatchBlocks), len(method.TryCatchBlocks))
+	}
+}
+
+func TestExtractMethodAcrossTryBlock(t *testing.T) {
+	class, method := splitClass(opcodes.ACC_STATIC, "g", "()V", tryCatchMethod)
+	extracted := extractAndVerify(t, class, method, 0, 8)
+	if len(extracted.TryCatchBlocks) != 1 || len(method.TryCatchBlocks) != 0 {
+		t.Errorf("unexpected try catch blocks %d %d", len(extracted.TryCatchBlocks), len(method.TryCatchBlocks))
+	}
+}
+
+func TestExtractMethodJumpToEnd(t *testing.T) {
+	class, method := splitClass(opcodes.ACC_STATIC, "b", "(I)V", branchMethod)
+	extracted := extractAndVerify(t, class, method, 5, 9)
+	if extracted.Descriptor != "(I)V" {
+		t.Errorf("unexpected descriptor %s", extracted.Descriptor)
+	}
+}
+
+func TestExtractMethodConstructor(t *testing.T) {
+	class, method := splitClass(opcodes.ACC_PUBLIC, "<init>", "()V", constructorMethod)
+	if _, err := analysis.ExtractMethod(class, method, 0, 1, "extracted"); err == nil ||
+		!strings.Contains(err.Error(), "super constructor") {
+		t.Errorf("unexpected error %v", err)
+	}
+	extractAndVerify(t, class, method, 3, 4)
+}
+
+func TestExtractMethodRejectedRanges(t *testing.T) {
+	for _, test := range []struct {
+		code       func(methodVisitor asm.MethodVisitor)
+		descriptor string
+		start, end int
+		message    string
+	}{
+		{liveOutMethod, "(I)I", 4, 4, "invalid instruction range"},
+		{liveOutMethod, "(I)I", 8, 11, "invalid instruction range"},
+		{liveOutMethod, "(I)I", 4, 5, "stack must be empty"},
+		{liveOutMethod, "(I)I", 9, 10, "stack must be empty"},
+		{liveOutMethod, "(I)I", 8, 10, "return instruction"},
+		{tryCatchMethod, "()V", 2, 4, "partially overlaps"},
+		{tryCatchMethod, "()V", 4, 7, "partially overlaps"},
+		{tryCatchMethod, "()V", 2, 6, "stack must be empty"},
+		{branchMethod, "(I)V", 2, 5, "target of a jump"},
+		{branchMethod, "(I)V", 5, 8, "jumps outside"},
+	} {
+		class, method := splitClass(opcodes.ACC_STATIC, "m", test.descriptor, test.code)
+		if _, err := analysis.ExtractMethod(class, method, test.start, test.end, "extracted"); err == nil ||
+			!strings.Contains(err.Error(), test.message) {
+			t.Errorf("[%d, %d): expected an error containing %q, got %v", test.start, test.end, test.message, err)
+		}
+	}
+
+	class, method := splitClass(opcodes.ACC_STATIC, "f", "(I)I", liveOutMethod)
+	if _, err := analysis.ExtractMethod(class, method, 4, 8, "f"); err == nil {
+		t.Error("expected an error for an existing method name")
+	}
+}
@@ -0,0 +1,331 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Nullness the possible states of a {@link NullValue}.
+const (
+	// UNKNOWN_NULLNESS nothing is known about the value (e.g. a parameter, or a value returned by a method).
+	UNKNOWN_NULLNESS = iota
+	// NULL the value is always null.
+	NULL
+	// NON_NULL the value is never null.
+	NON_NULL
+	// MAYBE_NULL the value is null on some paths, and non null on others.
+	MAYBE_NULL
+)
+
+// NullValue a {@link Value} used by the {@link NullnessInterpreter}.
+type NullValue struct {
+	size     int
+	nullness int
+}
+
+var (
+	uninitializedNullValue = &NullValue{size: 1}
+	primitiveNullValue     = &NullValue{size: 1, nullness: NON_NULL}
+	longNullValue          = &NullValue{size: 2, nullness: NON_NULL}
+	unknownNullValue       = &NullValue{size: 1, nullness: UNKNOWN_NULLNESS}
+	nullNullValue          = &NullValue{size: 1, nullness: NULL}
+	nonNullNullValue       = &NullValue{size: 1, nullness: NON_NULL}
+	maybeNullNullValue     = &NullValue{size: 1, nullness: MAYBE_NULL}
+)
+
+func (n *NullValue) GetSize() int {
+	return n.size
+}
+
+// GetNullness returns the nullness of this value: {@link UNKNOWN_NULLNESS}, {@link NULL}, {@link NON_NULL} or
+// {@link MAYBE_NULL}. Primitive values are {@link NON_NULL}.
+func (n *NullValue) GetNullness() int {
+	return n.nullness
+}
+
+func (n *NullValue) String() string {
+	switch n {
+	case uninitializedNullValue:
+		return "."
+	case primitiveNullValue, longNullValue:
+		return "P"
+	case nullNullValue:
+		return "null"
+	case nonNullNullValue:
+		return "!null"
+	case maybeNullNullValue:
+		return "?null"
+	}
+	return "?"
+}
+
+// NullnessInterpreter an {@link Interpreter} tracking whether references are null, non null, or may be null.
+// The receiver of instance methods and the references created with NEW, NEWARRAY, ANEWARRAY, MULTIANEWARRAY and
+// LDC are non null, ACONST_NULL is null, and the merge of a null or maybe null reference with a non null or
+// unknown one is maybe null. The conditional jumps do not refine the values of their successors.
+type NullnessInterpreter struct{}
+
+// NewNullnessInterpreter constructs a new {@link NullnessInterpreter}.
+func NewNullnessInterpreter() NullnessInterpreter {
+	return NullnessInterpreter{}
+}
+
+func newNullValue(t *asm.Type) *NullValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.LONG, typed.DOUBLE:
+		return longNullValue
+	case typed.OBJECT, typed.ARRAY:
+		return unknownNullValue
+	default:
+		return primitiveNullValue
+	}
+}
+
+func (NullnessInterpreter) NewValue(t *asm.Type) *NullValue {
+	if t == nil {
+		return uninitializedNullValue
+	}
+	return newNullValue(t)
+}
+
+func (NullnessInterpreter) NewParameterValue(isInstanceMethod bool, local int, t *asm.Type) *NullValue {
+	if isInstanceMethod && local == 0 {
+		return nonNullNullValue
+	}
+	return newNullValue(t)
+}
+
+func (NullnessInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *NullValue {
+	return nonNullNullValue
+}
+
+func (NullnessInterpreter) NewOperation(insn tree.AbstractInsnNode) (*NullValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.ACONST_NULL:
+		return nullNullValue, nil
+	case opcodes.LCONST_0, opcodes.LCONST_1, opcodes.DCONST_0, opcodes.DCONST_1:
+		return longNullValue, nil
+	case opcodes.LDC:
+		switch insn.(*tree.LdcInsnNode).Value.(type) {
+		case int64, float64:
+			return longNullValue, nil
+		case int, int32, float32:
+			return primitiveNullValue, nil
+		}
+		return nonNullNullValue, nil
+	case opcodes.GETSTATIC:
+		return newNullValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcodes.NEW:
+		return nonNullNullValue, nil
+	}
+	return primitiveNullValue, nil
+}
+
+func (NullnessInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *NullValue) (*NullValue, error) {
+	return value, nil
+}
+
+func (NullnessInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *NullValue) (*NullValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LNEG, opcodes.DNEG, opcodes.I2L, opcodes.I2D, opcodes.L2D, opcodes.F2L, opcodes.F2D, opcodes.D2L:
+		return longNullValue, nil
+	case opcodes.GETFIELD:
+		return newNullValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcodes.NEWARRAY, opcodes.ANEWARRAY:
+		return nonNullNullValue, nil
+	case opcodes.CHECKCAST:
+		return value, nil
+	}
+	return primitiveNullValue, nil
+}
+
+func (NullnessInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *NullValue) (*NullValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LALOAD, opcodes.DALOAD, opcodes.LADD, opcodes.DADD, opcodes.LSUB, opcodes.DSUB, opcodes.LMUL,
+		opcodes.DMUL, opcodes.LDIV, opcodes.DDIV, opcodes.LREM, opcodes.DREM, opcodes.LSHL, opcodes.LSHR,
+		opcodes.LUSHR, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		return longNullValue, nil
+	case opcodes.AALOAD:
+		return unknownNullValue, nil
+	}
+	return primitiveNullValue, nil
+}
+
+func (NullnessInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *NullValue) (*NullValue, error) {
+	return nil, nil
+}
+
+func (NullnessInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*NullValue) (*NullValue, error) {
+	switch insn := insn.(type) {
+	case *tree.MethodInsnNode:
+		return newNullValue(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.InvokeDynamicInsnNode:
+		return newNullValue(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	}
+	return nonNullNullValue, nil
+}
+
+func (NullnessInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *NullValue) error {
+	return nil
+}
+
+func (NullnessInterpreter) Merge(value1, value2 *NullValue) *NullValue {
+	if value1 == value2 {
+		return value1
+	}
+	if value1 == nil || value2 == nil || value1.size != value2.size || value1 == uninitializedNullValue ||
+		value2 == uninitializedNullValue || value1 == primitiveNullValue || value2 == primitiveNullValue {
+		return uninitializedNullValue
+	}
+	if value1 == unknownNullValue || value2 == unknownNullValue {
+		// A value which may be null on some paths stays so, whatever the value on the other paths.
+		if value1 == nonNullNullValue || value2 == nonNullNullValue {
+			return unknownNullValue
+		}
+		return maybeNullNullValue
+	}
+	// The values are two different values among null, non null and maybe null.
+	if value1 == maybeNullNullValue {
+		return value1
+	}
+	return maybeNullNullValue
+}
+
+// Nullability issue kinds.
+const (
+	// NULL_DEREFERENCE a reference which is always null is dereferenced.
+	NULL_DEREFERENCE = iota
+	// POSSIBLE_NULL_DEREFERENCE a reference which is null on some paths is dereferenced.
+	POSSIBLE_NULL_DEREFERENCE
+	// REDUNDANT_NULL_CHECK a reference whose nullness is known is compared with null.
+	REDUNDANT_NULL_CHECK
+)
+
+// NullabilityIssue a problem found by {@link AnalyzeNullability}.
+type NullabilityIssue struct {
+	// Insn the index of the instruction in the method.
+	Insn int
+	// Kind {@link NULL_DEREFERENCE}, {@link POSSIBLE_NULL_DEREFERENCE} or {@link REDUNDANT_NULL_CHECK}.
+	Kind int
+	// Message a description of the problem.
+	Message string
+}
+
+func (n NullabilityIssue) String() string {
+	return fmt.Sprintf("#%d: %s", n.Insn, n.Message)
+}
+
+// NullabilityResult the result of {@link AnalyzeNullability}.
+type NullabilityResult struct {
+	// Method the analyzed method.
+	Method *tree.MethodNode
+	// Frames the frames before each instruction of the method, nil for the unreachable ones.
+	Frames []*Frame[*NullValue]
+	// Issues the problems found in the method, in instruction order.
+	Issues []NullabilityIssue
+}
+
+// AnalyzeNullability analyzes the given method of the given class (given by its internal name) with a
+// {@link NullnessInterpreter}, and reports the dereferences of null or maybe null references, and the redundant
+// null checks.
+func AnalyzeNullability(owner string, method *tree.MethodNode) (*NullabilityResult, error) {
+	frames, err := NewAnalyzer[*NullValue](NewNullnessInterpreter()).Analyze(owner, method)
+	if err != nil {
+		return nil, err
+	}
+	result := &NullabilityResult{Method: method, Frames: frames}
+	for i, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		insn := method.Instructions[i]
+		if depth := dereferenceDepth(insn); depth >= 0 {
+			switch frame.GetStack(frame.GetStackSize() - 1 - depth).nullness {
+			case NULL:
+				result.add(i, NULL_DEREFERENCE, "null dereference in "+insnName(insn))
+			case MAYBE_NULL:
+				result.add(i, POSSIBLE_NULL_DEREFERENCE, "possible null dereference in "+insnName(insn))
+			}
+			continue
+		}
+		switch insn.GetOpcode() {
+		case opcodes.IFNULL, opcodes.IFNONNULL:
+			switch frame.GetStack(frame.GetStackSize() - 1).nullness {
+			case NULL:
+				result.add(i, REDUNDANT_NULL_CHECK, "redundant null check of a null reference")
+			case NON_NULL:
+				result.add(i, REDUNDANT_NULL_CHECK, "redundant null check of a non null reference")
+			}
+		case opcodes.IF_ACMPEQ, opcodes.IF_ACMPNE:
+			value1 := frame.GetStack(frame.GetStackSize() - 2)
+			value2 := frame.GetStack(frame.GetStackSize() - 1)
+			if value1 == nullNullValue && value2 == nullNullValue {
+				result.add(i, REDUNDANT_NULL_CHECK, "redundant comparison of two null references")
+			} else if (value1 == nullNullValue && value2 == nonNullNullValue) || (value1 == nonNullNullValue && value2 == nullNullValue) {
+				result.add(i, REDUNDANT_NULL_CHECK, "redundant null check of a non null reference")
+			}
+		}
+	}
+	return result, nil
+}
+
+func (n *NullabilityResult) add(insn, kind int, message string) {
+	n.Issues = append(n.Issues, NullabilityIssue{Insn: insn, Kind: kind, Message: message})
+}
+
+// Report returns a human readable report of the issues found in the method, one per line.
+func (n *NullabilityResult) Report() string {
+	var sb strings.Builder
+	for _, issue := range n.Issues {
+		sb.WriteString(n.Method.Name)
+		sb.WriteString(n.Method.Descriptor)
+		sb.WriteString(" ")
+		sb.WriteString(issue.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// dereferenceDepth returns the position, from the top of the stack, of the reference dereferenced by the given
+// instruction, or -1 if it does not dereference a reference. Long and double values use a single stack entry.
+func dereferenceDepth(insn tree.AbstractInsnNode) int {
+	switch insn.GetOpcode() {
+	case opcodes.GETFIELD, opcodes.ARRAYLENGTH, opcodes.ATHROW, opcodes.MONITORENTER, opcodes.MONITOREXIT:
+		return 0
+	case opcodes.PUTFIELD, opcodes.IALOAD, opcodes.LALOAD, opcodes.FALOAD, opcodes.DALOAD, opcodes.AALOAD,
+		opcodes.BALOAD, opcodes.CALOAD, opcodes.SALOAD:
+		return 1
+	case opcodes.IASTORE, opcodes.LASTORE, opcodes.FASTORE, opcodes.DASTORE, opcodes.AASTORE, opcodes.BASTORE,
+		opcodes.CASTORE, opcodes.SASTORE:
+		return 2
+	case opcodes.INVOKEVIRTUAL, opcodes.INVOKESPECIAL, opcodes.INVOKEINTERFACE:
+		return len(asm.GetMethodType(insn.(*tree.MethodInsnNode).Descriptor).GetArgumentTypes())
+	}
+	return -1
+}
+
+// insnName returns a short description of the given instruction.
+func insnName(insn tree.AbstractInsnNode) string {
+	switch insn := insn.(type) {
+	case *tree.FieldInsnNode:
+		return "field access " + insn.Owner + "." + insn.Name
+	case *tree.MethodInsnNode:
+		return "call to " + insn.Owner + "." + insn.Name + insn.Descriptor
+	}
+	switch insn.GetOpcode() {
+	case opcodes.ARRAYLENGTH:
+		return "array length"
+	case opcodes.ATHROW:
+		return "throw"
+	case opcodes.MONITORENTER, opcodes.MONITOREXIT:
+		return "synchronization"
+	}
+	return "array access"
+}
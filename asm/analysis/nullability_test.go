@@ -0,0 +1,155 @@
+package analysis_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// nullabilityReport returns the report of the nullability issues of the given method of the class C.
+func nullabilityReport(t *testing.T, method *tree.MethodNode) (string, *analysis.NullabilityResult) {
+	result, err := analysis.AnalyzeNullability("C", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSuffix(result.Report(), "\n"), result
+}
+
+// joinedMethod returns the method "static int m(boolean b, Object p) { Object o = <first>; if (b) o = <second>;
+// return o.hashCode(); }", where first and second push the values assigned to o.
+func joinedMethod(first, second func(method *tree.MethodNode)) *tree.MethodNode {
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(ZLjava/lang/Object;)I", "", nil)
+	label := &asm.Label{}
+	method.VisitCode()
+	first(method)
+	method.VisitVarInsn(opcodes.ASTORE, 2)
+	method.VisitVarInsn(opcodes.ILOAD, 0)
+	method.VisitJumpInsn(opcodes.IFEQ, label)
+	second(method)
+	method.VisitVarInsn(opcodes.ASTORE, 2)
+	method.VisitLabel(label)
+	method.VisitVarInsn(opcodes.ALOAD, 2)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/Object", "hashCode", "()I", false)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitMaxs(2, 3)
+	method.VisitEnd()
+	return method
+}
+
+func TestAnalyzeNullabilityMerges(t *testing.T) {
+	pushNull := func(method *tree.MethodNode) { method.VisitInsn(opcodes.ACONST_NULL) }
+	pushParameter := func(method *tree.MethodNode) { method.VisitVarInsn(opcodes.ALOAD, 1) }
+	pushNew := func(method *tree.MethodNode) {
+		method.VisitTypeInsn(opcodes.NEW, "java/lang/Object")
+		method.VisitInsn(opcodes.DUP)
+		method.VisitMethodInsnB(opcodes.INVOKESPECIAL, "java/lang/Object", "<init>", "()V", false)
+	}
+	for _, test := range []struct {
+		name          string
+		first, second func(method *tree.MethodNode)
+		nullness      int
+		report        string
+	}{
+		{"null", pushNull, pushNull, analysis.NULL,
+			"m(ZLjava/lang/Object;)I #8: null dereference in call to java/lang/Object.hashCode()I"},
+		{"null and non null", pushNull, pushNew, analysis.MAYBE_NULL,
+			"m(ZLjava/lang/Object;)I #10: possible null dereference in call to java/lang/Object.hashCode()I"},
+		{"null and unknown", pushNull, pushParameter, analysis.MAYBE_NULL,
+			"m(ZLjava/lang/Object;)I #8: possible null dereference in call to java/lang/Object.hashCode()I"},
+		{"unknown and null", pushParameter, pushNull, analysis.MAYBE_NULL,
+			"m(ZLjava/lang/Object;)I #8: possible null dereference in call to java/lang/Object.hashCode()I"},
+		{"unknown and non null", pushParameter, pushNew, analysis.UNKNOWN_NULLNESS, ""},
+		{"non null", pushNew, pushNew, analysis.NON_NULL, ""},
+	} {
+		report, result := nullabilityReport(t, joinedMethod(test.first, test.second))
+		// The value of o at the join point, i.e. at the label.
+		var frame *analysis.Frame[*analysis.NullValue]
+		for i, insn := range result.Method.Instructions {
+			if _, ok := insn.(*tree.LabelNode); ok && result.Frames[i] != nil {
+				frame = result.Frames[i]
+			}
+		}
+		if nullness := frame.GetLocal(2).GetNullness(); nullness != test.nullness {
+			t.Errorf("%s: expected nullness %d, got %d", test.name, test.nullness, nullness)
+		}
+		if report != test.report {
+			t.Errorf("%s: unexpected report\n%s", test.name, report)
+		}
+	}
+}
+
+// receiverCheckMethod returns the method "void m(Object o)" with the given access, which compares its local 0
+// with null and reads the field C.f of its local 0.
+func receiverCheckMethod(access int) *tree.MethodNode {
+	method := tree.NewMethodNode(access, "m", "(Ljava/lang/Object;)V", "", nil)
+	label := &asm.Label{}
+	method.VisitCode()
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitJumpInsn(opcodes.IFNULL, label)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitFieldInsn(opcodes.GETFIELD, "C", "f", "I")
+	method.VisitInsn(opcodes.POP)
+	method.VisitLabel(label)
+	method.VisitInsn(opcodes.RETURN)
+	method.VisitMaxs(1, 2)
+	method.VisitEnd()
+	return method
+}
+
+func TestAnalyzeNullabilityReceiver(t *testing.T) {
+	// The receiver of an instance method is never null.
+	report, result := nullabilityReport(t, receiverCheckMethod(opcodes.ACC_PUBLIC))
+	if nullness := result.Frames[0].GetLocal(0).GetNullness(); nullness != analysis.NON_NULL {
+		t.Errorf("expected a non null receiver, got %d", nullness)
+	}
+	if report != "m(Ljava/lang/Object;)V #1: redundant null check of a non null reference" {
+		t.Errorf("unexpected report\n%s", report)
+	}
+	// The first parameter of a static method may be null.
+	report, result = nullabilityReport(t, receiverCheckMethod(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC))
+	if nullness := result.Frames[0].GetLocal(0).GetNullness(); nullness != analysis.UNKNOWN_NULLNESS {
+		t.Errorf("expected an unknown parameter, got %d", nullness)
+	}
+	if report != "" {
+		t.Errorf("unexpected report\n%s", report)
+	}
+}
+
+func TestAnalyzeNullabilityBranches(t *testing.T) {
+	// static int m(boolean b) { int[] a = null; if (b) return a.length; a = new int[1]; if (a == null) return 0;
+	// return a.length; }
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(Z)I", "", nil)
+	notB, notNull := &asm.Label{}, &asm.Label{}
+	method.VisitCode()
+	method.VisitInsn(opcodes.ACONST_NULL)
+	method.VisitVarInsn(opcodes.ASTORE, 1)
+	method.VisitVarInsn(opcodes.ILOAD, 0)
+	method.VisitJumpInsn(opcodes.IFEQ, notB)
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitInsn(opcodes.ARRAYLENGTH)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitLabel(notB)
+	method.VisitInsn(opcodes.ICONST_1)
+	method.VisitIntInsn(opcodes.NEWARRAY, opcodes.T_INT)
+	method.VisitVarInsn(opcodes.ASTORE, 1)
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitJumpInsn(opcodes.IFNONNULL, notNull)
+	method.VisitInsn(opcodes.ICONST_0)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitLabel(notNull)
+	method.VisitVarInsn(opcodes.ALOAD, 1)
+	method.VisitInsn(opcodes.ARRAYLENGTH)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitMaxs(1, 2)
+	method.VisitEnd()
+
+	report, _ := nullabilityReport(t, method)
+	if report != "m(Z)I #5: null dereference in array length\n"+
+		"m(Z)I #12: redundant null check of a non null reference" {
+		t.Errorf("unexpected report\n%s", report)
+	}
+}
@@ -0,0 +1,287 @@
+package analysis
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// The scopes of an {@link OpcodeHistogram}.
+const (
+	HISTOGRAM_METHOD = iota
+	HISTOGRAM_CLASS
+	HISTOGRAM_JAR
+)
+
+var histogramScopes = []string{"method", "class", "jar"}
+
+// OpcodeHistogram the number of occurrences of each opcode in a method, a class or a jar. The instructions are
+// counted with their opcodes as visited by a {@link MethodVisitor}, i.e. the short forms of the load, store and
+// ldc instructions are counted as ILOAD, ISTORE, LDC, etc, and the wide instructions as their unwidened opcode.
+type OpcodeHistogram struct {
+	// Scope the scope of the histogram, one of {@link HISTOGRAM_METHOD}, {@link HISTOGRAM_CLASS} or
+	// {@link HISTOGRAM_JAR}.
+	Scope int
+	// Name the name of the method (owner.nameDescriptor), the internal name of the class, or the path of the jar.
+	Name string
+	// Counts the number of occurrences of each opcode, indexed by opcode.
+	Counts [256]int
+}
+
+// Add adds the counts of the given histogram to this histogram.
+func (h *OpcodeHistogram) Add(other *OpcodeHistogram) {
+	for opcode, count := range other.Counts {
+		h.Counts[opcode] += count
+	}
+}
+
+// GetTotal returns the total number of instructions counted in this histogram.
+func (h *OpcodeHistogram) GetTotal() int {
+	total := 0
+	for _, count := range h.Counts {
+		total += count
+	}
+	return total
+}
+
+// MarshalJSON encodes the histogram as an object with its scope, name and total, and the counts of its opcodes
+// which occur at least once, by opcode name.
+func (h *OpcodeHistogram) MarshalJSON() ([]byte, error) {
+	counts := make(map[string]int)
+	for opcode, count := range h.Counts {
+		if count != 0 {
+			counts[opcodeName(opcode)] = count
+		}
+	}
+	return json.Marshal(struct {
+		Scope   string         `json:"scope"`
+		Name    string         `json:"name"`
+		Total   int            `json:"total"`
+		Opcodes map[string]int `json:"opcodes"`
+	}{histogramScopes[h.Scope], h.Name, h.GetTotal(), counts})
+}
+
+func opcodeName(opcode int) string {
+	if opcode < len(opcodes.NAMES) && opcodes.NAMES[opcode] != "" {
+		return opcodes.NAMES[opcode]
+	}
+	return strconv.Itoa(opcode)
+}
+
+// OpcodeCounter computes the opcode histograms of the methods and classes added with {@link AddClass} or
+// {@link AddJar}, and of all of them. The classes are read without their debug information and frames, and the
+// histograms are streamed to a callback as soon as they are computed, so that large corpora can be processed
+// without keeping the per method results in memory.
+type OpcodeCounter struct {
+	emit  func(histogram *OpcodeHistogram) error
+	total OpcodeHistogram
+}
+
+// NewOpcodeCounter constructs a new {@link OpcodeCounter}, which passes the histogram of each method, then of its
+// class, and the histogram of each jar to the given callback (which may be nil). The method histograms are only
+// emitted for the methods with code. An error returned by the callback stops the counting and is returned.
+func NewOpcodeCounter(emit func(histogram *OpcodeHistogram) error) *OpcodeCounter {
+	return &OpcodeCounter{emit: emit, total: OpcodeHistogram{Scope: HISTOGRAM_JAR}}
+}
+
+// GetTotal returns the histogram of all the classes added so far.
+func (c *OpcodeCounter) GetTotal() *OpcodeHistogram {
+	return &c.total
+}
+
+// AddClass counts the instructions of the given class file, and returns its histogram.
+func (c *OpcodeCounter) AddClass(classFile []byte) (*OpcodeHistogram, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	collector := &opcodeCollector{counter: c, class: OpcodeHistogram{Scope: HISTOGRAM_CLASS}}
+	reader.Accept(collector, asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+	if collector.err != nil {
+		return nil, collector.err
+	}
+	c.total.Add(&collector.class)
+	if c.emit != nil {
+		if err := c.emit(&collector.class); err != nil {
+			return nil, err
+		}
+	}
+	return &collector.class, nil
+}
+
+// AddJar counts the instructions of the class files of the given jar (or zip) file, and returns its histogram.
+func (c *OpcodeCounter) AddJar(path string) (*OpcodeHistogram, error) {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer jar.Close()
+	histogram := &OpcodeHistogram{Scope: HISTOGRAM_JAR, Name: path}
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || strings.HasSuffix(file.Name, "module-info.class") {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		class, err := c.AddClass(classFile)
+		if err != nil {
+			return nil, err
+		}
+		histogram.Add(class)
+	}
+	if c.emit != nil {
+		if err := c.emit(histogram); err != nil {
+			return nil, err
+		}
+	}
+	return histogram, nil
+}
+
+// opcodeCollector a {@link ClassVisitor} which computes the opcode histograms of the methods of a class.
+type opcodeCollector struct {
+	helper.ClassVisitor
+	counter *OpcodeCounter
+	class   OpcodeHistogram
+	err     error
+}
+
+func (c *opcodeCollector) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.class.Name = name
+}
+
+func (c *opcodeCollector) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	if c.err != nil {
+		return nil
+	}
+	return &opcodeMethodCollector{class: c, method: OpcodeHistogram{Scope: HISTOGRAM_METHOD, Name: c.class.Name + "." + name + descriptor}}
+}
+
+// opcodeMethodCollector a {@link MethodVisitor} which computes the opcode histogram of a method.
+type opcodeMethodCollector struct {
+	helper.MethodVisitor
+	class  *opcodeCollector
+	method OpcodeHistogram
+}
+
+func (m *opcodeMethodCollector) VisitInsn(opcode int) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitIntInsn(opcode, operand int) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitVarInsn(opcode, vard int) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitTypeInsn(opcode int, typed string) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	m.method.Counts[opcodes.INVOKEDYNAMIC]++
+}
+
+func (m *opcodeMethodCollector) VisitJumpInsn(opcode int, label *asm.Label) {
+	m.method.Counts[opcode]++
+}
+
+func (m *opcodeMethodCollector) VisitLdcInsn(value interface{}) {
+	m.method.Counts[opcodes.LDC]++
+}
+
+func (m *opcodeMethodCollector) VisitIincInsn(vard, increment int) {
+	m.method.Counts[opcodes.IINC]++
+}
+
+func (m *opcodeMethodCollector) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	m.method.Counts[opcodes.TABLESWITCH]++
+}
+
+func (m *opcodeMethodCollector) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	m.method.Counts[opcodes.LOOKUPSWITCH]++
+}
+
+func (m *opcodeMethodCollector) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.method.Counts[opcodes.MULTIANEWARRAY]++
+}
+
+func (m *opcodeMethodCollector) VisitEnd() {
+	if m.method.GetTotal() == 0 {
+		return
+	}
+	m.class.class.Add(&m.method)
+	if m.class.counter.emit != nil && m.class.err == nil {
+		m.class.err = m.class.counter.emit(&m.method)
+	}
+}
+
+// HistogramWriter writes {@link OpcodeHistogram}s to a writer as they are computed, either in CSV, with one
+// "scope,name,opcode,count" row per opcode occurring in a histogram, or in JSON Lines, with one JSON object per
+// histogram (see {@link OpcodeHistogram#MarshalJSON}). Its {@link Write} method can be used as the callback of an
+// {@link OpcodeCounter}.
+type HistogramWriter struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewHistogramCSVWriter constructs a new {@link HistogramWriter} writing CSV rows, starting with a header row.
+func NewHistogramCSVWriter(writer io.Writer) *HistogramWriter {
+	h := &HistogramWriter{csv: csv.NewWriter(writer)}
+	h.csv.Write([]string{"scope", "name", "opcode", "count"})
+	return h
+}
+
+// NewHistogramJSONWriter constructs a new {@link HistogramWriter} writing JSON Lines.
+func NewHistogramJSONWriter(writer io.Writer) *HistogramWriter {
+	return &HistogramWriter{json: json.NewEncoder(writer)}
+}
+
+// Write writes the given histogram.
+func (h *HistogramWriter) Write(histogram *OpcodeHistogram) error {
+	if h.json != nil {
+		return h.json.Encode(histogram)
+	}
+	for opcode, count := range histogram.Counts {
+		if count != 0 {
+			h.csv.Write([]string{histogramScopes[histogram.Scope], histogram.Name, opcodeName(opcode), strconv.Itoa(count)})
+		}
+	}
+	return h.csv.Error()
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (h *HistogramWriter) Flush() error {
+	if h.csv != nil {
+		h.csv.Flush()
+		return h.csv.Error()
+	}
+	return nil
+}
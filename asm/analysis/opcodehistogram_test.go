@@ -0,0 +1,50 @@
+package analysis_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+func TestOpcodeCounter(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x421, "A", "java/lang/Object")
+	method := classFile.AddMethod(opcodes.ACC_STATIC, "m", "(I)I", "", nil)
+	method.VisitVarInsn(opcodes.ILOAD, 0)
+	method.VisitVarInsn(opcodes.ILOAD, 300)
+	method.VisitInsn(opcodes.IADD)
+	method.VisitLdcInsn("x")
+	method.VisitInsn(opcodes.POP)
+	method.VisitInsn(opcodes.IRETURN)
+	method.VisitMaxs(2, 301)
+	classFile.AddMethod(opcodes.ACC_ABSTRACT, "n", "()V", "", nil)
+
+	var output bytes.Buffer
+	writer := analysis.NewHistogramCSVWriter(&output)
+	counter := analysis.NewOpcodeCounter(writer.Write)
+	class, err := counter.AddClass(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+	expected := "scope,name,opcode,count\n" +
+		"method,A.m(I)I,LDC,1\nmethod,A.m(I)I,ILOAD,2\nmethod,A.m(I)I,POP,1\nmethod,A.m(I)I,IADD,1\nmethod,A.m(I)I,IRETURN,1\n" +
+		"class,A,LDC,1\nclass,A,ILOAD,2\nclass,A,POP,1\nclass,A,IADD,1\nclass,A,IRETURN,1\n"
+	if output.String() != expected {
+		t.Errorf("unexpected CSV output:\n%s", output.String())
+	}
+	if class.GetTotal() != 6 || counter.GetTotal().GetTotal() != 6 {
+		t.Errorf("unexpected totals %d %d", class.GetTotal(), counter.GetTotal().GetTotal())
+	}
+
+	output.Reset()
+	if err := analysis.NewHistogramJSONWriter(&output).Write(class); err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"scope":"class","name":"A","total":6,"opcodes":{"IADD":1,"ILOAD":2,"IRETURN":1,"LDC":1,"POP":1}}` + "\n"
+	if output.String() != expected {
+		t.Errorf("unexpected JSON output: %s", output.String())
+	}
+}
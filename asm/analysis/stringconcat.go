@@ -0,0 +1,433 @@
+package analysis
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Kinds of string concatenations.
+const (
+	// STRING_BUILDER_CONCAT a new StringBuilder (or StringBuffer), a chain of append calls, and toString.
+	STRING_BUILDER_CONCAT = iota
+	// INDY_CONCAT an invokedynamic instruction bootstrapped by java/lang/invoke/StringConcatFactory (Java 9+).
+	INDY_CONCAT
+)
+
+// CONCAT_ARGUMENT_TAG the character standing for a dynamic argument in a concatenation template, as in the
+// recipes of StringConcatFactory.makeConcatWithConstants.
+const CONCAT_ARGUMENT_TAG = '\u0001'
+
+// CONCAT_CONSTANT_TAG the character standing for a constant bootstrap argument in a makeConcatWithConstants
+// recipe.
+const CONCAT_CONSTANT_TAG = '\u0002'
+
+// ConcatPart a part of a {@link StringConcat}.
+type ConcatPart struct {
+	// Constant whether this part is a constant.
+	Constant bool
+	// Value the string value of a constant part.
+	Value string
+	// Type the type of the value of a dynamic part.
+	Type *asm.Type
+	// Insn the index of the instruction which pushes the value of a dynamic part, or of a constant part pushed
+	// on the stack, or -1.
+	Insn int
+}
+
+// StringConcat a string concatenation reconstructed by {@link FindStringConcats}.
+type StringConcat struct {
+	// Kind {@link STRING_BUILDER_CONCAT} or {@link INDY_CONCAT}.
+	Kind int
+	// Start the index of the NEW StringBuilder instruction, or of the invokedynamic instruction.
+	Start int
+	// Insn the index of the toString call, or of the invokedynamic instruction.
+	Insn int
+	// Parts the concatenated parts, in order.
+	Parts []ConcatPart
+}
+
+// Template returns the template of this concatenation: the constant parts, with {@link CONCAT_ARGUMENT_TAG}
+// in place of each dynamic part.
+func (s *StringConcat) Template() string {
+	var sb strings.Builder
+	for _, part := range s.Parts {
+		if part.Constant {
+			sb.WriteString(part.Value)
+		} else {
+			sb.WriteRune(CONCAT_ARGUMENT_TAG)
+		}
+	}
+	return sb.String()
+}
+
+// Arguments returns the dynamic parts of this concatenation.
+func (s *StringConcat) Arguments() []ConcatPart {
+	var arguments []ConcatPart
+	for _, part := range s.Parts {
+		if !part.Constant {
+			arguments = append(arguments, part)
+		}
+	}
+	return arguments
+}
+
+// IsConstant returns whether all the parts of this concatenation are constants.
+func (s *StringConcat) IsConstant() bool {
+	for _, part := range s.Parts {
+		if !part.Constant {
+			return false
+		}
+	}
+	return true
+}
+
+// FindStringConcats reconstructs the string concatenations of the given method of the given class (given by its
+// internal name), compiled either to StringBuilder (or StringBuffer) chains or to StringConcatFactory
+// invokedynamic call sites. The parts appended to a builder are ordered by instruction index, which is the
+// concatenation order of the chains generated by the compilers.
+func FindStringConcats(owner string, method *tree.MethodNode) ([]StringConcat, error) {
+	interpreter := &concatInterpreter{indexes: make(map[tree.AbstractInsnNode]int)}
+	for i, insn := range method.Instructions {
+		interpreter.indexes[insn] = i
+	}
+	frames, err := NewAnalyzer[*ConcatValue](interpreter).Analyze(owner, method)
+	if err != nil {
+		return nil, err
+	}
+	var concats []StringConcat
+	parts := make(map[int][]ConcatPart)
+	for i, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		switch insn := method.Instructions[i].(type) {
+		case *tree.MethodInsnNode:
+			if !isStringBuilder(insn.Owner) || insn.Opcode == opcodes.INVOKESTATIC {
+				continue
+			}
+			argumentTypes := asm.GetMethodType(insn.Descriptor).GetArgumentTypes()
+			receiver := frame.GetStack(frame.GetStackSize() - 1 - len(argumentTypes))
+			if receiver.site < 0 {
+				continue
+			}
+			switch {
+			case insn.Name == "append" && len(argumentTypes) == 1,
+				insn.Name == "<init>" && len(argumentTypes) == 1 && argumentTypes[0].GetSort() == typed.OBJECT:
+				part := newConcatPart(method, argumentTypes[0], frame.GetStack(frame.GetStackSize()-1))
+				parts[receiver.site] = append(parts[receiver.site], part)
+			case insn.Name == "toString" && len(argumentTypes) == 0:
+				concats = append(concats, StringConcat{
+					Kind:  STRING_BUILDER_CONCAT,
+					Start: receiver.site,
+					Insn:  i,
+					Parts: append([]ConcatPart(nil), parts[receiver.site]...),
+				})
+			}
+		case *tree.InvokeDynamicInsnNode:
+			handle := insn.BootstrapMethodHandle
+			if handle == nil || handle.GetOwner() != "java/lang/invoke/StringConcatFactory" {
+				continue
+			}
+			argumentTypes := asm.GetMethodType(insn.Descriptor).GetArgumentTypes()
+			first := frame.GetStackSize() - len(argumentTypes)
+			concat := StringConcat{Kind: INDY_CONCAT, Start: i, Insn: i}
+			argument, constant := 0, 1
+			nextArgument := func() {
+				if argument < len(argumentTypes) {
+					part := newConcatPart(method, argumentTypes[argument], frame.GetStack(first+argument))
+					concat.Parts = append(concat.Parts, part)
+					argument++
+				}
+			}
+			recipe, ok := "", false
+			if handle.GetName() == "makeConcatWithConstants" && len(insn.BootstrapMethodArguments) > 0 {
+				recipe, ok = insn.BootstrapMethodArguments[0].(string)
+			}
+			if !ok {
+				for argument < len(argumentTypes) {
+					nextArgument()
+				}
+			}
+			var sb strings.Builder
+			flush := func() {
+				if sb.Len() > 0 {
+					concat.Parts = append(concat.Parts, ConcatPart{Constant: true, Value: sb.String(), Insn: -1})
+					sb.Reset()
+				}
+			}
+			for _, c := range recipe {
+				switch c {
+				case CONCAT_ARGUMENT_TAG:
+					flush()
+					nextArgument()
+				case CONCAT_CONSTANT_TAG:
+					if constant < len(insn.BootstrapMethodArguments) {
+						sb.WriteString(constantString(insn.BootstrapMethodArguments[constant], nil))
+						constant++
+					}
+				default:
+					sb.WriteRune(c)
+				}
+			}
+			flush()
+			concats = append(concats, concat)
+		}
+	}
+	return concats, nil
+}
+
+func isStringBuilder(internalName string) bool {
+	return internalName == "java/lang/StringBuilder" || internalName == "java/lang/StringBuffer"
+}
+
+// newConcatPart returns the part of the given type whose value is the given value.
+func newConcatPart(method *tree.MethodNode, t *asm.Type, value *ConcatValue) ConcatPart {
+	part := ConcatPart{Type: t, Insn: -1}
+	if len(value.insns) != 1 {
+		return part
+	}
+	part.Insn = value.insns[0]
+	insn := method.Instructions[part.Insn]
+	var constant interface{}
+	switch {
+	case insn.GetOpcode() >= opcodes.ICONST_M1 && insn.GetOpcode() <= opcodes.ICONST_5:
+		constant = insn.GetOpcode() - opcodes.ICONST_0
+	case insn.GetOpcode() == opcodes.LCONST_0 || insn.GetOpcode() == opcodes.LCONST_1:
+		constant = int64(insn.GetOpcode() - opcodes.LCONST_0)
+	case insn.GetOpcode() >= opcodes.FCONST_0 && insn.GetOpcode() <= opcodes.FCONST_2:
+		constant = float32(insn.GetOpcode() - opcodes.FCONST_0)
+	case insn.GetOpcode() == opcodes.DCONST_0 || insn.GetOpcode() == opcodes.DCONST_1:
+		constant = float64(insn.GetOpcode() - opcodes.DCONST_0)
+	case insn.GetOpcode() == opcodes.BIPUSH || insn.GetOpcode() == opcodes.SIPUSH:
+		constant = insn.(*tree.IntInsnNode).Operand
+	case insn.GetOpcode() == opcodes.LDC:
+		constant = insn.(*tree.LdcInsnNode).Value
+		if _, ok := constant.(*asm.Type); ok {
+			return part
+		}
+		if _, ok := constant.(*asm.Handle); ok {
+			return part
+		}
+	default:
+		return part
+	}
+	part.Constant = true
+	part.Value = constantString(constant, t)
+	return part
+}
+
+// constantString returns the string value of the given constant, as appended to a string by Java, when its
+// static type is the given one (which may be nil).
+func constantString(constant interface{}, t *asm.Type) string {
+	switch value := constant.(type) {
+	case string:
+		return value
+	case int:
+		if t != nil && t.GetSort() == typed.CHAR {
+			return string(rune(value))
+		}
+		if t != nil && t.GetSort() == typed.BOOLEAN {
+			return strconv.FormatBool(value != 0)
+		}
+		return strconv.Itoa(value)
+	case int32:
+		return constantString(int(value), t)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float32:
+		return javaFloatString(float64(value), 32)
+	case float64:
+		return javaFloatString(value, 64)
+	}
+	return ""
+}
+
+// javaFloatString returns the decimal representation of the given value, as returned by Float.toString or
+// Double.toString.
+func javaFloatString(value float64, bitSize int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	if abs := math.Abs(value); abs == 0 || (abs >= 1e-3 && abs < 1e7) {
+		s := strconv.FormatFloat(value, 'f', -1, bitSize)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+	s := strconv.FormatFloat(value, 'E', -1, bitSize)
+	e := strings.IndexByte(s, 'E')
+	mantissa, exponent := s[:e], s[e+1:]
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	n, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(n)
+}
+
+// ConcatValue a {@link Value} used by {@link FindStringConcats}, which tracks the StringBuilder a reference may
+// be, and the instructions which may have pushed a value.
+type ConcatValue struct {
+	size int
+	// site the index of the NEW StringBuilder instruction of the value, or -1.
+	site  int
+	insns []int
+}
+
+var (
+	uninitializedConcatValue = &ConcatValue{size: 1, site: -1}
+	otherConcatValue         = &ConcatValue{size: 1, site: -1}
+	otherLongConcatValue     = &ConcatValue{size: 2, site: -1}
+)
+
+func (c *ConcatValue) GetSize() int {
+	return c.size
+}
+
+func (c *ConcatValue) String() string {
+	if c == uninitializedConcatValue {
+		return "."
+	}
+	if c.site >= 0 {
+		return "sb#" + strconv.Itoa(c.site)
+	}
+	insns := make([]string, len(c.insns))
+	for i, insn := range c.insns {
+		insns[i] = "#" + strconv.Itoa(insn)
+	}
+	return "{" + strings.Join(insns, ",") + "}"
+}
+
+// concatInterpreter the {@link Interpreter} of {@link FindStringConcats}.
+type concatInterpreter struct {
+	indexes map[tree.AbstractInsnNode]int
+}
+
+func (c *concatInterpreter) newValue(size int, insn tree.AbstractInsnNode) *ConcatValue {
+	return &ConcatValue{size: size, site: -1, insns: []int{c.indexes[insn]}}
+}
+
+func (c *concatInterpreter) newTypedValue(t *asm.Type, insn tree.AbstractInsnNode) *ConcatValue {
+	if t.GetSort() == typed.VOID {
+		return nil
+	}
+	return c.newValue(t.GetSize(), insn)
+}
+
+func (c *concatInterpreter) NewValue(t *asm.Type) *ConcatValue {
+	switch {
+	case t == nil:
+		return uninitializedConcatValue
+	case t.GetSort() == typed.VOID:
+		return nil
+	case t.GetSize() == 2:
+		return otherLongConcatValue
+	}
+	return otherConcatValue
+}
+
+func (c *concatInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *ConcatValue {
+	return otherConcatValue
+}
+
+func (c *concatInterpreter) NewOperation(insn tree.AbstractInsnNode) (*ConcatValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LCONST_0, opcodes.LCONST_1, opcodes.DCONST_0, opcodes.DCONST_1:
+		return c.newValue(2, insn), nil
+	case opcodes.LDC:
+		switch insn.(*tree.LdcInsnNode).Value.(type) {
+		case int64, float64:
+			return c.newValue(2, insn), nil
+		}
+	case opcodes.GETSTATIC:
+		return c.newTypedValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor), insn), nil
+	case opcodes.NEW:
+		if isStringBuilder(insn.(*tree.TypeInsnNode).Type) {
+			site := c.indexes[insn]
+			return &ConcatValue{size: 1, site: site, insns: []int{site}}, nil
+		}
+	}
+	return c.newValue(1, insn), nil
+}
+
+func (c *concatInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *ConcatValue) (*ConcatValue, error) {
+	return value, nil
+}
+
+func (c *concatInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *ConcatValue) (*ConcatValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LNEG, opcodes.DNEG, opcodes.I2L, opcodes.I2D, opcodes.L2D, opcodes.F2L, opcodes.F2D, opcodes.D2L:
+		return c.newValue(2, insn), nil
+	case opcodes.GETFIELD:
+		return c.newTypedValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor), insn), nil
+	case opcodes.CHECKCAST:
+		if value.site >= 0 {
+			return value, nil
+		}
+	}
+	return c.newValue(1, insn), nil
+}
+
+func (c *concatInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *ConcatValue) (*ConcatValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LALOAD, opcodes.DALOAD, opcodes.LADD, opcodes.DADD, opcodes.LSUB, opcodes.DSUB, opcodes.LMUL,
+		opcodes.DMUL, opcodes.LDIV, opcodes.DDIV, opcodes.LREM, opcodes.DREM, opcodes.LSHL, opcodes.LSHR,
+		opcodes.LUSHR, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		return c.newValue(2, insn), nil
+	}
+	return c.newValue(1, insn), nil
+}
+
+func (c *concatInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *ConcatValue) (*ConcatValue, error) {
+	return nil, nil
+}
+
+func (c *concatInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*ConcatValue) (*ConcatValue, error) {
+	switch insn := insn.(type) {
+	case *tree.MethodInsnNode:
+		returnType := asm.GetMethodType(insn.Descriptor).GetReturnType()
+		// StringBuilder.append returns its receiver.
+		if isStringBuilder(insn.Owner) && insn.Name == "append" && returnType.GetSort() == typed.OBJECT &&
+			isStringBuilder(returnType.GetInternalName()) && values[0].site >= 0 {
+			return values[0], nil
+		}
+		return c.newTypedValue(returnType, insn), nil
+	case *tree.InvokeDynamicInsnNode:
+		return c.newTypedValue(asm.GetMethodType(insn.Descriptor).GetReturnType(), insn), nil
+	}
+	return c.newValue(1, insn), nil
+}
+
+func (c *concatInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *ConcatValue) error {
+	return nil
+}
+
+func (c *concatInterpreter) Merge(value1, value2 *ConcatValue) *ConcatValue {
+	if value1 == value2 {
+		return value1
+	}
+	if value1 == nil || value2 == nil || value1.size != value2.size || value1 == uninitializedConcatValue ||
+		value2 == uninitializedConcatValue {
+		return uninitializedConcatValue
+	}
+	site := value1.site
+	if site != value2.site {
+		site = -1
+	}
+	insns := mergeInts(value1.insns, value2.insns)
+	if site == value1.site && len(insns) == len(value1.insns) {
+		return value1
+	}
+	return &ConcatValue{size: value1.size, site: site, insns: insns}
+}
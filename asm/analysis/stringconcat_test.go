@@ -0,0 +1,79 @@
+package analysis_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestFindStringConcats(t *testing.T) {
+	// static String m(String s, int i) { String a = "a=" + s + '!' + i + 2.5f; String b = s + " is " + i + "K" + 7;
+	// return a + b; }
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(Ljava/lang/String;I)Ljava/lang/String;", "", nil)
+	appendValue := func(descriptor string) {
+		method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/StringBuilder", "append",
+			"("+descriptor+")Ljava/lang/StringBuilder;", false)
+	}
+	method.VisitCode()
+	method.VisitTypeInsn(opcodes.NEW, "java/lang/StringBuilder")
+	method.VisitInsn(opcodes.DUP)
+	method.VisitLdcInsn("a=")
+	method.VisitMethodInsnB(opcodes.INVOKESPECIAL, "java/lang/StringBuilder", "<init>", "(Ljava/lang/String;)V", false)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	appendValue("Ljava/lang/String;")
+	method.VisitIntInsn(opcodes.BIPUSH, '!')
+	appendValue("C")
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	appendValue("I")
+	method.VisitLdcInsn(float32(2.5))
+	appendValue("F")
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/StringBuilder", "toString", "()Ljava/lang/String;", false)
+	method.VisitVarInsn(opcodes.ASTORE, 2)
+	// The Java 9+ compilation of the second concatenation, with a constant bootstrap argument.
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	method.VisitInvokeDynamicInsn("makeConcatWithConstants", "(Ljava/lang/String;I)Ljava/lang/String;",
+		asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/StringConcatFactory", "makeConcatWithConstants",
+			"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/String;"+
+				"[Ljava/lang/Object;)Ljava/lang/invoke/CallSite;", false),
+		"\u0001 is \u0001\u00027", "K")
+	method.VisitVarInsn(opcodes.ASTORE, 3)
+	// makeConcat has no recipe: the arguments are concatenated.
+	method.VisitVarInsn(opcodes.ALOAD, 2)
+	method.VisitVarInsn(opcodes.ALOAD, 3)
+	method.VisitInvokeDynamicInsn("makeConcat", "(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;",
+		asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/StringConcatFactory", "makeConcat",
+			"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;)"+
+				"Ljava/lang/invoke/CallSite;", false))
+	method.VisitInsn(opcodes.ARETURN)
+	method.VisitMaxs(3, 4)
+	method.VisitEnd()
+
+	concats, err := analysis.FindStringConcats("C", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, concat := range concats {
+		lines = append(lines, fmt.Sprintf("kind %d #%d-#%d %q constant %v", concat.Kind, concat.Start, concat.Insn,
+			concat.Template(), concat.IsConstant()))
+		for _, argument := range concat.Arguments() {
+			lines = append(lines, fmt.Sprintf("  #%d %s", argument.Insn, argument.Type.GetDescriptor()))
+		}
+	}
+	assertLines(t, lines, []string{
+		`kind 0 #0-#12 "a=\x01!\x012.5" constant false`,
+		`  #-1 Ljava/lang/String;`,
+		`  #-1 I`,
+		`kind 1 #16-#16 "\x01 is \x01K7" constant false`,
+		`  #-1 Ljava/lang/String;`,
+		`  #-1 I`,
+		`kind 1 #20-#20 "\x01\x01" constant false`,
+		`  #12 Ljava/lang/String;`,
+		`  #16 Ljava/lang/String;`,
+	})
+}
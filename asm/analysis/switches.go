@@ -0,0 +1,285 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// Kinds of lowered switches.
+const (
+	// STRING_SWITCH a switch on a String, lowered to a switch on its hashCode followed by equals calls.
+	STRING_SWITCH = iota
+	// ENUM_SWITCH a switch on an enum, lowered to a switch on a $SwitchMap$ array indexed by the ordinal.
+	ENUM_SWITCH
+)
+
+// SwitchCase a case of a {@link DecodedSwitch}.
+type SwitchCase struct {
+	// Value the string constant, or the enum constant name ("" if the switch map can't be resolved).
+	Value string
+	// Target the first instruction of the case.
+	Target *tree.LabelNode
+}
+
+// DecodedSwitch a switch on a String or on an enum, decoded from its lowered form.
+type DecodedSwitch struct {
+	// Kind {@link STRING_SWITCH} or {@link ENUM_SWITCH}.
+	Kind int
+	// Start the index of the first instruction of the lowered form: the hashCode call, or the GETSTATIC of the
+	// switch map.
+	Start int
+	// Insn the index of the switch instruction which jumps to the case targets.
+	Insn int
+	// EnumType the internal name of the enum, for {@link ENUM_SWITCH}.
+	EnumType string
+	// Cases the cases of the switch, in the order of the keys of the switch instruction.
+	Cases []SwitchCase
+	// Default the target of the default case.
+	Default *tree.LabelNode
+}
+
+// DecodeSwitches decodes the switches on strings and on enums of the given method, lowered by javac (hashCode
+// switch and equals chain setting an index, followed by a switch on this index) or by ecj (hashCode switch and
+// equals chain jumping directly to the cases), and the javac enum switches using a $SwitchMap$ array of a
+// synthetic class. This class is returned by the given function (which may be nil or return nil, in which case
+// the enum constants are unknown).
+func DecodeSwitches(method *tree.MethodNode, switchMapClass func(internalName string) *tree.ClassNode) []DecodedSwitch {
+	insns := method.Instructions
+	indexes := make(map[*tree.LabelNode]int)
+	for i, insn := range insns {
+		if label, ok := insn.(*tree.LabelNode); ok {
+			indexes[label] = i
+		}
+	}
+	var switches []DecodedSwitch
+	for i, insn := range insns {
+		call, ok := insn.(*tree.MethodInsnNode)
+		if !ok || call.Opcode != opcodes.INVOKEVIRTUAL || call.Descriptor != "()I" {
+			continue
+		}
+		if call.Owner == "java/lang/String" && call.Name == "hashCode" {
+			if decoded, ok := decodeStringSwitch(insns, indexes, i); ok {
+				switches = append(switches, decoded)
+			}
+		} else if call.Name == "ordinal" {
+			if decoded, ok := decodeEnumSwitch(insns, i, switchMapClass); ok {
+				switches = append(switches, decoded)
+			}
+		}
+	}
+	return switches
+}
+
+// decodeStringSwitch decodes the string switch whose hashCode call is the given instruction.
+func decodeStringSwitch(insns []tree.AbstractInsnNode, indexes map[*tree.LabelNode]int, hashCode int) (DecodedSwitch, bool) {
+	decoded := DecodedSwitch{Kind: STRING_SWITCH, Start: hashCode}
+	load := previousRealInsn(insns, hashCode-1)
+	hashSwitch := nextRealInsn(insns, hashCode+1)
+	if load < 0 || insns[load].GetOpcode() != opcodes.ALOAD || hashSwitch < 0 {
+		return decoded, false
+	}
+	tmp := insns[load].(*tree.VarInsnNode).Var
+	_, targets, dflt, ok := switchTargets(insns[hashSwitch])
+	if !ok {
+		return decoded, false
+	}
+	// The case index, for javac, or the case target, for ecj, of each string.
+	caseIndexes := make(map[int]string)
+	indexVar := -1
+	var direct []SwitchCase
+	for _, target := range targets {
+		for i := nextRealInsn(insns, indexes[target]); i >= 0; {
+			value, jump, ok := parseEquals(insns, i, tmp)
+			if !ok {
+				break
+			}
+			if jump.Opcode == opcodes.IFNE {
+				direct = append(direct, SwitchCase{value, jump.Label})
+				i = nextRealInsn(insns, jump.index+1)
+				continue
+			}
+			constant := nextRealInsn(insns, jump.index+1)
+			if constant < 0 {
+				break
+			}
+			store := nextRealInsn(insns, constant+1)
+			key, isConstant := intConstant(insns[constant])
+			if !isConstant || store < 0 || insns[store].GetOpcode() != opcodes.ISTORE {
+				break
+			}
+			indexVar = insns[store].(*tree.VarInsnNode).Var
+			caseIndexes[key] = value
+			// Strings with the same hash code are tested in sequence, each one jumping forward to the next.
+			if indexes[jump.Label] <= i {
+				break
+			}
+			i = nextRealInsn(insns, indexes[jump.Label])
+		}
+	}
+	if len(direct) > 0 {
+		decoded.Insn, decoded.Cases, decoded.Default = hashSwitch, direct, dflt
+		return decoded, true
+	}
+	// javac: the hash switch and all the equals chains end at ILOAD index, followed by the index switch.
+	indexLoad := nextRealInsn(insns, indexes[dflt])
+	if indexVar < 0 || indexLoad < 0 || insns[indexLoad].GetOpcode() != opcodes.ILOAD ||
+		insns[indexLoad].(*tree.VarInsnNode).Var != indexVar {
+		return decoded, false
+	}
+	indexSwitch := nextRealInsn(insns, indexLoad+1)
+	if indexSwitch < 0 {
+		return decoded, false
+	}
+	keys, labels, indexDflt, ok := switchTargets(insns[indexSwitch])
+	if !ok {
+		return decoded, false
+	}
+	for i, key := range keys {
+		if value, ok := caseIndexes[key]; ok {
+			decoded.Cases = append(decoded.Cases, SwitchCase{value, labels[i]})
+		}
+	}
+	decoded.Insn, decoded.Default = indexSwitch, indexDflt
+	return decoded, true
+}
+
+// indexedJump a jump instruction with its index.
+type indexedJump struct {
+	*tree.JumpInsnNode
+	index int
+}
+
+// parseEquals parses ALOAD tmp, LDC value, INVOKEVIRTUAL String.equals, IFEQ or IFNE, starting at the given
+// instruction.
+func parseEquals(insns []tree.AbstractInsnNode, i int, tmp int) (string, indexedJump, bool) {
+	if !isVarInsn(insns[i], opcodes.ALOAD, tmp) {
+		return "", indexedJump{}, false
+	}
+	ldc := nextRealInsn(insns, i+1)
+	equals := nextRealInsn(insns, ldc+1)
+	jump := nextRealInsn(insns, equals+1)
+	if jump < 0 || ldc < 0 || equals < 0 {
+		return "", indexedJump{}, false
+	}
+	constant, ok := insns[ldc].(*tree.LdcInsnNode)
+	if !ok {
+		return "", indexedJump{}, false
+	}
+	value, ok := constant.Value.(string)
+	call, isCall := insns[equals].(*tree.MethodInsnNode)
+	if !ok || !isCall || call.Owner != "java/lang/String" || call.Name != "equals" {
+		return "", indexedJump{}, false
+	}
+	jumpInsn, ok := insns[jump].(*tree.JumpInsnNode)
+	if !ok || (jumpInsn.Opcode != opcodes.IFEQ && jumpInsn.Opcode != opcodes.IFNE) {
+		return "", indexedJump{}, false
+	}
+	return value, indexedJump{jumpInsn, jump}, true
+}
+
+// decodeEnumSwitch decodes the enum switch whose ordinal call is the given instruction.
+func decodeEnumSwitch(insns []tree.AbstractInsnNode, ordinal int, switchMapClass func(internalName string) *tree.ClassNode) (DecodedSwitch, bool) {
+	call := insns[ordinal].(*tree.MethodInsnNode)
+	decoded := DecodedSwitch{Kind: ENUM_SWITCH, EnumType: call.Owner}
+	arrayLoad := nextRealInsn(insns, ordinal+1)
+	if arrayLoad < 0 || insns[arrayLoad].GetOpcode() != opcodes.IALOAD {
+		return decoded, false
+	}
+	var switchMap *tree.FieldInsnNode
+	for i := previousRealInsn(insns, ordinal-1); i >= 0 && switchMap == nil; i = previousRealInsn(insns, i-1) {
+		if field, ok := insns[i].(*tree.FieldInsnNode); ok && field.Opcode == opcodes.GETSTATIC &&
+			strings.HasPrefix(field.Name, "$SwitchMap$") && field.Descriptor == "[I" {
+			switchMap, decoded.Start = field, i
+		}
+	}
+	insn := nextRealInsn(insns, arrayLoad+1)
+	if switchMap == nil || insn < 0 {
+		return decoded, false
+	}
+	keys, labels, dflt, ok := switchTargets(insns[insn])
+	if !ok {
+		return decoded, false
+	}
+	var names map[int]string
+	if switchMapClass != nil {
+		if class := switchMapClass(switchMap.Owner); class != nil {
+			names = switchMapNames(class, switchMap.Name)
+		}
+	}
+	for i, key := range keys {
+		decoded.Cases = append(decoded.Cases, SwitchCase{names[key], labels[i]})
+	}
+	decoded.Insn, decoded.Default = insn, dflt
+	return decoded, true
+}
+
+// switchMapNames returns the enum constant names of the keys of the given switch map field of the given class,
+// from the GETSTATIC map, GETSTATIC constant, INVOKEVIRTUAL ordinal, key, IASTORE sequences of its <clinit>.
+func switchMapNames(class *tree.ClassNode, field string) map[int]string {
+	names := make(map[int]string)
+	clinit := class.GetMethod("<clinit>", "()V")
+	if clinit == nil {
+		return names
+	}
+	insns := clinit.Instructions
+	for i := nextRealInsn(insns, 0); i >= 0; i = nextRealInsn(insns, i+1) {
+		switchMap, ok := insns[i].(*tree.FieldInsnNode)
+		if !ok || switchMap.Opcode != opcodes.GETSTATIC || switchMap.Name != field {
+			continue
+		}
+		constant := nextRealInsn(insns, i+1)
+		if constant < 0 {
+			continue
+		}
+		ordinal := nextRealInsn(insns, constant+1)
+		key := nextRealInsn(insns, ordinal+1)
+		store := nextRealInsn(insns, key+1)
+		if store < 0 || insns[store].GetOpcode() != opcodes.IASTORE || insns[constant].GetOpcode() != opcodes.GETSTATIC {
+			continue
+		}
+		if value, ok := intConstant(insns[key]); ok {
+			names[value] = insns[constant].(*tree.FieldInsnNode).Name
+		}
+	}
+	return names
+}
+
+// switchTargets returns the keys, the targets and the default target of the given switch instruction.
+func switchTargets(insn tree.AbstractInsnNode) ([]int, []*tree.LabelNode, *tree.LabelNode, bool) {
+	switch insn := insn.(type) {
+	case *tree.TableSwitchInsnNode:
+		keys := make([]int, len(insn.Labels))
+		for i := range keys {
+			keys[i] = insn.Min + i
+		}
+		return keys, insn.Labels, insn.Dflt, true
+	case *tree.LookupSwitchInsnNode:
+		return insn.Keys, insn.Labels, insn.Dflt, true
+	}
+	return nil, nil, nil, false
+}
+
+// intConstant returns the value pushed by the given ICONST_*, BIPUSH, SIPUSH or LDC instruction.
+func intConstant(insn tree.AbstractInsnNode) (int, bool) {
+	switch insn := insn.(type) {
+	case *tree.InsnNode:
+		if insn.Opcode >= opcodes.ICONST_M1 && insn.Opcode <= opcodes.ICONST_5 {
+			return insn.Opcode - opcodes.ICONST_0, true
+		}
+	case *tree.IntInsnNode:
+		if insn.Opcode == opcodes.BIPUSH || insn.Opcode == opcodes.SIPUSH {
+			return insn.Operand, true
+		}
+	case *tree.LdcInsnNode:
+		value, ok := insn.Value.(int)
+		return value, ok
+	}
+	return 0, false
+}
+
+func isVarInsn(insn tree.AbstractInsnNode, opcode, local int) bool {
+	varInsn, ok := insn.(*tree.VarInsnNode)
+	return ok && varInsn.Opcode == opcode && varInsn.Var == local
+}
@@ -0,0 +1,159 @@
+package analysis_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// switchLines returns the switches decoded in the given method, one per line, with the instruction indices of
+// their targets.
+func switchLines(method *tree.MethodNode, switchMapClass func(internalName string) *tree.ClassNode) []string {
+	indexes := make(map[*tree.LabelNode]int)
+	for i, insn := range method.Instructions {
+		if label, ok := insn.(*tree.LabelNode); ok {
+			indexes[label] = i
+		}
+	}
+	var lines []string
+	for _, decoded := range analysis.DecodeSwitches(method, switchMapClass) {
+		cases := make([]string, len(decoded.Cases))
+		for i, switchCase := range decoded.Cases {
+			cases[i] = fmt.Sprintf("%q->#%d", switchCase.Value, indexes[switchCase.Target])
+		}
+		lines = append(lines, fmt.Sprintf("kind %d #%d-#%d %s [%s] default #%d", decoded.Kind, decoded.Start,
+			decoded.Insn, decoded.EnumType, strings.Join(cases, " "), indexes[decoded.Default]))
+	}
+	return lines
+}
+
+// switchMethod returns a method "static void m(String s, E e, int i)" whose code is generated by the given
+// function, followed by a RETURN.
+func switchMethod(code func(method *tree.MethodNode)) *tree.MethodNode {
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(Ljava/lang/String;Lp/E;I)V", "", nil)
+	method.VisitCode()
+	code(method)
+	method.VisitInsn(opcodes.RETURN)
+	method.VisitMaxs(2, 5)
+	method.VisitEnd()
+	return method
+}
+
+func TestDecodeSwitchesJavacString(t *testing.T) {
+	// switch (s) { case "c": ...; case "Aa": ...; case "BB": ...; default: ... }, where "Aa" and "BB" have the same
+	// hash code.
+	method := switchMethod(func(method *tree.MethodNode) {
+		c, aa, bb, indexSwitch := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+		case0, case1, case2, dflt := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+		method.VisitVarInsn(opcodes.ALOAD, 0)
+		method.VisitVarInsn(opcodes.ASTORE, 3)
+		method.VisitInsn(opcodes.ICONST_M1)
+		method.VisitVarInsn(opcodes.ISTORE, 4)
+		method.VisitVarInsn(opcodes.ALOAD, 3)
+		method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/String", "hashCode", "()I", false)
+		method.VisitLookupSwitchInsn(indexSwitch, []int{99, 2112}, []*asm.Label{c, aa})
+		equals := func(value string, index int, next *asm.Label) {
+			method.VisitVarInsn(opcodes.ALOAD, 3)
+			method.VisitLdcInsn(value)
+			method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/String", "equals", "(Ljava/lang/Object;)Z", false)
+			method.VisitJumpInsn(opcodes.IFEQ, next)
+			method.VisitInsn(opcodes.ICONST_0 + index)
+			method.VisitVarInsn(opcodes.ISTORE, 4)
+		}
+		method.VisitLabel(c)
+		equals("c", 0, indexSwitch)
+		method.VisitJumpInsn(opcodes.GOTO, indexSwitch)
+		method.VisitLabel(aa)
+		equals("Aa", 1, bb)
+		method.VisitJumpInsn(opcodes.GOTO, indexSwitch)
+		method.VisitLabel(bb)
+		equals("BB", 2, indexSwitch)
+		method.VisitLabel(indexSwitch)
+		method.VisitVarInsn(opcodes.ILOAD, 4)
+		method.VisitTableSwitchInsn(0, 2, dflt, case0, case1, case2)
+		for _, label := range []*asm.Label{case0, case1, case2, dflt} {
+			method.VisitLabel(label)
+			method.VisitInsn(opcodes.NOP)
+		}
+	})
+	assertLines(t, switchLines(method, nil), []string{
+		`kind 0 #5-#32  ["c"->#33 "Aa"->#35 "BB"->#37] default #39`,
+	})
+}
+
+func TestDecodeSwitchesEcjString(t *testing.T) {
+	// switch (s) { case "a": ...; default: ... }, where the equals chain jumps directly to the case.
+	method := switchMethod(func(method *tree.MethodNode) {
+		a, caseA, dflt := &asm.Label{}, &asm.Label{}, &asm.Label{}
+		method.VisitVarInsn(opcodes.ALOAD, 0)
+		method.VisitVarInsn(opcodes.ASTORE, 3)
+		method.VisitVarInsn(opcodes.ALOAD, 3)
+		method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/String", "hashCode", "()I", false)
+		method.VisitLookupSwitchInsn(dflt, []int{97}, []*asm.Label{a})
+		method.VisitLabel(a)
+		method.VisitVarInsn(opcodes.ALOAD, 3)
+		method.VisitLdcInsn("a")
+		method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/String", "equals", "(Ljava/lang/Object;)Z", false)
+		method.VisitJumpInsn(opcodes.IFNE, caseA)
+		method.VisitJumpInsn(opcodes.GOTO, dflt)
+		method.VisitLabel(caseA)
+		method.VisitInsn(opcodes.NOP)
+		method.VisitLabel(dflt)
+	})
+	assertLines(t, switchLines(method, nil), []string{
+		`kind 0 #3-#4  ["a"->#11] default #13`,
+	})
+}
+
+func TestDecodeSwitchesEnum(t *testing.T) {
+	// switch (e) { case RED: ...; case BLUE: ...; default: ... }, using the switch map of the class C$1.
+	method := switchMethod(func(method *tree.MethodNode) {
+		red, blue, dflt := &asm.Label{}, &asm.Label{}, &asm.Label{}
+		method.VisitFieldInsn(opcodes.GETSTATIC, "C$1", "$SwitchMap$p$E", "[I")
+		method.VisitVarInsn(opcodes.ALOAD, 1)
+		method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "p/E", "ordinal", "()I", false)
+		method.VisitInsn(opcodes.IALOAD)
+		method.VisitLookupSwitchInsn(dflt, []int{1, 2}, []*asm.Label{red, blue})
+		for _, label := range []*asm.Label{red, blue, dflt} {
+			method.VisitLabel(label)
+			method.VisitInsn(opcodes.NOP)
+		}
+		// A switch on an int is not decoded.
+		other := &asm.Label{}
+		method.VisitVarInsn(opcodes.ILOAD, 2)
+		method.VisitTableSwitchInsn(0, 0, other, other)
+		method.VisitLabel(other)
+	})
+	switchMap := tree.NewClassNode()
+	switchMap.Visit(opcodes.V1_8, opcodes.ACC_SYNTHETIC, "C$1", "", "java/lang/Object", nil)
+	clinit := switchMap.VisitMethod(opcodes.ACC_STATIC, "<clinit>", "()V", "", nil)
+	clinit.VisitCode()
+	for i, name := range []string{"RED", "BLUE"} {
+		clinit.VisitFieldInsn(opcodes.GETSTATIC, "C$1", "$SwitchMap$p$E", "[I")
+		clinit.VisitFieldInsn(opcodes.GETSTATIC, "p/E", name, "Lp/E;")
+		clinit.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "p/E", "ordinal", "()I", false)
+		clinit.VisitInsn(opcodes.ICONST_1 + i)
+		clinit.VisitInsn(opcodes.IASTORE)
+	}
+	clinit.VisitInsn(opcodes.RETURN)
+	clinit.VisitMaxs(3, 0)
+	clinit.VisitEnd()
+
+	assertLines(t, switchLines(method, func(internalName string) *tree.ClassNode {
+		if internalName == "C$1" {
+			return switchMap
+		}
+		return nil
+	}), []string{
+		`kind 1 #0-#4 p/E ["RED"->#5 "BLUE"->#7] default #9`,
+	})
+	// Without the switch map class, the enum constants are unknown.
+	assertLines(t, switchLines(method, nil), []string{
+		`kind 1 #0-#4 p/E [""->#5 ""->#7] default #9`,
+	})
+}
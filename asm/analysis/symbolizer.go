@@ -0,0 +1,273 @@
+package analysis
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// SymbolizedFrame a profiler stack frame (a class, a method and a bytecode offset) resolved to its source
+// location by a {@link Symbolizer}.
+type SymbolizedFrame struct {
+	// Class the internal name of the class.
+	Class      string
+	Method     string
+	Descriptor string
+	// SourceFile the name of the source file of the class, or "" if unknown.
+	SourceFile string
+	// Line the source line number of the bytecode offset, or -1 if unknown.
+	Line int
+	// Lambda if the method is the synthetic method implementing the body of a lambda, a description of this
+	// lambda (e.g. "java/lang/Runnable.run lambda in main([Ljava/lang/String;)V at line 12"), or "".
+	Lambda string
+	// Resolved whether the class and the method were found in the classpath of the symbolizer.
+	Resolved bool
+}
+
+func (s SymbolizedFrame) String() string {
+	result := strings.ReplaceAll(s.Class, "/", ".") + "." + s.Method
+	location := s.SourceFile
+	if location == "" {
+		location = "Unknown Source"
+	}
+	if s.Line >= 0 {
+		location += ":" + strconv.Itoa(s.Line)
+	}
+	result += "(" + location + ")"
+	if s.Lambda != "" {
+		result += " [" + s.Lambda + "]"
+	}
+	return result
+}
+
+// symbolizerClass the debug information of a class, kept by a {@link Symbolizer}.
+type symbolizerClass struct {
+	sourceFile string
+	methods    []symbolizerMethod
+	// lambdas the call sites of the lambdas implemented by a method of the class, indexed by method name and
+	// descriptor.
+	lambdas map[string]symbolizerLambda
+}
+
+// symbolizerMethod the line number table of a method, as (start_pc, line_number) pairs in class file order.
+type symbolizerMethod struct {
+	name       string
+	descriptor string
+	codeLength int
+	lines      [][2]int
+}
+
+type symbolizerLambda struct {
+	enclosingMethod string
+	interfaceMethod string
+	line            int
+}
+
+// Symbolizer resolves the method references reported by profilers (e.g. the class, method and bytecode index
+// of the JFR stack frames) to source files and line numbers, using the debug information of the classes of a
+// classpath, added with {@link AddClass}, {@link AddJar} or {@link AddPath}. The synthetic methods implementing
+// lambda bodies are resolved to the lambda expression which created them.
+type Symbolizer struct {
+	classes map[string]*symbolizerClass
+}
+
+// NewSymbolizer constructs a new {@link Symbolizer} with an empty classpath.
+func NewSymbolizer() *Symbolizer {
+	return &Symbolizer{classes: make(map[string]*symbolizerClass)}
+}
+
+// AddClass adds the given class file to the classpath of the symbolizer.
+func (s *Symbolizer) AddClass(classFile []byte) error {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return err
+	}
+	constantPool, err := raw.ReadConstantPool(classFile, 0)
+	if err != nil {
+		return err
+	}
+	class := &symbolizerClass{lambdas: make(map[string]symbolizerLambda)}
+	index := reader.Index()
+	if sourceFile := index.GetAttribute("SourceFile"); sourceFile != nil {
+		if sourceFileIndex, err := raw.ReadU2(classFile, sourceFile.Start+6); err == nil {
+			class.sourceFile, _ = constantPool.GetUTF8(classFile, sourceFileIndex)
+		}
+	}
+	for _, methodIndex := range index.Methods {
+		method := symbolizerMethod{name: methodIndex.Name, descriptor: methodIndex.Descriptor}
+		if code := methodIndex.GetAttribute("Code"); code != nil {
+			if err := readLineNumbers(classFile, constantPool, code, &method); err != nil {
+				return err
+			}
+		}
+		class.methods = append(class.methods, method)
+	}
+
+	classNode := tree.NewClassNode()
+	reader.Accept(classNode, asm.SKIP_FRAMES)
+	for _, callSite := range FindLambdaCallSites(classNode) {
+		if callSite.ImplementationMethod == nil {
+			continue
+		}
+		class.lambdas[callSite.ImplementationMethod.Name+callSite.ImplementationMethod.Descriptor] = symbolizerLambda{
+			enclosingMethod: callSite.Method.Name + callSite.Method.Descriptor,
+			interfaceMethod: callSite.Interface + "." + callSite.InterfaceMethod,
+			line:            callSite.Line,
+		}
+	}
+	s.classes[reader.GetClassName()] = class
+	return nil
+}
+
+// readLineNumbers reads the code length and the LineNumberTable attributes of the given Code attribute.
+func readLineNumbers(classFile []byte, constantPool *raw.ConstantPool, code *asm.AttributeRange, method *symbolizerMethod) error {
+	codeLength, err := raw.ReadU4(classFile, code.Start+10)
+	if err != nil {
+		return err
+	}
+	method.codeLength = int(codeLength)
+	currentOffset := code.Start + 14 + method.codeLength
+	exceptionTableLength, err := raw.ReadU2(classFile, currentOffset)
+	if err != nil {
+		return err
+	}
+	currentOffset += 2 + exceptionTableLength*8
+	attributesCount, err := raw.ReadU2(classFile, currentOffset)
+	if err != nil {
+		return err
+	}
+	currentOffset += 2
+	for i := 0; i < attributesCount; i++ {
+		nameIndex, err := raw.ReadU2(classFile, currentOffset)
+		if err != nil {
+			return err
+		}
+		length, err := raw.ReadU4(classFile, currentOffset+2)
+		if err != nil {
+			return err
+		}
+		if name, _ := constantPool.GetUTF8(classFile, nameIndex); name == "LineNumberTable" {
+			count, err := raw.ReadU2(classFile, currentOffset+6)
+			if err != nil {
+				return err
+			}
+			for j := 0; j < count; j++ {
+				startPc, err := raw.ReadU2(classFile, currentOffset+8+j*4)
+				if err != nil {
+					return err
+				}
+				line, err := raw.ReadU2(classFile, currentOffset+10+j*4)
+				if err != nil {
+					return err
+				}
+				method.lines = append(method.lines, [2]int{startPc, line})
+			}
+		}
+		currentOffset += 6 + int(length)
+	}
+	return nil
+}
+
+// AddJar adds the class files of the given jar (or zip) file to the classpath of the symbolizer.
+func (s *Symbolizer) AddJar(path string) error {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer jar.Close()
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || strings.HasSuffix(file.Name, "module-info.class") {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+		if err := s.AddClass(classFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddPath adds the given classpath entry to the classpath of the symbolizer: a class file, a jar file, or a
+// directory whose class and jar files are added recursively.
+func (s *Symbolizer) AddPath(path string) error {
+	return filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch {
+		case strings.HasSuffix(file, ".jar"), strings.HasSuffix(file, ".zip"):
+			return s.AddJar(file)
+		case strings.HasSuffix(file, ".class") && !strings.HasSuffix(file, "module-info.class"):
+			classFile, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			return s.AddClass(classFile)
+		}
+		return nil
+	})
+}
+
+// Symbolize resolves the given bytecode offset of the given method. The class can be given by its internal
+// name or by its binary name (with dots). The descriptor can be "" if unknown, in which case the first method
+// with the given name whose code contains the offset is used.
+func (s *Symbolizer) Symbolize(className, method, descriptor string, bci int) SymbolizedFrame {
+	className = strings.ReplaceAll(className, ".", "/")
+	frame := SymbolizedFrame{Class: className, Method: method, Descriptor: descriptor, Line: -1}
+	class := s.classes[className]
+	if class == nil {
+		return frame
+	}
+	frame.SourceFile = class.sourceFile
+	for _, candidate := range class.methods {
+		if candidate.name != method || (descriptor != "" && candidate.descriptor != descriptor) ||
+			(descriptor == "" && bci >= candidate.codeLength) {
+			continue
+		}
+		frame.Descriptor, frame.Resolved = candidate.descriptor, true
+		// The line is the one of the entry with the largest start_pc less than or equal to the offset.
+		startPc := -1
+		for _, line := range candidate.lines {
+			if line[0] <= bci && line[0] >= startPc {
+				startPc, frame.Line = line[0], line[1]
+			}
+		}
+		frame.Lambda = s.describeLambda(class, candidate.name+candidate.descriptor, 0)
+		break
+	}
+	return frame
+}
+
+// describeLambda returns the description of the lambda implemented by the given method, or "". The lambdas
+// nested in other lambdas are described up to their enclosing non lambda method.
+func (s *Symbolizer) describeLambda(class *symbolizerClass, method string, depth int) string {
+	lambda, ok := class.lambdas[method]
+	if !ok || depth > len(class.lambdas) {
+		return ""
+	}
+	description := lambda.interfaceMethod + " lambda in "
+	if enclosing := s.describeLambda(class, lambda.enclosingMethod, depth+1); enclosing != "" {
+		description += "(" + enclosing + ")"
+	} else {
+		description += lambda.enclosingMethod
+	}
+	if lambda.line >= 0 {
+		description += " at line " + strconv.Itoa(lambda.line)
+	}
+	return description
+}
@@ -0,0 +1,125 @@
+package analysis_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// symbolizedClass returns the class file of a class p/C compiled from C.java, with the methods:
+//
+//	main([Ljava/lang/String;)V: bci 0-1 at line 10, 2-7 at line 12 (creating a lambda), 8 at line 14
+//	lambda$main$0()V: bci 0-5 at line 13 (creating a nested lambda)
+//	lambda$main$1()V: bci 0 at line 13
+//	m()V: bci 0, without line numbers
+//	m(I)V: bci 0-3 at line 20
+func symbolizedClass(t *testing.T) []byte {
+	classWriter := asm.NewClassWriter(nil)
+	classWriter.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "", "java/lang/Object", nil)
+	classWriter.VisitSource("C.java", "")
+	method := func(access int, name, descriptor string, code func(methodVisitor asm.MethodVisitor, line func(int))) {
+		methodVisitor := classWriter.VisitMethod(access, name, descriptor, "", nil)
+		methodVisitor.VisitCode()
+		code(methodVisitor, func(line int) {
+			label := &asm.Label{}
+			methodVisitor.VisitLabel(label)
+			methodVisitor.VisitLineNumber(line, label)
+		})
+		methodVisitor.VisitMaxs(2, 1)
+		methodVisitor.VisitEnd()
+	}
+	runnable := func(methodVisitor asm.MethodVisitor, implementation string) {
+		methodVisitor.VisitInvokeDynamicInsn("run", "()Ljava/lang/Runnable;", metafactory, asm.GetMethodType("()V"),
+			asm.NewHandle(opcodes.H_INVOKESTATIC, "p/C", implementation, "()V", false), asm.GetMethodType("()V"))
+		methodVisitor.VisitInsn(opcodes.POP)
+	}
+	method(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC, "main", "([Ljava/lang/String;)V", func(methodVisitor asm.MethodVisitor, line func(int)) {
+		line(10)
+		methodVisitor.VisitInsn(opcodes.ICONST_0)
+		methodVisitor.VisitInsn(opcodes.POP)
+		line(12)
+		runnable(methodVisitor, "lambda$main$0")
+		line(14)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+	})
+	method(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC|opcodes.ACC_SYNTHETIC, "lambda$main$0", "()V", func(methodVisitor asm.MethodVisitor, line func(int)) {
+		line(13)
+		runnable(methodVisitor, "lambda$main$1")
+		methodVisitor.VisitInsn(opcodes.RETURN)
+	})
+	method(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC|opcodes.ACC_SYNTHETIC, "lambda$main$1", "()V", func(methodVisitor asm.MethodVisitor, line func(int)) {
+		line(13)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+	})
+	method(opcodes.ACC_STATIC, "m", "()V", func(methodVisitor asm.MethodVisitor, line func(int)) {
+		methodVisitor.VisitInsn(opcodes.RETURN)
+	})
+	method(opcodes.ACC_STATIC, "m", "(I)V", func(methodVisitor asm.MethodVisitor, line func(int)) {
+		line(20)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+		methodVisitor.VisitVarInsn(opcodes.ISTORE, 0)
+		methodVisitor.VisitInsn(opcodes.NOP)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+	})
+	classWriter.VisitEnd()
+	classFile, err := classWriter.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return classFile
+}
+
+func TestSymbolizer(t *testing.T) {
+	// The class is added from a classpath directory.
+	classpath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(classpath, "p"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(classpath, "p", "C.class"), symbolizedClass(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	symbolizer := analysis.NewSymbolizer()
+	if err := symbolizer.AddPath(classpath); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, test := range []struct {
+		class, method, descriptor string
+		bci                       int
+	}{
+		{"p.C", "main", "([Ljava/lang/String;)V", 0},
+		{"p.C", "main", "([Ljava/lang/String;)V", 1},
+		{"p.C", "main", "([Ljava/lang/String;)V", 5},
+		{"p/C", "main", "([Ljava/lang/String;)V", 8},
+		{"p.C", "lambda$main$0", "()V", 0},
+		{"p.C", "lambda$main$1", "()V", 0},
+		// Without descriptor, the first method whose code contains the offset is used.
+		{"p.C", "m", "", 0},
+		{"p.C", "m", "", 2},
+		{"p.C", "m", "(I)V", 0},
+		{"p.C", "n", "", 0},
+		{"p.D", "m", "()V", 0},
+	} {
+		frame := symbolizer.Symbolize(test.class, test.method, test.descriptor, test.bci)
+		lines = append(lines, fmt.Sprintf("%s %s%s %v", frame, frame.Method, frame.Descriptor, frame.Resolved))
+	}
+	assertLines(t, lines, []string{
+		`p.C.main(C.java:10) main([Ljava/lang/String;)V true`,
+		`p.C.main(C.java:10) main([Ljava/lang/String;)V true`,
+		`p.C.main(C.java:12) main([Ljava/lang/String;)V true`,
+		`p.C.main(C.java:14) main([Ljava/lang/String;)V true`,
+		`p.C.lambda$main$0(C.java:13) [java/lang/Runnable.run lambda in main([Ljava/lang/String;)V at line 12] lambda$main$0()V true`,
+		`p.C.lambda$main$1(C.java:13) [java/lang/Runnable.run lambda in (java/lang/Runnable.run lambda in main([Ljava/lang/String;)V at line 12) at line 13] lambda$main$1()V true`,
+		`p.C.m(C.java) m()V true`,
+		`p.C.m(C.java:20) m(I)V true`,
+		`p.C.m(C.java:20) m(I)V true`,
+		`p.C.n(C.java) n false`,
+		`p.D.m(Unknown Source) m()V false`,
+	})
+}
@@ -0,0 +1,362 @@
+package analysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// TaintConfig the configuration of a {@link TaintAnalyzer}. Methods are matched with patterns of the form
+// "owner.name descriptor" (e.g. "java/lang/System.getenv (Ljava/lang/String;)Ljava/lang/String;"), where '*'
+// matches any sequence of characters. A pattern without descriptor matches all the overloads of the method.
+type TaintConfig struct {
+	// Sources the methods whose return value is tainted.
+	Sources []string
+	// Sinks the methods which must not receive a tainted argument (or receiver).
+	Sinks []string
+	// Sanitizers the methods whose return value is never tainted, whatever their arguments.
+	Sanitizers []string
+	// TaintParameters whether the parameters of the analyzed methods are tainted.
+	TaintParameters bool
+}
+
+// TaintSource the origin of a tainted value: a call to a source method, or a method parameter.
+type TaintSource struct {
+	// Insn the index of the source method call instruction, or -1 for a parameter.
+	Insn int
+	// Method the source method ("owner.name descriptor"), or "" for a parameter.
+	Method string
+	// Local the local variable index of the parameter, or -1 for a source method call.
+	Local int
+}
+
+func (t TaintSource) String() string {
+	if t.Insn < 0 {
+		return "parameter " + strconv.Itoa(t.Local)
+	}
+	return "#" + strconv.Itoa(t.Insn) + " " + t.Method
+}
+
+func (t TaintSource) less(source TaintSource) bool {
+	if t.Insn != source.Insn {
+		return t.Insn < source.Insn
+	}
+	return t.Local < source.Local
+}
+
+// TaintFlow a flow of tainted data from one or more sources to a sink argument.
+type TaintFlow struct {
+	// Sink the index of the sink method call instruction.
+	Sink int
+	// SinkMethod the sink method ("owner.name descriptor").
+	SinkMethod string
+	// Argument the index of the tainted argument, 0 being the receiver for instance methods.
+	Argument int
+	// Sources the origins of the tainted argument.
+	Sources []TaintSource
+}
+
+// String returns the path of this flow, from its sources to its sink.
+func (t TaintFlow) String() string {
+	sources := make([]string, len(t.Sources))
+	for i, source := range t.Sources {
+		sources[i] = source.String()
+	}
+	return "{" + strings.Join(sources, ", ") + "} -> #" + strconv.Itoa(t.Sink) + " " + t.SinkMethod +
+		" argument " + strconv.Itoa(t.Argument)
+}
+
+// TaintAnalyzer a taint analysis skeleton. It tracks the values derived from the configured sources through the
+// stack and the local variables, and reports the tainted values passed to the configured sinks. Tainted data
+// propagates through all the operations (arithmetic, array loads, method calls, etc), but not through the heap:
+// fields, array elements and objects mutated by a method call (e.g. a StringBuilder) are not tracked.
+type TaintAnalyzer struct {
+	Config TaintConfig
+}
+
+// NewTaintAnalyzer constructs a new {@link TaintAnalyzer}.
+func NewTaintAnalyzer(config TaintConfig) *TaintAnalyzer {
+	return &TaintAnalyzer{Config: config}
+}
+
+// Analyze analyzes the given method of the given class (given by its internal name), and returns the flows from
+// a source to a sink, in instruction order.
+func (t *TaintAnalyzer) Analyze(owner string, method *tree.MethodNode) ([]TaintFlow, error) {
+	interpreter := &taintInterpreter{config: &t.Config, indexes: make(map[tree.AbstractInsnNode]int)}
+	for i, insn := range method.Instructions {
+		interpreter.indexes[insn] = i
+	}
+	frames, err := NewAnalyzer[*TaintValue](interpreter).Analyze(owner, method)
+	if err != nil {
+		return nil, err
+	}
+	var flows []TaintFlow
+	for i, frame := range frames {
+		insn, ok := method.Instructions[i].(*tree.MethodInsnNode)
+		if frame == nil || !ok {
+			continue
+		}
+		if !matchesAny(t.Config.Sinks, insn) {
+			continue
+		}
+		numArguments := len(asm.GetMethodType(insn.Descriptor).GetArgumentTypes())
+		if insn.Opcode != opcodes.INVOKESTATIC {
+			numArguments++
+		}
+		first := frame.GetStackSize() - numArguments
+		for argument := 0; argument < numArguments; argument++ {
+			value := frame.GetStack(first + argument)
+			if len(value.sources) == 0 {
+				continue
+			}
+			flows = append(flows, TaintFlow{
+				Sink:       i,
+				SinkMethod: methodSignature(insn),
+				Argument:   argument,
+				Sources:    append([]TaintSource(nil), value.sources...),
+			})
+		}
+	}
+	return flows, nil
+}
+
+// TaintValue a {@link Value} used by the {@link TaintAnalyzer}, which tracks the sources of tainted values.
+type TaintValue struct {
+	size    int
+	sources []TaintSource
+}
+
+var (
+	uninitializedTaintValue = &TaintValue{size: 1}
+	cleanTaintValue         = &TaintValue{size: 1}
+	cleanLongTaintValue     = &TaintValue{size: 2}
+)
+
+func (t *TaintValue) GetSize() int {
+	return t.size
+}
+
+// IsTainted returns whether this value is derived from a source.
+func (t *TaintValue) IsTainted() bool {
+	return len(t.sources) > 0
+}
+
+// GetSources returns the sources of this value, in instruction order (parameters first).
+func (t *TaintValue) GetSources() []TaintSource {
+	return t.sources
+}
+
+func (t *TaintValue) String() string {
+	if t == uninitializedTaintValue {
+		return "."
+	}
+	if len(t.sources) == 0 {
+		return "-"
+	}
+	sources := make([]string, len(t.sources))
+	for i, source := range t.sources {
+		sources[i] = source.String()
+	}
+	return "T{" + strings.Join(sources, ", ") + "}"
+}
+
+// taintInterpreter the {@link Interpreter} of the {@link TaintAnalyzer}.
+type taintInterpreter struct {
+	config  *TaintConfig
+	indexes map[tree.AbstractInsnNode]int
+}
+
+func cleanTaintValueOf(t *asm.Type) *TaintValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.LONG, typed.DOUBLE:
+		return cleanLongTaintValue
+	default:
+		return cleanTaintValue
+	}
+}
+
+// taint returns a value of the given size whose sources are the union of the sources of the given values.
+func taint(size int, values ...*TaintValue) *TaintValue {
+	var sources []TaintSource
+	for _, value := range values {
+		sources = mergeTaintSources(sources, value.sources)
+	}
+	if len(sources) == 0 {
+		if size == 2 {
+			return cleanLongTaintValue
+		}
+		return cleanTaintValue
+	}
+	return &TaintValue{size: size, sources: sources}
+}
+
+func (t *taintInterpreter) NewValue(typ *asm.Type) *TaintValue {
+	if typ == nil {
+		return uninitializedTaintValue
+	}
+	return cleanTaintValueOf(typ)
+}
+
+func (t *taintInterpreter) NewParameterValue(isInstanceMethod bool, local int, typ *asm.Type) *TaintValue {
+	if !t.config.TaintParameters || (isInstanceMethod && local == 0) {
+		return cleanTaintValueOf(typ)
+	}
+	return &TaintValue{size: typ.GetSize(), sources: []TaintSource{{Insn: -1, Local: local}}}
+}
+
+func (t *taintInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *TaintValue {
+	return cleanTaintValue
+}
+
+func (t *taintInterpreter) NewOperation(insn tree.AbstractInsnNode) (*TaintValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LCONST_0, opcodes.LCONST_1, opcodes.DCONST_0, opcodes.DCONST_1:
+		return cleanLongTaintValue, nil
+	case opcodes.LDC:
+		switch insn.(*tree.LdcInsnNode).Value.(type) {
+		case int64, float64:
+			return cleanLongTaintValue, nil
+		}
+	case opcodes.GETSTATIC:
+		return cleanTaintValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	}
+	return cleanTaintValue, nil
+}
+
+func (t *taintInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *TaintValue) (*TaintValue, error) {
+	return value, nil
+}
+
+func (t *taintInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *TaintValue) (*TaintValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LNEG, opcodes.DNEG, opcodes.I2L, opcodes.I2D, opcodes.L2D, opcodes.F2L, opcodes.F2D, opcodes.D2L:
+		return taint(2, value), nil
+	case opcodes.GETFIELD:
+		return cleanTaintValueOf(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	}
+	return taint(1, value), nil
+}
+
+func (t *taintInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *TaintValue) (*TaintValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LALOAD, opcodes.DALOAD, opcodes.LADD, opcodes.DADD, opcodes.LSUB, opcodes.DSUB, opcodes.LMUL,
+		opcodes.DMUL, opcodes.LDIV, opcodes.DDIV, opcodes.LREM, opcodes.DREM, opcodes.LSHL, opcodes.LSHR,
+		opcodes.LUSHR, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		return taint(2, value1, value2), nil
+	}
+	return taint(1, value1, value2), nil
+}
+
+func (t *taintInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *TaintValue) (*TaintValue, error) {
+	return nil, nil
+}
+
+func (t *taintInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*TaintValue) (*TaintValue, error) {
+	var returnType *asm.Type
+	switch insn := insn.(type) {
+	case *tree.MethodInsnNode:
+		returnType = asm.GetMethodType(insn.Descriptor).GetReturnType()
+		if matchesAny(t.config.Sanitizers, insn) {
+			return cleanTaintValueOf(returnType), nil
+		}
+		if matchesAny(t.config.Sources, insn) {
+			if returnType.GetSort() == typed.VOID {
+				return nil, nil
+			}
+			source := TaintSource{Insn: t.indexes[insn], Method: methodSignature(insn), Local: -1}
+			return &TaintValue{size: returnType.GetSize(), sources: []TaintSource{source}}, nil
+		}
+	case *tree.InvokeDynamicInsnNode:
+		returnType = asm.GetMethodType(insn.Descriptor).GetReturnType()
+	default:
+		return cleanTaintValue, nil
+	}
+	if returnType.GetSort() == typed.VOID {
+		return nil, nil
+	}
+	return taint(returnType.GetSize(), values...), nil
+}
+
+func (t *taintInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *TaintValue) error {
+	return nil
+}
+
+func (t *taintInterpreter) Merge(value1, value2 *TaintValue) *TaintValue {
+	if value1 == value2 {
+		return value1
+	}
+	if value1 == nil || value2 == nil || value1.size != value2.size || value1 == uninitializedTaintValue ||
+		value2 == uninitializedTaintValue {
+		return uninitializedTaintValue
+	}
+	sources := mergeTaintSources(value1.sources, value2.sources)
+	if len(sources) == len(value1.sources) {
+		return value1
+	}
+	return &TaintValue{size: value1.size, sources: sources}
+}
+
+// mergeTaintSources returns the sorted union of the given sorted sets.
+func mergeTaintSources(set1, set2 []TaintSource) []TaintSource {
+	if len(set2) == 0 {
+		return set1
+	}
+	if len(set1) == 0 {
+		return set2
+	}
+	result := append([]TaintSource(nil), set1...)
+	for _, source := range set2 {
+		i := sort.Search(len(result), func(i int) bool { return !result[i].less(source) })
+		if i < len(result) && result[i] == source {
+			continue
+		}
+		result = append(result, TaintSource{})
+		copy(result[i+1:], result[i:])
+		result[i] = source
+	}
+	return result
+}
+
+// methodSignature returns the "owner.name descriptor" signature of the method called by the given instruction.
+func methodSignature(insn *tree.MethodInsnNode) string {
+	return insn.Owner + "." + insn.Name + " " + insn.Descriptor
+}
+
+// matchesAny returns whether the method called by the given instruction matches one of the given patterns.
+func matchesAny(patterns []string, insn *tree.MethodInsnNode) bool {
+	for _, pattern := range patterns {
+		if strings.IndexByte(pattern, ' ') < 0 {
+			if matchPattern(pattern, insn.Owner+"."+insn.Name) {
+				return true
+			}
+		} else if matchPattern(pattern, methodSignature(insn)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern returns whether the given string matches the given pattern, where '*' matches any sequence of
+// characters.
+func matchPattern(pattern, s string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, pattern[:star]) {
+		return false
+	}
+	for i := star; i <= len(s); i++ {
+		if matchPattern(pattern[star+1:], s[i:]) {
+			return true
+		}
+	}
+	return false
+}
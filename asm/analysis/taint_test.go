@@ -0,0 +1,91 @@
+package analysis_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// taintedMethod returns the method "static void m(String p, boolean b) { String env = System.getenv("HOME");
+// String s = env.trim(); Sink.exec(p, s); Sink.exec(Sanitizer.clean(s)); String t = b ? "constant" : s;
+// Sink.exec(t); }".
+func taintedMethod() *tree.MethodNode {
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "(Ljava/lang/String;Z)V", "", nil)
+	constant, join := &asm.Label{}, &asm.Label{}
+	method.VisitCode()
+	method.VisitLdcInsn("HOME")
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "java/lang/System", "getenv", "(Ljava/lang/String;)Ljava/lang/String;", false)
+	method.VisitVarInsn(opcodes.ASTORE, 2)
+	method.VisitVarInsn(opcodes.ALOAD, 2)
+	method.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/String", "trim", "()Ljava/lang/String;", false)
+	method.VisitVarInsn(opcodes.ASTORE, 3)
+	// The arguments are pushed in reverse order and swapped, to propagate them through the stack.
+	method.VisitVarInsn(opcodes.ALOAD, 3)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitInsn(opcodes.SWAP)
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/Sink", "exec", "(Ljava/lang/String;Ljava/lang/String;)V", false)
+	method.VisitVarInsn(opcodes.ALOAD, 3)
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/Sanitizer", "clean", "(Ljava/lang/String;)Ljava/lang/String;", false)
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/Sink", "exec", "(Ljava/lang/String;)V", false)
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	method.VisitJumpInsn(opcodes.IFEQ, constant)
+	method.VisitLdcInsn("constant")
+	method.VisitJumpInsn(opcodes.GOTO, join)
+	method.VisitLabel(constant)
+	method.VisitVarInsn(opcodes.ALOAD, 3)
+	method.VisitLabel(join)
+	method.VisitVarInsn(opcodes.ASTORE, 4)
+	method.VisitVarInsn(opcodes.ALOAD, 4)
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "p/Sink", "exec", "(Ljava/lang/String;)V", false)
+	method.VisitInsn(opcodes.RETURN)
+	method.VisitMaxs(2, 5)
+	method.VisitEnd()
+	return method
+}
+
+// taintFlows returns the flows found in the {@link taintedMethod} with the given config.
+func taintFlows(t *testing.T, config analysis.TaintConfig) []string {
+	flows, err := analysis.NewTaintAnalyzer(config).Analyze("C", taintedMethod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make([]string, len(flows))
+	for i, flow := range flows {
+		result[i] = flow.String()
+	}
+	return result
+}
+
+func TestTaintAnalyzer(t *testing.T) {
+	config := analysis.TaintConfig{
+		Sources:    []string{"java/lang/System.getenv"},
+		Sinks:      []string{"p/Sink.*"},
+		Sanitizers: []string{"p/Sanitizer.clean (Ljava/lang/String;)Ljava/lang/String;"},
+	}
+	// The getenv result flows through the locals, the trim call and the stack, and through the join point of the
+	// conditional, but not through the sanitizer.
+	assertLines(t, taintFlows(t, config), []string{
+		`{#1 java/lang/System.getenv (Ljava/lang/String;)Ljava/lang/String;} -> #9 p/Sink.exec (Ljava/lang/String;Ljava/lang/String;)V argument 1`,
+		`{#1 java/lang/System.getenv (Ljava/lang/String;)Ljava/lang/String;} -> #22 p/Sink.exec (Ljava/lang/String;)V argument 0`,
+	})
+
+	// With tainted parameters, p flows to the first argument of the first sink.
+	config.TaintParameters = true
+	assertLines(t, taintFlows(t, config), []string{
+		`{parameter 0} -> #9 p/Sink.exec (Ljava/lang/String;Ljava/lang/String;)V argument 0`,
+		`{#1 java/lang/System.getenv (Ljava/lang/String;)Ljava/lang/String;} -> #9 p/Sink.exec (Ljava/lang/String;Ljava/lang/String;)V argument 1`,
+		`{#1 java/lang/System.getenv (Ljava/lang/String;)Ljava/lang/String;} -> #22 p/Sink.exec (Ljava/lang/String;)V argument 0`,
+	})
+}
+
+// assertLines checks that the given lines are the expected ones.
+func assertLines(t *testing.T, lines, expected []string) {
+	t.Helper()
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
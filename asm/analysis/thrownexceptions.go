@@ -0,0 +1,401 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// knownSuperClasses the super classes of the common exceptions of the java.lang and java.io packages, used when
+// the {@link ExceptionAnalyzer} can't resolve them.
+var knownSuperClasses = map[string]string{
+	"java/lang/Throwable":                         "java/lang/Object",
+	"java/lang/Exception":                         "java/lang/Throwable",
+	"java/lang/Error":                             "java/lang/Throwable",
+	"java/lang/RuntimeException":                  "java/lang/Exception",
+	"java/lang/ReflectiveOperationException":      "java/lang/Exception",
+	"java/lang/ClassNotFoundException":            "java/lang/ReflectiveOperationException",
+	"java/lang/NoSuchMethodException":             "java/lang/ReflectiveOperationException",
+	"java/lang/NoSuchFieldException":              "java/lang/ReflectiveOperationException",
+	"java/lang/IllegalAccessException":            "java/lang/ReflectiveOperationException",
+	"java/lang/InstantiationException":            "java/lang/ReflectiveOperationException",
+	"java/lang/CloneNotSupportedException":        "java/lang/Exception",
+	"java/lang/InterruptedException":              "java/lang/Exception",
+	"java/io/IOException":                         "java/lang/Exception",
+	"java/io/FileNotFoundException":               "java/io/IOException",
+	"java/io/UncheckedIOException":                "java/lang/RuntimeException",
+	"java/lang/IllegalArgumentException":          "java/lang/RuntimeException",
+	"java/lang/IllegalStateException":             "java/lang/RuntimeException",
+	"java/lang/NullPointerException":              "java/lang/RuntimeException",
+	"java/lang/ClassCastException":                "java/lang/RuntimeException",
+	"java/lang/ArithmeticException":               "java/lang/RuntimeException",
+	"java/lang/IndexOutOfBoundsException":         "java/lang/RuntimeException",
+	"java/lang/ArrayIndexOutOfBoundsException":    "java/lang/IndexOutOfBoundsException",
+	"java/lang/UnsupportedOperationException":     "java/lang/RuntimeException",
+	"java/lang/NumberFormatException":             "java/lang/IllegalArgumentException",
+	"java/lang/AssertionError":                    "java/lang/Error",
+	"java/lang/LinkageError":                      "java/lang/Error",
+	"java/lang/VirtualMachineError":               "java/lang/Error",
+	"java/lang/OutOfMemoryError":                  "java/lang/VirtualMachineError",
+	"java/lang/StackOverflowError":                "java/lang/VirtualMachineError",
+	"java/lang/ExceptionInInitializerError":       "java/lang/LinkageError",
+	"java/lang/NoClassDefFoundError":              "java/lang/LinkageError",
+	"java/lang/IncompatibleClassChangeError":      "java/lang/LinkageError",
+	"java/lang/reflect/InvocationTargetException": "java/lang/ReflectiveOperationException",
+}
+
+// ThrownExceptions the result of an {@link ExceptionAnalyzer}. All the lists contain internal names, in
+// alphabetical order.
+type ThrownExceptions struct {
+	// Thrown the exceptions that can be thrown by the method, explicitly with ATHROW or by the methods it
+	// calls, and which are not caught by the method itself.
+	Thrown []string
+	// Undeclared the checked exceptions of Thrown which are not covered by the Exceptions attribute.
+	Undeclared []string
+	// OverDeclared the checked exceptions of the Exceptions attribute which can't be thrown.
+	OverDeclared []string
+}
+
+// ExceptionAnalyzer infers the exceptions that a method can actually throw, from the static types of the values
+// thrown with ATHROW and from the exceptions declared by the methods it calls, minus the exceptions caught by
+// its own try catch blocks. The exceptions rethrown from a handler are the ones the handler can actually catch
+// (as for the precise rethrow of the Java language). The result is compared with the Exceptions attribute of
+// the method to report undeclared and over-declared checked exceptions.
+//
+// Implicit exceptions (e.g. NullPointerException) are ignored. The classes whose super class can't be resolved
+// are considered as checked exceptions.
+type ExceptionAnalyzer struct {
+	// MethodExceptions if not nil, returns the exceptions declared by the given method, or nil if unknown.
+	MethodExceptions func(owner, name, descriptor string) []string
+	// SuperClass if not nil, returns the super class of the given class, or "" if unknown.
+	SuperClass func(internalName string) string
+}
+
+// Analyze analyzes the given method of the given class (given by its internal name).
+func (e *ExceptionAnalyzer) Analyze(owner string, method *tree.MethodNode) (*ThrownExceptions, error) {
+	analyzer := NewAnalyzer[*ExceptionValue](exceptionInterpreter{})
+	frames, err := analyzer.Analyze(owner, method)
+	if err != nil {
+		return nil, err
+	}
+
+	// The exceptions thrown by each instruction, before being routed to the handlers.
+	type throwSite struct {
+		insn     int
+		types    []string
+		handlers []*tree.TryCatchBlockNode
+	}
+	var throwSites []throwSite
+	for i, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		switch insn := method.Instructions[i].(type) {
+		case *tree.InsnNode:
+			if insn.Opcode == opcodes.ATHROW {
+				value := frame.GetStack(frame.GetStackSize() - 1)
+				if value != nil {
+					throwSites = append(throwSites, throwSite{i, value.types, value.handlers})
+				}
+			}
+		case *tree.MethodInsnNode:
+			if e.MethodExceptions != nil {
+				if exceptions := e.MethodExceptions(insn.Owner, insn.Name, insn.Descriptor); len(exceptions) > 0 {
+					throwSites = append(throwSites, throwSite{i, exceptions, nil})
+				}
+			}
+		}
+	}
+
+	// Routes the exceptions to the first matching handler, until a fixpoint is reached (the exceptions caught by
+	// a handler may be rethrown, and caught by another handler).
+	caught := make(map[*tree.TryCatchBlockNode]map[string]bool)
+	escaping := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, site := range throwSites {
+			types := append([]string(nil), site.types...)
+			for _, handler := range site.handlers {
+				for exception := range caught[handler] {
+					types = append(types, exception)
+				}
+			}
+			for _, exception := range types {
+				target := escaping
+				for _, tryCatchBlock := range analyzer.GetHandlers(site.insn) {
+					if tryCatchBlock.Type == "" || e.isSubclass(exception, tryCatchBlock.Type) == 1 {
+						if caught[tryCatchBlock] == nil {
+							caught[tryCatchBlock] = make(map[string]bool)
+						}
+						target = caught[tryCatchBlock]
+						break
+					}
+				}
+				if !target[exception] {
+					target[exception] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	result := &ThrownExceptions{}
+	for exception := range escaping {
+		result.Thrown = append(result.Thrown, exception)
+		if e.isChecked(exception) && !e.isCovered(exception, method.Exceptions) {
+			result.Undeclared = append(result.Undeclared, exception)
+		}
+	}
+	for _, declared := range method.Exceptions {
+		if !e.isChecked(declared) {
+			continue
+		}
+		used := false
+		for exception := range escaping {
+			if e.isSubclass(exception, declared) != 0 || e.isSubclass(declared, exception) == 1 {
+				used = true
+				break
+			}
+		}
+		if !used {
+			result.OverDeclared = append(result.OverDeclared, declared)
+		}
+	}
+	sort.Strings(result.Thrown)
+	sort.Strings(result.Undeclared)
+	sort.Strings(result.OverDeclared)
+	return result, nil
+}
+
+// isSubclass returns 1 if the given class is a subclass of (or is equal to) the given super class, 0 if it is
+// not, and -1 if this can't be determined.
+func (e *ExceptionAnalyzer) isSubclass(class, superClass string) int {
+	for class != "" {
+		if class == superClass {
+			return 1
+		}
+		if class == "java/lang/Object" {
+			return 0
+		}
+		next := ""
+		if e.SuperClass != nil {
+			next = e.SuperClass(class)
+		}
+		if next == "" {
+			next = knownSuperClasses[class]
+		}
+		class = next
+	}
+	return -1
+}
+
+func (e *ExceptionAnalyzer) isChecked(exception string) bool {
+	return e.isSubclass(exception, "java/lang/RuntimeException") != 1 && e.isSubclass(exception, "java/lang/Error") != 1
+}
+
+func (e *ExceptionAnalyzer) isCovered(exception string, declared []string) bool {
+	for _, declaredException := range declared {
+		if e.isSubclass(exception, declaredException) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ExceptionValue a {@link Value} used by the {@link ExceptionAnalyzer}, which tracks the possible static types of
+// references, and the handlers whose caught exception they may contain.
+type ExceptionValue struct {
+	size     int
+	types    []string
+	handlers []*tree.TryCatchBlockNode
+}
+
+var (
+	uninitializedExceptionValue = &ExceptionValue{size: 1}
+	intExceptionValue           = &ExceptionValue{size: 1}
+	longExceptionValue          = &ExceptionValue{size: 2}
+	referenceExceptionValue     = &ExceptionValue{size: 1}
+)
+
+func (e *ExceptionValue) GetSize() int {
+	return e.size
+}
+
+// GetTypes returns the possible static types of this value, as internal names.
+func (e *ExceptionValue) GetTypes() []string {
+	return e.types
+}
+
+func (e *ExceptionValue) String() string {
+	if e == uninitializedExceptionValue {
+		return "."
+	}
+	return "{" + strings.Join(e.types, ",") + "}"
+}
+
+// exceptionInterpreter the {@link Interpreter} of the {@link ExceptionAnalyzer}.
+type exceptionInterpreter struct{}
+
+func newTypeValue(t *asm.Type) *ExceptionValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.LONG, typed.DOUBLE:
+		return longExceptionValue
+	case typed.OBJECT, typed.ARRAY:
+		return &ExceptionValue{size: 1, types: []string{t.GetInternalName()}}
+	default:
+		return intExceptionValue
+	}
+}
+
+func (exceptionInterpreter) NewValue(t *asm.Type) *ExceptionValue {
+	if t == nil {
+		return uninitializedExceptionValue
+	}
+	return newTypeValue(t)
+}
+
+func (exceptionInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *ExceptionValue {
+	return &ExceptionValue{size: 1, handlers: []*tree.TryCatchBlockNode{tryCatchBlock}}
+}
+
+func (exceptionInterpreter) NewOperation(insn tree.AbstractInsnNode) (*ExceptionValue, error) {
+	switch insn := insn.(type) {
+	case *tree.InsnNode:
+		switch insn.Opcode {
+		case opcodes.ACONST_NULL:
+			return referenceExceptionValue, nil
+		case opcodes.LCONST_0, opcodes.LCONST_1, opcodes.DCONST_0, opcodes.DCONST_1:
+			return longExceptionValue, nil
+		}
+	case *tree.LdcInsnNode:
+		switch insn.Value.(type) {
+		case int64, float64:
+			return longExceptionValue, nil
+		case string, *asm.Type, *asm.Handle:
+			return referenceExceptionValue, nil
+		}
+	case *tree.FieldInsnNode:
+		return newTypeValue(asm.GetType(insn.Descriptor)), nil
+	case *tree.TypeInsnNode:
+		return newTypeValue(asm.GetObjectType(insn.Type)), nil
+	}
+	return intExceptionValue, nil
+}
+
+func (exceptionInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *ExceptionValue) (*ExceptionValue, error) {
+	return value, nil
+}
+
+func (exceptionInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *ExceptionValue) (*ExceptionValue, error) {
+	switch insn := insn.(type) {
+	case *tree.InsnNode:
+		switch insn.Opcode {
+		case opcodes.LNEG, opcodes.DNEG, opcodes.I2L, opcodes.I2D, opcodes.L2D, opcodes.F2L, opcodes.F2D, opcodes.D2L:
+			return longExceptionValue, nil
+		}
+	case *tree.FieldInsnNode:
+		return newTypeValue(asm.GetType(insn.Descriptor)), nil
+	case *tree.IntInsnNode:
+		if insn.Opcode == opcodes.NEWARRAY {
+			return referenceExceptionValue, nil
+		}
+	case *tree.TypeInsnNode:
+		switch insn.Opcode {
+		case opcodes.CHECKCAST:
+			return newTypeValue(asm.GetObjectType(insn.Type)), nil
+		case opcodes.ANEWARRAY:
+			elementType := asm.GetObjectType(insn.Type)
+			return newTypeValue(asm.GetType("[" + elementType.GetDescriptor())), nil
+		}
+	}
+	return intExceptionValue, nil
+}
+
+func (exceptionInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *ExceptionValue) (*ExceptionValue, error) {
+	switch insn.GetOpcode() {
+	case opcodes.LALOAD, opcodes.DALOAD, opcodes.LADD, opcodes.DADD, opcodes.LSUB, opcodes.DSUB, opcodes.LMUL,
+		opcodes.DMUL, opcodes.LDIV, opcodes.DDIV, opcodes.LREM, opcodes.DREM, opcodes.LSHL, opcodes.LSHR,
+		opcodes.LUSHR, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		return longExceptionValue, nil
+	case opcodes.AALOAD:
+		var types []string
+		for _, arrayType := range value1.types {
+			if strings.HasPrefix(arrayType, "[") {
+				elementType := asm.GetType(arrayType[1:])
+				if elementType.GetSort() == typed.OBJECT || elementType.GetSort() == typed.ARRAY {
+					types = append(types, elementType.GetInternalName())
+				}
+			}
+		}
+		return &ExceptionValue{size: 1, types: types}, nil
+	}
+	return intExceptionValue, nil
+}
+
+func (exceptionInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *ExceptionValue) (*ExceptionValue, error) {
+	return nil, nil
+}
+
+func (exceptionInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*ExceptionValue) (*ExceptionValue, error) {
+	switch insn := insn.(type) {
+	case *tree.MethodInsnNode:
+		return newTypeValue(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.InvokeDynamicInsnNode:
+		return newTypeValue(asm.GetMethodType(insn.Descriptor).GetReturnType()), nil
+	case *tree.MultiANewArrayInsnNode:
+		return newTypeValue(asm.GetType(insn.Descriptor)), nil
+	}
+	return nil, nil
+}
+
+func (exceptionInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *ExceptionValue) error {
+	return nil
+}
+
+func (exceptionInterpreter) Merge(value1, value2 *ExceptionValue) *ExceptionValue {
+	if value1 == value2 {
+		return value1
+	}
+	if value1 == nil || value2 == nil || value1.size != value2.size || value1 == uninitializedExceptionValue ||
+		value2 == uninitializedExceptionValue {
+		return uninitializedExceptionValue
+	}
+	types := mergeStrings(value1.types, value2.types)
+	handlers := value1.handlers
+	for _, handler := range value2.handlers {
+		found := false
+		for _, h := range handlers {
+			if h == handler {
+				found = true
+				break
+			}
+		}
+		if !found {
+			handlers = append(append([]*tree.TryCatchBlockNode(nil), handlers...), handler)
+		}
+	}
+	if len(types) == len(value1.types) && len(handlers) == len(value1.handlers) {
+		return value1
+	}
+	return &ExceptionValue{size: value1.size, types: types, handlers: handlers}
+}
+
+// mergeStrings returns the sorted union of the given sorted sets.
+func mergeStrings(set1, set2 []string) []string {
+	union := append([]string(nil), set1...)
+	for _, s := range set2 {
+		index := sort.SearchStrings(union, s)
+		if index == len(union) || union[index] != s {
+			union = append(union, "")
+			copy(union[index+1:], union[index:])
+			union[index] = s
+		}
+	}
+	return union
+}
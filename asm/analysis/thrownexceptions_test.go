@@ -0,0 +1,69 @@
+package analysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// newRethrowMethod returns the following method:
+//
+//	static void m() throws IOException, InterruptedException {
+//	  try { foo(); } catch (Exception e) { throw e; }
+//	  throw new IllegalStateException();
+//	}
+func newRethrowMethod() *tree.MethodNode {
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "()V", "", []string{"java/io/IOException", "java/lang/InterruptedException"})
+	start, end, handler, next := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	method.VisitCode()
+	method.VisitTryCatchBlock(start, end, handler, "java/lang/Exception")
+	method.VisitLabel(start)
+	method.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "foo", "()V", false)
+	method.VisitLabel(end)
+	method.VisitJumpInsn(opcodes.GOTO, next)
+	method.VisitLabel(handler)
+	method.VisitVarInsn(opcodes.ASTORE, 0)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitInsn(opcodes.ATHROW)
+	method.VisitLabel(next)
+	method.VisitTypeInsn(opcodes.NEW, "java/lang/IllegalStateException")
+	method.VisitInsn(opcodes.DUP)
+	method.VisitMethodInsnB(opcodes.INVOKESPECIAL, "java/lang/IllegalStateException", "<init>", "()V", false)
+	method.VisitInsn(opcodes.ATHROW)
+	method.VisitMaxs(2, 1)
+	method.VisitEnd()
+	return method
+}
+
+func TestExceptionAnalyzer(t *testing.T) {
+	analyzer := &analysis.ExceptionAnalyzer{
+		MethodExceptions: func(owner, name, descriptor string) []string {
+			if owner == "A" && name == "foo" {
+				return []string{"java/io/IOException", "java/sql/SQLException"}
+			}
+			return nil
+		},
+		SuperClass: func(internalName string) string {
+			if internalName == "java/sql/SQLException" {
+				return "java/lang/Exception"
+			}
+			return ""
+		},
+	}
+	result, err := analyzer.Analyze("A", newRethrowMethod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &analysis.ThrownExceptions{
+		Thrown:       []string{"java/io/IOException", "java/lang/IllegalStateException", "java/sql/SQLException"},
+		Undeclared:   []string{"java/sql/SQLException"},
+		OverDeclared: []string{"java/lang/InterruptedException"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Analyze() = %+v, expected %+v", result, expected)
+	}
+}
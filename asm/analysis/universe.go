@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/leaklessgfy/asm/asm"
+)
+
+// UNIVERSE_SHARDS the number of independently locked shards of a {@link Universe}, to limit the contention
+// between the analyses using it concurrently.
+const UNIVERSE_SHARDS = 16
+
+// Universe a table of interned types and descriptors, shared by the analyses of many methods (e.g. all the
+// methods of a jar) so that equal types are represented by a single object, which keeps the memory used by
+// the analysis results bounded. The types and slices returned by a universe are shared and must not be
+// modified. A universe can be used concurrently. Its hit and miss counts can be monitored with {@link Stats},
+// or published with expvar (a universe is an expvar.Var).
+type Universe struct {
+	shards [UNIVERSE_SHARDS]universeShard
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// universeShard the interned values of a {@link Universe} whose key hashes to the same shard.
+type universeShard struct {
+	mutex         sync.RWMutex
+	strings       map[string]string
+	types         map[string]*asm.Type
+	argumentTypes map[string][]*asm.Type
+}
+
+// UniverseStats the metrics of a {@link Universe}.
+type UniverseStats struct {
+	// Hits the number of lookups which found an interned value.
+	Hits uint64
+	// Misses the number of lookups which interned a new value.
+	Misses uint64
+	// Size the number of interned strings and types.
+	Size int
+}
+
+// NewUniverse constructs a new, empty {@link Universe}.
+func NewUniverse() *Universe {
+	universe := &Universe{}
+	for i := range universe.shards {
+		universe.shards[i] = universeShard{
+			strings:       make(map[string]string),
+			types:         make(map[string]*asm.Type),
+			argumentTypes: make(map[string][]*asm.Type),
+		}
+	}
+	return universe
+}
+
+// shard returns the shard of the given key.
+func (u *Universe) shard(key string) *universeShard {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return &u.shards[hash.Sum32()%UNIVERSE_SHARDS]
+}
+
+// lookup returns the value of the given key in the given map of the shard of the key, created with newValue
+// and interned if needed.
+func lookup[T any](u *Universe, key string, values func(shard *universeShard) map[string]T, newValue func() T) T {
+	shard := u.shard(key)
+	shard.mutex.RLock()
+	value, ok := values(shard)[key]
+	shard.mutex.RUnlock()
+	if ok {
+		u.hits.Add(1)
+		return value
+	}
+	// The value is created outside of the lock, since it may use the universe.
+	value = newValue()
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if interned, ok := values(shard)[key]; ok {
+		u.hits.Add(1)
+		return interned
+	}
+	u.misses.Add(1)
+	values(shard)[key] = value
+	return value
+}
+
+// Intern returns the interned string equal to the given one (e.g. an internal name or a descriptor).
+func (u *Universe) Intern(s string) string {
+	return lookup(u, s, func(shard *universeShard) map[string]string { return shard.strings }, func() string { return s })
+}
+
+// getType returns the interned type with the given descriptor, created with newType if needed.
+func (u *Universe) getType(descriptor string, newType func() *asm.Type) *asm.Type {
+	return lookup(u, descriptor, func(shard *universeShard) map[string]*asm.Type { return shard.types }, newType)
+}
+
+// GetType returns the interned {@link Type} corresponding to the given field or method descriptor.
+func (u *Universe) GetType(descriptor string) *asm.Type {
+	if len(descriptor) > 0 && descriptor[0] == '(' {
+		return u.GetMethodType(descriptor)
+	}
+	return u.getType(descriptor, func() *asm.Type { return asm.GetType(descriptor) })
+}
+
+// GetObjectType returns the interned {@link Type} corresponding to the given internal name.
+func (u *Universe) GetObjectType(internalName string) *asm.Type {
+	descriptor := internalName
+	if len(internalName) > 0 && internalName[0] != '[' {
+		descriptor = "L" + internalName + ";"
+	}
+	return u.getType(descriptor, func() *asm.Type { return asm.GetObjectType(internalName) })
+}
+
+// GetMethodType returns the interned {@link Type} corresponding to the given method descriptor.
+func (u *Universe) GetMethodType(methodDescriptor string) *asm.Type {
+	return u.getType(methodDescriptor, func() *asm.Type { return asm.GetMethodType(methodDescriptor) })
+}
+
+// GetArgumentTypes returns the interned argument types of the given method descriptor.
+func (u *Universe) GetArgumentTypes(methodDescriptor string) []*asm.Type {
+	return lookup(u, methodDescriptor, func(shard *universeShard) map[string][]*asm.Type { return shard.argumentTypes },
+		func() []*asm.Type {
+			argumentTypes := asm.GetMethodType(methodDescriptor).GetArgumentTypes()
+			for i, argumentType := range argumentTypes {
+				argumentTypes[i] = u.GetType(argumentType.GetDescriptor())
+			}
+			return argumentTypes
+		})
+}
+
+// GetReturnType returns the interned return type of the given method descriptor.
+func (u *Universe) GetReturnType(methodDescriptor string) *asm.Type {
+	return u.GetType(u.GetMethodType(methodDescriptor).GetReturnType().GetDescriptor())
+}
+
+// Size returns the number of interned strings and types.
+func (u *Universe) Size() int {
+	size := 0
+	for i := range u.shards {
+		shard := &u.shards[i]
+		shard.mutex.RLock()
+		size += len(shard.strings) + len(shard.types)
+		shard.mutex.RUnlock()
+	}
+	return size
+}
+
+// Stats returns the current metrics of this universe.
+func (u *Universe) Stats() UniverseStats {
+	return UniverseStats{Hits: u.hits.Load(), Misses: u.misses.Load(), Size: u.Size()}
+}
+
+// String returns the metrics of this universe as a JSON object, so that a universe can be published with
+// expvar.Publish.
+func (u *Universe) String() string {
+	stats := u.Stats()
+	return `{"hits": ` + strconv.FormatUint(stats.Hits, 10) + `, "misses": ` + strconv.FormatUint(stats.Misses, 10) +
+		`, "size": ` + strconv.Itoa(stats.Size) + `}`
+}
@@ -0,0 +1,117 @@
+package analysis_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+)
+
+func TestUniverseInternsTypes(t *testing.T) {
+	universe := analysis.NewUniverse()
+	objectType := universe.GetType("Lp/C;")
+	if objectType.GetInternalName() != "p/C" {
+		t.Errorf("unexpected type %v", objectType.GetDescriptor())
+	}
+	// Equal types are represented by the same object, whatever the method used to get them.
+	if universe.GetType("Lp/C;") != objectType || universe.GetObjectType("p/C") != objectType {
+		t.Error("the object type is not interned")
+	}
+	arrayType := universe.GetObjectType("[Lp/C;")
+	if arrayType != universe.GetType("[Lp/C;") || arrayType.GetDescriptor() != "[Lp/C;" {
+		t.Error("the array type is not interned")
+	}
+	methodType := universe.GetType("(ILp/C;)Lp/C;")
+	if methodType != universe.GetMethodType("(ILp/C;)Lp/C;") || len(methodType.GetArgumentTypes()) != 2 {
+		t.Error("the method type is not interned")
+	}
+	if universe.GetType("I") != universe.GetType("I") {
+		t.Error("the primitive type is not interned")
+	}
+}
+
+func TestUniverseInternsArgumentAndReturnTypes(t *testing.T) {
+	universe := analysis.NewUniverse()
+	objectType := universe.GetObjectType("p/C")
+	argumentTypes := universe.GetArgumentTypes("(ILp/C;[J)Lp/C;")
+	if len(argumentTypes) != 3 || argumentTypes[0] != universe.GetType("I") || argumentTypes[1] != objectType ||
+		argumentTypes[2] != universe.GetType("[J") {
+		t.Errorf("unexpected argument types %v", argumentTypes)
+	}
+	// The argument types of a descriptor are returned as a single shared slice.
+	if &universe.GetArgumentTypes("(ILp/C;[J)Lp/C;")[0] != &argumentTypes[0] {
+		t.Error("the argument types are not interned")
+	}
+	if len(universe.GetArgumentTypes("()V")) != 0 {
+		t.Error("unexpected argument types")
+	}
+	if universe.GetReturnType("(ILp/C;[J)Lp/C;") != objectType || universe.GetReturnType("()V") != universe.GetType("V") {
+		t.Error("the return type is not interned")
+	}
+}
+
+func TestUniverseInternsStrings(t *testing.T) {
+	universe := analysis.NewUniverse()
+	name := universe.Intern(string([]byte("p/C")))
+	// The interned string shares the bytes of the first one.
+	if interned := universe.Intern(string([]byte("p/C"))); interned != "p/C" || unsafe.StringData(interned) != unsafe.StringData(name) {
+		t.Errorf("the string %q is not interned", interned)
+	}
+	// Strings and types are interned separately: "p/C" and "Lp/C;".
+	universe.GetObjectType("p/C")
+	if size := universe.Size(); size != 2 {
+		t.Errorf("unexpected size %d", size)
+	}
+}
+
+func TestUniverseStats(t *testing.T) {
+	universe := analysis.NewUniverse()
+	universe.GetType("I")
+	universe.GetType("I")
+	universe.GetObjectType("p/C")
+	// The argument types are interned in their own table, and their elements are looked up too.
+	universe.GetArgumentTypes("(I)V")
+	universe.Intern("p/C")
+	stats := universe.Stats()
+	if stats != (analysis.UniverseStats{Hits: 2, Misses: 4, Size: 3}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if s := universe.String(); s != `{"hits": 2, "misses": 4, "size": 3}` {
+		t.Errorf("unexpected metrics %s", s)
+	}
+}
+
+func TestUniverseConcurrentLookups(t *testing.T) {
+	const goroutines, names = 8, 100
+	universe := analysis.NewUniverse()
+	results := make([][]*asm.Type, goroutines)
+	var group sync.WaitGroup
+	for i := range results {
+		group.Add(1)
+		go func(i int) {
+			defer group.Done()
+			for j := 0; j < names; j++ {
+				// Each goroutine looks up the names in a different order.
+				results[i] = append(results[i], universe.GetObjectType("p/C"+strconv.Itoa((i*7+j)%names)))
+			}
+		}(i)
+	}
+	group.Wait()
+	for i, types := range results {
+		for j, objectType := range types {
+			name := "p/C" + strconv.Itoa((i*7+j)%names)
+			if objectType.GetInternalName() != name || objectType != universe.GetObjectType(name) {
+				t.Fatalf("the type %s is not interned", name)
+			}
+		}
+	}
+	// Each type is interned once, even when concurrent lookups miss it at the same time. The checks above add
+	// one hit per lookup.
+	stats := universe.Stats()
+	if stats != (analysis.UniverseStats{Hits: 2*goroutines*names - names, Misses: names, Size: names}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
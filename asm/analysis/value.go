@@ -0,0 +1,11 @@
+package analysis
+
+// Value an immutable symbolic value for the semantic interpretation of bytecode (see {@link Interpreter}).
+// Values must be comparable with ==, since this is how the {@link Analyzer} detects that the merge of two
+// frames changed something: interpreters must return the same value when nothing changes.
+type Value interface {
+	comparable
+	// GetSize returns the size of this value in 32 bits words, i.e. 2 for long and double values, and 1
+	// otherwise.
+	GetSize() int
+}
@@ -0,0 +1,416 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// VerifyError a method of a class which does not pass the verification of {@link VerifyClass}, with the
+// location and the cause of the problem.
+type VerifyError struct {
+	// Class the internal name of the class.
+	Class string
+	// Method the name and descriptor of the method.
+	Method string
+	// Insn the index of the offending instruction in the method, or -1 if the problem is not specific to an
+	// instruction.
+	Insn int
+	// Line the source line number of the offending instruction, or -1 if unknown.
+	Line int
+	// Offset the bytecode offset of the offending instruction, or -1 if unknown. It is only known for the methods
+	// verified by {@link VerifyOutput}, whose bytecode is available.
+	Offset int
+	// Expected the expected type of the offending value, for type errors, or "".
+	Expected string
+	// Found the actual type of the offending value, for type errors, or "".
+	Found   string
+	Message string
+}
+
+func (v *VerifyError) Error() string {
+	s := v.Class + "." + v.Method
+	if v.Line >= 0 {
+		s += " (line " + strconv.Itoa(v.Line) + ")"
+	}
+	if v.Offset >= 0 {
+		s += " (offset " + strconv.Itoa(v.Offset) + ")"
+	}
+	return s + ": " + v.Message
+}
+
+// VerifyMethod checks that the instructions of the given method of the given class (given by its internal
+// name) are type consistent, i.e. that each instruction finds values of the expected types (int, float, long,
+// double or reference) in the stack and in the local variables, without exceeding the maximum stack size and
+// number of locals of the method. The assignability of reference types is not checked. Returns nil or a
+// {@link VerifyError}.
+func VerifyMethod(owner string, method *tree.MethodNode) *VerifyError {
+	_, err := NewAnalyzer[*verifierValue](verifierInterpreter{}).Analyze(owner, method)
+	if err == nil {
+		return nil
+	}
+	verifyError := &VerifyError{Class: owner, Method: method.Name + method.Descriptor, Insn: -1, Line: -1,
+		Offset: -1, Message: err.Error()}
+	if analyzerError, ok := err.(*AnalyzerError); ok {
+		verifyError.Expected, verifyError.Found = analyzerError.Expected, analyzerError.Found
+		for i, insn := range method.Instructions {
+			if analyzerError.Node != nil && insn == analyzerError.Node {
+				verifyError.Insn = i
+			}
+		}
+		for i := verifyError.Insn; i >= 0; i-- {
+			if lineNumber, ok := method.Instructions[i].(*tree.LineNumberNode); ok {
+				verifyError.Line = lineNumber.Line
+				break
+			}
+		}
+	}
+	return verifyError
+}
+
+// VerifyClass checks the methods of the given class with {@link VerifyMethod}, and returns their errors.
+func VerifyClass(class *tree.ClassNode) []*VerifyError {
+	var verifyErrors []*VerifyError
+	for _, method := range class.Methods {
+		if verifyError := VerifyMethod(class.Name, method); verifyError != nil {
+			verifyErrors = append(verifyErrors, verifyError)
+		}
+	}
+	return verifyErrors
+}
+
+// VerifyOutput is the validation gate of the class generation pipelines: it parses and verifies the given
+// generated or transformed class file, and returns it unchanged if it is valid (see the Verify options of
+// {@link asm.AddMemberOptions} and {@link commons.TransformJarOptions}). Otherwise, it returns the
+// {@link ParseError} of a malformed class file, or an error joining the {@link VerifyError}s of the invalid
+// methods, with the bytecode offsets of their offending instructions, so that broken bytecode is reported when
+// it is produced, instead of with a VerifyError when the class is loaded by the JVM.
+func VerifyOutput(classFile []byte) (_ []byte, err error) {
+	defer asm.RecoverParseError(&err)
+	class, err := tree.ReadClassNode(classFile, asm.SKIP_FRAMES)
+	if err != nil {
+		return nil, err
+	}
+	verifyErrors := VerifyClass(class)
+	if len(verifyErrors) == 0 {
+		return classFile, nil
+	}
+	insnOffsets, err := instructionOffsets(classFile)
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(verifyErrors))
+	for i, verifyError := range verifyErrors {
+		if verifyError.Insn >= 0 {
+			for _, method := range class.Methods {
+				if method.Name+method.Descriptor == verifyError.Method {
+					verifyError.Offset = instructionOffset(method, verifyError.Insn, insnOffsets[verifyError.Method])
+				}
+			}
+		}
+		errs[i] = verifyError
+	}
+	return nil, errors.Join(errs...)
+}
+
+// instructionOffset returns the bytecode offset of the instruction of the given method at the given index, from
+// the bytecode offsets of its real instructions (i.e. excluding its labels, line numbers and frames), or -1 if
+// the instruction is not a real instruction.
+func instructionOffset(method *tree.MethodNode, insnIndex int, insnOffsets []int) int {
+	if method.Instructions[insnIndex].GetOpcode() < 0 {
+		return -1
+	}
+	realInsnIndex := 0
+	for _, insn := range method.Instructions[:insnIndex] {
+		if insn.GetOpcode() >= 0 {
+			realInsnIndex++
+		}
+	}
+	if realInsnIndex >= len(insnOffsets) {
+		return -1
+	}
+	return insnOffsets[realInsnIndex]
+}
+
+// instructionOffsets returns the bytecode offsets of the instructions of the methods of the given class file, by
+// method name and descriptor.
+func instructionOffsets(classFile []byte) (map[string][]int, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	insnOffsets := make(map[string][]int)
+	for _, method := range reader.Index().Methods {
+		codeAttribute := method.GetAttribute("Code")
+		if codeAttribute == nil {
+			continue
+		}
+		// The code array follows the max_stack, max_locals and code_length fields.
+		codeStart := codeAttribute.Start + 14
+		code := classFile[codeStart : codeStart+int(binary.BigEndian.Uint32(classFile[codeStart-4:]))]
+		var offsets []int
+		for offset := 0; offset < len(code); {
+			offsets = append(offsets, offset)
+			size, err := raw.InstructionSize(code, offset)
+			if err != nil {
+				return nil, err
+			}
+			offset += size
+		}
+		insnOffsets[method.Name+method.Descriptor] = offsets
+	}
+	return insnOffsets, nil
+}
+
+// verifierValue a {@link Value} used by {@link VerifyMethod}, which only distinguishes the verification types
+// of the values.
+type verifierValue struct {
+	name string
+	size int
+}
+
+var (
+	uninitializedVerifierValue = &verifierValue{"uninitialized", 1}
+	intVerifierValue           = &verifierValue{"int", 1}
+	floatVerifierValue         = &verifierValue{"float", 1}
+	longVerifierValue          = &verifierValue{"long", 2}
+	doubleVerifierValue        = &verifierValue{"double", 2}
+	referenceVerifierValue     = &verifierValue{"reference", 1}
+)
+
+func (v *verifierValue) GetSize() int {
+	return v.size
+}
+
+func newVerifierValue(t *asm.Type) *verifierValue {
+	switch t.GetSort() {
+	case typed.VOID:
+		return nil
+	case typed.BOOLEAN, typed.CHAR, typed.BYTE, typed.SHORT, typed.INT:
+		return intVerifierValue
+	case typed.FLOAT:
+		return floatVerifierValue
+	case typed.LONG:
+		return longVerifierValue
+	case typed.DOUBLE:
+		return doubleVerifierValue
+	}
+	return referenceVerifierValue
+}
+
+// checkValue returns an error if the given value, used by the given instruction, is not the expected one.
+func checkValue(insn tree.AbstractInsnNode, value, expected *verifierValue, argument string) error {
+	if value != expected {
+		return NewAnalyzerTypeError(insn, argument, expected.name, value.name)
+	}
+	return nil
+}
+
+// verifierInterpreter the {@link Interpreter} of {@link VerifyMethod}.
+type verifierInterpreter struct{}
+
+func (v verifierInterpreter) NewValue(t *asm.Type) *verifierValue {
+	if t == nil {
+		return uninitializedVerifierValue
+	}
+	return newVerifierValue(t)
+}
+
+func (v verifierInterpreter) NewExceptionValue(tryCatchBlock *tree.TryCatchBlockNode, exceptionType *asm.Type) *verifierValue {
+	return referenceVerifierValue
+}
+
+func (v verifierInterpreter) NewOperation(insn tree.AbstractInsnNode) (*verifierValue, error) {
+	switch opcode := insn.GetOpcode(); {
+	case opcode >= opcodes.ICONST_M1 && opcode <= opcodes.ICONST_5, opcode == opcodes.BIPUSH, opcode == opcodes.SIPUSH:
+		return intVerifierValue, nil
+	case opcode == opcodes.LCONST_0 || opcode == opcodes.LCONST_1:
+		return longVerifierValue, nil
+	case opcode >= opcodes.FCONST_0 && opcode <= opcodes.FCONST_2:
+		return floatVerifierValue, nil
+	case opcode == opcodes.DCONST_0 || opcode == opcodes.DCONST_1:
+		return doubleVerifierValue, nil
+	case opcode == opcodes.GETSTATIC:
+		return newVerifierValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcode == opcodes.LDC:
+		switch insn.(*tree.LdcInsnNode).Value.(type) {
+		case int:
+			return intVerifierValue, nil
+		case float32:
+			return floatVerifierValue, nil
+		case int64:
+			return longVerifierValue, nil
+		case float64:
+			return doubleVerifierValue, nil
+		case string, *asm.Type, *asm.Handle:
+			return referenceVerifierValue, nil
+		}
+		return nil, NewAnalyzerError(insn, "Illegal LDC value")
+	}
+	return referenceVerifierValue, nil
+}
+
+func (v verifierInterpreter) CopyOperation(insn tree.AbstractInsnNode, value *verifierValue) (*verifierValue, error) {
+	var expected *verifierValue
+	switch insn.GetOpcode() {
+	case opcodes.ILOAD, opcodes.ISTORE:
+		expected = intVerifierValue
+	case opcodes.LLOAD, opcodes.LSTORE:
+		expected = longVerifierValue
+	case opcodes.FLOAD, opcodes.FSTORE:
+		expected = floatVerifierValue
+	case opcodes.DLOAD, opcodes.DSTORE:
+		expected = doubleVerifierValue
+	case opcodes.ALOAD, opcodes.ASTORE:
+		expected = referenceVerifierValue
+	default:
+		return value, nil
+	}
+	return value, checkValue(insn, value, expected, "Value")
+}
+
+func (v verifierInterpreter) UnaryOperation(insn tree.AbstractInsnNode, value *verifierValue) (*verifierValue, error) {
+	var expected *verifierValue
+	switch opcode := insn.GetOpcode(); opcode {
+	case opcodes.INEG, opcodes.IINC, opcodes.I2L, opcodes.I2F, opcodes.I2D, opcodes.I2B, opcodes.I2C, opcodes.I2S,
+		opcodes.IFEQ, opcodes.IFNE, opcodes.IFLT, opcodes.IFGE, opcodes.IFGT, opcodes.IFLE, opcodes.TABLESWITCH,
+		opcodes.LOOKUPSWITCH, opcodes.IRETURN, opcodes.NEWARRAY, opcodes.ANEWARRAY:
+		expected = intVerifierValue
+	case opcodes.FNEG, opcodes.F2I, opcodes.F2L, opcodes.F2D, opcodes.FRETURN:
+		expected = floatVerifierValue
+	case opcodes.LNEG, opcodes.L2I, opcodes.L2F, opcodes.L2D, opcodes.LRETURN:
+		expected = longVerifierValue
+	case opcodes.DNEG, opcodes.D2I, opcodes.D2F, opcodes.D2L, opcodes.DRETURN:
+		expected = doubleVerifierValue
+	case opcodes.PUTSTATIC:
+		expected = newVerifierValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor))
+	default:
+		// GETFIELD, ARRAYLENGTH, CHECKCAST, INSTANCEOF, ARETURN, ATHROW, MONITORENTER, MONITOREXIT, IFNULL and
+		// IFNONNULL.
+		expected = referenceVerifierValue
+	}
+	if err := checkValue(insn, value, expected, "Argument"); err != nil {
+		return nil, err
+	}
+	switch opcode := insn.GetOpcode(); opcode {
+	case opcodes.INEG, opcodes.IINC, opcodes.L2I, opcodes.F2I, opcodes.D2I, opcodes.I2B, opcodes.I2C, opcodes.I2S,
+		opcodes.ARRAYLENGTH, opcodes.INSTANCEOF:
+		return intVerifierValue, nil
+	case opcodes.LNEG, opcodes.I2L, opcodes.F2L, opcodes.D2L:
+		return longVerifierValue, nil
+	case opcodes.FNEG, opcodes.I2F, opcodes.L2F, opcodes.D2F:
+		return floatVerifierValue, nil
+	case opcodes.DNEG, opcodes.I2D, opcodes.L2D, opcodes.F2D:
+		return doubleVerifierValue, nil
+	case opcodes.GETFIELD:
+		return newVerifierValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor)), nil
+	case opcodes.NEWARRAY, opcodes.ANEWARRAY, opcodes.CHECKCAST:
+		return referenceVerifierValue, nil
+	}
+	return nil, nil
+}
+
+// arrayElementValues the values of the elements of the arrays loaded by IALOAD to SALOAD, and stored by
+// IASTORE to SASTORE.
+var arrayElementValues = []*verifierValue{intVerifierValue, longVerifierValue, floatVerifierValue,
+	doubleVerifierValue, referenceVerifierValue, intVerifierValue, intVerifierValue, intVerifierValue}
+
+// arithmeticValues the values of the operands of IADD to DREM, in the order of the opcodes.
+var arithmeticValues = []*verifierValue{intVerifierValue, longVerifierValue, floatVerifierValue, doubleVerifierValue}
+
+func (v verifierInterpreter) BinaryOperation(insn tree.AbstractInsnNode, value1, value2 *verifierValue) (*verifierValue, error) {
+	var expected1, expected2, result *verifierValue
+	switch opcode := insn.GetOpcode(); {
+	case opcode >= opcodes.IALOAD && opcode <= opcodes.SALOAD:
+		expected1, expected2, result = referenceVerifierValue, intVerifierValue, arrayElementValues[opcode-opcodes.IALOAD]
+	case opcode >= opcodes.IADD && opcode <= opcodes.DREM:
+		expected1 = arithmeticValues[(opcode-opcodes.IADD)%4]
+		expected2, result = expected1, expected1
+	case opcode >= opcodes.ISHL && opcode <= opcodes.LUSHR:
+		expected1 = arithmeticValues[(opcode-opcodes.ISHL)%2]
+		expected2, result = intVerifierValue, expected1
+	case opcode >= opcodes.IAND && opcode <= opcodes.LXOR:
+		expected1 = arithmeticValues[(opcode-opcodes.IAND)%2]
+		expected2, result = expected1, expected1
+	case opcode == opcodes.LCMP:
+		expected1, expected2, result = longVerifierValue, longVerifierValue, intVerifierValue
+	case opcode == opcodes.FCMPL || opcode == opcodes.FCMPG:
+		expected1, expected2, result = floatVerifierValue, floatVerifierValue, intVerifierValue
+	case opcode == opcodes.DCMPL || opcode == opcodes.DCMPG:
+		expected1, expected2, result = doubleVerifierValue, doubleVerifierValue, intVerifierValue
+	case opcode >= opcodes.IF_ICMPEQ && opcode <= opcodes.IF_ICMPLE:
+		expected1, expected2 = intVerifierValue, intVerifierValue
+	case opcode == opcodes.IF_ACMPEQ || opcode == opcodes.IF_ACMPNE:
+		expected1, expected2 = referenceVerifierValue, referenceVerifierValue
+	case opcode == opcodes.PUTFIELD:
+		expected1 = referenceVerifierValue
+		expected2 = newVerifierValue(asm.GetType(insn.(*tree.FieldInsnNode).Descriptor))
+	}
+	if err := checkValue(insn, value1, expected1, "First argument"); err != nil {
+		return nil, err
+	}
+	if err := checkValue(insn, value2, expected2, "Second argument"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (v verifierInterpreter) TernaryOperation(insn tree.AbstractInsnNode, value1, value2, value3 *verifierValue) (*verifierValue, error) {
+	if err := checkValue(insn, value1, referenceVerifierValue, "First argument"); err != nil {
+		return nil, err
+	}
+	if err := checkValue(insn, value2, intVerifierValue, "Second argument"); err != nil {
+		return nil, err
+	}
+	return nil, checkValue(insn, value3, arrayElementValues[insn.GetOpcode()-opcodes.IASTORE], "Third argument")
+}
+
+func (v verifierInterpreter) NaryOperation(insn tree.AbstractInsnNode, values []*verifierValue) (*verifierValue, error) {
+	var descriptor string
+	switch insn := insn.(type) {
+	case *tree.MultiANewArrayInsnNode:
+		for i, value := range values {
+			if err := checkValue(insn, value, intVerifierValue, "Argument "+strconv.Itoa(i+1)); err != nil {
+				return nil, err
+			}
+		}
+		return referenceVerifierValue, nil
+	case *tree.MethodInsnNode:
+		descriptor = insn.Descriptor
+		if insn.Opcode != opcodes.INVOKESTATIC {
+			if err := checkValue(insn, values[0], referenceVerifierValue, "Method owner"); err != nil {
+				return nil, err
+			}
+			values = values[1:]
+		}
+	case *tree.InvokeDynamicInsnNode:
+		descriptor = insn.Descriptor
+	}
+	methodType := asm.GetMethodType(descriptor)
+	for i, argumentType := range methodType.GetArgumentTypes() {
+		if err := checkValue(insn, values[i], newVerifierValue(argumentType), "Argument "+strconv.Itoa(i+1)); err != nil {
+			return nil, err
+		}
+	}
+	return newVerifierValue(methodType.GetReturnType()), nil
+}
+
+func (v verifierInterpreter) ReturnOperation(insn tree.AbstractInsnNode, value, expected *verifierValue) error {
+	if expected == nil {
+		return NewAnalyzerError(insn, "Incompatible return type")
+	}
+	return checkValue(insn, value, expected, "Incompatible return type")
+}
+
+func (v verifierInterpreter) Merge(value1, value2 *verifierValue) *verifierValue {
+	if value1 != value2 {
+		return uninitializedVerifierValue
+	}
+	return value1
+}
@@ -0,0 +1,101 @@
+package analysis_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// singleMethodClass returns a class A with a static method m()V, whose code is visited by the given function.
+func singleMethodClass(code func(methodVisitor asm.MethodVisitor)) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	writer := classFile.AddMethod(opcodes.ACC_STATIC, "m", "()V", "", nil)
+	writer.VisitCode()
+	code(writer)
+	writer.VisitEnd()
+	return classFile.Bytes()
+}
+
+func TestVerifyOutputOffset(t *testing.T) {
+	classFile := singleMethodClass(func(methodVisitor asm.MethodVisitor) {
+		start := &asm.Label{}
+		methodVisitor.VisitLabel(start)
+		methodVisitor.VisitLineNumber(7, start)
+		methodVisitor.VisitIntInsn(opcodes.SIPUSH, 300)
+		methodVisitor.VisitInsn(opcodes.FNEG)
+		methodVisitor.VisitInsn(opcodes.POP)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(1, 0)
+	})
+	_, err := analysis.VerifyOutput(classFile)
+	var verifyError *analysis.VerifyError
+	if !errors.As(err, &verifyError) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if verifyError.Insn != 3 || verifyError.Offset != 3 || verifyError.Line != 7 {
+		t.Errorf("unexpected location of %v: insn %d, offset %d", verifyError, verifyError.Insn, verifyError.Offset)
+	}
+	if expected := "A.m()V (line 7) (offset 3): "; err.Error()[:len(expected)] != expected {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestVerifyOutputMalformedClass(t *testing.T) {
+	classFile := singleMethodClass(func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if _, err := analysis.VerifyOutput(classFile); err != nil {
+		t.Fatal(err)
+	}
+	// Replaces the RETURN instruction, followed by the exception table length and the attributes count of the Code
+	// attribute, and by the attributes count of the class.
+	classFile[len(classFile)-7] = 0xFE
+	_, err := analysis.VerifyOutput(classFile)
+	var parseError *asm.ParseError
+	if !errors.As(err, &parseError) || parseError.Kind != asm.ErrUnknownOpcode {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestVerifyOutputAddMember(t *testing.T) {
+	classFile := singleMethodClass(func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	options := asm.AddMemberOptions{Verify: analysis.VerifyOutput}
+	classFile, err := asm.AddFieldWithOptions(classFile, opcodes.ACC_STATIC, "f", "I", "", nil, nil, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classFile, err = asm.AddMethodWithOptions(classFile, opcodes.ACC_STATIC, "get", "()I", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitCode()
+		methodVisitor.VisitFieldInsn(opcodes.GETSTATIC, "A", "f", "I")
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The method is valid for the frames computation, but passes an int instead of a String.
+	invalidMethod := func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitCode()
+		methodVisitor.VisitInsn(opcodes.ICONST_0)
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, "A", "print", "(Ljava/lang/String;)V", false)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	}
+	if _, err := asm.AddMethod(classFile, opcodes.ACC_STATIC, "invalid", "()V", invalidMethod); err != nil {
+		t.Fatal(err)
+	}
+	_, err = asm.AddMethodWithOptions(classFile, opcodes.ACC_STATIC, "invalid", "()V", invalidMethod, options)
+	var verifyError *analysis.VerifyError
+	if !errors.As(err, &verifyError) || verifyError.Method != "invalid()V" || verifyError.Offset != 1 {
+		t.Errorf("unexpected error %v", err)
+	}
+}
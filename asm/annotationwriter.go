@@ -0,0 +1,331 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AnnotationWriter an {@link AnnotationVisitor} that generates a corresponding 'annotation' or 'type_annotation'
+// structure, as defined in the Java Virtual Machine Specification (JVMS), with the constants it refers to added to
+// a {@link SymbolTable}. The annotation writers of a program element are chained, with previousAnnotation and
+// nextAnnotation, to generate the RuntimeVisible/InvisibleAnnotations and RuntimeVisible/InvisibleTypeAnnotations
+// attributes.
+//
+// The values are written with the element value tag corresponding to their Go type, as read by the
+// {@link ClassReader}: byte (B), rune i.e. int32 (C), int16 (S), bool (Z), int (I), int64 (J), float32 (F), float64
+// (D), string (s), *Type (c) and the slices of these primitive types (as arrays). The values of other types are
+// recorded as errors in the symbol table (see {@link SymbolTable#GetError}).
+type AnnotationWriter struct {
+	symbolTable *SymbolTable
+	// useNamedValues whether the values of this annotation are named, i.e. whether it is not an array value.
+	useNamedValues bool
+	// annotation the 'annotation' or 'type_annotation' structure, or the array element_value, being written. The
+	// nested annotations and arrays are written in the vector of their enclosing annotation.
+	annotation *ByteVector
+	// numElementValuePairsOffset the offset of the num_element_value_pairs (or num_values) field in annotation,
+	// or -1 for an annotation default value, which has no such field.
+	numElementValuePairsOffset int
+	numElementValuePairs       int
+	previousAnnotation         *AnnotationWriter
+	nextAnnotation             *AnnotationWriter
+}
+
+// newAnnotationWriter constructs a new {@link AnnotationWriter} writing in the given vector, whose last two bytes
+// are the num_element_value_pairs or num_values field of the annotation, if it is not empty.
+func newAnnotationWriter(symbolTable *SymbolTable, useNamedValues bool, annotation *ByteVector, previousAnnotation *AnnotationWriter) *AnnotationWriter {
+	a := &AnnotationWriter{
+		symbolTable:                symbolTable,
+		useNamedValues:             useNamedValues,
+		annotation:                 annotation,
+		numElementValuePairsOffset: annotation.Size() - 2,
+		previousAnnotation:         previousAnnotation,
+	}
+	if annotation.Size() == 0 {
+		a.numElementValuePairsOffset = -1
+	}
+	if previousAnnotation != nil {
+		previousAnnotation.nextAnnotation = a
+	}
+	return a
+}
+
+// NewAnnotationWriter constructs a new {@link AnnotationWriter} for an annotation of the given type, chained after
+// the given previous annotation (which may be nil).
+func NewAnnotationWriter(symbolTable *SymbolTable, descriptor string, previousAnnotation *AnnotationWriter) *AnnotationWriter {
+	annotation := NewByteVector()
+	// Write type_index and reserve space for num_element_value_pairs.
+	annotation.PutShort(symbolTable.AddConstantUtf8(descriptor)).PutShort(0)
+	return newAnnotationWriter(symbolTable, true, annotation, previousAnnotation)
+}
+
+// NewTypeAnnotationWriter constructs a new {@link AnnotationWriter} for a type annotation of the given type, on the
+// given type reference and type path (which may be nil), chained after the given previous annotation (which may be
+// nil). The type reference must not be a local variable reference, nor the reference of an instruction, whose
+// targets depend on the code of the method (see {@link MethodWriter#VisitInsnAnnotation}).
+func NewTypeAnnotationWriter(symbolTable *SymbolTable, typeRef int, typePath *TypePath, descriptor string, previousAnnotation *AnnotationWriter) *AnnotationWriter {
+	typeAnnotation := NewByteVector()
+	putTarget(typeRef, typeAnnotation)
+	if err := putTypePath(typePath, typeAnnotation); err != nil && symbolTable.err == nil {
+		symbolTable.err = err
+	}
+	// Write type_index and reserve space for num_element_value_pairs.
+	typeAnnotation.PutShort(symbolTable.AddConstantUtf8(descriptor)).PutShort(0)
+	return newAnnotationWriter(symbolTable, true, typeAnnotation, previousAnnotation)
+}
+
+// newAnnotationDefaultWriter constructs a new {@link AnnotationWriter} writing the element_value of an
+// AnnotationDefault attribute in the given empty vector.
+func newAnnotationDefaultWriter(symbolTable *SymbolTable, defaultValue *ByteVector) *AnnotationWriter {
+	return newAnnotationWriter(symbolTable, false, defaultValue, nil)
+}
+
+func (a *AnnotationWriter) Visit(name string, value interface{}) {
+	// Case of an element_value with a const_value_index, class_info_index or array_index field.
+	a.numElementValuePairs++
+	if a.useNamedValues {
+		a.annotation.PutShort(a.symbolTable.AddConstantUtf8(name))
+	}
+	switch value := value.(type) {
+	case string:
+		a.annotation.put12('s', a.symbolTable.AddConstantUtf8(value))
+	case byte:
+		a.annotation.put12('B', a.symbolTable.AddConstantInteger(int32(value)).index)
+	case bool:
+		a.annotation.put12('Z', a.symbolTable.AddConstantInteger(booleanValue(value)).index)
+	case rune:
+		a.annotation.put12('C', a.symbolTable.AddConstantInteger(value).index)
+	case int16:
+		a.annotation.put12('S', a.symbolTable.AddConstantInteger(int32(value)).index)
+	case int:
+		a.annotation.put12('I', a.symbolTable.AddConstantInteger(int32(value)).index)
+	case int64:
+		a.annotation.put12('J', a.symbolTable.AddConstantLong(value).index)
+	case float32:
+		a.annotation.put12('F', a.symbolTable.AddConstantFloat(value).index)
+	case float64:
+		a.annotation.put12('D', a.symbolTable.AddConstantDouble(value).index)
+	case *Type:
+		a.annotation.put12('c', a.symbolTable.AddConstantUtf8(value.GetDescriptor()))
+	case []byte:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('B', a.symbolTable.AddConstantInteger(int32(element)).index)
+		}
+	case []bool:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('Z', a.symbolTable.AddConstantInteger(booleanValue(element)).index)
+		}
+	case []rune:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('C', a.symbolTable.AddConstantInteger(element).index)
+		}
+	case []int16:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('S', a.symbolTable.AddConstantInteger(int32(element)).index)
+		}
+	case []int:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('I', a.symbolTable.AddConstantInteger(int32(element)).index)
+		}
+	case []int64:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('J', a.symbolTable.AddConstantLong(element).index)
+		}
+	case []float32:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('F', a.symbolTable.AddConstantFloat(element).index)
+		}
+	case []float64:
+		a.annotation.put12('[', len(value))
+		for _, element := range value {
+			a.annotation.put12('D', a.symbolTable.AddConstantDouble(element).index)
+		}
+	default:
+		if a.symbolTable.err == nil {
+			a.symbolTable.err = errors.New("Illegal Argument - unsupported annotation value type " + fmt.Sprintf("%T", value))
+		}
+		// Keeps the annotation well formed.
+		a.annotation.put12('s', a.symbolTable.AddConstantUtf8(""))
+	}
+}
+
+// booleanValue returns the value of the CONSTANT_Integer entry of the given boolean.
+func booleanValue(value bool) int32 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func (a *AnnotationWriter) VisitEnum(name, descriptor, value string) {
+	// Case of an element_value with an enum_const_value field.
+	a.numElementValuePairs++
+	if a.useNamedValues {
+		a.annotation.PutShort(a.symbolTable.AddConstantUtf8(name))
+	}
+	a.annotation.put12('e', a.symbolTable.AddConstantUtf8(descriptor)).PutShort(a.symbolTable.AddConstantUtf8(value))
+}
+
+func (a *AnnotationWriter) VisitAnnotation(name, descriptor string) AnnotationVisitor {
+	// Case of an element_value with an annotation_value field.
+	a.numElementValuePairs++
+	if a.useNamedValues {
+		a.annotation.PutShort(a.symbolTable.AddConstantUtf8(name))
+	}
+	// Write tag and type_index, and reserve 2 bytes for num_element_value_pairs.
+	a.annotation.put12('@', a.symbolTable.AddConstantUtf8(descriptor)).PutShort(0)
+	return newAnnotationWriter(a.symbolTable, true, a.annotation, nil)
+}
+
+func (a *AnnotationWriter) VisitArray(name string) AnnotationVisitor {
+	// Case of an element_value with an array_value field.
+	a.numElementValuePairs++
+	if a.useNamedValues {
+		a.annotation.PutShort(a.symbolTable.AddConstantUtf8(name))
+	}
+	// Write tag, and reserve 2 bytes for num_values. Here we take advantage of the fact that the
+	// elements of an array_value are element_values, which are written like the values of an annotation
+	// without their names.
+	a.annotation.put12('[', 0)
+	return newAnnotationWriter(a.symbolTable, false, a.annotation, nil)
+}
+
+func (a *AnnotationWriter) VisitEnd() {
+	if a.numElementValuePairsOffset != -1 {
+		data := a.annotation.data
+		data[a.numElementValuePairsOffset] = byte(a.numElementValuePairs >> 8)
+		data[a.numElementValuePairsOffset+1] = byte(a.numElementValuePairs)
+	}
+}
+
+// computeAnnotationsSize returns the size of a Runtime[In]Visible[Type]Annotations attribute containing this
+// annotation and all its predecessors (see {@link #previousAnnotation}), including its 6 bytes header and its
+// num_annotations field. Also adds the given attribute name to the constant pool, if it is not "".
+func (a *AnnotationWriter) computeAnnotationsSize(attributeName string) int {
+	if attributeName != "" {
+		a.symbolTable.AddConstantUtf8(attributeName)
+	}
+	// The attribute_name_index, attribute_length and num_annotations fields use 8 bytes.
+	attributeSize := 8
+	for annotationWriter := a; annotationWriter != nil; annotationWriter = annotationWriter.previousAnnotation {
+		attributeSize += annotationWriter.annotation.Size()
+	}
+	return attributeSize
+}
+
+// putAnnotations puts a Runtime[In]Visible[Type]Annotations attribute containing this annotation and all its
+// predecessors (see {@link #previousAnnotation}) in the given vector. The annotations are put in the order in which
+// they were visited.
+func (a *AnnotationWriter) putAnnotations(attributeNameIndex int, output *ByteVector) {
+	attributeLength := 2
+	numAnnotations := 0
+	var firstAnnotation *AnnotationWriter
+	for annotationWriter := a; annotationWriter != nil; annotationWriter = annotationWriter.previousAnnotation {
+		// In case the user forgot to call VisitEnd().
+		annotationWriter.VisitEnd()
+		attributeLength += annotationWriter.annotation.Size()
+		numAnnotations++
+		firstAnnotation = annotationWriter
+	}
+	output.PutShort(attributeNameIndex).PutInt(attributeLength).PutShort(numAnnotations)
+	for annotationWriter := firstAnnotation; annotationWriter != nil; annotationWriter = annotationWriter.nextAnnotation {
+		output.PutByteArray(annotationWriter.annotation.data, 0, annotationWriter.annotation.Size())
+	}
+}
+
+// computeAnnotationAttributesSize returns the size of the Runtime[In]Visible[Type]Annotations attributes containing
+// the given annotations and all their predecessors, and adds their names to the constant pool. Each annotation
+// writer may be nil.
+func computeAnnotationAttributesSize(lastRuntimeVisibleAnnotation, lastRuntimeInvisibleAnnotation, lastRuntimeVisibleTypeAnnotation, lastRuntimeInvisibleTypeAnnotation *AnnotationWriter) int {
+	size := 0
+	if lastRuntimeVisibleAnnotation != nil {
+		size += lastRuntimeVisibleAnnotation.computeAnnotationsSize("RuntimeVisibleAnnotations")
+	}
+	if lastRuntimeInvisibleAnnotation != nil {
+		size += lastRuntimeInvisibleAnnotation.computeAnnotationsSize("RuntimeInvisibleAnnotations")
+	}
+	if lastRuntimeVisibleTypeAnnotation != nil {
+		size += lastRuntimeVisibleTypeAnnotation.computeAnnotationsSize("RuntimeVisibleTypeAnnotations")
+	}
+	if lastRuntimeInvisibleTypeAnnotation != nil {
+		size += lastRuntimeInvisibleTypeAnnotation.computeAnnotationsSize("RuntimeInvisibleTypeAnnotations")
+	}
+	return size
+}
+
+// countAnnotationAttributes returns the number of non nil annotation writers among the given ones.
+func countAnnotationAttributes(annotationWriters ...*AnnotationWriter) int {
+	count := 0
+	for _, annotationWriter := range annotationWriters {
+		if annotationWriter != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// putAnnotationAttributes puts the Runtime[In]Visible[Type]Annotations attributes containing the given annotations
+// and all their predecessors in the given vector. Each annotation writer may be nil.
+func putAnnotationAttributes(symbolTable *SymbolTable, lastRuntimeVisibleAnnotation, lastRuntimeInvisibleAnnotation, lastRuntimeVisibleTypeAnnotation, lastRuntimeInvisibleTypeAnnotation *AnnotationWriter, output *ByteVector) {
+	if lastRuntimeVisibleAnnotation != nil {
+		lastRuntimeVisibleAnnotation.putAnnotations(symbolTable.AddConstantUtf8("RuntimeVisibleAnnotations"), output)
+	}
+	if lastRuntimeInvisibleAnnotation != nil {
+		lastRuntimeInvisibleAnnotation.putAnnotations(symbolTable.AddConstantUtf8("RuntimeInvisibleAnnotations"), output)
+	}
+	if lastRuntimeVisibleTypeAnnotation != nil {
+		lastRuntimeVisibleTypeAnnotation.putAnnotations(symbolTable.AddConstantUtf8("RuntimeVisibleTypeAnnotations"), output)
+	}
+	if lastRuntimeInvisibleTypeAnnotation != nil {
+		lastRuntimeInvisibleTypeAnnotation.putAnnotations(symbolTable.AddConstantUtf8("RuntimeInvisibleTypeAnnotations"), output)
+	}
+}
+
+// computeParameterAnnotationsSize returns the size of a Runtime[In]Visible[Type]ParameterAnnotations attribute
+// containing the given annotations (indexed by parameter), and adds its name to the constant pool.
+func computeParameterAnnotationsSize(symbolTable *SymbolTable, attributeName string, annotationWriters []*AnnotationWriter, annotableParameterCount int) int {
+	symbolTable.AddConstantUtf8(attributeName)
+	// The attribute_name_index, attribute_length and num_parameters fields use 7 bytes, and each element of
+	// the parameter_annotations array uses 2 bytes for its num_annotations field.
+	attributeSize := 7 + 2*annotableParameterCount
+	for i := 0; i < annotableParameterCount; i++ {
+		if annotationWriter := annotationWriters[i]; annotationWriter != nil {
+			attributeSize += annotationWriter.computeAnnotationsSize("") - 8
+		}
+	}
+	return attributeSize
+}
+
+// putParameterAnnotations puts a Runtime[In]Visible[Type]ParameterAnnotations attribute containing the given
+// annotations (indexed by parameter) in the given vector.
+func putParameterAnnotations(attributeNameIndex int, annotationWriters []*AnnotationWriter, annotableParameterCount int, output *ByteVector) {
+	// The num_parameters field uses 1 byte, and each element of the parameter_annotations array uses 2 bytes
+	// for its num_annotations field.
+	attributeLength := 1 + 2*annotableParameterCount
+	for i := 0; i < annotableParameterCount; i++ {
+		if annotationWriter := annotationWriters[i]; annotationWriter != nil {
+			attributeLength += annotationWriter.computeAnnotationsSize("") - 8
+		}
+	}
+	output.PutShort(attributeNameIndex).PutInt(attributeLength).PutByte(annotableParameterCount)
+	for i := 0; i < annotableParameterCount; i++ {
+		var firstAnnotation *AnnotationWriter
+		numAnnotations := 0
+		for annotationWriter := annotationWriters[i]; annotationWriter != nil; annotationWriter = annotationWriter.previousAnnotation {
+			// In case the user forgot to call VisitEnd().
+			annotationWriter.VisitEnd()
+			numAnnotations++
+			firstAnnotation = annotationWriter
+		}
+		output.PutShort(numAnnotations)
+		for annotationWriter := firstAnnotation; annotationWriter != nil; annotationWriter = annotationWriter.nextAnnotation {
+			output.PutByteArray(annotationWriter.annotation.data, 0, annotationWriter.annotation.Size())
+		}
+	}
+}
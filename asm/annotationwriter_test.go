@@ -0,0 +1,194 @@
+package asm_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typereference"
+)
+
+// annotationPrinter an annotation visitor which prints the visited values, nested annotations and arrays.
+type annotationPrinter struct {
+	events *[]string
+	prefix string
+}
+
+func (a annotationPrinter) print(event string) {
+	*a.events = append(*a.events, a.prefix+event)
+}
+
+func (a annotationPrinter) Visit(name string, value interface{}) {
+	if typed, ok := value.(*asm.Type); ok {
+		value = typed.GetDescriptor()
+	}
+	a.print(fmt.Sprintf("%s=%v %T", name, value, value))
+}
+
+func (a annotationPrinter) VisitEnum(name, descriptor, value string) {
+	a.print(name + "=" + descriptor + "." + value)
+}
+
+func (a annotationPrinter) VisitAnnotation(name, descriptor string) asm.AnnotationVisitor {
+	a.print(name + "=@" + descriptor)
+	return annotationPrinter{a.events, a.prefix + "  "}
+}
+
+func (a annotationPrinter) VisitArray(name string) asm.AnnotationVisitor {
+	a.print(name + "=[")
+	return annotationPrinter{a.events, a.prefix + "  "}
+}
+
+func (a annotationPrinter) VisitEnd() {
+	a.print("end")
+}
+
+// annotationPrinterClassVisitor a class visitor which prints the annotations of the fields and methods of a class.
+type annotationPrinterClassVisitor struct {
+	helper.ClassVisitor
+	events []string
+}
+
+func (a *annotationPrinterClassVisitor) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	return annotationPrinterFieldVisitor{a}
+}
+
+func (a *annotationPrinterClassVisitor) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	return annotationPrinterMethodVisitor{printer: a}
+}
+
+func (a *annotationPrinterClassVisitor) visitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	a.events = append(a.events, fmt.Sprint("@", descriptor, " ", visible))
+	return annotationPrinter{&a.events, "  "}
+}
+
+type annotationPrinterFieldVisitor struct {
+	printer *annotationPrinterClassVisitor
+}
+
+func (f annotationPrinterFieldVisitor) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return f.printer.visitAnnotation(descriptor, visible)
+}
+
+func (f annotationPrinterFieldVisitor) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return f.printer.visitAnnotation(fmt.Sprintf("%s %x %v", descriptor, typeRef, typePath), visible)
+}
+
+func (f annotationPrinterFieldVisitor) VisitAttribute(attribute *asm.Attribute) {
+}
+
+func (f annotationPrinterFieldVisitor) VisitEnd() {
+}
+
+type annotationPrinterMethodVisitor struct {
+	helper.MethodVisitor
+	printer *annotationPrinterClassVisitor
+}
+
+func (m annotationPrinterMethodVisitor) VisitAnnotationDefault() asm.AnnotationVisitor {
+	return m.printer.visitAnnotation("default", true)
+}
+
+func (m annotationPrinterMethodVisitor) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.printer.visitAnnotation(fmt.Sprint(parameter, " ", descriptor), visible)
+}
+
+func (m annotationPrinterMethodVisitor) VisitTypeInsn(opcode int, typed string) {
+	m.printer.events = append(m.printer.events, opcodes.NAMES[opcode]+" "+typed)
+}
+
+func (m annotationPrinterMethodVisitor) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.printer.visitAnnotation(fmt.Sprintf("%s %x", descriptor, typeRef), visible)
+}
+
+func TestAnnotationWriter(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+
+	result, err := asm.AddField(classFile.Bytes(), opcodes.ACC_PRIVATE, "f", "Ljava/util/List;", "", nil, func(fieldVisitor asm.FieldVisitor) {
+		annotationVisitor := fieldVisitor.VisitAnnotation("LA;", true)
+		annotationVisitor.Visit("s", "text")
+		annotationVisitor.Visit("i", 42)
+		annotationVisitor.Visit("z", true)
+		annotationVisitor.Visit("c", asm.GetType("Ljava/lang/String;"))
+		annotationVisitor.Visit("ints", []int{1, 2})
+		annotationVisitor.VisitEnum("e", "LE;", "ONE")
+		nested := annotationVisitor.VisitAnnotation("n", "LB;")
+		nested.Visit("j", int64(7))
+		nested.VisitEnd()
+		array := annotationVisitor.VisitArray("a")
+		array.Visit("", "x")
+		array.VisitAnnotation("", "LB;").VisitEnd()
+		array.VisitEnd()
+		annotationVisitor.VisitEnd()
+		fieldVisitor.VisitAnnotation("LC;", false).VisitEnd()
+		typeRef := typereference.FIELD << 24
+		typePath, err := asm.NewTypePathFromString("0;")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fieldVisitor.VisitTypeAnnotation(typeRef, typePath, "LT;", true).VisitEnd()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = asm.AddMethod(result, opcodes.ACC_PUBLIC|opcodes.ACC_STATIC, "m", "(II)I", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitAnnotationDefault().Visit("", 3.5)
+		methodVisitor.VisitParameterAnnotation(1, "LP;", true).VisitEnd()
+		methodVisitor.VisitCode()
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 1)
+		methodVisitor.VisitTypeInsn(opcodes.NEW, "java/lang/Object")
+		methodVisitor.VisitInsnAnnotation(typereference.NEW<<24, nil, "LI;", false).VisitEnd()
+		methodVisitor.VisitInsn(opcodes.POP)
+		methodVisitor.VisitInsn(opcodes.IADD)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := asm.NewClassReader(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	printer := &annotationPrinterClassVisitor{}
+	reader.Accept(printer, 0)
+	expected := []string{
+		"@LA; true",
+		"  s=text string",
+		"  i=42 int",
+		"  z=true bool",
+		"  c=Ljava/lang/String; string",
+		"  ints=[1 2] []int",
+		"  e=LE;.ONE",
+		"  n=@LB;",
+		"    j=7 int64",
+		"    end",
+		"  a=[",
+		"    =x string",
+		"    =@LB;",
+		"      end",
+		"    end",
+		"  end",
+		"@LC; false",
+		"  end",
+		"@LT; 13000000 0; true",
+		"  end",
+		"@default true",
+		"  =3.5 float64",
+		"  end",
+		"@1 LP; true",
+		"  end",
+		"NEW java/lang/Object",
+		"@LI; 44000000 false",
+		"  end",
+	}
+	if strings.Join(printer.events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected annotations:\n%s", strings.Join(printer.events, "\n"))
+	}
+}
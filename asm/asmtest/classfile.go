@@ -0,0 +1,128 @@
+package asmtest
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+)
+
+// ClassFile a builder of small class files, for the tests which need specific class file bytes. The fields and
+// methods are created with the symbol table of the class, and the class file is written by {@link ClassFile#Bytes}.
+type ClassFile struct {
+	// SymbolTable the symbol table of the class, to which constants can be added before the class is written.
+	SymbolTable *asm.SymbolTable
+	version     int
+	access      int
+	thisClass   int
+	superClass  int
+	interfaces  []int
+	fields      []*asm.FieldWriter
+	methods     []*asm.MethodWriter
+	attributes  []classFileAttributes
+}
+
+// classFileAttributes some attributes of a class, written by a function.
+type classFileAttributes struct {
+	count int
+	size  func() int
+	put   func(output *asm.ByteVector)
+}
+
+// NewClassFile constructs a new {@link ClassFile} with the given version, access flags, name, super class (or ""
+// for none, as in module-info classes) and interfaces, and without any members or attributes.
+func NewClassFile(version, access int, name, superName string, interfaces ...string) *ClassFile {
+	symbolTable := asm.NewSymbolTable()
+	classFile := &ClassFile{
+		SymbolTable: symbolTable,
+		version:     version,
+		access:      access,
+		thisClass:   symbolTable.SetMajorVersionAndClassName(version&0xFFFF, name),
+	}
+	if superName != "" {
+		classFile.superClass = symbolTable.AddConstantClass(superName).GetIndex()
+	}
+	for _, itf := range interfaces {
+		classFile.interfaces = append(classFile.interfaces, symbolTable.AddConstantClass(itf).GetIndex())
+	}
+	return classFile
+}
+
+// AddField adds a field to the class, and returns its writer, to visit its attributes.
+func (c *ClassFile) AddField(access int, name, descriptor, signature string, value interface{}) *asm.FieldWriter {
+	fieldWriter := asm.NewFieldWriter(c.SymbolTable, access, name, descriptor, signature, value)
+	c.fields = append(c.fields, fieldWriter)
+	return fieldWriter
+}
+
+// AddMethod adds a method to the class, and returns its writer, to visit its attributes and its code.
+func (c *ClassFile) AddMethod(access int, name, descriptor, signature string, exceptions []string) *asm.MethodWriter {
+	methodWriter := asm.NewMethodWriter(c.SymbolTable, access, name, descriptor, signature, exceptions)
+	c.methods = append(c.methods, methodWriter)
+	return methodWriter
+}
+
+// AddMethodWriter adds a method, whose writer was created with the symbol table of the class, to the class.
+func (c *ClassFile) AddMethodWriter(methodWriter *asm.MethodWriter) {
+	c.methods = append(c.methods, methodWriter)
+}
+
+// AddAttribute adds a class attribute with the given name and raw content. Its content may contain constant
+// pool indices obtained from {@link ClassFile#SymbolTable}.
+func (c *ClassFile) AddAttribute(name string, content []byte) {
+	nameIndex := c.SymbolTable.AddConstantUtf8(name)
+	c.attributes = append(c.attributes, classFileAttributes{
+		count: 1,
+		size:  func() int { return 6 + len(content) },
+		put: func(output *asm.ByteVector) {
+			output.PutShort(nameIndex).PutInt(len(content)).PutByteArray(content, 0, len(content))
+		},
+	})
+}
+
+// AddModule adds the attributes of the given module writer, which must have been created with the symbol table
+// of the class, to the class.
+func (c *ClassFile) AddModule(moduleWriter *asm.ModuleWriter) {
+	c.attributes = append(c.attributes, classFileAttributes{
+		count: moduleWriter.GetAttributeCount(),
+		size:  moduleWriter.ComputeAttributesSize,
+		put:   moduleWriter.PutAttributes,
+	})
+}
+
+// Bytes returns the class file built so far.
+func (c *ClassFile) Bytes() []byte {
+	// Adds the attribute names of the members and of the class to the constant pool, before writing it.
+	for _, fieldWriter := range c.fields {
+		fieldWriter.ComputeFieldInfoSize()
+	}
+	for _, methodWriter := range c.methods {
+		methodWriter.ComputeMethodInfoSize()
+	}
+	attributeCount := 0
+	for _, attributes := range c.attributes {
+		attributes.size()
+		attributeCount += attributes.count
+	}
+	if c.SymbolTable.ComputeBootstrapMethodsSize() > 0 {
+		attributeCount++
+	}
+
+	output := asm.NewByteVector().PutInt(0xCAFEBABE).PutInt(c.version)
+	c.SymbolTable.PutConstantPool(output)
+	output.PutShort(c.access).PutShort(c.thisClass).PutShort(c.superClass).PutShort(len(c.interfaces))
+	for _, itf := range c.interfaces {
+		output.PutShort(itf)
+	}
+	output.PutShort(len(c.fields))
+	for _, fieldWriter := range c.fields {
+		fieldWriter.PutFieldInfo(output)
+	}
+	output.PutShort(len(c.methods))
+	for _, methodWriter := range c.methods {
+		methodWriter.PutMethodInfo(output)
+	}
+	output.PutShort(attributeCount)
+	for _, attributes := range c.attributes {
+		attributes.put(output)
+	}
+	c.SymbolTable.PutBootstrapMethods(output)
+	return output.Bytes()
+}
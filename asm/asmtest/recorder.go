@@ -0,0 +1,194 @@
+// Package asmtest provides utilities to test class visitors and adapters.
+package asmtest
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// UPDATE_GOLDEN_ENV the environment variable which, if set to a non empty value, makes
+// {@link Recorder#AssertGolden} write the golden files instead of comparing them.
+const UPDATE_GOLDEN_ENV = "ASMTEST_UPDATE"
+
+// Recorder a {@link ClassVisitor} which records the events of the class and of its fields and methods (but not
+// of its annotations and module), and forwards them to Next, if not nil. It is typically given as the last
+// visitor of the tested adapter, to check the events it produces.
+type Recorder struct {
+	*helper.MiddlewareClassAdapter
+	// Events the recorded events, in visit order.
+	Events []*helper.Event
+}
+
+// NewRecorder constructs a new {@link Recorder}, forwarding the events to the given visitor (which may be nil).
+func NewRecorder(next asm.ClassVisitor) *Recorder {
+	recorder := &Recorder{}
+	recorder.MiddlewareClassAdapter = helper.NewMiddlewareClassAdapter(next, helper.Middleware{
+		After: func(event *helper.Event) { recorder.Events = append(recorder.Events, event) },
+	})
+	return recorder
+}
+
+// Trace returns the recorded events, one per line, with their arguments. Labels are named L0, L1... in the
+// order in which they are first used, so that traces can be compared across runs.
+func (r *Recorder) Trace() []string {
+	labels := make(map[*asm.Label]int)
+	lines := make([]string, len(r.Events))
+	for i, event := range r.Events {
+		args := make([]string, len(event.Args))
+		for j, arg := range event.Args {
+			args[j] = formatArg(arg, labels)
+		}
+		lines[i] = event.String()
+		if len(args) > 0 {
+			lines[i] += " " + strings.Join(args, " ")
+		}
+	}
+	return lines
+}
+
+func formatArg(arg interface{}, labels map[*asm.Label]int) string {
+	switch arg := arg.(type) {
+	case *asm.Label:
+		if arg == nil {
+			return "<nil>"
+		}
+		index, ok := labels[arg]
+		if !ok {
+			index = len(labels)
+			labels[arg] = index
+		}
+		return "L" + strconv.Itoa(index)
+	case []*asm.Label:
+		values := make([]interface{}, len(arg))
+		for i, label := range arg {
+			values[i] = label
+		}
+		return formatArg(values, labels)
+	case []interface{}:
+		values := make([]string, len(arg))
+		for i, value := range arg {
+			values[i] = formatArg(value, labels)
+		}
+		return "[" + strings.Join(values, " ") + "]"
+	case string:
+		return strconv.Quote(arg)
+	case *asm.Type:
+		if arg == nil {
+			return "<nil>"
+		}
+		return "type " + arg.GetDescriptor()
+	case *asm.Attribute:
+		if arg == nil {
+			return "<nil>"
+		}
+		return "attribute " + arg.GetType()
+	}
+	return fmt.Sprint(arg)
+}
+
+// GetEvents returns the recorded events of the given kind (see {@link helper.Event}), for the given member (the
+// name and descriptor of a field or method, or "" for class events).
+func (r *Recorder) GetEvents(kind int, member string) []*helper.Event {
+	var events []*helper.Event
+	for _, event := range r.Events {
+		if event.Kind == kind && event.Member == member {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// AssertVisitedMethod reports an error if the given method was not visited.
+func (r *Recorder) AssertVisitedMethod(t testing.TB, name, descriptor string) {
+	t.Helper()
+	if !r.hasMember(helper.CLASS_VISIT_METHOD, name, descriptor) {
+		t.Errorf("method %s%s was not visited", name, descriptor)
+	}
+}
+
+// AssertNotVisitedMethod reports an error if the given method was visited.
+func (r *Recorder) AssertNotVisitedMethod(t testing.TB, name, descriptor string) {
+	t.Helper()
+	if r.hasMember(helper.CLASS_VISIT_METHOD, name, descriptor) {
+		t.Errorf("method %s%s was visited", name, descriptor)
+	}
+}
+
+// AssertVisitedField reports an error if the given field was not visited.
+func (r *Recorder) AssertVisitedField(t testing.TB, name, descriptor string) {
+	t.Helper()
+	if !r.hasMember(helper.CLASS_VISIT_FIELD, name, descriptor) {
+		t.Errorf("field %s %s was not visited", name, descriptor)
+	}
+}
+
+// AssertNotVisitedField reports an error if the given field was visited.
+func (r *Recorder) AssertNotVisitedField(t testing.TB, name, descriptor string) {
+	t.Helper()
+	if r.hasMember(helper.CLASS_VISIT_FIELD, name, descriptor) {
+		t.Errorf("field %s %s was visited", name, descriptor)
+	}
+}
+
+// hasMember returns whether a CLASS_VISIT_FIELD or CLASS_VISIT_METHOD event was recorded for the given member.
+func (r *Recorder) hasMember(kind int, name, descriptor string) bool {
+	for _, event := range r.GetEvents(kind, "") {
+		// The access flags are followed by the name and the descriptor.
+		if event.Args[1] == name && event.Args[2] == descriptor {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertTrace reports an error if the trace of the recorded events is not the expected one, with the first
+// differing line.
+func (r *Recorder) AssertTrace(t testing.TB, expected []string) {
+	t.Helper()
+	if diff := diffLines(expected, r.Trace()); diff != "" {
+		t.Error("unexpected trace: " + diff)
+	}
+}
+
+// AssertGolden compares the trace of the recorded events with the content of the given golden file, and
+// reports an error with the first differing line if they are not equal. If the {@link UPDATE_GOLDEN_ENV}
+// environment variable is set, the golden file is written with the trace instead.
+func (r *Recorder) AssertGolden(t testing.TB, path string) {
+	t.Helper()
+	trace := strings.Join(r.Trace(), "\n") + "\n"
+	if os.Getenv(UPDATE_GOLDEN_ENV) != "" {
+		if err := os.WriteFile(path, []byte(trace), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (set %s=1 to create it)", err, UPDATE_GOLDEN_ENV)
+	}
+	expected := strings.Split(strings.TrimSuffix(string(golden), "\n"), "\n")
+	if diff := diffLines(expected, r.Trace()); diff != "" {
+		t.Errorf("trace differs from %s: %s", path, diff)
+	}
+}
+
+// diffLines returns a description of the first difference between the given lines, or "" if they are equal.
+func diffLines(expected, actual []string) string {
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		switch {
+		case i >= len(expected):
+			return "unexpected line " + strconv.Itoa(i+1) + ": " + actual[i]
+		case i >= len(actual):
+			return "missing line " + strconv.Itoa(i+1) + ": " + expected[i]
+		case expected[i] != actual[i]:
+			return "line " + strconv.Itoa(i+1) + ": expected " + expected[i] + ", got " + actual[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package asmtest_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := asmtest.NewRecorder(nil)
+	recorder.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC, "A", "", "java/lang/Object", nil)
+	methodVisitor := recorder.VisitMethod(opcodes.ACC_STATIC, "m", "()V", "", nil)
+	label := &asm.Label{}
+	methodVisitor.VisitCode()
+	methodVisitor.VisitJumpInsn(opcodes.GOTO, label)
+	methodVisitor.VisitLabel(label)
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitEnd()
+	recorder.VisitEnd()
+
+	recorder.AssertVisitedMethod(t, "m", "()V")
+	recorder.AssertNotVisitedMethod(t, "<init>", "()V")
+	recorder.AssertTrace(t, []string{
+		`class visit A 52 1 "A" "" "java/lang/Object" []`,
+		`class visit method A 8 "m" "()V" "" []`,
+		`method visit code A.m()V`,
+		`method visit jump insn A.m()V 167 L0`,
+		`method visit label A.m()V L0`,
+		`method visit insn A.m()V 177`,
+		`method visit end A.m()V`,
+		`class visit end A`,
+	})
+}
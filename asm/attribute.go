@@ -1,9 +1,15 @@
 package asm
 
+import "errors"
+
+// Attribute a non standard class, field, method or Code attribute, as defined in the Java Virtual Machine
+// Specification (JVMS).
 type Attribute struct {
 	typed         string
 	content       []byte
 	nextAttribute *Attribute
+	// classReader the reader of a preserved attribute, whose constant pool its content refers to.
+	classReader *ClassReader
 }
 
 func NewAttribute(typed string) *Attribute {
@@ -12,6 +18,50 @@ func NewAttribute(typed string) *Attribute {
 	}
 }
 
+// NewAttributeWithContent constructs a new attribute with the given raw content (without the 6 bytes header).
+// The content must not contain constant pool indices, since it is written as is.
+func NewAttributeWithContent(typed string, content []byte) *Attribute {
+	return &Attribute{
+		typed:   typed,
+		content: content,
+	}
+}
+
+// GetType returns the type of this attribute, i.e. its name in the class file.
+func (a Attribute) GetType() string {
+	return a.typed
+}
+
+// GetContent returns the raw content of this attribute, without its 6 bytes header, or nil if this attribute
+// has not been read from a class file.
+func (a Attribute) GetContent() []byte {
+	return a.content
+}
+
+// IsPreserved returns whether this attribute has been read with the {@link PRESERVE_UNKNOWN_ATTRIBUTES} option.
+// A preserved attribute is written back byte for byte, and can only be visited by a writer whose symbol table has
+// been built from its {@link ClassReader} (see {@link NewSymbolTableFromClassReader}), so that the constant pool
+// indices it may contain remain valid.
+func (a Attribute) IsPreserved() bool {
+	return a.classReader != nil
+}
+
+// checkConstantPool returns an error if this attribute is preserved, and if its constant pool indices are not
+// valid in the given symbol table.
+func (a Attribute) checkConstantPool(symbolTable *SymbolTable) error {
+	if a.classReader == nil || symbolTable.classReader != nil && sameClassFile(a.classReader, symbolTable.classReader) {
+		return nil
+	}
+	return errors.New("Illegal Argument - preserved attribute " + a.typed +
+		" must be written with the constant pool of its class (see NewSymbolTableFromClassReader)")
+}
+
+// sameClassFile returns whether the given readers parse the same class file bytes.
+func sameClassFile(classReader1, classReader2 *ClassReader) bool {
+	b1, b2 := classReader1.b, classReader2.b
+	return len(b1) == len(b2) && (len(b1) == 0 || &b1[0] == &b2[0])
+}
+
 func (a Attribute) isUnknow() bool {
 	return true
 }
@@ -27,13 +77,14 @@ func (a Attribute) getLabels() []Label {
 func (a Attribute) read(classReader *ClassReader, offset int, length int, charBuffer []rune, codeAttributeOffset int, labels []*Label) *Attribute {
 	attribute := NewAttribute(a.typed)
 	attribute.content = make([]byte, length)
-	copy(attribute.content, classReader.b) //System.arraycopy(classReader.b, offset, attribute.content, 0, length)
+	copy(attribute.content, classReader.b[offset:offset+length])
 	return attribute
 }
 
-//ClassWriter
-func (a Attribute) write(classWriter interface{}, code []byte, codeLength int, maxStack int, maxLocals int) {
-	//return new ByteVector(content)
+// write returns the content of this attribute, without its 6 bytes header, as it must be written in the class
+// using the given symbol table.
+func (a Attribute) write(symbolTable *SymbolTable, code []byte, codeLength int, maxStack int, maxLocals int) *ByteVector {
+	return newByteVectorFrom(a.content)
 }
 
 func (a Attribute) getAttributeCount() int {
@@ -46,40 +97,40 @@ func (a Attribute) getAttributeCount() int {
 	return count
 }
 
-func (a Attribute) computeAttributesSize(symbolTable interface{}) int {
+// computeAttributesSize returns the size of all the attributes in this attribute list, including their 6 bytes
+// headers, and adds their names to the constant pool of the given symbol table.
+func (a Attribute) computeAttributesSize(symbolTable *SymbolTable) int {
 	codeLength := 0
 	maxStack := -1
 	maxLocals := -1
 	return a._computeAttributesSize(symbolTable, nil, codeLength, maxStack, maxLocals)
 }
 
-func (a Attribute) _computeAttributesSize(symbolTable interface{}, code []byte, codeLength int, maxStack int, maxLocals int) int {
-	//ClassWriter classWrite = symbolTable.classWriter
+func (a Attribute) _computeAttributesSize(symbolTable *SymbolTable, code []byte, codeLength int, maxStack int, maxLocals int) int {
 	size := 0
 	attribute := &a
 	for attribute != nil {
-		//symbolTable.addConstantUtf8(attribute.typed)
-		//size += 6 + attribute.write(classWriter, code, codeLength, maxStack, maxLocals).length
+		symbolTable.AddConstantUtf8(attribute.typed)
+		size += 6 + attribute.write(symbolTable, code, codeLength, maxStack, maxLocals).Size()
 		attribute = attribute.nextAttribute
 	}
 	return size
 }
 
-//SymbolTable, ByteVector
-func (a Attribute) putAttribute(symbolTable interface{}, output interface{}) {
+// putAttribute puts all the attributes of this attribute list, with their headers, into the given vector.
+func (a Attribute) putAttribute(symbolTable *SymbolTable, output *ByteVector) {
 	codeLength := 0
 	maxStack := -1
 	maxLocals := -1
 	a._putAttribute(symbolTable, nil, codeLength, maxStack, maxLocals, output)
 }
 
-func (a Attribute) _putAttribute(symbolTable interface{}, code []byte, codeLength int, maxStack int, maxLocals int, output interface{}) {
-	//ClassWriter classWrite = symbolTable.classWriter
+func (a Attribute) _putAttribute(symbolTable *SymbolTable, code []byte, codeLength int, maxStack int, maxLocals int, output *ByteVector) {
 	attribute := &a
 	for attribute != nil {
-		//ByteVector attributeContent = attribute.write(classWriter, code, codeLength, maxStack, maxLocals)
-		//output.putShort(symbolTable.addConstantUtf8(attribute.typed)).putInt(attributeContent.length)
-		//output.putByteArray(attributeContent.data, 0, attributeContent.length)
+		attributeContent := attribute.write(symbolTable, code, codeLength, maxStack, maxLocals)
+		output.PutShort(symbolTable.AddConstantUtf8(attribute.typed)).PutInt(attributeContent.Size())
+		output.PutByteArray(attributeContent.Bytes(), 0, attributeContent.Size())
 		attribute = attribute.nextAttribute
 	}
 }
@@ -0,0 +1,97 @@
+package asm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// customAttributeClass returns a class with a field "int f" and an abstract method "void m()", which both have a
+// non standard Custom attribute whose content is the constant pool index of the "f" string.
+func customAttributeClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x421, "A", "java/lang/Object")
+	fieldName := classFile.SymbolTable.AddConstantUtf8("f")
+	content := asm.NewByteVector().PutShort(fieldName).Bytes()
+	classFile.AddField(0, "f", "I", "", nil).VisitAttribute(asm.NewAttributeWithContent("Custom", content))
+	classFile.AddMethod(opcodes.ACC_ABSTRACT, "m", "()V", "", nil).VisitAttribute(asm.NewAttributeWithContent("Custom", content))
+	return classFile.Bytes()
+}
+
+// memberWriterClassVisitor a class visitor which writes the fields and methods it visits with the given symbol
+// table.
+type memberWriterClassVisitor struct {
+	helper.ClassVisitor
+	symbolTable   *asm.SymbolTable
+	fieldWriters  []*asm.FieldWriter
+	methodWriters []*asm.MethodWriter
+}
+
+func (m *memberWriterClassVisitor) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	fieldWriter := asm.NewFieldWriter(m.symbolTable, access, name, descriptor, signature, value)
+	m.fieldWriters = append(m.fieldWriters, fieldWriter)
+	return fieldWriter
+}
+
+func (m *memberWriterClassVisitor) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	methodWriter := asm.NewMethodWriter(m.symbolTable, access, name, descriptor, signature, exceptions)
+	m.methodWriters = append(m.methodWriters, methodWriter)
+	return methodWriter
+}
+
+func TestPreservedAttributeRoundTrip(t *testing.T) {
+	classFile := customAttributeClass()
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbolTable := asm.NewSymbolTableFromClassReader(reader)
+	symbolTable.SetMajorVersionAndClassName(opcodes.V1_8, "A")
+	visitor := &memberWriterClassVisitor{symbolTable: symbolTable}
+	reader.Accept(visitor, asm.PRESERVE_UNKNOWN_ATTRIBUTES)
+
+	index := reader.Index()
+	fieldWriter, methodWriter := visitor.fieldWriters[0], visitor.methodWriters[0]
+	if err := fieldWriter.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if err := methodWriter.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	fieldWriter.ComputeFieldInfoSize()
+	methodWriter.ComputeMethodInfoSize()
+	if symbolTable.GetConstantPoolCount() != reader.GetItemCount() {
+		t.Errorf("unexpected new constant pool entries")
+	}
+	fieldInfo := asm.NewByteVector()
+	fieldWriter.PutFieldInfo(fieldInfo)
+	if field := index.Fields[0]; !bytes.Equal(fieldInfo.Bytes(), classFile[field.Start:field.End]) {
+		t.Errorf("field_info %x, expected %x", fieldInfo.Bytes(), classFile[field.Start:field.End])
+	}
+	methodInfo := asm.NewByteVector()
+	methodWriter.PutMethodInfo(methodInfo)
+	if method := index.Methods[0]; !bytes.Equal(methodInfo.Bytes(), classFile[method.Start:method.End]) {
+		t.Errorf("method_info %x, expected %x", methodInfo.Bytes(), classFile[method.Start:method.End])
+	}
+}
+
+func TestPreservedAttributeOtherConstantPool(t *testing.T) {
+	reader, err := asm.NewClassReader(customAttributeClass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	visitor := &memberWriterClassVisitor{symbolTable: asm.NewSymbolTable()}
+	reader.Accept(visitor, asm.PRESERVE_UNKNOWN_ATTRIBUTES)
+	if visitor.fieldWriters[0].GetError() == nil || visitor.methodWriters[0].GetError() == nil {
+		t.Error("preserved attributes written with another constant pool")
+	}
+
+	visitor = &memberWriterClassVisitor{symbolTable: asm.NewSymbolTable()}
+	reader.Accept(visitor, 0)
+	if err := visitor.fieldWriters[0].GetError(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,133 @@
+package asm
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ByteVector a dynamically extensible vector of bytes, used to serialize the content of the classes, methods
+// and attributes. The put methods append their value in big endian order, grow the vector if needed, and
+// return the vector itself so that calls can be chained.
+type ByteVector struct {
+	data []byte
+}
+
+// NewByteVector constructs a new {@link ByteVector} with a default initial capacity.
+func NewByteVector() *ByteVector {
+	return NewByteVectorWithCapacity(64)
+}
+
+// NewByteVectorWithCapacity constructs a new {@link ByteVector} with the given initial capacity.
+func NewByteVectorWithCapacity(initialCapacity int) *ByteVector {
+	return &ByteVector{data: make([]byte, 0, initialCapacity)}
+}
+
+// newByteVectorFrom constructs a new {@link ByteVector} containing the given bytes, which are not copied.
+func newByteVectorFrom(data []byte) *ByteVector {
+	return &ByteVector{data: data}
+}
+
+// Size returns the number of bytes of this vector.
+func (b *ByteVector) Size() int {
+	return len(b.data)
+}
+
+// Bytes returns the content of this vector. The returned slice is shared with the vector, and is only valid
+// until the next put.
+func (b *ByteVector) Bytes() []byte {
+	return b.data
+}
+
+// PutByte puts a byte into this byte vector.
+func (b *ByteVector) PutByte(byteValue int) *ByteVector {
+	b.data = append(b.data, byte(byteValue))
+	return b
+}
+
+// put11 puts two bytes into this byte vector.
+func (b *ByteVector) put11(byteValue1 int, byteValue2 int) *ByteVector {
+	b.data = append(b.data, byte(byteValue1), byte(byteValue2))
+	return b
+}
+
+// PutShort puts a short into this byte vector.
+func (b *ByteVector) PutShort(shortValue int) *ByteVector {
+	b.data = append(b.data, byte(shortValue>>8), byte(shortValue))
+	return b
+}
+
+// put12 puts a byte and a short into this byte vector.
+func (b *ByteVector) put12(byteValue int, shortValue int) *ByteVector {
+	b.data = append(b.data, byte(byteValue), byte(shortValue>>8), byte(shortValue))
+	return b
+}
+
+// put112 puts two bytes and a short into this byte vector.
+func (b *ByteVector) put112(byteValue1 int, byteValue2 int, shortValue int) *ByteVector {
+	b.data = append(b.data, byte(byteValue1), byte(byteValue2), byte(shortValue>>8), byte(shortValue))
+	return b
+}
+
+// PutInt puts an int into this byte vector.
+func (b *ByteVector) PutInt(intValue int) *ByteVector {
+	b.data = append(b.data, byte(intValue>>24), byte(intValue>>16), byte(intValue>>8), byte(intValue))
+	return b
+}
+
+// put122 puts one byte and two shorts into this byte vector.
+func (b *ByteVector) put122(byteValue int, shortValue1 int, shortValue2 int) *ByteVector {
+	b.data = append(b.data, byte(byteValue), byte(shortValue1>>8), byte(shortValue1), byte(shortValue2>>8),
+		byte(shortValue2))
+	return b
+}
+
+// PutLong puts a long into this byte vector.
+func (b *ByteVector) PutLong(longValue int64) *ByteVector {
+	b.PutInt(int(longValue >> 32))
+	return b.PutInt(int(longValue))
+}
+
+// PutUTF8 puts the length of the given string, encoded in modified UTF-8, and the encoded string into this byte
+// vector (i.e. the content of a CONSTANT_Utf8 constant pool entry, without its tag). It returns an error, and
+// does not change this vector, if the encoded string is longer than {@link MAX_NAME_LENGTH} bytes.
+func (b *ByteVector) PutUTF8(stringValue string) (*ByteVector, error) {
+	length := utf8Length(stringValue)
+	if length > MAX_NAME_LENGTH {
+		return b, errors.New("Illegal Argument - UTF8 string too large: " + strconv.Itoa(length) + " bytes")
+	}
+	b.PutShort(length)
+	for _, charValue := range stringValue {
+		if charValue > 0xFFFF {
+			// A supplementary character, encoded as a surrogate pair.
+			charValue -= 0x10000
+			b.putChar(0xD800 + (charValue>>10)&0x3FF)
+			b.putChar(0xDC00 + charValue&0x3FF)
+		} else {
+			b.putChar(charValue)
+		}
+	}
+	return b, nil
+}
+
+// putChar puts the given UTF-16 code unit, encoded in modified UTF-8, into this byte vector.
+func (b *ByteVector) putChar(charValue rune) {
+	switch {
+	case charValue >= 0x01 && charValue <= 0x7F:
+		b.data = append(b.data, byte(charValue))
+	case charValue <= 0x7FF:
+		b.data = append(b.data, byte(0xC0|charValue>>6&0x1F), byte(0x80|charValue&0x3F))
+	default:
+		b.data = append(b.data, byte(0xE0|charValue>>12&0xF), byte(0x80|charValue>>6&0x3F), byte(0x80|charValue&0x3F))
+	}
+}
+
+// PutByteArray puts length bytes of the given byte array, starting at offset, into this byte vector. If
+// byteArrayValue is nil, length zero bytes are put instead.
+func (b *ByteVector) PutByteArray(byteArrayValue []byte, byteOffset int, byteLength int) *ByteVector {
+	if byteArrayValue != nil {
+		b.data = append(b.data, byteArrayValue[byteOffset:byteOffset+byteLength]...)
+	} else {
+		b.data = append(b.data, make([]byte, byteLength)...)
+	}
+	return b
+}
@@ -0,0 +1,39 @@
+package asm_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/raw"
+)
+
+func TestByteVector(t *testing.T) {
+	vector := asm.NewByteVectorWithCapacity(1)
+	vector.PutByte(0xCA).PutShort(0xFEBA).PutInt(0x01020304).PutLong(-2).PutByteArray([]byte{7, 8, 9}, 1, 2)
+	expected := []byte{0xCA, 0xFE, 0xBA, 1, 2, 3, 4, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE, 8, 9}
+	if !bytes.Equal(vector.Bytes(), expected) {
+		t.Errorf("expected %v, got %v", expected, vector.Bytes())
+	}
+
+	value := "a\x00é€\U0001F600"
+	vector = asm.NewByteVector()
+	if _, err := vector.PutUTF8(value); err != nil {
+		t.Fatal(err)
+	}
+	if vector.Size() != 2+1+2+2+3+6 {
+		t.Errorf("unexpected modified UTF-8 length %d", vector.Size())
+	}
+	if !bytes.Equal(vector.Bytes()[10:], []byte{0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80}) {
+		t.Errorf("unexpected surrogate pair encoding %v", vector.Bytes()[10:])
+	}
+	vector = asm.NewByteVector()
+	vector.PutUTF8(value[:7])
+	if decoded, err := raw.ReadUTF8(vector.Bytes(), 0); err != nil || decoded != value[:7] {
+		t.Errorf("unexpected decoded string %q (%v)", decoded, err)
+	}
+	if _, err := vector.PutUTF8(strings.Repeat("x", asm.MAX_NAME_LENGTH+1)); err == nil {
+		t.Error("expected an error for a too large string")
+	}
+}
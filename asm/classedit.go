@@ -0,0 +1,547 @@
+package asm
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// AddMethod returns a copy of the given class file with a new method, whose content is visited by buildBody.
+// The existing constant pool entries, members and attributes of the class are copied unchanged, and the
+// constants of the new method are appended to the constant pool (along with its bootstrap methods, if any).
+// The maximum stack size, the maximum number of local variables and, for classes whose version is V1_6 or more,
+// the stack map frames of the new method are computed (see {@link FramesComputer}): buildBody must thus visit
+// its code with VisitCode, its instructions and VisitMaxs (whose arguments are ignored), but must not visit any
+// frame. AddMethod then calls VisitEnd. buildBody can be nil for abstract and native methods. The forward jumps
+// whose offset does not fit in a signed short are replaced with GOTO_W instructions, preceded by an inverted
+// conditional jump for the conditional ones (see {@link expandAsmInstructions}). Returns an error if the class
+// already has a method with the same name and descriptor, or if the code of the new method is invalid or too
+// large.
+func AddMethod(classBytes []byte, access int, name, descriptor string, buildBody func(methodVisitor MethodVisitor)) ([]byte, error) {
+	return AddMethodWithOptions(classBytes, access, name, descriptor, buildBody, AddMemberOptions{})
+}
+
+// AddMemberOptions the options of {@link AddMethodWithOptions} and {@link AddFieldWithOptions}.
+type AddMemberOptions struct {
+	// Verify if not nil, is called with the class file produced by the edit, and must return it if it is valid,
+	// or an error otherwise. Use analysis.VerifyOutput to fail with the diagnostics of the bytecode verifier,
+	// instead of with a VerifyError when the class is loaded by the JVM.
+	Verify func(classFile []byte) ([]byte, error)
+	// GetCommonSuperClass if not nil, returns the internal name of the common super class of the two given
+	// classes, for the stack map frames of a new method (see {@link FramesComputer#GetCommonSuperClass}). It is
+	// needed if the code of the method merges two classes which are not unrelated, e.g. sub classes of the same
+	// class on the two branches of a condition. For instance, use the GetCommonSuperClass method of a
+	// commons.ClassHierarchy.
+	GetCommonSuperClass func(type1, type2 string) string
+}
+
+// verify returns the given class file, checked with the Verify function of these options, if any.
+func (a AddMemberOptions) verify(classFile []byte, err error) ([]byte, error) {
+	if err != nil || a.Verify == nil {
+		return classFile, err
+	}
+	return a.Verify(classFile)
+}
+
+// AddMethodWithOptions returns a copy of the given class file with a new method, like {@link AddMethod}, using
+// the given options.
+func AddMethodWithOptions(classBytes []byte, access int, name, descriptor string, buildBody func(methodVisitor MethodVisitor), options AddMemberOptions) ([]byte, error) {
+	reader, err := NewClassReader(classBytes)
+	if err != nil {
+		return nil, err
+	}
+	index := reader.Index()
+	if index.GetMethod(name, descriptor) != nil {
+		return nil, errors.New("Illegal Argument - duplicate method " + reader.GetClassName() + "." + name + descriptor)
+	}
+	symbolTable := NewSymbolTableFromClassReader(reader)
+	symbolTable.SetMajorVersionAndClassName(reader.readUnsignedShort(6), reader.GetClassName())
+
+	methodWriter := NewMethodWriter(symbolTable, access, name, descriptor, "", nil)
+	if buildBody != nil {
+		if err := writeMethodBody(methodWriter, buildBody, options.GetCommonSuperClass); err != nil {
+			return nil, err
+		}
+	}
+	if err := methodWriter.GetError(); err != nil {
+		return nil, err
+	}
+	for methodWriter.HasAsmInstructions() {
+		if symbolTable, methodWriter, err = expandAsmInstructions(classBytes, reader, index, symbolTable, methodWriter, options.GetCommonSuperClass); err != nil {
+			return nil, err
+		}
+	}
+	return options.verify(appendMember(classBytes, reader, index, symbolTable, false, methodWriter.ComputeMethodInfoSize, methodWriter.PutMethodInfo))
+}
+
+// writeMethodBody makes the given method writer write the code visited by buildBody, with its maxs and, for
+// classes whose version is V1_6 or more, its stack map frames computed, with the given common super class function
+// (which can be nil). VisitEnd is called after buildBody.
+func writeMethodBody(methodWriter *MethodWriter, buildBody func(methodVisitor MethodVisitor), getCommonSuperClass func(type1, type2 string) string) error {
+	var framesComputer *FramesComputer
+	var methodVisitor MethodVisitor
+	if methodWriter.symbolTable.GetMajorVersion() >= opcodes.V1_6 {
+		framesComputer = NewFramesComputer(methodWriter.symbolTable.GetClassName(), methodWriter.accessFlags,
+			methodWriter.name, methodWriter.descriptor, methodWriter)
+		framesComputer.GetCommonSuperClass = getCommonSuperClass
+		methodVisitor = framesComputer
+	} else {
+		methodVisitor = NewMaxsComputer(methodWriter.accessFlags, methodWriter.descriptor, methodWriter)
+	}
+	buildBody(methodVisitor)
+	methodVisitor.VisitEnd()
+	if framesComputer != nil && len(framesComputer.Errors) > 0 {
+		return framesComputer.Errors[0]
+	}
+	return methodWriter.GetError()
+}
+
+// expandAsmInstructions returns a new symbol table and method writer for the method written by the given writer,
+// whose code contains ASM specific instructions (see {@link MethodWriter}). The method is appended to the class,
+// its code is read again with the {@link EXPAND_ASM_INSNS} option, which replaces these instructions with GOTO_W
+// instructions (preceded by an inverted conditional jump for the conditional ones), and it is written again with
+// its maxs and frames recomputed. The new code is longer, so it may still contain ASM specific instructions, in
+// which case this must be repeated.
+func expandAsmInstructions(classBytes []byte, reader *ClassReader, index *ClassIndex, symbolTable *SymbolTable, methodWriter *MethodWriter, getCommonSuperClass func(type1, type2 string) string) (*SymbolTable, *MethodWriter, error) {
+	classWithAsmInstructions, err := appendMember(classBytes, reader, index, symbolTable, false, methodWriter.ComputeMethodInfoSize, methodWriter.PutMethodInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	asmInstructionsReader, err := NewClassReader(classWithAsmInstructions)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The method is the last one of the class.
+	codeAttribute := asmInstructionsReader.Index().Methods[len(index.Methods)].GetAttribute("Code")
+
+	expandedSymbolTable := NewSymbolTableFromClassReader(reader)
+	expandedSymbolTable.SetMajorVersionAndClassName(symbolTable.GetMajorVersion(), symbolTable.GetClassName())
+	expandedMethodWriter := NewMethodWriter(expandedSymbolTable, methodWriter.accessFlags, methodWriter.name, methodWriter.descriptor, "", nil)
+	err = writeMethodBody(expandedMethodWriter, func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitCode()
+		asmInstructionsReader.readMethodCode(methodVisitor, methodWriter.accessFlags, methodWriter.name,
+			methodWriter.descriptor, codeAttribute.Start+6, EXPAND_ASM_INSNS|SKIP_FRAMES)
+	}, getCommonSuperClass)
+	if err != nil {
+		return nil, nil, err
+	}
+	return expandedSymbolTable, expandedMethodWriter, nil
+}
+
+// readMethodCode makes the given method visitor visit the instructions of the given method, whose Code attribute
+// content starts at the given offset, and then VisitMaxs.
+func (c ClassReader) readMethodCode(methodVisitor MethodVisitor, access int, name, descriptor string, codeOffset int, parsingOptions int) {
+	context := &Context{
+		parsingOptions:           parsingOptions,
+		charBuffer:               make([]rune, c.maxStringLength),
+		currentMethodAccessFlags: access,
+		currentMethodName:        name,
+		currentMethodDescriptor:  descriptor,
+	}
+	if bootstrapMethods := c.Index().GetAttribute("BootstrapMethods"); bootstrapMethods != nil {
+		currentBootstrapMethodOffset := bootstrapMethods.Start + 8
+		context.bootstrapMethodOffsets = make([]int, c.readUnsignedShort(bootstrapMethods.Start+6))
+		for i := range context.bootstrapMethodOffsets {
+			context.bootstrapMethodOffsets[i] = currentBootstrapMethodOffset
+			currentBootstrapMethodOffset += 4 + c.readUnsignedShort(currentBootstrapMethodOffset+2)*2
+		}
+	}
+	c.readCode(methodVisitor, context, codeOffset)
+}
+
+// AddField returns a copy of the given class file with a new field, whose annotations and attributes, if any,
+// are visited by visitField (which can be nil). The existing constant pool entries, members and attributes of the
+// class are copied unchanged, and the constants of the new field are appended to the constant pool. The constant
+// value, which may be nil, is the value of the ConstantValue attribute of the field (see {@link FieldWriter}).
+// Returns an error if the class already has a field with the same name.
+func AddField(classBytes []byte, access int, name, descriptor, signature string, constantValue interface{}, visitField func(fieldVisitor FieldVisitor)) ([]byte, error) {
+	return AddFieldWithOptions(classBytes, access, name, descriptor, signature, constantValue, visitField, AddMemberOptions{})
+}
+
+// AddFieldWithOptions returns a copy of the given class file with a new field, like {@link AddField}, using the
+// given options.
+func AddFieldWithOptions(classBytes []byte, access int, name, descriptor, signature string, constantValue interface{}, visitField func(fieldVisitor FieldVisitor), options AddMemberOptions) ([]byte, error) {
+	reader, err := NewClassReader(classBytes)
+	if err != nil {
+		return nil, err
+	}
+	index := reader.Index()
+	if index.GetField(name) != nil {
+		return nil, errors.New("Illegal Argument - duplicate field " + reader.GetClassName() + "." + name)
+	}
+	symbolTable := NewSymbolTableFromClassReader(reader)
+	symbolTable.SetMajorVersionAndClassName(reader.readUnsignedShort(6), reader.GetClassName())
+
+	fieldWriter := NewFieldWriter(symbolTable, access, name, descriptor, signature, constantValue)
+	if visitField != nil {
+		visitField(fieldWriter)
+	}
+	fieldWriter.VisitEnd()
+	if err := fieldWriter.GetError(); err != nil {
+		return nil, err
+	}
+	return options.verify(appendMember(classBytes, reader, index, symbolTable, true, fieldWriter.ComputeFieldInfoSize, fieldWriter.PutFieldInfo))
+}
+
+// appendMember returns a copy of the given class file, whose constant pool is replaced with the one of the given
+// symbol table (which must extend the constant pool of the class), with a new field or method appended to its
+// fields or methods. The size of the new member_info structure, computed by computeMemberInfoSize, must be
+// computed before the constant pool is written, since it adds the attribute names to it.
+func appendMember(classBytes []byte, reader *ClassReader, index *ClassIndex, symbolTable *SymbolTable, field bool, computeMemberInfoSize func() int, putMemberInfo func(output *ByteVector)) ([]byte, error) {
+	// Computes the offsets of the fields_count, methods_count and of the class attributes_count fields.
+	fieldsCountOffset := reader.header + 8 + reader.readUnsignedShort(reader.header+6)*2
+	methodsCountOffset := fieldsCountOffset + 2
+	if len(index.Fields) > 0 {
+		methodsCountOffset = index.Fields[len(index.Fields)-1].End
+	}
+	attributesCountOffset := methodsCountOffset + 2
+	if len(index.Methods) > 0 {
+		attributesCountOffset = index.Methods[len(index.Methods)-1].End
+	}
+
+	memberInfoSize := computeMemberInfoSize()
+	bootstrapMethodsSize := symbolTable.ComputeBootstrapMethodsSize()
+	if err := symbolTable.GetError(); err != nil {
+		return nil, err
+	}
+	if symbolTable.GetConstantPoolCount() > MAX_CONSTANT_POOL_ENTRIES {
+		return nil, &LimitExceededError{
+			Kind:      ErrClassTooLarge,
+			ClassName: reader.GetClassName(),
+			Value:     symbolTable.GetConstantPoolCount(),
+			Limit:     MAX_CONSTANT_POOL_ENTRIES,
+		}
+	}
+	output := NewByteVectorWithCapacity(len(classBytes) + symbolTable.GetConstantPoolLength() + memberInfoSize + bootstrapMethodsSize)
+	output.PutByteArray(classBytes, 0, 8)
+	symbolTable.PutConstantPool(output)
+	if field {
+		output.PutByteArray(classBytes, reader.header, fieldsCountOffset-reader.header)
+		output.PutShort(len(index.Fields) + 1)
+		output.PutByteArray(classBytes, fieldsCountOffset+2, methodsCountOffset-fieldsCountOffset-2)
+		putMemberInfo(output)
+		output.PutByteArray(classBytes, methodsCountOffset, attributesCountOffset-methodsCountOffset)
+	} else {
+		output.PutByteArray(classBytes, reader.header, methodsCountOffset-reader.header)
+		output.PutShort(len(index.Methods) + 1)
+		output.PutByteArray(classBytes, methodsCountOffset+2, attributesCountOffset-methodsCountOffset-2)
+		putMemberInfo(output)
+	}
+
+	// Copies the class attributes, except the BootstrapMethods attribute, which is replaced with the one of the
+	// symbol table (containing the bootstrap methods of the class and those of the new member).
+	attributesCount := 0
+	for _, attribute := range index.Attributes {
+		if attribute.Name != "BootstrapMethods" {
+			attributesCount++
+		}
+	}
+	if bootstrapMethodsSize > 0 {
+		attributesCount++
+	}
+	output.PutShort(attributesCount)
+	for _, attribute := range index.Attributes {
+		if attribute.Name != "BootstrapMethods" {
+			output.PutByteArray(classBytes, attribute.Start, attribute.End-attribute.Start)
+		}
+	}
+	symbolTable.PutBootstrapMethods(output)
+	return output.Bytes(), nil
+}
+
+// AddClassAnnotation returns a copy of the given class file with a new class annotation of the given type,
+// without element values. The annotation is appended to the RuntimeVisibleAnnotations attribute of the class (or
+// to its RuntimeInvisibleAnnotations attribute if visible is false), which is created if needed. The existing
+// constant pool entries, members and other attributes of the class are copied unchanged. Returns the class file
+// unchanged if the class already has an annotation of this type, visible or not.
+func AddClassAnnotation(classBytes []byte, descriptor string, visible bool) ([]byte, error) {
+	reader, err := NewClassReader(classBytes)
+	if err != nil {
+		return nil, err
+	}
+	index := reader.Index()
+	attributeName := "RuntimeInvisibleAnnotations"
+	if visible {
+		attributeName = "RuntimeVisibleAnnotations"
+	}
+	var annotations *AttributeRange
+	charBuffer := make([]rune, reader.maxStringLength)
+	for i, attribute := range index.Attributes {
+		if attribute.Name != "RuntimeVisibleAnnotations" && attribute.Name != "RuntimeInvisibleAnnotations" {
+			continue
+		}
+		if attribute.Name == attributeName {
+			annotations = &index.Attributes[i]
+		}
+		currentOffset := attribute.Start + 8
+		for numAnnotations := reader.readUnsignedShort(attribute.Start + 6); numAnnotations > 0; numAnnotations-- {
+			if reader.readUTF8(currentOffset, charBuffer) == descriptor {
+				return classBytes, nil
+			}
+			currentOffset = reader.readElementValues(nil, currentOffset+2, true, charBuffer)
+		}
+	}
+	symbolTable := NewSymbolTableFromClassReader(reader)
+	symbolTable.SetMajorVersionAndClassName(reader.readUnsignedShort(6), reader.GetClassName())
+	attributeNameIndex := symbolTable.AddConstantUtf8(attributeName)
+	typeIndex := symbolTable.AddConstantUtf8(descriptor)
+	if err := symbolTable.GetError(); err != nil {
+		return nil, err
+	}
+	if symbolTable.GetConstantPoolCount() > MAX_CONSTANT_POOL_ENTRIES {
+		return nil, &LimitExceededError{
+			Kind:      ErrClassTooLarge,
+			ClassName: reader.GetClassName(),
+			Value:     symbolTable.GetConstantPoolCount(),
+			Limit:     MAX_CONSTANT_POOL_ENTRIES,
+		}
+	}
+
+	// Computes the offset of the class attributes_count field, following the fields and methods.
+	attributesCountOffset := reader.header + 8 + reader.readUnsignedShort(reader.header+6)*2 + 4
+	if len(index.Fields) > 0 {
+		attributesCountOffset = index.Fields[len(index.Fields)-1].End + 2
+	}
+	if len(index.Methods) > 0 {
+		attributesCountOffset = index.Methods[len(index.Methods)-1].End
+	}
+	output := NewByteVectorWithCapacity(len(classBytes) + symbolTable.GetConstantPoolLength() + 10)
+	output.PutByteArray(classBytes, 0, 8)
+	symbolTable.PutConstantPool(output)
+	output.PutByteArray(classBytes, reader.header, attributesCountOffset-reader.header)
+	if annotations == nil {
+		output.PutShort(len(index.Attributes) + 1)
+	} else {
+		output.PutShort(len(index.Attributes))
+	}
+	for _, attribute := range index.Attributes {
+		if annotations == nil || attribute.Start != annotations.Start {
+			output.PutByteArray(classBytes, attribute.Start, attribute.End-attribute.Start)
+			continue
+		}
+		// Copies the existing annotations, followed by the new one.
+		output.PutShort(attributeNameIndex).PutInt(attribute.End - attribute.Start - 2)
+		output.PutShort(reader.readUnsignedShort(attribute.Start+6) + 1)
+		output.PutByteArray(classBytes, attribute.Start+8, attribute.End-attribute.Start-8)
+		output.PutShort(typeIndex).PutShort(0)
+	}
+	if annotations == nil {
+		output.PutShort(attributeNameIndex).PutInt(6).PutShort(1).PutShort(typeIndex).PutShort(0)
+	}
+	return output.Bytes(), nil
+}
+
+// MemberSelector selects the fields or methods of a class with the given name and, if Descriptor is not "", with
+// the given descriptor.
+type MemberSelector struct {
+	Name       string
+	Descriptor string
+}
+
+func (m MemberSelector) matches(member *MemberIndex) bool {
+	return member.Name == m.Name && (m.Descriptor == "" || member.Descriptor == m.Descriptor)
+}
+
+// MemberEditWarning a potential problem caused by {@link RemoveMethod}, {@link RemoveField} or
+// {@link RenameMethod}, which does not prevent the edit: the references to the edited member from other classes
+// are not updated, and must be checked by the caller.
+type MemberEditWarning struct {
+	// Member the edited member, as owner.name followed by its descriptor for a method, or by ':' and its
+	// descriptor for a field.
+	Member string
+	// Message a description of the problem.
+	Message string
+}
+
+func (m MemberEditWarning) String() string {
+	return m.Member + ": " + m.Message
+}
+
+// MemberEditOptions the options of {@link RemoveMethod}, {@link RemoveField} and {@link RenameMethod}.
+type MemberEditOptions struct {
+	// WarningHandler if not nil, is called with each warning about the edit.
+	WarningHandler func(MemberEditWarning)
+}
+
+func (m MemberEditOptions) warn(member, message string) {
+	if m.WarningHandler != nil {
+		m.WarningHandler(MemberEditWarning{member, message})
+	}
+}
+
+// RemoveMethod returns a copy of the given class file without the selected methods. The constant pool is copied
+// unchanged. A warning is reported for each removed method which is still referenced by the class (the removed
+// method may be recursive), and for each one which may override a method of a super type, or be overridden.
+// Returns an error if no method is selected.
+func RemoveMethod(classBytes []byte, selector MemberSelector, options MemberEditOptions) ([]byte, error) {
+	return removeMembers(classBytes, selector, options, false)
+}
+
+// RemoveField returns a copy of the given class file without the selected fields. The constant pool is copied
+// unchanged. A warning is reported for each removed field which is still referenced by the class. Returns an error
+// if no field is selected.
+func RemoveField(classBytes []byte, selector MemberSelector, options MemberEditOptions) ([]byte, error) {
+	return removeMembers(classBytes, selector, options, true)
+}
+
+func removeMembers(classBytes []byte, selector MemberSelector, options MemberEditOptions, field bool) ([]byte, error) {
+	reader, err := NewClassReader(classBytes)
+	if err != nil {
+		return nil, err
+	}
+	className := reader.GetClassName()
+	index := reader.Index()
+	members := index.Methods
+	if field {
+		members = index.Fields
+	}
+	var removed []*MemberIndex
+	for i := range members {
+		if selector.matches(&members[i]) {
+			removed = append(removed, &members[i])
+		}
+	}
+	if len(removed) == 0 {
+		return nil, errors.New("Illegal Argument - no member " + className + "." + selector.Name + selector.Descriptor)
+	}
+
+	references := reader.selfReferences(field)
+	for _, member := range removed {
+		name := memberName(className, member, field)
+		if references[member.Name+member.Descriptor] {
+			options.warn(name, "removed member is still referenced in the constant pool of the class")
+		}
+		if !field && mayOverride(member) {
+			options.warn(name, "removed method may override a method of a super type, or be overridden in a subclass")
+		}
+	}
+
+	// Copies the class file, except the removed member_info structures, and updates the member count.
+	countOffset := members[0].Start - 2
+	output := make([]byte, 0, len(classBytes))
+	output = append(output, classBytes[:countOffset]...)
+	output = append(output, byte((len(members)-len(removed))>>8), byte(len(members)-len(removed)))
+	offset := countOffset + 2
+	for _, member := range removed {
+		output = append(output, classBytes[offset:member.Start]...)
+		offset = member.End
+	}
+	return append(output, classBytes[offset:]...), nil
+}
+
+// RenameMethod returns a copy of the given class file in which the selected methods are renamed to the given
+// name. The references to these methods from the class itself (method calls and method handles) are updated, by
+// redirecting their CONSTANT_Methodref or CONSTANT_InterfaceMethodref entries to new CONSTANT_NameAndType entries.
+// A warning is reported for each renamed method which may override a method of a super type, or be overridden.
+// Returns an error if no method is selected, if a constructor or class initializer is selected, or if a renamed
+// method would clash with an existing method.
+func RenameMethod(classBytes []byte, selector MemberSelector, newName string, options MemberEditOptions) ([]byte, error) {
+	reader, err := NewClassReader(classBytes)
+	if err != nil {
+		return nil, err
+	}
+	className := reader.GetClassName()
+	if selector.Name == "<init>" || selector.Name == "<clinit>" || newName == "<init>" || newName == "<clinit>" {
+		return nil, errors.New("Illegal Argument - constructors and class initializers can't be renamed")
+	}
+	index := reader.Index()
+	renamed := make(map[string]*MemberIndex)
+	for i := range index.Methods {
+		method := &index.Methods[i]
+		if !selector.matches(method) {
+			continue
+		}
+		if index.GetMethod(newName, method.Descriptor) != nil {
+			return nil, errors.New("Illegal Argument - duplicate method " + className + "." + newName + method.Descriptor)
+		}
+		renamed[method.Descriptor] = method
+		if mayOverride(method) {
+			options.warn(memberName(className, method, false),
+				"renamed method may override a method of a super type, or be overridden in a subclass")
+		}
+	}
+	if len(renamed) == 0 {
+		return nil, errors.New("Illegal Argument - no member " + className + "." + selector.Name + selector.Descriptor)
+	}
+
+	// Adds the new constants, and computes the patches of the name_and_type_index of the self references.
+	symbolTable := NewSymbolTableFromClassReader(reader)
+	symbolTable.SetMajorVersionAndClassName(reader.readUnsignedShort(6), className)
+	newNameIndex := symbolTable.AddConstantUtf8(newName)
+	patches := make(map[int]int)
+	charBuffer := make([]rune, reader.maxStringLength)
+	for i := 1; i < len(reader.cpInfoOffsets); i++ {
+		tag := reader.GetItemTag(i)
+		if tag != symbol.CONSTANT_METHODREF_TAG && tag != symbol.CONSTANT_INTERFACE_METHODREF_TAG {
+			continue
+		}
+		owner, name, descriptor := reader.readMemberRef(reader.cpInfoOffsets[i], charBuffer)
+		if owner == className && name == selector.Name && renamed[descriptor] != nil {
+			patches[reader.cpInfoOffsets[i]+2] = symbolTable.AddConstantNameAndType(newName, descriptor)
+		}
+	}
+	if err := symbolTable.GetError(); err != nil {
+		return nil, err
+	}
+	if symbolTable.GetConstantPoolCount() > MAX_CONSTANT_POOL_ENTRIES {
+		return nil, &LimitExceededError{
+			Kind:      ErrClassTooLarge,
+			ClassName: className,
+			Value:     symbolTable.GetConstantPoolCount(),
+			Limit:     MAX_CONSTANT_POOL_ENTRIES,
+		}
+	}
+
+	// The existing constant pool entries keep their offsets, and the rest of the class is shifted by the size of
+	// the new entries.
+	output := NewByteVectorWithCapacity(len(classBytes) + symbolTable.GetConstantPoolLength() - reader.header + 10)
+	output.PutByteArray(classBytes, 0, 8)
+	symbolTable.PutConstantPool(output)
+	shift := output.Size() - reader.header
+	output.PutByteArray(classBytes, reader.header, len(classBytes)-reader.header)
+	result := output.Bytes()
+	for offset, nameAndTypeIndex := range patches {
+		result[offset] = byte(nameAndTypeIndex >> 8)
+		result[offset+1] = byte(nameAndTypeIndex)
+	}
+	for _, method := range renamed {
+		result[method.Start+shift+2] = byte(newNameIndex >> 8)
+		result[method.Start+shift+3] = byte(newNameIndex)
+	}
+	return result, nil
+}
+
+// selfReferences returns the names and descriptors of the fields, or of the methods, of this class which are
+// referenced by a CONSTANT_Fieldref, or CONSTANT_Methodref or CONSTANT_InterfaceMethodref entry of its constant
+// pool.
+func (c *ClassReader) selfReferences(field bool) map[string]bool {
+	className := c.GetClassName()
+	charBuffer := make([]rune, c.maxStringLength)
+	references := make(map[string]bool)
+	for i := 1; i < len(c.cpInfoOffsets); i++ {
+		tag := c.GetItemTag(i)
+		if field && tag != symbol.CONSTANT_FIELDREF_TAG ||
+			!field && tag != symbol.CONSTANT_METHODREF_TAG && tag != symbol.CONSTANT_INTERFACE_METHODREF_TAG {
+			continue
+		}
+		if owner, name, descriptor := c.readMemberRef(c.cpInfoOffsets[i], charBuffer); owner == className {
+			references[name+descriptor] = true
+		}
+	}
+	return references
+}
+
+// mayOverride returns whether the given method may override a method of a super type, or be overridden.
+func mayOverride(method *MemberIndex) bool {
+	return (method.Access&(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC)) == 0 && method.Name != "<init>" && method.Name != "<clinit>"
+}
+
+// memberName returns the name of the given member of the given class, in the format of
+// {@link MemberEditWarning#Member}.
+func memberName(className string, member *MemberIndex, field bool) string {
+	if field {
+		return className + "." + member.Name + ":" + member.Descriptor
+	}
+	return className + "." + member.Name + member.Descriptor
+}
@@ -0,0 +1,258 @@
+package asm_test
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestAddMethod(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	sourceFileName := classFile.SymbolTable.AddConstantUtf8("A.java")
+	classFile.AddAttribute("SourceFile", asm.NewByteVector().PutShort(sourceFileName).Bytes())
+
+	buildMax := func(methodVisitor asm.MethodVisitor) {
+		other := &asm.Label{}
+		methodVisitor.VisitCode()
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 1)
+		methodVisitor.VisitJumpInsn(opcodes.IF_ICMPLT, other)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitLabel(other)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 1)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	}
+	result, err := asm.AddMethod(classFile.Bytes(), opcodes.ACC_PUBLIC|opcodes.ACC_STATIC, "max", "(II)I", buildMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asm.AddMethod(result, opcodes.ACC_STATIC, "max", "(II)I", buildMax); err == nil {
+		t.Error("expected an error for a duplicate method")
+	}
+	result, err = asm.AddMethod(result, opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "run", "()V", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := asm.NewClassReader(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classNode := tree.NewClassNode()
+	reader.Accept(classNode, 0)
+	if classNode.SourceFile != "A.java" || len(classNode.Methods) != 2 || classNode.Methods[1].Name != "run" {
+		t.Fatalf("unexpected class %q %d", classNode.SourceFile, len(classNode.Methods))
+	}
+	method := classNode.Methods[0]
+	labelNames := tree.GetLabelNames(method)
+	var insns []string
+	for _, insn := range method.Instructions {
+		insns = append(insns, tree.InsnToString(insn, labelNames))
+	}
+	expected := "ILOAD 0\nILOAD 1\nIF_ICMPLT L0\nILOAD 0\nIRETURN\nL0:\nFRAME SAME [] []\nILOAD 1\nIRETURN"
+	if strings.Join(insns, "\n") != expected {
+		t.Errorf("unexpected instructions:\n%s", strings.Join(insns, "\n"))
+	}
+	if method.MaxStack != 2 || method.MaxLocals != 2 {
+		t.Errorf("unexpected maxs %d %d", method.MaxStack, method.MaxLocals)
+	}
+}
+
+func TestAddMethodWideJumps(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+
+	// Both methods jump forward over 33000 bytes of NOP instructions, with a conditional jump and with a GOTO.
+	result, err := asm.AddMethod(classFile.Bytes(), opcodes.ACC_STATIC, "conditional", "(I)V", func(methodVisitor asm.MethodVisitor) {
+		end := &asm.Label{}
+		methodVisitor.VisitCode()
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+		methodVisitor.VisitJumpInsn(opcodes.IFEQ, end)
+		for i := 0; i < 33000; i++ {
+			methodVisitor.VisitInsn(opcodes.NOP)
+		}
+		methodVisitor.VisitLabel(end)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = asm.AddMethod(result, opcodes.ACC_STATIC, "unconditional", "(I)V", func(methodVisitor asm.MethodVisitor) {
+		nops, end := &asm.Label{}, &asm.Label{}
+		methodVisitor.VisitCode()
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+		methodVisitor.VisitJumpInsn(opcodes.IFEQ, nops)
+		methodVisitor.VisitJumpInsn(opcodes.GOTO, end)
+		methodVisitor.VisitLabel(nops)
+		for i := 0; i < 33000; i++ {
+			methodVisitor.VisitInsn(opcodes.NOP)
+		}
+		methodVisitor.VisitLabel(end)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	classNode, err := tree.ReadClassNode(result, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"conditional":   "ILOAD 0\nIFNE L0\nGOTO L1\nL0:\nFRAME SAME [] []\n",
+		"unconditional": "ILOAD 0\nIFEQ L0\nGOTO L1\nL0:\nFRAME SAME [] []\n",
+	}
+	for _, method := range classNode.Methods {
+		labelNames := tree.GetLabelNames(method)
+		var insns []string
+		for _, insn := range method.Instructions {
+			if insn.GetOpcode() != opcodes.NOP {
+				insns = append(insns, tree.InsnToString(insn, labelNames))
+			}
+		}
+		// The end label is followed by a frame, since it is the target of a jump.
+		actual := strings.Join(insns, "\n")
+		if actual != expected[method.Name]+"L1:\nFRAME SAME [] []\nRETURN" {
+			t.Errorf("unexpected instructions of %s:\n%s", method.Name, actual)
+		}
+	}
+	// The GOTO instructions are read from GOTO_W instructions: the code is made of an ILOAD, an IFEQ or IFNE, a
+	// GOTO_W, the NOP instructions and a RETURN.
+	reader, err := asm.NewClassReader(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range reader.Index().Methods {
+		code := method.GetAttribute("Code")
+		if codeLength := binary.BigEndian.Uint32(result[code.Start+10:]); codeLength != 1+3+5+33000+1 {
+			t.Errorf("unexpected code length of %s: %d", method.Name, codeLength)
+		}
+	}
+}
+
+func TestAddField(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	result, err := asm.AddMethod(classFile.Bytes(), opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "run", "()V", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = asm.AddField(result, opcodes.ACC_STATIC|opcodes.ACC_FINAL|opcodes.ACC_DEPRECATED, "MAX", "J", "", int64(1)<<40,
+		func(fieldVisitor asm.FieldVisitor) {
+			fieldVisitor.VisitAttribute(asm.NewAttributeWithContent("Custom", []byte{1, 2, 3}))
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err = asm.AddField(result, 0, "names", "Ljava/util/List;", "Ljava/util/List<Ljava/lang/String;>;", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asm.AddField(result, 0, "MAX", "I", "", nil, nil); err == nil {
+		t.Error("expected an error for a duplicate field")
+	}
+	if _, err := asm.AddField(result, 0, "x", "I", "", []int{1}, nil); err == nil {
+		t.Error("expected an error for an unsupported constant value")
+	}
+
+	reader, err := asm.NewClassReader(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classNode := tree.NewClassNode()
+	reader.Accept(classNode, 0)
+	if len(classNode.Fields) != 2 || len(classNode.Methods) != 1 {
+		t.Fatalf("unexpected members %d %d", len(classNode.Fields), len(classNode.Methods))
+	}
+	constant, names := classNode.Fields[0], classNode.Fields[1]
+	if constant.Name != "MAX" || constant.Value != int64(1)<<40 || constant.Access&opcodes.ACC_DEPRECATED == 0 {
+		t.Errorf("unexpected field %+v", constant)
+	}
+	if names.Signature != "Ljava/util/List<Ljava/lang/String;>;" || names.Value != nil {
+		t.Errorf("unexpected field %+v", names)
+	}
+	if custom := reader.Index().GetField("MAX").GetAttribute("Custom"); custom == nil || custom.End-custom.Start != 9 {
+		t.Errorf("unexpected custom attribute %v", custom)
+	}
+}
+
+func TestRenameAndRemoveMembers(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	result, err := asm.AddMethod(classFile.Bytes(), opcodes.ACC_PUBLIC, "foo", "()I", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitCode()
+		methodVisitor.VisitInsn(opcodes.ICONST_1)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = asm.AddMethod(result, opcodes.ACC_PRIVATE, "bar", "()I", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitCode()
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "A", "foo", "()I", false)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err = asm.AddField(result, 0, "f", "I", "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	options := asm.MemberEditOptions{WarningHandler: func(warning asm.MemberEditWarning) {
+		warnings = append(warnings, warning.String())
+	}}
+	if _, err := asm.RenameMethod(result, asm.MemberSelector{Name: "foo"}, "bar", options); err == nil {
+		t.Error("expected an error for a clashing method")
+	}
+	renamed, err := asm.RenameMethod(result, asm.MemberSelector{Name: "foo", Descriptor: "()I"}, "baz", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := asm.RemoveMethod(renamed, asm.MemberSelector{Name: "baz"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed, err = asm.RemoveField(removed, asm.MemberSelector{Name: "f"}, options); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asm.RemoveField(removed, asm.MemberSelector{Name: "f"}, options); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+	expected := "A.foo()I: renamed method may override a method of a super type, or be overridden in a subclass\n" +
+		"A.baz()I: removed member is still referenced in the constant pool of the class\n" +
+		"A.baz()I: removed method may override a method of a super type, or be overridden in a subclass"
+	if strings.Join(warnings, "\n") != expected {
+		t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+
+	reader, err := asm.NewClassReader(renamed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classNode := tree.NewClassNode()
+	reader.Accept(classNode, 0)
+	call, ok := classNode.Methods[1].Instructions[1].(*tree.MethodInsnNode)
+	if classNode.Methods[0].Name != "baz" || !ok || call.Owner != "A" || call.Name != "baz" || call.Descriptor != "()I" {
+		t.Errorf("unexpected renamed class %s %v", classNode.Methods[0].Name, classNode.Methods[1].Instructions[1])
+	}
+	reader, err = asm.NewClassReader(removed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classNode = tree.NewClassNode()
+	reader.Accept(classNode, 0)
+	if len(classNode.Methods) != 1 || classNode.Methods[0].Name != "bar" || len(classNode.Fields) != 0 {
+		t.Errorf("unexpected class after removals %d %d", len(classNode.Methods), len(classNode.Fields))
+	}
+}
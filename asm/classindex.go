@@ -0,0 +1,112 @@
+package asm
+
+// AttributeRange the location of an attribute in a class file.
+type AttributeRange struct {
+	Name string
+	// Start the offset of the attribute, i.e. of its attribute_name_index field. Its content starts at Start + 6.
+	Start int
+	// End the offset following the attribute.
+	End int
+}
+
+// MemberIndex the location of a field or method in a class file.
+type MemberIndex struct {
+	Access     int
+	Name       string
+	Descriptor string
+	// Start the offset of the field_info or method_info structure, i.e. of its access_flags field.
+	Start int
+	// End the offset following the structure.
+	End        int
+	Attributes []AttributeRange
+}
+
+// GetAttribute returns the attribute of the member with the given name, or nil.
+func (m *MemberIndex) GetAttribute(name string) *AttributeRange {
+	return getAttributeRange(m.Attributes, name)
+}
+
+// ClassIndex the structural index of a class file, returned by {@link ClassReader#Index}.
+type ClassIndex struct {
+	Fields     []MemberIndex
+	Methods    []MemberIndex
+	Attributes []AttributeRange
+}
+
+// GetMethod returns the method with the given name and descriptor, or nil.
+func (c *ClassIndex) GetMethod(name, descriptor string) *MemberIndex {
+	for i := range c.Methods {
+		if c.Methods[i].Name == name && c.Methods[i].Descriptor == descriptor {
+			return &c.Methods[i]
+		}
+	}
+	return nil
+}
+
+// GetField returns the field with the given name, or nil.
+func (c *ClassIndex) GetField(name string) *MemberIndex {
+	for i := range c.Fields {
+		if c.Fields[i].Name == name {
+			return &c.Fields[i]
+		}
+	}
+	return nil
+}
+
+// GetAttribute returns the class attribute with the given name, or nil.
+func (c *ClassIndex) GetAttribute(name string) *AttributeRange {
+	return getAttributeRange(c.Attributes, name)
+}
+
+func getAttributeRange(attributes []AttributeRange, name string) *AttributeRange {
+	for i := range attributes {
+		if attributes[i].Name == name {
+			return &attributes[i]
+		}
+	}
+	return nil
+}
+
+// Index returns the structural index of the class: the byte ranges of its fields, methods and attributes, so
+// that tools can seek directly to the members of interest (and patch them in place), without visiting the
+// whole class. The offsets are relative to the class file given to the reader.
+func (c *ClassReader) Index() *ClassIndex {
+	charBuffer := make([]rune, c.maxStringLength)
+	index := &ClassIndex{}
+	currentOffset := c.header + 8 + c.readUnsignedShort(c.header+6)*2
+	index.Fields, currentOffset = c.indexMembers(currentOffset, charBuffer)
+	index.Methods, currentOffset = c.indexMembers(currentOffset, charBuffer)
+	index.Attributes, _ = c.indexAttributes(currentOffset, charBuffer)
+	return index
+}
+
+// indexMembers returns the index of the fields or methods whose count starts at the given offset, and the
+// offset following them.
+func (c *ClassReader) indexMembers(offset int, charBuffer []rune) ([]MemberIndex, int) {
+	members := make([]MemberIndex, c.readUnsignedShort(offset))
+	currentOffset := offset + 2
+	for i := range members {
+		members[i] = MemberIndex{
+			Access:     c.readUnsignedShort(currentOffset),
+			Name:       c.readUTF8(currentOffset+2, charBuffer),
+			Descriptor: c.readUTF8(currentOffset+4, charBuffer),
+			Start:      currentOffset,
+		}
+		members[i].Attributes, currentOffset = c.indexAttributes(currentOffset+6, charBuffer)
+		members[i].End = currentOffset
+	}
+	return members, currentOffset
+}
+
+// indexAttributes returns the ranges of the attributes whose count starts at the given offset, and the offset
+// following them.
+func (c *ClassReader) indexAttributes(offset int, charBuffer []rune) ([]AttributeRange, int) {
+	attributes := make([]AttributeRange, c.readUnsignedShort(offset))
+	currentOffset := offset + 2
+	for i := range attributes {
+		start := currentOffset
+		currentOffset += 6 + c.readInt(currentOffset+2)
+		attributes[i] = AttributeRange{c.readUTF8(start, charBuffer), start, currentOffset}
+	}
+	return attributes, currentOffset
+}
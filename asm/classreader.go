@@ -1,11 +1,13 @@
 package asm
 
 import (
-	"errors"
+	"math"
+	"strconv"
 
 	"github.com/leaklessgfy/asm/asm/constants"
 	"github.com/leaklessgfy/asm/asm/frame"
 	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/raw"
 	"github.com/leaklessgfy/asm/asm/symbol"
 	"github.com/leaklessgfy/asm/asm/typereference"
 )
@@ -20,6 +22,7 @@ type ClassReader struct {
 	constantUtf8Values []string
 	maxStringLength    int
 	header             int
+	moduleWarnings     func(ModuleWarning)
 }
 
 // SKIP_CODE a flag to skip the Code attributes. If this flag is set the Code attributes are neither parsed nor visited.
@@ -44,6 +47,15 @@ const SKIP_FRAMES = 4
 // degrades performance quite a lot).
 const EXPAND_FRAMS = 8
 
+// PRESERVE_UNKNOWN_ATTRIBUTES a flag to preserve the attributes for which no attribute prototype is given. If this
+// flag is set these attributes keep their exact bytes and a reference to this reader (see
+// {@link Attribute#IsPreserved}). They are written back byte for byte by the field and method writers using a
+// symbol table built from this reader (see {@link NewSymbolTableFromClassReader}), and the other writers reject
+// them, since the constant pool indices they may contain would be invalid. If this flag is not set they are
+// visited as generic attributes, written as is by any writer, whose content may refer to constant pool entries
+// which no longer exist.
+const PRESERVE_UNKNOWN_ATTRIBUTES = 16
+
 // EXPAND_ASM_INSNS A flag to expand the ASM specific instructions into an equivalent sequence of standard bytecode
 // instructions. When resolving a forward jump it may happen that the signed 2 bytes offset
 // reserved for it is not sufficient to store the bytecode offset. In this case the jump
@@ -55,6 +67,13 @@ const EXPAND_FRAMS = 8
 // goto_w in ClassWriter cannot occur.
 const EXPAND_ASM_INSNS = 256
 
+// SKIP_INVISIBLE_ANNOTATIONS a flag to skip the RuntimeInvisibleAnnotations, RuntimeInvisibleParameterAnnotations
+// and RuntimeInvisibleTypeAnnotations attributes of the class, fields, methods and Code, i.e. the annotations
+// with a CLASS retention policy. If this flag is set these attributes are neither parsed nor visited, and only
+// the annotations visible at runtime (i.e. with visible set to true) are visited. This flag is useful for the
+// scanners which only look for the annotations which can be seen by reflection.
+const SKIP_INVISIBLE_ANNOTATIONS = 32
+
 // NewClassReader constructs a new {@link ClassReader} object.
 func NewClassReader(classFile []byte) (*ClassReader, error) {
 	return classReader(classFile, 0, len(classFile))
@@ -65,51 +84,21 @@ func classReader(byteBuffer []byte, offset int, length int) (*ClassReader, error
 		b: byteBuffer,
 	}
 
-	if reader.readShort(offset+6) > opcodes.V10 {
-		return nil, errors.New("Illegal Argument")
+	if version, err := raw.ReadS2(byteBuffer, offset+6); err != nil {
+		return nil, err
+	} else if version > opcodes.V17 {
+		return nil, raw.NewParseError(raw.ErrUnsupportedVersion, offset+6, "unsupported class file major version "+
+			strconv.Itoa(int(version)))
 	}
 
-	constantPoolCount := reader.readUnsignedShort(offset + 8)
-	reader.cpInfoOffsets = make([]int, constantPoolCount)
-	reader.constantUtf8Values = make([]string, constantPoolCount)
-	currentCpInfoOffset := offset + 10
-	maxStringLength := 0
-
-	for i := 1; i < constantPoolCount; i++ {
-		reader.cpInfoOffsets[i] = currentCpInfoOffset + 1
-		var cpInfoSize int
-
-		switch byteBuffer[currentCpInfoOffset] {
-		case byte(symbol.CONSTANT_FIELDREF_TAG), byte(symbol.CONSTANT_METHODREF_TAG), byte(symbol.CONSTANT_INTERFACE_METHODREF_TAG),
-			byte(symbol.CONSTANT_INTEGER_TAG), byte(symbol.CONSTANT_FLOAT_TAG), byte(symbol.CONSTANT_NAME_AND_TYPE_TAG),
-			byte(symbol.CONSTANT_INVOKE_DYNAMIC_TAG):
-			cpInfoSize = 5
-			break
-		case byte(symbol.CONSTANT_LONG_TAG), byte(symbol.CONSTANT_DOUBLE_TAG):
-			cpInfoSize = 9
-			i++
-			break
-		case byte(symbol.CONSTANT_UTF8_TAG):
-			cpInfoSize = 3 + reader.readUnsignedShort(currentCpInfoOffset+1)
-			if cpInfoSize > maxStringLength {
-				maxStringLength = cpInfoSize
-			}
-			break
-		case byte(symbol.CONSTANT_METHOD_HANDLE_TAG):
-			cpInfoSize = 4
-			break
-		case byte(symbol.CONSTANT_CLASS_TAG), byte(symbol.CONSTANT_STRING_TAG), byte(symbol.CONSTANT_METHOD_TYPE_TAG),
-			byte(symbol.CONSTANT_PACKAGE_TAG), byte(symbol.CONSTANT_MODULE_TAG):
-			cpInfoSize = 3
-			break
-		default:
-			return nil, errors.New("Assertion Error")
-		}
-		currentCpInfoOffset += cpInfoSize
+	constantPool, err := raw.ReadConstantPool(byteBuffer, offset)
+	if err != nil {
+		return nil, err
 	}
-
-	reader.maxStringLength = maxStringLength
-	reader.header = currentCpInfoOffset
+	reader.cpInfoOffsets = constantPool.Offsets
+	reader.constantUtf8Values = make([]string, len(constantPool.Offsets))
+	reader.maxStringLength = constantPool.MaxStringLength
+	reader.header = constantPool.Header
 
 	return reader, nil
 }
@@ -137,6 +126,36 @@ func (c *ClassReader) GetSuperName() string {
 	return c.readClass(c.header+4, charBuffer)
 }
 
+// GetMinorVersion returns the minor_version of the class file.
+func (c *ClassReader) GetMinorVersion() int {
+	return c.readUnsignedShort(c.getConstantPoolOffset() - 6)
+}
+
+// GetMajorVersion returns the major_version of the class file (see {@link Opcodes#V1_1} to {@link Opcodes#V17}).
+func (c *ClassReader) GetMajorVersion() int {
+	return c.readUnsignedShort(c.getConstantPoolOffset() - 4)
+}
+
+// GetConstantPoolSizeInBytes returns the size in bytes of the class's constant pool table, excluding the
+// constant_pool_count.
+func (c *ClassReader) GetConstantPoolSizeInBytes() int {
+	return c.header - c.getConstantPoolOffset()
+}
+
+// getConstantPoolOffset returns the offset of the first entry of the constant pool table.
+func (c *ClassReader) getConstantPoolOffset() int {
+	if len(c.cpInfoOffsets) > 1 {
+		return c.cpInfoOffsets[1] - 1
+	}
+	return c.header
+}
+
+// SetModuleWarningHandler sets the function called for each {@link ModuleWarning} found while visiting the
+// Module attribute. By default the Module attribute is not checked.
+func (c *ClassReader) SetModuleWarningHandler(handler func(ModuleWarning)) {
+	c.moduleWarnings = handler
+}
+
 // GetInterfaces returns the internal names of the implemented interfaces (see {@link Type#getInternalName()}).
 func (c ClassReader) GetInterfaces() []string {
 	currentOffset := c.header + 6
@@ -157,18 +176,38 @@ func (c ClassReader) GetInterfaces() []string {
 // -----------------------------------------------------------------------------------------------
 
 // Accept Makes the given visitor visit the JVMS ClassFile structure passed to the constructor of this {@link ClassReader}.
+// The events are visited in the order of the Java ASM ClassReader: the annotations, then the type annotations
+// (visible ones first), then the non standard attributes (in the reverse order of the class file), then the inner
+// classes, the fields and the methods. Adapters ported from Java can thus rely on this order, which is the only one
+// provided.
 func (c ClassReader) Accept(classVisitor ClassVisitor, parsingOptions int) {
 	c.AcceptB(classVisitor, make([]*Attribute, 0), parsingOptions)
 }
 
 // AcceptB Makes the given visitor visit the JVMS ClassFile structure passed to the constructor of this {@link ClassReader}.
 func (c ClassReader) AcceptB(classVisitor ClassVisitor, attributePrototypes []*Attribute, parsingOptions int) {
-	context := &Context{
+	c.accept(classVisitor, &Context{
 		attributePrototypes: attributePrototypes,
 		parsingOptions:      parsingOptions,
 		charBuffer:          make([]rune, c.maxStringLength),
-	}
+	})
+}
 
+// AcceptFiltered Makes the given visitor visit the JVMS ClassFile structure passed to the constructor of this
+// {@link ClassReader}, restricted to the fields and methods accepted by the given filter. The other members
+// are skipped without being parsed.
+func (c ClassReader) AcceptFiltered(classVisitor ClassVisitor, filter Filter, parsingOptions int) {
+	c.accept(classVisitor, &Context{
+		attributePrototypes: make([]*Attribute, 0),
+		parsingOptions:      parsingOptions,
+		charBuffer:          make([]rune, c.maxStringLength),
+		filter:              &filter,
+	})
+}
+
+func (c ClassReader) accept(classVisitor ClassVisitor, context *Context) {
+	attributePrototypes := context.attributePrototypes
+	parsingOptions := context.parsingOptions
 	charBuffer := context.charBuffer
 	currentOffset := c.header
 	accessFlags := c.readUnsignedShort(currentOffset)
@@ -231,10 +270,14 @@ func (c ClassReader) AcceptB(classVisitor ClassVisitor, attributePrototypes []*A
 			sourceDebugExtension = c.readUTFB(currentAttributeOffset, attributeLength, make([]rune, attributeLength))
 			break
 		case "RuntimeInvisibleAnnotations":
-			runtimeInvisibleAnnotationsOffset = currentAttributeOffset
+			if (parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				runtimeInvisibleAnnotationsOffset = currentAttributeOffset
+			}
 			break
 		case "RuntimeInvisibleTypeAnnotations":
-			runtimeInvisibleTypeAnnotationsOffset = currentAttributeOffset
+			if (parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				runtimeInvisibleTypeAnnotationsOffset = currentAttributeOffset
+			}
 			break
 		case "Module":
 			moduleOffset = currentAttributeOffset
@@ -255,7 +298,7 @@ func (c ClassReader) AcceptB(classVisitor ClassVisitor, attributePrototypes []*A
 			context.bootstrapMethodOffsets = bootstrapMethodOffsets
 			break
 		default:
-			attribute := c.readAttribute(attributePrototypes, attributeName, currentAttributeOffset, attributeLength, charBuffer, -1, nil)
+			attribute := c.readAttribute((parsingOptions&PRESERVE_UNKNOWN_ATTRIBUTES) != 0, attributePrototypes, attributeName, currentAttributeOffset, attributeLength, charBuffer, -1, nil)
 			attribute.nextAttribute = attributes
 			attributes = attribute
 		}
@@ -307,13 +350,14 @@ func (c ClassReader) AcceptB(classVisitor ClassVisitor, attributePrototypes []*A
 	}
 
 	if runtimeVisibleTypeAnnotationsOffset != 0 {
-		numAnnotations := c.readUnsignedShort(runtimeInvisibleAnnotationsOffset)
-		currentAnnotationOffset := runtimeInvisibleAnnotationsOffset + 2
+		numAnnotations := c.readUnsignedShort(runtimeVisibleTypeAnnotationsOffset)
+		currentAnnotationOffset := runtimeVisibleTypeAnnotationsOffset + 2
 		for numAnnotations > 0 {
 			numAnnotations--
+			currentAnnotationOffset = c.readTypeAnnotationTarget(context, currentAnnotationOffset)
 			annotationDescriptor := c.readUTF8(currentAnnotationOffset, charBuffer)
 			currentAnnotationOffset += 2
-			currentAnnotationOffset = c.readElementValues(classVisitor.VisitAnnotation(annotationDescriptor, false), currentAnnotationOffset, true, charBuffer)
+			currentAnnotationOffset = c.readElementValues(classVisitor.VisitTypeAnnotation(context.currentTypeAnnotationTarget, context.currentTypeAnnotationTargetPath, annotationDescriptor, true), currentAnnotationOffset, true, charBuffer)
 		}
 	}
 
@@ -355,12 +399,20 @@ func (c ClassReader) AcceptB(classVisitor ClassVisitor, attributePrototypes []*A
 	currentOffset += 2
 	for fieldsCount > 0 {
 		fieldsCount--
+		if context.filter != nil && !context.filter.acceptField(c.readUnsignedShort(currentOffset), c.readUTF8(currentOffset+2, charBuffer), c.readUTF8(currentOffset+4, charBuffer)) {
+			currentOffset = c.skipMember(currentOffset)
+			continue
+		}
 		currentOffset = c.readField(classVisitor, context, currentOffset)
 	}
 	methodsCount := c.readUnsignedShort(currentOffset)
 	currentOffset += 2
 	for methodsCount > 0 {
 		methodsCount--
+		if context.filter != nil && !context.filter.acceptMethod(c.readUnsignedShort(currentOffset), c.readUTF8(currentOffset+2, charBuffer), c.readUTF8(currentOffset+4, charBuffer)) {
+			currentOffset = c.skipMember(currentOffset)
+			continue
+		}
 		currentOffset = c.readMethod(classVisitor, context, currentOffset)
 	}
 
@@ -382,6 +434,14 @@ func (c ClassReader) readModule(classVisitor ClassVisitor, context *Context, mod
 	if moduleVisitor == nil {
 		return
 	}
+	var checker *moduleChecker
+	if c.moduleWarnings != nil {
+		checker = newModuleChecker(c.moduleWarnings, moduleName, moduleFlags, c.readUnsignedShort(c.cpInfoOffsets[1]-5))
+	}
+
+	if moduleMainClass != "" {
+		moduleVisitor.VisitMainClass(moduleMainClass)
+	}
 
 	if modulePackagesOffset != 0 {
 		packageCount := c.readUnsignedShort(modulePackagesOffset)
@@ -401,6 +461,9 @@ func (c ClassReader) readModule(classVisitor ClassVisitor, context *Context, mod
 		requiresFlags := c.readUnsignedShort(currentOffset + 2)
 		requiresVersion := c.readUTF8(currentOffset+4, buffer)
 		currentOffset += 6
+		if checker != nil {
+			checker.checkRequire(requires, requiresFlags)
+		}
 		moduleVisitor.VisitRequire(requires, requiresFlags, requiresVersion)
 	}
 
@@ -420,6 +483,9 @@ func (c ClassReader) readModule(classVisitor ClassVisitor, context *Context, mod
 				currentOffset += 2
 			}
 		}
+		if checker != nil {
+			checker.checkExport(exports, exportsFlags)
+		}
 		moduleVisitor.VisitExport(exports, exportsFlags, exportsTo...)
 	}
 
@@ -439,6 +505,9 @@ func (c ClassReader) readModule(classVisitor ClassVisitor, context *Context, mod
 				currentOffset += 2
 			}
 		}
+		if checker != nil {
+			checker.checkOpen(opens, opensFlags)
+		}
 		moduleVisitor.VisitOpen(opens, opensFlags, opensTo...)
 	}
 
@@ -462,9 +531,15 @@ func (c ClassReader) readModule(classVisitor ClassVisitor, context *Context, mod
 			providesWith[i] = c.readClass(currentOffset, buffer)
 			currentOffset += 2
 		}
+		if checker != nil {
+			checker.checkProvide(provides, providesWith)
+		}
 		moduleVisitor.VisitProvide(provides, providesWith...)
 	}
 
+	if checker != nil {
+		checker.checkEnd()
+	}
 	moduleVisitor.VisitEnd()
 }
 
@@ -517,13 +592,17 @@ func (c ClassReader) readField(classVisitor ClassVisitor, context *Context, fiel
 			runtimeVisibleTypeAnnotationsOffset = currentOffset
 			break
 		case "RuntimeInvisibleAnnotations":
-			runtimeInvisibleAnnotationsOffset = currentOffset
+			if (context.parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				runtimeInvisibleAnnotationsOffset = currentOffset
+			}
 			break
 		case "RuntimeInvisibleTypeAnnotations":
-			runtimeInvisibleTypeAnnotationsOffset = currentOffset
+			if (context.parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				runtimeInvisibleTypeAnnotationsOffset = currentOffset
+			}
 			break
 		default:
-			attribute := c.readAttribute(context.attributePrototypes, attributeName, currentOffset, attributeLength, charBuffer, -1, nil)
+			attribute := c.readAttribute((context.parsingOptions&PRESERVE_UNKNOWN_ATTRIBUTES) != 0, context.attributePrototypes, attributeName, currentOffset, attributeLength, charBuffer, -1, nil)
 			attribute.nextAttribute = attributes
 			attributes = attribute
 			break
@@ -659,22 +738,28 @@ func (c ClassReader) readMethod(classVisitor ClassVisitor, context *Context, met
 			context.currentMethodAccessFlags |= opcodes.ACC_SYNTHETIC
 			break
 		case "RuntimeInvisibleAnnotations":
-			runtimeInvisibleAnnotationsOffset = currentOffset
+			if (context.parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				runtimeInvisibleAnnotationsOffset = currentOffset
+			}
 			break
 		case "RuntimeInvisibleTypeAnnotations":
-			runtimeInvisibleTypeAnnotationsOffset = currentOffset
+			if (context.parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				runtimeInvisibleTypeAnnotationsOffset = currentOffset
+			}
 			break
 		case "RuntimeVisibleParameterAnnotations":
 			runtimeVisibleParameterAnnotationsOffset = currentOffset
 			break
 		case "RuntimeInvisibleParameterAnnotations":
-			runtimeInvisibleParameterAnnotationsOffset = currentOffset
+			if (context.parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				runtimeInvisibleParameterAnnotationsOffset = currentOffset
+			}
 			break
 		case "MethodParameters":
 			methodParametersOffset = currentOffset
 			break
 		default:
-			attribute := c.readAttribute(context.attributePrototypes, attributeName, currentOffset, attributeLength, charBuffer, -1, nil)
+			attribute := c.readAttribute((context.parsingOptions&PRESERVE_UNKNOWN_ATTRIBUTES) != 0, context.attributePrototypes, attributeName, currentOffset, attributeLength, charBuffer, -1, nil)
 			attribute.nextAttribute = attributes
 			attributes = attribute
 			break
@@ -886,8 +971,8 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 			currentOffset += 4
 			break
 		default:
-			//throw error
-			panic(errors.New("AssertionError"))
+			panic(raw.NewParseError(raw.ErrUnknownOpcode, currentOffset, "unknown opcode "+strconv.Itoa(int(opcode))+
+				" at offset "+strconv.Itoa(currentOffset)))
 			break
 		}
 	}
@@ -962,23 +1047,27 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 			visibleTypeAnnotationOffsets = c.readTypeAnnotations(methodVisitor, context, currentOffset, true)
 			break
 		case "RuntimeInvisibleTypeAnnotations":
-			invisibleTypeAnnotationOffsets = c.readTypeAnnotations(methodVisitor, context, currentOffset, false)
+			if (context.parsingOptions & SKIP_INVISIBLE_ANNOTATIONS) == 0 {
+				invisibleTypeAnnotationOffsets = c.readTypeAnnotations(methodVisitor, context, currentOffset, false)
+			}
 			break
 		case "StackMapTable":
 			if (context.parsingOptions & SKIP_FRAMES) == 0 {
 				stackMapFrameOffset = currentOffset + 2
 				stackMapTableEndOffset = currentOffset + attributeLength
+				compressedFrames = true
 			}
 			break
 		case "StackMap":
-			if (context.parsingOptions & SKIP_FRAMES) == 0 {
+			// A StackMapTable attribute takes precedence over a legacy StackMap attribute, whatever their order.
+			if (context.parsingOptions&SKIP_FRAMES) == 0 && (stackMapFrameOffset == 0 || !compressedFrames) {
 				stackMapFrameOffset = currentOffset + 2
 				stackMapTableEndOffset = currentOffset + attributeLength
 				compressedFrames = false
 			}
 			break
 		default:
-			attribute := c.readAttribute(context.attributePrototypes, attributeName, currentOffset, attributeLength, charBuffer, codeOffset, labels)
+			attribute := c.readAttribute((context.parsingOptions&PRESERVE_UNKNOWN_ATTRIBUTES) != 0, context.attributePrototypes, attributeName, currentOffset, attributeLength, charBuffer, codeOffset, labels)
 			attribute.nextAttribute = attributes
 			attributes = attribute
 			break
@@ -1032,8 +1121,11 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 			currentLabel.accept(methodVisitor, (context.parsingOptions&SKIP_DEBUG) == 0)
 		}
 
-		for stackMapFrameOffset != 0 && (context.currentFrameOffset == currentBytecodeOffset || context.currentFrameOffset == -1) {
-			if context.currentFrameOffset != -1 {
+		// The frames of a legacy StackMap attribute have absolute offsets, which are not necessarily sorted: the
+		// frames whose offset is before the current instruction are ignored.
+		for stackMapFrameOffset != 0 && (context.currentFrameOffset == currentBytecodeOffset || context.currentFrameOffset == -1 ||
+			(!compressedFrames && context.currentFrameOffset < currentBytecodeOffset)) {
+			if context.currentFrameOffset == currentBytecodeOffset {
 				if !compressedFrames || expandFrames {
 					methodVisitor.VisitFrame(opcodes.F_NEW, context.currentFrameLocalCount, context.currentFrameLocalTypes, context.currentFrameStackCount, context.currentFrameStackTypes)
 				} else {
@@ -1195,7 +1287,7 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 			currentOffset += 2
 			break
 		case constants.BIPUSH, constants.NEWARRAY:
-			methodVisitor.VisitIntInsn(int(opcode), int(b[currentOffset+1]))
+			methodVisitor.VisitIntInsn(int(opcode), int(int8(b[currentOffset+1])))
 			currentOffset += 2
 			break
 		case constants.SIPUSH:
@@ -1247,7 +1339,7 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 					bootstrapMethodArguments[i], _ = c.readConst(c.readUnsignedShort(bootstrapMethodOffset), charBuffer)
 					bootstrapMethodOffset += 2
 				}
-				methodVisitor.VisitInvokeDynamicInsn(name, desc, handle.(*Handle), bootstrapMethodArguments)
+				methodVisitor.VisitInvokeDynamicInsn(name, desc, handle.(*Handle), bootstrapMethodArguments...)
 				currentOffset += 5
 				break
 			}
@@ -1256,7 +1348,7 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 			currentOffset += 3
 			break
 		case constants.IINC:
-			methodVisitor.VisitIincInsn(int(b[currentOffset+1]&0xFF), int(b[currentOffset+2]))
+			methodVisitor.VisitIincInsn(int(b[currentOffset+1]&0xFF), int(int8(b[currentOffset+2])))
 			currentOffset += 3
 			break
 		case constants.MULTIANEWARRAY:
@@ -1264,7 +1356,8 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 			currentOffset += 4
 			break
 		default:
-			panic(errors.New("Assertion Error"))
+			panic(raw.NewParseError(raw.ErrUnknownOpcode, currentOffset, "unknown opcode "+strconv.Itoa(int(opcode))+
+				" at offset "+strconv.Itoa(currentOffset)))
 			break
 		}
 
@@ -1356,7 +1449,7 @@ func (c ClassReader) readCode(methodVisitor MethodVisitor, context *Context, cod
 
 	if invisibleTypeAnnotationOffsets != nil {
 		for i := 0; i < len(invisibleTypeAnnotationOffsets); i++ {
-			targetType := c.readByte(visibleTypeAnnotationOffsets[i])
+			targetType := c.readByte(invisibleTypeAnnotationOffsets[i])
 			if targetType == typereference.LOCAL_VARIABLE || targetType == typereference.RESOURCE_VARIABLE {
 				currentOffset = c.readTypeAnnotationTarget(context, invisibleTypeAnnotationOffsets[i])
 				annotationDescriptor := c.readUTF8(currentOffset, charBuffer)
@@ -1446,7 +1539,8 @@ func (c ClassReader) readTypeAnnotations(methodVisitor MethodVisitor, context *C
 			currentOffset += 3
 			break
 		default:
-			panic(errors.New("Assertion Error"))
+			panic(raw.NewParseError(raw.ErrMalformedAttribute, typeAnnotationsOffsets[i], "invalid type annotation target type "+strconv.Itoa((targetType>>24)&0xFF)+
+				" at offset "+strconv.Itoa(typeAnnotationsOffsets[i])))
 			break
 		}
 
@@ -1521,7 +1615,8 @@ func (c ClassReader) readTypeAnnotationTarget(context *Context, typeAnnotationOf
 		currentOffset += 3
 		break
 	default:
-		panic(errors.New("Assertion Error"))
+		panic(raw.NewParseError(raw.ErrMalformedAttribute, typeAnnotationOffset, "invalid type annotation target type "+strconv.Itoa((targetType>>24)&0xFF)+
+			" at offset "+strconv.Itoa(typeAnnotationOffset)))
 		break
 	}
 	context.currentTypeAnnotationTarget = targetType
@@ -1583,8 +1678,11 @@ func (c ClassReader) readElementValue(annotationVisitor AnnotationVisitor, eleme
 			return c.readElementValues(nil, currentOffset+3, true, charBuffer)
 		case '[':
 			return c.readElementValues(nil, currentOffset+1, false, charBuffer)
-		default:
+		case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z', 's', 'c':
 			return currentOffset + 3
+		default:
+			panic(raw.NewParseError(raw.ErrMalformedAttribute, elementValueOffset, "unknown element value tag "+
+				strconv.Itoa(int(c.b[elementValueOffset]))+" at offset "+strconv.Itoa(elementValueOffset)))
 		}
 	}
 	switch c.b[currentOffset] & 0xFF {
@@ -1630,12 +1728,12 @@ func (c ClassReader) readElementValue(annotationVisitor AnnotationVisitor, eleme
 		break
 	case 'c':
 		currentOffset++
-		annotationVisitor.Visit(elementName, getType(c.readUTF8(currentOffset, charBuffer)))
+		annotationVisitor.Visit(elementName, GetType(c.readUTF8(currentOffset, charBuffer)))
 		currentOffset += 2
 		break
 	case '@':
 		currentOffset++
-		currentOffset = c.readElementValues(annotationVisitor.VisitArray(elementName), currentOffset-2, false, charBuffer)
+		currentOffset = c.readElementValues(annotationVisitor.VisitAnnotation(elementName, c.readUTF8(currentOffset, charBuffer)), currentOffset+2, true, charBuffer)
 		break
 	case '[':
 		currentOffset++
@@ -1696,7 +1794,7 @@ func (c ClassReader) readElementValue(annotationVisitor AnnotationVisitor, eleme
 		case 'F':
 			floatValues := make([]float32, numValues)
 			for i := 0; i < numValues; i++ {
-				floatValues[i] = float32(c.readInt(c.cpInfoOffsets[c.readUnsignedShort(currentOffset+1)]))
+				floatValues[i] = c.readFloat(c.cpInfoOffsets[c.readUnsignedShort(currentOffset+1)])
 				currentOffset += 3
 			}
 			annotationVisitor.Visit(elementName, floatValues)
@@ -1704,7 +1802,7 @@ func (c ClassReader) readElementValue(annotationVisitor AnnotationVisitor, eleme
 		case 'D':
 			doubleValues := make([]float64, numValues)
 			for i := 0; i < numValues; i++ {
-				doubleValues[i] = float64(c.readLong(c.cpInfoOffsets[c.readUnsignedShort(currentOffset+1)]))
+				doubleValues[i] = c.readDouble(c.cpInfoOffsets[c.readUnsignedShort(currentOffset+1)])
 				currentOffset += 3
 			}
 			annotationVisitor.Visit(elementName, doubleValues)
@@ -1715,7 +1813,8 @@ func (c ClassReader) readElementValue(annotationVisitor AnnotationVisitor, eleme
 		}
 		break
 	default:
-		panic(errors.New("Assertion Error"))
+		panic(raw.NewParseError(raw.ErrMalformedAttribute, elementValueOffset, "unknown element value tag "+strconv.Itoa(int(c.b[elementValueOffset]))+
+			" at offset "+strconv.Itoa(elementValueOffset)))
 		break
 	}
 	return currentOffset
@@ -1802,7 +1901,8 @@ func (c ClassReader) readStackMapFrame(stackMapFrameOffset int, compressed bool,
 		context.currentFrameOffset = -1
 	}
 	var offsetDelta int
-	context.currentFrameLocalCount = 0
+	// The local count is kept, since the append and chop frames are relative to the locals of the previous frame.
+	context.currentFrameLocalCountDelta = 0
 	if frameType < frame.SAME_LOCALS_1_STACK_ITEM_FRAME {
 		offsetDelta = frameType
 		context.currentFrameType = opcodes.F_SAME
@@ -1863,38 +1963,29 @@ func (c ClassReader) readStackMapFrame(stackMapFrameOffset int, compressed bool,
 }
 
 func (c ClassReader) readVerificationTypeInfo(verificationTypeInfoOffset int, framed []interface{}, index int, charBuffer []rune, labels []*Label) int {
-	currentOffset := verificationTypeInfoOffset
-	tag := c.b[currentOffset] & 0xFF
-	currentOffset++
-	switch tag {
+	verificationType, currentOffset, err := raw.DecodeVerificationType(c.b, verificationTypeInfoOffset)
+	if err != nil {
+		panic(err)
+	}
+	switch verificationType.Tag {
 	case frame.ITEM_TOP:
 		framed[index] = opcodes.TOP
-		break
 	case frame.ITEM_INTEGER:
 		framed[index] = opcodes.INTEGER
-		break
 	case frame.ITEM_FLOAT:
 		framed[index] = opcodes.FLOAT
-		break
 	case frame.ITEM_DOUBLE:
 		framed[index] = opcodes.DOUBLE
-		break
 	case frame.ITEM_LONG:
 		framed[index] = opcodes.LONG
-		break
 	case frame.ITEM_NULL:
 		framed[index] = opcodes.NULL
-		break
 	case frame.ITEM_UNINITIALIZED_THIS:
 		framed[index] = opcodes.UNINITIALIZED_THIS
-		break
 	case frame.ITEM_OBJECT:
-		framed[index] = c.readClass(currentOffset, charBuffer)
-		currentOffset += 2
-		break
+		framed[index] = c.readClass(verificationTypeInfoOffset+1, charBuffer)
 	default:
-		framed[index] = c.createLabel(c.readUnsignedShort(currentOffset), labels)
-		currentOffset += 2
+		framed[index] = c.createLabel(verificationType.Offset, labels)
 	}
 	return currentOffset
 }
@@ -1932,28 +2023,49 @@ func (c ClassReader) getFirstAttributeOffset() int {
 	return currentOffset + 2
 }
 
-func (c ClassReader) readAttribute(attributePrototypes []*Attribute, typed string, offset int, length int, charBuffer []rune, codeAttributeOffset int, labels []*Label) *Attribute {
+// skipMember returns the offset of the field_info or method_info structure following the one at the given offset.
+func (c ClassReader) skipMember(memberInfoOffset int) int {
+	attributesCount := c.readUnsignedShort(memberInfoOffset + 6)
+	currentOffset := memberInfoOffset + 8
+	for attributesCount > 0 {
+		attributesCount--
+		currentOffset += 6 + c.readInt(currentOffset+2)
+	}
+	return currentOffset
+}
+
+func (c ClassReader) readAttribute(preserve bool, attributePrototypes []*Attribute, typed string, offset int, length int, charBuffer []rune, codeAttributeOffset int, labels []*Label) *Attribute {
 	for i := 0; i < len(attributePrototypes); i++ {
 		if attributePrototypes[i].typed == typed {
 			return attributePrototypes[i].read(&c, offset, length, charBuffer, codeAttributeOffset, labels)
 		}
 	}
-	return NewAttribute(typed).read(&c, offset, length, nil, -1, nil)
+	attribute := NewAttribute(typed).read(&c, offset, length, nil, -1, nil)
+	if preserve {
+		attribute.classReader = &c
+	}
+	return attribute
 }
 
 // -----------------------------------------------------------------------------------------------
 // Utility methods: low level parsing
 // -----------------------------------------------------------------------------------------------
 
-func (c ClassReader) getItemCount() int {
+// GetItemCount returns the number of entries in the class's constant pool table, plus one (the constant_pool_count
+// of the ClassFile structure).
+func (c ClassReader) GetItemCount() int {
 	return len(c.cpInfoOffsets)
 }
 
-func (c ClassReader) getItem(constantPoolEntryIndex int) int {
+// GetItem returns the start offset in the class file of the content of the given constant pool entry (i.e. the
+// offset of its tag byte, plus one). Returns 0 for the unusable entries following Long and Double constants.
+func (c ClassReader) GetItem(constantPoolEntryIndex int) int {
 	return c.cpInfoOffsets[constantPoolEntryIndex]
 }
 
-func (c ClassReader) getMaxStringLength() int {
+// GetMaxStringLength returns a conservative estimate of the maximum length of the strings contained in the
+// class's constant pool table.
+func (c ClassReader) GetMaxStringLength() int {
 	return c.maxStringLength
 }
 
@@ -1973,7 +2085,7 @@ func (c ClassReader) readShort(offset int) int16 {
 
 func (c ClassReader) readInt(offset int) int {
 	b := c.b
-	return int((b[offset]&0xFF))<<24 | int((b[offset+1]&0xFF))<<16 | int((b[offset+2]&0xFF))<<8 | int(b[offset+3]&0xFF)
+	return int(int32(uint32(b[offset])<<24 | uint32(b[offset+1])<<16 | uint32(b[offset+2])<<8 | uint32(b[offset+3])))
 }
 
 func (c ClassReader) readLong(offset int) int64 {
@@ -1984,6 +2096,16 @@ func (c ClassReader) readLong(offset int) int64 {
 	return (l1 << 32) | l0
 }
 
+// readFloat reads a float value, stored as an IEEE 754 single precision bit pattern, in this ClassReader.
+func (c ClassReader) readFloat(offset int) float32 {
+	return math.Float32frombits(uint32(c.readInt(offset)))
+}
+
+// readDouble reads a double value, stored as an IEEE 754 double precision bit pattern, in this ClassReader.
+func (c ClassReader) readDouble(offset int) float64 {
+	return math.Float64frombits(uint64(c.readLong(offset)))
+}
+
 func (c ClassReader) readUTF8(offset int, charBuffer []rune) string {
 	constantPoolEntryIndex := c.readUnsignedShort(offset)
 	if offset == 0 || constantPoolEntryIndex == 0 {
@@ -2004,31 +2126,7 @@ func (c ClassReader) readUTF(constantPoolEntryIndex int, charBuffer []rune) stri
 }
 
 func (c ClassReader) readUTFB(utfOffset int, utfLength int, charBuffer []rune) string {
-	currentOffset := utfOffset
-	endOffset := currentOffset + utfLength
-	strLength := 0
-	b := c.b
-	for currentOffset < endOffset {
-		currentByte := b[currentOffset]
-		currentOffset++
-		if (currentByte & 0x80) == 0 {
-			charBuffer[strLength] = rune(currentByte & 0x7F)
-			strLength++
-		} else if (currentByte & 0xE0) == 0xC0 {
-			charBuffer[strLength] = rune((((currentByte & 0x1F) << 6) + (b[currentOffset] & 0x3F)))
-			strLength++
-			currentOffset++
-		} else {
-			d := ((currentByte & 0xF) << 12) + ((b[currentOffset] & 0x3F) << 6)
-			currentOffset++
-			charBuffer[strLength] = rune((d + (b[currentOffset] & 0x3F)))
-			currentOffset++
-			strLength++
-		}
-	}
-	str := make([]rune, strLength)
-	copy(str, charBuffer[0:strLength])
-	return string(str)
+	return raw.DecodeUTF8(c.b[utfOffset:utfOffset+utfLength], charBuffer)
 }
 
 func (c ClassReader) readStringish(offset int, charBuffer []rune) string {
@@ -2053,17 +2151,17 @@ func (c ClassReader) readConst(constantPoolEntryIndex int, charBuffer []rune) (i
 	case byte(symbol.CONSTANT_INTEGER_TAG):
 		return c.readInt(cpInfoOffset), nil
 	case byte(symbol.CONSTANT_FLOAT_TAG):
-		return float32(c.readInt(cpInfoOffset)), nil
+		return c.readFloat(cpInfoOffset), nil
 	case byte(symbol.CONSTANT_LONG_TAG):
 		return c.readLong(cpInfoOffset), nil
 	case byte(symbol.CONSTANT_DOUBLE_TAG):
-		return float64(c.readLong(cpInfoOffset)), nil
+		return c.readDouble(cpInfoOffset), nil
 	case byte(symbol.CONSTANT_CLASS_TAG):
-		return getObjectType(c.readUTF8(cpInfoOffset, charBuffer)), nil
+		return GetObjectType(c.readUTF8(cpInfoOffset, charBuffer)), nil
 	case byte(symbol.CONSTANT_STRING_TAG):
 		return c.readUTF8(cpInfoOffset, charBuffer), nil
 	case byte(symbol.CONSTANT_METHOD_TYPE_TAG):
-		return getMethodType(c.readUTF8(cpInfoOffset, charBuffer)), nil
+		return GetMethodType(c.readUTF8(cpInfoOffset, charBuffer)), nil
 	case byte(symbol.CONSTANT_METHOD_HANDLE_TAG):
 		referenceKind := c.readByte(cpInfoOffset)
 		referenceCpInfoOffset := c.cpInfoOffsets[c.readUnsignedShort(cpInfoOffset+1)]
@@ -2080,6 +2178,7 @@ func (c ClassReader) readConst(constantPoolEntryIndex int, charBuffer []rune) (i
 			isInterface: itf,
 		}, nil
 	default:
-		return nil, errors.New("Assertion Error")
+		return nil, raw.NewParseError(raw.ErrMalformedConstantPool, cpInfoOffset-1, "unexpected constant pool tag "+
+			strconv.Itoa(int(c.b[cpInfoOffset-1]))+" at offset "+strconv.Itoa(cpInfoOffset-1))
 	}
 }
@@ -0,0 +1,502 @@
+package asm_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typereference"
+)
+
+// annotationRecorder an annotation visitor which records the visited values.
+type annotationRecorder struct {
+	values map[string]interface{}
+}
+
+func (a *annotationRecorder) Visit(name string, value interface{})     { a.values[name] = value }
+func (a *annotationRecorder) VisitEnum(name, descriptor, value string) {}
+func (a *annotationRecorder) VisitAnnotation(name, descriptor string) asm.AnnotationVisitor {
+	return nil
+}
+func (a *annotationRecorder) VisitArray(name string) asm.AnnotationVisitor { return nil }
+func (a *annotationRecorder) VisitEnd()                                    {}
+
+type annotationClassVisitor struct {
+	helper.ClassVisitor
+	recorder *annotationRecorder
+}
+
+func (a *annotationClassVisitor) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return a.recorder
+}
+
+// annotatedClass returns a class file with an annotation whose element values are the given float and double
+// values, and arrays of these values.
+func annotatedClass(floats []float32, doubles []float64, long int64) []byte {
+	var pool [][]byte
+	utf8 := func(s string) []byte { return append([]byte{1, 0, byte(len(s))}, s...) }
+	pool = append(pool, utf8("A"), []byte{7, 0, 1}, utf8("java/lang/Object"), []byte{7, 0, 3},
+		utf8("RuntimeVisibleAnnotations"), utf8("LAnn;"), utf8("f"), utf8("d"), utf8("fs"), utf8("ds"), utf8("j"))
+	index := len(pool) + 1
+	floatIndexes := make([]int, len(floats))
+	for i, f := range floats {
+		pool = append(pool, binary.BigEndian.AppendUint32([]byte{4}, math.Float32bits(f)))
+		floatIndexes[i] = index
+		index++
+	}
+	doubleIndexes := make([]int, len(doubles))
+	for i, d := range doubles {
+		pool = append(pool, binary.BigEndian.AppendUint64([]byte{6}, math.Float64bits(d)))
+		doubleIndexes[i] = index
+		index += 2
+	}
+	pool = append(pool, binary.BigEndian.AppendUint64([]byte{5}, uint64(long)))
+	longIndex := index
+	index += 2
+
+	u2 := func(b []byte, v int) []byte { return binary.BigEndian.AppendUint16(b, uint16(v)) }
+	annotation := u2(u2(nil, 6), 5)
+	annotation = append(u2(annotation, 7), 'F')
+	annotation = u2(annotation, floatIndexes[0])
+	annotation = append(u2(annotation, 8), 'D')
+	annotation = u2(annotation, doubleIndexes[0])
+	annotation = append(u2(annotation, 9), '[')
+	annotation = u2(annotation, len(floats))
+	for _, i := range floatIndexes {
+		annotation = u2(append(annotation, 'F'), i)
+	}
+	annotation = append(u2(annotation, 10), '[')
+	annotation = u2(annotation, len(doubles))
+	for _, i := range doubleIndexes {
+		annotation = u2(append(annotation, 'D'), i)
+	}
+	annotation = append(u2(annotation, 11), 'J')
+	annotation = u2(annotation, longIndex)
+
+	b := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52}
+	b = u2(b, index)
+	for _, entry := range pool {
+		b = append(b, entry...)
+	}
+	b = u2(u2(u2(u2(b, 0x21), 2), 4), 0)
+	b = u2(u2(u2(b, 0), 0), 1)
+	b = u2(b, 5)
+	b = binary.BigEndian.AppendUint32(b, uint32(2+len(annotation)))
+	b = u2(b, 1)
+	return append(b, annotation...)
+}
+
+func TestReadFloatingPointElementValues(t *testing.T) {
+	floats := []float32{float32(math.NaN()), float32(math.Inf(1)), math.SmallestNonzeroFloat32, -1.5}
+	doubles := []float64{math.Inf(-1), math.NaN(), math.SmallestNonzeroFloat64, 0.1}
+	reader, err := asm.NewClassReader(annotatedClass(floats, doubles, -2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &annotationRecorder{values: make(map[string]interface{})}
+	reader.Accept(&annotationClassVisitor{recorder: recorder}, 0)
+
+	if f, ok := recorder.values["f"].(float32); !ok || !math.IsNaN(float64(f)) {
+		t.Errorf("f: expected NaN, got %v", recorder.values["f"])
+	}
+	if d, ok := recorder.values["d"].(float64); !ok || !math.IsInf(d, -1) {
+		t.Errorf("d: expected -Inf, got %v", recorder.values["d"])
+	}
+	if j := recorder.values["j"]; j != int64(-2) {
+		t.Errorf("j: expected -2, got %v", j)
+	}
+	fs, ok := recorder.values["fs"].([]float32)
+	if !ok || len(fs) != len(floats) {
+		t.Fatalf("fs: expected %v, got %v", floats, recorder.values["fs"])
+	}
+	for i := range floats {
+		if math.Float32bits(fs[i]) != math.Float32bits(floats[i]) {
+			t.Errorf("fs[%d]: expected %v, got %v", i, floats[i], fs[i])
+		}
+	}
+	ds, ok := recorder.values["ds"].([]float64)
+	if !ok || len(ds) != len(doubles) {
+		t.Fatalf("ds: expected %v, got %v", doubles, recorder.values["ds"])
+	}
+	for i := range doubles {
+		if math.Float64bits(ds[i]) != math.Float64bits(doubles[i]) {
+			t.Errorf("ds[%d]: expected %v, got %v", i, doubles[i], ds[i])
+		}
+	}
+}
+
+// frameRecorder a method visitor which records the visited instructions ('I') and frames ('F').
+type frameRecorder struct {
+	helper.MethodVisitor
+	trace  string
+	frames [][]interface{}
+}
+
+func (f *frameRecorder) VisitInsn(opcode int)                       { f.trace += "I" }
+func (f *frameRecorder) VisitVarInsn(opcode, vard int)              { f.trace += "I" }
+func (f *frameRecorder) VisitJumpInsn(opcode int, label *asm.Label) { f.trace += "I" }
+func (f *frameRecorder) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	f.trace += "F"
+	if typed != opcodes.F_NEW {
+		f.trace += "?"
+	}
+	f.frames = append(f.frames, append([]interface{}(nil), local.([]interface{})[:nLocal]...))
+}
+
+type frameClassVisitor struct {
+	helper.ClassVisitor
+	recorder *frameRecorder
+}
+
+func (f *frameClassVisitor) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	return f.recorder
+}
+
+// stackMapClass returns a version 45.3 class file with a static method m(I)V, whose Code attribute has a legacy
+// StackMap attribute with the given frame offsets (each frame has a single int local).
+func stackMapClass(frameOffsets ...int) []byte {
+	u2 := func(b []byte, v int) []byte { return binary.BigEndian.AppendUint16(b, uint16(v)) }
+	utf8 := func(s string) []byte { return append(u2([]byte{1}, len(s)), s...) }
+	b := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 3, 0, 45}
+	b = u2(b, 9)
+	for _, entry := range [][]byte{utf8("A"), {7, 0, 1}, utf8("java/lang/Object"), {7, 0, 3}, utf8("m"),
+		utf8("(I)V"), utf8("Code"), utf8("StackMap")} {
+		b = append(b, entry...)
+	}
+	b = u2(u2(u2(u2(b, 0x21), 2), 4), 0)
+	b = u2(u2(b, 0), 1)
+	b = u2(u2(u2(u2(b, 0x09), 5), 6), 1)
+
+	// iload_0; ifeq 7; iload_0; pop; nop; return
+	code := []byte{0x1a, 0x99, 0, 6, 0x1a, 0x57, 0, 0xb1}
+	stackMap := u2(nil, len(frameOffsets))
+	for _, offset := range frameOffsets {
+		stackMap = append(u2(u2(stackMap, offset), 1), 1)
+		stackMap = u2(stackMap, 0)
+	}
+	attribute := binary.BigEndian.AppendUint32(u2(u2(nil, 1), 1), uint32(len(code)))
+	attribute = append(attribute, code...)
+	attribute = u2(u2(attribute, 0), 1)
+	attribute = binary.BigEndian.AppendUint32(u2(attribute, 8), uint32(len(stackMap)))
+	attribute = append(attribute, stackMap...)
+
+	b = binary.BigEndian.AppendUint32(u2(b, 7), uint32(len(attribute)))
+	b = append(b, attribute...)
+	return u2(b, 0)
+}
+
+func TestReadLegacyStackMap(t *testing.T) {
+	tests := []struct {
+		frameOffsets []int
+		trace        string
+	}{
+		{[]int{4, 7}, "IIFIIIFI"},
+		{[]int{7}, "IIIIIFI"},
+		// Unsorted frames: the frame at offset 4, read after the one at offset 6, is ignored.
+		{[]int{6, 4, 7}, "IIIIFIFI"},
+	}
+	for _, test := range tests {
+		reader, err := asm.NewClassReader(stackMapClass(test.frameOffsets...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if variants := reader.GetStackMapVariants(); variants["m(I)V"] != asm.STACK_MAP {
+			t.Errorf("%v: expected the STACK_MAP variant, got %v", test.frameOffsets, variants)
+		}
+		recorder := &frameRecorder{}
+		reader.Accept(&frameClassVisitor{recorder: recorder}, 0)
+		if recorder.trace != test.trace {
+			t.Errorf("%v: expected %s, got %s", test.frameOffsets, test.trace, recorder.trace)
+		}
+		for _, locals := range recorder.frames {
+			if len(locals) != 1 || locals[0] != opcodes.INTEGER {
+				t.Errorf("%v: expected a single int local, got %v", test.frameOffsets, locals)
+			}
+		}
+	}
+}
+
+func TestReaderLimits(t *testing.T) {
+	classFile := stackMapClass(4, 7)
+	tests := []struct {
+		limits asm.ReaderLimits
+		kind   error
+	}{
+		{asm.ReaderLimits{}, nil},
+		{asm.ReaderLimits{MaxConstantPoolEntries: 9, MaxCodeLength: 8, MaxAttributeLength: 42, MaxParseMemory: 1024}, nil},
+		{asm.ReaderLimits{MaxConstantPoolEntries: 8}, asm.ErrClassTooLarge},
+		{asm.ReaderLimits{MaxCodeLength: 7}, asm.ErrMethodTooLarge},
+		{asm.ReaderLimits{MaxAttributeLength: 41}, asm.ErrAttributeTooLarge},
+		{asm.ReaderLimits{MaxParseMemory: 64}, asm.ErrParseMemoryTooLarge},
+	}
+	for _, test := range tests {
+		_, err := asm.NewClassReaderWithLimits(classFile, test.limits)
+		if test.kind == nil {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", test.limits, err)
+			}
+			continue
+		}
+		var limitError *asm.LimitExceededError
+		if !errors.As(err, &limitError) || !errors.Is(err, test.kind) {
+			t.Errorf("%+v: expected a %v error, got %v", test.limits, test.kind, err)
+		}
+	}
+	var limitError *asm.LimitExceededError
+	_, err := asm.NewClassReaderWithLimits(classFile, asm.ReaderLimits{MaxCodeLength: 7})
+	if errors.As(err, &limitError); limitError.ClassName != "A" || limitError.MethodName != "m" || limitError.Descriptor != "(I)V" {
+		t.Errorf("unexpected method in %v", err)
+	}
+	if _, err := asm.NewClassReaderWithLimits(classFile[:len(classFile)-20], asm.ReaderLimits{}); !errors.Is(err, asm.ErrTruncated) {
+		t.Errorf("expected a truncated class error, got %v", err)
+	}
+}
+
+func TestReaderLimitsConstantPoolReferences(t *testing.T) {
+	classReader, err := asm.NewClassReader(stackMapClass(4, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := 10 + classReader.GetConstantPoolSizeInBytes()
+	// The offsets of this_class and super_class, with the index of a CONSTANT_Utf8 entry, and of the name,
+	// descriptor and Code attribute name of the method, with the index of a CONSTANT_Class entry.
+	for _, offset := range []int{header + 2, header + 4, header + 14, header + 16, header + 20} {
+		wrongTagIndex := 2
+		if offset <= header+4 {
+			wrongTagIndex = 1
+		}
+		for _, index := range []int{0, wrongTagIndex, 60000} {
+			if offset == header+4 && index == 0 {
+				continue
+			}
+			classFile := stackMapClass(4, 7)
+			binary.BigEndian.PutUint16(classFile[offset:], uint16(index))
+			_, err := asm.NewClassReaderWithLimits(classFile, asm.ReaderLimits{})
+			var parseError *asm.ParseError
+			if !errors.Is(err, asm.ErrMalformedConstantPool) || !errors.As(err, &parseError) || parseError.Offset != offset {
+				t.Errorf("index %d at offset %d: expected a malformed constant pool error, got %v", index, offset, err)
+			}
+		}
+	}
+}
+
+// orderedClass returns a class file with annotations, type annotations, non standard attributes, inner classes,
+// a field and a method, to check the order of the class events.
+func orderedClass() []byte {
+	u2 := func(b []byte, v int) []byte { return binary.BigEndian.AppendUint16(b, uint16(v)) }
+	u4 := func(b []byte, v int) []byte { return binary.BigEndian.AppendUint32(b, uint32(v)) }
+	utf8 := func(s string) []byte { return append(u2([]byte{1}, len(s)), s...) }
+	b := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52}
+	b = u2(b, 23)
+	for _, entry := range [][]byte{utf8("A"), {7, 0, 1}, utf8("java/lang/Object"), {7, 0, 3},
+		utf8("RuntimeVisibleAnnotations"), utf8("RuntimeInvisibleAnnotations"), utf8("RuntimeVisibleTypeAnnotations"),
+		utf8("RuntimeInvisibleTypeAnnotations"), utf8("LVisible;"), utf8("LInvisible;"), utf8("LTypeVisible;"),
+		utf8("LTypeInvisible;"), utf8("Custom1"), utf8("Custom2"), utf8("InnerClasses"), utf8("A$B"), {7, 0, 16},
+		utf8("B"), utf8("f"), utf8("I"), utf8("m"), utf8("()V")} {
+		b = append(b, entry...)
+	}
+	b = u2(u2(u2(u2(b, 0x21), 2), 4), 0)
+	b = u2(u2(u2(u2(u2(b, 1), 0), 19), 20), 0)
+	b = u2(u2(u2(u2(u2(b, 1), 0x401), 21), 22), 0)
+	b = u2(b, 7)
+	// One annotation without element value pairs.
+	b = u2(u2(u2(u4(u2(b, 5), 6), 1), 9), 0)
+	b = u2(u2(u2(u4(u2(b, 6), 6), 1), 10), 0)
+	// One type annotation on the super class (CLASS_EXTENDS, supertype index 65535), with an empty type path.
+	b = u2(u2(append(u2(append(u2(u4(u2(b, 7), 10), 1), 0x10), 0xFFFF), 0), 11), 0)
+	b = u2(u2(append(u2(append(u2(u4(u2(b, 8), 10), 1), 0x10), 0xFFFF), 0), 12), 0)
+	b = append(u4(u2(b, 13), 1), 0)
+	b = u4(u2(b, 14), 0)
+	return u2(u2(u2(u2(u2(u4(u2(b, 15), 10), 1), 17), 2), 18), 0x09)
+}
+
+func TestClassEventsOrder(t *testing.T) {
+	reader, err := asm.NewClassReader(orderedClass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(recorder, 0)
+	// The order of the events of the Java ASM ClassReader for the same class. Non standard attributes are
+	// visited in the reverse order of the class file.
+	recorder.AssertTrace(t, []string{
+		`class visit A 52 33 "A" "" "java/lang/Object" []`,
+		`class visit annotation A "LVisible;" true`,
+		`class visit annotation A "LInvisible;" false`,
+		`class visit type annotation A 285212416 <nil> "LTypeVisible;" true`,
+		`class visit type annotation A 285212416 <nil> "LTypeInvisible;" false`,
+		`class visit attribute A attribute Custom2`,
+		`class visit attribute A attribute Custom1`,
+		`class visit inner class A "A$B" "A" "B" 9`,
+		`class visit field A 0 "f" "I" "" <nil>`,
+		`field visit end A.f I`,
+		`class visit method A 1025 "m" "()V" "" []`,
+		`method visit end A.m()V`,
+		`class visit end A`,
+	})
+}
+
+func TestResolveConstantPoolEntries(t *testing.T) {
+	utf8 := func(s string) []byte { return append([]byte{1, 0, byte(len(s))}, s...) }
+	classFile := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52, 0, 15}
+	classFile = append(classFile, utf8("A")...)                // 1
+	classFile = append(classFile, 7, 0, 1)                     // 2: class A
+	classFile = append(classFile, utf8("java/lang/Object")...) // 3
+	classFile = append(classFile, 7, 0, 3)                     // 4: class java/lang/Object
+	classFile = append(classFile, utf8("f")...)                // 5
+	classFile = append(classFile, utf8("I")...)                // 6
+	classFile = append(classFile, 12, 0, 5, 0, 6)              // 7: f I
+	classFile = append(classFile, 9, 0, 2, 0, 7)               // 8: A.f I
+	classFile = append(classFile, utf8("run")...)              // 9
+	classFile = append(classFile, utf8("()V")...)              // 10
+	classFile = append(classFile, 12, 0, 9, 0, 10)             // 11: run ()V
+	classFile = append(classFile, 11, 0, 4, 0, 11)             // 12: java/lang/Object.run ()V
+	classFile = append(classFile, 5, 0, 0, 0, 0, 0, 0, 0, 42)  // 13 and 14: 42L
+	classFile = append(classFile, 0, 0x21, 0, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0)
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field, err := reader.ResolveFieldRef(8); err != nil || field != (asm.FieldRef{Owner: "A", Name: "f", Descriptor: "I"}) {
+		t.Errorf("unexpected field reference %v %v", field, err)
+	}
+	if method, err := reader.ResolveMethodRef(12); err != nil ||
+		method != (asm.MethodRef{Owner: "java/lang/Object", Name: "run", Descriptor: "()V", IsInterface: true}) {
+		t.Errorf("unexpected method reference %v %v", method, err)
+	}
+	if value, err := reader.ResolveConstant(13); err != nil || value != int64(42) {
+		t.Errorf("unexpected constant %v %v", value, err)
+	}
+	if _, err := reader.ResolveClass(8); err == nil {
+		t.Error("expected an error for a field reference resolved as a class")
+	}
+	if _, err := reader.ResolveUtf8(14); err == nil || reader.GetItemTag(14) != 0 {
+		t.Error("expected an error for the entry following a long constant")
+	}
+}
+
+// acceptError returns the error recovered while the given visitor visits the given class file.
+func acceptError(t *testing.T, classFile []byte, classVisitor asm.ClassVisitor) (err error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer asm.RecoverParseError(&err)
+	reader.Accept(classVisitor, 0)
+	return nil
+}
+
+func TestMalformedAnnotations(t *testing.T) {
+	expectMalformed := func(name string, classFile []byte, offset int) {
+		// The annotations are skipped by the recorder, and visited by the class writer.
+		for _, classVisitor := range []asm.ClassVisitor{asmtest.NewRecorder(nil), asm.NewClassWriter(nil)} {
+			err := acceptError(t, classFile, classVisitor)
+			var parseError *asm.ParseError
+			if !errors.Is(err, asm.ErrMalformedAttribute) || !errors.As(err, &parseError) || parseError.Offset != offset {
+				t.Errorf("%s: expected a malformed attribute error at offset %d, got %v", name, offset, err)
+			}
+		}
+	}
+
+	// A class annotation whose element value has an invalid tag.
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_SUPER, "C", "java/lang/Object")
+	annotation := classFile.SymbolTable.AddConstantUtf8("LA;")
+	element := classFile.SymbolTable.AddConstantUtf8("value")
+	classFile.AddAttribute("RuntimeVisibleAnnotations",
+		asm.NewByteVector().PutShort(1).PutShort(annotation).PutShort(1).PutShort(element).PutByte('x').PutShort(0).Bytes())
+	content := classFile.Bytes()
+	expectMalformed("element value", content, bytes.Index(content, []byte{'x', 0, 0}))
+
+	// A class type annotation with an invalid target type.
+	classFile = asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_SUPER, "C", "java/lang/Object")
+	annotation = classFile.SymbolTable.AddConstantUtf8("LA;")
+	classFile.AddAttribute("RuntimeVisibleTypeAnnotations",
+		asm.NewByteVector().PutShort(1).PutByte(0x99).PutByte(0).PutShort(annotation).PutShort(0).Bytes())
+	content = classFile.Bytes()
+	expectMalformed("class type annotation", content, bytes.Index(content, []byte{0x99, 0}))
+
+	// An instruction type annotation with an invalid target type, in the Code attribute.
+	classFile = asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_SUPER, "C", "java/lang/Object")
+	methodVisitor := classFile.AddMethod(opcodes.ACC_STATIC, "m", "()V", "", nil)
+	methodVisitor.VisitCode()
+	methodVisitor.VisitTypeInsn(opcodes.NEW, "java/lang/Object")
+	methodVisitor.VisitInsnAnnotation(typereference.NEW<<24, nil, "LA;", true)
+	methodVisitor.VisitInsn(opcodes.POP)
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitMaxs(1, 0)
+	methodVisitor.VisitEnd()
+	content = classFile.Bytes()
+	offset := bytes.Index(content, []byte{0, 1, typereference.NEW, 0, 0, 0}) + 2
+	content[offset] = 0x99
+	expectMalformed("instruction type annotation", content, offset)
+}
+
+// versionClass returns a class p/C with the given minor and major version, whose constant pool contains, in order,
+// the Utf8 "p/C", a Class, a Long (using two entries), the Utf8 "java/lang/Object" and a Class.
+func versionClass(minorVersion, majorVersion uint16) []byte {
+	var buffer bytes.Buffer
+	write := func(values ...interface{}) {
+		for _, value := range values {
+			binary.Write(&buffer, binary.BigEndian, value)
+		}
+	}
+	write(uint32(0xCAFEBABE), minorVersion, majorVersion, uint16(7))
+	write(uint8(1), uint16(3), []byte("p/C"))
+	write(uint8(7), uint16(1))
+	write(uint8(5), int64(5))
+	write(uint8(1), uint16(16), []byte("java/lang/Object"))
+	write(uint8(7), uint16(5))
+	// The access flags, this class, super class, and no interfaces, fields, methods and attributes.
+	write(uint16(opcodes.ACC_PUBLIC|opcodes.ACC_SUPER), uint16(2), uint16(6), uint16(0), uint16(0), uint16(0), uint16(0))
+	return buffer.Bytes()
+}
+
+func TestReaderVersionAndConstantPool(t *testing.T) {
+	reader, err := asm.NewClassReader(versionClass(3, 45))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.GetMinorVersion() != 3 || reader.GetMajorVersion() != 45 {
+		t.Errorf("unexpected version %d.%d", reader.GetMajorVersion(), reader.GetMinorVersion())
+	}
+	if reader.GetClassName() != "p/C" || reader.GetSuperName() != "java/lang/Object" {
+		t.Errorf("unexpected class %s extends %s", reader.GetClassName(), reader.GetSuperName())
+	}
+	// The constant pool starts at offset 10, and its entries have 6, 3, 9, 19 and 3 bytes.
+	if size := reader.GetConstantPoolSizeInBytes(); size != 40 {
+		t.Errorf("unexpected constant pool size %d", size)
+	}
+	if count := reader.GetItemCount(); count != 7 {
+		t.Errorf("unexpected item count %d", count)
+	}
+	// The entry following the Long is unusable.
+	for index, expected := range []int{0, 11, 17, 20, 0, 29, 48} {
+		if item := reader.GetItem(index); item != expected {
+			t.Errorf("unexpected offset %d of item %d", item, index)
+		}
+	}
+	if length := reader.GetMaxStringLength(); length != 19 {
+		t.Errorf("unexpected max string length %d", length)
+	}
+
+	// A class generated by a ClassWriter has the version given to Visit.
+	classWriter := asm.NewClassWriter(nil)
+	classWriter.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC, "p/D", "", "java/lang/Object", nil)
+	classWriter.VisitEnd()
+	classFile, err := classWriter.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader, err = asm.NewClassReader(classFile); err != nil {
+		t.Fatal(err)
+	}
+	if reader.GetMinorVersion() != 0 || reader.GetMajorVersion() != opcodes.V1_8 {
+		t.Errorf("unexpected version %d.%d", reader.GetMajorVersion(), reader.GetMinorVersion())
+	}
+}
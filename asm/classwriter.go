@@ -0,0 +1,301 @@
+package asm
+
+import (
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// ClassWriter a {@link ClassVisitor} that generates a class file, as defined in the Java Virtual Machine
+// Specification (JVMS), with the {@link FieldWriter}s and {@link MethodWriter}s of its members. Like these
+// writers, it writes the maxs and the stack map frames as visited: to compute them, a {@link MaxsComputer} or a
+// {@link FramesComputer} must be chained in front of the method writers. A class which is transformed should thus
+// be read with the {@link EXPAND_FRAMS} option, so that removing or moving instructions can't invalidate the
+// compressed frames which follow them, and with the {@link PRESERVE_UNKNOWN_ATTRIBUTES} option, so that its non
+// standard attributes are kept.
+type ClassWriter struct {
+	symbolTable                        *SymbolTable
+	version                            int
+	accessFlags                        int
+	thisClass                          int
+	superClass                         int
+	interfaces                         []int
+	signatureIndex                     int
+	sourceFileIndex                    int
+	debugExtension                     *ByteVector
+	moduleWriter                       *ModuleWriter
+	enclosingClassIndex                int
+	enclosingMethodIndex               int
+	lastRuntimeVisibleAnnotation       *AnnotationWriter
+	lastRuntimeInvisibleAnnotation     *AnnotationWriter
+	lastRuntimeVisibleTypeAnnotation   *AnnotationWriter
+	lastRuntimeInvisibleTypeAnnotation *AnnotationWriter
+	firstAttribute                     *Attribute
+	numberOfInnerClasses               int
+	innerClasses                       *ByteVector
+	innerClassNames                    map[string]bool
+	fields                             []*FieldWriter
+	methods                            []*MethodWriter
+	err                                error
+}
+
+// NewClassWriter constructs a new {@link ClassWriter}. If the given class reader is not nil, the constant pool
+// and the bootstrap methods of its class are copied in the new class (see
+// {@link NewSymbolTableFromClassReader}), which allows to write its preserved attributes, and makes the
+// transformation of a class faster when most of its constants are kept.
+func NewClassWriter(classReader *ClassReader) *ClassWriter {
+	symbolTable := NewSymbolTable()
+	if classReader != nil {
+		symbolTable = NewSymbolTableFromClassReader(classReader)
+	}
+	return &ClassWriter{
+		symbolTable:     symbolTable,
+		innerClassNames: make(map[string]bool),
+	}
+}
+
+// GetSymbolTable returns the symbol table of the class, to which constants can be added while it is visited.
+func (c *ClassWriter) GetSymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
+func (c *ClassWriter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.version = version
+	c.accessFlags = access
+	c.thisClass = c.symbolTable.SetMajorVersionAndClassName(version&0xFFFF, name)
+	if signature != "" {
+		c.signatureIndex = c.symbolTable.AddConstantUtf8(signature)
+	}
+	if superName != "" {
+		c.superClass = c.symbolTable.AddConstantClass(superName).index
+	}
+	for _, itf := range interfaces {
+		c.interfaces = append(c.interfaces, c.symbolTable.AddConstantClass(itf).index)
+	}
+}
+
+func (c *ClassWriter) VisitSource(source, debug string) {
+	if source != "" {
+		c.sourceFileIndex = c.symbolTable.AddConstantUtf8(source)
+	}
+	if debug != "" {
+		// The content of the SourceDebugExtension attribute is a modified UTF-8 string, without length.
+		c.debugExtension = NewByteVector()
+		for _, charValue := range debug {
+			if charValue > 0xFFFF {
+				charValue -= 0x10000
+				c.debugExtension.putChar(0xD800 + (charValue>>10)&0x3FF)
+				c.debugExtension.putChar(0xDC00 + charValue&0x3FF)
+			} else {
+				c.debugExtension.putChar(charValue)
+			}
+		}
+	}
+}
+
+func (c *ClassWriter) VisitModule(name string, access int, version string) ModuleVisitor {
+	c.moduleWriter = NewModuleWriter(c.symbolTable, name, access, version)
+	return c.moduleWriter
+}
+
+func (c *ClassWriter) VisitOuterClass(owner, name, descriptor string) {
+	c.enclosingClassIndex = c.symbolTable.AddConstantClass(owner).index
+	if name != "" && descriptor != "" {
+		c.enclosingMethodIndex = c.symbolTable.AddConstantNameAndType(name, descriptor)
+	}
+}
+
+func (c *ClassWriter) VisitAnnotation(descriptor string, visible bool) AnnotationVisitor {
+	if visible {
+		c.lastRuntimeVisibleAnnotation = NewAnnotationWriter(c.symbolTable, descriptor, c.lastRuntimeVisibleAnnotation)
+		return c.lastRuntimeVisibleAnnotation
+	}
+	c.lastRuntimeInvisibleAnnotation = NewAnnotationWriter(c.symbolTable, descriptor, c.lastRuntimeInvisibleAnnotation)
+	return c.lastRuntimeInvisibleAnnotation
+}
+
+func (c *ClassWriter) VisitTypeAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if visible {
+		c.lastRuntimeVisibleTypeAnnotation = NewTypeAnnotationWriter(c.symbolTable, typeRef, typePath, descriptor, c.lastRuntimeVisibleTypeAnnotation)
+		return c.lastRuntimeVisibleTypeAnnotation
+	}
+	c.lastRuntimeInvisibleTypeAnnotation = NewTypeAnnotationWriter(c.symbolTable, typeRef, typePath, descriptor, c.lastRuntimeInvisibleTypeAnnotation)
+	return c.lastRuntimeInvisibleTypeAnnotation
+}
+
+func (c *ClassWriter) VisitAttribute(attribute *Attribute) {
+	if err := attribute.checkConstantPool(c.symbolTable); err != nil && c.err == nil {
+		c.err = err
+	}
+	attribute.nextAttribute = c.firstAttribute
+	c.firstAttribute = attribute
+}
+
+func (c *ClassWriter) VisitInnerClass(name, outerName, innerName string, access int) {
+	// An inner class must be listed only once in the InnerClasses attribute (JVMS 4.7.6).
+	if c.innerClassNames[name] {
+		return
+	}
+	c.innerClassNames[name] = true
+	if c.innerClasses == nil {
+		c.innerClasses = NewByteVector()
+	}
+	c.innerClasses.PutShort(c.symbolTable.AddConstantClass(name).index)
+	if outerName != "" {
+		c.innerClasses.PutShort(c.symbolTable.AddConstantClass(outerName).index)
+	} else {
+		c.innerClasses.PutShort(0)
+	}
+	if innerName != "" {
+		c.innerClasses.PutShort(c.symbolTable.AddConstantUtf8(innerName))
+	} else {
+		c.innerClasses.PutShort(0)
+	}
+	c.innerClasses.PutShort(access)
+	c.numberOfInnerClasses++
+}
+
+func (c *ClassWriter) VisitField(access int, name, descriptor, signature string, value interface{}) FieldVisitor {
+	fieldWriter := NewFieldWriter(c.symbolTable, access, name, descriptor, signature, value)
+	c.fields = append(c.fields, fieldWriter)
+	return fieldWriter
+}
+
+func (c *ClassWriter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) MethodVisitor {
+	methodWriter := NewMethodWriter(c.symbolTable, access, name, descriptor, signature, exceptions)
+	c.methods = append(c.methods, methodWriter)
+	return methodWriter
+}
+
+func (c *ClassWriter) VisitEnd() {
+}
+
+// useSyntheticAttribute returns whether the ACC_SYNTHETIC flag must be written as a Synthetic attribute.
+func (c *ClassWriter) useSyntheticAttribute() bool {
+	return c.symbolTable.GetMajorVersion() < opcodes.V1_5
+}
+
+// Bytes returns the class file of the visited class, or the first error which occurred while writing the class
+// or one of its members (see {@link FieldWriter#GetError} and {@link MethodWriter#GetError}).
+func (c *ClassWriter) Bytes() ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	for _, fieldWriter := range c.fields {
+		if err := fieldWriter.GetError(); err != nil {
+			return nil, err
+		}
+	}
+	for _, methodWriter := range c.methods {
+		if err := methodWriter.GetError(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Adds the attribute names of the members and of the class to the constant pool, before writing it.
+	for _, fieldWriter := range c.fields {
+		fieldWriter.ComputeFieldInfoSize()
+	}
+	for _, methodWriter := range c.methods {
+		methodWriter.ComputeMethodInfoSize()
+	}
+	attributeCount := 0
+	if c.innerClasses != nil {
+		c.symbolTable.AddConstantUtf8("InnerClasses")
+		attributeCount++
+	}
+	if c.enclosingClassIndex != 0 {
+		c.symbolTable.AddConstantUtf8("EnclosingMethod")
+		attributeCount++
+	}
+	if (c.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && c.useSyntheticAttribute() {
+		c.symbolTable.AddConstantUtf8("Synthetic")
+		attributeCount++
+	}
+	if c.signatureIndex != 0 {
+		c.symbolTable.AddConstantUtf8("Signature")
+		attributeCount++
+	}
+	if c.sourceFileIndex != 0 {
+		c.symbolTable.AddConstantUtf8("SourceFile")
+		attributeCount++
+	}
+	if c.debugExtension != nil {
+		c.symbolTable.AddConstantUtf8("SourceDebugExtension")
+		attributeCount++
+	}
+	if (c.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		c.symbolTable.AddConstantUtf8("Deprecated")
+		attributeCount++
+	}
+	computeAnnotationAttributesSize(c.lastRuntimeVisibleAnnotation, c.lastRuntimeInvisibleAnnotation,
+		c.lastRuntimeVisibleTypeAnnotation, c.lastRuntimeInvisibleTypeAnnotation)
+	attributeCount += countAnnotationAttributes(c.lastRuntimeVisibleAnnotation, c.lastRuntimeInvisibleAnnotation,
+		c.lastRuntimeVisibleTypeAnnotation, c.lastRuntimeInvisibleTypeAnnotation)
+	if c.moduleWriter != nil {
+		c.moduleWriter.ComputeAttributesSize()
+		attributeCount += c.moduleWriter.GetAttributeCount()
+	}
+	if c.firstAttribute != nil {
+		c.firstAttribute.computeAttributesSize(c.symbolTable)
+		attributeCount += c.firstAttribute.getAttributeCount()
+	}
+	if c.symbolTable.ComputeBootstrapMethodsSize() > 0 {
+		attributeCount++
+	}
+	if err := c.symbolTable.GetError(); err != nil {
+		return nil, err
+	}
+
+	mask := 0
+	if c.useSyntheticAttribute() {
+		mask = opcodes.ACC_SYNTHETIC
+	}
+	output := NewByteVector().PutInt(0xCAFEBABE).PutInt(c.version)
+	c.symbolTable.PutConstantPool(output)
+	output.PutShort(c.accessFlags &^ mask).PutShort(c.thisClass).PutShort(c.superClass).PutShort(len(c.interfaces))
+	for _, itf := range c.interfaces {
+		output.PutShort(itf)
+	}
+	output.PutShort(len(c.fields))
+	for _, fieldWriter := range c.fields {
+		fieldWriter.PutFieldInfo(output)
+	}
+	output.PutShort(len(c.methods))
+	for _, methodWriter := range c.methods {
+		methodWriter.PutMethodInfo(output)
+	}
+	output.PutShort(attributeCount)
+	if c.innerClasses != nil {
+		output.PutShort(c.symbolTable.AddConstantUtf8("InnerClasses")).PutInt(c.innerClasses.Size()+2).
+			PutShort(c.numberOfInnerClasses).PutByteArray(c.innerClasses.Bytes(), 0, c.innerClasses.Size())
+	}
+	if c.enclosingClassIndex != 0 {
+		output.PutShort(c.symbolTable.AddConstantUtf8("EnclosingMethod")).PutInt(4).
+			PutShort(c.enclosingClassIndex).PutShort(c.enclosingMethodIndex)
+	}
+	if (c.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && c.useSyntheticAttribute() {
+		output.PutShort(c.symbolTable.AddConstantUtf8("Synthetic")).PutInt(0)
+	}
+	if c.signatureIndex != 0 {
+		output.PutShort(c.symbolTable.AddConstantUtf8("Signature")).PutInt(2).PutShort(c.signatureIndex)
+	}
+	if c.sourceFileIndex != 0 {
+		output.PutShort(c.symbolTable.AddConstantUtf8("SourceFile")).PutInt(2).PutShort(c.sourceFileIndex)
+	}
+	if c.debugExtension != nil {
+		output.PutShort(c.symbolTable.AddConstantUtf8("SourceDebugExtension")).PutInt(c.debugExtension.Size()).
+			PutByteArray(c.debugExtension.Bytes(), 0, c.debugExtension.Size())
+	}
+	if (c.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		output.PutShort(c.symbolTable.AddConstantUtf8("Deprecated")).PutInt(0)
+	}
+	putAnnotationAttributes(c.symbolTable, c.lastRuntimeVisibleAnnotation, c.lastRuntimeInvisibleAnnotation,
+		c.lastRuntimeVisibleTypeAnnotation, c.lastRuntimeInvisibleTypeAnnotation, output)
+	if c.moduleWriter != nil {
+		c.moduleWriter.PutAttributes(output)
+	}
+	if c.firstAttribute != nil {
+		c.firstAttribute.putAttribute(c.symbolTable, output)
+	}
+	c.symbolTable.PutBootstrapMethods(output)
+	return output.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package asm_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// rewrite returns the given class file, read with the given options and written again with a {@link ClassWriter}.
+func rewrite(t *testing.T, classFile []byte, parsingOptions int) []byte {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classWriter := asm.NewClassWriter(reader)
+	reader.Accept(classWriter, parsingOptions)
+	result, err := classWriter.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// trace returns the trace of the events of the given class file.
+func trace(t *testing.T, classFile []byte) []string {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(recorder, 0)
+	return recorder.Trace()
+}
+
+func TestClassWriterRoundTrip(t *testing.T) {
+	exampleClass, err := os.ReadFile("../ExampleClass.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The compressed frames are written as visited, and the expanded ones are compressed again.
+	for _, parsingOptions := range []int{0, asm.EXPAND_FRAMS, asm.PRESERVE_UNKNOWN_ATTRIBUTES} {
+		recorder := asmtest.NewRecorder(nil)
+		reader, err := asm.NewClassReader(rewrite(t, exampleClass, parsingOptions))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader.Accept(recorder, 0)
+		recorder.AssertTrace(t, trace(t, exampleClass))
+	}
+}
+
+func TestClassWriterAttributes(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_4, opcodes.ACC_SUPER|opcodes.ACC_SYNTHETIC|opcodes.ACC_DEPRECATED,
+		"p/C$1", "java/lang/Object")
+	classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "m", "()V", "", []string{"java/io/IOException"})
+	classFile.AddField(opcodes.ACC_STATIC|opcodes.ACC_FINAL, "F", "I", "", int32(1))
+	original := classFile.Bytes()
+
+	reader, err := asm.NewClassReader(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classWriter := asm.NewClassWriter(nil)
+	classWriter.Visit(opcodes.V1_4, opcodes.ACC_SUPER|opcodes.ACC_SYNTHETIC|opcodes.ACC_DEPRECATED, "p/C$1",
+		"Ljava/lang/Object;", "java/lang/Object", nil)
+	classWriter.VisitSource("C.java", "SMAP\né\x00\n")
+	classWriter.VisitOuterClass("p/C", "m", "()V")
+	classWriter.VisitAnnotation("Lp/A;", false).VisitEnd()
+	classWriter.VisitAttribute(asm.NewAttributeWithContent("Custom", []byte{1, 2, 3}))
+	classWriter.VisitInnerClass("p/C$1", "", "", 0)
+	// A class listed twice is written once.
+	classWriter.VisitInnerClass("p/C$1", "", "", 0)
+	reader.Accept(&skipClassHeader{classWriter}, 0)
+	result, err := classWriter.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err = asm.NewClassReader(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(recorder, 0)
+	// The synthetic and deprecated flags of a 1.4 class are written as attributes, and read back as flags.
+	recorder.AssertTrace(t, []string{
+		`class visit p/C$1 48 135200 "p/C$1" "Ljava/lang/Object;" "java/lang/Object" []`,
+		`class visit source p/C$1 "C.java" "SMAP\né\x00\n"`,
+		`class visit outer class p/C$1 "p/C" "m" "()V"`,
+		`class visit annotation p/C$1 "Lp/A;" false`,
+		`class visit attribute p/C$1 attribute Custom`,
+		`class visit inner class p/C$1 "p/C$1" "" "" 0`,
+		`class visit field p/C$1 24 "F" "I" "" 1`,
+		`field visit end p/C$1.F I`,
+		`class visit method p/C$1 1025 "m" "()V" "" [java/io/IOException]`,
+		`method visit end p/C$1.m()V`,
+		`class visit end p/C$1`,
+	})
+}
+
+// skipClassHeader a {@link ClassVisitor} forwarding only the members of a class.
+type skipClassHeader struct {
+	asm.ClassVisitor
+}
+
+func (s *skipClassHeader) Visit(version, access int, name, signature, superName string, interfaces []string) {
+}
+
+func (s *skipClassHeader) VisitEnd() {
+}
@@ -0,0 +1,161 @@
+package commons
+
+import (
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// UsageData the usage information computed from a call graph, used by {@link AccessHardener}.
+type UsageData struct {
+	// Users maps classes (internal name), fields ("owner.name") and methods ("owner.name" + descriptor) to the
+	// internal names of the classes which reference them. A class must be listed as used by the classes which
+	// use any of its members.
+	Users map[string][]string
+	// Subclassed the internal names of the classes which are extended by another class.
+	Subclassed map[string]bool
+	// Overridden the methods ("owner.name" + descriptor) which are overridden in a subclass.
+	Overridden map[string]bool
+	// Overriding the methods ("owner.name" + descriptor) which override or implement a method of a super type.
+	// Their visibility can't be reduced.
+	Overriding map[string]bool
+}
+
+// AccessChange an access modification made by {@link AccessHardener}. Member is the internal name of a class,
+// "owner.name" for a field or "owner.name" + descriptor for a method.
+type AccessChange struct {
+	Member    string
+	OldAccess int
+	NewAccess int
+}
+
+// AccessHardener a {@link ClassVisitor} that tightens the access flags of the visited class and of its members,
+// where the given usage data shows that it is safe:
+//
+// - public classes, fields and methods only used from their own package become package private, except the
+// methods overriding a super type method,
+// - classes which are not subclassed become final,
+// - methods of non final classes which are not overridden become final.
+//
+// Interfaces, their members, constructors, static initializers and the JVM entry points (the public static main,
+// premain and agentmain methods) are left unchanged. The InnerClasses entries of the nested classes are updated
+// with the flags given to the nested classes themselves, so that the entries of their outer classes stay
+// consistent. All the modifications are reported in Changes. This is the inverse of {@link FinalFieldOpener}.
+type AccessHardener struct {
+	helper.ClassAdapter
+	Changes     []AccessChange
+	usage       UsageData
+	className   string
+	classAccess int
+	skip        bool
+}
+
+// NewAccessHardener constructs a new {@link AccessHardener}.
+func NewAccessHardener(classVisitor asm.ClassVisitor, usage UsageData) *AccessHardener {
+	return &AccessHardener{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		usage:        usage,
+	}
+}
+
+func (a *AccessHardener) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	a.className = name
+	a.skip = (access & (opcodes.ACC_INTERFACE | opcodes.ACC_MODULE)) != 0
+	if !a.skip {
+		access = a.change(name, access, a.hardenClass(name, access))
+	}
+	a.classAccess = access
+	a.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+// hardenClass returns the tightened access flags of the given class, which must not be an interface. The access
+// flags can also be those of an InnerClasses entry.
+func (a *AccessHardener) hardenClass(name string, access int) int {
+	newAccess := access
+	if (access&(opcodes.ACC_PUBLIC|opcodes.ACC_PROTECTED)) != 0 && a.isPackageLocal(name, name) {
+		newAccess &^= opcodes.ACC_PUBLIC | opcodes.ACC_PROTECTED
+	}
+	if (access&(opcodes.ACC_FINAL|opcodes.ACC_ABSTRACT)) == 0 && !a.usage.Subclassed[name] {
+		newAccess |= opcodes.ACC_FINAL
+	}
+	return newAccess
+}
+
+func (a *AccessHardener) VisitInnerClass(name, outerName, innerName string, access int) {
+	// Keeps the InnerClasses entries, of the visited class and of the other nested classes it references,
+	// consistent with the new access flags of these classes, computed in the same way when they are visited.
+	if (access & opcodes.ACC_INTERFACE) == 0 {
+		access = a.hardenClass(name, access)
+	}
+	a.ClassAdapter.VisitInnerClass(name, outerName, innerName, access)
+}
+
+func (a *AccessHardener) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	if !a.skip {
+		key := a.className + "." + name
+		if (access&opcodes.ACC_PUBLIC) != 0 && a.isPackageLocal(a.className, key) {
+			access = a.change(key, access, access&^opcodes.ACC_PUBLIC)
+		}
+	}
+	return a.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+}
+
+func (a *AccessHardener) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	if !a.skip && name != "<init>" && name != "<clinit>" && !isEntryPoint(access, name, descriptor) {
+		key := a.className + "." + name + descriptor
+		newAccess := access
+		if (access&opcodes.ACC_PUBLIC) != 0 && !a.usage.Overriding[key] && a.isPackageLocal(a.className, key) {
+			newAccess &^= opcodes.ACC_PUBLIC
+		}
+		if (a.classAccess&opcodes.ACC_FINAL) == 0 &&
+			(access&(opcodes.ACC_FINAL|opcodes.ACC_STATIC|opcodes.ACC_PRIVATE|opcodes.ACC_ABSTRACT)) == 0 &&
+			!a.usage.Overridden[key] {
+			newAccess |= opcodes.ACC_FINAL
+		}
+		access = a.change(key, access, newAccess)
+	}
+	return a.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+}
+
+// isEntryPoint returns whether the given method is called by the JVM to start a program or an agent.
+func isEntryPoint(access int, name, descriptor string) bool {
+	if (access & (opcodes.ACC_PUBLIC | opcodes.ACC_STATIC)) != opcodes.ACC_PUBLIC|opcodes.ACC_STATIC {
+		return false
+	}
+	switch name {
+	case "main":
+		return descriptor == "([Ljava/lang/String;)V"
+	case "premain", "agentmain":
+		return descriptor == "(Ljava/lang/String;)V" || descriptor == "(Ljava/lang/String;Ljava/lang/instrument/Instrumentation;)V"
+	}
+	return false
+}
+
+// isPackageLocal returns whether the given class or member of the given class is only used from the package of
+// this class.
+func (a *AccessHardener) isPackageLocal(className, key string) bool {
+	classPackage := packageName(className)
+	for _, user := range a.usage.Users[key] {
+		if packageName(user) != classPackage {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *AccessHardener) change(member string, oldAccess, newAccess int) int {
+	if oldAccess != newAccess {
+		a.Changes = append(a.Changes, AccessChange{member, oldAccess, newAccess})
+	}
+	return newAccess
+}
+
+func packageName(internalName string) string {
+	index := strings.LastIndexByte(internalName, '/')
+	if index == -1 {
+		return ""
+	}
+	return internalName[:index]
+}
@@ -0,0 +1,106 @@
+package commons_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// innerClassEntry an entry of an InnerClasses attribute.
+type innerClassEntry struct {
+	name, outerName, innerName string
+	access                     int
+}
+
+// nestedClass returns a class with the given access, name and InnerClasses entries, a public field f, and the
+// public methods "void run()" and "static void main(String[])".
+func nestedClass(access int, name string, entries ...innerClassEntry) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, access, name, "java/lang/Object")
+	classFile.AddField(opcodes.ACC_PUBLIC, "f", "I", "", nil)
+	for _, method := range []struct {
+		access           int
+		name, descriptor string
+	}{
+		{opcodes.ACC_PUBLIC, "run", "()V"},
+		{opcodes.ACC_PUBLIC | opcodes.ACC_STATIC, "main", "([Ljava/lang/String;)V"},
+	} {
+		methodVisitor := classFile.AddMethod(method.access, method.name, method.descriptor, "", nil)
+		methodVisitor.VisitCode()
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 1)
+		methodVisitor.VisitEnd()
+	}
+	content := []byte{byte(len(entries) >> 8), byte(len(entries))}
+	for _, entry := range entries {
+		outerClass := classFile.SymbolTable.AddConstantClass(entry.outerName).GetIndex()
+		innerName := classFile.SymbolTable.AddConstantUtf8(entry.innerName)
+		for _, value := range []int{classFile.SymbolTable.AddConstantClass(entry.name).GetIndex(), outerClass, innerName, entry.access} {
+			content = append(content, byte(value>>8), byte(value))
+		}
+	}
+	classFile.AddAttribute("InnerClasses", content)
+	return classFile.Bytes()
+}
+
+// hardenClass returns the changes made by an {@link AccessHardener} to the given class, and the recorded
+// events of the hardened class.
+func hardenClass(t *testing.T, classFile []byte, usage commons.UsageData) ([]commons.AccessChange, *asmtest.Recorder) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	hardener := commons.NewAccessHardener(recorder, usage)
+	reader.Accept(hardener, 0)
+	return hardener.Changes, recorder
+}
+
+func TestAccessHardener(t *testing.T) {
+	usage := commons.UsageData{
+		Users: map[string][]string{
+			"p/Outer":        {"q/Main"},
+			"p/Outer.f":      {"q/Main"},
+			"p/Outer$In":     {"p/Outer"},
+			"p/Outer.run()V": {"p/Outer$In"},
+		},
+		Subclassed: map[string]bool{},
+	}
+	public := opcodes.ACC_PUBLIC | opcodes.ACC_SUPER
+	entries := []innerClassEntry{
+		{"p/Outer$In", "p/Outer", "In", opcodes.ACC_PROTECTED | opcodes.ACC_STATIC},
+		{"p/Outer$Itf", "p/Outer", "Itf", opcodes.ACC_PUBLIC | opcodes.ACC_STATIC | opcodes.ACC_INTERFACE | opcodes.ACC_ABSTRACT},
+	}
+	changes, recorder := hardenClass(t, nestedClass(public, "p/Outer", entries...), usage)
+	// Outer is used from another package and stays public, but becomes final, its field f is used from another
+	// package, and its main method is an entry point.
+	expected := []commons.AccessChange{
+		{"p/Outer", public, public | opcodes.ACC_FINAL},
+		{"p/Outer.run()V", opcodes.ACC_PUBLIC, 0},
+	}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Errorf("unexpected changes %v", changes)
+	}
+	// The entry of In is updated as In itself, and the one of the interface is unchanged.
+	innerClasses := recorder.GetEvents(helper.CLASS_VISIT_INNER_CLASS, "")
+	if len(innerClasses) != 2 || innerClasses[0].Args[3] != opcodes.ACC_STATIC|opcodes.ACC_FINAL ||
+		innerClasses[1].Args[3] != entries[1].access {
+		t.Errorf("unexpected InnerClasses entries %v", innerClasses)
+	}
+
+	changes, recorder = hardenClass(t, nestedClass(public, "p/Outer$In", entries[0]), usage)
+	if len(changes) == 0 || changes[0] != (commons.AccessChange{"p/Outer$In", public, opcodes.ACC_SUPER | opcodes.ACC_FINAL}) {
+		t.Errorf("unexpected changes %v", changes)
+	}
+	if access := recorder.GetEvents(helper.CLASS_VISIT_INNER_CLASS, "")[0].Args[3]; access != opcodes.ACC_STATIC|opcodes.ACC_FINAL {
+		t.Errorf("unexpected InnerClasses entry access %v", access)
+	}
+	recorder.AssertVisitedMethod(t, "main", "([Ljava/lang/String;)V")
+	if access := recorder.GetEvents(helper.CLASS_VISIT_METHOD, "")[1].Args[0]; access != opcodes.ACC_PUBLIC|opcodes.ACC_STATIC {
+		t.Errorf("unexpected main access %v", access)
+	}
+}
@@ -0,0 +1,594 @@
+package commons
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// AnalyzerAdapter a {@link MethodVisitor} that keeps track of stack map frame changes between
+// {@link VisitFrame} calls. This adapter must be used with the {@link asm.EXPAND_FRAMS} option. Each visitX
+// instruction delegates to the next visitor in the chain, if any, and then simulates the effect of this
+// instruction on the stack map frame, represented by {@link Locals} and {@link Stack}. The next visitor in
+// the chain can get the state of the stack map frame <i>before</i> each instruction by reading the value of
+// these fields in its visitX methods (this requires a reference to the AnalyzerAdapter that is before it in
+// the chain). If this adapter is used with a class that does not contain stack map table attributes (i.e.,
+// pre Java 6 classes) then this adapter may not be able to compute the stack map frame for each instruction.
+// In this case no exception is thrown but the {@link Locals} and {@link Stack} fields will be nil for these
+// instructions.
+//
+// Stack underflows and unsupported instructions are reported to the Handler, as the stack shape of the
+// visited code can't be trusted from then on.
+type AnalyzerAdapter struct {
+	helper.MethodAdapter
+	// Locals the local variable slots for the current execution frame. Primitive types are represented by
+	// {@link opcodes.TOP}, {@link opcodes.INTEGER}, {@link opcodes.FLOAT}, {@link opcodes.LONG},
+	// {@link opcodes.DOUBLE}, {@link opcodes.NULL} or {@link opcodes.UNINITIALIZED_THIS} (long and double are
+	// represented by two elements, the second one being TOP). Reference types are represented by string
+	// objects (representing internal names), and uninitialized types by *asm.Label objects (this label
+	// designates the NEW instruction that created this uninitialized value). This field is nil for
+	// unreachable instructions.
+	Locals []interface{}
+	// Stack the operand stack slots for the current execution frame, represented as {@link Locals}. This field
+	// is nil for unreachable instructions.
+	Stack []interface{}
+	// Handler if not nil, is called with each error found in the visited code.
+	Handler func(err error)
+	// Errors the errors found so far in the visited code.
+	Errors []error
+	// labels the labels that designate the next instruction to be visited. May be nil.
+	labels []*asm.Label
+	// uninitializedTypes the uninitialized types in the current execution frame. This map associates internal
+	// names to labels, designating the NEW instruction that created the corresponding value.
+	uninitializedTypes map[*asm.Label]string
+	maxStack           int
+	maxLocals          int
+	owner              string
+}
+
+// NewAnalyzerAdapter constructs a new {@link AnalyzerAdapter} for the given method of the given class (given by
+// its internal name), delegating to the given method visitor (which may be nil).
+func NewAnalyzerAdapter(owner string, access int, name, descriptor string, methodVisitor asm.MethodVisitor) *AnalyzerAdapter {
+	a := &AnalyzerAdapter{
+		MethodAdapter:      helper.MethodAdapter{Next: methodVisitor},
+		Locals:             []interface{}{},
+		Stack:              []interface{}{},
+		uninitializedTypes: make(map[*asm.Label]string),
+		owner:              owner,
+	}
+	if (access & opcodes.ACC_STATIC) == 0 {
+		if name == "<init>" {
+			a.Locals = append(a.Locals, opcodes.UNINITIALIZED_THIS)
+		} else {
+			a.Locals = append(a.Locals, owner)
+		}
+	}
+	for _, argumentType := range asm.GetMethodType(descriptor).GetArgumentTypes() {
+		switch argumentType.GetSort() {
+		case typed.BOOLEAN, typed.CHAR, typed.BYTE, typed.SHORT, typed.INT:
+			a.Locals = append(a.Locals, opcodes.INTEGER)
+		case typed.FLOAT:
+			a.Locals = append(a.Locals, opcodes.FLOAT)
+		case typed.LONG:
+			a.Locals = append(a.Locals, opcodes.LONG, opcodes.TOP)
+		case typed.DOUBLE:
+			a.Locals = append(a.Locals, opcodes.DOUBLE, opcodes.TOP)
+		case typed.ARRAY:
+			a.Locals = append(a.Locals, argumentType.GetDescriptor())
+		default:
+			a.Locals = append(a.Locals, argumentType.GetInternalName())
+		}
+	}
+	a.maxLocals = len(a.Locals)
+	return a
+}
+
+func (a *AnalyzerAdapter) error(err error) {
+	a.Errors = append(a.Errors, err)
+	if a.Handler != nil {
+		a.Handler(err)
+	}
+}
+
+func (a *AnalyzerAdapter) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	if typed != opcodes.F_NEW {
+		a.error(errors.New("Illegal Argument - AnalyzerAdapter only accepts expanded frames (see asm.EXPAND_FRAMS)"))
+		a.MethodAdapter.VisitFrame(typed, nLocal, local, nStack, stack)
+		return
+	}
+	a.MethodAdapter.VisitFrame(typed, nLocal, local, nStack, stack)
+	a.Locals = appendFrameTypes(make([]interface{}, 0, nLocal), nLocal, local)
+	a.Stack = appendFrameTypes(make([]interface{}, 0, nStack), nStack, stack)
+	a.maxLocals = max(a.maxLocals, len(a.Locals))
+	a.maxStack = max(a.maxStack, len(a.Stack))
+}
+
+func appendFrameTypes(result []interface{}, numTypes int, frameTypes interface{}) []interface{} {
+	types, _ := frameTypes.([]interface{})
+	for i := 0; i < numTypes && i < len(types); i++ {
+		result = append(result, types[i])
+		if types[i] == opcodes.LONG || types[i] == opcodes.DOUBLE {
+			result = append(result, opcodes.TOP)
+		}
+	}
+	return result
+}
+
+func (a *AnalyzerAdapter) VisitInsn(opcode int) {
+	a.MethodAdapter.VisitInsn(opcode)
+	a.execute(opcode, 0, "")
+	if (opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN) || opcode == opcodes.ATHROW {
+		a.Locals = nil
+		a.Stack = nil
+	}
+}
+
+func (a *AnalyzerAdapter) VisitIntInsn(opcode, operand int) {
+	a.MethodAdapter.VisitIntInsn(opcode, operand)
+	a.execute(opcode, operand, "")
+}
+
+func (a *AnalyzerAdapter) VisitVarInsn(opcode, vard int) {
+	a.MethodAdapter.VisitVarInsn(opcode, vard)
+	isLongOrDouble := opcode == opcodes.LLOAD || opcode == opcodes.DLOAD || opcode == opcodes.LSTORE || opcode == opcodes.DSTORE
+	if isLongOrDouble {
+		a.maxLocals = max(a.maxLocals, vard+2)
+	} else {
+		a.maxLocals = max(a.maxLocals, vard+1)
+	}
+	a.execute(opcode, vard, "")
+}
+
+func (a *AnalyzerAdapter) VisitTypeInsn(opcode int, typed string) {
+	if opcode == opcodes.NEW {
+		if a.labels == nil {
+			label := &asm.Label{}
+			a.labels = []*asm.Label{label}
+			a.MethodAdapter.VisitLabel(label)
+		}
+		for _, label := range a.labels {
+			a.uninitializedTypes[label] = typed
+		}
+	}
+	a.MethodAdapter.VisitTypeInsn(opcode, typed)
+	a.execute(opcode, 0, typed)
+}
+
+func (a *AnalyzerAdapter) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	a.MethodAdapter.VisitFieldInsn(opcode, owner, name, descriptor)
+	a.execute(opcode, 0, descriptor)
+}
+
+func (a *AnalyzerAdapter) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	a.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
+
+func (a *AnalyzerAdapter) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	a.MethodAdapter.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+	if a.Locals == nil {
+		a.labels = nil
+		return
+	}
+	a.popDescriptor(descriptor)
+	if opcode != opcodes.INVOKESTATIC {
+		value := a.pop()
+		if opcode == opcodes.INVOKESPECIAL && name == "<init>" {
+			var initializedValue interface{}
+			if value == opcodes.UNINITIALIZED_THIS {
+				initializedValue = a.owner
+			} else if label, ok := value.(*asm.Label); ok {
+				initializedValue = a.uninitializedTypes[label]
+			}
+			if initializedValue != nil {
+				for i, local := range a.Locals {
+					if local == value {
+						a.Locals[i] = initializedValue
+					}
+				}
+				for i, element := range a.Stack {
+					if element == value {
+						a.Stack[i] = initializedValue
+					}
+				}
+			}
+		}
+	}
+	a.pushDescriptor(descriptor)
+	a.labels = nil
+}
+
+func (a *AnalyzerAdapter) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	a.MethodAdapter.VisitInvokeDynamicInsn(name, descriptor, bootstrapMethodHande, bootstrapMethodArguments...)
+	if a.Locals == nil {
+		a.labels = nil
+		return
+	}
+	a.popDescriptor(descriptor)
+	a.pushDescriptor(descriptor)
+	a.labels = nil
+}
+
+func (a *AnalyzerAdapter) VisitJumpInsn(opcode int, label *asm.Label) {
+	a.MethodAdapter.VisitJumpInsn(opcode, label)
+	a.execute(opcode, 0, "")
+	if opcode == opcodes.GOTO {
+		a.Locals = nil
+		a.Stack = nil
+	}
+}
+
+func (a *AnalyzerAdapter) VisitLabel(label *asm.Label) {
+	a.MethodAdapter.VisitLabel(label)
+	a.labels = append(a.labels, label)
+}
+
+func (a *AnalyzerAdapter) VisitLdcInsn(value interface{}) {
+	a.MethodAdapter.VisitLdcInsn(value)
+	if a.Locals == nil {
+		a.labels = nil
+		return
+	}
+	switch value := value.(type) {
+	case int, int32:
+		a.push(opcodes.INTEGER)
+	case int64:
+		a.push(opcodes.LONG)
+		a.push(opcodes.TOP)
+	case float32:
+		a.push(opcodes.FLOAT)
+	case float64:
+		a.push(opcodes.DOUBLE)
+		a.push(opcodes.TOP)
+	case string:
+		a.push("java/lang/String")
+	case *asm.Type:
+		if value.GetSort() == typed.METHOD {
+			a.push("java/lang/invoke/MethodType")
+		} else {
+			a.push("java/lang/Class")
+		}
+	case *asm.Handle:
+		a.push("java/lang/invoke/MethodHandle")
+	default:
+		a.error(errors.New("Illegal Argument - unsupported LDC constant"))
+	}
+	a.labels = nil
+}
+
+func (a *AnalyzerAdapter) VisitIincInsn(vard, increment int) {
+	a.MethodAdapter.VisitIincInsn(vard, increment)
+	a.maxLocals = max(a.maxLocals, vard+1)
+	a.execute(opcodes.IINC, vard, "")
+}
+
+func (a *AnalyzerAdapter) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	a.MethodAdapter.VisitTableSwitchInsn(min, max, dflt, labels...)
+	a.execute(opcodes.TABLESWITCH, 0, "")
+	a.Locals = nil
+	a.Stack = nil
+}
+
+func (a *AnalyzerAdapter) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	a.MethodAdapter.VisitLookupSwitchInsn(dflt, keys, labels)
+	a.execute(opcodes.LOOKUPSWITCH, 0, "")
+	a.Locals = nil
+	a.Stack = nil
+}
+
+func (a *AnalyzerAdapter) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	a.MethodAdapter.VisitMultiANewArrayInsn(descriptor, numDimensions)
+	a.execute(opcodes.MULTIANEWARRAY, numDimensions, descriptor)
+}
+
+func (a *AnalyzerAdapter) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	if descriptor[0] == 'J' || descriptor[0] == 'D' {
+		a.maxLocals = max(a.maxLocals, index+2)
+	} else {
+		a.maxLocals = max(a.maxLocals, index+1)
+	}
+	a.MethodAdapter.VisitLocalVariable(name, descriptor, signature, start, end, index)
+}
+
+func (a *AnalyzerAdapter) VisitMaxs(maxStack int, maxLocals int) {
+	a.maxStack = max(a.maxStack, maxStack)
+	a.maxLocals = max(a.maxLocals, maxLocals)
+	a.MethodAdapter.VisitMaxs(a.maxStack, a.maxLocals)
+}
+
+// GetMaxStack returns the maximum stack size seen so far.
+func (a *AnalyzerAdapter) GetMaxStack() int {
+	return a.maxStack
+}
+
+// GetMaxLocals returns the maximum number of local variables seen so far.
+func (a *AnalyzerAdapter) GetMaxLocals() int {
+	return a.maxLocals
+}
+
+func (a *AnalyzerAdapter) get(local int) interface{} {
+	a.maxLocals = max(a.maxLocals, local+1)
+	if local < len(a.Locals) {
+		return a.Locals[local]
+	}
+	return opcodes.TOP
+}
+
+func (a *AnalyzerAdapter) set(local int, typed interface{}) {
+	a.maxLocals = max(a.maxLocals, local+1)
+	for local >= len(a.Locals) {
+		a.Locals = append(a.Locals, opcodes.TOP)
+	}
+	a.Locals[local] = typed
+}
+
+func (a *AnalyzerAdapter) push(typed interface{}) {
+	a.Stack = append(a.Stack, typed)
+	a.maxStack = max(a.maxStack, len(a.Stack))
+}
+
+func (a *AnalyzerAdapter) pushDescriptor(fieldOrMethodDescriptor string) {
+	descriptor := fieldOrMethodDescriptor
+	if descriptor[0] == '(' {
+		descriptor = asm.GetMethodType(descriptor).GetReturnType().GetDescriptor()
+	}
+	switch descriptor[0] {
+	case 'V':
+	case 'Z', 'C', 'B', 'S', 'I':
+		a.push(opcodes.INTEGER)
+	case 'F':
+		a.push(opcodes.FLOAT)
+	case 'J':
+		a.push(opcodes.LONG)
+		a.push(opcodes.TOP)
+	case 'D':
+		a.push(opcodes.DOUBLE)
+		a.push(opcodes.TOP)
+	case '[':
+		a.push(descriptor)
+	case 'L':
+		a.push(descriptor[1 : len(descriptor)-1])
+	default:
+		a.error(errors.New("Illegal Argument - invalid descriptor " + fieldOrMethodDescriptor))
+	}
+}
+
+func (a *AnalyzerAdapter) pop() interface{} {
+	if len(a.Stack) == 0 {
+		a.error(errors.New("Illegal State - operand stack underflow"))
+		return opcodes.TOP
+	}
+	value := a.Stack[len(a.Stack)-1]
+	a.Stack = a.Stack[:len(a.Stack)-1]
+	return value
+}
+
+func (a *AnalyzerAdapter) popN(numSlots int) {
+	if numSlots > len(a.Stack) {
+		a.error(errors.New("Illegal State - operand stack underflow"))
+		numSlots = len(a.Stack)
+	}
+	a.Stack = a.Stack[:len(a.Stack)-numSlots]
+}
+
+func (a *AnalyzerAdapter) popDescriptor(descriptor string) {
+	switch descriptor[0] {
+	case '(':
+		numSlots := 0
+		for _, argumentType := range asm.GetMethodType(descriptor).GetArgumentTypes() {
+			numSlots += argumentType.GetSize()
+		}
+		a.popN(numSlots)
+	case 'J', 'D':
+		a.popN(2)
+	default:
+		a.popN(1)
+	}
+}
+
+func (a *AnalyzerAdapter) execute(opcode, intArg int, stringArg string) {
+	if opcode == opcodes.JSR || opcode == opcodes.RET {
+		a.error(errors.New("Illegal Argument - JSR/RET are not supported"))
+		a.Locals = nil
+		a.Stack = nil
+	}
+	if a.Locals == nil {
+		a.labels = nil
+		return
+	}
+	switch opcode {
+	case opcodes.NOP, opcodes.INEG, opcodes.LNEG, opcodes.FNEG, opcodes.DNEG, opcodes.I2B, opcodes.I2C, opcodes.I2S,
+		opcodes.GOTO, opcodes.RETURN:
+	case opcodes.ACONST_NULL:
+		a.push(opcodes.NULL)
+	case opcodes.ICONST_M1, opcodes.ICONST_0, opcodes.ICONST_1, opcodes.ICONST_2, opcodes.ICONST_3, opcodes.ICONST_4,
+		opcodes.ICONST_5, opcodes.BIPUSH, opcodes.SIPUSH:
+		a.push(opcodes.INTEGER)
+	case opcodes.LCONST_0, opcodes.LCONST_1:
+		a.push(opcodes.LONG)
+		a.push(opcodes.TOP)
+	case opcodes.FCONST_0, opcodes.FCONST_1, opcodes.FCONST_2:
+		a.push(opcodes.FLOAT)
+	case opcodes.DCONST_0, opcodes.DCONST_1:
+		a.push(opcodes.DOUBLE)
+		a.push(opcodes.TOP)
+	case opcodes.ILOAD, opcodes.FLOAD, opcodes.ALOAD:
+		a.push(a.get(intArg))
+	case opcodes.LLOAD, opcodes.DLOAD:
+		a.push(a.get(intArg))
+		a.push(opcodes.TOP)
+	case opcodes.LALOAD, opcodes.D2L:
+		a.popN(2)
+		a.push(opcodes.LONG)
+		a.push(opcodes.TOP)
+	case opcodes.DALOAD, opcodes.L2D:
+		a.popN(2)
+		a.push(opcodes.DOUBLE)
+		a.push(opcodes.TOP)
+	case opcodes.AALOAD:
+		a.popN(1)
+		value := a.pop()
+		if descriptor, ok := value.(string); ok && len(descriptor) > 1 && descriptor[0] == '[' {
+			a.pushDescriptor(descriptor[1:])
+		} else if value == opcodes.NULL {
+			a.push(value)
+		} else {
+			a.push("java/lang/Object")
+		}
+	case opcodes.ISTORE, opcodes.FSTORE, opcodes.ASTORE:
+		a.set(intArg, a.pop())
+		if intArg > 0 {
+			if value := a.get(intArg - 1); value == opcodes.LONG || value == opcodes.DOUBLE {
+				a.set(intArg-1, opcodes.TOP)
+			}
+		}
+	case opcodes.LSTORE, opcodes.DSTORE:
+		a.popN(1)
+		a.set(intArg, a.pop())
+		a.set(intArg+1, opcodes.TOP)
+		if intArg > 0 {
+			if value := a.get(intArg - 1); value == opcodes.LONG || value == opcodes.DOUBLE {
+				a.set(intArg-1, opcodes.TOP)
+			}
+		}
+	case opcodes.IASTORE, opcodes.BASTORE, opcodes.CASTORE, opcodes.SASTORE, opcodes.FASTORE, opcodes.AASTORE:
+		a.popN(3)
+	case opcodes.LASTORE, opcodes.DASTORE:
+		a.popN(4)
+	case opcodes.POP, opcodes.IFEQ, opcodes.IFNE, opcodes.IFLT, opcodes.IFGE, opcodes.IFGT, opcodes.IFLE,
+		opcodes.IRETURN, opcodes.FRETURN, opcodes.ARETURN, opcodes.TABLESWITCH, opcodes.LOOKUPSWITCH,
+		opcodes.ATHROW, opcodes.MONITORENTER, opcodes.MONITOREXIT, opcodes.IFNULL, opcodes.IFNONNULL:
+		a.popN(1)
+	case opcodes.POP2, opcodes.IF_ICMPEQ, opcodes.IF_ICMPNE, opcodes.IF_ICMPLT, opcodes.IF_ICMPGE, opcodes.IF_ICMPGT,
+		opcodes.IF_ICMPLE, opcodes.IF_ACMPEQ, opcodes.IF_ACMPNE, opcodes.LRETURN, opcodes.DRETURN:
+		a.popN(2)
+	case opcodes.DUP:
+		value1 := a.pop()
+		a.push(value1)
+		a.push(value1)
+	case opcodes.DUP_X1:
+		value1, value2 := a.pop(), a.pop()
+		a.push(value1)
+		a.push(value2)
+		a.push(value1)
+	case opcodes.DUP_X2:
+		value1, value2, value3 := a.pop(), a.pop(), a.pop()
+		a.push(value1)
+		a.push(value3)
+		a.push(value2)
+		a.push(value1)
+	case opcodes.DUP2:
+		value1, value2 := a.pop(), a.pop()
+		a.push(value2)
+		a.push(value1)
+		a.push(value2)
+		a.push(value1)
+	case opcodes.DUP2_X1:
+		value1, value2, value3 := a.pop(), a.pop(), a.pop()
+		a.push(value2)
+		a.push(value1)
+		a.push(value3)
+		a.push(value2)
+		a.push(value1)
+	case opcodes.DUP2_X2:
+		value1, value2, value3, value4 := a.pop(), a.pop(), a.pop(), a.pop()
+		a.push(value2)
+		a.push(value1)
+		a.push(value4)
+		a.push(value3)
+		a.push(value2)
+		a.push(value1)
+	case opcodes.SWAP:
+		value1, value2 := a.pop(), a.pop()
+		a.push(value1)
+		a.push(value2)
+	case opcodes.IALOAD, opcodes.BALOAD, opcodes.CALOAD, opcodes.SALOAD, opcodes.IADD, opcodes.ISUB, opcodes.IMUL,
+		opcodes.IDIV, opcodes.IREM, opcodes.IAND, opcodes.IOR, opcodes.IXOR, opcodes.ISHL, opcodes.ISHR, opcodes.IUSHR,
+		opcodes.L2I, opcodes.D2I, opcodes.FCMPL, opcodes.FCMPG:
+		a.popN(2)
+		a.push(opcodes.INTEGER)
+	case opcodes.LADD, opcodes.LSUB, opcodes.LMUL, opcodes.LDIV, opcodes.LREM, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		a.popN(4)
+		a.push(opcodes.LONG)
+		a.push(opcodes.TOP)
+	case opcodes.FALOAD, opcodes.FADD, opcodes.FSUB, opcodes.FMUL, opcodes.FDIV, opcodes.FREM, opcodes.L2F, opcodes.D2F:
+		a.popN(2)
+		a.push(opcodes.FLOAT)
+	case opcodes.DADD, opcodes.DSUB, opcodes.DMUL, opcodes.DDIV, opcodes.DREM:
+		a.popN(4)
+		a.push(opcodes.DOUBLE)
+		a.push(opcodes.TOP)
+	case opcodes.LSHL, opcodes.LSHR, opcodes.LUSHR:
+		a.popN(3)
+		a.push(opcodes.LONG)
+		a.push(opcodes.TOP)
+	case opcodes.IINC:
+		a.set(intArg, opcodes.INTEGER)
+	case opcodes.I2L, opcodes.F2L:
+		a.popN(1)
+		a.push(opcodes.LONG)
+		a.push(opcodes.TOP)
+	case opcodes.I2F:
+		a.popN(1)
+		a.push(opcodes.FLOAT)
+	case opcodes.I2D, opcodes.F2D:
+		a.popN(1)
+		a.push(opcodes.DOUBLE)
+		a.push(opcodes.TOP)
+	case opcodes.F2I, opcodes.ARRAYLENGTH, opcodes.INSTANCEOF:
+		a.popN(1)
+		a.push(opcodes.INTEGER)
+	case opcodes.LCMP, opcodes.DCMPL, opcodes.DCMPG:
+		a.popN(4)
+		a.push(opcodes.INTEGER)
+	case opcodes.GETSTATIC:
+		a.pushDescriptor(stringArg)
+	case opcodes.PUTSTATIC:
+		a.popDescriptor(stringArg)
+	case opcodes.GETFIELD:
+		a.popN(1)
+		a.pushDescriptor(stringArg)
+	case opcodes.PUTFIELD:
+		a.popDescriptor(stringArg)
+		a.pop()
+	case opcodes.NEW:
+		a.push(a.labels[0])
+	case opcodes.NEWARRAY:
+		a.pop()
+		switch intArg {
+		case opcodes.T_BOOLEAN:
+			a.pushDescriptor("[Z")
+		case opcodes.T_CHAR:
+			a.pushDescriptor("[C")
+		case opcodes.T_BYTE:
+			a.pushDescriptor("[B")
+		case opcodes.T_SHORT:
+			a.pushDescriptor("[S")
+		case opcodes.T_INT:
+			a.pushDescriptor("[I")
+		case opcodes.T_FLOAT:
+			a.pushDescriptor("[F")
+		case opcodes.T_DOUBLE:
+			a.pushDescriptor("[D")
+		case opcodes.T_LONG:
+			a.pushDescriptor("[J")
+		default:
+			a.error(errors.New("Illegal Argument - invalid array type " + strconv.Itoa(intArg)))
+		}
+	case opcodes.ANEWARRAY:
+		a.pop()
+		a.pushDescriptor("[" + asm.GetObjectType(stringArg).GetDescriptor())
+	case opcodes.CHECKCAST:
+		a.pop()
+		a.pushDescriptor(asm.GetObjectType(stringArg).GetDescriptor())
+	case opcodes.MULTIANEWARRAY:
+		a.popN(intArg)
+		a.pushDescriptor(stringArg)
+	default:
+		a.error(errors.New("Illegal Argument - invalid opcode " + strconv.Itoa(opcode)))
+	}
+	a.labels = nil
+}
@@ -0,0 +1,193 @@
+package commons_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// loopMethod adds to the given class the method "int m(long l, String s)", with a loop, a try catch block, long,
+// double and uninitialized values, whose frames are computed by {@link asm.AddMethod}.
+func loopMethod(t *testing.T, classFile []byte) []byte {
+	result, err := asm.AddMethod(classFile, opcodes.ACC_PUBLIC, "m", "(JLjava/lang/String;)I", func(methodVisitor asm.MethodVisitor) {
+		loop, end, tryStart, tryEnd, handler, after, notNull := &asm.Label{}, &asm.Label{}, &asm.Label{},
+			&asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+		methodVisitor.VisitCode()
+		methodVisitor.VisitTryCatchBlock(tryStart, tryEnd, handler, "java/lang/RuntimeException")
+		methodVisitor.VisitTypeInsn(opcodes.NEW, "java/lang/StringBuilder")
+		methodVisitor.VisitInsn(opcodes.DUP)
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, "java/lang/StringBuilder", "<init>", "()V", false)
+		methodVisitor.VisitVarInsn(opcodes.ASTORE, 4)
+		methodVisitor.VisitInsn(opcodes.ICONST_0)
+		methodVisitor.VisitVarInsn(opcodes.ISTORE, 5)
+		methodVisitor.VisitLabel(loop)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 5)
+		methodVisitor.VisitIntInsn(opcodes.BIPUSH, 10)
+		methodVisitor.VisitJumpInsn(opcodes.IF_ICMPGE, end)
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 4)
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 3)
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/StringBuilder", "append",
+			"(Ljava/lang/String;)Ljava/lang/StringBuilder;", false)
+		methodVisitor.VisitInsn(opcodes.POP)
+		methodVisitor.VisitIincInsn(5, 1)
+		methodVisitor.VisitJumpInsn(opcodes.GOTO, loop)
+		methodVisitor.VisitLabel(end)
+		methodVisitor.VisitLabel(tryStart)
+		methodVisitor.VisitVarInsn(opcodes.LLOAD, 1)
+		methodVisitor.VisitInsn(opcodes.L2D)
+		methodVisitor.VisitVarInsn(opcodes.DSTORE, 6)
+		methodVisitor.VisitLabel(tryEnd)
+		methodVisitor.VisitJumpInsn(opcodes.GOTO, after)
+		methodVisitor.VisitLabel(handler)
+		methodVisitor.VisitVarInsn(opcodes.ASTORE, 8)
+		methodVisitor.VisitInsn(opcodes.ACONST_NULL)
+		methodVisitor.VisitVarInsn(opcodes.ASTORE, 3)
+		methodVisitor.VisitLabel(after)
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 3)
+		methodVisitor.VisitJumpInsn(opcodes.IFNONNULL, notNull)
+		methodVisitor.VisitInsn(opcodes.ICONST_0)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitLabel(notNull)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 5)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// basicFrameType returns the {@link AnalyzerAdapter} representation of the given value, with "reference" for
+// all the reference types.
+func basicFrameType(value *analysis.BasicValue) interface{} {
+	switch value {
+	case analysis.UNINITIALIZED_VALUE:
+		return opcodes.TOP
+	case analysis.INT_VALUE:
+		return opcodes.INTEGER
+	case analysis.FLOAT_VALUE:
+		return opcodes.FLOAT
+	case analysis.LONG_VALUE:
+		return opcodes.LONG
+	case analysis.DOUBLE_VALUE:
+		return opcodes.DOUBLE
+	}
+	return "reference"
+}
+
+// adapterFrameType returns the given {@link AnalyzerAdapter} frame type, with "reference" for all the
+// reference types.
+func adapterFrameType(value interface{}) interface{} {
+	switch value.(type) {
+	case string, *asm.Label:
+		return "reference"
+	}
+	if value == opcodes.NULL || value == opcodes.UNINITIALIZED_THIS {
+		return "reference"
+	}
+	return value
+}
+
+// checkAnalyzerAdapter checks that the stack and locals computed by an {@link AnalyzerAdapter} before each
+// instruction of the given method match the frames computed by an {@link analysis.Analyzer}.
+func checkAnalyzerAdapter(t *testing.T, owner string, method *tree.MethodNode) {
+	frames, err := analysis.NewAnalyzer[*analysis.BasicValue](analysis.NewBasicInterpreter()).Analyze(owner, method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := commons.NewAnalyzerAdapter(owner, method.Access, method.Name, method.Descriptor, nil)
+	for i, insn := range method.Instructions {
+		if insn.GetOpcode() < 0 {
+			insn.Accept(adapter)
+			continue
+		}
+		frame := frames[i]
+		var stack []interface{}
+		for j := 0; j < frame.GetStackSize(); j++ {
+			stack = append(stack, basicFrameType(frame.GetStack(j)))
+			if frame.GetStack(j).GetSize() == 2 {
+				stack = append(stack, opcodes.TOP)
+			}
+		}
+		var adapterStack []interface{}
+		for _, value := range adapter.Stack {
+			adapterStack = append(adapterStack, adapterFrameType(value))
+		}
+		if fmt.Sprint(adapterStack) != fmt.Sprint(stack) {
+			t.Errorf("%s%s, insn %d: expected stack %v, got %v", method.Name, method.Descriptor, i, stack, adapter.Stack)
+		}
+		if len(adapter.Locals) > frame.GetLocals() {
+			t.Errorf("%s%s, insn %d: too many locals %v", method.Name, method.Descriptor, i, adapter.Locals)
+		}
+		for j, value := range adapter.Locals {
+			// A local which is still defined may be out of scope in the frames of the class, and thus be TOP.
+			if j < frame.GetLocals() && value != opcodes.TOP && adapterFrameType(value) != basicFrameType(frame.GetLocal(j)) {
+				t.Errorf("%s%s, insn %d: expected local %d to be %v, got %v", method.Name, method.Descriptor, i, j,
+					frame.GetLocal(j), value)
+			}
+		}
+		insn.Accept(adapter)
+	}
+	if len(adapter.Errors) > 0 {
+		t.Errorf("%s%s: unexpected errors %v", method.Name, method.Descriptor, adapter.Errors)
+	}
+}
+
+func TestAnalyzerAdapter(t *testing.T) {
+	exampleClass, err := os.ReadFile("../../ExampleClass.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	classFiles := [][]byte{
+		exampleClass,
+		loopMethod(t, asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC, "A", "java/lang/Object").Bytes()),
+	}
+	for _, classFile := range classFiles {
+		class, err := tree.ReadClassNode(classFile, asm.EXPAND_FRAMS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, method := range class.Methods {
+			checkAnalyzerAdapter(t, class.Name, method)
+		}
+	}
+}
+
+func TestAnalyzerAdapterUninitializedThis(t *testing.T) {
+	method := tree.NewMethodNode(0, "<init>", "(I)V", "", nil)
+	method.VisitCode()
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitMethodInsnB(opcodes.INVOKESPECIAL, "java/lang/Object", "<init>", "()V", false)
+	method.VisitInsn(opcodes.RETURN)
+	method.VisitMaxs(1, 2)
+	method.VisitEnd()
+
+	adapter := commons.NewAnalyzerAdapter("A", method.Access, method.Name, method.Descriptor, nil)
+	var stacks, locals [][]interface{}
+	for _, insn := range method.Instructions {
+		stacks = append(stacks, append([]interface{}{}, adapter.Stack...))
+		locals = append(locals, append([]interface{}{}, adapter.Locals...))
+		insn.Accept(adapter)
+	}
+	// The receiver is UNINITIALIZED_THIS until the super constructor call, and the class type after it.
+	expectedStacks := [][]interface{}{{}, {opcodes.UNINITIALIZED_THIS}, {}}
+	expectedLocals := [][]interface{}{
+		{opcodes.UNINITIALIZED_THIS, opcodes.INTEGER},
+		{opcodes.UNINITIALIZED_THIS, opcodes.INTEGER},
+		{"A", opcodes.INTEGER},
+	}
+	if fmt.Sprint(stacks) != fmt.Sprint(expectedStacks) || fmt.Sprint(locals) != fmt.Sprint(expectedLocals) {
+		t.Errorf("unexpected stacks %v and locals %v", stacks, locals)
+	}
+	if adapter.Stack != nil || adapter.Locals != nil {
+		t.Errorf("expected no frame after RETURN, got %v %v", adapter.Stack, adapter.Locals)
+	}
+}
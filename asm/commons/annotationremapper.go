@@ -0,0 +1,43 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// AnnotationRemapper an {@link AnnotationVisitor} that remaps types with a {@link Remapper}.
+type AnnotationRemapper struct {
+	helper.AnnotationAdapter
+	remapper Remapper
+}
+
+// NewAnnotationRemapper constructs a new {@link AnnotationRemapper} delegating to the given visitor.
+func NewAnnotationRemapper(annotationVisitor asm.AnnotationVisitor, remapper Remapper) *AnnotationRemapper {
+	return &AnnotationRemapper{
+		AnnotationAdapter: helper.AnnotationAdapter{Next: annotationVisitor},
+		remapper:          remapper,
+	}
+}
+
+func newAnnotationRemapperOrNil(annotationVisitor asm.AnnotationVisitor, remapper Remapper) asm.AnnotationVisitor {
+	if annotationVisitor == nil {
+		return nil
+	}
+	return NewAnnotationRemapper(annotationVisitor, remapper)
+}
+
+func (a *AnnotationRemapper) Visit(name string, value interface{}) {
+	a.AnnotationAdapter.Visit(name, MapValue(a.remapper, value))
+}
+
+func (a *AnnotationRemapper) VisitEnum(name, descriptor, value string) {
+	a.AnnotationAdapter.VisitEnum(name, MapDesc(a.remapper, descriptor), value)
+}
+
+func (a *AnnotationRemapper) VisitAnnotation(name, descriptor string) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(a.AnnotationAdapter.VisitAnnotation(name, MapDesc(a.remapper, descriptor)), a.remapper)
+}
+
+func (a *AnnotationRemapper) VisitArray(name string) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(a.AnnotationAdapter.VisitArray(name), a.remapper)
+}
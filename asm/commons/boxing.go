@@ -0,0 +1,60 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+var boxTypes = map[int][2]string{
+	typed.BOOLEAN: {"java/lang/Boolean", "booleanValue"},
+	typed.CHAR:    {"java/lang/Character", "charValue"},
+	typed.BYTE:    {"java/lang/Byte", "byteValue"},
+	typed.SHORT:   {"java/lang/Short", "shortValue"},
+	typed.INT:     {"java/lang/Integer", "intValue"},
+	typed.FLOAT:   {"java/lang/Float", "floatValue"},
+	typed.LONG:    {"java/lang/Long", "longValue"},
+	typed.DOUBLE:  {"java/lang/Double", "doubleValue"},
+}
+
+// box generates the instructions to box the top stack value, of the given type, into an object.
+func box(methodVisitor asm.MethodVisitor, t *asm.Type) {
+	boxType, ok := boxTypes[t.GetSort()]
+	if !ok {
+		return
+	}
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, boxType[0], "valueOf", "("+t.GetDescriptor()+")L"+boxType[0]+";", false)
+}
+
+// unbox generates the instructions to convert the top stack object into a value of the given type.
+func unbox(methodVisitor asm.MethodVisitor, t *asm.Type) {
+	switch t.GetSort() {
+	case typed.VOID:
+		methodVisitor.VisitInsn(opcodes.POP)
+	case typed.BOOLEAN, typed.CHAR:
+		boxType := boxTypes[t.GetSort()]
+		methodVisitor.VisitTypeInsn(opcodes.CHECKCAST, boxType[0])
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, boxType[0], boxType[1], "()"+t.GetDescriptor(), false)
+	case typed.BYTE, typed.SHORT, typed.INT, typed.FLOAT, typed.LONG, typed.DOUBLE:
+		methodVisitor.VisitTypeInsn(opcodes.CHECKCAST, "java/lang/Number")
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/lang/Number", boxTypes[t.GetSort()][1], "()"+t.GetDescriptor(), false)
+	default:
+		if t.GetInternalName() != "java/lang/Object" {
+			methodVisitor.VisitTypeInsn(opcodes.CHECKCAST, t.GetInternalName())
+		}
+	}
+}
+
+// pushInt generates the most compact instruction pushing the given int constant.
+func pushInt(methodVisitor asm.MethodVisitor, value int) {
+	switch {
+	case value >= -1 && value <= 5:
+		methodVisitor.VisitInsn(opcodes.ICONST_0 + value)
+	case value >= -128 && value <= 127:
+		methodVisitor.VisitIntInsn(opcodes.BIPUSH, value)
+	case value >= -32768 && value <= 32767:
+		methodVisitor.VisitIntInsn(opcodes.SIPUSH, value)
+	default:
+		methodVisitor.VisitLdcInsn(value)
+	}
+}
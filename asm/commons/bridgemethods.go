@@ -0,0 +1,124 @@
+package commons
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Bridge a bridge method to generate: a synthetic method with the given name and descriptor, that casts its
+// arguments and delegates to the method with the same name and the target descriptor. This is what javac
+// generates for covariant return types and for methods overriding generic methods with a different erasure.
+type Bridge struct {
+	Name             string
+	Descriptor       string
+	TargetDescriptor string
+}
+
+// GenerateBridgeMethod makes the given visitor visit the given bridge method of the given class. The bridge
+// and target descriptors must have the same number of arguments, and may only differ in their reference types.
+func GenerateBridgeMethod(classVisitor asm.ClassVisitor, owner string, isInterface bool, bridge Bridge) error {
+	bridgeType := asm.GetMethodType(bridge.Descriptor)
+	targetType := asm.GetMethodType(bridge.TargetDescriptor)
+	bridgeArgumentTypes := bridgeType.GetArgumentTypes()
+	targetArgumentTypes := targetType.GetArgumentTypes()
+	if len(bridgeArgumentTypes) != len(targetArgumentTypes) {
+		return errors.New("Illegal Argument - " + bridge.Descriptor + " can't bridge " + bridge.TargetDescriptor)
+	}
+	for i := range bridgeArgumentTypes {
+		if !isBridgeable(bridgeArgumentTypes[i], targetArgumentTypes[i]) {
+			return errors.New("Illegal Argument - " + bridge.Descriptor + " can't bridge " + bridge.TargetDescriptor)
+		}
+	}
+	if !isBridgeable(bridgeType.GetReturnType(), targetType.GetReturnType()) {
+		return errors.New("Illegal Argument - " + bridge.Descriptor + " can't bridge " + bridge.TargetDescriptor)
+	}
+
+	access := opcodes.ACC_PUBLIC | opcodes.ACC_SYNTHETIC | opcodes.ACC_BRIDGE
+	methodVisitor := classVisitor.VisitMethod(access, bridge.Name, bridge.Descriptor, "", nil)
+	if methodVisitor == nil {
+		return nil
+	}
+	methodVisitor.VisitCode()
+	methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+	local := 1
+	for i, argumentType := range bridgeArgumentTypes {
+		methodVisitor.VisitVarInsn(argumentType.GetOpcode(opcodes.ILOAD), local)
+		if argumentType.GetDescriptor() != targetArgumentTypes[i].GetDescriptor() {
+			methodVisitor.VisitTypeInsn(opcodes.CHECKCAST, targetArgumentTypes[i].GetInternalName())
+		}
+		local += argumentType.GetSize()
+	}
+	if isInterface {
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKEINTERFACE, owner, bridge.Name, bridge.TargetDescriptor, true)
+	} else {
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, owner, bridge.Name, bridge.TargetDescriptor, false)
+	}
+	returnType := bridgeType.GetReturnType()
+	methodVisitor.VisitInsn(returnType.GetOpcode(opcodes.IRETURN))
+	maxStack := local
+	if returnType.GetSize() > maxStack {
+		maxStack = returnType.GetSize()
+	}
+	methodVisitor.VisitMaxs(maxStack, local)
+	methodVisitor.VisitEnd()
+	return nil
+}
+
+func isBridgeable(bridgeType, targetType *asm.Type) bool {
+	if bridgeType.GetDescriptor() == targetType.GetDescriptor() {
+		return true
+	}
+	return isReference(bridgeType) && isReference(targetType)
+}
+
+func isReference(t *asm.Type) bool {
+	return t.GetSort() == typed.OBJECT || t.GetSort() == typed.ARRAY
+}
+
+// BridgeMethodAdder a {@link ClassVisitor} that adds the given bridge methods to the visited class, except
+// those already declared by the class. The bridges that can't be generated are reported with Errors.
+type BridgeMethodAdder struct {
+	helper.ClassAdapter
+	Errors          []error
+	bridges         []Bridge
+	className       string
+	isInterface     bool
+	declaredMethods map[string]bool
+}
+
+// NewBridgeMethodAdder constructs a new {@link BridgeMethodAdder}.
+func NewBridgeMethodAdder(classVisitor asm.ClassVisitor, bridges []Bridge) *BridgeMethodAdder {
+	return &BridgeMethodAdder{
+		ClassAdapter:    helper.ClassAdapter{Next: classVisitor},
+		bridges:         bridges,
+		declaredMethods: make(map[string]bool),
+	}
+}
+
+func (b *BridgeMethodAdder) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	b.className = name
+	b.isInterface = (access & opcodes.ACC_INTERFACE) != 0
+	b.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (b *BridgeMethodAdder) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	b.declaredMethods[name+descriptor] = true
+	return b.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+}
+
+func (b *BridgeMethodAdder) VisitEnd() {
+	for _, bridge := range b.bridges {
+		if b.declaredMethods[bridge.Name+bridge.Descriptor] {
+			continue
+		}
+		b.declaredMethods[bridge.Name+bridge.Descriptor] = true
+		if err := GenerateBridgeMethod(b.ClassAdapter, b.className, b.isInterface, bridge); err != nil {
+			b.Errors = append(b.Errors, err)
+		}
+	}
+	b.ClassAdapter.VisitEnd()
+}
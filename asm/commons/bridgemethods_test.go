@@ -0,0 +1,116 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// bridgeTrace returns the trace of the given bridge method, generated in the class p/C.
+func bridgeTrace(t *testing.T, bridge commons.Bridge) []string {
+	recorder := asmtest.NewRecorder(nil)
+	recorder.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "", "java/lang/Object", nil)
+	if err := commons.GenerateBridgeMethod(recorder, "p/C", false, bridge); err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Trace()[1:]
+}
+
+func TestGenerateBridgeMethod(t *testing.T) {
+	// The bridges are ACC_PUBLIC | ACC_SYNTHETIC | ACC_BRIDGE (4161) methods.
+	// The bridge of a covariant return type.
+	assertTrace(t, bridgeTrace(t, commons.Bridge{Name: "get", Descriptor: "()Ljava/lang/Object;",
+		TargetDescriptor: "()Ljava/lang/String;"}), []string{
+		`class visit method p/C 4161 "get" "()Ljava/lang/Object;" "" []`,
+		`method visit code p/C.get()Ljava/lang/Object;`,
+		`method visit var insn p/C.get()Ljava/lang/Object; 25 0`,
+		`method visit method insn p/C.get()Ljava/lang/Object; 182 "p/C" "get" "()Ljava/lang/String;" false`,
+		`method visit insn p/C.get()Ljava/lang/Object; 176`,
+		`method visit maxs p/C.get()Ljava/lang/Object; 1 1`,
+		`method visit end p/C.get()Ljava/lang/Object;`,
+	})
+	// The bridge of a method overriding a generic method, with a different erasure.
+	assertTrace(t, bridgeTrace(t, commons.Bridge{Name: "compareTo", Descriptor: "(Ljava/lang/Object;)I",
+		TargetDescriptor: "(Lp/C;)I"}), []string{
+		`class visit method p/C 4161 "compareTo" "(Ljava/lang/Object;)I" "" []`,
+		`method visit code p/C.compareTo(Ljava/lang/Object;)I`,
+		`method visit var insn p/C.compareTo(Ljava/lang/Object;)I 25 0`,
+		`method visit var insn p/C.compareTo(Ljava/lang/Object;)I 25 1`,
+		`method visit type insn p/C.compareTo(Ljava/lang/Object;)I 192 "p/C"`,
+		`method visit method insn p/C.compareTo(Ljava/lang/Object;)I 182 "p/C" "compareTo" "(Lp/C;)I" false`,
+		`method visit insn p/C.compareTo(Ljava/lang/Object;)I 172`,
+		`method visit maxs p/C.compareTo(Ljava/lang/Object;)I 2 2`,
+		`method visit end p/C.compareTo(Ljava/lang/Object;)I`,
+	})
+	// The arguments are cast to the generic and array types of the target, the primitive ones are unchanged.
+	assertTrace(t, bridgeTrace(t, commons.Bridge{Name: "apply",
+		Descriptor:       "(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object;",
+		TargetDescriptor: "(Ljava/util/List;JLjava/lang/Object;[Ljava/lang/String;)Ljava/lang/Integer;"}), []string{
+		`class visit method p/C 4161 "apply" "(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object;" "" []`,
+		`method visit code p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object;`,
+		`method visit var insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 25 0`,
+		`method visit var insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 25 1`,
+		`method visit type insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 192 "java/util/List"`,
+		`method visit var insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 22 2`,
+		`method visit var insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 25 4`,
+		`method visit var insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 25 5`,
+		`method visit type insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 192 "[Ljava/lang/String;"`,
+		`method visit method insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 182 "p/C" "apply" "(Ljava/util/List;JLjava/lang/Object;[Ljava/lang/String;)Ljava/lang/Integer;" false`,
+		`method visit insn p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 176`,
+		`method visit maxs p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object; 6 6`,
+		`method visit end p/C.apply(Ljava/lang/Object;JLjava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object;`,
+	})
+
+	for _, bridge := range []commons.Bridge{
+		{Name: "m", Descriptor: "(Ljava/lang/Object;)V", TargetDescriptor: "(I)V"},
+		{Name: "m", Descriptor: "()J", TargetDescriptor: "()I"},
+		{Name: "m", Descriptor: "(Ljava/lang/Object;)V", TargetDescriptor: "()V"},
+	} {
+		recorder := asmtest.NewRecorder(nil)
+		if err := commons.GenerateBridgeMethod(recorder, "p/C", false, bridge); err == nil || len(recorder.Trace()) != 0 {
+			t.Errorf("%v: expected an error and no method, got %v %v", bridge, err, recorder.Trace())
+		}
+	}
+}
+
+func TestBridgeMethodAdder(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_INTERFACE|opcodes.ACC_ABSTRACT,
+		"p/I", "java/lang/Object")
+	classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "get", "()Ljava/lang/String;", "", nil).VisitEnd()
+	classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "set", "(Ljava/lang/Object;)V", "", nil).VisitEnd()
+	reader, err := asm.NewClassReader(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	bridgeMethodAdder := commons.NewBridgeMethodAdder(recorder, []commons.Bridge{
+		{Name: "get", Descriptor: "()Ljava/lang/Object;", TargetDescriptor: "()Ljava/lang/String;"},
+		// Already declared by the interface.
+		{Name: "set", Descriptor: "(Ljava/lang/Object;)V", TargetDescriptor: "(Ljava/lang/String;)V"},
+		{Name: "get", Descriptor: "()I", TargetDescriptor: "()Ljava/lang/String;"},
+	})
+	reader.Accept(bridgeMethodAdder, 0)
+	// The target of a bridge in an interface is called with invokeinterface.
+	assertTrace(t, recorder.Trace(), []string{
+		`class visit p/I 52 1537 "p/I" "" "java/lang/Object" []`,
+		`class visit method p/I 1025 "get" "()Ljava/lang/String;" "" []`,
+		`method visit end p/I.get()Ljava/lang/String;`,
+		`class visit method p/I 1025 "set" "(Ljava/lang/Object;)V" "" []`,
+		`method visit end p/I.set(Ljava/lang/Object;)V`,
+		`class visit method p/I 4161 "get" "()Ljava/lang/Object;" "" []`,
+		`method visit code p/I.get()Ljava/lang/Object;`,
+		`method visit var insn p/I.get()Ljava/lang/Object; 25 0`,
+		`method visit method insn p/I.get()Ljava/lang/Object; 185 "p/I" "get" "()Ljava/lang/String;" true`,
+		`method visit insn p/I.get()Ljava/lang/Object; 176`,
+		`method visit maxs p/I.get()Ljava/lang/Object; 1 1`,
+		`method visit end p/I.get()Ljava/lang/Object;`,
+		`class visit end p/I`,
+	})
+	if len(bridgeMethodAdder.Errors) != 1 || bridgeMethodAdder.Errors[0].Error() !=
+		"Illegal Argument - ()I can't bridge ()Ljava/lang/String;" {
+		t.Errorf("unexpected errors %v", bridgeMethodAdder.Errors)
+	}
+}
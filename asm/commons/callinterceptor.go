@@ -0,0 +1,230 @@
+package commons
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// GENERIC_INTERCEPTOR_DESCRIPTOR the descriptor of generic interceptor methods. They receive the receiver
+// of the intercepted call (null for static calls), the "owner.name descriptor" key of the called method
+// and its boxed arguments, and return the boxed result of the call (ignored for void methods).
+const GENERIC_INTERCEPTOR_DESCRIPTOR = "(Ljava/lang/Object;Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/Object;"
+
+// CallInterception the call sites to intercept, and the static interceptor method to call instead. An empty
+// Descriptor matches all the methods with the given owner and name. If Generic is false, the interceptor
+// must have the descriptor of the intercepted method, with the receiver type prepended to the arguments for
+// instance methods. Otherwise it must have the {@link GENERIC_INTERCEPTOR_DESCRIPTOR}.
+type CallInterception struct {
+	Owner            string
+	Name             string
+	Descriptor       string
+	InterceptorOwner string
+	InterceptorName  string
+	Generic          bool
+}
+
+// CallInterceptor a {@link ClassVisitor} that replaces the INVOKEVIRTUAL, INVOKEINTERFACE and INVOKESTATIC
+// call sites matching the given interceptions with calls to their interceptor. Generic interceptions go
+// through a private static synthetic method added to the visited class, which boxes the receiver and
+// arguments and unboxes the result, so that the call sites themselves don't need any stack shuffling. These
+// adapter methods are named intercept$N, with the smallest N such that no method of the class has this name.
+// Interfaces can only declare private methods since Java 9, and static methods since Java 8: the adapters
+// of Java 8 interfaces are public, and the generic call sites of older interfaces are not intercepted, but
+// reported with Errors.
+type CallInterceptor struct {
+	helper.ClassAdapter
+	Errors        []error
+	interceptions []CallInterception
+	version       int
+	className     string
+	isInterface   bool
+	methodNames   map[string]bool
+	adapters      map[string]*interceptorAdapter
+	adapterList   []*interceptorAdapter
+}
+
+type interceptorAdapter struct {
+	name             string
+	descriptor       string
+	hasReceiver      bool
+	key              string
+	interceptorOwner string
+	interceptorName  string
+}
+
+// NewCallInterceptor constructs a new {@link CallInterceptor}. The adapter names are chosen when the first
+// call sites using them are visited, i.e. possibly before all the methods of the class are visited. The given
+// index of the visited class (see {@link asm.ClassReader#Index}) gives the names of all its methods, to avoid
+// them. If it is nil, only the names of the methods visited before the call sites are avoided.
+func NewCallInterceptor(classVisitor asm.ClassVisitor, interceptions []CallInterception, classIndex *asm.ClassIndex) *CallInterceptor {
+	methodNames := make(map[string]bool)
+	if classIndex != nil {
+		for _, method := range classIndex.Methods {
+			methodNames[method.Name] = true
+		}
+	}
+	return &CallInterceptor{
+		ClassAdapter:  helper.ClassAdapter{Next: classVisitor},
+		interceptions: interceptions,
+		methodNames:   methodNames,
+		adapters:      make(map[string]*interceptorAdapter),
+	}
+}
+
+func (c *CallInterceptor) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.version = version
+	c.className = name
+	c.isInterface = (access & opcodes.ACC_INTERFACE) != 0
+	c.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (c *CallInterceptor) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	c.methodNames[name] = true
+	methodVisitor := c.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil {
+		return nil
+	}
+	for _, interception := range c.interceptions {
+		if interception.InterceptorOwner == c.className && interception.InterceptorName == name {
+			return methodVisitor
+		}
+	}
+	return &callSiteRewriter{MethodAdapter: helper.MethodAdapter{Next: methodVisitor}, interceptor: c}
+}
+
+func (c *CallInterceptor) VisitEnd() {
+	for _, adapter := range c.adapterList {
+		c.generateAdapter(adapter)
+	}
+	c.ClassAdapter.VisitEnd()
+}
+
+func (c *CallInterceptor) match(opcode int, owner, name, descriptor string) *CallInterception {
+	if opcode != opcodes.INVOKEVIRTUAL && opcode != opcodes.INVOKEINTERFACE && opcode != opcodes.INVOKESTATIC {
+		return nil
+	}
+	for i := range c.interceptions {
+		interception := &c.interceptions[i]
+		if interception.Owner == owner && interception.Name == name && (interception.Descriptor == "" || interception.Descriptor == descriptor) {
+			return interception
+		}
+	}
+	return nil
+}
+
+// getAdapter returns the adapter method calling the given generic interception for the given call site, or nil
+// if the visited class can't declare it.
+func (c *CallInterceptor) getAdapter(interception *CallInterception, opcode int, owner, name, descriptor string) *interceptorAdapter {
+	hasReceiver := opcode != opcodes.INVOKESTATIC
+	key := owner + "." + name + descriptor
+	adapterKey := strconv.Itoa(opcode) + key + "->" + interception.InterceptorOwner + "." + interception.InterceptorName
+	if adapter, ok := c.adapters[adapterKey]; ok {
+		return adapter
+	}
+	if c.isInterface && (c.version&0xFFFF) < opcodes.V1_8 {
+		c.Errors = append(c.Errors, errors.New("Illegal Argument - can't intercept "+key+" in "+c.className+
+			", interfaces before Java 8 can't declare static methods"))
+		return nil
+	}
+	adapterName := "intercept$" + strconv.Itoa(len(c.adapterList))
+	for i := len(c.adapterList) + 1; c.methodNames[adapterName]; i++ {
+		adapterName = "intercept$" + strconv.Itoa(i)
+	}
+	c.methodNames[adapterName] = true
+	adapterDescriptor := descriptor
+	if hasReceiver {
+		adapterDescriptor = "(" + receiverDescriptor(owner) + descriptor[1:]
+	}
+	adapter := &interceptorAdapter{
+		name:             adapterName,
+		descriptor:       adapterDescriptor,
+		hasReceiver:      hasReceiver,
+		key:              key,
+		interceptorOwner: interception.InterceptorOwner,
+		interceptorName:  interception.InterceptorName,
+	}
+	c.adapters[adapterKey] = adapter
+	c.adapterList = append(c.adapterList, adapter)
+	return adapter
+}
+
+func (c *CallInterceptor) generateAdapter(adapter *interceptorAdapter) {
+	access := opcodes.ACC_PRIVATE | opcodes.ACC_STATIC | opcodes.ACC_SYNTHETIC
+	if c.isInterface && (c.version&0xFFFF) < opcodes.V9 {
+		access = opcodes.ACC_PUBLIC | opcodes.ACC_STATIC | opcodes.ACC_SYNTHETIC
+	}
+	methodVisitor := c.ClassAdapter.VisitMethod(access, adapter.name, adapter.descriptor, "", nil)
+	if methodVisitor == nil {
+		return
+	}
+	methodType := asm.GetMethodType(adapter.descriptor)
+	argumentTypes := methodType.GetArgumentTypes()
+	methodVisitor.VisitCode()
+	local := 0
+	if adapter.hasReceiver {
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+		local = 1
+		argumentTypes = argumentTypes[1:]
+	} else {
+		methodVisitor.VisitInsn(opcodes.ACONST_NULL)
+	}
+	methodVisitor.VisitLdcInsn(adapter.key)
+	pushInt(methodVisitor, len(argumentTypes))
+	methodVisitor.VisitTypeInsn(opcodes.ANEWARRAY, "java/lang/Object")
+	for i, argumentType := range argumentTypes {
+		methodVisitor.VisitInsn(opcodes.DUP)
+		pushInt(methodVisitor, i)
+		methodVisitor.VisitVarInsn(argumentType.GetOpcode(opcodes.ILOAD), local)
+		box(methodVisitor, argumentType)
+		methodVisitor.VisitInsn(opcodes.AASTORE)
+		local += argumentType.GetSize()
+	}
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, adapter.interceptorOwner, adapter.interceptorName, GENERIC_INTERCEPTOR_DESCRIPTOR, false)
+	returnType := methodType.GetReturnType()
+	unbox(methodVisitor, returnType)
+	methodVisitor.VisitInsn(returnType.GetOpcode(opcodes.IRETURN))
+	methodVisitor.VisitMaxs(7, local)
+	methodVisitor.VisitEnd()
+}
+
+func receiverDescriptor(owner string) string {
+	if owner[0] == '[' {
+		return owner
+	}
+	return "L" + owner + ";"
+}
+
+type callSiteRewriter struct {
+	helper.MethodAdapter
+	interceptor *CallInterceptor
+}
+
+func (c *callSiteRewriter) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	c.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
+
+func (c *callSiteRewriter) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	interception := c.interceptor.match(opcode, owner, name, descriptor)
+	if interception == nil {
+		c.MethodAdapter.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+		return
+	}
+	if interception.Generic {
+		adapter := c.interceptor.getAdapter(interception, opcode, owner, name, descriptor)
+		if adapter == nil {
+			c.MethodAdapter.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+			return
+		}
+		c.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, c.interceptor.className, adapter.name, adapter.descriptor, c.interceptor.isInterface)
+		return
+	}
+	interceptorDescriptor := descriptor
+	if opcode != opcodes.INVOKESTATIC {
+		interceptorDescriptor = "(" + receiverDescriptor(owner) + descriptor[1:]
+	}
+	c.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, interception.InterceptorOwner, interception.InterceptorName, interceptorDescriptor, false)
+}
@@ -0,0 +1,148 @@
+package commons_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// interceptedClass returns a class p/C with the method "int run(List l)", which calls l.get(0), l.size() and
+// Math.abs.
+func interceptedClass() []byte {
+	return newInterceptedClass(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, nil)
+}
+
+// newInterceptedClass returns the class of {@link interceptedClass} with the given version and access flags,
+// followed by the methods with the given names and the descriptor "()V".
+func newInterceptedClass(version, access int, methodNames []string) []byte {
+	classFile := asmtest.NewClassFile(version, access, "p/C", "java/lang/Object")
+	run := classFile.AddMethod(opcodes.ACC_PUBLIC, "run", "(Ljava/util/List;)I", "", nil)
+	run.VisitCode()
+	run.VisitVarInsn(opcodes.ALOAD, 1)
+	run.VisitInsn(opcodes.ICONST_0)
+	run.VisitMethodInsnB(opcodes.INVOKEINTERFACE, "java/util/List", "get", "(I)Ljava/lang/Object;", true)
+	run.VisitInsn(opcodes.POP)
+	run.VisitVarInsn(opcodes.ALOAD, 1)
+	run.VisitMethodInsnB(opcodes.INVOKEINTERFACE, "java/util/List", "size", "()I", true)
+	run.VisitMethodInsnB(opcodes.INVOKESTATIC, "java/lang/Math", "abs", "(I)I", false)
+	run.VisitInsn(opcodes.IRETURN)
+	run.VisitMaxs(2, 2)
+	run.VisitEnd()
+	for _, name := range methodNames {
+		methodVisitor := classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, name, "()V", "", nil)
+		methodVisitor.VisitEnd()
+	}
+	return classFile.Bytes()
+}
+
+// genericInterceptions the interceptions of {@link TestCallInterceptor}.
+var genericInterceptions = []commons.CallInterception{
+	{Owner: "java/util/List", Name: "get", InterceptorOwner: "p/Hooks", InterceptorName: "generic", Generic: true},
+	{Owner: "java/util/List", Name: "size", Descriptor: "()I", InterceptorOwner: "p/Hooks", InterceptorName: "size"},
+}
+
+func TestCallInterceptor(t *testing.T) {
+	intercept := func(next asm.ClassVisitor) asm.ClassVisitor {
+		return commons.NewCallInterceptor(next, genericInterceptions, nil)
+	}
+	// The calls to get and size are replaced, the one to Math.abs is not intercepted.
+	assertTrace(t, transformedTrace(t, interceptedClass(), "run(Ljava/util/List;)I", intercept), []string{
+		`class visit method p/C 1 "run" "(Ljava/util/List;)I" "" []`,
+		`method visit code p/C.run(Ljava/util/List;)I`,
+		`method visit var insn p/C.run(Ljava/util/List;)I 25 1`,
+		`method visit insn p/C.run(Ljava/util/List;)I 3`,
+		`method visit method insn p/C.run(Ljava/util/List;)I 184 "p/C" "intercept$0" "(Ljava/util/List;I)Ljava/lang/Object;" false`,
+		`method visit insn p/C.run(Ljava/util/List;)I 87`,
+		`method visit var insn p/C.run(Ljava/util/List;)I 25 1`,
+		`method visit method insn p/C.run(Ljava/util/List;)I 184 "p/Hooks" "size" "(Ljava/util/List;)I" false`,
+		`method visit method insn p/C.run(Ljava/util/List;)I 184 "java/lang/Math" "abs" "(I)I" false`,
+		`method visit insn p/C.run(Ljava/util/List;)I 172`,
+		`method visit maxs p/C.run(Ljava/util/List;)I 2 2`,
+		`method visit end p/C.run(Ljava/util/List;)I`,
+	})
+	// The generic interceptor is called by a synthetic method boxing the receiver and arguments.
+	assertTrace(t, transformedTrace(t, interceptedClass(), "intercept$0(Ljava/util/List;I)Ljava/lang/Object;", intercept), []string{
+		`class visit method p/C 4106 "intercept$0" "(Ljava/util/List;I)Ljava/lang/Object;" "" []`,
+		`method visit code p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object;`,
+		`method visit var insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 25 0`,
+		`method visit ldc insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; "java/util/List.get(I)Ljava/lang/Object;"`,
+		`method visit insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 4`,
+		`method visit type insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 189 "java/lang/Object"`,
+		`method visit insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 89`,
+		`method visit insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 3`,
+		`method visit var insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 21 1`,
+		`method visit method insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 184 "java/lang/Integer" "valueOf" "(I)Ljava/lang/Integer;" false`,
+		`method visit insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 83`,
+		`method visit method insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 184 "p/Hooks" "generic" "(Ljava/lang/Object;Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/Object;" false`,
+		`method visit insn p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 176`,
+		`method visit maxs p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object; 7 2`,
+		`method visit end p/C.intercept$0(Ljava/util/List;I)Ljava/lang/Object;`,
+	})
+}
+
+// adapterEvents returns the generic call site and the adapter methods of the given class, transformed by a
+// {@link CallInterceptor} with the {@link genericInterceptions}, and the errors of the interceptor.
+func adapterEvents(t *testing.T, classFile []byte) ([]string, []error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	callInterceptor := commons.NewCallInterceptor(recorder, genericInterceptions, reader.Index())
+	reader.Accept(callInterceptor, 0)
+	var events []string
+	for _, line := range recorder.Trace() {
+		if (strings.HasPrefix(line, "class visit method ") || strings.HasPrefix(line, "method visit method insn p/C.run")) &&
+			(strings.Contains(line, "intercept$") || strings.Contains(line, `"get"`)) {
+			events = append(events, line)
+		}
+	}
+	return events, callInterceptor.Errors
+}
+
+func TestCallInterceptorAdapterNames(t *testing.T) {
+	// The adapter names of the class are avoided, even if they are declared after the intercepted call sites.
+	events, _ := adapterEvents(t, newInterceptedClass(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER,
+		[]string{"intercept$0", "intercept$2"}))
+	assertTrace(t, events, []string{
+		`method visit method insn p/C.run(Ljava/util/List;)I 184 "p/C" "intercept$1" "(Ljava/util/List;I)Ljava/lang/Object;" false`,
+		`class visit method p/C 1025 "intercept$0" "()V" "" []`,
+		`class visit method p/C 1025 "intercept$2" "()V" "" []`,
+		`class visit method p/C 4106 "intercept$1" "(Ljava/util/List;I)Ljava/lang/Object;" "" []`,
+	})
+}
+
+func TestCallInterceptorInterfaces(t *testing.T) {
+	interfaceAccess := opcodes.ACC_PUBLIC | opcodes.ACC_INTERFACE | opcodes.ACC_ABSTRACT
+	// The adapters can be private in Java 9 interfaces.
+	events, errs := adapterEvents(t, newInterceptedClass(opcodes.V9, interfaceAccess, nil))
+	assertTrace(t, events, []string{
+		`method visit method insn p/C.run(Ljava/util/List;)I 184 "p/C" "intercept$0" "(Ljava/util/List;I)Ljava/lang/Object;" true`,
+		`class visit method p/C 4106 "intercept$0" "(Ljava/util/List;I)Ljava/lang/Object;" "" []`,
+	})
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	// They must be public in Java 8 interfaces.
+	events, errs = adapterEvents(t, newInterceptedClass(opcodes.V1_8, interfaceAccess, nil))
+	assertTrace(t, events, []string{
+		`method visit method insn p/C.run(Ljava/util/List;)I 184 "p/C" "intercept$0" "(Ljava/util/List;I)Ljava/lang/Object;" true`,
+		`class visit method p/C 4105 "intercept$0" "(Ljava/util/List;I)Ljava/lang/Object;" "" []`,
+	})
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	// And older interfaces can't declare them.
+	events, errs = adapterEvents(t, newInterceptedClass(opcodes.V1_7, interfaceAccess, nil))
+	assertTrace(t, events, []string{
+		`method visit method insn p/C.run(Ljava/util/List;)I 185 "java/util/List" "get" "(I)Ljava/lang/Object;" true`,
+	})
+	if len(errs) != 1 || errs[0].Error() != "Illegal Argument - can't intercept java/util/List.get(I)Ljava/lang/Object; "+
+		"in p/C, interfaces before Java 8 can't declare static methods" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
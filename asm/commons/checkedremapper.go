@@ -0,0 +1,55 @@
+package commons
+
+import "github.com/leaklessgfy/asm/asm"
+
+// CheckedRemapper a {@link Remapper} that checks the names returned by another remapper, so that a faulty
+// mapping fails fast instead of producing classes rejected by the JVM. Invalid names are replaced with the
+// original ones, and the first error is recorded in Err, which must be checked after the remapping.
+type CheckedRemapper struct {
+	remapper Remapper
+	// Err the error of the first invalid name returned by the remapper, if any.
+	Err error
+}
+
+// NewCheckedRemapper constructs a new {@link CheckedRemapper} checking the names of the given remapper.
+func NewCheckedRemapper(remapper Remapper) *CheckedRemapper {
+	return &CheckedRemapper{remapper: remapper}
+}
+
+// check returns the given new name if it is unchanged or valid, or the original name otherwise.
+func (c *CheckedRemapper) check(name, newName string, check func(string) error) string {
+	if newName == name {
+		return newName
+	}
+	if err := check(newName); err != nil {
+		if c.Err == nil {
+			c.Err = err
+		}
+		return name
+	}
+	return newName
+}
+
+func (c *CheckedRemapper) Map(internalName string) string {
+	return c.check(internalName, c.remapper.Map(internalName), asm.CheckDefinableClassName)
+}
+
+func (c *CheckedRemapper) MapMethodName(owner, name, descriptor string) string {
+	return c.check(name, c.remapper.MapMethodName(owner, name, descriptor), asm.CheckMethodName)
+}
+
+func (c *CheckedRemapper) MapInvokeDynamicMethodName(name, descriptor string) string {
+	return c.check(name, c.remapper.MapInvokeDynamicMethodName(name, descriptor), asm.CheckMethodName)
+}
+
+func (c *CheckedRemapper) MapFieldName(owner, name, descriptor string) string {
+	return c.check(name, c.remapper.MapFieldName(owner, name, descriptor), asm.CheckUnqualifiedName)
+}
+
+func (c *CheckedRemapper) MapPackageName(name string) string {
+	return c.check(name, c.remapper.MapPackageName(name), asm.CheckInternalName)
+}
+
+func (c *CheckedRemapper) MapModuleName(name string) string {
+	return c.check(name, c.remapper.MapModuleName(name), asm.CheckModuleName)
+}
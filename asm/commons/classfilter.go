@@ -0,0 +1,173 @@
+package commons
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// ClassFilter selects the classes of interest in a jar or classpath, so that the irrelevant classes of large
+// classpaths are not fully parsed. The checks are done from the cheapest to the most expensive one: the class
+// name, then the constant pool strings (without parsing the rest of the class), then the class annotations. A
+// filter is safe for concurrent use, but its fields must not be modified after its first use.
+type ClassFilter struct {
+	// Include the globs of the names of the included classes, or nil to include all the classes. The names
+	// are internal names, or binary names with dots. In a glob, '*' matches any sequence of characters except
+	// '/', and '**' matches any sequence of characters: "com/foo/*" matches the classes of the com/foo package,
+	// "com/foo/**" also matches those of its sub packages.
+	Include []string
+	// Exclude the globs of the names of the excluded classes.
+	Exclude []string
+	// IncludePatterns the regular expressions of the internal names of the included classes, in addition to
+	// those matching Include. If both Include and IncludePatterns are nil, all the classes are included.
+	IncludePatterns []*regexp.Regexp
+	// ExcludePatterns the regular expressions of the internal names of the excluded classes.
+	ExcludePatterns []*regexp.Regexp
+	// Mentions if not nil, only the classes whose constant pool contains one of these strings (e.g. the
+	// internal name or descriptor of a type, or a method name) are accepted.
+	Mentions []string
+	// Annotations if not nil, only the classes annotated with one of these annotations (given by their
+	// descriptors) are accepted.
+	Annotations []string
+
+	once    sync.Once
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// globToRegexp returns the regular expression source matching the given glob.
+func globToRegexp(glob string) string {
+	glob = strings.ReplaceAll(glob, ".", "/")
+	var s strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			s.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			s.WriteString("[^/]*")
+		default:
+			s.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return s.String()
+}
+
+// compileNames returns a regular expression matching the given globs or patterns, or nil if there are none.
+func compileNames(globs []string, patterns []*regexp.Regexp) *regexp.Regexp {
+	var alternatives []string
+	for _, glob := range globs {
+		alternatives = append(alternatives, "^(?:"+globToRegexp(glob)+")$")
+	}
+	for _, pattern := range patterns {
+		alternatives = append(alternatives, "(?:"+pattern.String()+")")
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(strings.Join(alternatives, "|"))
+}
+
+// MatchesName returns whether the class with the given internal name passes the name filters.
+func (c *ClassFilter) MatchesName(className string) bool {
+	c.once.Do(func() {
+		c.include = compileNames(c.Include, c.IncludePatterns)
+		c.exclude = compileNames(c.Exclude, c.ExcludePatterns)
+	})
+	return (c.include == nil || c.include.MatchString(className)) && (c.exclude == nil || !c.exclude.MatchString(className))
+}
+
+// MatchesEntry returns whether the jar entry with the given name may contain an accepted class, based on its
+// name: the entries which are not class files, and those whose class name does not pass the name filters, are
+// rejected. The versioned (META-INF/versions/n/) and packaged (BOOT-INF/classes/, WEB-INF/classes/) entry
+// prefixes are ignored.
+func (c *ClassFilter) MatchesEntry(entryName string) bool {
+	if !strings.HasSuffix(entryName, ".class") {
+		return false
+	}
+	className := strings.TrimSuffix(entryName, ".class")
+	if strings.HasPrefix(className, "META-INF/versions/") {
+		if i := strings.IndexByte(className[len("META-INF/versions/"):], '/'); i >= 0 {
+			className = className[len("META-INF/versions/")+i+1:]
+		}
+	}
+	className = strings.TrimPrefix(strings.TrimPrefix(className, "BOOT-INF/classes/"), "WEB-INF/classes/")
+	return c.MatchesName(className)
+}
+
+// Accept returns whether the given class passes all the filters. The class is only parsed if the Annotations
+// filter is used, and if its constant pool contains one of the annotation descriptors.
+func (c *ClassFilter) Accept(classFile []byte) (bool, error) {
+	constantPool, err := raw.ReadConstantPool(classFile, 0)
+	if err != nil {
+		return false, err
+	}
+	classIndex, err := raw.ReadU2(classFile, constantPool.Header+2)
+	if err != nil {
+		return false, err
+	}
+	if tag, err := constantPool.GetTag(classFile, classIndex); err != nil || tag != symbol.CONSTANT_CLASS_TAG {
+		return false, raw.NewParseError(raw.ErrMalformedConstantPool, constantPool.Header+2, "invalid this_class constant pool index")
+	}
+	nameIndex, err := raw.ReadU2(classFile, constantPool.Offsets[classIndex])
+	if err != nil {
+		return false, err
+	}
+	className, err := constantPool.GetUTF8(classFile, nameIndex)
+	if err != nil {
+		return false, err
+	}
+	if !c.MatchesName(className) {
+		return false, nil
+	}
+	if c.Mentions != nil && !mentionsAny(classFile, constantPool, c.Mentions) {
+		return false, nil
+	}
+	if c.Annotations == nil {
+		return true, nil
+	}
+	if !mentionsAny(classFile, constantPool, c.Annotations) {
+		return false, nil
+	}
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return false, err
+	}
+	annotated := false
+	reader.Accept(helper.NewMiddlewareClassAdapter(nil, helper.Middleware{
+		Before: func(event *helper.Event) bool {
+			if event.Kind == helper.CLASS_VISIT_ANNOTATION && containsAny([]string{event.Args[0].(string)}, c.Annotations) {
+				annotated = true
+			}
+			return true
+		},
+	}), asm.SKIP_CODE|asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+	return annotated, nil
+}
+
+// mentionsAny returns whether the given constant pool contains a CONSTANT_Utf8 entry equal to one of the given
+// strings. The entries are compared without being decoded, so the strings must not contain NUL or
+// supplementary characters, whose modified UTF-8 encoding differs from their UTF-8 encoding.
+func mentionsAny(classFile []byte, constantPool *raw.ConstantPool, values []string) bool {
+	for i := 1; i < len(constantPool.Offsets); i++ {
+		offset := constantPool.Offsets[i]
+		if offset == 0 || classFile[offset-1] != symbol.CONSTANT_UTF8_TAG {
+			continue
+		}
+		length, err := raw.ReadU2(classFile, offset)
+		if err != nil || offset+2+length > len(classFile) {
+			continue
+		}
+		for _, value := range values {
+			if len(value) == length && value == string(classFile[offset+2:offset+2+length]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package commons_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/commons"
+)
+
+func TestClassFilterMatchesName(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		filter    *commons.ClassFilter
+		className string
+		expected  bool
+	}{
+		{"no filter", &commons.ClassFilter{}, "a/B", true},
+		// '*' doesn't match the package separators, '**' does.
+		{"star", &commons.ClassFilter{Include: []string{"com/foo/*"}}, "com/foo/Bar", true},
+		{"star sub package", &commons.ClassFilter{Include: []string{"com/foo/*"}}, "com/foo/bar/Baz", false},
+		{"double star", &commons.ClassFilter{Include: []string{"com/foo/**"}}, "com/foo/bar/Baz", true},
+		{"double star other package", &commons.ClassFilter{Include: []string{"com/foo/**"}}, "com/foobar/Baz", false},
+		{"binary name", &commons.ClassFilter{Include: []string{"com.foo.*"}}, "com/foo/Bar", true},
+		// The globs match whole names, and their other characters are literal.
+		{"whole name", &commons.ClassFilter{Include: []string{"Bar"}}, "com/foo/Bar", false},
+		{"inner class", &commons.ClassFilter{Include: []string{"com/foo/Bar$*"}}, "com/foo/Bar$1", true},
+		{"literal", &commons.ClassFilter{Include: []string{"com/foo/Bar$*"}}, "com/foo/Bar1", false},
+		{"several globs", &commons.ClassFilter{Include: []string{"a/*", "b/*"}}, "b/C", true},
+		// The exclusions win over the inclusions.
+		{"excluded", &commons.ClassFilter{Include: []string{"com/**"}, Exclude: []string{"**/*Test"}}, "com/foo/BarTest", false},
+		{"not excluded", &commons.ClassFilter{Include: []string{"com/**"}, Exclude: []string{"**/*Test"}}, "com/foo/Bar", true},
+		{"exclude only", &commons.ClassFilter{Exclude: []string{"**$*"}}, "com/foo/Bar$1", false},
+		// The patterns are not anchored, and are added to the globs.
+		{"include pattern", &commons.ClassFilter{IncludePatterns: []*regexp.Regexp{regexp.MustCompile(`Impl$`)}},
+			"com/foo/BarImpl", true},
+		{"include pattern mismatch", &commons.ClassFilter{IncludePatterns: []*regexp.Regexp{regexp.MustCompile(`Impl$`)}},
+			"com/foo/Bar", false},
+		{"include glob or pattern", &commons.ClassFilter{Include: []string{"a/*"},
+			IncludePatterns: []*regexp.Regexp{regexp.MustCompile(`Impl$`)}}, "b/CImpl", true},
+		{"exclude pattern", &commons.ClassFilter{ExcludePatterns: []*regexp.Regexp{regexp.MustCompile(`/internal/`)}},
+			"com/internal/Bar", false},
+	} {
+		if actual := test.filter.MatchesName(test.className); actual != test.expected {
+			t.Errorf("%s: MatchesName(%s) = %v", test.name, test.className, actual)
+		}
+	}
+}
+
+func TestClassFilterMatchesEntry(t *testing.T) {
+	filter := &commons.ClassFilter{Include: []string{"com/**"}}
+	for _, test := range []struct {
+		entryName string
+		expected  bool
+	}{
+		{"com/foo/Bar.class", true},
+		{"org/foo/Bar.class", false},
+		{"com/foo/bar.properties", false},
+		{"META-INF/versions/11/com/foo/Bar.class", true},
+		{"BOOT-INF/classes/com/foo/Bar.class", true},
+		{"WEB-INF/classes/org/foo/Bar.class", false},
+	} {
+		if actual := filter.MatchesEntry(test.entryName); actual != test.expected {
+			t.Errorf("MatchesEntry(%s) = %v", test.entryName, actual)
+		}
+	}
+}
+
+func TestClassFilterAccept(t *testing.T) {
+	// p/Component is annotated with @p/Component, and p/App mentions it in a method descriptor without being
+	// annotated.
+	component := entryPointClass("p/Component", "java/lang/Object", "Lp/Component;")
+	app := entryPointClass("p/App", "java/lang/Object", "",
+		entryPointMethod{0, "m", "(Lp/Component;)V", []string{"Lp/Other;"}})
+	for _, test := range []struct {
+		name      string
+		filter    *commons.ClassFilter
+		classFile []byte
+		expected  bool
+	}{
+		{"name", &commons.ClassFilter{Include: []string{"p/App"}}, app, true},
+		{"excluded name", &commons.ClassFilter{Exclude: []string{"p/App"}}, app, false},
+		{"mentions", &commons.ClassFilter{Mentions: []string{"(Lp/Component;)V"}}, app, true},
+		// The constant pool strings must be equal to a mentioned string, not just contain it.
+		{"mentions partial string", &commons.ClassFilter{Mentions: []string{"Lp/Component;"}}, app, false},
+		{"annotation", &commons.ClassFilter{Annotations: []string{"Lp/Component;"}}, component, true},
+		{"not annotated", &commons.ClassFilter{Annotations: []string{"Lp/Component;"}}, app, false},
+		// The descriptor is in the constant pool, but is not a class annotation.
+		{"method annotation", &commons.ClassFilter{Annotations: []string{"Lp/Other;"}}, app, false},
+		{"all filters", &commons.ClassFilter{Include: []string{"p/*"}, Mentions: []string{"java/lang/Object"},
+			Annotations: []string{"Lp/Component;"}}, component, true},
+	} {
+		actual, err := test.filter.Accept(test.classFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("%s: Accept = %v", test.name, actual)
+		}
+	}
+	if _, err := (&commons.ClassFilter{}).Accept([]byte{0xCA, 0xFE, 0xBA, 0xBE}); err == nil {
+		t.Error("expected an error")
+	}
+}
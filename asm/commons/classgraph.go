@@ -0,0 +1,282 @@
+package commons
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// The kinds of the nodes of a {@link ClassGraph}.
+const (
+	GRAPH_CLASS = iota
+	GRAPH_INTERFACE
+	GRAPH_METHOD
+	GRAPH_FIELD
+)
+
+// The kinds of the edges of a {@link ClassGraph}.
+const (
+	GRAPH_EXTENDS = iota
+	GRAPH_IMPLEMENTS
+	GRAPH_DECLARES
+	GRAPH_CALLS
+	GRAPH_READS
+	GRAPH_WRITES
+)
+
+// The labels of the node kinds, in the exported graphs.
+var graphNodeLabels = []string{"Class", "Interface", "Method", "Field"}
+
+// The types of the edge kinds, in the exported graphs.
+var graphEdgeTypes = []string{"EXTENDS", "IMPLEMENTS", "DECLARES", "CALLS", "READS", "WRITES"}
+
+// ClassGraphNode a class, an interface, a method or a field of a {@link ClassGraph}.
+type ClassGraphNode struct {
+	// ID the unique identifier of the node: the internal name of a class, "owner.name(descriptor)" for a method,
+	// and "owner.name:descriptor" for a field.
+	ID   string
+	Kind int
+	// Owner the internal name of the class declaring a method or a field, or "" for a class.
+	Owner      string
+	Name       string
+	Descriptor string
+	// External whether the node is only referenced by the classes of the graph, and not declared by one of them.
+	External bool
+}
+
+// ClassGraphEdge a relationship between two nodes of a {@link ClassGraph}, given by their ID.
+type ClassGraphEdge struct {
+	Kind int
+	From string
+	To   string
+}
+
+// ClassGraph the relationships between the classes of a program, and between their members: the inheritance
+// (extends and implements), the declarations of members, the method calls and the field accesses. The classes are
+// added with {@link AddClass} or {@link AddJar}, and the graph can then be exported for a graph database with
+// {@link WriteNeo4jCSV} or {@link WriteGraphML}. The call edges are based on the static type of the calls (no
+// virtual dispatch resolution), and include the methods referenced by the invokedynamic bootstrap arguments (e.g.
+// the lambda bodies and the method references).
+type ClassGraph struct {
+	nodes     map[string]*ClassGraphNode
+	nodeOrder []string
+	edges     map[ClassGraphEdge]bool
+	edgeOrder []ClassGraphEdge
+}
+
+// NewClassGraph constructs a new, empty {@link ClassGraph}.
+func NewClassGraph() *ClassGraph {
+	return &ClassGraph{nodes: make(map[string]*ClassGraphNode), edges: make(map[ClassGraphEdge]bool)}
+}
+
+// AddClass adds the given class file, its members and their relationships to the graph.
+func (c *ClassGraph) AddClass(classFile []byte) error {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return err
+	}
+	reader.Accept(&classGraphCollector{graph: c}, asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+	return nil
+}
+
+// AddJar adds the class files of the given jar (or zip) file to the graph.
+func (c *ClassGraph) AddJar(path string) error {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer jar.Close()
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || strings.HasSuffix(file.Name, "module-info.class") {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+		if err := c.AddClass(classFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetNodes returns the nodes of the graph, in the order in which they were first added or referenced.
+func (c *ClassGraph) GetNodes() []*ClassGraphNode {
+	nodes := make([]*ClassGraphNode, len(c.nodeOrder))
+	for i, id := range c.nodeOrder {
+		nodes[i] = c.nodes[id]
+	}
+	return nodes
+}
+
+// GetEdges returns the edges of the graph, without duplicates, in the order in which they were added.
+func (c *ClassGraph) GetEdges() []ClassGraphEdge {
+	return c.edgeOrder
+}
+
+// node returns the node with the given ID, creating it as an external node if necessary. A declared node replaces
+// an external one.
+func (c *ClassGraph) node(kind int, owner, name, descriptor string, external bool) string {
+	id := name
+	switch kind {
+	case GRAPH_METHOD:
+		id = owner + "." + name + descriptor
+	case GRAPH_FIELD:
+		id = owner + "." + name + ":" + descriptor
+	}
+	node, ok := c.nodes[id]
+	if !ok {
+		node = &ClassGraphNode{ID: id, Kind: kind, Owner: owner, Name: name, Descriptor: descriptor, External: external}
+		c.nodes[id] = node
+		c.nodeOrder = append(c.nodeOrder, id)
+	} else if node.External && !external {
+		node.Kind, node.External = kind, false
+	}
+	return id
+}
+
+func (c *ClassGraph) edge(kind int, from, to string) {
+	edge := ClassGraphEdge{kind, from, to}
+	if !c.edges[edge] {
+		c.edges[edge] = true
+		c.edgeOrder = append(c.edgeOrder, edge)
+	}
+}
+
+// WriteNeo4jCSV writes the nodes and the edges of the graph to the given writers, in the CSV format of the
+// neo4j-admin import command (the node IDs are in the "id:ID" column, and the node kinds are the node labels).
+func (c *ClassGraph) WriteNeo4jCSV(nodeWriter, edgeWriter io.Writer) error {
+	nodes := csv.NewWriter(nodeWriter)
+	nodes.Write([]string{"id:ID", "owner", "name", "descriptor", "external:boolean", ":LABEL"})
+	for _, node := range c.GetNodes() {
+		nodes.Write([]string{node.ID, node.Owner, node.Name, node.Descriptor, strconv.FormatBool(node.External), graphNodeLabels[node.Kind]})
+	}
+	nodes.Flush()
+	if err := nodes.Error(); err != nil {
+		return err
+	}
+	edges := csv.NewWriter(edgeWriter)
+	edges.Write([]string{":START_ID", ":END_ID", ":TYPE"})
+	for _, edge := range c.edgeOrder {
+		edges.Write([]string{edge.From, edge.To, graphEdgeTypes[edge.Kind]})
+	}
+	edges.Flush()
+	return edges.Error()
+}
+
+// WriteGraphML writes the graph to the given writer in the GraphML format, with the "kind", "owner", "name",
+// "descriptor" and "external" node attributes, and the "type" edge attribute.
+func (c *ClassGraph) WriteGraphML(writer io.Writer) error {
+	var graphML strings.Builder
+	graphML.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	graphML.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, key := range []string{"kind", "owner", "name", "descriptor"} {
+		graphML.WriteString(`  <key id="` + key + `" for="node" attr.name="` + key + `" attr.type="string"/>` + "\n")
+	}
+	graphML.WriteString(`  <key id="external" for="node" attr.name="external" attr.type="boolean"/>` + "\n")
+	graphML.WriteString(`  <key id="type" for="edge" attr.name="type" attr.type="string"/>` + "\n")
+	graphML.WriteString(`  <graph id="classes" edgedefault="directed">` + "\n")
+	for _, node := range c.GetNodes() {
+		graphML.WriteString(`    <node id="` + xmlEscape(node.ID) + `">`)
+		graphML.WriteString(`<data key="kind">` + graphNodeLabels[node.Kind] + `</data>`)
+		if node.Owner != "" {
+			graphML.WriteString(`<data key="owner">` + xmlEscape(node.Owner) + `</data>`)
+		}
+		graphML.WriteString(`<data key="name">` + xmlEscape(node.Name) + `</data>`)
+		if node.Descriptor != "" {
+			graphML.WriteString(`<data key="descriptor">` + xmlEscape(node.Descriptor) + `</data>`)
+		}
+		graphML.WriteString(`<data key="external">` + strconv.FormatBool(node.External) + "</data></node>\n")
+	}
+	for _, edge := range c.edgeOrder {
+		graphML.WriteString(`    <edge source="` + xmlEscape(edge.From) + `" target="` + xmlEscape(edge.To) + `">`)
+		graphML.WriteString(`<data key="type">` + graphEdgeTypes[edge.Kind] + "</data></edge>\n")
+	}
+	graphML.WriteString("  </graph>\n</graphml>\n")
+	_, err := io.WriteString(writer, graphML.String())
+	return err
+}
+
+// xmlEscape returns the given string with the XML special characters escaped.
+func xmlEscape(s string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
+
+// classGraphCollector a {@link ClassVisitor} which adds the nodes and edges of a class to a {@link ClassGraph}.
+type classGraphCollector struct {
+	helper.ClassVisitor
+	graph *ClassGraph
+	class string
+}
+
+func (c *classGraphCollector) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	kind := GRAPH_CLASS
+	if access&opcodes.ACC_INTERFACE != 0 {
+		kind = GRAPH_INTERFACE
+	}
+	c.class = c.graph.node(kind, "", name, "", false)
+	if superName != "" && kind == GRAPH_CLASS {
+		c.graph.edge(GRAPH_EXTENDS, c.class, c.graph.node(GRAPH_CLASS, "", superName, "", true))
+	}
+	for _, itf := range interfaces {
+		edgeKind := GRAPH_IMPLEMENTS
+		if kind == GRAPH_INTERFACE {
+			edgeKind = GRAPH_EXTENDS
+		}
+		c.graph.edge(edgeKind, c.class, c.graph.node(GRAPH_INTERFACE, "", itf, "", true))
+	}
+}
+
+func (c *classGraphCollector) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	c.graph.edge(GRAPH_DECLARES, c.class, c.graph.node(GRAPH_FIELD, c.class, name, descriptor, false))
+	return nil
+}
+
+func (c *classGraphCollector) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	method := c.graph.node(GRAPH_METHOD, c.class, name, descriptor, false)
+	c.graph.edge(GRAPH_DECLARES, c.class, method)
+	return &classGraphMethodCollector{graph: c.graph, method: method}
+}
+
+// classGraphMethodCollector a {@link MethodVisitor} which adds the calls and field accesses of a method to a
+// {@link ClassGraph}.
+type classGraphMethodCollector struct {
+	helper.MethodVisitor
+	graph  *ClassGraph
+	method string
+}
+
+func (c *classGraphMethodCollector) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	kind := GRAPH_READS
+	if opcode == opcodes.PUTFIELD || opcode == opcodes.PUTSTATIC {
+		kind = GRAPH_WRITES
+	}
+	c.graph.edge(kind, c.method, c.graph.node(GRAPH_FIELD, owner, name, descriptor, true))
+}
+
+func (c *classGraphMethodCollector) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	c.graph.edge(GRAPH_CALLS, c.method, c.graph.node(GRAPH_METHOD, owner, name, descriptor, true))
+}
+
+func (c *classGraphMethodCollector) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHandle *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	for _, argument := range bootstrapMethodArguments {
+		if handle, ok := argument.(*asm.Handle); ok && !handle.IsField() {
+			c.graph.edge(GRAPH_CALLS, c.method, c.graph.node(GRAPH_METHOD, handle.GetOwner(), handle.GetName(), handle.GetDesc(), true))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package commons_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// graphClasses returns an interface p/I extending p/J, a class p/A extending p/Base and implementing p/I, whose
+// method "void m()" accesses fields and calls methods, and a class p/B, referenced by p/A.
+func graphClasses() [][]byte {
+	itf := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_INTERFACE|opcodes.ACC_ABSTRACT, "p/I",
+		"java/lang/Object", "p/J")
+	itf.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "run", "()V", "", nil).VisitEnd()
+
+	a := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/A", "p/Base", "p/I")
+	a.AddField(opcodes.ACC_PRIVATE, "f", "I", "", nil).VisitEnd()
+	m := a.AddMethod(opcodes.ACC_PUBLIC, "m", "()V", "", nil)
+	m.VisitCode()
+	m.VisitVarInsn(opcodes.ALOAD, 0)
+	m.VisitFieldInsn(opcodes.GETFIELD, "p/A", "f", "I")
+	m.VisitFieldInsn(opcodes.PUTSTATIC, "p/B", "g", "I")
+	// The duplicate calls produce a single edge.
+	for i := 0; i < 2; i++ {
+		m.VisitFieldInsn(opcodes.GETSTATIC, "p/B", "b", "Lp/B;")
+		m.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "p/B", "run", "()V", false)
+	}
+	bootstrapMethod := asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/LambdaMetafactory", "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+			"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)"+
+			"Ljava/lang/invoke/CallSite;", false)
+	m.VisitInvokeDynamicInsn("run", "()Ljava/lang/Runnable;", bootstrapMethod, asm.GetMethodType("()V"),
+		asm.NewHandle(opcodes.H_INVOKESTATIC, "p/A", "lambda$m$0", "()V", false), asm.GetMethodType("()V"))
+	m.VisitInsn(opcodes.POP)
+	m.VisitInsn(opcodes.RETURN)
+	m.VisitMaxs(1, 1)
+	m.VisitEnd()
+	lambda := a.AddMethod(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC|opcodes.ACC_SYNTHETIC, "lambda$m$0", "()V", "", nil)
+	lambda.VisitCode()
+	lambda.VisitInsn(opcodes.RETURN)
+	lambda.VisitMaxs(0, 0)
+	lambda.VisitEnd()
+
+	b := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/B", "java/lang/Object")
+	b.AddField(opcodes.ACC_STATIC, "g", "I", "", nil).VisitEnd()
+	return [][]byte{itf.Bytes(), a.Bytes(), b.Bytes()}
+}
+
+func TestClassGraph(t *testing.T) {
+	graph := commons.NewClassGraph()
+	for _, classFile := range graphClasses() {
+		if err := graph.AddClass(classFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nodeKinds := []string{"class", "interface", "method", "field"}
+	var nodes []string
+	for _, node := range graph.GetNodes() {
+		nodes = append(nodes, fmt.Sprintf("%s %s external=%v", nodeKinds[node.Kind], node.ID, node.External))
+	}
+	// The members referenced before being declared, like p/A.lambda$m$0 and p/B.g, are not external.
+	assertTrace(t, nodes, []string{
+		`interface p/I external=false`,
+		`interface p/J external=true`,
+		`method p/I.run()V external=false`,
+		`class p/A external=false`,
+		`class p/Base external=true`,
+		`field p/A.f:I external=false`,
+		`method p/A.m()V external=false`,
+		`field p/B.g:I external=false`,
+		`field p/B.b:Lp/B; external=true`,
+		`method p/B.run()V external=true`,
+		`method p/A.lambda$m$0()V external=false`,
+		`class p/B external=false`,
+		`class java/lang/Object external=true`,
+	})
+
+	edgeKinds := []string{"extends", "implements", "declares", "calls", "reads", "writes"}
+	var edges []string
+	for _, edge := range graph.GetEdges() {
+		edges = append(edges, edge.From+" "+edgeKinds[edge.Kind]+" "+edge.To)
+	}
+	// The interfaces extend their super interfaces, and don't extend java/lang/Object.
+	assertTrace(t, edges, []string{
+		`p/I extends p/J`,
+		`p/I declares p/I.run()V`,
+		`p/A extends p/Base`,
+		`p/A implements p/I`,
+		`p/A declares p/A.f:I`,
+		`p/A declares p/A.m()V`,
+		`p/A.m()V reads p/A.f:I`,
+		`p/A.m()V writes p/B.g:I`,
+		`p/A.m()V reads p/B.b:Lp/B;`,
+		`p/A.m()V calls p/B.run()V`,
+		`p/A.m()V calls p/A.lambda$m$0()V`,
+		`p/A declares p/A.lambda$m$0()V`,
+		`p/B extends java/lang/Object`,
+		`p/B declares p/B.g:I`,
+	})
+}
@@ -0,0 +1,185 @@
+package commons
+
+import (
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// Kinds of classes (see {@link ClassKind}).
+const (
+	CLASS_KIND = iota
+	INTERFACE_KIND
+	ANNOTATION_KIND
+	ENUM_KIND
+	RECORD_KIND
+	MODULE_KIND
+	PACKAGE_INFO_KIND
+)
+
+// Nesting kinds of classes (see {@link ClassKind}).
+const (
+	// TOP_LEVEL a class which is not nested in another class.
+	TOP_LEVEL = iota
+	// MEMBER a class declared as a member of another class, static or not.
+	MEMBER
+	// LOCAL a named class declared in a method or an initializer.
+	LOCAL
+	// ANONYMOUS a class without name, declared in an expression.
+	ANONYMOUS
+)
+
+var classKindNames = []string{"class", "interface", "annotation", "enum", "record", "module", "package-info"}
+var nestingNames = []string{"top level", "member", "local", "anonymous"}
+
+// ClassKind the kind of a class, computed by a {@link ClassKindVisitor} from its access flags and its
+// InnerClasses, EnclosingMethod, Record and PermittedSubclasses attributes.
+type ClassKind struct {
+	// Name the internal name of the class.
+	Name string
+	// Kind {@link CLASS_KIND} to {@link PACKAGE_INFO_KIND}.
+	Kind int
+	// Nesting {@link TOP_LEVEL}, {@link MEMBER}, {@link LOCAL} or {@link ANONYMOUS}.
+	Nesting int
+	// Access the access flags of the class, including the source level flags of nested classes (private,
+	// protected, static) found in their InnerClasses entry.
+	Access int
+	// SimpleName the simple name of the class in the source code, or "" for anonymous classes.
+	SimpleName string
+	// OuterClass the internal name of the enclosing class of a nested class, or "".
+	OuterClass string
+	// EnclosingMethod the name and descriptor of the method enclosing a local or anonymous class, or "".
+	EnclosingMethod string
+	// IsSealed whether the class has a PermittedSubclasses attribute.
+	IsSealed bool
+	// NumPermittedSubclasses the number of classes listed in the PermittedSubclasses attribute.
+	NumPermittedSubclasses int
+}
+
+// IsNested returns whether the class is declared in another class.
+func (c *ClassKind) IsNested() bool {
+	return c.Nesting != TOP_LEVEL
+}
+
+// IsInner returns whether the class is a nested class which is not static, i.e. whose instances have an
+// enclosing instance (for local and anonymous classes, if they are declared in a non static context).
+func (c *ClassKind) IsInner() bool {
+	return c.Nesting != TOP_LEVEL && (c.Access&opcodes.ACC_STATIC) == 0 && c.Kind != INTERFACE_KIND &&
+		c.Kind != ANNOTATION_KIND && c.Kind != ENUM_KIND && c.Kind != RECORD_KIND
+}
+
+// IsNonSealed returns whether the class is neither sealed nor final, i.e. can be freely extended.
+func (c *ClassKind) IsNonSealed() bool {
+	return !c.IsSealed && (c.Access&opcodes.ACC_FINAL) == 0
+}
+
+func (c *ClassKind) String() string {
+	var modifiers []string
+	if c.IsSealed {
+		modifiers = append(modifiers, "sealed")
+	}
+	if c.IsInner() {
+		modifiers = append(modifiers, "inner")
+	} else if c.IsNested() && (c.Access&opcodes.ACC_STATIC) != 0 {
+		modifiers = append(modifiers, "static")
+	}
+	modifiers = append(modifiers, nestingNames[c.Nesting], classKindNames[c.Kind], c.Name)
+	return strings.Join(modifiers, " ")
+}
+
+// ClassKindVisitor a {@link ClassVisitor} which computes the {@link ClassKind} of the visited class, and
+// delegates all the calls to the next visitor, if any.
+type ClassKindVisitor struct {
+	helper.ClassAdapter
+	// Kind the kind of the visited class, complete after VisitEnd.
+	Kind      *ClassKind
+	superName string
+}
+
+// NewClassKindVisitor constructs a new {@link ClassKindVisitor}. The given class visitor may be nil.
+func NewClassKindVisitor(classVisitor asm.ClassVisitor) *ClassKindVisitor {
+	return &ClassKindVisitor{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		Kind:         &ClassKind{},
+	}
+}
+
+// GetClassKind returns the {@link ClassKind} of the given class file.
+func GetClassKind(classFile []byte) (*ClassKind, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	visitor := NewClassKindVisitor(nil)
+	reader.Accept(visitor, asm.SKIP_CODE|asm.SKIP_FRAMES)
+	return visitor.Kind, nil
+}
+
+func (c *ClassKindVisitor) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.Kind.Name = name
+	c.Kind.Access = access
+	c.superName = superName
+	switch {
+	case (access & opcodes.ACC_MODULE) != 0:
+		c.Kind.Kind = MODULE_KIND
+	case name == "package-info" || strings.HasSuffix(name, "/package-info"):
+		c.Kind.Kind = PACKAGE_INFO_KIND
+	case (access & opcodes.ACC_ANNOTATION) != 0:
+		c.Kind.Kind = ANNOTATION_KIND
+	case (access & opcodes.ACC_INTERFACE) != 0:
+		c.Kind.Kind = INTERFACE_KIND
+	case (access&opcodes.ACC_ENUM) != 0 && superName == "java/lang/Enum":
+		c.Kind.Kind = ENUM_KIND
+	}
+	c.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (c *ClassKindVisitor) VisitOuterClass(owner, name, descriptor string) {
+	c.Kind.OuterClass = owner
+	if name != "" {
+		c.Kind.EnclosingMethod = name + descriptor
+	}
+	c.ClassAdapter.VisitOuterClass(owner, name, descriptor)
+}
+
+func (c *ClassKindVisitor) VisitAttribute(attribute *asm.Attribute) {
+	switch attribute.GetType() {
+	case "Record":
+		if c.superName == "java/lang/Record" {
+			c.Kind.Kind = RECORD_KIND
+		}
+	case "PermittedSubclasses":
+		c.Kind.IsSealed = true
+		if content := attribute.GetContent(); len(content) >= 2 {
+			c.Kind.NumPermittedSubclasses = int(content[0])<<8 | int(content[1])
+		}
+	}
+	c.ClassAdapter.VisitAttribute(attribute)
+}
+
+func (c *ClassKindVisitor) VisitInnerClass(name, outerName, innerName string, access int) {
+	if name == c.Kind.Name {
+		// The InnerClasses entry of the class itself gives its nesting kind and its source level access flags.
+		switch {
+		case innerName == "":
+			c.Kind.Nesting = ANONYMOUS
+		case outerName == "":
+			c.Kind.Nesting = LOCAL
+		default:
+			c.Kind.Nesting = MEMBER
+			c.Kind.OuterClass = outerName
+		}
+		c.Kind.SimpleName = innerName
+		c.Kind.Access = access | (c.Kind.Access & (opcodes.ACC_SYNTHETIC | opcodes.ACC_DEPRECATED))
+	}
+	c.ClassAdapter.VisitInnerClass(name, outerName, innerName, access)
+}
+
+func (c *ClassKindVisitor) VisitEnd() {
+	if c.Kind.Nesting == TOP_LEVEL && c.Kind.Kind != MODULE_KIND {
+		c.Kind.SimpleName = c.Kind.Name[strings.LastIndex(c.Kind.Name, "/")+1:]
+	}
+	c.ClassAdapter.VisitEnd()
+}
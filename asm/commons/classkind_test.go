@@ -0,0 +1,67 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// javaRecordClass returns the version 60 class of "record R(int x) {}", compiled as javac does (without its methods).
+func javaRecordClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V16, opcodes.ACC_PUBLIC|opcodes.ACC_FINAL|opcodes.ACC_SUPER, "p/R",
+		"java/lang/Record")
+	classFile.AddField(opcodes.ACC_PRIVATE|opcodes.ACC_FINAL, "x", "I", "", nil)
+	name := classFile.SymbolTable.AddConstantUtf8("x")
+	descriptor := classFile.SymbolTable.AddConstantUtf8("I")
+	classFile.AddAttribute("Record", asm.NewByteVector().PutShort(1).PutShort(name).PutShort(descriptor).PutShort(0).Bytes())
+	return classFile.Bytes()
+}
+
+// sealedInterface returns the version 61 class of "sealed interface S permits A, B {}", compiled as javac does.
+func sealedInterface() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V17, opcodes.ACC_PUBLIC|opcodes.ACC_INTERFACE|opcodes.ACC_ABSTRACT,
+		"p/S", "java/lang/Object")
+	a := classFile.SymbolTable.AddConstantClass("p/A").GetIndex()
+	b := classFile.SymbolTable.AddConstantClass("p/B").GetIndex()
+	classFile.AddAttribute("PermittedSubclasses", asm.NewByteVector().PutShort(2).PutShort(a).PutShort(b).Bytes())
+	return classFile.Bytes()
+}
+
+func TestGetClassKind(t *testing.T) {
+	record, err := commons.GetClassKind(javaRecordClass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Kind != commons.RECORD_KIND || record.IsSealed || record.String() != "top level record p/R" {
+		t.Errorf("unexpected record kind %v", record)
+	}
+
+	sealed, err := commons.GetClassKind(sealedInterface())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.Kind != commons.INTERFACE_KIND || !sealed.IsSealed || sealed.NumPermittedSubclasses != 2 ||
+		sealed.IsNonSealed() || sealed.String() != "sealed top level interface p/S" {
+		t.Errorf("unexpected sealed kind %v", sealed)
+	}
+
+	// A Record attribute in a class which does not extend java.lang.Record does not make it a record.
+	classFile := asmtest.NewClassFile(opcodes.V16, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	classFile.AddAttribute("Record", asm.NewByteVector().PutShort(0).Bytes())
+	class, err := commons.GetClassKind(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class.Kind != commons.CLASS_KIND {
+		t.Errorf("unexpected class kind %v", class)
+	}
+
+	// Class file versions after Java 17 are rejected.
+	newer := asmtest.NewClassFile(opcodes.V17+1, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	if _, err := commons.GetClassKind(newer.Bytes()); err == nil {
+		t.Error("expected an error for a version 62 class")
+	}
+}
@@ -0,0 +1,190 @@
+package commons
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ClasspathClass the location of a class in a {@link Classpath}.
+type ClasspathClass struct {
+	// Name the internal name of the class, derived from its path.
+	Name string
+	// Entry the jar (or zip) file or the directory of the classpath containing the class.
+	Entry string
+	// Path the path of the class file in its entry, with '/' separators, and a "!/" separator after each nested
+	// jar (see {@link ScanJar}).
+	Path string
+}
+
+// ShadowedClass a class defined in several entries of a {@link Classpath}: the JVM loads the one of the first
+// entry, and ignores the others.
+type ShadowedClass struct {
+	Loaded   ClasspathClass
+	Shadowed []ClasspathClass
+}
+
+// Classpath an ordered list of jar (or zip) files and directories, which resolves the classes as the JVM does
+// with its class path: when several entries define a class, the first one wins and shadows the others. The class
+// names are derived from the paths of the class files. The jar entries are scanned with {@link ScanJar}, i.e.
+// including the classes of their nested jars, after their own classes. The module-info classes and the META-INF
+// directory, including the versioned classes of the multi-release jars, are ignored.
+type Classpath struct {
+	entries []string
+	classes map[string]ClasspathClass
+	// names the names of the classes, in the order of their first definition.
+	names    []string
+	shadowed map[string][]ClasspathClass
+}
+
+// NewClasspath constructs a new {@link Classpath} with the given entries, in order, and indexes their classes.
+// The class files are not read until they are needed.
+func NewClasspath(entries ...string) (*Classpath, error) {
+	c := &Classpath{entries: entries, classes: make(map[string]ClasspathClass), shadowed: make(map[string][]ClasspathClass)}
+	for _, entry := range entries {
+		err := walkClasspathEntry(entry, func(name, path string, open func() ([]byte, error)) error {
+			class := ClasspathClass{Name: name, Entry: entry, Path: path}
+			if _, ok := c.classes[class.Name]; ok {
+				c.shadowed[class.Name] = append(c.shadowed[class.Name], class)
+				return nil
+			}
+			c.classes[class.Name] = class
+			c.names = append(c.names, class.Name)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// GetEntries returns the entries of the classpath, in order.
+func (c *Classpath) GetEntries() []string {
+	return c.entries
+}
+
+// GetClassNames returns the internal names of the classes of the classpath, in the order of their first
+// definition.
+func (c *Classpath) GetClassNames() []string {
+	return c.names
+}
+
+// GetClass returns the location of the class loaded for the given internal name, or false if no entry defines it.
+func (c *Classpath) GetClass(name string) (ClasspathClass, bool) {
+	class, ok := c.classes[name]
+	return class, ok
+}
+
+// GetShadowedClasses returns the classes defined in several entries, in the order of their first definition.
+func (c *Classpath) GetShadowedClasses() []ShadowedClass {
+	var shadowedClasses []ShadowedClass
+	for _, name := range c.names {
+		if shadowed := c.shadowed[name]; shadowed != nil {
+			shadowedClasses = append(shadowedClasses, ShadowedClass{Loaded: c.classes[name], Shadowed: shadowed})
+		}
+	}
+	return shadowedClasses
+}
+
+// ReadClass returns the content of the class file loaded for the given internal name.
+func (c *Classpath) ReadClass(name string) ([]byte, error) {
+	class, ok := c.classes[name]
+	if !ok {
+		return nil, errors.New("Illegal Argument - class " + name + " not found in the classpath")
+	}
+	if info, err := os.Stat(class.Entry); err == nil && info.IsDir() {
+		return os.ReadFile(filepath.Join(class.Entry, filepath.FromSlash(class.Path)))
+	}
+	var classFile []byte
+	err := ScanJar(class.Entry, func(jarClass *JarClass) error {
+		if jarClass.Path != class.Path {
+			return nil
+		}
+		var err error
+		classFile, err = jarClass.Read()
+		if err == nil {
+			err = errClassFound
+		}
+		return err
+	})
+	if err == errClassFound {
+		return classFile, nil
+	}
+	if err == nil {
+		err = errors.New("Illegal State - " + class.Path + " removed from " + class.Entry)
+	}
+	return nil, err
+}
+
+// Accept calls the given function with each class loaded from the classpath, i.e. without the shadowed ones, in
+// the order of the entries. Each entry is opened only once.
+func (c *Classpath) Accept(visit func(name string, classFile []byte) error) error {
+	for _, entry := range c.entries {
+		err := walkClasspathEntry(entry, func(name, path string, open func() ([]byte, error)) error {
+			if c.classes[name].Path != path || c.classes[name].Entry != entry {
+				return nil
+			}
+			classFile, err := open()
+			if err != nil {
+				return err
+			}
+			return visit(name, classFile)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkClasspathEntry calls the given function with the name and path of each class file of the given entry, in
+// the order of {@link ScanJar} or in lexical order for a directory, and with a function returning its content.
+func walkClasspathEntry(entry string, visit func(name, path string, open func() ([]byte, error)) error) error {
+	info, err := os.Stat(entry)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return filepath.WalkDir(entry, func(file string, dirEntry fs.DirEntry, err error) error {
+			if err != nil || dirEntry.IsDir() {
+				return err
+			}
+			path, err := filepath.Rel(entry, file)
+			if err != nil {
+				return err
+			}
+			path = filepath.ToSlash(path)
+			if !isClasspathClass(path) {
+				return nil
+			}
+			return visit(strings.TrimSuffix(path, ".class"), path, func() ([]byte, error) { return os.ReadFile(file) })
+		})
+	}
+	return ScanJar(entry, func(class *JarClass) error {
+		return visit(class.Name, class.Path, class.Read)
+	})
+}
+
+// errClassFound stops the scan of a jar when the class being read is found.
+var errClassFound = errors.New("class found")
+
+// isClasspathClass returns whether the given path of a classpath entry is a class file loaded by the JVM.
+func isClasspathClass(path string) bool {
+	return strings.HasSuffix(path, ".class") && !strings.HasSuffix(path, "module-info.class") &&
+		!strings.HasPrefix(path, "META-INF/")
+}
+
+// readZipFile returns the content of the given file of a zip archive.
+func readZipFile(file *zip.File) ([]byte, error) {
+	content, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	return io.ReadAll(content)
+}
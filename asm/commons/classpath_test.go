@@ -0,0 +1,65 @@
+package commons_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+func TestClasspath(t *testing.T) {
+	dir := t.TempDir()
+	classes := filepath.Join(dir, "classes")
+	if err := os.MkdirAll(filepath.Join(classes, "p"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(classes, "p", "A.class"), hierarchyClass(t, opcodes.ACC_PUBLIC, "p/A", "java/lang/Object", true), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jarPath := filepath.Join(dir, "lib.jar")
+	jarFile, err := os.Create(jarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jar := zip.NewWriter(jarFile)
+	for name, classFile := range map[string][]byte{
+		"p/A.class":                     hierarchyClass(t, opcodes.ACC_PUBLIC, "p/A", "java/lang/Object", false),
+		"p/B.class":                     hierarchyClass(t, opcodes.ACC_PUBLIC, "p/B", "p/A", false),
+		"META-INF/versions/9/p/B.class": hierarchyClass(t, opcodes.ACC_PUBLIC, "p/B", "java/lang/Object", false),
+		"module-info.class":             {},
+	} {
+		writer, err := jar.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write(classFile)
+	}
+	jar.Close()
+	jarFile.Close()
+
+	classpath, err := commons.NewClasspath(classes, jarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class, _ := classpath.GetClass("p/A"); class.Entry != classes || len(classpath.GetClassNames()) != 2 {
+		t.Errorf("unexpected classes %v, p/A in %s", classpath.GetClassNames(), class.Entry)
+	}
+	shadowed := classpath.GetShadowedClasses()
+	if len(shadowed) != 1 || shadowed[0].Loaded.Name != "p/A" || shadowed[0].Shadowed[0].Entry != jarPath {
+		t.Errorf("unexpected shadowed classes %v", shadowed)
+	}
+
+	hierarchy := commons.NewClassHierarchy()
+	if err := hierarchy.AddClasspath(classpath); err != nil {
+		t.Fatal(err)
+	}
+	if method := hierarchy.ResolveMethod("p/B", "m", "()V"); method == nil || method.Owner != "p/A" {
+		t.Errorf("expected p/B.m()V to resolve to the method of the loaded p/A, got %v", method)
+	}
+	if _, err := classpath.ReadClass("p/C"); err == nil {
+		t.Error("expected an error for a missing class")
+	}
+}
@@ -0,0 +1,87 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// ClassRemapper a {@link ClassVisitor} that remaps types with a {@link Remapper}, including the types of the
+// generic signatures (see {@link MapSignature}).
+type ClassRemapper struct {
+	helper.ClassAdapter
+	remapper  Remapper
+	className string
+}
+
+// NewClassRemapper constructs a new {@link ClassRemapper} delegating to the given visitor.
+func NewClassRemapper(classVisitor asm.ClassVisitor, remapper Remapper) *ClassRemapper {
+	return &ClassRemapper{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		remapper:     remapper,
+	}
+}
+
+func (c *ClassRemapper) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.className = name
+	c.ClassAdapter.Visit(version, access, MapType(c.remapper, name), MapSignature(c.remapper, signature, false), MapType(c.remapper, superName), MapTypes(c.remapper, interfaces))
+}
+
+func (c *ClassRemapper) VisitModule(name string, access int, version string) asm.ModuleVisitor {
+	moduleVisitor := c.ClassAdapter.VisitModule(c.remapper.MapModuleName(name), access, version)
+	if moduleVisitor == nil {
+		return nil
+	}
+	return NewModuleRemapper(moduleVisitor, c.remapper)
+}
+
+func (c *ClassRemapper) VisitOuterClass(owner, name, descriptor string) {
+	remappedName := name
+	if name != "" {
+		remappedName = c.remapper.MapMethodName(owner, name, descriptor)
+	}
+	c.ClassAdapter.VisitOuterClass(MapType(c.remapper, owner), remappedName, MapMethodDesc(c.remapper, descriptor))
+}
+
+func (c *ClassRemapper) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(c.ClassAdapter.VisitAnnotation(MapDesc(c.remapper, descriptor), visible), c.remapper)
+}
+
+func (c *ClassRemapper) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(c.ClassAdapter.VisitTypeAnnotation(typeRef, typePath, MapDesc(c.remapper, descriptor), visible), c.remapper)
+}
+
+func (c *ClassRemapper) VisitInnerClass(name, outerName, innerName string, access int) {
+	remappedInnerName := innerName
+	if innerName != "" {
+		remappedInnerName = MapInnerClassName(c.remapper, name, outerName, innerName)
+	}
+	c.ClassAdapter.VisitInnerClass(MapType(c.remapper, name), MapType(c.remapper, outerName), remappedInnerName, access)
+}
+
+func (c *ClassRemapper) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	fieldVisitor := c.ClassAdapter.VisitField(
+		access,
+		c.remapper.MapFieldName(c.className, name, descriptor),
+		MapDesc(c.remapper, descriptor),
+		MapSignature(c.remapper, signature, true),
+		MapValue(c.remapper, value),
+	)
+	if fieldVisitor == nil {
+		return nil
+	}
+	return NewFieldRemapper(fieldVisitor, c.remapper)
+}
+
+func (c *ClassRemapper) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	methodVisitor := c.ClassAdapter.VisitMethod(
+		access,
+		c.remapper.MapMethodName(c.className, name, descriptor),
+		MapMethodDesc(c.remapper, descriptor),
+		MapSignature(c.remapper, signature, false),
+		MapTypes(c.remapper, exceptions),
+	)
+	if methodVisitor == nil {
+		return nil
+	}
+	return NewMethodRemapper(methodVisitor, c.remapper)
+}
@@ -0,0 +1,18 @@
+package commons
+
+import "github.com/leaklessgfy/asm/asm"
+
+// CloneClass returns a copy of the given class renamed to newInternalName. The this_class entry, the references
+// of the class to itself (owners of its field and method references, descriptors, generic signatures, local
+// variables and annotations) and its inner class records are updated accordingly, with
+// {@link asm.RemapClassReferences}. The string constants are unchanged.
+func CloneClass(classFile []byte, newInternalName string) ([]byte, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	remapper := NewSimpleRemapper(map[string]string{
+		reader.GetClassName(): newInternalName,
+	})
+	return asm.RemapClassReferences(classFile, NewClassReferenceMapper(remapper))
+}
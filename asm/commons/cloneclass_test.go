@@ -0,0 +1,91 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// selfReferencingClass returns a class a/A with a field "static List<A> self" and a method
+// "static A m(A a) { "a/A".length(); m(self); return a; }".
+func selfReferencingClass(t *testing.T) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "a/A", "java/lang/Object")
+
+	result, err := asm.AddField(classFile.Bytes(), opcodes.ACC_STATIC, "self", "Ljava/util/List;",
+		"Ljava/util/List<La/A;>;", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = asm.AddMethod(result, opcodes.ACC_STATIC, "m", "(La/A;)La/A;", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitCode()
+		methodVisitor.VisitLdcInsn("a/A")
+		methodVisitor.VisitMethodInsn(opcodes.INVOKEVIRTUAL, "java/lang/String", "length", "()I")
+		methodVisitor.VisitInsn(opcodes.POP)
+		methodVisitor.VisitFieldInsn(opcodes.GETSTATIC, "a/A", "self", "Ljava/util/List;")
+		methodVisitor.VisitTypeInsn(opcodes.CHECKCAST, "a/A")
+		methodVisitor.VisitMethodInsn(opcodes.INVOKESTATIC, "a/A", "m", "(La/A;)La/A;")
+		methodVisitor.VisitInsn(opcodes.POP)
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+		methodVisitor.VisitInsn(opcodes.ARETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestCloneClass(t *testing.T) {
+	clone, err := commons.CloneClass(selfReferencingClass(t), "b/B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, err := tree.ReadClassNode(clone, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class.Name != "b/B" || class.SuperName != "java/lang/Object" {
+		t.Errorf("unexpected class %s extends %s", class.Name, class.SuperName)
+	}
+	if field := class.Fields[0]; field.Descriptor != "Ljava/util/List;" || field.Signature != "Ljava/util/List<Lb/B;>;" {
+		t.Errorf("unexpected field %s %s", field.Descriptor, field.Signature)
+	}
+	method := class.GetMethod("m", "(Lb/B;)Lb/B;")
+	if method == nil {
+		t.Fatalf("method m not remapped: %v", class.Methods)
+	}
+	var ldc *tree.LdcInsnNode
+	var field *tree.FieldInsnNode
+	var call *tree.MethodInsnNode
+	var cast *tree.TypeInsnNode
+	for _, insn := range method.Instructions {
+		switch insn := insn.(type) {
+		case *tree.LdcInsnNode:
+			ldc = insn
+		case *tree.FieldInsnNode:
+			field = insn
+		case *tree.MethodInsnNode:
+			if insn.Name == "m" {
+				call = insn
+			}
+		case *tree.TypeInsnNode:
+			cast = insn
+		}
+	}
+	if ldc.Value != "a/A" {
+		t.Errorf("string constant remapped to %v", ldc.Value)
+	}
+	if field.Owner != "b/B" {
+		t.Errorf("unexpected field owner %s", field.Owner)
+	}
+	if call.Owner != "b/B" || call.Descriptor != "(Lb/B;)Lb/B;" {
+		t.Errorf("unexpected method reference %s.%s%s", call.Owner, call.Name, call.Descriptor)
+	}
+	if cast.Type != "b/B" {
+		t.Errorf("unexpected cast type %s", cast.Type)
+	}
+}
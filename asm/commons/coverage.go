@@ -0,0 +1,258 @@
+package commons
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// COVERAGE_MAGIC the first 4 bytes ("ACOV") of the coverage data files read by {@link ReadCoverageData}.
+const COVERAGE_MAGIC = 0x41434F56
+
+// CoverageProbe a line coverage probe of a class: the instrumented code sets a probe when the first
+// instruction of the line is executed in the method.
+type CoverageProbe struct {
+	// Method the name and descriptor of the method.
+	Method string
+	Line   int
+}
+
+// GetCoverageProbes returns the line coverage probes of the given class, in the order of their probe ids: for
+// each method, in the order of the class methods, the distinct lines of its LineNumberTable, in instruction
+// order. The coverage instrumentation must number its probes in the same way.
+func GetCoverageProbes(class *tree.ClassNode) []CoverageProbe {
+	var probes []CoverageProbe
+	for _, method := range class.Methods {
+		lines := make(map[int]bool)
+		for _, insn := range method.Instructions {
+			if lineNumber, ok := insn.(*tree.LineNumberNode); ok && !lines[lineNumber.Line] {
+				lines[lineNumber.Line] = true
+				probes = append(probes, CoverageProbe{Method: method.Name + method.Descriptor, Line: lineNumber.Line})
+			}
+		}
+	}
+	return probes
+}
+
+// ReadCoverageData parses the runtime probe data of the given reader, and returns the probes of each class
+// (given by its internal name). The data has the following format:
+//
+//	u4 magic (COVERAGE_MAGIC)
+//	class classes[] { u2 name_length; u1 name[name_length]; u4 probes_count; u1 probes[(probes_count + 7) / 8]; }
+//
+// where the probes are a bit set, probe i being the bit i%8 of probes[i/8]. The data of a class which appears
+// several times (e.g. from several runs) is merged.
+func ReadCoverageData(reader io.Reader) (map[string][]bool, error) {
+	input := bufio.NewReader(reader)
+	var header [4]byte
+	if _, err := io.ReadFull(input, header[:]); err != nil || binary.BigEndian.Uint32(header[:]) != COVERAGE_MAGIC {
+		return nil, errors.New("Illegal Argument - not a coverage data file")
+	}
+	data := make(map[string][]bool)
+	for {
+		var nameLength [2]byte
+		if _, err := io.ReadFull(input, nameLength[:]); err == io.EOF {
+			return data, nil
+		} else if err != nil {
+			return nil, errors.New("Illegal Argument - truncated coverage data")
+		}
+		name := make([]byte, binary.BigEndian.Uint16(nameLength[:]))
+		var probesCount [4]byte
+		if _, err := io.ReadFull(input, name); err != nil {
+			return nil, errors.New("Illegal Argument - truncated coverage data")
+		}
+		if _, err := io.ReadFull(input, probesCount[:]); err != nil {
+			return nil, errors.New("Illegal Argument - truncated coverage data")
+		}
+		n := int(binary.BigEndian.Uint32(probesCount[:]))
+		bits := make([]byte, (n+7)/8)
+		if _, err := io.ReadFull(input, bits); err != nil {
+			return nil, errors.New("Illegal Argument - truncated coverage data")
+		}
+		probes := data[string(name)]
+		if probes == nil {
+			probes = make([]bool, n)
+		} else if len(probes) != n {
+			return nil, errors.New("Illegal Argument - inconsistent probe counts for " + string(name))
+		}
+		for i := range probes {
+			probes[i] = probes[i] || bits[i/8]&(1<<(i%8)) != 0
+		}
+		data[string(name)] = probes
+	}
+}
+
+// WriteCoverageData writes the given probes of each class (given by its internal name) in the format of
+// {@link ReadCoverageData}.
+func WriteCoverageData(writer io.Writer, data map[string][]bool) error {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	output := binary.BigEndian.AppendUint32(nil, COVERAGE_MAGIC)
+	for _, name := range names {
+		probes := data[name]
+		output = binary.BigEndian.AppendUint16(output, uint16(len(name)))
+		output = append(output, name...)
+		output = binary.BigEndian.AppendUint32(output, uint32(len(probes)))
+		bits := make([]byte, (len(probes)+7)/8)
+		for i, probe := range probes {
+			if probe {
+				bits[i/8] |= 1 << (i % 8)
+			}
+		}
+		output = append(output, bits...)
+	}
+	_, err := writer.Write(output)
+	return err
+}
+
+// LineCoverage the coverage of a source line.
+type LineCoverage struct {
+	Line    int
+	Covered bool
+}
+
+// ClassCoverage the line coverage of a class, obtained by merging its probe data with its debug information.
+type ClassCoverage struct {
+	// Name the internal name of the class.
+	Name string
+	// SourceFile the name of the source file of the class, or "" if unknown.
+	SourceFile string
+	// Lines the lines of the class, sorted by line number. A line is covered if one of its probes is set.
+	Lines []LineCoverage
+}
+
+// NewClassCoverage merges the given probes of the given class (see {@link GetCoverageProbes}). The probes can
+// be nil if the class was not executed.
+func NewClassCoverage(class *tree.ClassNode, probes []bool) (*ClassCoverage, error) {
+	classProbes := GetCoverageProbes(class)
+	if probes != nil && len(probes) != len(classProbes) {
+		return nil, errors.New("Illegal Argument - " + strconv.Itoa(len(probes)) + " probes for class " + class.Name +
+			", expected " + strconv.Itoa(len(classProbes)))
+	}
+	covered := make(map[int]bool)
+	for i, probe := range classProbes {
+		covered[probe.Line] = covered[probe.Line] || (probes != nil && probes[i])
+	}
+	coverage := &ClassCoverage{Name: class.Name, SourceFile: class.SourceFile}
+	for line, isCovered := range covered {
+		coverage.Lines = append(coverage.Lines, LineCoverage{Line: line, Covered: isCovered})
+	}
+	sort.Slice(coverage.Lines, func(i, j int) bool { return coverage.Lines[i].Line < coverage.Lines[j].Line })
+	return coverage, nil
+}
+
+// GetSourcePath returns the path of the source file of the class, relative to the source root (e.g.
+// "a/b/C.java" for a class a/b/C or a/b/C$D compiled from C.java).
+func (c *ClassCoverage) GetSourcePath() string {
+	packageName := ""
+	if i := strings.LastIndexByte(c.Name, '/'); i >= 0 {
+		packageName = c.Name[:i+1]
+	}
+	if c.SourceFile != "" {
+		return packageName + c.SourceFile
+	}
+	name := c.Name
+	if i := strings.IndexByte(name[len(packageName):], '$'); i >= 0 {
+		name = name[:len(packageName)+i]
+	}
+	return name + ".java"
+}
+
+// GetCoveredLines returns the number of covered lines of the class.
+func (c *ClassCoverage) GetCoveredLines() int {
+	covered := 0
+	for _, line := range c.Lines {
+		if line.Covered {
+			covered++
+		}
+	}
+	return covered
+}
+
+// WriteLCOV writes the given class coverages in the LCOV tracefile format, with one record per source file.
+func WriteLCOV(writer io.Writer, coverages []*ClassCoverage) error {
+	// Merges the classes compiled from the same source file (e.g. inner classes).
+	var sourcePaths []string
+	lines := make(map[string]map[int]bool)
+	for _, coverage := range coverages {
+		sourcePath := coverage.GetSourcePath()
+		if lines[sourcePath] == nil {
+			sourcePaths = append(sourcePaths, sourcePath)
+			lines[sourcePath] = make(map[int]bool)
+		}
+		for _, line := range coverage.Lines {
+			lines[sourcePath][line.Line] = lines[sourcePath][line.Line] || line.Covered
+		}
+	}
+	sort.Strings(sourcePaths)
+	var s strings.Builder
+	for _, sourcePath := range sourcePaths {
+		lineNumbers := make([]int, 0, len(lines[sourcePath]))
+		for line := range lines[sourcePath] {
+			lineNumbers = append(lineNumbers, line)
+		}
+		sort.Ints(lineNumbers)
+		s.WriteString("TN:\nSF:" + sourcePath + "\n")
+		covered := 0
+		for _, line := range lineNumbers {
+			hits := "0"
+			if lines[sourcePath][line] {
+				hits = "1"
+				covered++
+			}
+			s.WriteString("DA:" + strconv.Itoa(line) + "," + hits + "\n")
+		}
+		s.WriteString("LF:" + strconv.Itoa(len(lineNumbers)) + "\nLH:" + strconv.Itoa(covered) + "\nend_of_record\n")
+	}
+	_, err := io.WriteString(writer, s.String())
+	return err
+}
+
+// WriteCoverageHTML writes an HTML page showing the given class coverage on the given source file content.
+// The covered lines are highlighted in green, and the lines with code which was not executed in red. If
+// source is nil, only the line numbers of the class are shown.
+func WriteCoverageHTML(writer io.Writer, coverage *ClassCoverage, source []byte) error {
+	covered := make(map[int]bool)
+	for _, line := range coverage.Lines {
+		covered[line.Line] = line.Covered
+	}
+	var sourceLines []string
+	if source != nil {
+		sourceLines = strings.Split(strings.TrimSuffix(string(source), "\n"), "\n")
+	} else if len(coverage.Lines) > 0 {
+		sourceLines = make([]string, coverage.Lines[len(coverage.Lines)-1].Line)
+	}
+
+	title := html.EscapeString(strings.ReplaceAll(coverage.Name, "/", "."))
+	var s strings.Builder
+	s.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + title + "</title>\n")
+	s.WriteString("<style>\npre { margin: 0; }\n.covered { background: #ccffcc; }\n.missed { background: #ffcccc; }\n" +
+		"td.line { text-align: right; color: #808080; padding-right: 1em; }\n</style>\n</head>\n<body>\n")
+	s.WriteString("<h1>" + title + "</h1>\n<p>" + html.EscapeString(coverage.GetSourcePath()) + ": " +
+		strconv.Itoa(coverage.GetCoveredLines()) + " of " + strconv.Itoa(len(coverage.Lines)) + " lines covered</p>\n")
+	s.WriteString("<table>\n")
+	for i, sourceLine := range sourceLines {
+		line := i + 1
+		class := ""
+		if isCovered, ok := covered[line]; ok && isCovered {
+			class = " class=\"covered\""
+		} else if ok {
+			class = " class=\"missed\""
+		}
+		s.WriteString("<tr" + class + "><td class=\"line\">" + strconv.Itoa(line) + "</td><td><pre>" +
+			html.EscapeString(sourceLine) + "</pre></td></tr>\n")
+	}
+	s.WriteString("</table>\n</body>\n</html>\n")
+	_, err := io.WriteString(writer, s.String())
+	return err
+}
@@ -0,0 +1,168 @@
+package commons_test
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// coverageClass returns a class with the given name and source file, whose methods have the given line numbers
+// (one NOP per line).
+func coverageClass(name, sourceFile string, methodLines ...[]int) *tree.ClassNode {
+	class := tree.NewClassNode()
+	class.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, name, "", "java/lang/Object", nil)
+	if sourceFile != "" {
+		class.VisitSource(sourceFile, "")
+	}
+	for i, lines := range methodLines {
+		methodVisitor := class.VisitMethod(opcodes.ACC_STATIC, "m"+string(rune('0'+i)), "()V", "", nil)
+		methodVisitor.VisitCode()
+		for _, line := range lines {
+			label := &asm.Label{}
+			methodVisitor.VisitLabel(label)
+			methodVisitor.VisitLineNumber(line, label)
+			methodVisitor.VisitInsn(opcodes.NOP)
+		}
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+		methodVisitor.VisitEnd()
+	}
+	return class
+}
+
+// assertGoldenFile checks that the given content is equal to the content of the given golden file, or writes it
+// to this file if the {@link UPDATE_GOLDEN_ENV} environment variable is set.
+func assertGoldenFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if os.Getenv(asmtest.UPDATE_GOLDEN_ENV) != "" {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (set %s=1 to create it)", err, asmtest.UPDATE_GOLDEN_ENV)
+	}
+	if !bytes.Equal(golden, content) {
+		t.Errorf("content differs from %s:\n%s", path, content)
+	}
+}
+
+// classCoverages returns the coverage of the classes p/C and p/C$D, compiled from p/C.java, where line 4 is
+// in both classes, and of the class p/E, which was not executed.
+func classCoverages(t *testing.T) []*commons.ClassCoverage {
+	var coverages []*commons.ClassCoverage
+	for _, test := range []struct {
+		class  *tree.ClassNode
+		probes []bool
+	}{
+		// Line 3 is covered in one method and not in the other.
+		{coverageClass("p/C", "C.java", []int{3, 4, 3}, []int{3, 6}), []bool{false, false, true, false}},
+		{coverageClass("p/C$D", "", []int{4, 8}), []bool{true, false}},
+		{coverageClass("p/E", "E.java", []int{2}), nil},
+	} {
+		coverage, err := commons.NewClassCoverage(test.class, test.probes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		coverages = append(coverages, coverage)
+	}
+	return coverages
+}
+
+func TestNewClassCoverage(t *testing.T) {
+	coverages := classCoverages(t)
+	if !reflect.DeepEqual(coverages[0].Lines, []commons.LineCoverage{{3, true}, {4, false}, {6, false}}) ||
+		coverages[0].GetCoveredLines() != 1 || coverages[0].GetSourcePath() != "p/C.java" {
+		t.Errorf("unexpected coverage %+v", coverages[0])
+	}
+	if coverages[1].GetSourcePath() != "p/C.java" || coverages[2].GetCoveredLines() != 0 {
+		t.Errorf("unexpected coverages %+v %+v", coverages[1], coverages[2])
+	}
+	_, err := commons.NewClassCoverage(coverageClass("p/C", "", []int{1, 2}), []bool{true})
+	if err == nil || err.Error() != "Illegal Argument - 1 probes for class p/C, expected 2" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	var output bytes.Buffer
+	if err := commons.WriteLCOV(&output, classCoverages(t)); err != nil {
+		t.Fatal(err)
+	}
+	assertGoldenFile(t, "testdata/coverage.lcov", output.Bytes())
+}
+
+func TestWriteCoverageHTML(t *testing.T) {
+	coverage := classCoverages(t)[0]
+	source := []byte("package p;\n\nclass C { static void m0() { if (a < b) {\n  f();\n}\n  g(); }\n}\n")
+	var output bytes.Buffer
+	if err := commons.WriteCoverageHTML(&output, coverage, source); err != nil {
+		t.Fatal(err)
+	}
+	assertGoldenFile(t, "testdata/coverage.html", output.Bytes())
+
+	// Without source, the lines up to the last line of the class are shown.
+	output.Reset()
+	if err := commons.WriteCoverageHTML(&output, coverage, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertGoldenFile(t, "testdata/coverage_nosource.html", output.Bytes())
+}
+
+func TestReadCoverageData(t *testing.T) {
+	data := map[string][]bool{
+		"p/C":   {true, false, false, true, false, false, false, false, true},
+		"p/C$D": {},
+	}
+	var output bytes.Buffer
+	if err := commons.WriteCoverageData(&output, data); err != nil {
+		t.Fatal(err)
+	}
+	readBack, err := commons.ReadCoverageData(bytes.NewReader(output.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(readBack, data) {
+		t.Errorf("unexpected data %v", readBack)
+	}
+
+	// The data of several runs is merged.
+	var otherRun bytes.Buffer
+	if err := commons.WriteCoverageData(&otherRun, map[string][]bool{
+		"p/C": {false, true, false, false, false, false, false, false, false},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := commons.ReadCoverageData(bytes.NewReader(append(output.Bytes(), otherRun.Bytes()[4:]...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []bool{true, true, false, true, false, false, false, false, true}; !reflect.DeepEqual(merged["p/C"], expected) {
+		t.Errorf("unexpected merged data %v", merged["p/C"])
+	}
+
+	for _, test := range []struct {
+		name    string
+		data    []byte
+		message string
+	}{
+		{"magic", []byte("ACOW"), "Illegal Argument - not a coverage data file"},
+		{"empty", nil, "Illegal Argument - not a coverage data file"},
+		{"truncated", output.Bytes()[:len(output.Bytes())-1], "Illegal Argument - truncated coverage data"},
+		{"inconsistent", append(output.Bytes(), 0, 3, 'p', '/', 'C', 0, 0, 0, 1, 1),
+			"Illegal Argument - inconsistent probe counts for p/C"},
+	} {
+		if _, err := commons.ReadCoverageData(bytes.NewReader(test.data)); err == nil || err.Error() != test.message {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
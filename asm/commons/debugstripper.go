@@ -0,0 +1,272 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// STRIP_ASSERTIONS a flag to remove the assert statements compiled by javac, as well as the synthetic
+// $assertionsDisabled field they use.
+const STRIP_ASSERTIONS = 1
+
+// STRIP_DEBUG a flag to remove the SourceFile, SourceDebugExtension, LineNumberTable, LocalVariableTable and
+// LocalVariableTypeTable attributes, and the type annotations on local variables.
+const STRIP_DEBUG = 2
+
+// STRIP_PARAMETERS a flag to remove the MethodParameters attributes, i.e. the parameter names.
+const STRIP_PARAMETERS = 4
+
+// STRIP_ALL the combination of all the strip flags, for release builds.
+const STRIP_ALL = STRIP_ASSERTIONS | STRIP_DEBUG | STRIP_PARAMETERS
+
+// ASSERTIONS_DISABLED_FIELD the name of the synthetic static field generated by javac for assert statements.
+const ASSERTIONS_DISABLED_FIELD = "$assertionsDisabled"
+
+// DebugStripper a {@link ClassVisitor} that removes assertions and debug information in a single pass,
+// depending on its flags.
+//
+// An assert statement is compiled by javac to "getstatic $assertionsDisabled; ifne end; <check>; end:". The
+// whole sequence is removed. Any other read of $assertionsDisabled is replaced with the constant true, and its
+// writes (in the static initializer) with a pop, so that the field itself can be removed.
+type DebugStripper struct {
+	helper.ClassAdapter
+	flags     int
+	className string
+}
+
+// NewDebugStripper constructs a new {@link DebugStripper}. The flags are a combination of
+// {@link STRIP_ASSERTIONS}, {@link STRIP_DEBUG} and {@link STRIP_PARAMETERS}.
+func NewDebugStripper(classVisitor asm.ClassVisitor, flags int) *DebugStripper {
+	return &DebugStripper{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		flags:        flags,
+	}
+}
+
+// StripClass makes the given visitor visit the given class without its assertions and debug information,
+// depending on the given flags (see {@link NewDebugStripper}).
+func StripClass(classFile []byte, flags int, classVisitor asm.ClassVisitor) error {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return err
+	}
+	reader.Accept(NewDebugStripper(classVisitor, flags), 0)
+	return nil
+}
+
+// StripClassFile returns the given class file without its assertions and debug information, depending on the
+// given flags (see {@link NewDebugStripper}). The class is read with expanded frames, so that the frames which
+// follow a removed assert statement remain valid, and written with a {@link asm.ClassWriter}.
+func StripClassFile(classFile []byte, flags int) ([]byte, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	classWriter := asm.NewClassWriter(reader)
+	reader.Accept(NewDebugStripper(classWriter, flags), asm.EXPAND_FRAMS|asm.PRESERVE_UNKNOWN_ATTRIBUTES)
+	return classWriter.Bytes()
+}
+
+func (d *DebugStripper) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	d.className = name
+	d.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (d *DebugStripper) VisitSource(source, debug string) {
+	if (d.flags & STRIP_DEBUG) == 0 {
+		d.ClassAdapter.VisitSource(source, debug)
+	}
+}
+
+func (d *DebugStripper) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	if (d.flags&STRIP_ASSERTIONS) != 0 && name == ASSERTIONS_DISABLED_FIELD && descriptor == "Z" && (access&opcodes.ACC_STATIC) != 0 {
+		return nil
+	}
+	return d.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+}
+
+func (d *DebugStripper) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	methodVisitor := d.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil {
+		return nil
+	}
+	return &debugStripperMethod{
+		MethodAdapter: helper.MethodAdapter{Next: methodVisitor},
+		stripper:      d,
+	}
+}
+
+type debugStripperMethod struct {
+	helper.MethodAdapter
+	stripper *DebugStripper
+	// pending whether a getstatic $assertionsDisabled has been removed and not yet replaced.
+	pending bool
+	// skipUntil the end label of the assert statement being removed, or nil.
+	skipUntil *asm.Label
+}
+
+func (d *debugStripperMethod) isAssertionsDisabled(owner, name string) bool {
+	return (d.stripper.flags&STRIP_ASSERTIONS) != 0 && owner == d.stripper.className && name == ASSERTIONS_DISABLED_FIELD
+}
+
+// emit returns whether the current instruction must be forwarded, after replacing any pending
+// $assertionsDisabled read.
+func (d *debugStripperMethod) emit() bool {
+	if d.pending {
+		d.pending = false
+		d.MethodAdapter.VisitInsn(opcodes.ICONST_1)
+	}
+	return d.skipUntil == nil
+}
+
+func (d *debugStripperMethod) VisitParameter(name string, access int) {
+	if (d.stripper.flags & STRIP_PARAMETERS) == 0 {
+		d.MethodAdapter.VisitParameter(name, access)
+	}
+}
+
+func (d *debugStripperMethod) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	if d.emit() {
+		d.MethodAdapter.VisitFrame(typed, nLocal, local, nStack, stack)
+	}
+}
+
+func (d *debugStripperMethod) VisitInsn(opcode int) {
+	if d.emit() {
+		d.MethodAdapter.VisitInsn(opcode)
+	}
+}
+
+func (d *debugStripperMethod) VisitIntInsn(opcode, operand int) {
+	if d.emit() {
+		d.MethodAdapter.VisitIntInsn(opcode, operand)
+	}
+}
+
+func (d *debugStripperMethod) VisitVarInsn(opcode, vard int) {
+	if d.emit() {
+		d.MethodAdapter.VisitVarInsn(opcode, vard)
+	}
+}
+
+func (d *debugStripperMethod) VisitTypeInsn(opcode int, typed string) {
+	if d.emit() {
+		d.MethodAdapter.VisitTypeInsn(opcode, typed)
+	}
+}
+
+func (d *debugStripperMethod) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	if !d.emit() {
+		return
+	}
+	if d.isAssertionsDisabled(owner, name) {
+		switch opcode {
+		case opcodes.GETSTATIC:
+			d.pending = true
+			return
+		case opcodes.PUTSTATIC:
+			d.MethodAdapter.VisitInsn(opcodes.POP)
+			return
+		}
+	}
+	d.MethodAdapter.VisitFieldInsn(opcode, owner, name, descriptor)
+}
+
+func (d *debugStripperMethod) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	d.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
+
+func (d *debugStripperMethod) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	if d.emit() {
+		d.MethodAdapter.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+	}
+}
+
+func (d *debugStripperMethod) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	if d.emit() {
+		d.MethodAdapter.VisitInvokeDynamicInsn(name, descriptor, bootstrapMethodHande, bootstrapMethodArguments...)
+	}
+}
+
+func (d *debugStripperMethod) VisitJumpInsn(opcode int, label *asm.Label) {
+	if d.pending && opcode == opcodes.IFNE {
+		d.pending = false
+		d.skipUntil = label
+		return
+	}
+	if d.emit() {
+		d.MethodAdapter.VisitJumpInsn(opcode, label)
+	}
+}
+
+func (d *debugStripperMethod) VisitLabel(label *asm.Label) {
+	d.emit()
+	if label == d.skipUntil {
+		d.skipUntil = nil
+	}
+	// Labels inside a removed assert statement are kept, since they may still be referenced by line numbers.
+	d.MethodAdapter.VisitLabel(label)
+}
+
+func (d *debugStripperMethod) VisitLdcInsn(value interface{}) {
+	if d.emit() {
+		d.MethodAdapter.VisitLdcInsn(value)
+	}
+}
+
+func (d *debugStripperMethod) VisitIincInsn(vard, increment int) {
+	if d.emit() {
+		d.MethodAdapter.VisitIincInsn(vard, increment)
+	}
+}
+
+func (d *debugStripperMethod) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	if d.emit() {
+		d.MethodAdapter.VisitTableSwitchInsn(min, max, dflt, labels...)
+	}
+}
+
+func (d *debugStripperMethod) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	if d.emit() {
+		d.MethodAdapter.VisitLookupSwitchInsn(dflt, keys, labels)
+	}
+}
+
+func (d *debugStripperMethod) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	if d.emit() {
+		d.MethodAdapter.VisitMultiANewArrayInsn(descriptor, numDimensions)
+	}
+}
+
+func (d *debugStripperMethod) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	if d.pending || d.skipUntil != nil {
+		// The annotated instruction has been removed.
+		return nil
+	}
+	return d.MethodAdapter.VisitInsnAnnotation(typeRef, typePath, descriptor, visible)
+}
+
+func (d *debugStripperMethod) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	if (d.stripper.flags & STRIP_DEBUG) == 0 {
+		d.MethodAdapter.VisitLocalVariable(name, descriptor, signature, start, end, index)
+	}
+}
+
+func (d *debugStripperMethod) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	if (d.stripper.flags & STRIP_DEBUG) != 0 {
+		return nil
+	}
+	return d.MethodAdapter.VisitLocalVariableAnnotation(typeRef, typePath, start, end, index, descriptor, visible)
+}
+
+func (d *debugStripperMethod) VisitLineNumber(line int, start *asm.Label) {
+	if (d.stripper.flags & STRIP_DEBUG) == 0 {
+		d.MethodAdapter.VisitLineNumber(line, start)
+	}
+}
+
+func (d *debugStripperMethod) VisitMaxs(maxStack int, maxLocals int) {
+	d.emit()
+	d.MethodAdapter.VisitMaxs(maxStack, maxLocals)
+}
@@ -0,0 +1,153 @@
+package commons_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// assertingClass returns a class A with the $assertionsDisabled field, initialized in <clinit>, a SourceFile
+// attribute, and a method "void check(int x) { assert x != 0; }" with line numbers, local variables and parameter
+// names.
+func assertingClass(t *testing.T) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_5, opcodes.ACC_SUPER, "A", "java/lang/Object")
+	sourceFile := classFile.SymbolTable.AddConstantUtf8("A.java")
+	classFile.AddAttribute("SourceFile", []byte{byte(sourceFile >> 8), byte(sourceFile)})
+	classFile.AddField(opcodes.ACC_STATIC|opcodes.ACC_FINAL|opcodes.ACC_SYNTHETIC, commons.ASSERTIONS_DISABLED_FIELD, "Z", "", nil)
+
+	clinit := classFile.AddMethod(opcodes.ACC_STATIC, "<clinit>", "()V", "", nil)
+	clinit.VisitCode()
+	clinit.VisitInsn(opcodes.ICONST_1)
+	clinit.VisitFieldInsn(opcodes.PUTSTATIC, "A", commons.ASSERTIONS_DISABLED_FIELD, "Z")
+	clinit.VisitInsn(opcodes.RETURN)
+	clinit.VisitMaxs(1, 0)
+	clinit.VisitEnd()
+
+	check := classFile.AddMethod(0, "check", "(I)V", "", nil)
+	check.VisitParameter("x", 0)
+	check.VisitCode()
+	start := &asm.Label{}
+	end := &asm.Label{}
+	check.VisitLabel(start)
+	check.VisitLineNumber(1, start)
+	check.VisitFieldInsn(opcodes.GETSTATIC, "A", commons.ASSERTIONS_DISABLED_FIELD, "Z")
+	check.VisitJumpInsn(opcodes.IFNE, end)
+	check.VisitVarInsn(opcodes.ILOAD, 1)
+	check.VisitJumpInsn(opcodes.IFNE, end)
+	check.VisitTypeInsn(opcodes.NEW, "java/lang/AssertionError")
+	check.VisitInsn(opcodes.DUP)
+	check.VisitMethodInsnB(opcodes.INVOKESPECIAL, "java/lang/AssertionError", "<init>", "()V", false)
+	check.VisitInsn(opcodes.ATHROW)
+	check.VisitLabel(end)
+	check.VisitLineNumber(2, end)
+	check.VisitInsn(opcodes.RETURN)
+	check.VisitLocalVariable("x", "I", "", start, end, 1)
+	check.VisitMaxs(2, 2)
+	check.VisitEnd()
+	return classFile.Bytes()
+}
+
+// strippedEvents returns the trace of the given class, stripped with the given flags, without the class and
+// method headers.
+func strippedEvents(t *testing.T, classFile []byte, flags int) []string {
+	recorder := asmtest.NewRecorder(nil)
+	if err := commons.StripClass(classFile, flags, recorder); err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, event := range recorder.Trace() {
+		if !strings.HasPrefix(event, "class visit method") {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestDebugStripperAssertions(t *testing.T) {
+	events := strippedEvents(t, assertingClass(t), commons.STRIP_ASSERTIONS)
+	expected := []string{
+		`class visit A 49 32 "A" "" "java/lang/Object" []`,
+		`class visit source A "A.java" ""`,
+		`method visit code A.<clinit>()V`,
+		`method visit insn A.<clinit>()V 4`,
+		`method visit insn A.<clinit>()V 87`,
+		`method visit insn A.<clinit>()V 177`,
+		`method visit maxs A.<clinit>()V 1 0`,
+		`method visit end A.<clinit>()V`,
+		`method visit parameter A.check(I)V "x" 0`,
+		`method visit code A.check(I)V`,
+		`method visit label A.check(I)V L0`,
+		`method visit line number A.check(I)V 1 L0`,
+		`method visit label A.check(I)V L1`,
+		`method visit line number A.check(I)V 2 L1`,
+		`method visit insn A.check(I)V 177`,
+		`method visit local variable A.check(I)V "x" "I" "" L0 L1 1`,
+		`method visit maxs A.check(I)V 2 2`,
+		`method visit end A.check(I)V`,
+		`class visit end A`,
+	}
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected events:\n%s", strings.Join(events, "\n"))
+	}
+}
+
+func TestDebugStripperDebug(t *testing.T) {
+	events := strippedEvents(t, assertingClass(t), commons.STRIP_DEBUG|commons.STRIP_PARAMETERS)
+	trace := strings.Join(events, "\n")
+	for _, removed := range []string{"visit source", "visit line number", "visit local variable", "visit parameter"} {
+		if strings.Contains(trace, removed) {
+			t.Errorf("unexpected %s event:\n%s", removed, trace)
+		}
+	}
+	// The assert statement and the field are kept.
+	for _, kept := range []string{"class visit field A 4120 \"$assertionsDisabled\"", "method visit type insn A.check(I)V 187"} {
+		if !strings.Contains(trace, kept) {
+			t.Errorf("missing %s event:\n%s", kept, trace)
+		}
+	}
+}
+
+func TestStripClassFile(t *testing.T) {
+	stripped, err := commons.StripClassFile(assertingClass(t), commons.STRIP_ALL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The written class has no debug information, assert statement and $assertionsDisabled field.
+	expected := []string{
+		`class visit A 49 32 "A" "" "java/lang/Object" []`,
+		`method visit code A.<clinit>()V`,
+		`method visit insn A.<clinit>()V 4`,
+		`method visit insn A.<clinit>()V 87`,
+		`method visit insn A.<clinit>()V 177`,
+		`method visit maxs A.<clinit>()V 1 0`,
+		`method visit end A.<clinit>()V`,
+		`method visit code A.check(I)V`,
+		`method visit insn A.check(I)V 177`,
+		`method visit maxs A.check(I)V 2 2`,
+		`method visit end A.check(I)V`,
+		`class visit end A`,
+	}
+	if events := strippedEvents(t, stripped, 0); strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected events:\n%s", strings.Join(events, "\n"))
+	}
+	// The stripped version of a class with stack map frames is still valid.
+	exampleClass, err := os.ReadFile("../../ExampleClass.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stripped, err = commons.StripClassFile(exampleClass, commons.STRIP_ALL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := analysis.VerifyOutput(stripped); err != nil {
+		t.Error(err)
+	}
+	if _, err := commons.StripClassFile([]byte{0xCA, 0xFE}, commons.STRIP_ALL); err == nil {
+		t.Error("expected an error for a truncated class")
+	}
+}
@@ -0,0 +1,344 @@
+package commons
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// DefaultMethod a default method declared in an interface, as returned by {@link ExtractDefaultMethods}.
+type DefaultMethod struct {
+	Owner      string
+	Access     int
+	Name       string
+	Descriptor string
+	Signature  string
+	Exceptions []string
+	classFile  []byte
+	// the name and descriptor of the private methods of the interface.
+	privateMethods map[string]bool
+}
+
+// ExtractDefaultMethods returns the default methods declared in the given interface class file, i.e. its
+// non abstract, non static and non private methods.
+func ExtractDefaultMethods(interfaceFile []byte) ([]*DefaultMethod, error) {
+	reader, err := asm.NewClassReader(interfaceFile)
+	if err != nil {
+		return nil, err
+	}
+	owner := reader.GetClassName()
+	if (reader.GetAccess() & opcodes.ACC_INTERFACE) == 0 {
+		return nil, errors.New("Illegal Argument - " + owner + " is not an interface")
+	}
+	defaultMethods := make([]*DefaultMethod, 0)
+	privateMethods := make(map[string]bool)
+	reader.Accept(&helper.ClassVisitor{
+		OnVisitMethod: func(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+			if (access & opcodes.ACC_PRIVATE) != 0 {
+				privateMethods[name+descriptor] = true
+			}
+			if (access&(opcodes.ACC_ABSTRACT|opcodes.ACC_STATIC|opcodes.ACC_PRIVATE)) == 0 && name != "<clinit>" {
+				defaultMethods = append(defaultMethods, &DefaultMethod{
+					Owner:          owner,
+					Access:         access,
+					Name:           name,
+					Descriptor:     descriptor,
+					Signature:      signature,
+					Exceptions:     exceptions,
+					classFile:      interfaceFile,
+					privateMethods: privateMethods,
+				})
+			}
+			return nil
+		},
+	}, asm.SKIP_CODE)
+	return defaultMethods, nil
+}
+
+// CompanionDescriptor returns the descriptor of the static companion method of this default method, i.e. its
+// descriptor with the interface prepended to the arguments.
+func (d DefaultMethod) CompanionDescriptor() string {
+	return "(L" + d.Owner + ";" + d.Descriptor[1:]
+}
+
+// AcceptBody makes the given visitor visit the code of this default method, from visitCode to visitMaxs. The
+// parameters, annotations and non code attributes of the method are not visited, nor is visitEnd.
+func (d DefaultMethod) AcceptBody(methodVisitor asm.MethodVisitor) error {
+	reader, err := asm.NewClassReader(d.classFile)
+	if err != nil {
+		return err
+	}
+	reader.AcceptFiltered(&helper.ClassVisitor{
+		OnVisitMethod: func(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+			return &bodyAdapter{MethodAdapter: helper.MethodAdapter{Next: methodVisitor}}
+		},
+	}, asm.Filter{
+		MethodNames:       []string{d.Name},
+		DescriptorPattern: regexp.MustCompile("^" + regexp.QuoteMeta(d.Descriptor) + "$"),
+	}, 0)
+	return nil
+}
+
+// bodyAdapter a MethodVisitor forwarding only the events of the Code attribute of a method.
+type bodyAdapter struct {
+	helper.MethodAdapter
+	inCode bool
+}
+
+func (b *bodyAdapter) VisitParameter(name string, access int) {}
+
+func (b *bodyAdapter) VisitAnnotationDefault() asm.AnnotationVisitor {
+	return nil
+}
+
+func (b *bodyAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (b *bodyAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (b *bodyAdapter) VisitAnnotableParameterCount(parameterCount int, visible bool) {}
+
+func (b *bodyAdapter) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (b *bodyAdapter) VisitAttribute(attribute *asm.Attribute) {
+	if b.inCode {
+		b.MethodAdapter.VisitAttribute(attribute)
+	}
+}
+
+func (b *bodyAdapter) VisitCode() {
+	b.inCode = true
+	b.MethodAdapter.VisitCode()
+}
+
+func (b *bodyAdapter) VisitEnd() {}
+
+// WriteCompanionMethods makes the given visitor visit, for each given default method, a public static method
+// with the same body, named after the default method and using its {@link DefaultMethod#CompanionDescriptor}.
+// The super interface calls of these bodies are rewritten with a {@link DefaultMethodCallRewriter}. This is
+// the content of the companion classes used to move default method bodies out of interfaces, for targets
+// older than Java 8. An error is returned, before visiting any method, if a body uses invokedynamic (e.g. a
+// lambda) or calls a private method of its interface (e.g. a lambda$ method), which a companion class can't.
+func WriteCompanionMethods(classVisitor asm.ClassVisitor, defaultMethods []*DefaultMethod, companionSuffix string) error {
+	for _, defaultMethod := range defaultMethods {
+		checker := &companionBodyChecker{defaultMethod: defaultMethod}
+		if err := defaultMethod.AcceptBody(checker); err != nil {
+			return err
+		}
+		if checker.err != nil {
+			return checker.err
+		}
+	}
+	for _, defaultMethod := range defaultMethods {
+		methodVisitor := classVisitor.VisitMethod(
+			opcodes.ACC_PUBLIC|opcodes.ACC_STATIC|(defaultMethod.Access&opcodes.ACC_VARARGS),
+			defaultMethod.Name,
+			defaultMethod.CompanionDescriptor(),
+			"",
+			defaultMethod.Exceptions,
+		)
+		if methodVisitor == nil {
+			continue
+		}
+		if err := defaultMethod.AcceptBody(NewDefaultMethodCallRewriter(methodVisitor, defaultMethods, companionSuffix)); err != nil {
+			return err
+		}
+		methodVisitor.VisitEnd()
+	}
+	return nil
+}
+
+// companionBodyChecker a MethodVisitor finding the first instruction of a default method body which can't be
+// moved to a companion class.
+type companionBodyChecker struct {
+	helper.MethodAdapter
+	defaultMethod *DefaultMethod
+	err           error
+}
+
+func (c *companionBodyChecker) fail(instruction string) {
+	if c.err == nil {
+		c.err = errors.New("Illegal Argument - " + c.defaultMethod.Owner + "." + c.defaultMethod.Name +
+			c.defaultMethod.Descriptor + " can't be moved to a companion class, it uses " + instruction)
+	}
+}
+
+func (c *companionBodyChecker) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	if owner == c.defaultMethod.Owner && c.defaultMethod.privateMethods[name+descriptor] {
+		c.fail("the private interface method " + name + descriptor)
+	}
+}
+
+func (c *companionBodyChecker) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	c.fail("invokedynamic")
+}
+
+// DefaultMethodCallRewriter a {@link MethodVisitor} that replaces the super interface calls to the given
+// default methods (i.e. invokespecial instructions on interfaces, as in I.super.m()) with invokestatic
+// calls to their companion methods (see {@link WriteCompanionMethods}). The operand stack is left
+// unchanged, the receiver becoming the first argument of the companion method.
+type DefaultMethodCallRewriter struct {
+	helper.MethodAdapter
+	defaultMethods  map[string]*DefaultMethod
+	companionSuffix string
+}
+
+// NewDefaultMethodCallRewriter constructs a new {@link DefaultMethodCallRewriter}.
+func NewDefaultMethodCallRewriter(methodVisitor asm.MethodVisitor, defaultMethods []*DefaultMethod, companionSuffix string) *DefaultMethodCallRewriter {
+	index := make(map[string]*DefaultMethod, len(defaultMethods))
+	for _, defaultMethod := range defaultMethods {
+		index[defaultMethod.Owner+"."+defaultMethod.Name+defaultMethod.Descriptor] = defaultMethod
+	}
+	return &DefaultMethodCallRewriter{
+		MethodAdapter:   helper.MethodAdapter{Next: methodVisitor},
+		defaultMethods:  index,
+		companionSuffix: companionSuffix,
+	}
+}
+
+func (d *DefaultMethodCallRewriter) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	if opcode == opcodes.INVOKESPECIAL && isInterface {
+		if defaultMethod, ok := d.defaultMethods[owner+"."+name+descriptor]; ok {
+			d.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, owner+d.companionSuffix, name, defaultMethod.CompanionDescriptor(), false)
+			return
+		}
+	}
+	d.MethodAdapter.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+}
+
+// DefaultMethodInjector a {@link ClassVisitor} that adds to the visited class a public implementation of each
+// given default method that the class does not declare itself. If the companion suffix is empty, the
+// implementations delegate to the default methods with super interface calls, which requires a Java 8+
+// class and is only done for the default methods of the direct super interfaces of the class. Otherwise
+// they delegate to the companion methods of the default methods, and the existing super interface calls
+// of the class are rewritten accordingly (see {@link DefaultMethodCallRewriter}). No implementation is added
+// for the default methods which the class inherits a non abstract implementation of from its super classes,
+// as found in the given {@link ClassHierarchy} (which can be nil if the super classes are unknown).
+type DefaultMethodInjector struct {
+	helper.ClassAdapter
+	defaultMethods  []*DefaultMethod
+	companionSuffix string
+	hierarchy       *ClassHierarchy
+	superName       string
+	interfaces      []string
+	declaredMethods map[string]bool
+}
+
+// NewDefaultMethodInjector constructs a new {@link DefaultMethodInjector}.
+func NewDefaultMethodInjector(classVisitor asm.ClassVisitor, defaultMethods []*DefaultMethod, companionSuffix string, hierarchy *ClassHierarchy) *DefaultMethodInjector {
+	return &DefaultMethodInjector{
+		ClassAdapter:    helper.ClassAdapter{Next: classVisitor},
+		defaultMethods:  defaultMethods,
+		companionSuffix: companionSuffix,
+		hierarchy:       hierarchy,
+		declaredMethods: make(map[string]bool),
+	}
+}
+
+func (d *DefaultMethodInjector) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	d.superName = superName
+	d.interfaces = interfaces
+	d.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (d *DefaultMethodInjector) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	d.declaredMethods[name+descriptor] = true
+	methodVisitor := d.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil || d.companionSuffix == "" {
+		return methodVisitor
+	}
+	return NewDefaultMethodCallRewriter(methodVisitor, d.defaultMethods, d.companionSuffix)
+}
+
+func (d *DefaultMethodInjector) VisitEnd() {
+	for _, defaultMethod := range d.defaultMethods {
+		if d.declaredMethods[defaultMethod.Name+defaultMethod.Descriptor] {
+			continue
+		}
+		if d.companionSuffix == "" && !d.isDirectInterface(defaultMethod.Owner) {
+			continue
+		}
+		if d.inheritsImplementation(defaultMethod) {
+			continue
+		}
+		d.declaredMethods[defaultMethod.Name+defaultMethod.Descriptor] = true
+		d.injectDelegate(defaultMethod)
+	}
+	d.ClassAdapter.VisitEnd()
+}
+
+func (d *DefaultMethodInjector) isDirectInterface(owner string) bool {
+	for _, itf := range d.interfaces {
+		if itf == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// inheritsImplementation returns whether the visited class inherits a non abstract method overriding the given
+// default method from its super classes, i.e. whether the method selected by the JVM for this class is not the
+// default method.
+func (d *DefaultMethodInjector) inheritsImplementation(defaultMethod *DefaultMethod) bool {
+	if d.hierarchy == nil {
+		return false
+	}
+	resolvedMethod := &HierarchyMethod{
+		Owner:      defaultMethod.Owner,
+		Access:     defaultMethod.Access,
+		Name:       defaultMethod.Name,
+		Descriptor: defaultMethod.Descriptor,
+	}
+	visited := make(map[string]bool)
+	for class := d.hierarchy.GetClass(d.superName); class != nil && !visited[class.Name]; class = d.hierarchy.GetClass(class.SuperName) {
+		visited[class.Name] = true
+		method := class.GetMethod(defaultMethod.Name, defaultMethod.Descriptor)
+		if method != nil && (method.Access&opcodes.ACC_STATIC) == 0 && canOverride(method, resolvedMethod) {
+			// An abstract method hides the default method, and must be implemented by the visited class.
+			return !method.isAbstract()
+		}
+	}
+	return false
+}
+
+func (d *DefaultMethodInjector) injectDelegate(defaultMethod *DefaultMethod) {
+	methodVisitor := d.ClassAdapter.VisitMethod(
+		opcodes.ACC_PUBLIC|(defaultMethod.Access&opcodes.ACC_VARARGS),
+		defaultMethod.Name,
+		defaultMethod.Descriptor,
+		defaultMethod.Signature,
+		defaultMethod.Exceptions,
+	)
+	if methodVisitor == nil {
+		return
+	}
+	methodType := asm.GetMethodType(defaultMethod.Descriptor)
+	methodVisitor.VisitCode()
+	methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+	local := 1
+	for _, argumentType := range methodType.GetArgumentTypes() {
+		methodVisitor.VisitVarInsn(argumentType.GetOpcode(opcodes.ILOAD), local)
+		local += argumentType.GetSize()
+	}
+	if d.companionSuffix == "" {
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, defaultMethod.Owner, defaultMethod.Name, defaultMethod.Descriptor, true)
+	} else {
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESTATIC, defaultMethod.Owner+d.companionSuffix, defaultMethod.Name, defaultMethod.CompanionDescriptor(), false)
+	}
+	returnType := methodType.GetReturnType()
+	methodVisitor.VisitInsn(returnType.GetOpcode(opcodes.IRETURN))
+	maxStack := local
+	if returnType.GetSize() > maxStack {
+		maxStack = returnType.GetSize()
+	}
+	methodVisitor.VisitMaxs(maxStack, local)
+	methodVisitor.VisitEnd()
+}
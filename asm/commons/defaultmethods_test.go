@@ -0,0 +1,202 @@
+package commons_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// defaultMethodsInterface returns an interface p/I with the default methods "int m()" and "int n()", which calls
+// I.super.m(), and the given default method "void d()".
+func defaultMethodsInterface(d func(methodVisitor asm.MethodVisitor)) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_INTERFACE|opcodes.ACC_ABSTRACT,
+		"p/I", "java/lang/Object")
+	m := classFile.AddMethod(opcodes.ACC_PUBLIC, "m", "()I", "", nil)
+	m.VisitCode()
+	m.VisitInsn(opcodes.ICONST_1)
+	m.VisitInsn(opcodes.IRETURN)
+	m.VisitMaxs(1, 1)
+	m.VisitEnd()
+
+	n := classFile.AddMethod(opcodes.ACC_PUBLIC, "n", "()I", "", nil)
+	n.VisitCode()
+	n.VisitVarInsn(opcodes.ALOAD, 0)
+	n.VisitMethodInsnB(opcodes.INVOKESPECIAL, "p/I", "m", "()I", true)
+	n.VisitInsn(opcodes.IRETURN)
+	n.VisitMaxs(1, 1)
+	n.VisitEnd()
+
+	lambda := classFile.AddMethod(opcodes.ACC_PRIVATE|opcodes.ACC_SYNTHETIC, "lambda$d$0", "()V", "", nil)
+	lambda.VisitCode()
+	lambda.VisitInsn(opcodes.RETURN)
+	lambda.VisitMaxs(0, 1)
+	lambda.VisitEnd()
+
+	if d != nil {
+		methodVisitor := classFile.AddMethod(opcodes.ACC_PUBLIC, "d", "()V", "", nil)
+		methodVisitor.VisitCode()
+		d(methodVisitor)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(1, 1)
+		methodVisitor.VisitEnd()
+	}
+	return classFile.Bytes()
+}
+
+func TestWriteCompanionMethods(t *testing.T) {
+	defaultMethods, err := commons.ExtractDefaultMethods(defaultMethodsInterface(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defaultMethods) != 2 {
+		t.Fatalf("unexpected default methods %v", defaultMethods)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	if err := commons.WriteCompanionMethods(recorder, defaultMethods, "$Companion"); err != nil {
+		t.Fatal(err)
+	}
+	// The super interface call is rewritten to a call to the companion method.
+	recorder.AssertTrace(t, []string{
+		`class visit method  9 "m" "(Lp/I;)I" "" []`,
+		`method visit code .m(Lp/I;)I`,
+		`method visit insn .m(Lp/I;)I 4`,
+		`method visit insn .m(Lp/I;)I 172`,
+		`method visit maxs .m(Lp/I;)I 1 1`,
+		`method visit end .m(Lp/I;)I`,
+		`class visit method  9 "n" "(Lp/I;)I" "" []`,
+		`method visit code .n(Lp/I;)I`,
+		`method visit var insn .n(Lp/I;)I 25 0`,
+		`method visit method insn .n(Lp/I;)I 184 "p/I$Companion" "m" "(Lp/I;)I" false`,
+		`method visit insn .n(Lp/I;)I 172`,
+		`method visit maxs .n(Lp/I;)I 1 1`,
+		`method visit end .n(Lp/I;)I`,
+	})
+}
+
+func TestWriteCompanionMethodsRejectedBodies(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		d       func(methodVisitor asm.MethodVisitor)
+		message string
+	}{
+		{"invokedynamic", func(methodVisitor asm.MethodVisitor) {
+			bootstrapMethod := asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/LambdaMetafactory", "metafactory",
+				"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+					"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)"+
+					"Ljava/lang/invoke/CallSite;", false)
+			methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+			methodVisitor.VisitInvokeDynamicInsn("run", "(Lp/I;)Ljava/lang/Runnable;", bootstrapMethod,
+				asm.GetMethodType("()V"), asm.NewHandle(opcodes.H_INVOKEINTERFACE, "p/I", "lambda$d$0", "()V", true),
+				asm.GetMethodType("()V"))
+			methodVisitor.VisitInsn(opcodes.POP)
+		}, "uses invokedynamic"},
+		{"invokeinterface", func(methodVisitor asm.MethodVisitor) {
+			methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKEINTERFACE, "p/I", "lambda$d$0", "()V", true)
+		}, "uses the private interface method lambda$d$0()V"},
+		{"invokespecial", func(methodVisitor asm.MethodVisitor) {
+			methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, "p/I", "lambda$d$0", "()V", true)
+		}, "uses the private interface method lambda$d$0()V"},
+	} {
+		defaultMethods, err := commons.ExtractDefaultMethods(defaultMethodsInterface(test.d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := asmtest.NewRecorder(nil)
+		err = commons.WriteCompanionMethods(recorder, defaultMethods, "$Companion")
+		if err == nil || !strings.Contains(err.Error(), "p/I.d()V") || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		// No method is visited when a body is rejected.
+		if len(recorder.Trace()) != 0 {
+			t.Errorf("%s: unexpected events %v", test.name, recorder.Trace())
+		}
+	}
+}
+
+// injectedMethods returns the methods added by a {@link DefaultMethodInjector} to a class p/C, which extends p/B
+// and implements the interface of {@link defaultMethodsInterface}, where p/B declares "int m()" with the given
+// access flags. p/B is added to the class hierarchy used by the injector only if addSuperClass is true.
+func injectedMethods(t *testing.T, superAccess int, addSuperClass bool) []string {
+	defaultMethods, err := commons.ExtractDefaultMethods(defaultMethodsInterface(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	superClass := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER|opcodes.ACC_ABSTRACT,
+		"p/B", "p/A")
+	m := superClass.AddMethod(superAccess, "m", "()I", "", nil)
+	if (superAccess & opcodes.ACC_ABSTRACT) == 0 {
+		m.VisitCode()
+		m.VisitInsn(opcodes.ICONST_2)
+		m.VisitInsn(opcodes.IRETURN)
+		m.VisitMaxs(1, 1)
+	}
+	m.VisitEnd()
+	// The implementations of the super classes of p/B are found too.
+	indirectSuperClass := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/A",
+		"java/lang/Object")
+	n := indirectSuperClass.AddMethod(opcodes.ACC_PUBLIC, "n", "()I", "", nil)
+	n.VisitCode()
+	n.VisitInsn(opcodes.ICONST_3)
+	n.VisitInsn(opcodes.IRETURN)
+	n.VisitMaxs(1, 1)
+	n.VisitEnd()
+	hierarchy := commons.NewClassHierarchy()
+	if err := hierarchy.AddClass(indirectSuperClass.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if addSuperClass {
+		if err := hierarchy.AddClass(superClass.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "p/B", "p/I")
+	reader, err := asm.NewClassReader(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(commons.NewDefaultMethodInjector(recorder, defaultMethods, "", hierarchy), 0)
+	var methods []string
+	for _, line := range recorder.Trace() {
+		if strings.HasPrefix(line, "class visit method ") {
+			methods = append(methods, line)
+		}
+	}
+	return methods
+}
+
+func TestDefaultMethodInjectorInheritedImplementations(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		superAccess   int
+		addSuperClass bool
+		expected      []string
+	}{
+		{"inherited", opcodes.ACC_PUBLIC, true, nil},
+		{"abstract", opcodes.ACC_PUBLIC | opcodes.ACC_ABSTRACT, true, []string{
+			`class visit method p/C 1 "m" "()I" "" []`,
+		}},
+		{"private", opcodes.ACC_PRIVATE, true, []string{
+			`class visit method p/C 1 "m" "()I" "" []`,
+		}},
+		{"static", opcodes.ACC_PUBLIC | opcodes.ACC_STATIC, true, []string{
+			`class visit method p/C 1 "m" "()I" "" []`,
+		}},
+		// Without p/B, the super classes of p/C, including p/A, are unknown.
+		{"unknown", opcodes.ACC_PUBLIC, false, []string{
+			`class visit method p/C 1 "m" "()I" "" []`,
+			`class visit method p/C 1 "n" "()I" "" []`,
+		}},
+	} {
+		if actual := injectedMethods(t, test.superAccess, test.addSuperClass); strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%s: unexpected injected methods:\n%s", test.name, strings.Join(actual, "\n"))
+		}
+	}
+}
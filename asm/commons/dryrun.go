@@ -0,0 +1,107 @@
+package commons
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// DiffClass writes to the given writer a human readable diff between the given old and new versions of a class,
+// and returns whether they differ. The class header and fields, then each added, removed or changed method, are
+// written as a textual disassembly (see {@link tree.InsnToString}), where the removed lines are prefixed with
+// '-', the added lines with '+', and the unchanged lines with ' '. The unchanged methods are not written. The
+// labels are named in declaration order, so that they do not produce spurious differences.
+func DiffClass(writer io.Writer, name string, oldClassFile, newClassFile []byte) (bool, error) {
+	oldClass, err := tree.ReadClassNode(oldClassFile, asm.EXPAND_FRAMS)
+	if err != nil {
+		return false, err
+	}
+	newClass, err := tree.ReadClassNode(newClassFile, asm.EXPAND_FRAMS)
+	if err != nil {
+		return false, err
+	}
+	var diff strings.Builder
+	writeDiff(&diff, "class "+newClass.Name, classHeaderText(oldClass), classHeaderText(newClass))
+	oldMethods := make(map[string]*tree.MethodNode, len(oldClass.Methods))
+	for _, method := range oldClass.Methods {
+		oldMethods[method.Name+method.Descriptor] = method
+	}
+	for _, method := range newClass.Methods {
+		key := method.Name + method.Descriptor
+		var oldText []string
+		if oldMethod, ok := oldMethods[key]; ok {
+			oldText = methodText(oldMethod)
+			delete(oldMethods, key)
+		}
+		writeDiff(&diff, "method "+key, oldText, methodText(method))
+	}
+	for _, method := range oldClass.Methods {
+		if _, ok := oldMethods[method.Name+method.Descriptor]; ok {
+			writeDiff(&diff, "method "+method.Name+method.Descriptor, methodText(method), nil)
+		}
+	}
+	if diff.Len() == 0 {
+		return false, nil
+	}
+	_, err = io.WriteString(writer, "--- a/"+name+"\n+++ b/"+name+"\n"+diff.String())
+	return true, err
+}
+
+// classHeaderText returns the textual representation of the header and of the fields of the given class.
+func classHeaderText(class *tree.ClassNode) []string {
+	header := *class
+	header.Methods = nil
+	return strings.Split(strings.TrimSuffix(tree.Textify(&header), "\n"), "\n")
+}
+
+// methodText returns the textual representation of the given method, with one line per instruction, try catch
+// block and local variable.
+func methodText(method *tree.MethodNode) []string {
+	text := []string{"access 0x" + strconv.FormatInt(int64(method.Access), 16)}
+	text = append(text, methodBody(method)...)
+	if len(method.Instructions) > 0 {
+		text = append(text, "MAXSTACK = "+strconv.Itoa(method.MaxStack), "MAXLOCALS = "+strconv.Itoa(method.MaxLocals))
+	}
+	return text
+}
+
+// writeDiff writes the line diff between the given old and new lines, under the given title, if they differ. The
+// diff is computed with a longest common subsequence, and all the lines are written, with their prefix.
+func writeDiff(diff *strings.Builder, title string, oldLines, newLines []string) {
+	// common[i][j] the length of the longest common subsequence of oldLines[i:] and newLines[j:].
+	common := make([][]int, len(oldLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	if common[0][0] == len(oldLines) && common[0][0] == len(newLines) {
+		return
+	}
+	diff.WriteString("@@ " + title + " @@\n")
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			diff.WriteString("  " + oldLines[i] + "\n")
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || common[i+1][j] >= common[i][j+1]):
+			diff.WriteString("- " + oldLines[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+ " + newLines[j] + "\n")
+			j++
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package commons_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// changedClass returns the class of {@link hotSwapClass} with a new field, its method "m" changed, its method
+// "n" removed, and a new method "o".
+func changedClass() []byte {
+	return hotSwapClass(func(spec *hotSwapSpec) {
+		spec.fields = append(spec.fields, hotSwapMember{opcodes.ACC_PRIVATE, "g", "J", 0})
+		spec.methods = []hotSwapMember{{opcodes.ACC_PUBLIC, "m", "()I", 3}, {opcodes.ACC_STATIC, "o", "()I", 4}}
+	})
+}
+
+func TestDiffClass(t *testing.T) {
+	var output bytes.Buffer
+	changed, err := commons.DiffClass(&output, "p/C.class", hotSwapClass(nil), changedClass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("the classes should differ")
+	}
+	assertTrace(t, strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n"), []string{
+		`--- a/p/C.class`,
+		`+++ b/p/C.class`,
+		`@@ class p/C @@`,
+		`  class p/C extends java/lang/Object // access 0x21`,
+		`  `,
+		`    field f I // access 0x2`,
+		`+ `,
+		`+   field g J // access 0x2`,
+		`@@ method m()I @@`,
+		`  access 0x1`,
+		`- BIPUSH 1`,
+		`+ BIPUSH 3`,
+		`  IRETURN`,
+		`  MAXSTACK = 1`,
+		`  MAXLOCALS = 1`,
+		`@@ method o()I @@`,
+		`+ access 0x8`,
+		`+ BIPUSH 4`,
+		`+ IRETURN`,
+		`+ MAXSTACK = 1`,
+		`+ MAXLOCALS = 1`,
+		`@@ method n()I @@`,
+		`- access 0x1`,
+		`- BIPUSH 2`,
+		`- IRETURN`,
+		`- MAXSTACK = 1`,
+		`- MAXLOCALS = 1`,
+	})
+}
+
+func TestDiffClassUnchanged(t *testing.T) {
+	// A different constant pool order doesn't produce any difference.
+	var output bytes.Buffer
+	changed, err := commons.DiffClass(&output, "p/C.class", hotSwapClass(nil),
+		hotSwapClass(func(spec *hotSwapSpec) { spec.constant = "unused" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || output.Len() != 0 {
+		t.Errorf("unexpected diff %s", output.String())
+	}
+}
+
+func TestTransformJarDryRun(t *testing.T) {
+	directory := t.TempDir()
+	in := filepath.Join(directory, "in.jar")
+	out := filepath.Join(directory, "out.jar")
+	err := os.WriteFile(in, writeJar(t,
+		jarEntry{"p/C.class", hotSwapClass(nil), zip.Deflate},
+		jarEntry{"p/D.class", hierarchyClass(t, opcodes.ACC_PUBLIC, "p/D", "java/lang/Object", false), zip.Deflate},
+		jarEntry{"p/resource.txt", []byte("text"), zip.Deflate},
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only p/C is changed, p/D is transformed without changes.
+	factory := func(className string) *commons.ClassVisitorChain {
+		return &commons.ClassVisitorChain{Transform: func(classFile []byte) ([]byte, error) {
+			if className == "p/C" {
+				return hotSwapClass(func(spec *hotSwapSpec) { spec.methods[0].value = 3 }), nil
+			}
+			return classFile, nil
+		}}
+	}
+	var dryRun bytes.Buffer
+	count, err := commons.TransformJar(in, out, factory, commons.TransformJarOptions{DryRun: &dryRun})
+	if err != nil || count != 2 {
+		t.Fatalf("unexpected result %d %v", count, err)
+	}
+	assertTrace(t, strings.Split(strings.TrimSuffix(dryRun.String(), "\n"), "\n"), []string{
+		`--- a/p/C.class`,
+		`+++ b/p/C.class`,
+		`@@ method m()I @@`,
+		`  access 0x1`,
+		`- BIPUSH 1`,
+		`+ BIPUSH 3`,
+		`  IRETURN`,
+		`  MAXSTACK = 1`,
+		`  MAXLOCALS = 1`,
+	})
+	// Nothing is written, not even a temporary file.
+	if files, _ := filepath.Glob(filepath.Join(directory, "out.jar*")); len(files) != 0 {
+		t.Errorf("unexpected output files %v", files)
+	}
+}
@@ -0,0 +1,270 @@
+package commons
+
+import (
+	"archive/zip"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// EntryPointClass the information about a class needed by the {@link EntryPointRule}s.
+type EntryPointClass struct {
+	Access     int
+	Name       string
+	SuperName  string
+	Interfaces []string
+	// Annotations the descriptors of the annotations of the class.
+	Annotations []string
+	Methods     []*EntryPointMethod
+}
+
+// EntryPointMethod the information about a method needed by the {@link EntryPointRule}s.
+type EntryPointMethod struct {
+	Access     int
+	Name       string
+	Descriptor string
+	// Annotations the descriptors of the annotations of the method.
+	Annotations []string
+}
+
+// HasAnnotation returns whether the method has one of the given annotations (given by their descriptor).
+func (e *EntryPointMethod) HasAnnotation(descriptors ...string) bool {
+	return containsAny(e.Annotations, descriptors)
+}
+
+// HasAnnotation returns whether the class has one of the given annotations (given by their descriptor).
+func (e *EntryPointClass) HasAnnotation(descriptors ...string) bool {
+	return containsAny(e.Annotations, descriptors)
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EntryPointRule a rule selecting the entry point methods of a program. Match is called for each non abstract
+// method, with the finder, which gives access to the class hierarchy.
+type EntryPointRule struct {
+	Name  string
+	Match func(finder *EntryPointFinder, class *EntryPointClass, method *EntryPointMethod) bool
+}
+
+// EntryPoint a method selected by an {@link EntryPointRule}.
+type EntryPoint struct {
+	Owner      string
+	Name       string
+	Descriptor string
+	// Rules the names of the rules which selected the method.
+	Rules []string
+}
+
+func (e EntryPoint) String() string {
+	return e.Owner + "." + e.Name + e.Descriptor + " [" + strings.Join(e.Rules, ", ") + "]"
+}
+
+// MAIN_METHOD_RULE selects the public static void main(String[]) methods.
+var MAIN_METHOD_RULE = EntryPointRule{"main", func(finder *EntryPointFinder, class *EntryPointClass, method *EntryPointMethod) bool {
+	return method.Name == "main" && method.Descriptor == "([Ljava/lang/String;)V" &&
+		(method.Access&(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC)) == opcodes.ACC_PUBLIC|opcodes.ACC_STATIC
+}}
+
+// SERVLET_RULE selects the request handling methods (service and doXxx) of the javax and jakarta servlets.
+var SERVLET_RULE = EntryPointRule{"servlet", func(finder *EntryPointFinder, class *EntryPointClass, method *EntryPointMethod) bool {
+	if method.Name != "service" && !(strings.HasPrefix(method.Name, "do") && len(method.Name) > 2) {
+		return false
+	}
+	return finder.IsSubtypeOf(class.Name, "javax/servlet/Servlet") ||
+		finder.IsSubtypeOf(class.Name, "jakarta/servlet/Servlet") ||
+		finder.IsSubtypeOf(class.Name, "javax/servlet/http/HttpServlet") ||
+		finder.IsSubtypeOf(class.Name, "jakarta/servlet/http/HttpServlet")
+}}
+
+var jaxRsAnnotations = func() []string {
+	var descriptors []string
+	for _, pkg := range []string{"javax/ws/rs/", "jakarta/ws/rs/"} {
+		for _, name := range []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "Path"} {
+			descriptors = append(descriptors, "L"+pkg+name+";")
+		}
+	}
+	return descriptors
+}()
+
+// JAX_RS_RULE selects the JAX-RS resource methods, annotated with @Path or an HTTP method annotation.
+var JAX_RS_RULE = EntryPointRule{"jax-rs", func(finder *EntryPointFinder, class *EntryPointClass, method *EntryPointMethod) bool {
+	return method.HasAnnotation(jaxRsAnnotations...)
+}}
+
+// TEST_RULE selects the JUnit 4, JUnit 5 and TestNG test and lifecycle methods.
+var TEST_RULE = EntryPointRule{"test", func(finder *EntryPointFinder, class *EntryPointClass, method *EntryPointMethod) bool {
+	return method.HasAnnotation("Lorg/junit/Test;", "Lorg/junit/Before;", "Lorg/junit/After;",
+		"Lorg/junit/BeforeClass;", "Lorg/junit/AfterClass;", "Lorg/junit/jupiter/api/Test;",
+		"Lorg/junit/jupiter/params/ParameterizedTest;", "Lorg/junit/jupiter/api/RepeatedTest;",
+		"Lorg/junit/jupiter/api/TestFactory;", "Lorg/junit/jupiter/api/BeforeEach;",
+		"Lorg/junit/jupiter/api/AfterEach;", "Lorg/junit/jupiter/api/BeforeAll;", "Lorg/junit/jupiter/api/AfterAll;",
+		"Lorg/testng/annotations/Test;", "Lorg/testng/annotations/BeforeMethod;",
+		"Lorg/testng/annotations/AfterMethod;", "Lorg/testng/annotations/BeforeClass;",
+		"Lorg/testng/annotations/AfterClass;")
+}}
+
+// DefaultEntryPointRules returns the main method, servlet, JAX-RS and test rules.
+func DefaultEntryPointRules() []EntryPointRule {
+	return []EntryPointRule{MAIN_METHOD_RULE, SERVLET_RULE, JAX_RS_RULE, TEST_RULE}
+}
+
+// EntryPointFinder finds the entry points of a program, i.e. the methods which are called from outside of the
+// program (by the JVM launcher, a container or a test framework), to seed whole program reachability analyses.
+// The classes of the program are added with {@link AddClass} or {@link AddJar}, then the entry points are
+// computed with {@link Find}, using the rules of the finder.
+type EntryPointFinder struct {
+	Rules   []EntryPointRule
+	classes map[string]*EntryPointClass
+}
+
+// NewEntryPointFinder constructs a new {@link EntryPointFinder} with the given rules.
+func NewEntryPointFinder(rules []EntryPointRule) *EntryPointFinder {
+	return &EntryPointFinder{Rules: rules, classes: make(map[string]*EntryPointClass)}
+}
+
+// AddClass adds the given class file to the analyzed program.
+func (e *EntryPointFinder) AddClass(classFile []byte) error {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return err
+	}
+	collector := &entryPointCollector{class: &EntryPointClass{}}
+	reader.Accept(collector, asm.SKIP_CODE|asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+	e.classes[collector.class.Name] = collector.class
+	return nil
+}
+
+// AddJar adds the class files of the given jar (or zip) file to the analyzed program.
+func (e *EntryPointFinder) AddJar(path string) error {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer jar.Close()
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || strings.HasSuffix(file.Name, "module-info.class") {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+		if err := e.AddClass(classFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetClass returns the class of the analyzed program with the given internal name, or nil.
+func (e *EntryPointFinder) GetClass(name string) *EntryPointClass {
+	return e.classes[name]
+}
+
+// IsSubtypeOf returns whether the given class extends or implements the given type, directly or indirectly,
+// through the classes of the analyzed program. Classes outside of the program are only known by name.
+func (e *EntryPointFinder) IsSubtypeOf(name, superType string) bool {
+	visited := make(map[string]bool)
+	var isSubtype func(name string) bool
+	isSubtype = func(name string) bool {
+		if name == superType {
+			return true
+		}
+		class := e.classes[name]
+		if class == nil || visited[name] {
+			return false
+		}
+		visited[name] = true
+		if class.SuperName != "" && isSubtype(class.SuperName) {
+			return true
+		}
+		for _, itf := range class.Interfaces {
+			if isSubtype(itf) {
+				return true
+			}
+		}
+		return false
+	}
+	return name != superType && isSubtype(name)
+}
+
+// Find returns the entry points of the analyzed program, sorted by owner, name and descriptor.
+func (e *EntryPointFinder) Find() []EntryPoint {
+	var entryPoints []EntryPoint
+	for _, class := range e.classes {
+		for _, method := range class.Methods {
+			if (method.Access & opcodes.ACC_ABSTRACT) != 0 {
+				continue
+			}
+			var rules []string
+			for _, rule := range e.Rules {
+				if rule.Match(e, class, method) {
+					rules = append(rules, rule.Name)
+				}
+			}
+			if len(rules) > 0 {
+				entryPoints = append(entryPoints, EntryPoint{class.Name, method.Name, method.Descriptor, rules})
+			}
+		}
+	}
+	sort.Slice(entryPoints, func(i, j int) bool {
+		if entryPoints[i].Owner != entryPoints[j].Owner {
+			return entryPoints[i].Owner < entryPoints[j].Owner
+		}
+		if entryPoints[i].Name != entryPoints[j].Name {
+			return entryPoints[i].Name < entryPoints[j].Name
+		}
+		return entryPoints[i].Descriptor < entryPoints[j].Descriptor
+	})
+	return entryPoints
+}
+
+// entryPointCollector a {@link ClassVisitor} which collects the {@link EntryPointClass} of a class.
+type entryPointCollector struct {
+	helper.ClassVisitor
+	class *EntryPointClass
+}
+
+func (e *entryPointCollector) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	e.class.Access, e.class.Name, e.class.SuperName, e.class.Interfaces = access, name, superName, interfaces
+}
+
+func (e *entryPointCollector) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	e.class.Annotations = append(e.class.Annotations, descriptor)
+	return nil
+}
+
+func (e *entryPointCollector) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	method := &EntryPointMethod{Access: access, Name: name, Descriptor: descriptor}
+	e.class.Methods = append(e.class.Methods, method)
+	return &entryPointMethodCollector{method: method}
+}
+
+// entryPointMethodCollector a {@link MethodVisitor} which collects the annotations of a method.
+type entryPointMethodCollector struct {
+	helper.MethodVisitor
+	method *EntryPointMethod
+}
+
+func (e *entryPointMethodCollector) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	e.method.Annotations = append(e.method.Annotations, descriptor)
+	return nil
+}
@@ -0,0 +1,139 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// entryPointMethod a method of a class built by {@link entryPointClass}, with its annotation descriptors.
+type entryPointMethod struct {
+	access      int
+	name        string
+	descriptor  string
+	annotations []string
+}
+
+// entryPointClass returns a class with the given name, super class, class annotation (or "" for none) and
+// methods.
+func entryPointClass(name, superName, annotation string, methods ...entryPointMethod) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, name, superName)
+	for _, method := range methods {
+		methodWriter := classFile.AddMethod(method.access, method.name, method.descriptor, "", nil)
+		for _, descriptor := range method.annotations {
+			methodWriter.VisitAnnotation(descriptor, true).VisitEnd()
+		}
+		if (method.access & opcodes.ACC_ABSTRACT) == 0 {
+			methodWriter.VisitCode()
+			methodWriter.VisitInsn(opcodes.RETURN)
+			methodWriter.VisitMaxs(0, 2)
+		}
+		methodWriter.VisitEnd()
+	}
+	if annotation != "" {
+		// A RuntimeVisibleAnnotations attribute with one annotation without elements.
+		typeIndex := classFile.SymbolTable.AddConstantUtf8(annotation)
+		classFile.AddAttribute("RuntimeVisibleAnnotations", []byte{0, 1, byte(typeIndex >> 8), byte(typeIndex), 0, 0})
+	}
+	return classFile.Bytes()
+}
+
+// entryPointProgram the classes of the program analyzed by the {@link EntryPointFinder} tests.
+func entryPointProgram() [][]byte {
+	public, static := opcodes.ACC_PUBLIC, opcodes.ACC_PUBLIC|opcodes.ACC_STATIC
+	return [][]byte{
+		entryPointClass("p/App", "java/lang/Object", "",
+			entryPointMethod{static, "main", "([Ljava/lang/String;)V", nil},
+			entryPointMethod{opcodes.ACC_PRIVATE | opcodes.ACC_STATIC, "main", "([Ljava/lang/String;)V", nil},
+			entryPointMethod{public, "main", "([Ljava/lang/String;)V", nil},
+			entryPointMethod{static, "main", "()V", nil}),
+		// p/MyServlet is an indirect subclass of HttpServlet.
+		entryPointClass("p/BaseServlet", "javax/servlet/http/HttpServlet", ""),
+		entryPointClass("p/MyServlet", "p/BaseServlet", "",
+			entryPointMethod{public, "doGet", "(Ljavax/servlet/http/HttpServletRequest;Ljavax/servlet/http/HttpServletResponse;)V", nil},
+			entryPointMethod{public, "service", "(Ljavax/servlet/ServletRequest;Ljavax/servlet/ServletResponse;)V", nil},
+			entryPointMethod{public, "do", "()V", nil},
+			entryPointMethod{public, "compute", "()V", nil}),
+		entryPointClass("p/NotAServlet", "java/lang/Object", "",
+			entryPointMethod{public, "doGet", "()V", nil}),
+		entryPointClass("p/Resource", "java/lang/Object", "",
+			entryPointMethod{public, "list", "()Ljava/util/List;", []string{"Ljavax/ws/rs/GET;"}},
+			entryPointMethod{public, "find", "(I)Ljava/lang/Object;", []string{"Ljakarta/ws/rs/Path;"}},
+			entryPointMethod{public, "helper", "()V", []string{"Ljava/lang/Deprecated;"}}),
+		entryPointClass("p/Tests", "java/lang/Object", "",
+			entryPointMethod{public, "setUp", "()V", []string{"Lorg/junit/jupiter/api/BeforeEach;"}},
+			entryPointMethod{public, "test", "()V", []string{"Lorg/junit/Test;"}},
+			entryPointMethod{public | opcodes.ACC_ABSTRACT, "abstractTest", "()V", []string{"Lorg/junit/Test;"}}),
+		entryPointClass("p/Component", "java/lang/Object", "Lp/Component;",
+			entryPointMethod{public, "<init>", "()V", nil},
+			entryPointMethod{static, "main", "([Ljava/lang/String;)V", nil}),
+	}
+}
+
+// findEntryPoints returns the entry points of the {@link entryPointProgram}, selected by the given rules.
+func findEntryPoints(t *testing.T, rules []commons.EntryPointRule) []string {
+	finder := commons.NewEntryPointFinder(rules)
+	for _, classFile := range entryPointProgram() {
+		if err := finder.AddClass(classFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var entryPoints []string
+	for _, entryPoint := range finder.Find() {
+		entryPoints = append(entryPoints, entryPoint.String())
+	}
+	return entryPoints
+}
+
+func TestEntryPointFinderDefaultRules(t *testing.T) {
+	assertTrace(t, findEntryPoints(t, commons.DefaultEntryPointRules()), []string{
+		`p/App.main([Ljava/lang/String;)V [main]`,
+		`p/Component.main([Ljava/lang/String;)V [main]`,
+		`p/MyServlet.doGet(Ljavax/servlet/http/HttpServletRequest;Ljavax/servlet/http/HttpServletResponse;)V [servlet]`,
+		`p/MyServlet.service(Ljavax/servlet/ServletRequest;Ljavax/servlet/ServletResponse;)V [servlet]`,
+		`p/Resource.find(I)Ljava/lang/Object; [jax-rs]`,
+		`p/Resource.list()Ljava/util/List; [jax-rs]`,
+		`p/Tests.setUp()V [test]`,
+		`p/Tests.test()V [test]`,
+	})
+}
+
+func TestEntryPointFinderCustomRules(t *testing.T) {
+	// The constructors of the classes annotated with @Component, which are also selected with the main methods.
+	componentRule := commons.EntryPointRule{Name: "component", Match: func(finder *commons.EntryPointFinder,
+		class *commons.EntryPointClass, method *commons.EntryPointMethod) bool {
+		return class.HasAnnotation("Lp/Component;") && (method.Name == "<init>" || method.Name == "main")
+	}}
+	assertTrace(t, findEntryPoints(t, []commons.EntryPointRule{commons.MAIN_METHOD_RULE, componentRule}), []string{
+		`p/App.main([Ljava/lang/String;)V [main]`,
+		`p/Component.<init>()V [component]`,
+		`p/Component.main([Ljava/lang/String;)V [main, component]`,
+	})
+
+}
+
+func TestEntryPointFinderIsSubtypeOf(t *testing.T) {
+	finder := commons.NewEntryPointFinder(nil)
+	for _, classFile := range entryPointProgram() {
+		if err := finder.AddClass(classFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		name, superType string
+		expected        bool
+	}{
+		{"p/MyServlet", "p/BaseServlet", true},
+		{"p/MyServlet", "javax/servlet/http/HttpServlet", true},
+		// HttpServlet is not in the program, so its super classes are unknown.
+		{"p/MyServlet", "java/lang/Object", false},
+		{"p/MyServlet", "p/MyServlet", false},
+		{"p/Unknown", "java/lang/Object", false},
+	} {
+		if actual := finder.IsSubtypeOf(test.name, test.superType); actual != test.expected {
+			t.Errorf("IsSubtypeOf(%s, %s) = %v", test.name, test.superType, actual)
+		}
+	}
+}
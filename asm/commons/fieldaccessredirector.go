@@ -0,0 +1,134 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// FieldRedirect the static accessor methods replacing the accesses to a field. The getter of an instance
+// field takes the instance as argument, and its setter the instance followed by the new value; the
+// accessors of a static field take no instance. An empty getter or setter name leaves the corresponding
+// accesses unchanged.
+type FieldRedirect struct {
+	Owner         string
+	Name          string
+	AccessorOwner string
+	GetterName    string
+	SetterName    string
+}
+
+// FieldAccessRedirector a {@link ClassVisitor} that replaces the GETFIELD, PUTFIELD, GETSTATIC and PUTSTATIC
+// instructions on the redirected fields with INVOKESTATIC calls to their accessors or, if constructed with
+// {@link NewFieldAccessInliner}, the calls to the accessors with the corresponding field instructions. The
+// accessor methods themselves are left unchanged.
+type FieldAccessRedirector struct {
+	helper.ClassAdapter
+	fields    map[string]*FieldRedirect
+	accessors map[string]*FieldRedirect
+	inline    bool
+	className string
+}
+
+// NewFieldAccessRedirector constructs a {@link FieldAccessRedirector} replacing field accesses with accessor calls.
+func NewFieldAccessRedirector(classVisitor asm.ClassVisitor, redirects []FieldRedirect) *FieldAccessRedirector {
+	return newFieldAccessRedirector(classVisitor, redirects, false)
+}
+
+// NewFieldAccessInliner constructs a {@link FieldAccessRedirector} replacing accessor calls with field accesses.
+func NewFieldAccessInliner(classVisitor asm.ClassVisitor, redirects []FieldRedirect) *FieldAccessRedirector {
+	return newFieldAccessRedirector(classVisitor, redirects, true)
+}
+
+func newFieldAccessRedirector(classVisitor asm.ClassVisitor, redirects []FieldRedirect, inline bool) *FieldAccessRedirector {
+	fields := make(map[string]*FieldRedirect, len(redirects))
+	accessors := make(map[string]*FieldRedirect, 2*len(redirects))
+	for i := range redirects {
+		redirect := &redirects[i]
+		fields[redirect.Owner+"."+redirect.Name] = redirect
+		if redirect.GetterName != "" {
+			accessors[redirect.AccessorOwner+"."+redirect.GetterName] = redirect
+		}
+		if redirect.SetterName != "" {
+			accessors[redirect.AccessorOwner+"."+redirect.SetterName] = redirect
+		}
+	}
+	return &FieldAccessRedirector{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		fields:       fields,
+		accessors:    accessors,
+		inline:       inline,
+	}
+}
+
+func (f *FieldAccessRedirector) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	f.className = name
+	f.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (f *FieldAccessRedirector) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	methodVisitor := f.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil {
+		return nil
+	}
+	if _, isAccessor := f.accessors[f.className+"."+name]; isAccessor {
+		return methodVisitor
+	}
+	return &fieldAccessRewriter{MethodAdapter: helper.MethodAdapter{Next: methodVisitor}, redirector: f}
+}
+
+type fieldAccessRewriter struct {
+	helper.MethodAdapter
+	redirector *FieldAccessRedirector
+}
+
+func (f *fieldAccessRewriter) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	redirect, ok := f.redirector.fields[owner+"."+name]
+	if f.redirector.inline || !ok {
+		f.MethodAdapter.VisitFieldInsn(opcode, owner, name, descriptor)
+		return
+	}
+	instance := ""
+	if opcode == opcodes.GETFIELD || opcode == opcodes.PUTFIELD {
+		instance = "L" + owner + ";"
+	}
+	switch {
+	case (opcode == opcodes.GETFIELD || opcode == opcodes.GETSTATIC) && redirect.GetterName != "":
+		f.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, redirect.AccessorOwner, redirect.GetterName, "("+instance+")"+descriptor, false)
+	case (opcode == opcodes.PUTFIELD || opcode == opcodes.PUTSTATIC) && redirect.SetterName != "":
+		f.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, redirect.AccessorOwner, redirect.SetterName, "("+instance+descriptor+")V", false)
+	default:
+		f.MethodAdapter.VisitFieldInsn(opcode, owner, name, descriptor)
+	}
+}
+
+func (f *fieldAccessRewriter) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	redirect, ok := f.redirector.accessors[owner+"."+name]
+	if !f.redirector.inline || !ok || opcode != opcodes.INVOKESTATIC {
+		f.MethodAdapter.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+		return
+	}
+	methodType := asm.GetMethodType(descriptor)
+	argumentTypes := methodType.GetArgumentTypes()
+	if name == redirect.GetterName && len(argumentTypes) <= 1 {
+		if len(argumentTypes) == 0 {
+			f.MethodAdapter.VisitFieldInsn(opcodes.GETSTATIC, redirect.Owner, redirect.Name, methodType.GetReturnType().GetDescriptor())
+		} else {
+			f.MethodAdapter.VisitFieldInsn(opcodes.GETFIELD, redirect.Owner, redirect.Name, methodType.GetReturnType().GetDescriptor())
+		}
+		return
+	}
+	if name == redirect.SetterName && len(argumentTypes) >= 1 && len(argumentTypes) <= 2 {
+		if len(argumentTypes) == 1 {
+			f.MethodAdapter.VisitFieldInsn(opcodes.PUTSTATIC, redirect.Owner, redirect.Name, argumentTypes[0].GetDescriptor())
+		} else {
+			f.MethodAdapter.VisitFieldInsn(opcodes.PUTFIELD, redirect.Owner, redirect.Name, argumentTypes[1].GetDescriptor())
+		}
+		return
+	}
+	f.MethodAdapter.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+}
+
+func (f *fieldAccessRewriter) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	f.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
@@ -0,0 +1,118 @@
+package commons_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// transformedTrace returns the trace of the events of the given method (given by its name and descriptor) of the
+// given class, transformed by the class visitor returned by transformer.
+func transformedTrace(t *testing.T, classFile []byte, method string, transformer func(next asm.ClassVisitor) asm.ClassVisitor) []string {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(transformer(recorder), 0)
+	name := method[:strings.Index(method, "(")]
+	descriptor := method[len(name):]
+	var trace []string
+	for _, line := range recorder.Trace() {
+		if strings.Contains(line, "."+method+" ") || strings.HasSuffix(line, "."+method) ||
+			strings.HasPrefix(line, "class visit method ") && strings.Contains(line, `"`+name+`" "`+descriptor+`"`) {
+			trace = append(trace, line)
+		}
+	}
+	return trace
+}
+
+// assertTrace checks that the given trace is the expected one.
+func assertTrace(t *testing.T, trace, expected []string) {
+	t.Helper()
+	if strings.Join(trace, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(trace, "\n"))
+	}
+}
+
+// redirectorClass returns a class p/C with the method "void use(A a)", which reads and writes the field a.x and
+// reads the static field A.s, and with the accessor "static int getX(A a)".
+func redirectorClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	use := classFile.AddMethod(opcodes.ACC_PUBLIC, "use", "(Lp/A;)V", "", nil)
+	use.VisitCode()
+	use.VisitVarInsn(opcodes.ALOAD, 1)
+	use.VisitFieldInsn(opcodes.GETFIELD, "p/A", "x", "I")
+	use.VisitInsn(opcodes.POP)
+	use.VisitVarInsn(opcodes.ALOAD, 1)
+	use.VisitInsn(opcodes.ICONST_1)
+	use.VisitFieldInsn(opcodes.PUTFIELD, "p/A", "x", "I")
+	use.VisitFieldInsn(opcodes.GETSTATIC, "p/A", "s", "J")
+	use.VisitInsn(opcodes.POP2)
+	use.VisitInsn(opcodes.LCONST_1)
+	use.VisitFieldInsn(opcodes.PUTSTATIC, "p/A", "s", "J")
+	use.VisitInsn(opcodes.RETURN)
+	use.VisitMaxs(2, 2)
+	use.VisitEnd()
+
+	getX := classFile.AddMethod(opcodes.ACC_STATIC, "getX", "(Lp/A;)I", "", nil)
+	getX.VisitCode()
+	getX.VisitVarInsn(opcodes.ALOAD, 0)
+	getX.VisitFieldInsn(opcodes.GETFIELD, "p/A", "x", "I")
+	getX.VisitInsn(opcodes.IRETURN)
+	getX.VisitMaxs(1, 1)
+	getX.VisitEnd()
+	return classFile.Bytes()
+}
+
+func TestFieldAccessRedirector(t *testing.T) {
+	redirects := []commons.FieldRedirect{
+		{Owner: "p/A", Name: "x", AccessorOwner: "p/C", GetterName: "getX", SetterName: "setX"},
+		{Owner: "p/A", Name: "s", AccessorOwner: "p/C", GetterName: "getS"},
+	}
+	redirect := func(next asm.ClassVisitor) asm.ClassVisitor {
+		return commons.NewFieldAccessRedirector(next, redirects)
+	}
+	// The accesses to x and the reads of s are replaced, but not the write of s, which has no setter.
+	redirected := []string{
+		`class visit method p/C 1 "use" "(Lp/A;)V" "" []`,
+		`method visit code p/C.use(Lp/A;)V`,
+		`method visit var insn p/C.use(Lp/A;)V 25 1`,
+		`method visit method insn p/C.use(Lp/A;)V 184 "p/C" "getX" "(Lp/A;)I" false`,
+		`method visit insn p/C.use(Lp/A;)V 87`,
+		`method visit var insn p/C.use(Lp/A;)V 25 1`,
+		`method visit insn p/C.use(Lp/A;)V 4`,
+		`method visit method insn p/C.use(Lp/A;)V 184 "p/C" "setX" "(Lp/A;I)V" false`,
+		`method visit method insn p/C.use(Lp/A;)V 184 "p/C" "getS" "()J" false`,
+		`method visit insn p/C.use(Lp/A;)V 88`,
+		`method visit insn p/C.use(Lp/A;)V 10`,
+		`method visit field insn p/C.use(Lp/A;)V 179 "p/A" "s" "J"`,
+		`method visit insn p/C.use(Lp/A;)V 177`,
+		`method visit maxs p/C.use(Lp/A;)V 2 2`,
+		`method visit end p/C.use(Lp/A;)V`,
+	}
+	assertTrace(t, transformedTrace(t, redirectorClass(), "use(Lp/A;)V", redirect), redirected)
+	// The accessor itself is unchanged.
+	assertTrace(t, transformedTrace(t, redirectorClass(), "getX(Lp/A;)I", redirect), []string{
+		`class visit method p/C 8 "getX" "(Lp/A;)I" "" []`,
+		`method visit code p/C.getX(Lp/A;)I`,
+		`method visit var insn p/C.getX(Lp/A;)I 25 0`,
+		`method visit field insn p/C.getX(Lp/A;)I 180 "p/A" "x" "I"`,
+		`method visit insn p/C.getX(Lp/A;)I 172`,
+		`method visit maxs p/C.getX(Lp/A;)I 1 1`,
+		`method visit end p/C.getX(Lp/A;)I`,
+	})
+
+	// Inlining the accessors restores the field instructions.
+	inlined := transformedTrace(t, redirectorClass(), "use(Lp/A;)V", func(next asm.ClassVisitor) asm.ClassVisitor {
+		return redirect(commons.NewFieldAccessInliner(next, redirects))
+	})
+	original := transformedTrace(t, redirectorClass(), "use(Lp/A;)V", func(next asm.ClassVisitor) asm.ClassVisitor {
+		return next
+	})
+	assertTrace(t, inlined, original)
+}
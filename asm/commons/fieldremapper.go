@@ -0,0 +1,28 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// FieldRemapper a {@link FieldVisitor} that remaps types with a {@link Remapper}.
+type FieldRemapper struct {
+	helper.FieldAdapter
+	remapper Remapper
+}
+
+// NewFieldRemapper constructs a new {@link FieldRemapper} delegating to the given visitor.
+func NewFieldRemapper(fieldVisitor asm.FieldVisitor, remapper Remapper) *FieldRemapper {
+	return &FieldRemapper{
+		FieldAdapter: helper.FieldAdapter{Next: fieldVisitor},
+		remapper:     remapper,
+	}
+}
+
+func (f *FieldRemapper) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(f.FieldAdapter.VisitAnnotation(MapDesc(f.remapper, descriptor), visible), f.remapper)
+}
+
+func (f *FieldRemapper) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(f.FieldAdapter.VisitTypeAnnotation(typeRef, typePath, MapDesc(f.remapper, descriptor), visible), f.remapper)
+}
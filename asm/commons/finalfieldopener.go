@@ -0,0 +1,82 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// FieldReport the reason why a selected field has been left unchanged by a transformer.
+type FieldReport struct {
+	Owner  string
+	Name   string
+	Reason string
+}
+
+func (f FieldReport) String() string {
+	return f.Owner + "." + f.Name + ": " + f.Reason
+}
+
+// FinalFieldOpener a {@link ClassVisitor} that clears the ACC_FINAL flag of selected classes and fields, so that
+// test frameworks can subclass them or substitute their values. When a class is selected, its InnerClasses
+// entry is updated too, and all its fields are selected.
+//
+// The static final fields with a ConstantValue attribute are left unchanged, since their value is inlined by
+// compilers in the classes which use them, and the fields of interfaces, which must be final. They are reported
+// in Unchanged. The fields which have been opened are listed in Opened, with the "owner.name" format.
+type FinalFieldOpener struct {
+	helper.ClassAdapter
+	Opened      []string
+	Unchanged   []FieldReport
+	classes     map[string]bool
+	fields      map[string]bool
+	className   string
+	isInterface bool
+}
+
+// NewFinalFieldOpener constructs a new {@link FinalFieldOpener}. The classes are given by their internal name,
+// and the fields with the "owner.name" format.
+func NewFinalFieldOpener(classVisitor asm.ClassVisitor, classes []string, fields []string) *FinalFieldOpener {
+	f := &FinalFieldOpener{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		classes:      make(map[string]bool),
+		fields:       make(map[string]bool),
+	}
+	for _, class := range classes {
+		f.classes[class] = true
+	}
+	for _, field := range fields {
+		f.fields[field] = true
+	}
+	return f
+}
+
+func (f *FinalFieldOpener) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	f.className = name
+	f.isInterface = (access & opcodes.ACC_INTERFACE) != 0
+	if f.classes[name] {
+		access &^= opcodes.ACC_FINAL
+	}
+	f.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (f *FinalFieldOpener) VisitInnerClass(name, outerName, innerName string, access int) {
+	if f.classes[name] {
+		access &^= opcodes.ACC_FINAL
+	}
+	f.ClassAdapter.VisitInnerClass(name, outerName, innerName, access)
+}
+
+func (f *FinalFieldOpener) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	if (access&opcodes.ACC_FINAL) != 0 && (f.classes[f.className] || f.fields[f.className+"."+name]) {
+		if f.isInterface {
+			f.Unchanged = append(f.Unchanged, FieldReport{f.className, name, "interface fields are implicitly final"})
+		} else if (access&opcodes.ACC_STATIC) != 0 && value != nil {
+			f.Unchanged = append(f.Unchanged, FieldReport{f.className, name, "constant value is inlined at use sites"})
+		} else {
+			access &^= opcodes.ACC_FINAL
+			f.Opened = append(f.Opened, f.className+"."+name)
+		}
+	}
+	return f.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+}
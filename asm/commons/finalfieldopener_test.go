@@ -0,0 +1,89 @@
+package commons_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// finalFieldsClass returns a class with the given access and name, final if it is not an interface, with an
+// InnerClasses entry for itself, and the final fields "int a", "int b" and "static final int K = 1".
+func finalFieldsClass(access int, name string) []byte {
+	if (access & opcodes.ACC_INTERFACE) == 0 {
+		access |= opcodes.ACC_FINAL
+	}
+	classFile := asmtest.NewClassFile(opcodes.V1_8, access, name, "java/lang/Object")
+	classFile.AddField(opcodes.ACC_PRIVATE|opcodes.ACC_FINAL, "a", "I", "", nil)
+	classFile.AddField(opcodes.ACC_PRIVATE|opcodes.ACC_FINAL, "b", "I", "", nil)
+	classFile.AddField(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC|opcodes.ACC_FINAL, "K", "I", "", int32(1))
+	entryAccess := opcodes.ACC_STATIC | access
+	content := []byte{0, 1}
+	for _, value := range []int{classFile.SymbolTable.AddConstantClass(name).GetIndex(),
+		classFile.SymbolTable.AddConstantClass("p/Outer").GetIndex(), classFile.SymbolTable.AddConstantUtf8("In"), entryAccess} {
+		content = append(content, byte(value>>8), byte(value))
+	}
+	classFile.AddAttribute("InnerClasses", content)
+	return classFile.Bytes()
+}
+
+// openFields returns the opener which visited the given class, and the recorded events of the opened class.
+func openFields(t *testing.T, classFile []byte, classes, fields []string) (*commons.FinalFieldOpener, *asmtest.Recorder) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	opener := commons.NewFinalFieldOpener(recorder, classes, fields)
+	reader.Accept(opener, 0)
+	return opener, recorder
+}
+
+// fieldAccesses returns the access flags of the visited fields.
+func fieldAccesses(recorder *asmtest.Recorder) []interface{} {
+	var accesses []interface{}
+	for _, event := range recorder.GetEvents(helper.CLASS_VISIT_FIELD, "") {
+		accesses = append(accesses, event.Args[0])
+	}
+	return accesses
+}
+
+func TestFinalFieldOpener(t *testing.T) {
+	final := opcodes.ACC_PRIVATE | opcodes.ACC_FINAL
+	constant := opcodes.ACC_PUBLIC | opcodes.ACC_STATIC | opcodes.ACC_FINAL
+
+	// Only the selected field is opened.
+	opener, recorder := openFields(t, finalFieldsClass(opcodes.ACC_PUBLIC, "p/Outer$In"), nil, []string{"p/Outer$In.b"})
+	if fmt.Sprint(fieldAccesses(recorder)) != fmt.Sprint([]interface{}{final, opcodes.ACC_PRIVATE, constant}) ||
+		fmt.Sprint(opener.Opened) != "[p/Outer$In.b]" || len(opener.Unchanged) != 0 {
+		t.Errorf("unexpected fields %v %v %v", fieldAccesses(recorder), opener.Opened, opener.Unchanged)
+	}
+	if access := recorder.GetEvents(helper.CLASS_VISIT, "")[0].Args[1]; access != opcodes.ACC_PUBLIC|opcodes.ACC_FINAL {
+		t.Errorf("unexpected class access %v", access)
+	}
+
+	// The selected class and its InnerClasses entry are opened, and all its fields but the constant.
+	opener, recorder = openFields(t, finalFieldsClass(opcodes.ACC_PUBLIC, "p/Outer$In"), []string{"p/Outer$In"}, nil)
+	if fmt.Sprint(fieldAccesses(recorder)) != fmt.Sprint([]interface{}{opcodes.ACC_PRIVATE, opcodes.ACC_PRIVATE, constant}) ||
+		fmt.Sprint(opener.Opened) != "[p/Outer$In.a p/Outer$In.b]" ||
+		fmt.Sprint(opener.Unchanged) != "[p/Outer$In.K: constant value is inlined at use sites]" {
+		t.Errorf("unexpected fields %v %v %v", fieldAccesses(recorder), opener.Opened, opener.Unchanged)
+	}
+	if access := recorder.GetEvents(helper.CLASS_VISIT, "")[0].Args[1]; access != opcodes.ACC_PUBLIC {
+		t.Errorf("unexpected class access %v", access)
+	}
+	if access := recorder.GetEvents(helper.CLASS_VISIT_INNER_CLASS, "")[0].Args[3]; access != opcodes.ACC_PUBLIC|opcodes.ACC_STATIC {
+		t.Errorf("unexpected InnerClasses entry access %v", access)
+	}
+
+	// The fields of interfaces are left unchanged.
+	opener, recorder = openFields(t, finalFieldsClass(opcodes.ACC_INTERFACE|opcodes.ACC_ABSTRACT, "p/I"), nil, []string{"p/I.a"})
+	if fmt.Sprint(fieldAccesses(recorder)) != fmt.Sprint([]interface{}{final, final, constant}) ||
+		fmt.Sprint(opener.Unchanged) != "[p/I.a: interface fields are implicitly final]" {
+		t.Errorf("unexpected fields %v %v", fieldAccesses(recorder), opener.Unchanged)
+	}
+}
@@ -0,0 +1,358 @@
+package commons
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// HierarchyClass a class or an interface of a {@link ClassHierarchy}.
+type HierarchyClass struct {
+	Access     int
+	Name       string
+	SuperName  string
+	Interfaces []string
+	Methods    []*HierarchyMethod
+}
+
+// GetMethod returns the method declared by the class with the given name and descriptor, or nil.
+func (h *HierarchyClass) GetMethod(name, descriptor string) *HierarchyMethod {
+	for _, method := range h.Methods {
+		if method.Name == name && method.Descriptor == descriptor {
+			return method
+		}
+	}
+	return nil
+}
+
+// isInterface returns whether the class is an interface.
+func (h *HierarchyClass) isInterface() bool {
+	return (h.Access & opcodes.ACC_INTERFACE) != 0
+}
+
+// HierarchyMethod a method declared by a {@link HierarchyClass}.
+type HierarchyMethod struct {
+	Owner      string
+	Access     int
+	Name       string
+	Descriptor string
+}
+
+func (h HierarchyMethod) String() string {
+	return h.Owner + "." + h.Name + h.Descriptor
+}
+
+// isAbstract returns whether the method has no code.
+func (h *HierarchyMethod) isAbstract() bool {
+	return (h.Access & opcodes.ACC_ABSTRACT) != 0
+}
+
+// ClassHierarchy the inheritance relationships between the classes of a program, and the methods they declare,
+// to resolve the method calls of the program as the JVM does (see the JVMS 5.4.3.3, 5.4.3.4 and 5.4.6). The
+// classes are added with {@link AddClass}, {@link AddJar} or {@link AddClasspath}. The classes outside of the
+// program, i.e. not added to the hierarchy, are considered to declare no method and to have no super type
+// (java/lang/Object should be added to resolve its methods).
+type ClassHierarchy struct {
+	classes map[string]*HierarchyClass
+	// names the names of the classes, in the order in which they were added.
+	names []string
+}
+
+// NewClassHierarchy constructs a new, empty {@link ClassHierarchy}.
+func NewClassHierarchy() *ClassHierarchy {
+	return &ClassHierarchy{classes: make(map[string]*HierarchyClass)}
+}
+
+// AddClass adds the given class file to the hierarchy. A class with the same name as a previously added class
+// replaces it.
+func (c *ClassHierarchy) AddClass(classFile []byte) error {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return err
+	}
+	collector := &hierarchyCollector{class: &HierarchyClass{}}
+	reader.Accept(collector, asm.SKIP_CODE|asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+	if c.classes[collector.class.Name] == nil {
+		c.names = append(c.names, collector.class.Name)
+	}
+	c.classes[collector.class.Name] = collector.class
+	return nil
+}
+
+// AddJar adds the class files of the given jar (or zip) file to the hierarchy.
+func (c *ClassHierarchy) AddJar(path string) error {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer jar.Close()
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || strings.HasSuffix(file.Name, "module-info.class") {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+		if err := c.AddClass(classFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddClasspath adds the classes loaded from the given classpath to the hierarchy, i.e. without the classes shadowed
+// by those of a previous entry.
+func (c *ClassHierarchy) AddClasspath(classpath *Classpath) error {
+	return classpath.Accept(func(name string, classFile []byte) error {
+		return c.AddClass(classFile)
+	})
+}
+
+// GetClass returns the class of the hierarchy with the given internal name, or nil.
+func (c *ClassHierarchy) GetClass(name string) *HierarchyClass {
+	return c.classes[name]
+}
+
+// IsSubtypeOf returns whether the given class is the given type, or extends or implements it, directly or
+// indirectly, through the classes of the hierarchy.
+func (c *ClassHierarchy) IsSubtypeOf(name, superType string) bool {
+	if name == superType {
+		return true
+	}
+	visited := make(map[string]bool)
+	var isSubtype func(name string) bool
+	isSubtype = func(name string) bool {
+		if name == superType {
+			return true
+		}
+		class := c.classes[name]
+		if class == nil || visited[name] {
+			return false
+		}
+		visited[name] = true
+		if class.SuperName != "" && isSubtype(class.SuperName) {
+			return true
+		}
+		for _, itf := range class.Interfaces {
+			if isSubtype(itf) {
+				return true
+			}
+		}
+		return false
+	}
+	return isSubtype(name)
+}
+
+// GetCommonSuperClass returns the internal name of the nearest common super class of the two given classes, as
+// needed to compute stack map frames (see {@link asm.AddMemberOptions#GetCommonSuperClass}). Returns
+// java/lang/Object if one of them is an interface, or if they have no common super class in the hierarchy.
+func (c *ClassHierarchy) GetCommonSuperClass(type1, type2 string) string {
+	if class := c.classes[type1]; class == nil || class.isInterface() {
+		return "java/lang/Object"
+	}
+	if class := c.classes[type2]; class == nil || class.isInterface() {
+		return "java/lang/Object"
+	}
+	for name := type1; name != ""; {
+		if c.IsSubtypeOf(type2, name) {
+			return name
+		}
+		class := c.classes[name]
+		if class == nil {
+			break
+		}
+		name = class.SuperName
+	}
+	return "java/lang/Object"
+}
+
+// ResolveMethod returns the method referenced by a Methodref or an InterfaceMethodref with the given owner, name
+// and descriptor, as resolved by the JVM (JVMS 5.4.3.3 and 5.4.3.4): the method declared by the owner or its
+// nearest super class, or else the maximally-specific superinterface method, preferring a non abstract one.
+// Returns nil if the method can't be found in the hierarchy. Signature polymorphic methods are not supported.
+func (c *ClassHierarchy) ResolveMethod(owner, name, descriptor string) *HierarchyMethod {
+	class := c.classes[owner]
+	if class == nil {
+		return nil
+	}
+	if class.isInterface() {
+		if method := class.GetMethod(name, descriptor); method != nil {
+			return method
+		}
+		// The public instance methods of Object are members of all the interfaces.
+		if object := c.classes["java/lang/Object"]; object != nil {
+			method := object.GetMethod(name, descriptor)
+			if method != nil && (method.Access&(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC)) == opcodes.ACC_PUBLIC {
+				return method
+			}
+		}
+	} else {
+		for superClass := class; superClass != nil; superClass = c.classes[superClass.SuperName] {
+			if method := superClass.GetMethod(name, descriptor); method != nil {
+				return method
+			}
+			if superClass.SuperName == "" {
+				break
+			}
+		}
+	}
+	if method := c.uniqueNonAbstractMethod(c.maximallySpecificMethods(owner, name, descriptor)); method != nil {
+		return method
+	}
+	// Otherwise any superinterface method, even abstract or not maximally-specific, can be selected.
+	if methods := c.superInterfaceMethods(owner, name, descriptor); len(methods) > 0 {
+		return methods[0]
+	}
+	return nil
+}
+
+// ResolveVirtualCall returns the methods which can be invoked by an invokevirtual or invokeinterface instruction
+// with the given owner, name and descriptor, i.e. the methods selected by the JVM (JVMS 5.4.6) for each concrete
+// class of the hierarchy which is a subtype of the owner (including the owner itself): the overriding method
+// declared by the class or its nearest super class, or else the unique non abstract maximally-specific
+// superinterface method (i.e. a default method). The receivers for which the selection fails (because the
+// selected method is abstract, or because several default methods are maximally-specific) contribute no method.
+// The methods are returned without duplicates, in the order in which their receiver classes were added. Returns
+// nil if the method can't be resolved.
+func (c *ClassHierarchy) ResolveVirtualCall(owner, name, descriptor string) []*HierarchyMethod {
+	resolvedMethod := c.ResolveMethod(owner, name, descriptor)
+	if resolvedMethod == nil || (resolvedMethod.Access&opcodes.ACC_STATIC) != 0 {
+		return nil
+	}
+	if (resolvedMethod.Access & opcodes.ACC_PRIVATE) != 0 {
+		// Private methods are invoked without selection.
+		return []*HierarchyMethod{resolvedMethod}
+	}
+	var methods []*HierarchyMethod
+	selected := make(map[*HierarchyMethod]bool)
+	for _, receiverName := range c.names {
+		receiver := c.classes[receiverName]
+		if receiver.isInterface() || (receiver.Access&opcodes.ACC_ABSTRACT) != 0 || !c.IsSubtypeOf(receiverName, owner) {
+			continue
+		}
+		method := c.selectMethod(receiver, resolvedMethod)
+		if method != nil && !method.isAbstract() && !selected[method] {
+			selected[method] = true
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// selectMethod returns the method selected for the given receiver class and resolved method (JVMS 5.4.6), or nil.
+func (c *ClassHierarchy) selectMethod(receiver *HierarchyClass, resolvedMethod *HierarchyMethod) *HierarchyMethod {
+	for class := receiver; class != nil; class = c.classes[class.SuperName] {
+		method := class.GetMethod(resolvedMethod.Name, resolvedMethod.Descriptor)
+		if method != nil && (method.Access&opcodes.ACC_STATIC) == 0 && canOverride(method, resolvedMethod) {
+			return method
+		}
+		if class.SuperName == "" {
+			break
+		}
+	}
+	return c.uniqueNonAbstractMethod(c.maximallySpecificMethods(receiver.Name, resolvedMethod.Name, resolvedMethod.Descriptor))
+}
+
+// canOverride returns whether the given method can override the given resolved method (JVMS 5.4.5), ignoring the
+// transitive overriding of package private methods.
+func canOverride(method, resolvedMethod *HierarchyMethod) bool {
+	if method == resolvedMethod {
+		return true
+	}
+	if (method.Access & opcodes.ACC_PRIVATE) != 0 {
+		return false
+	}
+	if (resolvedMethod.Access & (opcodes.ACC_PUBLIC | opcodes.ACC_PROTECTED)) != 0 {
+		return true
+	}
+	return (resolvedMethod.Access&opcodes.ACC_PRIVATE) == 0 && packageName(method.Owner) == packageName(resolvedMethod.Owner)
+}
+
+// superInterfaceMethods returns the non private, non static methods with the given name and descriptor declared
+// by the superinterfaces (direct or indirect) of the given class, in depth first order.
+func (c *ClassHierarchy) superInterfaceMethods(name, methodName, descriptor string) []*HierarchyMethod {
+	var methods []*HierarchyMethod
+	visited := make(map[string]bool)
+	var collect func(className string, isSuperInterface bool)
+	collect = func(className string, isSuperInterface bool) {
+		class := c.classes[className]
+		if class == nil || visited[className] {
+			return
+		}
+		visited[className] = true
+		if isSuperInterface {
+			method := class.GetMethod(methodName, descriptor)
+			if method != nil && (method.Access&(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC)) == 0 {
+				methods = append(methods, method)
+			}
+		}
+		if class.SuperName != "" {
+			collect(class.SuperName, false)
+		}
+		for _, itf := range class.Interfaces {
+			collect(itf, true)
+		}
+	}
+	collect(name, false)
+	return methods
+}
+
+// maximallySpecificMethods returns the maximally-specific superinterface methods of the given class with the given
+// name and descriptor (JVMS 5.4.3.3), i.e. the superinterface methods whose declaring interface has no subinterface
+// declaring such a method among the superinterfaces of the class.
+func (c *ClassHierarchy) maximallySpecificMethods(name, methodName, descriptor string) []*HierarchyMethod {
+	methods := c.superInterfaceMethods(name, methodName, descriptor)
+	var maximallySpecificMethods []*HierarchyMethod
+	for _, method := range methods {
+		maximallySpecific := true
+		for _, other := range methods {
+			if other != method && c.IsSubtypeOf(other.Owner, method.Owner) {
+				maximallySpecific = false
+				break
+			}
+		}
+		if maximallySpecific {
+			maximallySpecificMethods = append(maximallySpecificMethods, method)
+		}
+	}
+	return maximallySpecificMethods
+}
+
+// uniqueNonAbstractMethod returns the non abstract method among the given ones if there is exactly one, or nil.
+func (c *ClassHierarchy) uniqueNonAbstractMethod(methods []*HierarchyMethod) *HierarchyMethod {
+	var nonAbstractMethod *HierarchyMethod
+	for _, method := range methods {
+		if !method.isAbstract() {
+			if nonAbstractMethod != nil {
+				return nil
+			}
+			nonAbstractMethod = method
+		}
+	}
+	return nonAbstractMethod
+}
+
+// hierarchyCollector a {@link ClassVisitor} which collects the {@link HierarchyClass} of a class.
+type hierarchyCollector struct {
+	helper.ClassVisitor
+	class *HierarchyClass
+}
+
+func (h *hierarchyCollector) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	h.class.Access, h.class.Name, h.class.SuperName, h.class.Interfaces = access, name, superName, interfaces
+}
+
+func (h *hierarchyCollector) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	h.class.Methods = append(h.class.Methods, &HierarchyMethod{Owner: h.class.Name, Access: access, Name: name, Descriptor: descriptor})
+	return nil
+}
@@ -0,0 +1,135 @@
+package commons_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// hierarchyClass returns a class file with the given access, name, super class and interfaces, and a public
+// method m()V, if declaresM is true.
+func hierarchyClass(t *testing.T, access int, name, superName string, declaresM bool, interfaces ...string) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, access, name, superName, interfaces...)
+	if !declaresM {
+		return classFile.Bytes()
+	}
+	result, err := asm.AddMethod(classFile.Bytes(), opcodes.ACC_PUBLIC, "m", "()V", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitCode()
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestResolveVirtualCall(t *testing.T) {
+	itf := opcodes.ACC_PUBLIC | opcodes.ACC_INTERFACE | opcodes.ACC_ABSTRACT
+	hierarchy := commons.NewClassHierarchy()
+	for _, classFile := range [][]byte{
+		hierarchyClass(t, itf, "I", "java/lang/Object", true),
+		hierarchyClass(t, itf, "J", "java/lang/Object", true, "I"),
+		hierarchyClass(t, itf, "K", "java/lang/Object", true),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "A", "java/lang/Object", false, "I"),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "B", "A", false, "J"),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "C", "A", true),
+		hierarchyClass(t, opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "D", "java/lang/Object", false, "I"),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "E", "java/lang/Object", false, "I", "K"),
+	} {
+		if err := hierarchy.AddClass(classFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		owner    string
+		expected string
+	}{
+		{"I", "[I.m()V J.m()V C.m()V]"},
+		{"A", "[I.m()V J.m()V C.m()V]"},
+		{"B", "[J.m()V]"},
+		{"K", "[]"},
+		{"D", "[]"},
+	}
+	for _, test := range tests {
+		var methods []string
+		for _, method := range hierarchy.ResolveVirtualCall(test.owner, "m", "()V") {
+			methods = append(methods, method.String())
+		}
+		if actual := fmt.Sprint(methods); actual != test.expected {
+			t.Errorf("%s.m()V: expected %s, got %s", test.owner, test.expected, actual)
+		}
+	}
+	if hierarchy.ResolveVirtualCall("A", "n", "()V") != nil {
+		t.Error("expected no method for an unresolved call")
+	}
+}
+
+func TestGetCommonSuperClass(t *testing.T) {
+	itf := opcodes.ACC_PUBLIC | opcodes.ACC_INTERFACE | opcodes.ACC_ABSTRACT
+	hierarchy := commons.NewClassHierarchy()
+	for _, classFile := range [][]byte{
+		hierarchyClass(t, itf, "I", "java/lang/Object", false),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "A", "java/lang/Object", false),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "B", "A", false, "I"),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "C", "A", false),
+		hierarchyClass(t, opcodes.ACC_PUBLIC, "D", "C", false),
+	} {
+		if err := hierarchy.AddClass(classFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range [][3]string{
+		{"B", "D", "A"},
+		{"D", "C", "C"},
+		{"C", "D", "C"},
+		{"B", "I", "java/lang/Object"},
+		{"B", "Unknown", "java/lang/Object"},
+	} {
+		if commonSuperClass := hierarchy.GetCommonSuperClass(test[0], test[1]); commonSuperClass != test[2] {
+			t.Errorf("%s %s: expected %s, got %s", test[0], test[1], test[2], commonSuperClass)
+		}
+	}
+
+	// The frame after "A a = z ? new B() : new D();" merges B and D into A.
+	classFile, err := asm.AddMethodWithOptions(hierarchyClass(t, opcodes.ACC_PUBLIC, "E", "java/lang/Object", false),
+		opcodes.ACC_STATIC, "choose", "(Z)LA;", func(methodVisitor asm.MethodVisitor) {
+			elseLabel, endLabel := &asm.Label{}, &asm.Label{}
+			methodVisitor.VisitCode()
+			methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+			methodVisitor.VisitJumpInsn(opcodes.IFEQ, elseLabel)
+			methodVisitor.VisitTypeInsn(opcodes.NEW, "B")
+			methodVisitor.VisitInsn(opcodes.DUP)
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, "B", "<init>", "()V", false)
+			methodVisitor.VisitJumpInsn(opcodes.GOTO, endLabel)
+			methodVisitor.VisitLabel(elseLabel)
+			methodVisitor.VisitTypeInsn(opcodes.NEW, "D")
+			methodVisitor.VisitInsn(opcodes.DUP)
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, "D", "<init>", "()V", false)
+			methodVisitor.VisitLabel(endLabel)
+			methodVisitor.VisitInsn(opcodes.ARETURN)
+			methodVisitor.VisitMaxs(0, 0)
+		}, asm.AddMemberOptions{GetCommonSuperClass: hierarchy.GetCommonSuperClass})
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, err := tree.ReadClassNode(classFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stacks []string
+	for _, insn := range class.Methods[0].Instructions {
+		if frame, ok := insn.(*tree.FrameNode); ok {
+			stacks = append(stacks, fmt.Sprint(frame.Stack))
+		}
+	}
+	if fmt.Sprint(stacks) != "[[] [A]]" {
+		t.Errorf("unexpected frame stacks %v", stacks)
+	}
+}
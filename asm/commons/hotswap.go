@@ -0,0 +1,157 @@
+package commons
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// HotSwapDiff the difference between two versions of a class, from the point of view of the JVMTI
+// RedefineClasses function, returned by {@link DiffHotSwap}.
+type HotSwapDiff struct {
+	// Class the internal name of the class.
+	Class string
+	// Incompatibilities the changes which cannot be applied by redefining the class with the standard JVMTI
+	// rules, i.e. the changes of the class hierarchy, of the class or member modifiers, of the fields, and the
+	// added or removed methods. A restart (or a full reload of the class loader) is needed if it is not empty.
+	Incompatibilities []string
+	// ChangedMethods the name and descriptor of the methods whose body changed, in the order of the new class.
+	ChangedMethods []string
+	// Payload the class file to pass to RedefineClasses, or nil if no redefinition is needed (the method bodies
+	// are unchanged, e.g. if only the constant pool order changed) or possible (see {@link Incompatibilities}).
+	Payload []byte
+}
+
+// IsHotSwappable returns whether the new version of the class can be applied by redefining the class.
+func (h *HotSwapDiff) IsHotSwappable() bool {
+	return len(h.Incompatibilities) == 0
+}
+
+func (h *HotSwapDiff) String() string {
+	switch {
+	case !h.IsHotSwappable():
+		return h.Class + ": not hot swappable: " + strings.Join(h.Incompatibilities, "; ")
+	case h.Payload == nil:
+		return h.Class + ": unchanged"
+	}
+	return h.Class + ": redefine " + strings.Join(h.ChangedMethods, ", ")
+}
+
+// DiffHotSwap compares the given old and new versions of a class, and returns whether the new one can be
+// applied to a running JVM by redefining the class, under the standard JVMTI rules which only allow changes to
+// the method bodies (and to the constant pool and attributes). The method bodies are compared instruction by
+// instruction, so that recompiling a class without changing its code does not require a redefinition.
+func DiffHotSwap(oldClassFile, newClassFile []byte) (*HotSwapDiff, error) {
+	oldClass, err := tree.ReadClassNode(oldClassFile, asm.SKIP_FRAMES)
+	if err != nil {
+		return nil, err
+	}
+	newClass, err := tree.ReadClassNode(newClassFile, asm.SKIP_FRAMES)
+	if err != nil {
+		return nil, err
+	}
+	diff := &HotSwapDiff{Class: newClass.Name}
+	incompatible := func(message string) {
+		diff.Incompatibilities = append(diff.Incompatibilities, message)
+	}
+	if oldClass.Name != newClass.Name {
+		incompatible("class renamed from " + oldClass.Name)
+	}
+	if oldClass.SuperName != newClass.SuperName {
+		incompatible("super class changed from " + oldClass.SuperName + " to " + newClass.SuperName)
+	}
+	if strings.Join(oldClass.Interfaces, ",") != strings.Join(newClass.Interfaces, ",") {
+		incompatible("interfaces changed from [" + strings.Join(oldClass.Interfaces, ", ") + "] to [" +
+			strings.Join(newClass.Interfaces, ", ") + "]")
+	}
+	if oldClass.Access != newClass.Access {
+		incompatible("class modifiers changed from " + accessString(oldClass.Access) + " to " + accessString(newClass.Access))
+	}
+
+	// The fields must be the same, in the same order.
+	for i := 0; i < max(len(oldClass.Fields), len(newClass.Fields)); i++ {
+		switch {
+		case i >= len(newClass.Fields):
+			incompatible("field " + oldClass.Fields[i].Name + " removed")
+		case i >= len(oldClass.Fields):
+			incompatible("field " + newClass.Fields[i].Name + " added")
+		case oldClass.Fields[i].Name != newClass.Fields[i].Name || oldClass.Fields[i].Descriptor != newClass.Fields[i].Descriptor:
+			incompatible("field " + oldClass.Fields[i].Name + " " + oldClass.Fields[i].Descriptor + " changed to " +
+				newClass.Fields[i].Name + " " + newClass.Fields[i].Descriptor)
+		case oldClass.Fields[i].Access != newClass.Fields[i].Access:
+			incompatible("field " + newClass.Fields[i].Name + " modifiers changed from " +
+				accessString(oldClass.Fields[i].Access) + " to " + accessString(newClass.Fields[i].Access))
+		}
+	}
+
+	// The methods must be the same, in any order.
+	oldMethods := make(map[string]*tree.MethodNode, len(oldClass.Methods))
+	for _, method := range oldClass.Methods {
+		oldMethods[method.Name+method.Descriptor] = method
+	}
+	for _, method := range newClass.Methods {
+		key := method.Name + method.Descriptor
+		oldMethod, ok := oldMethods[key]
+		delete(oldMethods, key)
+		switch {
+		case !ok:
+			incompatible("method " + key + " added")
+		case oldMethod.Access != method.Access:
+			incompatible("method " + key + " modifiers changed from " + accessString(oldMethod.Access) + " to " +
+				accessString(method.Access))
+		case !sameMethodBody(oldMethod, method):
+			diff.ChangedMethods = append(diff.ChangedMethods, key)
+		}
+	}
+	for _, method := range oldClass.Methods {
+		if _, ok := oldMethods[method.Name+method.Descriptor]; ok {
+			incompatible("method " + method.Name + method.Descriptor + " removed")
+		}
+	}
+
+	if diff.IsHotSwappable() && len(diff.ChangedMethods) > 0 {
+		diff.Payload = newClassFile
+	}
+	return diff, nil
+}
+
+// accessString returns the given access flags in hexadecimal.
+func accessString(access int) string {
+	return "0x" + strconv.FormatInt(int64(access), 16)
+}
+
+// sameMethodBody returns whether the given methods have the same instructions, try catch blocks, local
+// variables and line numbers, independently of the constant pool indexes and of the bytecode offsets.
+func sameMethodBody(method1, method2 *tree.MethodNode) bool {
+	body1, body2 := methodBody(method1), methodBody(method2)
+	if len(body1) != len(body2) {
+		return false
+	}
+	for i := range body1 {
+		if body1[i] != body2[i] {
+			return false
+		}
+	}
+	return method1.MaxStack == method2.MaxStack && method1.MaxLocals == method2.MaxLocals
+}
+
+// methodBody returns a textual representation of the body of the given method, with one string per
+// instruction, try catch block and local variable.
+func methodBody(method *tree.MethodNode) []string {
+	labelNames := tree.GetLabelNames(method)
+	var body []string
+	for _, insn := range method.Instructions {
+		body = append(body, tree.InsnToString(insn, labelNames))
+	}
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		body = append(body, "TRYCATCHBLOCK "+labelNames[tryCatchBlock.Start]+" "+labelNames[tryCatchBlock.End]+" "+
+			labelNames[tryCatchBlock.Handler]+" "+tryCatchBlock.Type)
+	}
+	for _, localVariable := range method.LocalVariables {
+		body = append(body, "LOCALVARIABLE "+localVariable.Name+" "+localVariable.Descriptor+" "+
+			labelNames[localVariable.Start]+" "+labelNames[localVariable.End]+" "+strconv.Itoa(localVariable.Index))
+	}
+	return body
+}
@@ -0,0 +1,134 @@
+package commons_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// hotSwapMember a field, or a method returning the given int value, of a class built by {@link hotSwapClass}.
+type hotSwapMember struct {
+	access     int
+	name       string
+	descriptor string
+	value      int
+}
+
+// hotSwapSpec the description of a class p/C built by {@link hotSwapClass}.
+type hotSwapSpec struct {
+	access     int
+	superName  string
+	interfaces []string
+	fields     []hotSwapMember
+	methods    []hotSwapMember
+	// constant a constant added first to the constant pool, to change the constant pool indexes.
+	constant string
+}
+
+// hotSwapClass returns the class file of a class p/C with a field "int f" and the methods "int m()", returning
+// 1, and "int n()", returning 2, modified by the given function.
+func hotSwapClass(edit func(spec *hotSwapSpec)) []byte {
+	spec := &hotSwapSpec{
+		access:    opcodes.ACC_PUBLIC | opcodes.ACC_SUPER,
+		superName: "java/lang/Object",
+		fields:    []hotSwapMember{{opcodes.ACC_PRIVATE, "f", "I", 0}},
+		methods:   []hotSwapMember{{opcodes.ACC_PUBLIC, "m", "()I", 1}, {opcodes.ACC_PUBLIC, "n", "()I", 2}},
+	}
+	if edit != nil {
+		edit(spec)
+	}
+	classFile := asmtest.NewClassFile(opcodes.V1_8, spec.access, "p/C", spec.superName, spec.interfaces...)
+	if spec.constant != "" {
+		classFile.SymbolTable.AddConstantUtf8(spec.constant)
+	}
+	for _, field := range spec.fields {
+		classFile.AddField(field.access, field.name, field.descriptor, "", nil)
+	}
+	for _, method := range spec.methods {
+		methodVisitor := classFile.AddMethod(method.access, method.name, method.descriptor, "", nil)
+		methodVisitor.VisitCode()
+		methodVisitor.VisitIntInsn(opcodes.BIPUSH, method.value)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(1, 1)
+		methodVisitor.VisitEnd()
+	}
+	return classFile.Bytes()
+}
+
+func TestDiffHotSwapAllowedChanges(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		edit     func(spec *hotSwapSpec)
+		expected string
+	}{
+		// Recompiling without code changes only changes the constant pool.
+		{"constant pool", func(spec *hotSwapSpec) { spec.constant = "unused" }, "p/C: unchanged"},
+		{"method body", func(spec *hotSwapSpec) { spec.methods[1].value = 3 }, "p/C: redefine n()I"},
+		// The method order doesn't matter, and the changed methods are in the order of the new class.
+		{"method order", func(spec *hotSwapSpec) {
+			spec.methods[0], spec.methods[1] = spec.methods[1], spec.methods[0]
+			spec.methods[0].value, spec.methods[1].value = 4, 5
+		}, "p/C: redefine n()I, m()I"},
+	} {
+		newClassFile := hotSwapClass(test.edit)
+		diff, err := commons.DiffHotSwap(hotSwapClass(nil), newClassFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff.String() != test.expected || !diff.IsHotSwappable() {
+			t.Errorf("%s: unexpected diff %s", test.name, diff)
+		}
+		// The payload is the new class, if it must be redefined.
+		if (diff.Payload != nil) != (len(diff.ChangedMethods) > 0) || (diff.Payload != nil && !bytes.Equal(diff.Payload, newClassFile)) {
+			t.Errorf("%s: unexpected payload", test.name)
+		}
+	}
+}
+
+func TestDiffHotSwapRejectedChanges(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		edit     func(spec *hotSwapSpec)
+		expected string
+	}{
+		{"super class", func(spec *hotSwapSpec) { spec.superName = "p/B" },
+			"super class changed from java/lang/Object to p/B"},
+		{"interfaces", func(spec *hotSwapSpec) { spec.interfaces = []string{"p/I"} },
+			"interfaces changed from [] to [p/I]"},
+		{"class modifiers", func(spec *hotSwapSpec) { spec.access |= opcodes.ACC_FINAL }, "class modifiers changed from 0x21 to 0x31"},
+		{"field added", func(spec *hotSwapSpec) {
+			spec.fields = append(spec.fields, hotSwapMember{opcodes.ACC_PRIVATE, "g", "J", 0})
+		}, "field g added"},
+		{"field removed", func(spec *hotSwapSpec) { spec.fields = nil }, "field f removed"},
+		{"field type", func(spec *hotSwapSpec) { spec.fields[0].descriptor = "J" }, "field f I changed to f J"},
+		{"field modifiers", func(spec *hotSwapSpec) { spec.fields[0].access |= opcodes.ACC_VOLATILE },
+			"field f modifiers changed from 0x2 to 0x42"},
+		{"method added", func(spec *hotSwapSpec) {
+			spec.methods = append(spec.methods, hotSwapMember{opcodes.ACC_PUBLIC, "o", "()I", 0})
+		}, "method o()I added"},
+		// Changing a method descriptor removes a method and adds another one.
+		{"method descriptor", func(spec *hotSwapSpec) { spec.methods[0].descriptor = "(I)I" },
+			"method m(I)I added; method m()I removed"},
+		{"method modifiers", func(spec *hotSwapSpec) {
+			spec.methods[0].access = opcodes.ACC_PRIVATE
+			spec.methods[1].value = 3
+		}, "method m()I modifiers changed from 0x1 to 0x2"},
+	} {
+		diff, err := commons.DiffHotSwap(hotSwapClass(nil), hotSwapClass(test.edit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff.String() != "p/C: not hot swappable: "+test.expected || diff.IsHotSwappable() || diff.Payload != nil {
+			t.Errorf("%s: unexpected diff %s", test.name, diff)
+		}
+	}
+}
+
+func TestDiffHotSwapInvalidClass(t *testing.T) {
+	if _, err := commons.DiffHotSwap(hotSwapClass(nil), []byte{0xCA, 0xFE}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,169 @@
+package commons
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// InvokeDynamicCallSite an invokedynamic call site, as given to and returned by the rewrite function of an
+// {@link InvokeDynamicRewriter}.
+type InvokeDynamicCallSite struct {
+	// Owner the internal name of the class containing the call site.
+	Owner string
+	// Method the name and descriptor of the method containing the call site.
+	Method string
+	// Index the index of the call site among the invokedynamic instructions of this method.
+	Index int
+	// Name the method name of the call site.
+	Name string
+	// Descriptor the method descriptor of the call site.
+	Descriptor string
+	// BootstrapMethod the bootstrap method handle.
+	BootstrapMethod *asm.Handle
+	// BootstrapMethodArguments the static arguments of the bootstrap method: int, float32, int64, float64,
+	// string, *asm.Type or *asm.Handle values.
+	BootstrapMethodArguments []interface{}
+}
+
+// InvokeDynamicRewriter a {@link ClassVisitor} that rewrites the invokedynamic instructions of the visited
+// class: the Rewrite function receives each call site and returns the call site to use instead, with possibly
+// a different name, descriptor, bootstrap method or static arguments. A rewritten descriptor must have the
+// same argument and return types, up to reference types, so that the stack remains consistent. Invalid
+// rewrites are ignored, i.e. the original call site is kept, and the errors are reported to Handler (if not
+// nil) and recorded in Errors. The BootstrapMethods attribute is not visited: it must be rebuilt from the visited
+// call sites, e.g. with {@link asm.SymbolTable#AddBootstrapMethod}, which deduplicates identical entries.
+type InvokeDynamicRewriter struct {
+	helper.ClassAdapter
+	// Rewrite returns the new call site for the given one. It may return its argument unchanged.
+	Rewrite func(callSite InvokeDynamicCallSite) InvokeDynamicCallSite
+	// Handler the function called for each invalid rewrite, or nil.
+	Handler func(error)
+	// Errors the errors of the invalid rewrites.
+	Errors []error
+	// Rewritten the number of rewritten call sites.
+	Rewritten int
+	className string
+}
+
+// NewInvokeDynamicRewriter constructs a new {@link InvokeDynamicRewriter}.
+func NewInvokeDynamicRewriter(classVisitor asm.ClassVisitor, rewrite func(callSite InvokeDynamicCallSite) InvokeDynamicCallSite) *InvokeDynamicRewriter {
+	return &InvokeDynamicRewriter{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		Rewrite:      rewrite,
+	}
+}
+
+func (i *InvokeDynamicRewriter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	i.className = name
+	i.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (i *InvokeDynamicRewriter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	methodVisitor := i.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil {
+		return nil
+	}
+	return &indyRewriterAdapter{MethodAdapter: helper.MethodAdapter{Next: methodVisitor}, rewriter: i, method: name + descriptor}
+}
+
+func (i *InvokeDynamicRewriter) report(err error) {
+	i.Errors = append(i.Errors, err)
+	if i.Handler != nil {
+		i.Handler(err)
+	}
+}
+
+type indyRewriterAdapter struct {
+	helper.MethodAdapter
+	rewriter *InvokeDynamicRewriter
+	method   string
+	index    int
+}
+
+func (a *indyRewriterAdapter) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	callSite := InvokeDynamicCallSite{
+		Owner:                    a.rewriter.className,
+		Method:                   a.method,
+		Index:                    a.index,
+		Name:                     name,
+		Descriptor:               descriptor,
+		BootstrapMethod:          bootstrapMethodHande,
+		BootstrapMethodArguments: bootstrapMethodArguments,
+	}
+	a.index++
+	newCallSite := a.rewriter.Rewrite(callSite)
+	if err := checkCallSiteRewrite(callSite, newCallSite); err != nil {
+		a.rewriter.report(errors.New(err.Error() + " in " + callSite.Owner + "." + callSite.Method + " #" +
+			strconv.Itoa(callSite.Index)))
+		newCallSite = callSite
+	} else if !sameCallSite(callSite, newCallSite) {
+		a.rewriter.Rewritten++
+	}
+	a.MethodAdapter.VisitInvokeDynamicInsn(newCallSite.Name, newCallSite.Descriptor, newCallSite.BootstrapMethod,
+		newCallSite.BootstrapMethodArguments...)
+}
+
+// checkCallSiteRewrite checks that the given new call site can replace the given old one.
+func checkCallSiteRewrite(oldCallSite, newCallSite InvokeDynamicCallSite) error {
+	if newCallSite.Name == "" || newCallSite.BootstrapMethod == nil {
+		return errors.New("Illegal Argument - call site without name or bootstrap method")
+	}
+	if newCallSite.BootstrapMethod.IsField() {
+		return errors.New("Illegal Argument - field handle used as bootstrap method")
+	}
+	if err := newCallSite.BootstrapMethod.Validate(); err != nil {
+		return err
+	}
+	for _, argument := range newCallSite.BootstrapMethodArguments {
+		switch argument.(type) {
+		case int, float32, int64, float64, string, *asm.Type, *asm.Handle:
+		default:
+			return errors.New("Illegal Argument - invalid bootstrap method argument")
+		}
+	}
+	if newCallSite.Descriptor == oldCallSite.Descriptor {
+		return nil
+	}
+	if len(newCallSite.Descriptor) == 0 || newCallSite.Descriptor[0] != '(' {
+		return errors.New("Illegal Argument - invalid call site descriptor " + newCallSite.Descriptor)
+	}
+	oldType, newType := asm.GetMethodType(oldCallSite.Descriptor), asm.GetMethodType(newCallSite.Descriptor)
+	oldArguments, newArguments := oldType.GetArgumentTypes(), newType.GetArgumentTypes()
+	compatible := len(oldArguments) == len(newArguments) && stackCompatible(oldType.GetReturnType(), newType.GetReturnType())
+	for j := 0; compatible && j < len(oldArguments); j++ {
+		compatible = stackCompatible(oldArguments[j], newArguments[j])
+	}
+	if !compatible {
+		return errors.New("Illegal Argument - call site descriptor " + newCallSite.Descriptor +
+			" is not compatible with " + oldCallSite.Descriptor)
+	}
+	return nil
+}
+
+// stackCompatible returns whether a value of the given type can be replaced with a value of the other type on
+// the stack, i.e. if they are loaded with the same instruction (e.g. both references, or both int-like).
+func stackCompatible(t1, t2 *asm.Type) bool {
+	if t1.GetSort() == typed.VOID || t2.GetSort() == typed.VOID {
+		return t1.GetSort() == t2.GetSort()
+	}
+	return t1.GetOpcode(opcodes.ILOAD) == t2.GetOpcode(opcodes.ILOAD)
+}
+
+func sameCallSite(callSite1, callSite2 InvokeDynamicCallSite) bool {
+	if callSite1.Name != callSite2.Name || callSite1.Descriptor != callSite2.Descriptor ||
+		callSite1.BootstrapMethod != callSite2.BootstrapMethod ||
+		len(callSite1.BootstrapMethodArguments) != len(callSite2.BootstrapMethodArguments) {
+		return false
+	}
+	for i := range callSite1.BootstrapMethodArguments {
+		if callSite1.BootstrapMethodArguments[i] != callSite2.BootstrapMethodArguments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+const metafactoryDescriptor = "(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;" +
+	"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;"
+
+// lambdaClass returns a class p/C with the method "void m()", which creates two Runnable lambdas.
+func lambdaClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	m := classFile.AddMethod(opcodes.ACC_STATIC, "m", "()V", "", nil)
+	m.VisitCode()
+	for _, lambda := range []string{"lambda$m$0", "lambda$m$1"} {
+		m.VisitInvokeDynamicInsn("run", "()Ljava/lang/Runnable;",
+			asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/LambdaMetafactory", "metafactory", metafactoryDescriptor, false),
+			asm.GetMethodType("()V"), asm.NewHandle(opcodes.H_INVOKESTATIC, "p/C", lambda, "()V", false), asm.GetMethodType("()V"))
+		m.VisitInsn(opcodes.POP)
+	}
+	m.VisitInsn(opcodes.RETURN)
+	m.VisitMaxs(1, 0)
+	m.VisitEnd()
+	return classFile.Bytes()
+}
+
+func TestInvokeDynamicRewriter(t *testing.T) {
+	var rewriter *commons.InvokeDynamicRewriter
+	rewrite := func(next asm.ClassVisitor) asm.ClassVisitor {
+		rewriter = commons.NewInvokeDynamicRewriter(next, func(callSite commons.InvokeDynamicCallSite) commons.InvokeDynamicCallSite {
+			if callSite.Method != "m()V" {
+				t.Errorf("unexpected call site %+v", callSite)
+			}
+			if callSite.Index == 0 {
+				callSite.BootstrapMethod = asm.NewHandle(opcodes.H_INVOKESTATIC, "p/Boot", "bootstrap", metafactoryDescriptor, false)
+				callSite.Descriptor = "()Ljava/lang/Object;"
+			} else {
+				callSite.Descriptor = "()I"
+			}
+			return callSite
+		})
+		return rewriter
+	}
+	// The first call site is rewritten, and the second one, whose descriptor is invalid, is kept.
+	assertTrace(t, transformedTrace(t, lambdaClass(), "m()V", rewrite), []string{
+		`class visit method p/C 8 "m" "()V" "" []`,
+		`method visit code p/C.m()V`,
+		`method visit invoke dynamic insn p/C.m()V "run" "()Ljava/lang/Object;" p/Boot.bootstrap(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite; (6) [type ()V p/C.lambda$m$0()V (6) type ()V]`,
+		`method visit insn p/C.m()V 87`,
+		`method visit invoke dynamic insn p/C.m()V "run" "()Ljava/lang/Runnable;" java/lang/invoke/LambdaMetafactory.metafactory(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite; (6) [type ()V p/C.lambda$m$1()V (6) type ()V]`,
+		`method visit insn p/C.m()V 87`,
+		`method visit insn p/C.m()V 177`,
+		`method visit maxs p/C.m()V 1 0`,
+		`method visit end p/C.m()V`,
+	})
+	if rewriter.Rewritten != 1 || len(rewriter.Errors) != 1 ||
+		rewriter.Errors[0].Error() != "Illegal Argument - call site descriptor ()I is not compatible with ()Ljava/lang/Runnable; in p/C.m()V #1" {
+		t.Errorf("unexpected result %d %v", rewriter.Rewritten, rewriter.Errors)
+	}
+}
@@ -0,0 +1,373 @@
+package commons
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// INTEGRITY_ATTRIBUTE the name of the class attribute containing a signature of the canonical form of the class
+// (see {@link Canonicalize}), to detect the modifications of a class file after it has been built. The
+// attribute content is self contained, with the following format:
+//
+//	string algorithm
+//	u2 signature_length
+//	u1 signature[signature_length]
+//
+// where the string is an u2 length followed by this number of UTF-8 bytes.
+const INTEGRITY_ATTRIBUTE = "io.asm.go.Integrity"
+
+// IntegritySigner an algorithm to sign and verify the canonical form of classes.
+type IntegritySigner interface {
+	// Algorithm returns the name of the algorithm, recorded in the {@link INTEGRITY_ATTRIBUTE} attribute.
+	Algorithm() string
+	Sign(data []byte) ([]byte, error)
+	Verify(data, signature []byte) bool
+}
+
+type hmacSigner struct {
+	key []byte
+}
+
+// NewHMACSigner returns an {@link IntegritySigner} computing an HMAC-SHA256 with the given secret key.
+func NewHMACSigner(key []byte) IntegritySigner {
+	return hmacSigner{key: key}
+}
+
+func (h hmacSigner) Algorithm() string {
+	return "HmacSHA256"
+}
+
+func (h hmacSigner) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func (h hmacSigner) Verify(data, signature []byte) bool {
+	expected, _ := h.Sign(data)
+	return hmac.Equal(expected, signature)
+}
+
+type ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewEd25519Signer returns an {@link IntegritySigner} computing Ed25519 signatures. The private key can be nil
+// to only verify signatures, so that the verifiers of the classes do not need the signing key.
+func NewEd25519Signer(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) IntegritySigner {
+	return ed25519Signer{privateKey: privateKey, publicKey: publicKey}
+}
+
+func (e ed25519Signer) Algorithm() string {
+	return "Ed25519"
+}
+
+func (e ed25519Signer) Sign(data []byte) ([]byte, error) {
+	if len(e.privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("Illegal State - no Ed25519 private key")
+	}
+	return ed25519.Sign(e.privateKey, data), nil
+}
+
+func (e ed25519Signer) Verify(data, signature []byte) bool {
+	return len(e.publicKey) == ed25519.PublicKeySize && ed25519.Verify(e.publicKey, data, signature)
+}
+
+// Canonicalize returns the canonical form of the given class: a textual representation of the visitor events of
+// the class, including the values of its annotations and the content of its non standard attributes, which does
+// not depend on the constant pool layout, on the stack map frames, on the maximum stack size and number of
+// locals, nor on the {@link INTEGRITY_ATTRIBUTE} attribute. It is thus preserved by the benign re-writes of a
+// class file (e.g. recomputing its frames, or sorting its constant pool with {@link asm.SortConstantPool}), but
+// not by changes to its structure, code or annotations. Returns an error if the class is malformed.
+func Canonicalize(classFile []byte) (_ []byte, err error) {
+	if magic, err := raw.ReadU4(classFile, 0); err != nil || magic != 0xCAFEBABE {
+		return nil, raw.NewParseError(asm.ErrInvalidMagic, 0, "invalid magic number")
+	}
+	reader, err := asm.NewClassReaderWithLimits(classFile, asm.ReaderLimits{})
+	if err != nil {
+		return nil, err
+	}
+	defer recoverMalformedClass(&err)
+	canonical := &strings.Builder{}
+	labels := helper.NewLabelIDs()
+	reader.Accept(helper.NewMiddlewareClassAdapter(&canonicalClassVisitor{canonical: canonical}, helper.Middleware{
+		Before: func(event *helper.Event) bool {
+			switch event.Kind {
+			case helper.METHOD_VISIT_FRAME, helper.METHOD_VISIT_MAXS:
+				return true
+			case helper.CLASS_VISIT_ATTRIBUTE:
+				if event.Args[0].(*asm.Attribute).GetType() == INTEGRITY_ATTRIBUTE {
+					return true
+				}
+			case helper.CLASS_VISIT_METHOD:
+				labels.Reset()
+			}
+			canonical.WriteString(strconv.Itoa(event.Kind))
+			for _, arg := range event.Args {
+				canonical.WriteString(" " + helper.FormatArg(arg, labels))
+			}
+			canonical.WriteString("\n")
+			return true
+		},
+	}), asm.SKIP_FRAMES)
+	return []byte(canonical.String()), nil
+}
+
+// recoverMalformedClass stops the panic of a {@link asm.ClassReader} on a malformed (e.g. tampered) class, and
+// stores the corresponding error in err. A {@link asm.ParseError} is stored as is, and an index out of range,
+// caused by an invalid reference that the reader does not check, is reported as a malformed attribute. The
+// other panics are propagated.
+func recoverMalformedClass(err *error) {
+	if recovered := recover(); recovered != nil {
+		switch recovered := recovered.(type) {
+		case *asm.ParseError:
+			*err = recovered
+		case runtime.Error:
+			*err = raw.NewParseError(asm.ErrMalformedAttribute, -1, "malformed class: "+recovered.Error())
+		default:
+			panic(recovered)
+		}
+	}
+}
+
+// canonicalClassVisitor a class visitor which writes the values of the annotations of a class, and of its
+// fields and methods, to a canonical form. The other events are written by the middleware of
+// {@link Canonicalize}.
+type canonicalClassVisitor struct {
+	helper.ClassAdapter
+	canonical *strings.Builder
+}
+
+func (c *canonicalClassVisitor) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalClassVisitor) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalClassVisitor) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	return &canonicalFieldVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalClassVisitor) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	return &canonicalMethodVisitor{canonical: c.canonical}
+}
+
+type canonicalFieldVisitor struct {
+	helper.FieldAdapter
+	canonical *strings.Builder
+}
+
+func (c *canonicalFieldVisitor) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalFieldVisitor) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+type canonicalMethodVisitor struct {
+	helper.MethodAdapter
+	canonical *strings.Builder
+}
+
+func (c *canonicalMethodVisitor) VisitAnnotationDefault() asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalMethodVisitor) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalMethodVisitor) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalMethodVisitor) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalMethodVisitor) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalMethodVisitor) VisitTryCatchAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+func (c *canonicalMethodVisitor) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return &canonicalAnnotationVisitor{canonical: c.canonical}
+}
+
+// canonicalAnnotationVisitor an annotation visitor which writes the element values of an annotation, one per
+// line, after the event of the annotation itself. The nested annotations and arrays end with an "end" line.
+type canonicalAnnotationVisitor struct {
+	canonical *strings.Builder
+}
+
+func (c *canonicalAnnotationVisitor) Visit(name string, value interface{}) {
+	c.canonical.WriteString("= " + strconv.Quote(name) + " " + helper.FormatArg(value, nil) + "\n")
+}
+
+func (c *canonicalAnnotationVisitor) VisitEnum(name, descriptor, value string) {
+	c.canonical.WriteString("enum " + strconv.Quote(name) + " " + strconv.Quote(descriptor) + " " + strconv.Quote(value) + "\n")
+}
+
+func (c *canonicalAnnotationVisitor) VisitAnnotation(name, descriptor string) asm.AnnotationVisitor {
+	c.canonical.WriteString("@ " + strconv.Quote(name) + " " + strconv.Quote(descriptor) + "\n")
+	return c
+}
+
+func (c *canonicalAnnotationVisitor) VisitArray(name string) asm.AnnotationVisitor {
+	c.canonical.WriteString("[ " + strconv.Quote(name) + "\n")
+	return c
+}
+
+func (c *canonicalAnnotationVisitor) VisitEnd() {
+	c.canonical.WriteString("end\n")
+}
+
+// SignClass returns the {@link INTEGRITY_ATTRIBUTE} attribute of the given class, signed with the given signer.
+// The attribute is self contained, so that it can be visited by any class visitor, or added directly with
+// {@link EmbedIntegrity}.
+func SignClass(classFile []byte, signer IntegritySigner) (*asm.Attribute, error) {
+	canonical, err := Canonicalize(classFile)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signer.Sign(canonical)
+	if err != nil {
+		return nil, err
+	}
+	algorithm := signer.Algorithm()
+	content := binary.BigEndian.AppendUint16(nil, uint16(len(algorithm)))
+	content = append(content, algorithm...)
+	content = binary.BigEndian.AppendUint16(content, uint16(len(signature)))
+	content = append(content, signature...)
+	return asm.NewAttributeWithContent(INTEGRITY_ATTRIBUTE, content), nil
+}
+
+// EmbedIntegrity returns a copy of the given class file with an {@link INTEGRITY_ATTRIBUTE} attribute signed with
+// the given signer, replacing the existing one, if any. The class file is patched in place: the attribute name
+// is appended to the constant pool if needed, and the attribute is appended to the class attributes.
+func EmbedIntegrity(classFile []byte, signer IntegritySigner) ([]byte, error) {
+	attribute, err := SignClass(classFile, signer)
+	if err != nil {
+		return nil, err
+	}
+	constantPool, err := raw.ReadConstantPool(classFile, 0)
+	if err != nil {
+		return nil, err
+	}
+	output := classFile
+	nameIndex := 0
+	for i := 1; i < len(constantPool.Offsets) && nameIndex == 0; i++ {
+		if tag, err := constantPool.GetTag(classFile, i); err == nil && tag == symbol.CONSTANT_UTF8_TAG {
+			if name, _ := constantPool.GetUTF8(classFile, i); name == INTEGRITY_ATTRIBUTE {
+				nameIndex = i
+			}
+		}
+	}
+	if nameIndex == 0 {
+		nameIndex = len(constantPool.Offsets)
+		if nameIndex >= 0xFFFF {
+			return nil, errors.New("Illegal Argument - constant pool is full")
+		}
+		entry := append([]byte{symbol.CONSTANT_UTF8_TAG, 0, byte(len(INTEGRITY_ATTRIBUTE))}, INTEGRITY_ATTRIBUTE...)
+		output = make([]byte, 0, len(classFile)+len(entry)+6+len(attribute.GetContent()))
+		output = append(output, classFile[:constantPool.Header]...)
+		output = append(output, entry...)
+		output = append(output, classFile[constantPool.Header:]...)
+		binary.BigEndian.PutUint16(output[8:], uint16(nameIndex+1))
+	}
+
+	reader, err := asm.NewClassReader(output)
+	if err != nil {
+		return nil, err
+	}
+	index := reader.Index()
+	// Computes the offset of the attributes_count field of the class, following the fields and methods.
+	header := constantPool.Header + len(output) - len(classFile)
+	interfacesCount, err := raw.ReadU2(output, header+6)
+	if err != nil {
+		return nil, err
+	}
+	attributesCountOffset := header + 8 + interfacesCount*2 + 4
+	if len(index.Fields) > 0 {
+		attributesCountOffset = index.Fields[len(index.Fields)-1].End + 2
+	}
+	if len(index.Methods) > 0 {
+		attributesCountOffset = index.Methods[len(index.Methods)-1].End
+	}
+
+	result := append([]byte(nil), output[:attributesCountOffset+2]...)
+	attributesCount := 0
+	for _, attributeRange := range index.Attributes {
+		if attributeRange.Name != INTEGRITY_ATTRIBUTE {
+			result = append(result, output[attributeRange.Start:attributeRange.End]...)
+			attributesCount++
+		}
+	}
+	result = binary.BigEndian.AppendUint16(result, uint16(nameIndex))
+	result = binary.BigEndian.AppendUint32(result, uint32(len(attribute.GetContent())))
+	result = append(result, attribute.GetContent()...)
+	binary.BigEndian.PutUint16(result[attributesCountOffset:], uint16(attributesCount+1))
+	return result, nil
+}
+
+// VerifyIntegrity checks the {@link INTEGRITY_ATTRIBUTE} attribute of the given class with the given signer.
+// Returns an error if the class has no such attribute, if it was signed with another algorithm, or if the
+// signature does not match the canonical form of the class, i.e. if the class has been tampered with.
+func VerifyIntegrity(classFile []byte, signer IntegritySigner) (err error) {
+	reader, err := asm.NewClassReaderWithLimits(classFile, asm.ReaderLimits{})
+	if err != nil {
+		return err
+	}
+	defer recoverMalformedClass(&err)
+	var content []byte
+	reader.Accept(&helper.ClassVisitor{
+		OnVisitAttribute: func(attribute *asm.Attribute) {
+			if attribute.GetType() == INTEGRITY_ATTRIBUTE {
+				content = attribute.GetContent()
+			}
+		},
+	}, asm.SKIP_CODE|asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+	if content == nil {
+		return errors.New("Illegal State - class " + reader.GetClassName() + " has no " + INTEGRITY_ATTRIBUTE + " attribute")
+	}
+
+	algorithm, err := raw.ReadUTF8(content, 0)
+	if err != nil {
+		return errors.New("Illegal State - malformed " + INTEGRITY_ATTRIBUTE + " attribute")
+	}
+	signatureOffset := 2 + len(algorithm)
+	signatureLength, err := raw.ReadU2(content, signatureOffset)
+	if err != nil || signatureOffset+2+signatureLength != len(content) {
+		return errors.New("Illegal State - malformed " + INTEGRITY_ATTRIBUTE + " attribute")
+	}
+	if algorithm != signer.Algorithm() {
+		return errors.New("Illegal State - class " + reader.GetClassName() + " is signed with " + algorithm +
+			", not " + signer.Algorithm())
+	}
+	canonical, err := Canonicalize(classFile)
+	if err != nil {
+		return err
+	}
+	if !signer.Verify(canonical, content[signatureOffset+2:]) {
+		return errors.New("Illegal State - class " + reader.GetClassName() + " has been modified after it was signed")
+	}
+	return nil
+}
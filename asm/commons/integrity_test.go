@@ -0,0 +1,157 @@
+package commons_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// annotatedClass returns a class A with a method "@RolesAllowed(roles) void run() {}", and a non standard
+// Custom class attribute with the given content, if not nil.
+func annotatedClass(t *testing.T, roles string, custom []byte) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	if custom != nil {
+		classFile.AddAttribute("Custom", custom)
+	}
+	result, err := asm.AddMethod(classFile.Bytes(), opcodes.ACC_PUBLIC, "run", "()V", func(methodVisitor asm.MethodVisitor) {
+		annotationVisitor := methodVisitor.VisitAnnotation("Ljavax/annotation/security/RolesAllowed;", true)
+		arrayVisitor := annotationVisitor.VisitArray("value")
+		arrayVisitor.Visit("", roles)
+		arrayVisitor.VisitEnd()
+		annotationVisitor.VisitEnd()
+		methodVisitor.VisitCode()
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestIntegrityRoundTrip(t *testing.T) {
+	classFile, err := os.ReadFile("../../ExampleClass.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, signer := range []commons.IntegritySigner{
+		commons.NewHMACSigner([]byte("secret")),
+		commons.NewEd25519Signer(privateKey, publicKey),
+	} {
+		signed, err := commons.EmbedIntegrity(classFile, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := commons.VerifyIntegrity(signed, signer); err != nil {
+			t.Errorf("%s: %v", signer.Algorithm(), err)
+		}
+		// Signing again replaces the attribute, instead of adding a second one.
+		resigned, err := commons.EmbedIntegrity(signed, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := commons.VerifyIntegrity(resigned, signer); err != nil || len(resigned) != len(signed) {
+			t.Errorf("%s: unexpected re-signed class %v %d %d", signer.Algorithm(), err, len(resigned), len(signed))
+		}
+	}
+
+	signed, err := commons.EmbedIntegrity(classFile, commons.NewHMACSigner([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := commons.VerifyIntegrity(signed, commons.NewHMACSigner([]byte("other"))); err == nil {
+		t.Error("expected an error for another key")
+	}
+	if err := commons.VerifyIntegrity(signed, commons.NewEd25519Signer(nil, publicKey)); err == nil {
+		t.Error("expected an error for another algorithm")
+	}
+	if err := commons.VerifyIntegrity(classFile, commons.NewHMACSigner([]byte("secret"))); err == nil {
+		t.Error("expected an error for an unsigned class")
+	}
+}
+
+func TestIntegrityTamper(t *testing.T) {
+	signer := commons.NewHMACSigner([]byte("secret"))
+	signed, err := commons.EmbedIntegrity(annotatedClass(t, "admin", []byte("content")), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := commons.VerifyIntegrity(signed, signer); err != nil {
+		t.Fatal(err)
+	}
+	for _, tampering := range []struct{ old, new string }{
+		{"admin", "guest"},
+		{"content", "CONTENT"},
+		{"run", "fly"},
+	} {
+		tampered := bytes.Replace(signed, []byte(tampering.old), []byte(tampering.new), 1)
+		if err := commons.VerifyIntegrity(tampered, signer); err == nil {
+			t.Errorf("expected an error after replacing %q with %q", tampering.old, tampering.new)
+		}
+	}
+}
+
+func TestIntegrityMalformed(t *testing.T) {
+	classFile, err := os.ReadFile("../../ExampleClass.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := commons.NewHMACSigner([]byte("secret"))
+	signed, err := commons.EmbedIntegrity(classFile, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := commons.Canonicalize(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each bit flip must either be detected, or leave the canonical form unchanged (e.g. in the maximum stack
+	// size), without panicking.
+	for i := 0; i < len(signed)*8; i++ {
+		tampered := append([]byte(nil), signed...)
+		tampered[i/8] ^= 1 << (i % 8)
+		if err := commons.VerifyIntegrity(tampered, signer); err == nil {
+			if tamperedCanonical, _ := commons.Canonicalize(tampered); !bytes.Equal(tamperedCanonical, canonical) {
+				t.Errorf("undetected bit flip at offset %d", i/8)
+			}
+		}
+	}
+	for _, length := range []int{0, 4, 10, len(signed) / 2, len(signed) - 1} {
+		if err := commons.VerifyIntegrity(signed[:length], signer); err == nil {
+			t.Errorf("expected an error for a class truncated to %d bytes", length)
+		}
+	}
+	var parseError *asm.ParseError
+	if _, err := commons.Canonicalize(signed[:len(signed)-1]); !errors.As(err, &parseError) {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+}
+
+func TestIntegritySortConstantPool(t *testing.T) {
+	signer := commons.NewHMACSigner([]byte("secret"))
+	signed, err := commons.EmbedIntegrity(annotatedClass(t, "admin", nil), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted, err := asm.SortConstantPool(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sorted, signed) {
+		t.Fatal("expected a different constant pool order")
+	}
+	if err := commons.VerifyIntegrity(sorted, signer); err != nil {
+		t.Error(err)
+	}
+}
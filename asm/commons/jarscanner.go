@@ -0,0 +1,103 @@
+package commons
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// nestedJarDirectories the directories of the nested jars of the Spring Boot executable jars and of the web
+// archives.
+var nestedJarDirectories = []string{"BOOT-INF/lib/", "WEB-INF/lib/"}
+
+// JarClass a class file found by {@link ScanJar}.
+type JarClass struct {
+	// Name the internal name of the class, derived from its path.
+	Name string
+	// Path the path of the class file in the scanned jar, with a "!/" separator after each nested jar, e.g.
+	// "BOOT-INF/lib/dep.jar!/p/A.class".
+	Path string
+	file *zip.File
+}
+
+// Read returns the content of the class file. It can only be called while the jar is being scanned.
+func (j *JarClass) Read() ([]byte, error) {
+	return readZipFile(j.file)
+}
+
+// ScanJar calls the given function with each class file of the given jar (or zip, war) file, including those of
+// the jars nested in its BOOT-INF/lib/ and WEB-INF/lib/ directories (see the Spring Boot executable jar layout).
+// The classes of the jar come first, those of the nested jars next, in the order of the jar entries. The
+// BOOT-INF/classes/ and WEB-INF/classes/ prefixes are removed from the class names of the jar. The nested jars
+// are read in place if they are stored (as required by Spring Boot), or in memory if they are compressed. The
+// module-info classes and the META-INF directories are ignored.
+func ScanJar(path string, visit func(class *JarClass) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return scanJar(file, info.Size(), "", true, visit)
+}
+
+// scanJar scans the jar with the given content and size, whose class paths are prefixed with the given path
+// prefix. The nested jars are only scanned in the outermost jar.
+func scanJar(content io.ReaderAt, size int64, pathPrefix string, outermost bool, visit func(class *JarClass) error) error {
+	jar, err := zip.NewReader(content, size)
+	if err != nil {
+		return err
+	}
+	var nestedJars []*zip.File
+	for _, file := range jar.File {
+		if outermost && strings.HasSuffix(file.Name, ".jar") && isNestedJar(file.Name) {
+			nestedJars = append(nestedJars, file)
+			continue
+		}
+		if !isClasspathClass(file.Name) {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name, ".class")
+		if outermost {
+			name = strings.TrimPrefix(strings.TrimPrefix(name, "BOOT-INF/classes/"), "WEB-INF/classes/")
+		}
+		if err := visit(&JarClass{Name: name, Path: pathPrefix + file.Name, file: file}); err != nil {
+			return err
+		}
+	}
+	for _, file := range nestedJars {
+		var nestedContent io.ReaderAt
+		if file.Method == zip.Store {
+			offset, err := file.DataOffset()
+			if err != nil {
+				return err
+			}
+			nestedContent = io.NewSectionReader(content, offset, int64(file.UncompressedSize64))
+		} else {
+			nestedJar, err := readZipFile(file)
+			if err != nil {
+				return err
+			}
+			nestedContent = bytes.NewReader(nestedJar)
+		}
+		if err := scanJar(nestedContent, int64(file.UncompressedSize64), pathPrefix+file.Name+"!/", false, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNestedJar returns whether the jar entry with the given name is directly in a nested jar directory.
+func isNestedJar(name string) bool {
+	for _, directory := range nestedJarDirectories {
+		if strings.HasPrefix(name, directory) && !strings.Contains(name[len(directory):], "/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package commons_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// jarEntry an entry of a jar built by {@link writeJar}.
+type jarEntry struct {
+	name    string
+	content []byte
+	method  uint16
+}
+
+// writeJar returns a jar with the given entries, in order.
+func writeJar(t *testing.T, entries ...jarEntry) []byte {
+	var buffer bytes.Buffer
+	jar := zip.NewWriter(&buffer)
+	for _, entry := range entries {
+		writer, err := jar.CreateHeader(&zip.FileHeader{Name: entry.name, Method: entry.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write(entry.content)
+	}
+	if err := jar.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestScanJar(t *testing.T) {
+	class := func(name string) []byte {
+		return hierarchyClass(t, opcodes.ACC_PUBLIC, name, "java/lang/Object", false)
+	}
+	storedJar := writeJar(t, jarEntry{"p/B.class", class("p/B"), zip.Deflate}, jarEntry{"p/A.class", class("p/A"), zip.Deflate})
+	deflatedJar := writeJar(t, jarEntry{"q/C.class", class("q/C"), zip.Deflate})
+	path := filepath.Join(t.TempDir(), "app.jar")
+	err := os.WriteFile(path, writeJar(t,
+		jarEntry{"BOOT-INF/lib/dep.jar", storedJar, zip.Store},
+		jarEntry{"BOOT-INF/classes/p/A.class", class("p/A"), zip.Deflate},
+		jarEntry{"BOOT-INF/lib/other.jar", deflatedJar, zip.Deflate},
+		jarEntry{"META-INF/MANIFEST.MF", []byte("Manifest-Version: 1.0\n"), zip.Deflate},
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var classes []string
+	err = commons.ScanJar(path, func(class *commons.JarClass) error {
+		if _, err := class.Read(); err != nil {
+			return err
+		}
+		classes = append(classes, class.Name+"="+class.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[p/A=BOOT-INF/classes/p/A.class p/B=BOOT-INF/lib/dep.jar!/p/B.class " +
+		"p/A=BOOT-INF/lib/dep.jar!/p/A.class q/C=BOOT-INF/lib/other.jar!/q/C.class]"
+	if actual := fmt.Sprint(classes); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	classpath, err := commons.NewClasspath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shadowed := classpath.GetShadowedClasses(); len(shadowed) != 1 || shadowed[0].Shadowed[0].Path != "BOOT-INF/lib/dep.jar!/p/A.class" {
+		t.Errorf("unexpected shadowed classes %v", shadowed)
+	}
+	if classFile, err := classpath.ReadClass("q/C"); err != nil || !bytes.Equal(classFile, class("q/C")) {
+		t.Errorf("unexpected q/C class %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// LegacyAttributesAdapter a {@link ClassVisitor} that converts the ACC_SYNTHETIC and ACC_DEPRECATED access flags
+// of the classes, fields and methods into Synthetic and Deprecated attributes, when the version of the visited
+// class is before 1.5. This is the reverse of the mapping done by the {@link ClassReader}, so that the classes
+// produced for an old target version remain usable by the toolchains which only know these attributes (the
+// ACC_SYNTHETIC flag was introduced in 1.5, and ACC_DEPRECATED is not a JVMS flag). The classes of version 1.5 and
+// later are left unchanged.
+type LegacyAttributesAdapter struct {
+	helper.ClassAdapter
+	legacy            bool
+	pendingAttributes []*asm.Attribute
+}
+
+// NewLegacyAttributesAdapter constructs a new {@link LegacyAttributesAdapter}.
+func NewLegacyAttributesAdapter(classVisitor asm.ClassVisitor) *LegacyAttributesAdapter {
+	return &LegacyAttributesAdapter{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+	}
+}
+
+// legacyAttributes returns the access flags without ACC_SYNTHETIC and ACC_DEPRECATED, and the corresponding
+// attributes.
+func legacyAttributes(access int) (int, []*asm.Attribute) {
+	var attributes []*asm.Attribute
+	if (access & opcodes.ACC_SYNTHETIC) != 0 {
+		attributes = append(attributes, asm.NewAttribute("Synthetic"))
+	}
+	if (access & opcodes.ACC_DEPRECATED) != 0 {
+		attributes = append(attributes, asm.NewAttribute("Deprecated"))
+	}
+	return access &^ (opcodes.ACC_SYNTHETIC | opcodes.ACC_DEPRECATED), attributes
+}
+
+func (l *LegacyAttributesAdapter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	l.legacy = (version & 0xFFFF) < opcodes.V1_5
+	l.pendingAttributes = nil
+	if l.legacy {
+		access, l.pendingAttributes = legacyAttributes(access)
+	}
+	l.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+// visitAttributes visits the attributes of the class, before the first event which can't precede them.
+func (l *LegacyAttributesAdapter) visitAttributes() {
+	for _, attribute := range l.pendingAttributes {
+		l.ClassAdapter.VisitAttribute(attribute)
+	}
+	l.pendingAttributes = nil
+}
+
+func (l *LegacyAttributesAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	l.visitAttributes()
+	return l.ClassAdapter.VisitAnnotation(descriptor, visible)
+}
+
+func (l *LegacyAttributesAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	l.visitAttributes()
+	return l.ClassAdapter.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+}
+
+func (l *LegacyAttributesAdapter) VisitAttribute(attribute *asm.Attribute) {
+	l.visitAttributes()
+	l.ClassAdapter.VisitAttribute(attribute)
+}
+
+func (l *LegacyAttributesAdapter) VisitInnerClass(name, outerName, innerName string, access int) {
+	l.visitAttributes()
+	l.ClassAdapter.VisitInnerClass(name, outerName, innerName, access)
+}
+
+func (l *LegacyAttributesAdapter) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	l.visitAttributes()
+	if !l.legacy {
+		return l.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+	}
+	access, attributes := legacyAttributes(access)
+	fieldVisitor := l.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+	if fieldVisitor != nil {
+		for _, attribute := range attributes {
+			fieldVisitor.VisitAttribute(attribute)
+		}
+	}
+	return fieldVisitor
+}
+
+func (l *LegacyAttributesAdapter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	l.visitAttributes()
+	if !l.legacy {
+		return l.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	}
+	access, attributes := legacyAttributes(access)
+	methodVisitor := l.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil || len(attributes) == 0 {
+		return methodVisitor
+	}
+	return &legacyAttributesMethodAdapter{MethodAdapter: helper.MethodAdapter{Next: methodVisitor}, pendingAttributes: attributes}
+}
+
+func (l *LegacyAttributesAdapter) VisitEnd() {
+	l.visitAttributes()
+	l.ClassAdapter.VisitEnd()
+}
+
+// legacyAttributesMethodAdapter visits the Synthetic and Deprecated attributes of a method, after its parameters
+// and annotation default, and before its code.
+type legacyAttributesMethodAdapter struct {
+	helper.MethodAdapter
+	pendingAttributes []*asm.Attribute
+}
+
+func (a *legacyAttributesMethodAdapter) visitAttributes() {
+	for _, attribute := range a.pendingAttributes {
+		a.MethodAdapter.VisitAttribute(attribute)
+	}
+	a.pendingAttributes = nil
+}
+
+func (a *legacyAttributesMethodAdapter) VisitAttribute(attribute *asm.Attribute) {
+	a.visitAttributes()
+	a.MethodAdapter.VisitAttribute(attribute)
+}
+
+func (a *legacyAttributesMethodAdapter) VisitCode() {
+	a.visitAttributes()
+	a.MethodAdapter.VisitCode()
+}
+
+func (a *legacyAttributesMethodAdapter) VisitEnd() {
+	a.visitAttributes()
+	a.MethodAdapter.VisitEnd()
+}
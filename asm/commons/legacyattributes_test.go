@@ -0,0 +1,101 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// legacyTrace returns the events received by a recorder from a {@link LegacyAttributesAdapter}, when visiting a
+// synthetic and deprecated class of the given version, with an inner class, a synthetic field, a deprecated
+// method with an annotation and code, and a method without these flags.
+func legacyTrace(version int) []string {
+	// The recorder forwards the events to a class writer, so that the field and method events are visited.
+	recorder := asmtest.NewRecorder(asm.NewClassWriter(nil))
+	adapter := commons.NewLegacyAttributesAdapter(recorder)
+	adapter.Visit(version, opcodes.ACC_PUBLIC|opcodes.ACC_SYNTHETIC|opcodes.ACC_DEPRECATED, "p/C", "", "java/lang/Object", nil)
+	adapter.VisitInnerClass("p/C$D", "p/C", "D", opcodes.ACC_STATIC|opcodes.ACC_SYNTHETIC)
+	adapter.VisitField(opcodes.ACC_PRIVATE|opcodes.ACC_SYNTHETIC, "f", "I", "", nil).VisitEnd()
+	methodVisitor := adapter.VisitMethod(opcodes.ACC_PUBLIC|opcodes.ACC_DEPRECATED, "m", "()V", "", nil)
+	methodVisitor.VisitAnnotation("Lp/A;", true).VisitEnd()
+	methodVisitor.VisitCode()
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitMaxs(0, 1)
+	methodVisitor.VisitEnd()
+	methodVisitor = adapter.VisitMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "n", "()V", "", nil)
+	methodVisitor.VisitEnd()
+	adapter.VisitEnd()
+	return recorder.Trace()
+}
+
+func TestLegacyAttributesAdapter(t *testing.T) {
+	// The flags are replaced with attributes, visited before the inner classes and members of the class, and
+	// after the annotations but before the code of the methods. The inner class flags are kept, since they have no
+	// attribute equivalent.
+	assertTrace(t, legacyTrace(opcodes.V1_4), []string{
+		`class visit p/C 48 1 "p/C" "" "java/lang/Object" []`,
+		`class visit attribute p/C attribute Synthetic`,
+		`class visit attribute p/C attribute Deprecated`,
+		`class visit inner class p/C "p/C$D" "p/C" "D" 4104`,
+		`class visit field p/C 2 "f" "I" "" <nil>`,
+		`field visit attribute p/C.f I attribute Synthetic`,
+		`field visit end p/C.f I`,
+		`class visit method p/C 1 "m" "()V" "" []`,
+		`method visit annotation p/C.m()V "Lp/A;" true`,
+		`method visit attribute p/C.m()V attribute Deprecated`,
+		`method visit code p/C.m()V`,
+		`method visit insn p/C.m()V 177`,
+		`method visit maxs p/C.m()V 0 1`,
+		`method visit end p/C.m()V`,
+		`class visit method p/C 1025 "n" "()V" "" []`,
+		`method visit end p/C.n()V`,
+		`class visit end p/C`,
+	})
+}
+
+func TestLegacyAttributesAdapterRecentVersion(t *testing.T) {
+	// The classes of version 1.5 and later keep their flags.
+	assertTrace(t, legacyTrace(opcodes.V1_5), []string{
+		`class visit p/C 49 135169 "p/C" "" "java/lang/Object" []`,
+		`class visit inner class p/C "p/C$D" "p/C" "D" 4104`,
+		`class visit field p/C 4098 "f" "I" "" <nil>`,
+		`field visit end p/C.f I`,
+		`class visit method p/C 131073 "m" "()V" "" []`,
+		`method visit annotation p/C.m()V "Lp/A;" true`,
+		`method visit code p/C.m()V`,
+		`method visit insn p/C.m()V 177`,
+		`method visit maxs p/C.m()V 0 1`,
+		`method visit end p/C.m()V`,
+		`class visit method p/C 1025 "n" "()V" "" []`,
+		`method visit end p/C.n()V`,
+		`class visit end p/C`,
+	})
+}
+
+func TestLegacyAttributesAdapterWrittenClass(t *testing.T) {
+	// A ClassWriter writes the attributes, which are converted back to flags when the class is read.
+	classWriter := asm.NewClassWriter(nil)
+	adapter := commons.NewLegacyAttributesAdapter(classWriter)
+	adapter.Visit(opcodes.V1_4, opcodes.ACC_PUBLIC|opcodes.ACC_DEPRECATED, "p/C", "", "java/lang/Object", nil)
+	adapter.VisitField(opcodes.ACC_PRIVATE|opcodes.ACC_SYNTHETIC, "f", "I", "", nil).VisitEnd()
+	adapter.VisitEnd()
+	classFile, err := classWriter.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(recorder, 0)
+	assertTrace(t, recorder.Trace(), []string{
+		`class visit p/C 48 131073 "p/C" "" "java/lang/Object" []`,
+		`class visit field p/C 4098 "f" "I" "" <nil>`,
+		`field visit end p/C.f I`,
+		`class visit end p/C`,
+	})
+}
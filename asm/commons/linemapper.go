@@ -0,0 +1,188 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/smap"
+)
+
+// SYNTHETIC_LINE_BASE the default first line number allocated to the synthetic lines of a {@link LineMapper},
+// above the lines of (almost all) source files.
+const SYNTHETIC_LINE_BASE = 60000
+
+// LineMapper a {@link ClassVisitor} that adjusts the debug information of instrumented classes: it remaps or
+// removes the line numbers of the methods, replaces the source file name, and declares synthetic lines, which
+// map the code injected by an instrumentation tool to the tool source files. Synthetic lines are line numbers
+// above SyntheticLineBase, mapped to the tool source files by a JSR-45 source map in the SourceDebugExtension
+// of the class (appended to the existing one, if any), so that debuggers show the tool code when stepping in
+// injected code. Synthetic lines must be allocated with {@link AddSyntheticLine} before the class is visited,
+// since the source map is visited with the source file, before the methods, and are used in injected code with
+// {@link VisitSyntheticLine}. Classes without source file name (and without SourceFile) get no source map.
+type LineMapper struct {
+	helper.ClassAdapter
+	// MapLine if not nil, returns the new line number of the given line of the given method (given by its name
+	// and descriptor), or 0 to remove it. Synthetic lines are not mapped.
+	MapLine func(method string, line int) int
+	// SourceFile if not "", the new source file name of the class.
+	SourceFile string
+	// SyntheticLineBase the first line number allocated to synthetic lines. Source lines at or above this line
+	// are not supported.
+	SyntheticLineBase int
+	syntheticLines    []syntheticLine
+	sourceVisited     bool
+}
+
+// syntheticLine a line of a tool source file, mapped to the synthetic line SyntheticLineBase + its index.
+type syntheticLine struct {
+	file string
+	line int
+}
+
+// NewLineMapper constructs a new {@link LineMapper}, with a {@link SYNTHETIC_LINE_BASE} synthetic line base.
+func NewLineMapper(classVisitor asm.ClassVisitor) *LineMapper {
+	return &LineMapper{
+		ClassAdapter:      helper.ClassAdapter{Next: classVisitor},
+		SyntheticLineBase: SYNTHETIC_LINE_BASE,
+	}
+}
+
+// AddSyntheticLine returns the synthetic line number mapped to the given line of the given tool source file,
+// allocating it if needed.
+func (l *LineMapper) AddSyntheticLine(file string, line int) int {
+	for i, syntheticLine := range l.syntheticLines {
+		if syntheticLine.file == file && syntheticLine.line == line {
+			return l.SyntheticLineBase + i
+		}
+	}
+	l.syntheticLines = append(l.syntheticLines, syntheticLine{file, line})
+	return l.SyntheticLineBase + len(l.syntheticLines) - 1
+}
+
+// VisitSyntheticLine makes the given method visitor visit a line number for the given (synthetic) line, at
+// the current position in the code.
+func VisitSyntheticLine(methodVisitor asm.MethodVisitor, line int) {
+	label := &asm.Label{}
+	methodVisitor.VisitLabel(label)
+	methodVisitor.VisitLineNumber(line, label)
+}
+
+// sourceDebug returns the given SourceDebugExtension content, with the source map of the synthetic lines.
+func (l *LineMapper) sourceDebug(source, debug string) string {
+	if len(l.syntheticLines) == 0 {
+		return debug
+	}
+	var sourceMap *smap.SMAP
+	if debug != "" {
+		parsed, err := smap.Parse(debug)
+		if err != nil {
+			// Unknown content, which can't be extended.
+			return debug
+		}
+		sourceMap = parsed
+	} else {
+		sourceMap = smap.NewSMAP(source, "Java")
+	}
+	stratum := sourceMap.GetStratum(sourceMap.DefaultStratum)
+	if stratum == nil {
+		stratum = sourceMap.AddStratum(sourceMap.DefaultStratum)
+	}
+	if debug == "" {
+		stratum.AddLine(stratum.AddFile(source, ""), 1, l.SyntheticLineBase-1, 1)
+	}
+	fileIDs := make(map[string]int)
+	for i, syntheticLine := range l.syntheticLines {
+		fileID, ok := fileIDs[syntheticLine.file]
+		if !ok {
+			fileID = stratum.AddFile(syntheticLine.file, "")
+			fileIDs[syntheticLine.file] = fileID
+		}
+		stratum.AddLine(fileID, syntheticLine.line, 1, l.SyntheticLineBase+i)
+	}
+	return sourceMap.String()
+}
+
+func (l *LineMapper) VisitSource(source, debug string) {
+	l.sourceVisited = true
+	if l.SourceFile != "" {
+		source = l.SourceFile
+	}
+	if source != "" {
+		debug = l.sourceDebug(source, debug)
+	}
+	l.ClassAdapter.VisitSource(source, debug)
+}
+
+// visitSource visits the new source file of a class without source file, before the first event which can't
+// precede it.
+func (l *LineMapper) visitSource() {
+	if !l.sourceVisited {
+		l.sourceVisited = true
+		if l.SourceFile != "" {
+			l.ClassAdapter.VisitSource(l.SourceFile, l.sourceDebug(l.SourceFile, ""))
+		}
+	}
+}
+
+func (l *LineMapper) VisitModule(name string, access int, version string) asm.ModuleVisitor {
+	l.visitSource()
+	return l.ClassAdapter.VisitModule(name, access, version)
+}
+
+func (l *LineMapper) VisitOuterClass(owner, name, descriptor string) {
+	l.visitSource()
+	l.ClassAdapter.VisitOuterClass(owner, name, descriptor)
+}
+
+func (l *LineMapper) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	l.visitSource()
+	return l.ClassAdapter.VisitAnnotation(descriptor, visible)
+}
+
+func (l *LineMapper) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	l.visitSource()
+	return l.ClassAdapter.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+}
+
+func (l *LineMapper) VisitAttribute(attribute *asm.Attribute) {
+	l.visitSource()
+	l.ClassAdapter.VisitAttribute(attribute)
+}
+
+func (l *LineMapper) VisitInnerClass(name, outerName, innerName string, access int) {
+	l.visitSource()
+	l.ClassAdapter.VisitInnerClass(name, outerName, innerName, access)
+}
+
+func (l *LineMapper) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	l.visitSource()
+	return l.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+}
+
+func (l *LineMapper) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	l.visitSource()
+	methodVisitor := l.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil || l.MapLine == nil {
+		return methodVisitor
+	}
+	return &lineMapperMethodAdapter{MethodAdapter: helper.MethodAdapter{Next: methodVisitor}, mapper: l, method: name + descriptor}
+}
+
+func (l *LineMapper) VisitEnd() {
+	l.visitSource()
+	l.ClassAdapter.VisitEnd()
+}
+
+type lineMapperMethodAdapter struct {
+	helper.MethodAdapter
+	mapper *LineMapper
+	method string
+}
+
+func (a *lineMapperMethodAdapter) VisitLineNumber(line int, start *asm.Label) {
+	if line < a.mapper.SyntheticLineBase {
+		if line = a.mapper.MapLine(a.method, line); line <= 0 {
+			return
+		}
+	}
+	a.MethodAdapter.VisitLineNumber(line, start)
+}
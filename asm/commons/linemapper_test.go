@@ -0,0 +1,89 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// lineNumbersClass returns a class p/C, compiled from C.java, with the method "void m()" which has the source
+// lines 10 and 20, and the synthetic line 60000.
+func lineNumbersClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	sourceFile := classFile.SymbolTable.AddConstantUtf8("C.java")
+	classFile.AddAttribute("SourceFile", asm.NewByteVector().PutShort(sourceFile).Bytes())
+	m := classFile.AddMethod(opcodes.ACC_PUBLIC, "m", "()V", "", nil)
+	line10, line20 := &asm.Label{}, &asm.Label{}
+	m.VisitCode()
+	m.VisitLabel(line10)
+	m.VisitLineNumber(10, line10)
+	m.VisitInsn(opcodes.NOP)
+	m.VisitLabel(line20)
+	m.VisitLineNumber(20, line20)
+	m.VisitInsn(opcodes.NOP)
+	commons.VisitSyntheticLine(m, commons.SYNTHETIC_LINE_BASE)
+	m.VisitInsn(opcodes.RETURN)
+	m.VisitMaxs(0, 1)
+	m.VisitEnd()
+	return classFile.Bytes()
+}
+
+// newLineMapper returns a {@link commons.LineMapper} which renames the source file to D.java, maps the line 10
+// of m() to 110, removes its other lines, and has the synthetic line mapped to the line 5 of Tool.java.
+func newLineMapper(t *testing.T, next asm.ClassVisitor) *commons.LineMapper {
+	lineMapper := commons.NewLineMapper(next)
+	lineMapper.SourceFile = "D.java"
+	lineMapper.MapLine = func(method string, line int) int {
+		if method != "m()V" || line != 10 {
+			return 0
+		}
+		return line + 100
+	}
+	if line := lineMapper.AddSyntheticLine("Tool.java", 5); line != commons.SYNTHETIC_LINE_BASE {
+		t.Errorf("unexpected synthetic line %d", line)
+	}
+	if line := lineMapper.AddSyntheticLine("Tool.java", 5); line != commons.SYNTHETIC_LINE_BASE {
+		t.Errorf("synthetic line allocated twice: %d", line)
+	}
+	return lineMapper
+}
+
+func TestLineMapper(t *testing.T) {
+	reader, err := asm.NewClassReader(lineNumbersClass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(newLineMapper(t, recorder), 0)
+	// The source file is replaced, and the synthetic line is mapped to the tool source file.
+	sources := recorder.GetEvents(helper.CLASS_VISIT_SOURCE, "")
+	if len(sources) != 1 || sources[0].Args[0] != "D.java" {
+		t.Fatalf("unexpected source events %v", sources)
+	}
+	expectedDebug := "SMAP\nD.java\nJava\n*S Java\n*F\n1 D.java\n2 Tool.java\n*L\n1#1,59999:1\n5#2:60000\n*E\n"
+	if debug := sources[0].Args[1]; debug != expectedDebug {
+		t.Errorf("unexpected source map %q", debug)
+	}
+
+	// Line 10 is mapped to 110, line 20 is removed and the synthetic line is kept.
+	assertTrace(t, transformedTrace(t, lineNumbersClass(), "m()V", func(next asm.ClassVisitor) asm.ClassVisitor {
+		return newLineMapper(t, next)
+	}), []string{
+		`class visit method p/C 1 "m" "()V" "" []`,
+		`method visit code p/C.m()V`,
+		`method visit label p/C.m()V L0`,
+		`method visit line number p/C.m()V 110 L0`,
+		`method visit insn p/C.m()V 0`,
+		`method visit label p/C.m()V L1`,
+		`method visit insn p/C.m()V 0`,
+		`method visit label p/C.m()V L2`,
+		`method visit line number p/C.m()V 60000 L2`,
+		`method visit insn p/C.m()V 177`,
+		`method visit maxs p/C.m()V 0 1`,
+		`method visit end p/C.m()V`,
+	})
+}
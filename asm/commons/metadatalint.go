@@ -0,0 +1,334 @@
+package commons
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/signature"
+)
+
+// MetadataIssue an inconsistency between the descriptor of a class or member and its metadata (its generic
+// signature, its MethodParameters attribute or its Exceptions attribute), found by {@link LintMetadata}. Such
+// metadata is ignored by the JVM, but breaks the reflection APIs, the compilers and the IDEs using the class.
+type MetadataIssue struct {
+	// Artifact the path of the jar containing the class, or "".
+	Artifact string `json:"artifact,omitempty"`
+	Class    string `json:"class"`
+	// Member the name and descriptor of the method or field, or "" for the class itself.
+	Member  string `json:"member,omitempty"`
+	Message string `json:"message"`
+}
+
+func (m MetadataIssue) String() string {
+	location := m.Class
+	if m.Member != "" {
+		location += "." + m.Member
+	}
+	if m.Artifact != "" {
+		location = m.Artifact + "!" + location
+	}
+	return location + ": " + m.Message
+}
+
+// LintMetadata returns the inconsistencies between the descriptors and the metadata of the given class file:
+//   - the generic signatures which are malformed, or whose super class, interfaces, parameters, return type or
+//     field type do not match the erased types (the signatures of the constructors may omit the leading
+//     synthetic or mandated parameters, e.g. the outer instance of an inner class),
+//   - the MethodParameters attributes whose parameter count differs from the descriptor,
+//   - the throws clauses of the method signatures which differ from the Exceptions attributes.
+func LintMetadata(classFile []byte) ([]MetadataIssue, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	linter := &metadataLinter{}
+	reader.Accept(linter, asm.SKIP_CODE|asm.SKIP_FRAMES)
+	return linter.issues, nil
+}
+
+// LintMetadataJar returns the inconsistencies between the descriptors and the metadata of the class files of the
+// given jar (or zip) file (see {@link LintMetadata}).
+func LintMetadataJar(path string) ([]MetadataIssue, error) {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer jar.Close()
+	var issues []MetadataIssue
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || strings.HasSuffix(file.Name, "module-info.class") {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		classIssues, err := LintMetadata(classFile)
+		if err != nil {
+			return nil, errors.New(file.Name + ": " + err.Error())
+		}
+		for _, issue := range classIssues {
+			issue.Artifact = path
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// signatureParts the type signatures of a class signature (supers) or of a method signature (parameters,
+// returnType and throws).
+type signatureParts struct {
+	supers     []string
+	parameters []string
+	returnType string
+	throws     []string
+}
+
+// splitSignature returns the type signatures of the given class or method signature, or false if it is
+// malformed.
+func splitSignature(signatureValue string) (parts *signatureParts, ok bool) {
+	defer recoverInvalidSignature(&ok)
+	splitter := &signatureSplitter{}
+	signature.NewSignatureReader(signatureValue).Accept(splitter)
+	parts = &signatureParts{
+		supers:     writtenSignatures(splitter.supers),
+		parameters: writtenSignatures(splitter.parameters),
+		throws:     writtenSignatures(splitter.throws),
+	}
+	if splitter.returnType == nil {
+		return parts, len(parts.supers) > 0
+	}
+	parts.returnType = splitter.returnType.String()
+	for _, parameter := range parts.parameters {
+		if parameter == "V" {
+			return nil, false
+		}
+	}
+	return parts, true
+}
+
+// isValidTypeSignature returns whether the given field type signature is well formed.
+func isValidTypeSignature(signatureValue string) (ok bool) {
+	defer recoverInvalidSignature(&ok)
+	signature.NewSignatureReader(signatureValue).AcceptType(signature.NewSignatureWriter())
+	return true
+}
+
+// recoverInvalidSignature stops the panic of a {@link signature.SignatureReader} on a malformed signature, and
+// sets ok to false.
+func recoverInvalidSignature(ok *bool) {
+	if recovered := recover(); recovered != nil {
+		if _, isError := recovered.(error); !isError {
+			panic(recovered)
+		}
+		*ok = false
+	}
+}
+
+// signatureSplitter a {@link signature.SignatureVisitor} which writes each top level type signature of a class
+// or method signature with its own {@link signature.SignatureWriter}. The formal type parameters are ignored.
+type signatureSplitter struct {
+	signature.SignatureWriter
+	supers     []*signature.SignatureWriter
+	parameters []*signature.SignatureWriter
+	returnType *signature.SignatureWriter
+	throws     []*signature.SignatureWriter
+}
+
+// add returns a new writer, appended to the given writers.
+func (s *signatureSplitter) add(writers *[]*signature.SignatureWriter) signature.SignatureVisitor {
+	writer := signature.NewSignatureWriter()
+	*writers = append(*writers, writer)
+	return writer
+}
+
+func (s *signatureSplitter) VisitFormalTypeParameter(name string) {}
+
+func (s *signatureSplitter) VisitClassBound() signature.SignatureVisitor {
+	return signature.NewSignatureWriter()
+}
+
+func (s *signatureSplitter) VisitInterfaceBound() signature.SignatureVisitor {
+	return signature.NewSignatureWriter()
+}
+
+func (s *signatureSplitter) VisitSuperclass() signature.SignatureVisitor {
+	return s.add(&s.supers)
+}
+
+func (s *signatureSplitter) VisitInterface() signature.SignatureVisitor {
+	return s.add(&s.supers)
+}
+
+func (s *signatureSplitter) VisitParameterType() signature.SignatureVisitor {
+	return s.add(&s.parameters)
+}
+
+func (s *signatureSplitter) VisitReturnType() signature.SignatureVisitor {
+	s.returnType = signature.NewSignatureWriter()
+	return s.returnType
+}
+
+func (s *signatureSplitter) VisitExceptionType() signature.SignatureVisitor {
+	return s.add(&s.throws)
+}
+
+// writtenSignatures returns the signatures written by the given writers.
+func writtenSignatures(writers []*signature.SignatureWriter) []string {
+	var signatures []string
+	for _, writer := range writers {
+		signatures = append(signatures, writer.String())
+	}
+	return signatures
+}
+
+// erasedClassName returns the internal name of the class of the given class type signature, or "" for a type
+// variable or an array type signature.
+func erasedClassName(typeSignature string) string {
+	if !strings.HasPrefix(typeSignature, "L") {
+		return ""
+	}
+	var name strings.Builder
+	depth := 0
+	for _, c := range typeSignature[1 : len(typeSignature)-1] {
+		switch {
+		case c == '<':
+			depth++
+		case c == '>':
+			depth--
+		case depth > 0:
+		case c == '.':
+			name.WriteByte('$')
+		default:
+			name.WriteRune(c)
+		}
+	}
+	return name.String()
+}
+
+// isCompatible returns whether the given type signature can be erased to the given type descriptor, i.e. whether
+// they are both references, or both the same primitive type.
+func isCompatible(typeSignature, descriptor string) bool {
+	if typeSignature[0] == 'L' || typeSignature[0] == 'T' || typeSignature[0] == '[' {
+		return descriptor[0] == 'L' || descriptor[0] == '['
+	}
+	return typeSignature == descriptor
+}
+
+// metadataLinter a {@link ClassVisitor} which collects the {@link MetadataIssue}s of a class.
+type metadataLinter struct {
+	helper.ClassVisitor
+	class  string
+	issues []MetadataIssue
+}
+
+func (m *metadataLinter) report(member, message string) {
+	m.issues = append(m.issues, MetadataIssue{Class: m.class, Member: member, Message: message})
+}
+
+func (m *metadataLinter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	m.class = name
+	if signature == "" {
+		return
+	}
+	parts, ok := splitSignature(signature)
+	if !ok || len(parts.parameters) > 0 || parts.returnType != "" {
+		m.report("", "malformed class signature "+signature)
+		return
+	}
+	if superClass := erasedClassName(parts.supers[0]); superClass != superName {
+		m.report("", "signature super class "+superClass+" differs from super class "+superName)
+	}
+	signatureInterfaces := make([]string, 0, len(parts.supers)-1)
+	for _, itf := range parts.supers[1:] {
+		signatureInterfaces = append(signatureInterfaces, erasedClassName(itf))
+	}
+	if strings.Join(signatureInterfaces, ",") != strings.Join(interfaces, ",") {
+		m.report("", fmt.Sprintf("signature interfaces %v differ from interfaces %v", signatureInterfaces, interfaces))
+	}
+}
+
+func (m *metadataLinter) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	if signature != "" {
+		if !isValidTypeSignature(signature) {
+			m.report(name, "malformed field signature "+signature)
+		} else if !isCompatible(signature, descriptor) {
+			m.report(name, "field signature "+signature+" differs from descriptor "+descriptor)
+		}
+	}
+	return nil
+}
+
+func (m *metadataLinter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	return &metadataMethodLinter{linter: m, name: name, descriptor: descriptor, signature: signature, exceptions: exceptions}
+}
+
+// metadataMethodLinter a {@link MethodVisitor} which collects the {@link MetadataIssue}s of a method.
+type metadataMethodLinter struct {
+	helper.MethodVisitor
+	linter          *metadataLinter
+	name            string
+	descriptor      string
+	signature       string
+	exceptions      []string
+	parametersCount int
+}
+
+func (m *metadataMethodLinter) VisitParameter(name string, access int) {
+	m.parametersCount++
+}
+
+func (m *metadataMethodLinter) VisitEnd() {
+	member := m.name + m.descriptor
+	methodType := asm.GetMethodType(m.descriptor)
+	var parameters []string
+	for _, argumentType := range methodType.GetArgumentTypes() {
+		parameters = append(parameters, argumentType.GetDescriptor())
+	}
+	if m.parametersCount > 0 && m.parametersCount != len(parameters) {
+		m.linter.report(member, fmt.Sprintf("MethodParameters has %d parameters, descriptor has %d", m.parametersCount, len(parameters)))
+	}
+	if m.signature == "" {
+		return
+	}
+	parts, ok := splitSignature(m.signature)
+	if !ok || parts.returnType == "" {
+		m.linter.report(member, "malformed method signature "+m.signature)
+		return
+	}
+	// The signatures of the constructors may omit the leading synthetic and mandated parameters.
+	omitted := len(parameters) - len(parts.parameters)
+	if omitted < 0 || (omitted > 0 && m.name != "<init>") {
+		m.linter.report(member, fmt.Sprintf("signature has %d parameters, descriptor has %d", len(parts.parameters), len(parameters)))
+	} else {
+		for i, parameter := range parts.parameters {
+			if !isCompatible(parameter, parameters[omitted+i]) {
+				m.linter.report(member, fmt.Sprintf("signature parameter %d %s differs from descriptor parameter %s", omitted+i, parameter, parameters[omitted+i]))
+			}
+		}
+	}
+	if returnType := methodType.GetReturnType().GetDescriptor(); !isCompatible(parts.returnType, returnType) {
+		m.linter.report(member, "signature return type "+parts.returnType+" differs from descriptor return type "+returnType)
+	}
+	if len(parts.throws) == 0 {
+		return
+	}
+	if len(parts.throws) != len(m.exceptions) {
+		m.linter.report(member, fmt.Sprintf("signature throws %d exceptions, Exceptions attribute has %d", len(parts.throws), len(m.exceptions)))
+	}
+	for _, throw := range parts.throws {
+		if exception := erasedClassName(throw); exception != "" && !containsAny(m.exceptions, []string{exception}) {
+			m.linter.report(member, "signature exception "+exception+" is missing from the Exceptions attribute")
+		}
+	}
+}
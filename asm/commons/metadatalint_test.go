@@ -0,0 +1,44 @@
+package commons_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+func TestLintMetadata(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "A", "java/lang/Object")
+	classFile.AddField(0, "f", "I", "TT;", nil)
+	classFile.AddField(0, "g", "Ljava/util/List;", "Ljava/util/List<TT;>;", nil)
+	abstractMethod := opcodes.ACC_PUBLIC | opcodes.ACC_ABSTRACT
+	classFile.AddMethod(abstractMethod, "m", "(ILjava/lang/String;)V",
+		"<X:Ljava/lang/Exception;>(TT;)V^TX;^Ljava/io/IOException;", []string{"java/lang/Exception"}).VisitParameter("t", 0)
+	classFile.AddMethod(abstractMethod, "<init>", "(LA;I)V", "(I)V", nil)
+	classFile.AddMethod(abstractMethod, "n", "(Ljava/util/List;)I", "(Ljava/util/List<TT;>;)I", nil)
+	classSignature := classFile.SymbolTable.AddConstantUtf8("<T:Ljava/lang/Object;>Ljava/lang/Number;")
+	classFile.AddAttribute("Signature", asm.NewByteVector().PutShort(classSignature).Bytes())
+
+	issues, err := commons.LintMetadata(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, issue := range issues {
+		actual = append(actual, issue.String())
+	}
+	expected := []string{
+		"A: signature super class java/lang/Number differs from super class java/lang/Object",
+		"A.f: field signature TT; differs from descriptor I",
+		"A.m(ILjava/lang/String;)V: MethodParameters has 1 parameters, descriptor has 2",
+		"A.m(ILjava/lang/String;)V: signature has 1 parameters, descriptor has 2",
+		"A.m(ILjava/lang/String;)V: signature throws 2 exceptions, Exceptions attribute has 1",
+		"A.m(ILjava/lang/String;)V: signature exception java/io/IOException is missing from the Exceptions attribute",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected issues:\n%s", strings.Join(actual, "\n"))
+	}
+}
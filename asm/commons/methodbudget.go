@@ -0,0 +1,150 @@
+package commons
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// Bailout behaviors of a {@link MethodBudgetTransformer}, for the methods which exceed their budget or whose
+// transformation fails.
+const (
+	// BAILOUT_COPY the method is copied through untouched.
+	BAILOUT_COPY = iota
+	// BAILOUT_SKIP the method is removed from the class.
+	BAILOUT_SKIP
+	// BAILOUT_ERROR the transformation of the class fails: the method is removed and the error is recorded in
+	// Err.
+	BAILOUT_ERROR
+)
+
+var bailoutNames = []string{"copied", "skipped", "failed"}
+
+// BailedOutMethod a method which exceeded its budget, or whose transformation failed.
+type BailedOutMethod struct {
+	Owner string
+	// Method the name and descriptor of the method.
+	Method string
+	Reason string
+	// Bailout the bailout behavior applied to the method.
+	Bailout int
+	// Duration the time spent transforming the method before bailing out.
+	Duration time.Duration
+}
+
+func (b BailedOutMethod) String() string {
+	return b.Owner + "." + b.Method + " " + bailoutNames[b.Bailout] + ": " + b.Reason
+}
+
+// MethodBudgetTransformer a {@link ClassVisitor} that transforms each method of the visited class with a
+// function working on a {@link MethodNode}, within a per method budget: methods with more than MaxInstructions
+// instructions are not transformed, and transformations which take more than MaxDuration are abandoned (their
+// context is cancelled, so that cooperative transformations can stop early). These methods, and those whose
+// transformation returns an error, are handled as specified by Bailout, and are reported in BailedOut. Like
+// with {@link MethodNode}, the annotations and non standard attributes of the methods are not kept.
+type MethodBudgetTransformer struct {
+	helper.ClassAdapter
+	// Transform transforms the given method in place. It must stop when its context is done.
+	Transform func(ctx context.Context, owner string, method *tree.MethodNode) error
+	// MaxInstructions the maximum number of instructions (including labels and line numbers) of the transformed
+	// methods, or 0 for no limit.
+	MaxInstructions int
+	// MaxDuration the maximum duration of the transformation of each method, or 0 for no limit.
+	MaxDuration time.Duration
+	// Bailout {@link BAILOUT_COPY}, {@link BAILOUT_SKIP} or {@link BAILOUT_ERROR}.
+	Bailout int
+	// BailedOut the methods which exceeded their budget or whose transformation failed.
+	BailedOut []BailedOutMethod
+	// Err the error of the first method bailed out with {@link BAILOUT_ERROR}, if any.
+	Err       error
+	className string
+}
+
+// NewMethodBudgetTransformer constructs a new {@link MethodBudgetTransformer} with the given method
+// transformation, without budget and with the {@link BAILOUT_COPY} behavior.
+func NewMethodBudgetTransformer(classVisitor asm.ClassVisitor, transform func(ctx context.Context, owner string, method *tree.MethodNode) error) *MethodBudgetTransformer {
+	return &MethodBudgetTransformer{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		Transform:    transform,
+	}
+}
+
+// Report returns a description of the methods which bailed out, one per line.
+func (m *MethodBudgetTransformer) Report() string {
+	lines := make([]string, len(m.BailedOut))
+	for i, bailedOut := range m.BailedOut {
+		lines[i] = bailedOut.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *MethodBudgetTransformer) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	m.className = name
+	m.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (m *MethodBudgetTransformer) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	return &budgetMethodNode{MethodNode: tree.NewMethodNode(access, name, descriptor, signature, exceptions), transformer: m}
+}
+
+// transform transforms the given method within the budget, and visits the result.
+func (m *MethodBudgetTransformer) transform(method *tree.MethodNode) {
+	if m.MaxInstructions > 0 && len(method.Instructions) > m.MaxInstructions {
+		m.bailOut(method, strconv.Itoa(len(method.Instructions))+" instructions exceed the budget of "+
+			strconv.Itoa(m.MaxInstructions), 0)
+		return
+	}
+	transformed := tree.NewMethodNode(method.Access, method.Name, method.Descriptor, method.Signature, method.Exceptions)
+	method.AcceptMethod(transformed)
+	ctx, cancel := context.Background(), func() {}
+	if m.MaxDuration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, m.MaxDuration)
+	}
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Transform(ctx, m.className, transformed)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			m.bailOut(method, err.Error(), time.Since(start))
+			return
+		}
+		transformed.Accept(m.ClassAdapter)
+	case <-ctx.Done():
+		// The transformation goroutine works on its own copy of the method, which is dropped.
+		m.bailOut(method, "transformation exceeded the budget of "+m.MaxDuration.String(), time.Since(start))
+	}
+}
+
+func (m *MethodBudgetTransformer) bailOut(method *tree.MethodNode, reason string, duration time.Duration) {
+	bailedOut := BailedOutMethod{m.className, method.Name + method.Descriptor, reason, m.Bailout, duration}
+	m.BailedOut = append(m.BailedOut, bailedOut)
+	switch m.Bailout {
+	case BAILOUT_COPY:
+		method.Accept(m.ClassAdapter)
+	case BAILOUT_ERROR:
+		if m.Err == nil {
+			m.Err = errors.New("Illegal State - " + bailedOut.String())
+		}
+	}
+}
+
+// budgetMethodNode a {@link MethodNode} which transforms itself with its {@link MethodBudgetTransformer} when
+// it is complete.
+type budgetMethodNode struct {
+	*tree.MethodNode
+	transformer *MethodBudgetTransformer
+}
+
+func (b *budgetMethodNode) VisitEnd() {
+	b.transformer.transform(b.MethodNode)
+}
@@ -0,0 +1,139 @@
+package commons_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// budgetClass returns a class p/C with the methods "small", "slow" and "failing", made of a RETURN, and the
+// method "big", made of 20 NOPs and a RETURN.
+func budgetClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	for _, name := range []string{"small", "big", "slow", "failing"} {
+		methodVisitor := classFile.AddMethod(opcodes.ACC_PUBLIC, name, "()V", "", nil)
+		methodVisitor.VisitCode()
+		if name == "big" {
+			for i := 0; i < 20; i++ {
+				methodVisitor.VisitInsn(opcodes.NOP)
+			}
+		}
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 1)
+		methodVisitor.VisitEnd()
+	}
+	return classFile.Bytes()
+}
+
+// budgetTransform makes the methods final, waits until its context is done for the "slow" method, and fails
+// for the "failing" method.
+func budgetTransform(ctx context.Context, owner string, method *tree.MethodNode) error {
+	switch method.Name {
+	case "slow":
+		<-ctx.Done()
+		return ctx.Err()
+	case "failing":
+		return errors.New("unsupported method")
+	}
+	method.Access |= opcodes.ACC_FINAL
+	return nil
+}
+
+func TestMethodBudgetTransformer(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		bailout  int
+		methods  []string
+		report   []string
+		expected string
+	}{
+		// The methods over budget and the failed ones are copied untouched, only "small" is final.
+		{"copy", commons.BAILOUT_COPY, []string{
+			`class visit method p/C 17 "small" "()V" "" []`,
+			`class visit method p/C 1 "big" "()V" "" []`,
+			`class visit method p/C 1 "slow" "()V" "" []`,
+			`class visit method p/C 1 "failing" "()V" "" []`,
+		}, []string{
+			"p/C.big()V copied: 21 instructions exceed the budget of 10",
+			"p/C.slow()V copied: transformation exceeded the budget of 50ms",
+			"p/C.failing()V copied: unsupported method",
+		}, ""},
+		{"skip", commons.BAILOUT_SKIP, []string{
+			`class visit method p/C 17 "small" "()V" "" []`,
+		}, []string{
+			"p/C.big()V skipped: 21 instructions exceed the budget of 10",
+			"p/C.slow()V skipped: transformation exceeded the budget of 50ms",
+			"p/C.failing()V skipped: unsupported method",
+		}, ""},
+		// The error is the one of the first method which bailed out.
+		{"error", commons.BAILOUT_ERROR, []string{
+			`class visit method p/C 17 "small" "()V" "" []`,
+		}, []string{
+			"p/C.big()V failed: 21 instructions exceed the budget of 10",
+			"p/C.slow()V failed: transformation exceeded the budget of 50ms",
+			"p/C.failing()V failed: unsupported method",
+		}, "Illegal State - p/C.big()V failed: 21 instructions exceed the budget of 10"},
+	} {
+		reader, err := asm.NewClassReader(budgetClass())
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := asmtest.NewRecorder(nil)
+		transformer := commons.NewMethodBudgetTransformer(recorder, budgetTransform)
+		transformer.MaxInstructions = 10
+		transformer.MaxDuration = 50 * time.Millisecond
+		transformer.Bailout = test.bailout
+		reader.Accept(transformer, 0)
+
+		var methods []string
+		for _, line := range recorder.Trace() {
+			if strings.HasPrefix(line, "class visit method ") {
+				methods = append(methods, line)
+			}
+		}
+		assertTrace(t, methods, test.methods)
+		if report := transformer.Report(); report != strings.Join(test.report, "\n") {
+			t.Errorf("%s: unexpected report\n%s", test.name, report)
+		}
+		if err := transformer.Err; (err == nil && test.expected != "") || (err != nil && err.Error() != test.expected) {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
+
+func TestMethodBudgetTransformerWithoutBudget(t *testing.T) {
+	reader, err := asm.NewClassReader(budgetClass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	transformer := commons.NewMethodBudgetTransformer(recorder, func(ctx context.Context, owner string, method *tree.MethodNode) error {
+		method.Access |= opcodes.ACC_FINAL
+		return nil
+	})
+	reader.Accept(transformer, 0)
+	// All the methods are transformed, whatever their size.
+	var methods []string
+	for _, line := range recorder.Trace() {
+		if strings.HasPrefix(line, "class visit method ") {
+			methods = append(methods, line)
+		}
+	}
+	assertTrace(t, methods, []string{
+		`class visit method p/C 17 "small" "()V" "" []`,
+		`class visit method p/C 17 "big" "()V" "" []`,
+		`class visit method p/C 17 "slow" "()V" "" []`,
+		`class visit method p/C 17 "failing" "()V" "" []`,
+	})
+	if len(transformer.BailedOut) != 0 || transformer.Err != nil {
+		t.Errorf("unexpected bail outs %v %v", transformer.BailedOut, transformer.Err)
+	}
+}
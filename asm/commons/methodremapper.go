@@ -0,0 +1,112 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// MethodRemapper a {@link MethodVisitor} that remaps types with a {@link Remapper}.
+type MethodRemapper struct {
+	helper.MethodAdapter
+	remapper Remapper
+}
+
+// NewMethodRemapper constructs a new {@link MethodRemapper} delegating to the given visitor.
+func NewMethodRemapper(methodVisitor asm.MethodVisitor, remapper Remapper) *MethodRemapper {
+	return &MethodRemapper{
+		MethodAdapter: helper.MethodAdapter{Next: methodVisitor},
+		remapper:      remapper,
+	}
+}
+
+func (m *MethodRemapper) VisitAnnotationDefault() asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(m.MethodAdapter.VisitAnnotationDefault(), m.remapper)
+}
+
+func (m *MethodRemapper) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(m.MethodAdapter.VisitAnnotation(MapDesc(m.remapper, descriptor), visible), m.remapper)
+}
+
+func (m *MethodRemapper) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(m.MethodAdapter.VisitTypeAnnotation(typeRef, typePath, MapDesc(m.remapper, descriptor), visible), m.remapper)
+}
+
+func (m *MethodRemapper) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(m.MethodAdapter.VisitParameterAnnotation(parameter, MapDesc(m.remapper, descriptor), visible), m.remapper)
+}
+
+func (m *MethodRemapper) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	m.MethodAdapter.VisitFrame(typed, nLocal, m.remapFrameTypes(nLocal, local), nStack, m.remapFrameTypes(nStack, stack))
+}
+
+func (m *MethodRemapper) remapFrameTypes(numTypes int, frameTypes interface{}) interface{} {
+	types, ok := frameTypes.([]interface{})
+	if !ok {
+		return frameTypes
+	}
+	remapped := make([]interface{}, len(types))
+	copy(remapped, types)
+	for i := 0; i < numTypes && i < len(remapped); i++ {
+		if internalName, ok := remapped[i].(string); ok {
+			remapped[i] = MapType(m.remapper, internalName)
+		}
+	}
+	return remapped
+}
+
+func (m *MethodRemapper) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.MethodAdapter.VisitFieldInsn(opcode, MapType(m.remapper, owner), m.remapper.MapFieldName(owner, name, descriptor), MapDesc(m.remapper, descriptor))
+}
+
+func (m *MethodRemapper) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.MethodAdapter.VisitMethodInsn(opcode, MapType(m.remapper, owner), m.remapper.MapMethodName(owner, name, descriptor), MapMethodDesc(m.remapper, descriptor))
+}
+
+func (m *MethodRemapper) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.MethodAdapter.VisitMethodInsnB(opcode, MapType(m.remapper, owner), m.remapper.MapMethodName(owner, name, descriptor), MapMethodDesc(m.remapper, descriptor), isInterface)
+}
+
+func (m *MethodRemapper) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	remappedBootstrapMethodArguments := make([]interface{}, len(bootstrapMethodArguments))
+	for i, bootstrapMethodArgument := range bootstrapMethodArguments {
+		remappedBootstrapMethodArguments[i] = MapValue(m.remapper, bootstrapMethodArgument)
+	}
+	m.MethodAdapter.VisitInvokeDynamicInsn(
+		m.remapper.MapInvokeDynamicMethodName(name, descriptor),
+		MapMethodDesc(m.remapper, descriptor),
+		MapValue(m.remapper, bootstrapMethodHande).(*asm.Handle),
+		remappedBootstrapMethodArguments...,
+	)
+}
+
+func (m *MethodRemapper) VisitTypeInsn(opcode int, typed string) {
+	m.MethodAdapter.VisitTypeInsn(opcode, MapType(m.remapper, typed))
+}
+
+func (m *MethodRemapper) VisitLdcInsn(value interface{}) {
+	m.MethodAdapter.VisitLdcInsn(MapValue(m.remapper, value))
+}
+
+func (m *MethodRemapper) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.MethodAdapter.VisitMultiANewArrayInsn(MapDesc(m.remapper, descriptor), numDimensions)
+}
+
+func (m *MethodRemapper) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(m.MethodAdapter.VisitInsnAnnotation(typeRef, typePath, MapDesc(m.remapper, descriptor), visible), m.remapper)
+}
+
+func (m *MethodRemapper) VisitTryCatchBlock(start, end, handler *asm.Label, typed string) {
+	m.MethodAdapter.VisitTryCatchBlock(start, end, handler, MapType(m.remapper, typed))
+}
+
+func (m *MethodRemapper) VisitTryCatchAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(m.MethodAdapter.VisitTryCatchAnnotation(typeRef, typePath, MapDesc(m.remapper, descriptor), visible), m.remapper)
+}
+
+func (m *MethodRemapper) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	m.MethodAdapter.VisitLocalVariable(name, MapDesc(m.remapper, descriptor), MapSignature(m.remapper, signature, true), start, end, index)
+}
+
+func (m *MethodRemapper) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return newAnnotationRemapperOrNil(m.MethodAdapter.VisitLocalVariableAnnotation(typeRef, typePath, start, end, index, MapDesc(m.remapper, descriptor), visible), m.remapper)
+}
@@ -0,0 +1,428 @@
+package commons
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// MinimizeOptions the options of {@link MinimizeClass}.
+type MinimizeOptions struct {
+	// MaxTests the maximum number of candidate class files given to the predicate, or 0 for no limit.
+	MaxTests int
+	// KeepInstructions whether the instructions of the methods must be kept. Otherwise the instructions are
+	// removed one by one, and the Code attributes are rewritten with a {@link MethodWriter}.
+	KeepInstructions bool
+}
+
+// MinimizeClass returns a minimal version of the given class file for which the given predicate still holds
+// (e.g. "the parser still panics"), to produce minimal reproducers of parser bugs. The class is reduced with the
+// delta debugging algorithm (ddmin), by removing its methods, its fields, its attributes, the attributes of its
+// members and, unless {@link MinimizeOptions#KeepInstructions} is set, the instructions of its methods, until no
+// single element can be removed. The constant pool, the class header and the instructions operands are kept
+// unchanged, and the candidate class files are not verified: the predicate must reject the candidates which do
+// not reproduce the problem for the expected reason. Returns an error if the predicate does not hold for the given
+// class file, or if its structure can't be parsed.
+func MinimizeClass(classFile []byte, predicate func(classFile []byte) bool, options MinimizeOptions) ([]byte, error) {
+	if !predicate(classFile) {
+		return nil, errors.New("Illegal Argument - the predicate does not hold for the class to minimize")
+	}
+	model, err := newMinimizedClass(classFile)
+	if err != nil {
+		return nil, err
+	}
+	m := &minimizer{predicate: predicate, options: options, tests: 1}
+	for changed := true; changed && !m.exhausted(); {
+		changed = false
+		methods := model.methods
+		changed = m.reduce(len(methods), func(keep []bool) *minimizedClass {
+			return model.withMembers(model.fields, filterMembers(methods, keep))
+		}, &model) || changed
+		fields := model.fields
+		changed = m.reduce(len(fields), func(keep []bool) *minimizedClass {
+			return model.withMembers(filterMembers(fields, keep), model.methods)
+		}, &model) || changed
+		attributes := model.attributes
+		changed = m.reduce(len(attributes), func(keep []bool) *minimizedClass {
+			candidate := *model
+			candidate.attributes = filterAttributes(attributes, keep)
+			return &candidate
+		}, &model) || changed
+		for _, field := range []bool{true, false} {
+			members := model.methods
+			if field {
+				members = model.fields
+			}
+			for i := range members {
+				changed = m.reduceMemberAttributes(&model, field, i) || changed
+				if !field && !options.KeepInstructions {
+					changed = m.reduceInstructions(&model, i) || changed
+				}
+			}
+		}
+	}
+	return model.toByteArray(), nil
+}
+
+// PanicPredicate returns a predicate, for {@link MinimizeClass}, which holds if the given function panics when
+// called with the candidate class file.
+func PanicPredicate(parse func(classFile []byte)) func(classFile []byte) bool {
+	return func(classFile []byte) (panicked bool) {
+		defer func() {
+			if recover() != nil {
+				panicked = true
+			}
+		}()
+		parse(classFile)
+		return false
+	}
+}
+
+// minimizedAttribute an attribute of a {@link minimizedClass}.
+type minimizedAttribute struct {
+	name string
+	// content the whole attribute, including its attribute_name_index and attribute_length fields.
+	content []byte
+}
+
+// minimizedMember a field or method of a {@link minimizedClass}.
+type minimizedMember struct {
+	// info the access_flags, name_index and descriptor_index fields of the member.
+	info       []byte
+	attributes []minimizedAttribute
+}
+
+// minimizedClass the structure of a class file being minimized.
+type minimizedClass struct {
+	// header the class file up to the interfaces, included.
+	header     []byte
+	fields     []minimizedMember
+	methods    []minimizedMember
+	attributes []minimizedAttribute
+}
+
+func newMinimizedClass(classFile []byte) (model *minimizedClass, err error) {
+	defer func() {
+		if recover() != nil {
+			model, err = nil, errors.New("Illegal Argument - malformed class file structure")
+		}
+	}()
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	index := reader.Index()
+	// The magic, minor_version, major_version and constant_pool_count fields use 10 bytes, followed by the
+	// constant pool, the access_flags, this_class, super_class and interfaces_count fields, and the interfaces.
+	interfacesCountOffset := 10 + reader.GetConstantPoolSizeInBytes() + 6
+	interfacesCount := int(classFile[interfacesCountOffset])<<8 | int(classFile[interfacesCountOffset+1])
+	model = &minimizedClass{header: classFile[:interfacesCountOffset+2+2*interfacesCount]}
+	attributes := func(ranges []asm.AttributeRange) []minimizedAttribute {
+		result := make([]minimizedAttribute, len(ranges))
+		for i, attribute := range ranges {
+			result[i] = minimizedAttribute{attribute.Name, classFile[attribute.Start:attribute.End]}
+		}
+		return result
+	}
+	members := func(indexes []asm.MemberIndex) []minimizedMember {
+		result := make([]minimizedMember, len(indexes))
+		for i, member := range indexes {
+			result[i] = minimizedMember{classFile[member.Start : member.Start+6], attributes(member.Attributes)}
+		}
+		return result
+	}
+	model.fields, model.methods, model.attributes = members(index.Fields), members(index.Methods), attributes(index.Attributes)
+	return model, nil
+}
+
+// withMembers returns a copy of this class with the given fields and methods.
+func (m *minimizedClass) withMembers(fields, methods []minimizedMember) *minimizedClass {
+	candidate := *m
+	candidate.fields, candidate.methods = fields, methods
+	return &candidate
+}
+
+// withMemberAttributes returns a copy of this class in which the attributes of the given field or method are
+// replaced with the given ones.
+func (m *minimizedClass) withMemberAttributes(field bool, index int, attributes []minimizedAttribute) *minimizedClass {
+	members := m.methods
+	if field {
+		members = m.fields
+	}
+	members = append([]minimizedMember(nil), members...)
+	members[index].attributes = attributes
+	if field {
+		return m.withMembers(members, m.methods)
+	}
+	return m.withMembers(m.fields, members)
+}
+
+func (m *minimizedClass) toByteArray() []byte {
+	output := asm.NewByteVector().PutByteArray(m.header, 0, len(m.header))
+	putAttributes := func(attributes []minimizedAttribute) {
+		output.PutShort(len(attributes))
+		for _, attribute := range attributes {
+			output.PutByteArray(attribute.content, 0, len(attribute.content))
+		}
+	}
+	for _, members := range [][]minimizedMember{m.fields, m.methods} {
+		output.PutShort(len(members))
+		for _, member := range members {
+			output.PutByteArray(member.info, 0, len(member.info))
+			putAttributes(member.attributes)
+		}
+	}
+	putAttributes(m.attributes)
+	return output.Bytes()
+}
+
+func filterMembers(members []minimizedMember, keep []bool) []minimizedMember {
+	var result []minimizedMember
+	for i, member := range members {
+		if keep[i] {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+func filterAttributes(attributes []minimizedAttribute, keep []bool) []minimizedAttribute {
+	var result []minimizedAttribute
+	for i, attribute := range attributes {
+		if keep[i] {
+			result = append(result, attribute)
+		}
+	}
+	return result
+}
+
+// minimizer the state of a {@link MinimizeClass} call.
+type minimizer struct {
+	predicate func(classFile []byte) bool
+	options   MinimizeOptions
+	// tests the number of class files given to the predicate.
+	tests int
+}
+
+func (m *minimizer) exhausted() bool {
+	return m.options.MaxTests > 0 && m.tests >= m.options.MaxTests
+}
+
+// test returns whether the predicate holds for the given candidate, which may be nil if it can't be built.
+func (m *minimizer) test(candidate *minimizedClass) bool {
+	if candidate == nil || m.exhausted() {
+		return false
+	}
+	m.tests++
+	return m.predicate(candidate.toByteArray())
+}
+
+// reduce removes elements from a list of n elements with the ddmin algorithm, by testing the candidates built
+// by the given function from the elements to keep. The current class is updated with each successful candidate.
+// Returns whether at least one element was removed.
+func (m *minimizer) reduce(n int, build func(keep []bool) *minimizedClass, model **minimizedClass) bool {
+	keep := make([]bool, n)
+	for i := range keep {
+		keep[i] = true
+	}
+	remaining := n
+	removed := false
+	granularity := 2
+	for remaining > 0 && !m.exhausted() {
+		if granularity > remaining {
+			granularity = remaining
+		}
+		// Tries to remove each of the granularity chunks of the remaining elements.
+		var indexes []int
+		for i, kept := range keep {
+			if kept {
+				indexes = append(indexes, i)
+			}
+		}
+		reduced := false
+		for chunk := 0; chunk < granularity && !reduced; chunk++ {
+			start, end := chunk*remaining/granularity, (chunk+1)*remaining/granularity
+			candidateKeep := append([]bool(nil), keep...)
+			for _, index := range indexes[start:end] {
+				candidateKeep[index] = false
+			}
+			if candidate := build(candidateKeep); m.test(candidate) {
+				*model = candidate
+				keep = candidateKeep
+				remaining -= end - start
+				reduced, removed = true, true
+			}
+		}
+		if reduced {
+			if granularity > 2 {
+				granularity--
+			}
+		} else if granularity < remaining {
+			granularity *= 2
+		} else {
+			break
+		}
+	}
+	return removed
+}
+
+// reduceMemberAttributes removes the attributes of the given field or method with the ddmin algorithm.
+func (m *minimizer) reduceMemberAttributes(model **minimizedClass, field bool, index int) bool {
+	members := (*model).methods
+	if field {
+		members = (*model).fields
+	}
+	attributes := members[index].attributes
+	return m.reduce(len(attributes), func(keep []bool) *minimizedClass {
+		return (*model).withMemberAttributes(field, index, filterAttributes(attributes, keep))
+	}, model)
+}
+
+// insnEventKinds the kinds of the method events which are instructions.
+var insnEventKinds = map[int]bool{
+	helper.METHOD_VISIT_INSN: true, helper.METHOD_VISIT_INT_INSN: true, helper.METHOD_VISIT_VAR_INSN: true,
+	helper.METHOD_VISIT_TYPE_INSN: true, helper.METHOD_VISIT_FIELD_INSN: true, helper.METHOD_VISIT_METHOD_INSN: true,
+	helper.METHOD_VISIT_INVOKE_DYNAMIC_INSN: true, helper.METHOD_VISIT_JUMP_INSN: true,
+	helper.METHOD_VISIT_LDC_INSN: true, helper.METHOD_VISIT_IINC_INSN: true,
+	helper.METHOD_VISIT_TABLE_SWITCH_INSN: true, helper.METHOD_VISIT_LOOKUP_SWITCH_INSN: true,
+	helper.METHOD_VISIT_MULTI_ANEW_ARRAY_INSN: true,
+}
+
+// reduceInstructions removes the instructions of the given method with the ddmin algorithm. Each candidate Code
+// attribute is generated with a {@link MethodWriter}, from the code of the method read from the current class,
+// without the removed instructions (the labels, frames, maxs, exception handlers and debug information are kept
+// unchanged). The candidates which would need new constants are skipped.
+func (m *minimizer) reduceInstructions(model **minimizedClass, index int) bool {
+	codeIndex := -1
+	for i, attribute := range (*model).methods[index].attributes {
+		if attribute.name == "Code" {
+			codeIndex = i
+		}
+	}
+	if codeIndex == -1 {
+		return false
+	}
+	classFile := (*model).toByteArray()
+	recorder := recordCode(classFile, index)
+	if recorder == nil {
+		return false
+	}
+	insnCount := 0
+	for _, event := range recorder.Events {
+		if insnEventKinds[event.Kind] {
+			insnCount++
+		}
+	}
+	return m.reduce(insnCount, func(keep []bool) *minimizedClass {
+		code := rewriteCode(classFile, index, keep)
+		if code == nil {
+			return nil
+		}
+		attributes := append([]minimizedAttribute(nil), (*model).methods[index].attributes...)
+		if len(code) == 0 {
+			attributes = append(attributes[:codeIndex], attributes[codeIndex+1:]...)
+		} else {
+			attributes[codeIndex] = minimizedAttribute{"Code", code}
+		}
+		return (*model).withMemberAttributes(false, index, attributes)
+	}, model)
+}
+
+// methodCodeRecorder a {@link ClassVisitor} which records the code events of the method of a class with the given
+// index.
+type methodCodeRecorder struct {
+	helper.ClassVisitor
+	index      int
+	methods    int
+	name       string
+	descriptor string
+	recorder   *helper.MethodRecorder
+}
+
+func (m *methodCodeRecorder) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	m.methods++
+	if m.methods-1 != m.index {
+		return nil
+	}
+	m.name, m.descriptor, m.recorder = name, descriptor, helper.NewMethodRecorder()
+	return m.recorder
+}
+
+// recordCode returns the recorded code events of the method of the given class with the given index, or nil if
+// the class can't be read.
+func recordCode(classFile []byte, index int) (recorder *helper.MethodRecorder) {
+	codeRecorder, _ := readCode(classFile, index)
+	if codeRecorder == nil {
+		return nil
+	}
+	return codeRecorder.recorder
+}
+
+// readCode reads the given class with a {@link methodCodeRecorder}, and returns it with the class reader, or nil
+// if the class can't be read.
+func readCode(classFile []byte, index int) (codeRecorder *methodCodeRecorder, reader *asm.ClassReader) {
+	defer func() {
+		if recover() != nil {
+			codeRecorder, reader = nil, nil
+		}
+	}()
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, nil
+	}
+	codeRecorder = &methodCodeRecorder{index: index}
+	reader.Accept(codeRecorder, 0)
+	if codeRecorder.recorder == nil {
+		return nil, nil
+	}
+	return codeRecorder, reader
+}
+
+// rewriteCode returns the Code attribute of the method of the given class with the given index, without the
+// instructions which must not be kept, or an empty array if no instruction is kept. Returns nil if the attribute
+// can't be generated without new constants.
+func rewriteCode(classFile []byte, index int, keep []bool) (code []byte) {
+	defer func() {
+		if recover() != nil {
+			code = nil
+		}
+	}()
+	codeRecorder, reader := readCode(classFile, index)
+	if codeRecorder == nil {
+		return nil
+	}
+	// Keeps only the code events, and the kept instructions.
+	recorder := codeRecorder.recorder
+	var events []*helper.Event
+	insnIndex := 0
+	for _, event := range recorder.Events {
+		if event.Kind < helper.METHOD_VISIT_CODE || event.Kind == helper.METHOD_VISIT_END {
+			continue
+		}
+		if insnEventKinds[event.Kind] {
+			insnIndex++
+			if !keep[insnIndex-1] {
+				continue
+			}
+		}
+		events = append(events, event)
+	}
+	recorder.Events = events
+
+	symbolTable := asm.NewSymbolTableFromClassReader(reader)
+	symbolTable.SetMajorVersionAndClassName(reader.GetMajorVersion(), reader.GetClassName())
+	constantPoolCount := symbolTable.GetConstantPoolCount()
+	methodWriter := asm.NewMethodWriter(symbolTable, 0, codeRecorder.name, codeRecorder.descriptor, "", nil)
+	if err := recorder.Replay(methodWriter); err != nil {
+		return nil
+	}
+	methodWriter.ComputeMethodInfoSize()
+	if methodWriter.GetError() != nil || symbolTable.GetConstantPoolCount() != constantPoolCount {
+		return nil
+	}
+	output := asm.NewByteVector()
+	methodWriter.PutMethodInfo(output)
+	// Skips the access_flags, name_index, descriptor_index and attributes_count fields.
+	return output.Bytes()[8:]
+}
@@ -0,0 +1,98 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// idivPanicker a class visitor which panics on the IDIV instructions, to simulate a parser bug.
+type idivPanicker struct {
+	helper.ClassVisitor
+}
+
+func (i idivPanicker) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	return idivMethodPanicker{}
+}
+
+type idivMethodPanicker struct {
+	helper.MethodVisitor
+}
+
+func (i idivMethodPanicker) VisitInsn(opcode int) {
+	if opcode == opcodes.IDIV {
+		panic("division")
+	}
+}
+
+func TestMinimizeClass(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	sourceFileName := classFile.SymbolTable.AddConstantUtf8("A.java")
+	classFile.AddAttribute("SourceFile", asm.NewByteVector().PutShort(sourceFileName).Bytes())
+
+	result, err := asm.AddField(classFile.Bytes(), opcodes.ACC_PRIVATE, "f", "I", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		isB := name == "b"
+		result, err = asm.AddMethod(result, opcodes.ACC_STATIC, name, "(II)I", func(methodVisitor asm.MethodVisitor) {
+			methodVisitor.VisitCode()
+			methodVisitor.VisitVarInsn(opcodes.ILOAD, 0)
+			methodVisitor.VisitVarInsn(opcodes.ILOAD, 1)
+			methodVisitor.VisitInsn(opcodes.IADD)
+			methodVisitor.VisitVarInsn(opcodes.ILOAD, 1)
+			if isB {
+				methodVisitor.VisitInsn(opcodes.IDIV)
+			} else {
+				methodVisitor.VisitInsn(opcodes.IMUL)
+			}
+			methodVisitor.VisitInsn(opcodes.IRETURN)
+			methodVisitor.VisitMaxs(0, 0)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	predicate := commons.PanicPredicate(func(classFile []byte) {
+		reader, err := asm.NewClassReader(classFile)
+		if err == nil {
+			reader.Accept(idivPanicker{}, 0)
+		}
+	})
+	if _, err := commons.MinimizeClass(classFile.Bytes(), predicate, commons.MinimizeOptions{}); err == nil {
+		t.Error("expected an error for a class which does not satisfy the predicate")
+	}
+	minimized, err := commons.MinimizeClass(result, predicate, commons.MinimizeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !predicate(minimized) {
+		t.Fatal("the predicate does not hold for the minimized class")
+	}
+
+	reader, err := asm.NewClassReader(minimized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classNode := tree.NewClassNode()
+	reader.Accept(classNode, 0)
+	if len(classNode.Fields) != 0 || classNode.SourceFile != "" || len(classNode.Methods) != 1 {
+		t.Fatalf("unexpected class %d %q %d", len(classNode.Fields), classNode.SourceFile, len(classNode.Methods))
+	}
+	method := classNode.Methods[0]
+	labelNames := tree.GetLabelNames(method)
+	var insns []string
+	for _, insn := range method.Instructions {
+		insns = append(insns, tree.InsnToString(insn, labelNames))
+	}
+	if method.Name != "b" || len(insns) != 1 || insns[0] != "IDIV" {
+		t.Errorf("unexpected method %s %v", method.Name, insns)
+	}
+}
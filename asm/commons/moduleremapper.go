@@ -0,0 +1,59 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// ModuleRemapper a {@link ModuleVisitor} that remaps module, package and class names with a {@link Remapper}.
+type ModuleRemapper struct {
+	helper.ModuleAdapter
+	remapper Remapper
+}
+
+// NewModuleRemapper constructs a new {@link ModuleRemapper} delegating to the given visitor.
+func NewModuleRemapper(moduleVisitor asm.ModuleVisitor, remapper Remapper) *ModuleRemapper {
+	return &ModuleRemapper{
+		ModuleAdapter: helper.ModuleAdapter{Next: moduleVisitor},
+		remapper:      remapper,
+	}
+}
+
+func (m *ModuleRemapper) VisitMainClass(mainClass string) {
+	m.ModuleAdapter.VisitMainClass(MapType(m.remapper, mainClass))
+}
+
+func (m *ModuleRemapper) VisitPackage(packaze string) {
+	m.ModuleAdapter.VisitPackage(m.remapper.MapPackageName(packaze))
+}
+
+func (m *ModuleRemapper) VisitRequire(module string, access int, version string) {
+	m.ModuleAdapter.VisitRequire(m.remapper.MapModuleName(module), access, version)
+}
+
+func (m *ModuleRemapper) VisitExport(packaze string, access int, modules ...string) {
+	m.ModuleAdapter.VisitExport(m.remapper.MapPackageName(packaze), access, m.mapModuleNames(modules)...)
+}
+
+func (m *ModuleRemapper) VisitOpen(packaze string, access int, modules ...string) {
+	m.ModuleAdapter.VisitOpen(m.remapper.MapPackageName(packaze), access, m.mapModuleNames(modules)...)
+}
+
+func (m *ModuleRemapper) VisitUse(service string) {
+	m.ModuleAdapter.VisitUse(MapType(m.remapper, service))
+}
+
+func (m *ModuleRemapper) VisitProvide(service string, providers ...string) {
+	m.ModuleAdapter.VisitProvide(MapType(m.remapper, service), MapTypes(m.remapper, providers)...)
+}
+
+func (m *ModuleRemapper) mapModuleNames(modules []string) []string {
+	if modules == nil {
+		return nil
+	}
+	remapped := make([]string, len(modules))
+	for i, module := range modules {
+		remapped[i] = m.remapper.MapModuleName(module)
+	}
+	return remapped
+}
@@ -0,0 +1,155 @@
+package commons
+
+import (
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// MONITOR_ENTER_METHOD the name of the static lock class method called instead of MONITORENTER.
+const MONITOR_ENTER_METHOD = "monitorEnter"
+
+// MONITOR_EXIT_METHOD the name of the static lock class method called instead of MONITOREXIT.
+const MONITOR_EXIT_METHOD = "monitorExit"
+
+// MONITOR_METHOD_DESCRIPTOR the descriptor of the lock class methods, which receive the locked object.
+const MONITOR_METHOD_DESCRIPTOR = "(Ljava/lang/Object;)V"
+
+// MonitorRewriter a {@link ClassVisitor} that replaces synchronized blocks and methods with explicit calls to
+// the static {@link MONITOR_ENTER_METHOD} and {@link MONITOR_EXIT_METHOD} methods of a lock class.
+//
+// MONITORENTER and MONITOREXIT instructions are replaced in place. The catch-any exception table entries
+// that javac makes protect their own handler (so that an asynchronous exception during monitorexit retries
+// it) are removed, since they would loop forever if the lock class exit method throws. Synchronized methods
+// lose their ACC_SYNCHRONIZED flag; their body is wrapped in enter/exit calls with a catch-any handler which
+// exits and rethrows.
+type MonitorRewriter struct {
+	helper.ClassAdapter
+	lockOwner string
+	className string
+	version   int
+}
+
+// NewMonitorRewriter constructs a new {@link MonitorRewriter} calling the methods of the given lock class.
+func NewMonitorRewriter(classVisitor asm.ClassVisitor, lockOwner string) *MonitorRewriter {
+	return &MonitorRewriter{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		lockOwner:    lockOwner,
+	}
+}
+
+func (m *MonitorRewriter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	m.className = name
+	m.version = version
+	m.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (m *MonitorRewriter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	synchronized := (access&opcodes.ACC_SYNCHRONIZED) != 0 && (access&opcodes.ACC_NATIVE) == 0
+	if synchronized {
+		access &^= opcodes.ACC_SYNCHRONIZED
+	}
+	methodVisitor := m.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil {
+		return nil
+	}
+	return &monitorMethodRewriter{
+		MethodAdapter: helper.MethodAdapter{Next: methodVisitor},
+		rewriter:      m,
+		synchronized:  synchronized,
+		static:        (access & opcodes.ACC_STATIC) != 0,
+	}
+}
+
+type monitorMethodRewriter struct {
+	helper.MethodAdapter
+	rewriter       *MonitorRewriter
+	synchronized   bool
+	static         bool
+	expandedFrames bool
+	start          *asm.Label
+	end            *asm.Label
+}
+
+func (m *monitorMethodRewriter) VisitCode() {
+	m.MethodAdapter.VisitCode()
+	if m.synchronized {
+		m.start = &asm.Label{}
+		m.end = &asm.Label{}
+		m.pushLock()
+		m.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, m.rewriter.lockOwner, MONITOR_ENTER_METHOD, MONITOR_METHOD_DESCRIPTOR, false)
+		m.MethodAdapter.VisitLabel(m.start)
+	}
+}
+
+func (m *monitorMethodRewriter) pushLock() {
+	if !m.static {
+		m.MethodAdapter.VisitVarInsn(opcodes.ALOAD, 0)
+	} else if m.rewriter.version >= opcodes.V1_5 {
+		m.MethodAdapter.VisitLdcInsn(asm.GetObjectType(m.rewriter.className))
+	} else {
+		m.MethodAdapter.VisitLdcInsn(strings.Replace(m.rewriter.className, "/", ".", -1))
+		m.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, "java/lang/Class", "forName", "(Ljava/lang/String;)Ljava/lang/Class;", false)
+	}
+}
+
+func (m *monitorMethodRewriter) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	if typed == opcodes.F_NEW {
+		m.expandedFrames = true
+	}
+	m.MethodAdapter.VisitFrame(typed, nLocal, local, nStack, stack)
+}
+
+func (m *monitorMethodRewriter) VisitInsn(opcode int) {
+	switch opcode {
+	case opcodes.MONITORENTER:
+		m.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, m.rewriter.lockOwner, MONITOR_ENTER_METHOD, MONITOR_METHOD_DESCRIPTOR, false)
+	case opcodes.MONITOREXIT:
+		m.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, m.rewriter.lockOwner, MONITOR_EXIT_METHOD, MONITOR_METHOD_DESCRIPTOR, false)
+	case opcodes.IRETURN, opcodes.LRETURN, opcodes.FRETURN, opcodes.DRETURN, opcodes.ARETURN, opcodes.RETURN:
+		if m.synchronized {
+			m.pushLock()
+			m.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, m.rewriter.lockOwner, MONITOR_EXIT_METHOD, MONITOR_METHOD_DESCRIPTOR, false)
+		}
+		m.MethodAdapter.VisitInsn(opcode)
+	default:
+		m.MethodAdapter.VisitInsn(opcode)
+	}
+}
+
+func (m *monitorMethodRewriter) VisitTryCatchBlock(start, end, handler *asm.Label, typed string) {
+	if typed == "" && start == handler {
+		return
+	}
+	m.MethodAdapter.VisitTryCatchBlock(start, end, handler, typed)
+}
+
+func (m *monitorMethodRewriter) VisitMaxs(maxStack int, maxLocals int) {
+	if m.synchronized {
+		handler := &asm.Label{}
+		m.MethodAdapter.VisitLabel(m.end)
+		m.MethodAdapter.VisitTryCatchBlock(m.start, m.end, handler, "")
+		m.MethodAdapter.VisitLabel(handler)
+		if m.rewriter.version >= opcodes.V1_6 {
+			var locals []interface{}
+			if !m.static {
+				locals = []interface{}{m.rewriter.className}
+			}
+			frameType := opcodes.F_FULL
+			if m.expandedFrames {
+				frameType = opcodes.F_NEW
+			}
+			m.MethodAdapter.VisitFrame(frameType, len(locals), locals, 1, []interface{}{"java/lang/Throwable"})
+		}
+		m.pushLock()
+		m.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, m.rewriter.lockOwner, MONITOR_EXIT_METHOD, MONITOR_METHOD_DESCRIPTOR, false)
+		m.MethodAdapter.VisitInsn(opcodes.ATHROW)
+		maxStack++
+		if maxStack < 2 {
+			maxStack = 2
+		}
+	}
+	m.MethodAdapter.VisitMaxs(maxStack, maxLocals)
+}
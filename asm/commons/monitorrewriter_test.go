@@ -0,0 +1,100 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// synchronizedClass returns a class p/C with the synchronized method "void sync()" and the method
+// "void block(Object o) { synchronized (o) {} }", compiled as javac does.
+func synchronizedClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	sync := classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_SYNCHRONIZED, "sync", "()V", "", nil)
+	sync.VisitCode()
+	sync.VisitInsn(opcodes.RETURN)
+	sync.VisitMaxs(0, 1)
+	sync.VisitEnd()
+
+	block := classFile.AddMethod(opcodes.ACC_PUBLIC, "block", "(Ljava/lang/Object;)V", "", nil)
+	start, end, handler, handlerEnd, after := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	block.VisitCode()
+	block.VisitTryCatchBlock(start, end, handler, "")
+	block.VisitTryCatchBlock(handler, handlerEnd, handler, "")
+	block.VisitVarInsn(opcodes.ALOAD, 1)
+	block.VisitInsn(opcodes.DUP)
+	block.VisitVarInsn(opcodes.ASTORE, 2)
+	block.VisitInsn(opcodes.MONITORENTER)
+	block.VisitLabel(start)
+	block.VisitVarInsn(opcodes.ALOAD, 2)
+	block.VisitInsn(opcodes.MONITOREXIT)
+	block.VisitLabel(end)
+	block.VisitJumpInsn(opcodes.GOTO, after)
+	block.VisitLabel(handler)
+	block.VisitVarInsn(opcodes.ASTORE, 3)
+	block.VisitVarInsn(opcodes.ALOAD, 2)
+	block.VisitInsn(opcodes.MONITOREXIT)
+	block.VisitLabel(handlerEnd)
+	block.VisitVarInsn(opcodes.ALOAD, 3)
+	block.VisitInsn(opcodes.ATHROW)
+	block.VisitLabel(after)
+	block.VisitInsn(opcodes.RETURN)
+	block.VisitMaxs(2, 4)
+	block.VisitEnd()
+	return classFile.Bytes()
+}
+
+func TestMonitorRewriter(t *testing.T) {
+	rewrite := func(next asm.ClassVisitor) asm.ClassVisitor {
+		return commons.NewMonitorRewriter(next, "p/Locks")
+	}
+	// The synchronized method loses its flag, and its body is wrapped in enter/exit calls with a catch-any handler.
+	assertTrace(t, transformedTrace(t, synchronizedClass(), "sync()V", rewrite), []string{
+		`class visit method p/C 1 "sync" "()V" "" []`,
+		`method visit code p/C.sync()V`,
+		`method visit var insn p/C.sync()V 25 0`,
+		`method visit method insn p/C.sync()V 184 "p/Locks" "monitorEnter" "(Ljava/lang/Object;)V" false`,
+		`method visit label p/C.sync()V L0`,
+		`method visit var insn p/C.sync()V 25 0`,
+		`method visit method insn p/C.sync()V 184 "p/Locks" "monitorExit" "(Ljava/lang/Object;)V" false`,
+		`method visit insn p/C.sync()V 177`,
+		`method visit label p/C.sync()V L1`,
+		`method visit try catch block p/C.sync()V L0 L1 L2 ""`,
+		`method visit label p/C.sync()V L2`,
+		`method visit frame p/C.sync()V 0 1 ["p/C"] 1 ["java/lang/Throwable"]`,
+		`method visit var insn p/C.sync()V 25 0`,
+		`method visit method insn p/C.sync()V 184 "p/Locks" "monitorExit" "(Ljava/lang/Object;)V" false`,
+		`method visit insn p/C.sync()V 191`,
+		`method visit maxs p/C.sync()V 2 1`,
+		`method visit end p/C.sync()V`,
+	})
+	// The monitor instructions are replaced in place, and the catch-any entry protecting its own handler is removed.
+	assertTrace(t, transformedTrace(t, synchronizedClass(), "block(Ljava/lang/Object;)V", rewrite), []string{
+		`class visit method p/C 1 "block" "(Ljava/lang/Object;)V" "" []`,
+		`method visit code p/C.block(Ljava/lang/Object;)V`,
+		`method visit try catch block p/C.block(Ljava/lang/Object;)V L3 L4 L5 ""`,
+		`method visit var insn p/C.block(Ljava/lang/Object;)V 25 1`,
+		`method visit insn p/C.block(Ljava/lang/Object;)V 89`,
+		`method visit var insn p/C.block(Ljava/lang/Object;)V 58 2`,
+		`method visit method insn p/C.block(Ljava/lang/Object;)V 184 "p/Locks" "monitorEnter" "(Ljava/lang/Object;)V" false`,
+		`method visit label p/C.block(Ljava/lang/Object;)V L3`,
+		`method visit var insn p/C.block(Ljava/lang/Object;)V 25 2`,
+		`method visit method insn p/C.block(Ljava/lang/Object;)V 184 "p/Locks" "monitorExit" "(Ljava/lang/Object;)V" false`,
+		`method visit label p/C.block(Ljava/lang/Object;)V L4`,
+		`method visit jump insn p/C.block(Ljava/lang/Object;)V 167 L6`,
+		`method visit label p/C.block(Ljava/lang/Object;)V L5`,
+		`method visit var insn p/C.block(Ljava/lang/Object;)V 58 3`,
+		`method visit var insn p/C.block(Ljava/lang/Object;)V 25 2`,
+		`method visit method insn p/C.block(Ljava/lang/Object;)V 184 "p/Locks" "monitorExit" "(Ljava/lang/Object;)V" false`,
+		`method visit label p/C.block(Ljava/lang/Object;)V L7`,
+		`method visit var insn p/C.block(Ljava/lang/Object;)V 25 3`,
+		`method visit insn p/C.block(Ljava/lang/Object;)V 191`,
+		`method visit label p/C.block(Ljava/lang/Object;)V L6`,
+		`method visit insn p/C.block(Ljava/lang/Object;)V 177`,
+		`method visit maxs p/C.block(Ljava/lang/Object;)V 2 4`,
+		`method visit end p/C.block(Ljava/lang/Object;)V`,
+	})
+}
@@ -0,0 +1,431 @@
+package commons
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// Types of the {@link PolicyRule}s.
+const (
+	// FORBID_OPCODES_RULE forbids the instructions whose opcode is one of Opcodes (e.g. "JSR", "INVOKEDYNAMIC").
+	FORBID_OPCODES_RULE = "forbidOpcodes"
+	// FORBID_API_RULE forbids the references to the methods, fields and classes matching Pattern. The methods and
+	// fields are matched as "owner.name descriptor" (e.g. "java/lang/System.exit (I)V"), and the classes created,
+	// cast or tested by NEW, ANEWARRAY, CHECKCAST and INSTANCEOF by their internal name.
+	FORBID_API_RULE = "forbidApi"
+	// FORBID_NATIVE_METHODS_RULE forbids the declaration of native methods.
+	FORBID_NATIVE_METHODS_RULE = "forbidNativeMethods"
+	// FORBID_FINALIZERS_RULE forbids the declaration of finalize()V methods.
+	FORBID_FINALIZERS_RULE = "forbidFinalizers"
+	// FORBID_ATTRIBUTES_RULE forbids the attributes whose name is one of Attributes, among the non standard
+	// attributes of the classes, fields, methods and code, and the SourceDebugExtension attribute.
+	FORBID_ATTRIBUTES_RULE = "forbidAttributes"
+)
+
+// PolicyRule a forbidden construct, of one of the types {@link FORBID_OPCODES_RULE}, {@link FORBID_API_RULE},
+// {@link FORBID_NATIVE_METHODS_RULE}, {@link FORBID_FINALIZERS_RULE} or {@link FORBID_ATTRIBUTES_RULE}. Only the
+// fields used by its type must be set.
+type PolicyRule struct {
+	Type string `json:"type"`
+	// Name the name of the rule in the violations (the type by default).
+	Name       string   `json:"name,omitempty"`
+	Opcodes    []string `json:"opcodes,omitempty"`
+	Pattern    string   `json:"pattern,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
+	// Allow the exceptions to this rule (see {@link Policy}).
+	Allow []string `json:"allow,omitempty"`
+}
+
+// PolicyViolation a forbidden construct found by a {@link Policy}.
+type PolicyViolation struct {
+	Rule string `json:"rule"`
+	// Artifact the path of the jar containing the class, or "".
+	Artifact string `json:"artifact,omitempty"`
+	Class    string `json:"class"`
+	// Member the name and descriptor of the method or field containing the construct, or "" for the class itself.
+	Member  string `json:"member,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p PolicyViolation) String() string {
+	location := p.Class
+	if p.Member != "" {
+		location += "." + p.Member
+	}
+	if p.Artifact != "" {
+		location = p.Artifact + "!" + location
+	}
+	return location + ": " + p.Message + " [" + p.Rule + "]"
+}
+
+// Policy enforces {@link PolicyRule}s, read from a configuration file, on classes and jars. The configuration is a
+// JSON document (or a YAML document written in the JSON compatible flow style) of the following form:
+//
+//	{"rules": [
+//	  {"type": "forbidOpcodes", "opcodes": ["JSR", "RET"]},
+//	  {"type": "forbidApi", "name": "no-exit", "pattern": "^java/lang/System\\.exit ", "allow": ["com/example/Main"]},
+//	  {"type": "forbidNativeMethods"},
+//	  {"type": "forbidFinalizers"},
+//	  {"type": "forbidAttributes", "attributes": ["SourceDebugExtension"]}
+//	 ],
+//	 "allow": ["com/example/legacy/.*"]}
+//
+// The allow lists of the rules and of the policy are regular expressions, which exempt the classes whose internal
+// name matches one of them entirely, or the members whose "class.member" matches one of them entirely, with the
+// member of the {@link PolicyViolation}s (e.g. "p/C.run()V" for a method, "p/C.f I" for a field).
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+	Allow []string     `json:"allow,omitempty"`
+	allow []*regexp.Regexp
+	rules []*policyRule
+}
+
+// policyRule the compiled form of a {@link PolicyRule}.
+type policyRule struct {
+	name       string
+	ruleType   string
+	opcodes    map[int]bool
+	pattern    *regexp.Regexp
+	attributes map[string]bool
+	allow      []*regexp.Regexp
+}
+
+// ParsePolicy returns the {@link Policy} described by the given configuration.
+func ParsePolicy(configuration []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := json.Unmarshal(configuration, policy); err != nil {
+		return nil, err
+	}
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// NewPolicy constructs a new {@link Policy} with the given rules and global allow list.
+func NewPolicy(rules []PolicyRule, allow []string) (*Policy, error) {
+	policy := &Policy{Rules: rules, Allow: allow}
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// compileAllowList compiles the given allow list into regular expressions matching entire strings.
+func compileAllowList(allow []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, exception := range allow {
+		pattern, err := regexp.Compile("^(?:" + exception + ")$")
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func (p *Policy) compile() error {
+	allow, err := compileAllowList(p.Allow)
+	if err != nil {
+		return errors.New("Illegal Argument - allow: " + err.Error())
+	}
+	p.allow = allow
+	p.rules = nil
+	for i, rule := range p.Rules {
+		invalid := func(message string) error {
+			return errors.New("Illegal Argument - rule " + strconv.Itoa(i) + " (" + rule.Type + "): " + message)
+		}
+		compiled := &policyRule{name: rule.Name, ruleType: rule.Type}
+		if compiled.name == "" {
+			compiled.name = rule.Type
+		}
+		if compiled.allow, err = compileAllowList(rule.Allow); err != nil {
+			return invalid(err.Error())
+		}
+		switch rule.Type {
+		case FORBID_OPCODES_RULE:
+			if len(rule.Opcodes) == 0 {
+				return invalid("opcodes are required")
+			}
+			compiled.opcodes = make(map[int]bool)
+			for _, name := range rule.Opcodes {
+				opcode := opcodeByName(name)
+				if opcode < 0 {
+					return invalid("unknown opcode " + name)
+				}
+				compiled.opcodes[opcode] = true
+			}
+		case FORBID_API_RULE:
+			if compiled.pattern, err = regexp.Compile(rule.Pattern); err != nil || rule.Pattern == "" {
+				return invalid("invalid pattern " + rule.Pattern)
+			}
+		case FORBID_ATTRIBUTES_RULE:
+			if len(rule.Attributes) == 0 {
+				return invalid("attributes are required")
+			}
+			compiled.attributes = make(map[string]bool)
+			for _, name := range rule.Attributes {
+				compiled.attributes[name] = true
+			}
+		case FORBID_NATIVE_METHODS_RULE, FORBID_FINALIZERS_RULE:
+		default:
+			return invalid("unknown rule type")
+		}
+		p.rules = append(p.rules, compiled)
+	}
+	return nil
+}
+
+// opcodeByName returns the opcode with the given name, or -1.
+func opcodeByName(name string) int {
+	for opcode, opcodeName := range opcodes.NAMES {
+		if opcodeName != "" && opcodeName == strings.ToUpper(name) {
+			return opcode
+		}
+	}
+	return -1
+}
+
+// CheckClass returns the violations of the policy in the given class file.
+func (p *Policy) CheckClass(classFile []byte) ([]PolicyViolation, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	checker := &policyChecker{policy: p}
+	reader.Accept(checker, asm.SKIP_FRAMES)
+	return checker.violations, nil
+}
+
+// CheckJar returns the violations of the policy in the class files of the given jar (or zip) file.
+func (p *Policy) CheckJar(path string) ([]PolicyViolation, error) {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer jar.Close()
+	var violations []PolicyViolation
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || strings.HasSuffix(file.Name, "module-info.class") {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		classViolations, err := p.CheckClass(classFile)
+		if err != nil {
+			return nil, errors.New(file.Name + ": " + err.Error())
+		}
+		for _, violation := range classViolations {
+			violation.Artifact = path
+			violations = append(violations, violation)
+		}
+	}
+	return violations, nil
+}
+
+// WritePolicyViolations writes the given violations to the given writer, as a JSON document of the form
+// {"violations": [{"rule": ..., "artifact": ..., "class": ..., "member": ..., "message": ...}, ...]}.
+func WritePolicyViolations(writer io.Writer, violations []PolicyViolation) error {
+	if violations == nil {
+		violations = []PolicyViolation{}
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(struct {
+		Violations []PolicyViolation `json:"violations"`
+	}{violations})
+}
+
+// isAllowed returns whether the given rule does not apply to the given class member (or to the class itself, if
+// member is "").
+func (p *Policy) isAllowed(rule *policyRule, class, member string) bool {
+	for _, allow := range [][]*regexp.Regexp{p.allow, rule.allow} {
+		for _, pattern := range allow {
+			if pattern.MatchString(class) || (member != "" && pattern.MatchString(class+"."+member)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// policyChecker a {@link ClassVisitor} which collects the violations of a {@link Policy} in a class.
+type policyChecker struct {
+	helper.ClassVisitor
+	policy     *Policy
+	class      string
+	violations []PolicyViolation
+}
+
+// check reports a violation of each rule of the given type for which matches returns true, unless the rule does
+// not apply to the given member.
+func (p *policyChecker) check(ruleType, member string, matches func(rule *policyRule) bool, message string) {
+	for _, rule := range p.policy.rules {
+		if rule.ruleType == ruleType && matches(rule) && !p.policy.isAllowed(rule, p.class, member) {
+			p.violations = append(p.violations, PolicyViolation{Rule: rule.name, Class: p.class, Member: member, Message: message})
+		}
+	}
+}
+
+func always(rule *policyRule) bool {
+	return true
+}
+
+// checkAttribute reports the violations of the {@link FORBID_ATTRIBUTES_RULE}s by the given attribute.
+func (p *policyChecker) checkAttribute(member, name string) {
+	p.check(FORBID_ATTRIBUTES_RULE, member, func(rule *policyRule) bool {
+		return rule.attributes[name]
+	}, "forbidden attribute "+name)
+}
+
+func (p *policyChecker) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	p.class = name
+}
+
+func (p *policyChecker) VisitSource(source, debug string) {
+	if debug != "" {
+		p.checkAttribute("", "SourceDebugExtension")
+	}
+}
+
+func (p *policyChecker) VisitAttribute(attribute *asm.Attribute) {
+	p.checkAttribute("", attribute.GetType())
+}
+
+func (p *policyChecker) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	return &policyFieldChecker{checker: p, member: name + " " + descriptor}
+}
+
+func (p *policyChecker) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	member := name + descriptor
+	if access&opcodes.ACC_NATIVE != 0 {
+		p.check(FORBID_NATIVE_METHODS_RULE, member, always, "forbidden native method")
+	}
+	if name == "finalize" && descriptor == "()V" && access&opcodes.ACC_STATIC == 0 {
+		p.check(FORBID_FINALIZERS_RULE, member, always, "forbidden finalizer")
+	}
+	return &policyMethodChecker{checker: p, member: member}
+}
+
+// policyFieldChecker a {@link FieldVisitor} which collects the violations of a {@link Policy} in a field.
+type policyFieldChecker struct {
+	checker *policyChecker
+	member  string
+}
+
+func (p *policyFieldChecker) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (p *policyFieldChecker) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (p *policyFieldChecker) VisitEnd() {}
+
+func (p *policyFieldChecker) VisitAttribute(attribute *asm.Attribute) {
+	p.checker.checkAttribute(p.member, attribute.GetType())
+}
+
+// policyMethodChecker a {@link MethodVisitor} which collects the violations of a {@link Policy} in a method.
+type policyMethodChecker struct {
+	helper.MethodVisitor
+	checker *policyChecker
+	member  string
+}
+
+// checkOpcode reports the violations of the {@link FORBID_OPCODES_RULE}s by the given instruction.
+func (p *policyMethodChecker) checkOpcode(opcode int) {
+	p.checker.check(FORBID_OPCODES_RULE, p.member, func(rule *policyRule) bool {
+		return rule.opcodes[opcode]
+	}, "forbidden instruction "+opcodes.NAMES[opcode])
+}
+
+// checkAPI reports the violations of the {@link FORBID_API_RULE}s by a reference to the given method, field or
+// class.
+func (p *policyMethodChecker) checkAPI(reference string) {
+	p.checker.check(FORBID_API_RULE, p.member, func(rule *policyRule) bool {
+		return rule.pattern.MatchString(reference)
+	}, "forbidden reference to "+reference)
+}
+
+func (p *policyMethodChecker) VisitAttribute(attribute *asm.Attribute) {
+	p.checker.checkAttribute(p.member, attribute.GetType())
+}
+
+func (p *policyMethodChecker) VisitInsn(opcode int) {
+	p.checkOpcode(opcode)
+}
+
+func (p *policyMethodChecker) VisitIntInsn(opcode, operand int) {
+	p.checkOpcode(opcode)
+}
+
+func (p *policyMethodChecker) VisitVarInsn(opcode, variable int) {
+	p.checkOpcode(opcode)
+}
+
+func (p *policyMethodChecker) VisitTypeInsn(opcode int, typed string) {
+	p.checkOpcode(opcode)
+	p.checkAPI(typed)
+}
+
+func (p *policyMethodChecker) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	p.checkOpcode(opcode)
+	p.checkAPI(owner + "." + name + " " + descriptor)
+}
+
+func (p *policyMethodChecker) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	p.checkOpcode(opcode)
+	p.checkAPI(owner + "." + name + " " + descriptor)
+}
+
+func (p *policyMethodChecker) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHandle *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	p.checkOpcode(opcodes.INVOKEDYNAMIC)
+	for _, argument := range bootstrapMethodArguments {
+		if handle, ok := argument.(*asm.Handle); ok {
+			p.checkAPI(handle.GetOwner() + "." + handle.GetName() + " " + handle.GetDesc())
+		}
+	}
+}
+
+func (p *policyMethodChecker) VisitJumpInsn(opcode int, label *asm.Label) {
+	p.checkOpcode(opcode)
+}
+
+func (p *policyMethodChecker) VisitLdcInsn(value interface{}) {
+	p.checkOpcode(opcodes.LDC)
+}
+
+func (p *policyMethodChecker) VisitIincInsn(variable, increment int) {
+	p.checkOpcode(opcodes.IINC)
+}
+
+func (p *policyMethodChecker) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	p.checkOpcode(opcodes.TABLESWITCH)
+}
+
+func (p *policyMethodChecker) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	p.checkOpcode(opcodes.LOOKUPSWITCH)
+}
+
+func (p *policyMethodChecker) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	p.checkOpcode(opcodes.MULTIANEWARRAY)
+}
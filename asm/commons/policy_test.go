@@ -0,0 +1,184 @@
+package commons_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// policyConfiguration the configuration of the {@link Policy} checked by the tests.
+const policyConfiguration = `{"rules": [
+  {"type": "forbidOpcodes", "opcodes": ["monitorenter", "INVOKEDYNAMIC"]},
+  {"type": "forbidApi", "name": "no-exit", "pattern": "^java/lang/(System\\.exit|Runtime\\.halt) ",
+   "allow": ["p/C\\.main\\(\\[Ljava/lang/String;\\)V"]},
+  {"type": "forbidApi", "name": "no-threads", "pattern": "^java/lang/Thread$"},
+  {"type": "forbidNativeMethods"},
+  {"type": "forbidFinalizers"},
+  {"type": "forbidAttributes", "attributes": ["Custom", "SourceDebugExtension"]}
+ ],
+ "allow": ["p/Legacy"]}`
+
+// policyClass returns a class with the given name, with a Custom and a SourceDebugExtension attribute, a native
+// method, a finalizer, a main method calling System.exit, and a method "void run()" calling System.exit, creating
+// a Thread, entering a monitor and referencing Runtime.halt in an invokedynamic instruction.
+func policyClass(name string) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, name, "java/lang/Object")
+	classFile.AddAttribute("Custom", []byte{1})
+	classFile.AddAttribute("SourceDebugExtension", []byte("SMAP"))
+	classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_NATIVE, "load", "()V", "", nil).VisitEnd()
+	for _, method := range []struct {
+		access           int
+		name, descriptor string
+	}{
+		{opcodes.ACC_PROTECTED, "finalize", "()V"},
+		{opcodes.ACC_PUBLIC | opcodes.ACC_STATIC, "main", "([Ljava/lang/String;)V"},
+	} {
+		methodWriter := classFile.AddMethod(method.access, method.name, method.descriptor, "", nil)
+		methodWriter.VisitCode()
+		if method.name == "main" {
+			methodWriter.VisitInsn(opcodes.ICONST_0)
+			methodWriter.VisitMethodInsnB(opcodes.INVOKESTATIC, "java/lang/System", "exit", "(I)V", false)
+		}
+		methodWriter.VisitInsn(opcodes.RETURN)
+		methodWriter.VisitMaxs(1, 1)
+		methodWriter.VisitEnd()
+	}
+	run := classFile.AddMethod(opcodes.ACC_PUBLIC, "run", "()V", "", nil)
+	run.VisitCode()
+	run.VisitInsn(opcodes.ICONST_0)
+	run.VisitMethodInsnB(opcodes.INVOKESTATIC, "java/lang/System", "exit", "(I)V", false)
+	run.VisitTypeInsn(opcodes.NEW, "java/lang/Thread")
+	run.VisitInsn(opcodes.POP)
+	run.VisitVarInsn(opcodes.ALOAD, 0)
+	run.VisitInsn(opcodes.MONITORENTER)
+	bootstrapMethod := asm.NewHandle(opcodes.H_INVOKESTATIC, "java/lang/invoke/LambdaMetafactory", "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+			"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)"+
+			"Ljava/lang/invoke/CallSite;", false)
+	run.VisitInvokeDynamicInsn("accept", "()Ljava/util/function/IntConsumer;", bootstrapMethod,
+		asm.GetMethodType("(I)V"), asm.NewHandle(opcodes.H_INVOKEVIRTUAL, "java/lang/Runtime", "halt", "(I)V", false),
+		asm.GetMethodType("(I)V"))
+	run.VisitInsn(opcodes.POP)
+	run.VisitInsn(opcodes.RETURN)
+	run.VisitMaxs(1, 1)
+	run.VisitEnd()
+	return classFile.Bytes()
+}
+
+func TestPolicyCheckClass(t *testing.T) {
+	policy, err := commons.ParsePolicy([]byte(policyConfiguration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := policy.CheckClass(policyClass("p/C"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, violation := range violations {
+		lines = append(lines, violation.String())
+	}
+	// The System.exit call of main is allowed by the no-exit rule.
+	assertTrace(t, lines, []string{
+		`p/C: forbidden attribute SourceDebugExtension [forbidAttributes]`,
+		`p/C: forbidden attribute Custom [forbidAttributes]`,
+		`p/C.load()V: forbidden native method [forbidNativeMethods]`,
+		`p/C.finalize()V: forbidden finalizer [forbidFinalizers]`,
+		`p/C.run()V: forbidden reference to java/lang/System.exit (I)V [no-exit]`,
+		`p/C.run()V: forbidden reference to java/lang/Thread [no-threads]`,
+		`p/C.run()V: forbidden instruction MONITORENTER [forbidOpcodes]`,
+		`p/C.run()V: forbidden instruction INVOKEDYNAMIC [forbidOpcodes]`,
+		`p/C.run()V: forbidden reference to java/lang/Runtime.halt (I)V [no-exit]`,
+	})
+
+	// The classes allowed by the policy have no violations.
+	violations, err = policy.CheckClass(policyClass("p/Legacy"))
+	if err != nil || len(violations) != 0 {
+		t.Errorf("unexpected violations %v, %v", violations, err)
+	}
+	if _, err := policy.CheckClass([]byte{0xCA, 0xFE}); err == nil {
+		t.Error("expected an error for an invalid class")
+	}
+}
+
+func TestPolicyCheckJar(t *testing.T) {
+	policy, err := commons.NewPolicy([]commons.PolicyRule{
+		{Type: commons.FORBID_NATIVE_METHODS_RULE, Name: "no-native"},
+		{Type: commons.FORBID_FINALIZERS_RULE, Allow: []string{`p/C\.finalize\(\)V`}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "in.jar")
+	if err := os.WriteFile(path, writeJar(t, jarEntry{"p/C.class", policyClass("p/C"), zip.Deflate},
+		jarEntry{"META-INF/MANIFEST.MF", []byte("Manifest-Version: 1.0\n"), zip.Deflate}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	violations, err := policy.CheckJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err := commons.WritePolicyViolations(&buffer, violations); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "violations": [
+    {
+      "rule": "no-native",
+      "artifact": "` + path + `",
+      "class": "p/C",
+      "member": "load()V",
+      "message": "forbidden native method"
+    }
+  ]
+}
+`
+	if buffer.String() != expected {
+		t.Errorf("unexpected violations:\n%s", buffer.String())
+	}
+
+	// The violations are an empty array, and not null, if there are none.
+	buffer.Reset()
+	if err := commons.WritePolicyViolations(&buffer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "{\n  \"violations\": []\n}\n" {
+		t.Errorf("unexpected violations:\n%s", buffer.String())
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, configuration, message string
+	}{
+		{"syntax", `{"rules": [`, "unexpected end of JSON input"},
+		{"allow", `{"rules": [], "allow": ["("]}`,
+			"Illegal Argument - allow: error parsing regexp: missing closing ): `^(?:()$`"},
+		{"type", `{"rules": [{"type": "forbidEverything"}]}`,
+			"Illegal Argument - rule 0 (forbidEverything): unknown rule type"},
+		{"no opcodes", `{"rules": [{"type": "forbidFinalizers"}, {"type": "forbidOpcodes"}]}`,
+			"Illegal Argument - rule 1 (forbidOpcodes): opcodes are required"},
+		{"opcode", `{"rules": [{"type": "forbidOpcodes", "opcodes": ["JSR", "GOTO_W"]}]}`,
+			"Illegal Argument - rule 0 (forbidOpcodes): unknown opcode GOTO_W"},
+		{"no pattern", `{"rules": [{"type": "forbidApi"}]}`,
+			"Illegal Argument - rule 0 (forbidApi): invalid pattern "},
+		{"pattern", `{"rules": [{"type": "forbidApi", "pattern": "["}]}`,
+			"Illegal Argument - rule 0 (forbidApi): invalid pattern ["},
+		{"no attributes", `{"rules": [{"type": "forbidAttributes"}]}`,
+			"Illegal Argument - rule 0 (forbidAttributes): attributes are required"},
+		{"rule allow", `{"rules": [{"type": "forbidNativeMethods", "allow": ["*"]}]}`,
+			"Illegal Argument - rule 0 (forbidNativeMethods): error parsing regexp: missing argument to repetition operator: `*`"},
+	} {
+		if _, err := commons.ParsePolicy([]byte(test.configuration)); err == nil || err.Error() != test.message {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package commons
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// PROVENANCE_ATTRIBUTE the name of the class attribute recording the tools which generated or transformed a
+// class.
+const PROVENANCE_ATTRIBUTE = "io.asm.go.Provenance"
+
+// ProvenanceEntry a tool which generated or transformed a class.
+type ProvenanceEntry struct {
+	Tool    string
+	Version string
+	// Transformations the names of the transformations applied by the tool.
+	Transformations []string
+}
+
+func (p ProvenanceEntry) String() string {
+	s := p.Tool
+	if p.Version != "" {
+		s += " " + p.Version
+	}
+	if len(p.Transformations) > 0 {
+		s += ": " + strings.Join(p.Transformations, ", ")
+	}
+	return s
+}
+
+// Provenance the content of a {@link PROVENANCE_ATTRIBUTE} attribute: the tools which processed the class, in
+// the order in which they were applied. The attribute content is self contained (it does not reference the
+// constant pool), with the following format:
+//
+//	u2 entries_count
+//	entry entries[entries_count] { string tool; string version; u2 count; string transformations[count]; }
+//
+// where each string is an u2 length followed by this number of UTF-8 bytes.
+type Provenance struct {
+	Entries []ProvenanceEntry
+}
+
+// ReadProvenance parses the given {@link PROVENANCE_ATTRIBUTE} attribute.
+func ReadProvenance(attribute *asm.Attribute) (*Provenance, error) {
+	if attribute.GetType() != PROVENANCE_ATTRIBUTE {
+		return nil, errors.New("Illegal Argument - not a " + PROVENANCE_ATTRIBUTE + " attribute")
+	}
+	content := attribute.GetContent()
+	offset := 0
+	malformed := false
+	readShort := func() int {
+		if offset+2 > len(content) {
+			malformed = true
+			return 0
+		}
+		value := int(binary.BigEndian.Uint16(content[offset:]))
+		offset += 2
+		return value
+	}
+	readString := func() string {
+		length := readShort()
+		if malformed || offset+length > len(content) {
+			malformed = true
+			return ""
+		}
+		value := string(content[offset : offset+length])
+		offset += length
+		return value
+	}
+	provenance := &Provenance{}
+	for numEntries := readShort(); numEntries > 0 && !malformed; numEntries-- {
+		entry := ProvenanceEntry{Tool: readString(), Version: readString()}
+		for numTransformations := readShort(); numTransformations > 0 && !malformed; numTransformations-- {
+			entry.Transformations = append(entry.Transformations, readString())
+		}
+		provenance.Entries = append(provenance.Entries, entry)
+	}
+	if malformed || offset != len(content) {
+		return nil, errors.New("Illegal State - malformed " + PROVENANCE_ATTRIBUTE + " attribute")
+	}
+	return provenance, nil
+}
+
+// ToAttribute returns the {@link PROVENANCE_ATTRIBUTE} attribute corresponding to this provenance.
+func (p *Provenance) ToAttribute() *asm.Attribute {
+	var content []byte
+	putString := func(s string) {
+		content = binary.BigEndian.AppendUint16(content, uint16(len(s)))
+		content = append(content, s...)
+	}
+	content = binary.BigEndian.AppendUint16(content, uint16(len(p.Entries)))
+	for _, entry := range p.Entries {
+		putString(entry.Tool)
+		putString(entry.Version)
+		content = binary.BigEndian.AppendUint16(content, uint16(len(entry.Transformations)))
+		for _, transformation := range entry.Transformations {
+			putString(transformation)
+		}
+	}
+	return asm.NewAttributeWithContent(PROVENANCE_ATTRIBUTE, content)
+}
+
+func (p *Provenance) String() string {
+	entries := make([]string, len(p.Entries))
+	for i, entry := range p.Entries {
+		entries[i] = entry.String()
+	}
+	return strings.Join(entries, "\n")
+}
+
+// ProvenanceRecorder a {@link ClassVisitor} that appends the given entry to the {@link PROVENANCE_ATTRIBUTE}
+// attribute of the visited class, creating it if needed. An existing attribute which can't be parsed is
+// replaced. The attribute is visited after the other attributes of the class.
+type ProvenanceRecorder struct {
+	helper.ClassAdapter
+	entry      ProvenanceEntry
+	provenance *Provenance
+	done       bool
+}
+
+// NewProvenanceRecorder constructs a new {@link ProvenanceRecorder}.
+func NewProvenanceRecorder(classVisitor asm.ClassVisitor, entry ProvenanceEntry) *ProvenanceRecorder {
+	return &ProvenanceRecorder{
+		ClassAdapter: helper.ClassAdapter{Next: classVisitor},
+		entry:        entry,
+		provenance:   &Provenance{},
+	}
+}
+
+func (p *ProvenanceRecorder) VisitAttribute(attribute *asm.Attribute) {
+	if attribute.GetType() != PROVENANCE_ATTRIBUTE {
+		p.ClassAdapter.VisitAttribute(attribute)
+		return
+	}
+	if provenance, err := ReadProvenance(attribute); err == nil {
+		p.provenance.Entries = append(p.provenance.Entries, provenance.Entries...)
+	}
+}
+
+// visitProvenance visits the provenance attribute, before the first event which can't precede the attributes.
+func (p *ProvenanceRecorder) visitProvenance() {
+	if !p.done {
+		p.done = true
+		p.provenance.Entries = append(p.provenance.Entries, p.entry)
+		p.ClassAdapter.VisitAttribute(p.provenance.ToAttribute())
+	}
+}
+
+func (p *ProvenanceRecorder) VisitInnerClass(name, outerName, innerName string, access int) {
+	p.visitProvenance()
+	p.ClassAdapter.VisitInnerClass(name, outerName, innerName, access)
+}
+
+func (p *ProvenanceRecorder) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	p.visitProvenance()
+	return p.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+}
+
+func (p *ProvenanceRecorder) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	p.visitProvenance()
+	return p.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+}
+
+func (p *ProvenanceRecorder) VisitEnd() {
+	p.visitProvenance()
+	p.ClassAdapter.VisitEnd()
+}
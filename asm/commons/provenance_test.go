@@ -0,0 +1,124 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// recordProvenance returns the given class file, rewritten by a {@link ProvenanceRecorder} with the given entry.
+func recordProvenance(t *testing.T, classFile []byte, entry commons.ProvenanceEntry) []byte {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classWriter := asm.NewClassWriter(nil)
+	reader.Accept(commons.NewProvenanceRecorder(classWriter, entry), 0)
+	bytes, err := classWriter.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+// readProvenance returns the provenance attributes of the given class file, read back with
+// {@link ReadProvenance}.
+func readProvenance(t *testing.T, classFile []byte) []string {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var provenances []string
+	reader.Accept(&helper.ClassVisitor{
+		OnVisitAttribute: func(attribute *asm.Attribute) {
+			if attribute.GetType() != commons.PROVENANCE_ATTRIBUTE {
+				return
+			}
+			provenance, err := commons.ReadProvenance(attribute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			provenances = append(provenances, provenance.String())
+		},
+	}, 0)
+	return provenances
+}
+
+// provenanceClass returns a class p/C with a method, and with a provenance attribute with the given content if
+// it is not nil.
+func provenanceClass(content []byte) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "p/C", "java/lang/Object")
+	methodVisitor := classFile.AddMethod(opcodes.ACC_PUBLIC, "m", "()V", "", nil)
+	methodVisitor.VisitCode()
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitMaxs(0, 1)
+	methodVisitor.VisitEnd()
+	if content != nil {
+		classFile.AddAttribute(commons.PROVENANCE_ATTRIBUTE, content)
+	}
+	return classFile.Bytes()
+}
+
+func TestProvenanceRecorder(t *testing.T) {
+	classFile := recordProvenance(t, provenanceClass(nil),
+		commons.ProvenanceEntry{Tool: "shrinker", Version: "1.0", Transformations: []string{"minimize", "strip-debug"}})
+	assertTrace(t, readProvenance(t, classFile), []string{
+		"shrinker 1.0: minimize, strip-debug",
+	})
+	// The entries are appended to the existing attribute, which remains unique.
+	classFile = recordProvenance(t, classFile, commons.ProvenanceEntry{Tool: "obfuscator"})
+	assertTrace(t, readProvenance(t, classFile), []string{
+		"shrinker 1.0: minimize, strip-debug\nobfuscator",
+	})
+	// The other members of the class are kept.
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(recorder, 0)
+	recorder.AssertVisitedMethod(t, "m", "()V")
+}
+
+func TestProvenanceRecorderMalformedAttribute(t *testing.T) {
+	// An attribute with one entry, whose tool name is truncated, is replaced.
+	classFile := recordProvenance(t, provenanceClass([]byte{0, 1, 0, 4, 't'}), commons.ProvenanceEntry{Tool: "tool"})
+	assertTrace(t, readProvenance(t, classFile), []string{"tool"})
+}
+
+func TestReadProvenance(t *testing.T) {
+	provenance := &commons.Provenance{Entries: []commons.ProvenanceEntry{
+		{Tool: "a", Version: "2", Transformations: []string{"x"}},
+		{Tool: "b"},
+	}}
+	readBack, err := commons.ReadProvenance(provenance.ToAttribute())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readBack.String() != "a 2: x\nb" {
+		t.Errorf("unexpected provenance %q", readBack.String())
+	}
+
+	for _, test := range []struct {
+		name      string
+		attribute *asm.Attribute
+		message   string
+	}{
+		{"type", asm.NewAttributeWithContent("Other", []byte{0, 0}),
+			"Illegal Argument - not a io.asm.go.Provenance attribute"},
+		{"empty", asm.NewAttributeWithContent(commons.PROVENANCE_ATTRIBUTE, nil),
+			"Illegal State - malformed io.asm.go.Provenance attribute"},
+		{"truncated", asm.NewAttributeWithContent(commons.PROVENANCE_ATTRIBUTE, []byte{0, 1, 0, 1, 'a', 0, 0, 0, 1}),
+			"Illegal State - malformed io.asm.go.Provenance attribute"},
+		{"trailing", asm.NewAttributeWithContent(commons.PROVENANCE_ATTRIBUTE, []byte{0, 0, 0}),
+			"Illegal State - malformed io.asm.go.Provenance attribute"},
+	} {
+		if _, err := commons.ReadProvenance(test.attribute); err == nil || err.Error() != test.message {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
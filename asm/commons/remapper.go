@@ -0,0 +1,154 @@
+package commons
+
+import (
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Remapper a class names remapper. The methods of this interface are the primitive mappings; the MapXxx
+// functions of this package use them to remap descriptors, types, handles and constants.
+type Remapper interface {
+	// Map maps the internal name of a class to its new name.
+	Map(internalName string) string
+	// MapMethodName maps a method name to its new name.
+	MapMethodName(owner, name, descriptor string) string
+	// MapInvokeDynamicMethodName maps an invokedynamic method name to its new name.
+	MapInvokeDynamicMethodName(name, descriptor string) string
+	// MapFieldName maps a field name to its new name.
+	MapFieldName(owner, name, descriptor string) string
+	// MapPackageName maps a package name to its new name.
+	MapPackageName(name string) string
+	// MapModuleName maps a module name to its new name.
+	MapModuleName(name string) string
+}
+
+// MapDesc returns the given field descriptor, remapped with the given remapper.
+func MapDesc(remapper Remapper, descriptor string) string {
+	return mapDescriptor(remapper, descriptor)
+}
+
+// MapMethodDesc returns the given method descriptor, remapped with the given remapper.
+func MapMethodDesc(remapper Remapper, methodDescriptor string) string {
+	return mapDescriptor(remapper, methodDescriptor)
+}
+
+func mapDescriptor(remapper Remapper, descriptor string) string {
+	if descriptor == "" {
+		return descriptor
+	}
+	var builder strings.Builder
+	for i := 0; i < len(descriptor); i++ {
+		if descriptor[i] != 'L' {
+			builder.WriteByte(descriptor[i])
+			continue
+		}
+		end := i + strings.IndexByte(descriptor[i:], ';')
+		if end < i {
+			builder.WriteString(descriptor[i:])
+			break
+		}
+		builder.WriteByte('L')
+		builder.WriteString(remapper.Map(descriptor[i+1 : end]))
+		builder.WriteByte(';')
+		i = end
+	}
+	return builder.String()
+}
+
+// MapType returns the given internal name, or array type descriptor, remapped with the given remapper.
+func MapType(remapper Remapper, internalName string) string {
+	if internalName == "" {
+		return internalName
+	}
+	if internalName[0] == '[' {
+		return MapDesc(remapper, internalName)
+	}
+	return remapper.Map(internalName)
+}
+
+// MapTypes returns the given internal names, remapped with the given remapper.
+func MapTypes(remapper Remapper, internalNames []string) []string {
+	if internalNames == nil {
+		return nil
+	}
+	remapped := make([]string, len(internalNames))
+	for i, internalName := range internalNames {
+		remapped[i] = MapType(remapper, internalName)
+	}
+	return remapped
+}
+
+// MapValue returns the given constant value, remapped with the given remapper. Only {@link Type} and
+// {@link Handle} values are remapped, the other values are returned unchanged.
+func MapValue(remapper Remapper, value interface{}) interface{} {
+	switch v := value.(type) {
+	case *asm.Type:
+		if v == nil {
+			return v
+		}
+		switch v.GetSort() {
+		case typed.OBJECT:
+			return asm.GetObjectType(MapType(remapper, v.GetInternalName()))
+		case typed.ARRAY:
+			return asm.GetType(MapDesc(remapper, v.GetDescriptor()))
+		case typed.METHOD:
+			return asm.GetMethodType(MapMethodDesc(remapper, v.GetDescriptor()))
+		}
+		return v
+	case *asm.Handle:
+		if v == nil {
+			return v
+		}
+		if v.GetTag() <= opcodes.H_PUTSTATIC {
+			return asm.NewHandle(
+				v.GetTag(),
+				MapType(remapper, v.GetOwner()),
+				remapper.MapFieldName(v.GetOwner(), v.GetName(), v.GetDesc()),
+				MapDesc(remapper, v.GetDesc()),
+				v.IsInterface(),
+			)
+		}
+		return asm.NewHandle(
+			v.GetTag(),
+			MapType(remapper, v.GetOwner()),
+			remapper.MapMethodName(v.GetOwner(), v.GetName(), v.GetDesc()),
+			MapMethodDesc(remapper, v.GetDesc()),
+			v.IsInterface(),
+		)
+	default:
+		return value
+	}
+}
+
+// MapInnerClassName returns the simple name of the given inner class, as it must be after its name has been
+// remapped with the given remapper.
+func MapInnerClassName(remapper Remapper, name, ownerName, innerName string) string {
+	remappedInnerName := MapType(remapper, name)
+	index := strings.LastIndexByte(remappedInnerName, '$')
+	if index < 0 {
+		return innerName
+	}
+	index++
+	for index < len(remappedInnerName) && remappedInnerName[index] >= '0' && remappedInnerName[index] <= '9' {
+		index++
+	}
+	return remappedInnerName[index:]
+}
+
+// NewClassReferenceMapper returns the {@link asm.ClassReferenceMapper} which remaps the class names with the given
+// remapper, for {@link asm.RemapClassReferences}.
+func NewClassReferenceMapper(remapper Remapper) asm.ClassReferenceMapper {
+	return asm.ClassReferenceMapper{
+		MapType:       func(internalName string) string { return MapType(remapper, internalName) },
+		MapDescriptor: func(descriptor string) string { return mapDescriptor(remapper, descriptor) },
+		MapSignature: func(signature string, typeSignature bool) string {
+			return MapSignature(remapper, signature, typeSignature)
+		},
+		MapInnerClassName: func(name, outerName, innerName string) string {
+			return MapInnerClassName(remapper, name, outerName, innerName)
+		},
+	}
+}
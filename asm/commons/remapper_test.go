@@ -0,0 +1,54 @@
+package commons_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/commons"
+)
+
+func TestMapDesc(t *testing.T) {
+	remapper := commons.NewSimpleRemapper(map[string]string{"a/A": "b/B"})
+	tests := map[string]string{
+		"":                     "",
+		"I":                    "I",
+		"La/A;":                "Lb/B;",
+		"[[La/A;":              "[[Lb/B;",
+		"(La/A;ILa/AA;)[La/A;": "(Lb/B;ILa/AA;)[Lb/B;",
+	}
+	for descriptor, expected := range tests {
+		if actual := commons.MapMethodDesc(remapper, descriptor); actual != expected {
+			t.Errorf("MapMethodDesc(%q) = %q, want %q", descriptor, actual, expected)
+		}
+	}
+}
+
+func TestMapInnerClassName(t *testing.T) {
+	remapper := commons.NewSimpleRemapper(map[string]string{"a/A$Inner": "b/B$Renamed", "a/A$1": "b/B$2"})
+	if actual := commons.MapInnerClassName(remapper, "a/A$Inner", "a/A", "Inner"); actual != "Renamed" {
+		t.Errorf("MapInnerClassName = %q, want %q", actual, "Renamed")
+	}
+	if actual := commons.MapInnerClassName(remapper, "a/A$1", "", ""); actual != "" {
+		t.Errorf("MapInnerClassName = %q, want %q", actual, "")
+	}
+}
+
+func TestMapSignature(t *testing.T) {
+	remapper := commons.NewSimpleRemapper(map[string]string{"a/A": "b/B", "a/A$Inner": "b/B$Renamed", "L": "X"})
+	tests := []struct {
+		signature     string
+		typeSignature bool
+		expected      string
+	}{
+		{"", false, ""},
+		{"<L:La/A;>Ljava/lang/Object;Ljava/util/List<TL;>;", false, "<L:Lb/B;>Ljava/lang/Object;Ljava/util/List<TL;>;"},
+		{"<T::Ljava/lang/Comparable<-La/A;>;>(TT;[La/A;I)La/A<*>.Inner<+TT;>;^La/A;", false,
+			"<T::Ljava/lang/Comparable<-Lb/B;>;>(TT;[Lb/B;I)Lb/B<*>.Renamed<+TT;>;^Lb/B;"},
+		{"Ljava/util/Map<La/A;[[TL;>;", true, "Ljava/util/Map<Lb/B;[[TL;>;"},
+		{"La/A<TT;", true, "La/A<TT;"},
+	}
+	for _, test := range tests {
+		if actual := commons.MapSignature(remapper, test.signature, test.typeSignature); actual != test.expected {
+			t.Errorf("MapSignature(%q) = %q, want %q", test.signature, actual, test.expected)
+		}
+	}
+}
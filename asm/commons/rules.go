@@ -0,0 +1,278 @@
+package commons
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// Types of the {@link TransformationRule}s.
+const (
+	// RENAME_CLASS_RULE renames the class From to To, and all the references to it.
+	RENAME_CLASS_RULE = "renameClass"
+	// REMOVE_METHODS_RULE removes the methods whose name and descriptor match Pattern, in the classes whose
+	// internal name matches Class (or in all classes if Class is empty).
+	REMOVE_METHODS_RULE = "removeMethods"
+	// ADD_ANNOTATION_RULE adds the annotation Descriptor, without values, to the classes of the package Package
+	// (an internal name, e.g. "com/example") and of its sub packages, unless they already have it.
+	ADD_ANNOTATION_RULE = "addAnnotation"
+)
+
+// TransformationRule a declarative class transformation, of one of the types {@link RENAME_CLASS_RULE},
+// {@link REMOVE_METHODS_RULE} or {@link ADD_ANNOTATION_RULE}. Only the fields used by its type must be set.
+type TransformationRule struct {
+	Type       string `json:"type"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	Class      string `json:"class,omitempty"`
+	Pattern    string `json:"pattern,omitempty"`
+	Package    string `json:"package,omitempty"`
+	Descriptor string `json:"descriptor,omitempty"`
+	Visible    bool   `json:"visible,omitempty"`
+}
+
+// RulesEngine applies {@link TransformationRule}s, read from a configuration file, to classes and jars. The
+// configuration is a JSON document of the following form, or the equivalent YAML document:
+//
+//	{"rules": [
+//	  {"type": "renameClass", "from": "com/example/Old", "to": "com/example/New"},
+//	  {"type": "removeMethods", "class": "com/example/.*", "pattern": "^debug.*"},
+//	  {"type": "addAnnotation", "package": "com/example/api", "descriptor": "Lcom/example/Api;", "visible": true}
+//	]}
+//
+//	rules:
+//	  - type: renameClass
+//	    from: com/example/Old
+//	    to: com/example/New
+//	  - type: removeMethods
+//	    class: com/example/.*
+//	    pattern: ^debug.*
+//
+// The methods to remove and the classes to annotate are selected with the original class names, before the
+// renamings, which are applied last.
+type RulesEngine struct {
+	Rules       []TransformationRule `json:"rules"`
+	renamings   map[string]string
+	classes     []*regexp.Regexp
+	patterns    []*regexp.Regexp
+	annotations []TransformationRule
+}
+
+// ParseRulesEngine returns the {@link RulesEngine} described by the given JSON or YAML configuration. Only the
+// block mappings and sequences, scalars and comments of YAML are supported, not its anchors, tags and multi line
+// scalars.
+func ParseRulesEngine(configuration []byte) (*RulesEngine, error) {
+	document, err := parseYAML(configuration)
+	if err != nil {
+		return nil, err
+	}
+	// The YAML document is decoded like the equivalent JSON one.
+	if configuration, err = json.Marshal(document); err != nil {
+		return nil, err
+	}
+	rulesEngine := &RulesEngine{}
+	if err := json.Unmarshal(configuration, rulesEngine); err != nil {
+		return nil, err
+	}
+	if err := rulesEngine.compile(); err != nil {
+		return nil, err
+	}
+	return rulesEngine, nil
+}
+
+// NewRulesEngine constructs a new {@link RulesEngine} with the given rules.
+func NewRulesEngine(rules []TransformationRule) (*RulesEngine, error) {
+	rulesEngine := &RulesEngine{Rules: rules}
+	if err := rulesEngine.compile(); err != nil {
+		return nil, err
+	}
+	return rulesEngine, nil
+}
+
+func (r *RulesEngine) compile() error {
+	r.renamings = make(map[string]string)
+	for i, rule := range r.Rules {
+		invalid := func(message string) error {
+			return errors.New("Illegal Argument - rule " + strconv.Itoa(i) + " (" + rule.Type + "): " + message)
+		}
+		switch rule.Type {
+		case RENAME_CLASS_RULE:
+			if rule.From == "" || rule.To == "" {
+				return invalid("from and to are required")
+			}
+			if err := asm.CheckInternalName(rule.From); err != nil {
+				return invalid(err.Error())
+			}
+			if err := asm.CheckDefinableClassName(rule.To); err != nil {
+				return invalid(err.Error())
+			}
+			if _, ok := r.renamings[rule.From]; ok {
+				return invalid("class " + rule.From + " is already renamed")
+			}
+			r.renamings[rule.From] = rule.To
+		case REMOVE_METHODS_RULE:
+			class, err := regexp.Compile("^(?:" + rule.Class + ")$")
+			if rule.Class == "" {
+				class, err = nil, nil
+			}
+			if err != nil {
+				return invalid(err.Error())
+			}
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil || rule.Pattern == "" {
+				return invalid("invalid pattern " + rule.Pattern)
+			}
+			r.classes = append(r.classes, class)
+			r.patterns = append(r.patterns, pattern)
+		case ADD_ANNOTATION_RULE:
+			if rule.Package == "" || !strings.HasPrefix(rule.Descriptor, "L") || !strings.HasSuffix(rule.Descriptor, ";") {
+				return invalid("package and annotation descriptor are required")
+			}
+			r.annotations = append(r.annotations, rule)
+		default:
+			return invalid("unknown rule type")
+		}
+	}
+	return nil
+}
+
+// NewClassVisitor returns a {@link ClassVisitor} which applies the rules to the visited class, and delegates
+// the result to the given visitor.
+func (r *RulesEngine) NewClassVisitor(classVisitor asm.ClassVisitor) asm.ClassVisitor {
+	if len(r.renamings) > 0 {
+		classVisitor = NewClassRemapper(classVisitor, NewSimpleRemapper(r.renamings))
+	}
+	return &rulesClassAdapter{ClassAdapter: helper.ClassAdapter{Next: classVisitor}, engine: r}
+}
+
+// MapClassName returns the name of the given class after the renamings of the rules.
+func (r *RulesEngine) MapClassName(name string) string {
+	if newName, ok := r.renamings[name]; ok {
+		return newName
+	}
+	return name
+}
+
+// TransformClass returns the given class file with the rules applied, at the byte level (see
+// {@link asm.RemoveMethod}, {@link asm.AddClassAnnotation} and {@link asm.RemapClassReferences}).
+func (r *RulesEngine) TransformClass(classFile []byte) ([]byte, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	className := reader.GetClassName()
+	for _, method := range reader.Index().Methods {
+		for i, pattern := range r.patterns {
+			class := r.classes[i]
+			if (class == nil || class.MatchString(className)) && pattern.MatchString(method.Name+method.Descriptor) {
+				selector := asm.MemberSelector{Name: method.Name, Descriptor: method.Descriptor}
+				if classFile, err = asm.RemoveMethod(classFile, selector, asm.MemberEditOptions{}); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	for _, rule := range r.annotations {
+		if strings.HasPrefix(className, rule.Package+"/") {
+			if classFile, err = asm.AddClassAnnotation(classFile, rule.Descriptor, rule.Visible); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(r.renamings) > 0 {
+		return asm.RemapClassReferences(classFile, NewClassReferenceMapper(NewSimpleRemapper(r.renamings)))
+	}
+	return classFile, nil
+}
+
+// ApplyJar applies the rules to each class of the given input jar (or zip) file, and writes the result to the
+// given output jar, with {@link TransformJar} (the entries of the renamed classes are thus renamed too). The
+// module descriptors and the other entries are copied unchanged. Returns the number of transformed classes.
+func (r *RulesEngine) ApplyJar(in, out string, options TransformJarOptions) (int, error) {
+	return TransformJar(in, out, func(className string) *ClassVisitorChain {
+		if className == "module-info" {
+			return nil
+		}
+		return &ClassVisitorChain{Transform: r.TransformClass}
+	}, options)
+}
+
+// rulesClassAdapter the {@link ClassVisitor} removing the methods and adding the annotations of a
+// {@link RulesEngine}.
+type rulesClassAdapter struct {
+	helper.ClassAdapter
+	engine      *RulesEngine
+	className   string
+	annotations map[string]bool
+	done        bool
+}
+
+func (r *rulesClassAdapter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	r.className = name
+	r.annotations = make(map[string]bool)
+	r.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (r *rulesClassAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	r.annotations[descriptor] = true
+	return r.ClassAdapter.VisitAnnotation(descriptor, visible)
+}
+
+// visitAnnotations visits the added annotations, after the existing ones.
+func (r *rulesClassAdapter) visitAnnotations() {
+	if r.done {
+		return
+	}
+	r.done = true
+	for _, rule := range r.engine.annotations {
+		if !strings.HasPrefix(r.className, rule.Package+"/") || r.annotations[rule.Descriptor] {
+			continue
+		}
+		r.annotations[rule.Descriptor] = true
+		if annotationVisitor := r.ClassAdapter.VisitAnnotation(rule.Descriptor, rule.Visible); annotationVisitor != nil {
+			annotationVisitor.VisitEnd()
+		}
+	}
+}
+
+func (r *rulesClassAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	r.visitAnnotations()
+	return r.ClassAdapter.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+}
+
+func (r *rulesClassAdapter) VisitAttribute(attribute *asm.Attribute) {
+	r.visitAnnotations()
+	r.ClassAdapter.VisitAttribute(attribute)
+}
+
+func (r *rulesClassAdapter) VisitInnerClass(name, outerName, innerName string, access int) {
+	r.visitAnnotations()
+	r.ClassAdapter.VisitInnerClass(name, outerName, innerName, access)
+}
+
+func (r *rulesClassAdapter) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	r.visitAnnotations()
+	return r.ClassAdapter.VisitField(access, name, descriptor, signature, value)
+}
+
+func (r *rulesClassAdapter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	r.visitAnnotations()
+	for i, pattern := range r.engine.patterns {
+		class := r.engine.classes[i]
+		if (class == nil || class.MatchString(r.className)) && pattern.MatchString(name+descriptor) {
+			return nil
+		}
+	}
+	return r.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+}
+
+func (r *rulesClassAdapter) VisitEnd() {
+	r.visitAnnotations()
+	r.ClassAdapter.VisitEnd()
+}
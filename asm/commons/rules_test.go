@@ -0,0 +1,199 @@
+package commons_test
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+const rulesYAML = `
+# Renames Old, removes the debug methods, and annotates the api package.
+rules:
+  - type: renameClass
+    from: com/example/Old
+    to: "com/example/New"
+  - type: removeMethods   # in all classes
+    pattern: '^debug.*'
+  - type: addAnnotation
+    package: com/example/api
+    descriptor: Lcom/example/Api;
+    visible: true
+`
+
+const rulesJSON = `{"rules": [
+  {"type": "renameClass", "from": "com/example/Old", "to": "com/example/New"},
+  {"type": "removeMethods", "pattern": "^debug.*"},
+  {"type": "addAnnotation", "package": "com/example/api", "descriptor": "Lcom/example/Api;", "visible": true}
+]}`
+
+// rulesClass returns a class with the abstract methods "void debug()" and "void use(Old)".
+func rulesClass(t *testing.T, name string) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, name, "java/lang/Object")
+	classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "debug", "()V", "", nil)
+	classFile.AddMethod(opcodes.ACC_PUBLIC|opcodes.ACC_ABSTRACT, "use", "(Lcom/example/Old;)V", "", nil)
+	return classFile.Bytes()
+}
+
+// recordClass returns the events of the given class.
+func recordClass(t *testing.T, classFile []byte) *asmtest.Recorder {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	reader.Accept(recorder, 0)
+	return recorder
+}
+
+func TestParseRulesEngine(t *testing.T) {
+	fromYAML, err := commons.ParseRulesEngine([]byte(rulesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := commons.ParseRulesEngine([]byte(rulesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML.Rules, fromJSON.Rules) {
+		t.Errorf("unexpected YAML rules %+v", fromYAML.Rules)
+	}
+
+	for _, configuration := range []string{
+		"rules:\n  - type: renameClass\n    from: a/A\n     to: a/B\n",
+		"rules:\n  - type: renameClass\n    type: renameClass\n",
+		"rules:\n  - type: unknown\n",
+		"rules:\n  - type: removeMethods\n    pattern: '(\n",
+		"rules: &anchor\n",
+		"rules\n",
+	} {
+		if _, err := commons.ParseRulesEngine([]byte(configuration)); err == nil {
+			t.Errorf("expected an error for %q", configuration)
+		}
+	}
+}
+
+func TestRulesEngineTransformClass(t *testing.T) {
+	rulesEngine, err := commons.ParseRulesEngine([]byte(rulesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name, newName string
+		annotated     bool
+	}{
+		{"com/example/api/Service", "com/example/api/Service", true},
+		{"com/example/Old", "com/example/New", false},
+	} {
+		transformed, err := rulesEngine.TransformClass(rulesClass(t, test.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := recordClass(t, transformed)
+		if name := recorder.GetEvents(helper.CLASS_VISIT, "")[0].Args[2]; name != test.newName {
+			t.Errorf("unexpected class name %v", name)
+		}
+		recorder.AssertNotVisitedMethod(t, "debug", "()V")
+		recorder.AssertVisitedMethod(t, "use", "(Lcom/example/New;)V")
+		annotations := recorder.GetEvents(helper.CLASS_VISIT_ANNOTATION, "")
+		if test.annotated != (len(annotations) == 1) {
+			t.Errorf("%s: unexpected annotations %v", test.name, annotations)
+		}
+
+		// The rules are idempotent: the annotation is not added twice.
+		again, err := rulesEngine.TransformClass(transformed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if annotations := recordClass(t, again).GetEvents(helper.CLASS_VISIT_ANNOTATION, ""); len(annotations) > 1 {
+			t.Errorf("duplicate annotations %v", annotations)
+		}
+	}
+
+	// The second annotation is appended to the RuntimeVisibleAnnotations attribute created for the first one.
+	rulesEngine, err = commons.NewRulesEngine([]commons.TransformationRule{
+		{Type: commons.ADD_ANNOTATION_RULE, Package: "p", Descriptor: "LA;", Visible: true},
+		{Type: commons.ADD_ANNOTATION_RULE, Package: "p", Descriptor: "LB;", Visible: true},
+		{Type: commons.ADD_ANNOTATION_RULE, Package: "p", Descriptor: "LC;"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transformed, err := rulesEngine.TransformClass(rulesClass(t, "p/C"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordClass(t, transformed).AssertTrace(t, []string{
+		`class visit p/C 52 1025 "p/C" "" "java/lang/Object" []`,
+		`class visit annotation p/C "LA;" true`,
+		`class visit annotation p/C "LB;" true`,
+		`class visit annotation p/C "LC;" false`,
+		`class visit method p/C 1025 "debug" "()V" "" []`,
+		`method visit end p/C.debug()V`,
+		`class visit method p/C 1025 "use" "(Lcom/example/Old;)V" "" []`,
+		`method visit end p/C.use(Lcom/example/Old;)V`,
+		`class visit end p/C`,
+	})
+}
+
+func TestRulesEngineApplyJar(t *testing.T) {
+	rulesEngine, err := commons.ParseRulesEngine([]byte(rulesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	moduleInfo := asmtest.NewClassFile(opcodes.V9, opcodes.ACC_MODULE, "module-info", "")
+	moduleInfo.AddModule(asm.NewModuleWriter(moduleInfo.SymbolTable, "m", 0, ""))
+	moduleInfoClass := moduleInfo.Bytes()
+	directory := t.TempDir()
+	in := filepath.Join(directory, "in.jar")
+	out := filepath.Join(directory, "out.jar")
+	err = os.WriteFile(in, writeJar(t,
+		jarEntry{"META-INF/MANIFEST.MF", []byte("Manifest-Version: 1.0\n"), zip.Deflate},
+		jarEntry{"module-info.class", moduleInfoClass, zip.Deflate},
+		jarEntry{"com/example/Old.class", rulesClass(t, "com/example/Old"), zip.Deflate},
+		jarEntry{"com/example/api/Service.class", rulesClass(t, "com/example/api/Service"), zip.Deflate},
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := rulesEngine.ApplyJar(in, out, commons.TransformJarOptions{}); err != nil || count != 2 {
+		t.Fatalf("unexpected result %d %v", count, err)
+	}
+
+	jar, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jar.Close()
+	var names []string
+	contents := make(map[string][]byte)
+	for _, file := range jar.File {
+		names = append(names, file.Name)
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[file.Name], _ = io.ReadAll(reader)
+		reader.Close()
+	}
+	expectedNames := []string{"META-INF/MANIFEST.MF", "module-info.class", "com/example/New.class", "com/example/api/Service.class"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("unexpected entries %v", names)
+	}
+	if string(contents["module-info.class"]) != string(moduleInfoClass) {
+		t.Error("module-info.class was modified")
+	}
+	recorder := recordClass(t, contents["com/example/api/Service.class"])
+	recorder.AssertNotVisitedMethod(t, "debug", "()V")
+	if annotations := recorder.GetEvents(helper.CLASS_VISIT_ANNOTATION, ""); len(annotations) != 1 {
+		t.Errorf("unexpected annotations %v", annotations)
+	}
+}
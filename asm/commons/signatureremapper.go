@@ -0,0 +1,135 @@
+package commons
+
+import (
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/signature"
+)
+
+// MapSignature returns the given generic signature, remapped with the given remapper. The signature is a class or
+// method signature, or a field or local variable type signature if typeSignature is true. The class names of the
+// class type signatures are remapped, including the names of their inner classes, whose simple names are
+// computed from their remapped names (see {@link SignatureRemapper}). A malformed signature is returned
+// unchanged.
+func MapSignature(remapper Remapper, signatureValue string, typeSignature bool) (remapped string) {
+	if signatureValue == "" {
+		return signatureValue
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if _, ok := recovered.(error); !ok {
+				panic(recovered)
+			}
+			remapped = signatureValue
+		}
+	}()
+	reader := signature.NewSignatureReader(signatureValue)
+	writer := signature.NewSignatureWriter()
+	if typeSignature {
+		reader.AcceptType(NewSignatureRemapper(writer, remapper))
+	} else {
+		reader.Accept(NewSignatureRemapper(writer, remapper))
+	}
+	return writer.String()
+}
+
+// SignatureRemapper a {@link signature.SignatureVisitor} that remaps the class names of the signature it visits
+// with a {@link Remapper}, and makes another visitor visit the remapped signature.
+type SignatureRemapper struct {
+	signatureVisitor signature.SignatureVisitor
+	remapper         Remapper
+	// classNames the internal names of the class types being visited, shared with the remappers of the nested
+	// signatures.
+	classNames *[]string
+}
+
+// NewSignatureRemapper constructs a new {@link SignatureRemapper}.
+func NewSignatureRemapper(signatureVisitor signature.SignatureVisitor, remapper Remapper) *SignatureRemapper {
+	return &SignatureRemapper{signatureVisitor: signatureVisitor, remapper: remapper, classNames: &[]string{}}
+}
+
+// remapperOf returns a remapper delegating to the given visitor, returned by the visitor of this remapper.
+func (s *SignatureRemapper) remapperOf(signatureVisitor signature.SignatureVisitor) signature.SignatureVisitor {
+	if signatureVisitor == s.signatureVisitor {
+		return s
+	}
+	return &SignatureRemapper{signatureVisitor: signatureVisitor, remapper: s.remapper, classNames: s.classNames}
+}
+
+func (s *SignatureRemapper) VisitFormalTypeParameter(name string) {
+	s.signatureVisitor.VisitFormalTypeParameter(name)
+}
+
+func (s *SignatureRemapper) VisitClassBound() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitClassBound())
+}
+
+func (s *SignatureRemapper) VisitInterfaceBound() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitInterfaceBound())
+}
+
+func (s *SignatureRemapper) VisitSuperclass() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitSuperclass())
+}
+
+func (s *SignatureRemapper) VisitInterface() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitInterface())
+}
+
+func (s *SignatureRemapper) VisitParameterType() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitParameterType())
+}
+
+func (s *SignatureRemapper) VisitReturnType() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitReturnType())
+}
+
+func (s *SignatureRemapper) VisitExceptionType() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitExceptionType())
+}
+
+func (s *SignatureRemapper) VisitBaseType(descriptor rune) {
+	s.signatureVisitor.VisitBaseType(descriptor)
+}
+
+func (s *SignatureRemapper) VisitTypeVariable(name string) {
+	s.signatureVisitor.VisitTypeVariable(name)
+}
+
+func (s *SignatureRemapper) VisitArrayType() signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitArrayType())
+}
+
+func (s *SignatureRemapper) VisitClassType(name string) {
+	*s.classNames = append(*s.classNames, name)
+	s.signatureVisitor.VisitClassType(MapType(s.remapper, name))
+}
+
+// VisitInnerClassType visits the simple name of the remapped inner class: the suffix of its remapped name after
+// the remapped name of its outer class, or else after its last '$'.
+func (s *SignatureRemapper) VisitInnerClassType(name string) {
+	classNames := *s.classNames
+	outerClassName := classNames[len(classNames)-1]
+	className := outerClassName + "$" + name
+	classNames[len(classNames)-1] = className
+	remappedOuter := MapType(s.remapper, outerClassName) + "$"
+	remappedName := MapType(s.remapper, className)
+	index := strings.LastIndexByte(remappedName, '$') + 1
+	if strings.HasPrefix(remappedName, remappedOuter) {
+		index = len(remappedOuter)
+	}
+	s.signatureVisitor.VisitInnerClassType(remappedName[index:])
+}
+
+func (s *SignatureRemapper) VisitTypeArgument() {
+	s.signatureVisitor.VisitTypeArgument()
+}
+
+func (s *SignatureRemapper) VisitTypeArgumentB(wildcard rune) signature.SignatureVisitor {
+	return s.remapperOf(s.signatureVisitor.VisitTypeArgumentB(wildcard))
+}
+
+func (s *SignatureRemapper) VisitEnd() {
+	s.signatureVisitor.VisitEnd()
+	*s.classNames = (*s.classNames)[:len(*s.classNames)-1]
+}
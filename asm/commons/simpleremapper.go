@@ -0,0 +1,51 @@
+package commons
+
+// SimpleRemapper a {@link Remapper} using a map to define its mapping. The keys are internal names for
+// classes, "owner.name" for fields, "owner.name" followed by the method descriptor for methods, and
+// ".name" followed by the method descriptor for invokedynamic methods.
+type SimpleRemapper struct {
+	mapping map[string]string
+}
+
+// NewSimpleRemapper constructs a new {@link SimpleRemapper} with the given mapping.
+func NewSimpleRemapper(mapping map[string]string) *SimpleRemapper {
+	return &SimpleRemapper{
+		mapping: mapping,
+	}
+}
+
+func (s SimpleRemapper) Map(key string) string {
+	if value, ok := s.mapping[key]; ok {
+		return value
+	}
+	return key
+}
+
+func (s SimpleRemapper) MapMethodName(owner, name, descriptor string) string {
+	if value, ok := s.mapping[owner+"."+name+descriptor]; ok {
+		return value
+	}
+	return name
+}
+
+func (s SimpleRemapper) MapInvokeDynamicMethodName(name, descriptor string) string {
+	if value, ok := s.mapping["."+name+descriptor]; ok {
+		return value
+	}
+	return name
+}
+
+func (s SimpleRemapper) MapFieldName(owner, name, descriptor string) string {
+	if value, ok := s.mapping[owner+"."+name]; ok {
+		return value
+	}
+	return name
+}
+
+func (s SimpleRemapper) MapPackageName(name string) string {
+	return s.Map(name)
+}
+
+func (s SimpleRemapper) MapModuleName(name string) string {
+	return s.Map(name)
+}
@@ -0,0 +1,131 @@
+package commons
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// TimingTransformer a {@link ClassVisitor} that measures the execution time of the methods: it stores
+// System.nanoTime() in a new local variable at the method entry, and prints the method name and its elapsed
+// time in nanoseconds to System.err before each return instruction. The new local variable is inserted after
+// the method parameters, and the following local variables are shifted accordingly. The class must be read
+// with the EXPAND_FRAMS option, or with SKIP_FRAMES if the transformed class does not need frames.
+type TimingTransformer struct {
+	helper.ClassAdapter
+	className string
+}
+
+// NewTimingTransformer constructs a new {@link TimingTransformer}.
+func NewTimingTransformer(classVisitor asm.ClassVisitor) *TimingTransformer {
+	return &TimingTransformer{ClassAdapter: helper.ClassAdapter{Next: classVisitor}}
+}
+
+func (t *TimingTransformer) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	t.className = name
+	t.ClassAdapter.Visit(version, access, name, signature, superName, interfaces)
+}
+
+func (t *TimingTransformer) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	methodVisitor := t.ClassAdapter.VisitMethod(access, name, descriptor, signature, exceptions)
+	if methodVisitor == nil || (access&(opcodes.ACC_ABSTRACT|opcodes.ACC_NATIVE)) != 0 {
+		return methodVisitor
+	}
+	firstLocal := 0
+	if (access & opcodes.ACC_STATIC) == 0 {
+		firstLocal = 1
+	}
+	for _, argumentType := range asm.GetMethodType(descriptor).GetArgumentTypes() {
+		firstLocal += argumentType.GetSize()
+	}
+	return &timingMethodTransformer{
+		MethodAdapter: helper.MethodAdapter{Next: methodVisitor},
+		method:        t.className + "." + name + descriptor,
+		firstLocal:    firstLocal,
+	}
+}
+
+type timingMethodTransformer struct {
+	helper.MethodAdapter
+	method string
+	// firstLocal the index of the local variable storing the start time.
+	firstLocal int
+}
+
+// remap returns the new index of the given local variable.
+func (t *timingMethodTransformer) remap(local int) int {
+	if local >= t.firstLocal {
+		return local + 2
+	}
+	return local
+}
+
+func (t *timingMethodTransformer) VisitCode() {
+	t.MethodAdapter.VisitCode()
+	t.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, "java/lang/System", "nanoTime", "()J", false)
+	t.MethodAdapter.VisitVarInsn(opcodes.LSTORE, t.firstLocal)
+}
+
+func (t *timingMethodTransformer) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	if typed == opcodes.F_NEW || typed == opcodes.F_FULL {
+		// Inserts the start time after the frame types of the parameters.
+		locals, _ := local.([]interface{})
+		locals = locals[:min(nLocal, len(locals))]
+		i, slot := 0, 0
+		for i < len(locals) && slot < t.firstLocal {
+			if locals[i] == opcodes.LONG || locals[i] == opcodes.DOUBLE {
+				slot++
+			}
+			i++
+			slot++
+		}
+		for slot < t.firstLocal {
+			locals = append(locals, opcodes.TOP)
+			slot++
+			i++
+		}
+		newLocals := append(append(append([]interface{}(nil), locals[:i]...), opcodes.LONG), locals[i:]...)
+		t.MethodAdapter.VisitFrame(typed, len(newLocals), newLocals, nStack, stack)
+		return
+	}
+	t.MethodAdapter.VisitFrame(typed, nLocal, local, nStack, stack)
+}
+
+func (t *timingMethodTransformer) VisitInsn(opcode int) {
+	if opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN {
+		t.MethodAdapter.VisitFieldInsn(opcodes.GETSTATIC, "java/lang/System", "err", "Ljava/io/PrintStream;")
+		t.MethodAdapter.VisitLdcInsn(t.method + ": ")
+		t.MethodAdapter.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/io/PrintStream", "print", "(Ljava/lang/String;)V", false)
+		t.MethodAdapter.VisitFieldInsn(opcodes.GETSTATIC, "java/lang/System", "err", "Ljava/io/PrintStream;")
+		t.MethodAdapter.VisitMethodInsnB(opcodes.INVOKESTATIC, "java/lang/System", "nanoTime", "()J", false)
+		t.MethodAdapter.VisitVarInsn(opcodes.LLOAD, t.firstLocal)
+		t.MethodAdapter.VisitInsn(opcodes.LSUB)
+		t.MethodAdapter.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, "java/io/PrintStream", "println", "(J)V", false)
+	}
+	t.MethodAdapter.VisitInsn(opcode)
+}
+
+func (t *timingMethodTransformer) VisitVarInsn(opcode, vard int) {
+	t.MethodAdapter.VisitVarInsn(opcode, t.remap(vard))
+}
+
+func (t *timingMethodTransformer) VisitIincInsn(vard, increment int) {
+	t.MethodAdapter.VisitIincInsn(t.remap(vard), increment)
+}
+
+func (t *timingMethodTransformer) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	t.MethodAdapter.VisitLocalVariable(name, descriptor, signature, start, end, t.remap(index))
+}
+
+func (t *timingMethodTransformer) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	remapped := make([]int, len(index))
+	for i, local := range index {
+		remapped[i] = t.remap(local)
+	}
+	return t.MethodAdapter.VisitLocalVariableAnnotation(typeRef, typePath, start, end, remapped, descriptor, visible)
+}
+
+func (t *timingMethodTransformer) VisitMaxs(maxStack int, maxLocals int) {
+	// The printing code needs up to 5 stack slots (the stream and two longs), above the returned value.
+	t.MethodAdapter.VisitMaxs(maxStack+5, max(maxLocals, t.firstLocal)+2)
+}
@@ -0,0 +1,264 @@
+package commons
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+)
+
+// ClassVisitorChain a chain of class visitors transforming a class: the class is visited by Visitor, and the
+// transformed class file is then returned by Result (built by the visitor ending the chain). Alternatively, a
+// class can be transformed directly at the byte level by Transform.
+type ClassVisitorChain struct {
+	// Visitor the first visitor of the chain.
+	Visitor asm.ClassVisitor
+	// Result returns the transformed class file, after the class has been visited.
+	Result func() ([]byte, error)
+	// Transform if not nil, is used instead of Visitor and Result: it returns the transformed version of the given
+	// class file, e.g. with {@link asm.RemapClassReferences} or {@link asm.RemoveMethod}.
+	Transform func(classFile []byte) ([]byte, error)
+}
+
+// TransformJarOptions the options of {@link TransformJar}.
+type TransformJarOptions struct {
+	// ParsingOptions the options used to read the classes (see {@link ClassReader#Accept}).
+	ParsingOptions int
+	// Parallelism the maximum number of classes transformed concurrently, or 0 for runtime.GOMAXPROCS(0).
+	Parallelism int
+	// Progress if not nil, is called after each entry is written to the output jar, with the number of written
+	// entries, the total number of entries, and the name of the entry. It is called from a single goroutine.
+	Progress func(done, total int, name string)
+	// Filter if not nil, only the classes accepted by this filter are passed to the factory; the other ones are
+	// copied unchanged. The entries whose name is rejected by the filter are not even decompressed.
+	Filter *ClassFilter
+	// DryRun if not nil, the classes are transformed but no output jar is written (the out path is ignored):
+	// instead, a diff between the original and the transformed version of each changed class is written to
+	// DryRun (see {@link DiffClass}), in the order of the input jar, to review what the transformation does.
+	DryRun io.Writer
+	// DeterministicConstantPool if true, the constant pool of each transformed class is sorted with
+	// {@link asm.SortConstantPool}, so that the output jar does not depend on the order in which the classes have
+	// been written (e.g. for reproducible builds).
+	DeterministicConstantPool bool
+	// Verify if true, each transformed class is checked with {@link analysis.VerifyOutput}, and the transformation
+	// fails with its diagnostics if the class is malformed or has an invalid method.
+	Verify bool
+}
+
+// transformedEntry an entry of the output jar of {@link TransformJar}.
+type transformedEntry struct {
+	file        *zip.File
+	name        string
+	content     []byte
+	diff        []byte
+	transformed bool
+	err         error
+}
+
+// TransformJar transforms each class of the given input jar (or zip) file with the visitor chain returned by
+// the factory for its internal name, and writes the transformed classes to the given output jar, in the order of
+// the input jar. The classes for which the factory returns nil, and the other entries, are copied unchanged
+// (without being decompressed). A class renamed by its chain is written under its new name. The classes are
+// transformed concurrently, so the factory must be safe for concurrent use, but each chain is used by a single
+// goroutine. A malformed class is reported as an error prefixed with its entry name. The output jar is written to
+// a temporary file, renamed to out only if all the entries have been written, so that out is left untouched on
+// error. Returns the number of transformed classes.
+func TransformJar(in, out string, factory func(className string) *ClassVisitorChain, options TransformJarOptions) (int, error) {
+	jar, err := zip.OpenReader(in)
+	if err != nil {
+		return 0, err
+	}
+	defer jar.Close()
+	var outputFile *os.File
+	var output *zip.Writer
+	if options.DryRun == nil {
+		if outputFile, err = os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".*.tmp"); err != nil {
+			return 0, err
+		}
+		defer func() {
+			// Does nothing if the temporary file has been closed and renamed.
+			outputFile.Close()
+			os.Remove(outputFile.Name())
+		}()
+		output = zip.NewWriter(outputFile)
+	}
+
+	parallelism := options.Parallelism
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	// The pending entries, in the input order. The channel capacity bounds the number of entries in memory.
+	pending := make(chan chan transformedEntry, parallelism)
+	stop := make(chan struct{})
+	go func() {
+		defer close(pending)
+		for _, file := range jar.File {
+			result := make(chan transformedEntry, 1)
+			select {
+			case pending <- result:
+			case <-stop:
+				return
+			}
+			go func(file *zip.File) {
+				result <- transformEntry(file, factory, options)
+			}(file)
+		}
+	}()
+
+	count := 0
+	done := 0
+	for result := range pending {
+		entry := <-result
+		if err == nil {
+			err = entry.err
+			if err == nil && options.DryRun != nil {
+				_, err = options.DryRun.Write(entry.diff)
+			} else if err == nil {
+				err = writeEntry(output, entry)
+			}
+			if err != nil {
+				close(stop)
+			}
+		}
+		if err != nil {
+			// Drain the pending entries, so that no goroutine is left blocked.
+			continue
+		}
+		if entry.transformed {
+			count++
+		}
+		done++
+		if options.Progress != nil {
+			options.Progress(done, len(jar.File), entry.name)
+		}
+	}
+	if err != nil || options.DryRun != nil {
+		return count, err
+	}
+	if err := output.Close(); err != nil {
+		return count, err
+	}
+	if err := outputFile.Close(); err != nil {
+		return count, err
+	}
+	return count, os.Rename(outputFile.Name(), out)
+}
+
+// transformEntry transforms the given jar entry if it is a class for which the factory returns a chain.
+func transformEntry(file *zip.File, factory func(className string) *ClassVisitorChain, options TransformJarOptions) transformedEntry {
+	entry := transformedEntry{file: file, name: file.Name}
+	if !strings.HasSuffix(file.Name, ".class") || file.FileInfo().IsDir() {
+		return entry
+	}
+	if options.Filter != nil && !options.Filter.MatchesEntry(file.Name) {
+		return entry
+	}
+	content, err := file.Open()
+	if err != nil {
+		entry.err = err
+		return entry
+	}
+	classFile, err := io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		entry.err = err
+		return entry
+	}
+	if options.Filter != nil {
+		if accepted, err := options.Filter.Accept(classFile); err != nil || !accepted {
+			if err != nil {
+				entry.err = errors.New(file.Name + ": " + err.Error())
+			}
+			return entry
+		}
+	}
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		entry.err = errors.New(file.Name + ": " + err.Error())
+		return entry
+	}
+	className := reader.GetClassName()
+	chain := factory(className)
+	if chain == nil {
+		return entry
+	}
+	if entry.content, err = transformClass(classFile, reader, chain, options.ParsingOptions); err != nil {
+		entry.err = errors.New(file.Name + ": " + err.Error())
+		return entry
+	}
+	if options.DeterministicConstantPool {
+		if entry.content, err = asm.SortConstantPool(entry.content); err != nil {
+			entry.err = errors.New(file.Name + ": " + err.Error())
+			return entry
+		}
+	}
+	if options.Verify {
+		if _, err = analysis.VerifyOutput(entry.content); err != nil {
+			entry.err = errors.New(file.Name + ": " + err.Error())
+			return entry
+		}
+	}
+	entry.transformed = true
+	if options.DryRun != nil {
+		var diff bytes.Buffer
+		if _, err := DiffClass(&diff, file.Name, classFile, entry.content); err != nil {
+			entry.err = errors.New(file.Name + ": " + err.Error())
+			return entry
+		}
+		entry.diff = diff.Bytes()
+	}
+	// Rename the entry of a renamed class, keeping its prefix (e.g. META-INF/versions/9/).
+	if prefix := strings.TrimSuffix(file.Name, className+".class"); prefix != file.Name {
+		if transformedReader, err := asm.NewClassReader(entry.content); err == nil {
+			entry.name = prefix + transformedReader.GetClassName() + ".class"
+		}
+	}
+	return entry
+}
+
+// transformClass transforms the given class with the given chain, and returns the transformed class file, or the
+// parse error of the class if it is malformed.
+func transformClass(classFile []byte, reader *asm.ClassReader, chain *ClassVisitorChain, parsingOptions int) (content []byte, err error) {
+	defer asm.RecoverParseError(&err)
+	if chain.Transform != nil {
+		return chain.Transform(classFile)
+	}
+	reader.Accept(chain.Visitor, parsingOptions)
+	return chain.Result()
+}
+
+// writeEntry writes the given entry to the given output jar.
+func writeEntry(output *zip.Writer, entry transformedEntry) error {
+	if !entry.transformed {
+		writer, err := output.CreateRaw(&entry.file.FileHeader)
+		if err != nil {
+			return err
+		}
+		content, err := entry.file.OpenRaw()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, content)
+		return err
+	}
+	header := &zip.FileHeader{
+		Name:     entry.name,
+		Comment:  entry.file.Comment,
+		Method:   zip.Deflate,
+		Modified: entry.file.Modified,
+	}
+	header.SetMode(entry.file.Mode())
+	writer, err := output.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(entry.content)
+	return err
+}
@@ -0,0 +1,113 @@
+package commons_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// unknownOpcodeClass returns a class with a method whose code is an unknown opcode.
+func unknownOpcodeClass(t *testing.T) []byte {
+	class := hierarchyClass(t, opcodes.ACC_PUBLIC, "p/B", "java/lang/Object", false)
+	class, err := asm.AddMethod(class, opcodes.ACC_STATIC, "m", "()V", func(methodVisitor asm.MethodVisitor) {
+		methodVisitor.VisitCode()
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := asm.NewClassReader(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := reader.Index().Methods[0]
+	// Replaces the RETURN instruction, which follows the max_stack, max_locals and code_length fields.
+	class[method.GetAttribute("Code").Start+6+8] = 0xFE
+	return class
+}
+
+func TestTransformJarMalformedClass(t *testing.T) {
+	directory := t.TempDir()
+	in := filepath.Join(directory, "in.jar")
+	out := filepath.Join(directory, "out.jar")
+	classA := hierarchyClass(t, opcodes.ACC_PUBLIC, "p/A", "java/lang/Object", false)
+	if err := os.WriteFile(out, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	factory := func(className string) *commons.ClassVisitorChain {
+		classNode := tree.NewClassNode()
+		return &commons.ClassVisitorChain{
+			Visitor: classNode,
+			Result:  func() ([]byte, error) { return classA, nil },
+		}
+	}
+
+	err := os.WriteFile(in, writeJar(t,
+		jarEntry{"p/A.class", classA, zip.Deflate},
+		jarEntry{"p/B.class", unknownOpcodeClass(t), zip.Deflate},
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := commons.TransformJar(in, out, factory, commons.TransformJarOptions{}); err == nil ||
+		!strings.HasPrefix(err.Error(), "p/B.class: ") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if content, err := os.ReadFile(out); err != nil || string(content) != "previous" {
+		t.Errorf("output jar overwritten on error: %q %v", content, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(directory, "out.jar.*")); len(files) != 0 {
+		t.Errorf("temporary files left: %v", files)
+	}
+
+	if err := os.WriteFile(in, writeJar(t, jarEntry{"p/A.class", classA, zip.Deflate}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := commons.TransformJar(in, out, factory, commons.TransformJarOptions{}); err != nil || count != 1 {
+		t.Fatalf("unexpected result %d %v", count, err)
+	}
+	jar, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jar.Close()
+	if len(jar.File) != 1 || jar.File[0].Name != "p/A.class" {
+		t.Errorf("unexpected output jar entries %v", jar.File)
+	}
+}
+
+func TestTransformJarVerify(t *testing.T) {
+	directory := t.TempDir()
+	in := filepath.Join(directory, "in.jar")
+	out := filepath.Join(directory, "out.jar")
+	classA := hierarchyClass(t, opcodes.ACC_PUBLIC, "p/A", "java/lang/Object", false)
+	if err := os.WriteFile(in, writeJar(t, jarEntry{"p/A.class", classA, zip.Deflate}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Adds a method returning a long from an int.
+	factory := func(className string) *commons.ClassVisitorChain {
+		return &commons.ClassVisitorChain{Transform: func(classFile []byte) ([]byte, error) {
+			return asm.AddMethod(classFile, opcodes.ACC_STATIC, "m", "()J", func(methodVisitor asm.MethodVisitor) {
+				methodVisitor.VisitCode()
+				methodVisitor.VisitInsn(opcodes.ICONST_0)
+				methodVisitor.VisitInsn(opcodes.LRETURN)
+				methodVisitor.VisitMaxs(0, 0)
+			})
+		}}
+	}
+	if _, err := commons.TransformJar(in, out, factory, commons.TransformJarOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := commons.TransformJar(in, out, factory, commons.TransformJarOptions{Verify: true})
+	if err == nil || !strings.HasPrefix(err.Error(), "p/A.class: p/A.m()J") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package commons
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// yamlLine a non empty line of a YAML document, without its comment.
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// parseYAML parses the given YAML document into maps, slices, strings, booleans and nils, which can be encoded
+// in JSON. Only the subset of YAML used by configuration files is supported: block mappings and sequences, plain
+// and quoted scalars, comments, and JSON compatible flow collections (a document in the flow style is parsed as
+// JSON). Anchors, tags, multi line scalars and multiple documents are not supported.
+func parseYAML(document []byte) (interface{}, error) {
+	var lines []yamlLine
+	for i, line := range strings.Split(string(document), "\n") {
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || (len(lines) == 0 && text == "---") {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, yamlError(i+1, "tabs are not allowed in indentation")
+		}
+		lines = append(lines, yamlLine{i + 1, len(line) - len(text), text})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	if text := lines[0].text; text[0] == '{' || text[0] == '[' {
+		var value interface{}
+		err := json.Unmarshal(document, &value)
+		return value, err
+	}
+	parser := &yamlParser{lines: lines}
+	value, err := parser.parseNode(lines[0].indent)
+	if err == nil && parser.next < len(lines) {
+		err = yamlError(lines[parser.next].number, "unexpected indentation")
+	}
+	return value, err
+}
+
+func yamlError(line int, message string) error {
+	return errors.New("Illegal Argument - YAML line " + strconv.Itoa(line) + ": " + message)
+}
+
+// stripYAMLComment returns the given line without its comment, if any: a '#' at the start of the line or after a
+// space, outside of a quoted scalar (which starts with a quote at the start of the line or after a space).
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,", line[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// yamlParser a recursive descent parser of the block structure of a YAML document.
+type yamlParser struct {
+	lines []yamlLine
+	next  int
+}
+
+// parseNode parses the block sequence or mapping starting at the next line, whose indentation is indent.
+func (y *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(y.lines[y.next].text) {
+		return y.parseSequence(indent)
+	}
+	return y.parseMapping(indent)
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (y *yamlParser) parseSequence(indent int) (interface{}, error) {
+	sequence := []interface{}{}
+	for y.next < len(y.lines) && y.lines[y.next].indent == indent && isYAMLSequenceItem(y.lines[y.next].text) {
+		line := y.lines[y.next]
+		item := strings.TrimLeft(line.text[1:], " ")
+		if item == "" {
+			y.next++
+			value, err := y.parseChild(line)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+			continue
+		}
+		if _, _, ok := splitYAMLKey(item); !ok {
+			value, err := parseYAMLScalar(line.number, item)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+			y.next++
+			continue
+		}
+		// The item is a mapping whose first key is on the line of the '-' indicator: this line is parsed as if the
+		// key was on its own line, indented like the following keys.
+		y.lines[y.next] = yamlLine{line.number, line.indent + len(line.text) - len(item), item}
+		value, err := y.parseMapping(y.lines[y.next].indent)
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, value)
+	}
+	return sequence, nil
+}
+
+func (y *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := map[string]interface{}{}
+	for y.next < len(y.lines) && y.lines[y.next].indent == indent {
+		line := y.lines[y.next]
+		key, value, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, yamlError(line.number, "expected a key")
+		}
+		key, err := parseYAMLKey(line.number, key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := mapping[key]; ok {
+			return nil, yamlError(line.number, "duplicate key "+key)
+		}
+		y.next++
+		if value == "" {
+			mapping[key], err = y.parseChild(line)
+		} else {
+			mapping[key], err = parseYAMLScalar(line.number, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return mapping, nil
+}
+
+// parseChild parses the value of the given key or sequence item line whose value is on the next lines: a node
+// indented more than the line or, for a key, a sequence with the same indentation. Returns nil if there is none.
+func (y *yamlParser) parseChild(line yamlLine) (interface{}, error) {
+	if y.next == len(y.lines) {
+		return nil, nil
+	}
+	next := y.lines[y.next]
+	if next.indent > line.indent ||
+		(next.indent == line.indent && !isYAMLSequenceItem(line.text) && isYAMLSequenceItem(next.text)) {
+		return y.parseNode(next.indent)
+	}
+	return nil, nil
+}
+
+// splitYAMLKey splits the given "key: value" text, where value may be empty. The key may be quoted.
+func splitYAMLKey(text string) (string, string, bool) {
+	start := 0
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		start = end + 2
+	}
+	for i := start; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+func parseYAMLKey(line int, key string) (string, error) {
+	value, err := parseYAMLScalar(line, key)
+	if s, ok := value.(string); ok && err == nil {
+		return s, nil
+	}
+	return key, err
+}
+
+// parseYAMLScalar parses a scalar, or a JSON compatible flow collection.
+func parseYAMLScalar(line int, text string) (interface{}, error) {
+	switch text[0] {
+	case '"':
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, yamlError(line, "invalid double quoted scalar "+text)
+		}
+		return value, nil
+	case '\'':
+		if len(text) < 2 || text[len(text)-1] != '\'' {
+			return nil, yamlError(line, "invalid single quoted scalar "+text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case '[', '{':
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, yamlError(line, "unsupported flow collection "+text)
+		}
+		return value, nil
+	case '&', '*', '!', '|', '>':
+		return nil, yamlError(line, "unsupported scalar "+text)
+	}
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	return text, nil
+}
@@ -0,0 +1,552 @@
+package asm
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm/constants"
+	"github.com/leaklessgfy/asm/asm/frame"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// SortConstantPool returns a copy of the given class file whose constant pool entries are sorted in an order
+// which only depends on their values, so that two classes which only differ by the order in which their
+// constants have been added (e.g. by the order in which they have been visited by concurrent transformations)
+// get identical bytes. The entries loaded by LDC instructions come first, so that their indices still fit in
+// one byte, and each group is sorted by tag and then by value. The duplicate entries are merged. The references
+// to the constant pool are remapped accordingly, without changing the layout of the rest of the class. Returns
+// an error if the class has a non standard attribute, whose constant pool references are unknown.
+func SortConstantPool(classBytes []byte) (_ []byte, err error) {
+	reader, err := NewClassReader(classBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer RecoverParseError(&err)
+	sorter := &constantPoolSorter{
+		reader:     reader,
+		charBuffer: make([]rune, reader.maxStringLength),
+		keys:       make(map[int]string),
+		ldcKeys:    make(map[string]bool),
+	}
+	sorter.collectReferences()
+	if sorter.err != nil {
+		return nil, sorter.err
+	}
+
+	// Sorts the distinct entries, and computes their new indices.
+	entries := make(map[string]int)
+	var keys []string
+	for i := 1; i < len(reader.cpInfoOffsets); i++ {
+		if reader.cpInfoOffsets[i] == 0 {
+			continue
+		}
+		if key := sorter.key(i); entries[key] == 0 {
+			entries[key] = i
+			keys = append(keys, key)
+		}
+	}
+	if sorter.err != nil {
+		return nil, sorter.err
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if sorter.ldcKeys[keys[i]] != sorter.ldcKeys[keys[j]] {
+			return sorter.ldcKeys[keys[i]]
+		}
+		return keys[i] < keys[j]
+	})
+	newIndices := make(map[string]int, len(keys))
+	constantPoolCount := 1
+	for _, key := range keys {
+		newIndices[key] = constantPoolCount
+		if tag := int(key[0]); tag == symbol.CONSTANT_LONG_TAG || tag == symbol.CONSTANT_DOUBLE_TAG {
+			constantPoolCount += 2
+		} else {
+			constantPoolCount++
+		}
+	}
+	newIndex := func(constantPoolEntryIndex int) int { return newIndices[sorter.key(constantPoolEntryIndex)] }
+
+	output := NewByteVectorWithCapacity(len(classBytes))
+	output.PutByteArray(classBytes, 0, 8)
+	output.PutShort(constantPoolCount)
+	for _, key := range keys {
+		cpInfoOffset := reader.cpInfoOffsets[entries[key]]
+		switch tag := int(key[0]); tag {
+		case symbol.CONSTANT_CLASS_TAG, symbol.CONSTANT_STRING_TAG, symbol.CONSTANT_METHOD_TYPE_TAG,
+			symbol.CONSTANT_MODULE_TAG, symbol.CONSTANT_PACKAGE_TAG:
+			output.PutByte(tag).PutShort(newIndex(reader.readUnsignedShort(cpInfoOffset)))
+		case symbol.CONSTANT_FIELDREF_TAG, symbol.CONSTANT_METHODREF_TAG, symbol.CONSTANT_INTERFACE_METHODREF_TAG,
+			symbol.CONSTANT_NAME_AND_TYPE_TAG:
+			output.PutByte(tag).PutShort(newIndex(reader.readUnsignedShort(cpInfoOffset)))
+			output.PutShort(newIndex(reader.readUnsignedShort(cpInfoOffset + 2)))
+		case symbol.CONSTANT_METHOD_HANDLE_TAG:
+			output.PutByte(tag).PutByte(int(reader.readByte(cpInfoOffset)))
+			output.PutShort(newIndex(reader.readUnsignedShort(cpInfoOffset + 1)))
+		case symbol.CONSTANT_INVOKE_DYNAMIC_TAG:
+			output.PutByte(tag).PutShort(reader.readUnsignedShort(cpInfoOffset))
+			output.PutShort(newIndex(reader.readUnsignedShort(cpInfoOffset + 2)))
+		default:
+			size, _, _ := raw.EntrySize(classBytes, cpInfoOffset-1)
+			output.PutByteArray(classBytes, cpInfoOffset-1, size)
+		}
+	}
+	shift := output.Size() - reader.header
+	output.PutByteArray(classBytes, reader.header, len(classBytes)-reader.header)
+	result := output.Bytes()
+	for _, reference := range sorter.references {
+		constantPoolEntryIndex := newIndex(reference.constantPoolEntryIndex)
+		offset := reference.offset + shift
+		if reference.ldc {
+			if constantPoolEntryIndex > 0xFF {
+				return nil, errors.New("Illegal Argument - too many constants loaded by LDC instructions")
+			}
+			result[offset] = byte(constantPoolEntryIndex)
+		} else {
+			result[offset] = byte(constantPoolEntryIndex >> 8)
+			result[offset+1] = byte(constantPoolEntryIndex)
+		}
+	}
+	return result, nil
+}
+
+// constantPoolReference a reference to a constant pool entry, outside of the constant pool.
+type constantPoolReference struct {
+	// offset the offset of the reference in the class file.
+	offset                 int
+	constantPoolEntryIndex int
+	// ldc whether the reference is the u1 operand of an LDC instruction (otherwise it is a u2 value).
+	ldc bool
+}
+
+// constantPoolSorter collects the constant pool references of a class, and computes the sort keys of its
+// constant pool entries, for {@link SortConstantPool}.
+type constantPoolSorter struct {
+	reader     *ClassReader
+	charBuffer []rune
+	references []constantPoolReference
+	// keys the sort keys of the constant pool entries, indexed by constant pool index.
+	keys map[int]string
+	// ldcKeys the sort keys of the entries loaded by LDC instructions.
+	ldcKeys map[string]bool
+	// err the first error found in the class.
+	err error
+}
+
+// setError records the given error, if it is the first one.
+func (c *constantPoolSorter) setError(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *constantPoolSorter) readU1(offset int) int {
+	value, err := raw.ReadU1(c.reader.b, offset)
+	c.setError(err)
+	return value
+}
+
+func (c *constantPoolSorter) readU2(offset int) int {
+	value, err := raw.ReadU2(c.reader.b, offset)
+	c.setError(err)
+	return value
+}
+
+// entry returns the constant pool index stored in the u2 value at the given offset, or 0 if it is not the index
+// of an entry with one of the given tags (or of any entry, if no tag is given).
+func (c *constantPoolSorter) entry(offset int, tags ...int) int {
+	constantPoolEntryIndex := c.readU2(offset)
+	tag := c.reader.GetItemTag(constantPoolEntryIndex)
+	if tag != 0 {
+		for _, expectedTag := range tags {
+			if tag == expectedTag {
+				return constantPoolEntryIndex
+			}
+		}
+		if len(tags) == 0 {
+			return constantPoolEntryIndex
+		}
+	}
+	c.setError(raw.NewParseError(ErrMalformedConstantPool, offset, "invalid constant pool index "+
+		strconv.Itoa(constantPoolEntryIndex)+" at offset "+strconv.Itoa(offset)))
+	return 0
+}
+
+// reference records the constant pool reference stored in the u2 value at the given offset.
+func (c *constantPoolSorter) reference(offset int) {
+	if constantPoolEntryIndex := c.entry(offset); constantPoolEntryIndex != 0 {
+		c.references = append(c.references, constantPoolReference{offset, constantPoolEntryIndex, false})
+	}
+}
+
+// optionalReference records the constant pool reference stored in the u2 value at the given offset, if it is
+// not 0.
+func (c *constantPoolSorter) optionalReference(offset int) {
+	if c.readU2(offset) != 0 {
+		c.reference(offset)
+	}
+}
+
+// referenceList records the constant pool references whose u2 count is stored at the given offset, and returns
+// the offset following them.
+func (c *constantPoolSorter) referenceList(offset int) int {
+	currentOffset := offset + 2
+	for i := c.readU2(offset); i > 0; i-- {
+		c.reference(currentOffset)
+		currentOffset += 2
+	}
+	return currentOffset
+}
+
+// key returns the sort key of the given constant pool entry: its tag followed by its value, in which the
+// referenced entries are replaced with their own keys.
+func (c *constantPoolSorter) key(constantPoolEntryIndex int) string {
+	if key, ok := c.keys[constantPoolEntryIndex]; ok || constantPoolEntryIndex == 0 {
+		return key
+	}
+	cpInfoOffset := c.reader.cpInfoOffsets[constantPoolEntryIndex]
+	tag := c.reader.GetItemTag(constantPoolEntryIndex)
+	var value string
+	switch tag {
+	case symbol.CONSTANT_UTF8_TAG:
+		value = c.reader.readUTF(constantPoolEntryIndex, c.charBuffer)
+	case symbol.CONSTANT_INTEGER_TAG, symbol.CONSTANT_FLOAT_TAG:
+		value = string(c.reader.b[cpInfoOffset : cpInfoOffset+4])
+	case symbol.CONSTANT_LONG_TAG, symbol.CONSTANT_DOUBLE_TAG:
+		value = string(c.reader.b[cpInfoOffset : cpInfoOffset+8])
+	case symbol.CONSTANT_CLASS_TAG, symbol.CONSTANT_STRING_TAG, symbol.CONSTANT_METHOD_TYPE_TAG,
+		symbol.CONSTANT_MODULE_TAG, symbol.CONSTANT_PACKAGE_TAG:
+		value = c.key(c.entry(cpInfoOffset, symbol.CONSTANT_UTF8_TAG))
+	case symbol.CONSTANT_FIELDREF_TAG, symbol.CONSTANT_METHODREF_TAG, symbol.CONSTANT_INTERFACE_METHODREF_TAG:
+		value = sortKey(c.key(c.entry(cpInfoOffset, symbol.CONSTANT_CLASS_TAG)),
+			c.key(c.entry(cpInfoOffset+2, symbol.CONSTANT_NAME_AND_TYPE_TAG)))
+	case symbol.CONSTANT_NAME_AND_TYPE_TAG:
+		value = sortKey(c.key(c.entry(cpInfoOffset, symbol.CONSTANT_UTF8_TAG)),
+			c.key(c.entry(cpInfoOffset+2, symbol.CONSTANT_UTF8_TAG)))
+	case symbol.CONSTANT_METHOD_HANDLE_TAG:
+		value = sortKey(string(c.reader.b[cpInfoOffset:cpInfoOffset+1]), c.key(c.entry(cpInfoOffset+1,
+			symbol.CONSTANT_FIELDREF_TAG, symbol.CONSTANT_METHODREF_TAG, symbol.CONSTANT_INTERFACE_METHODREF_TAG)))
+	case symbol.CONSTANT_INVOKE_DYNAMIC_TAG:
+		// The bootstrap method index is not a constant pool reference, and is kept unchanged.
+		value = sortKey(string(c.reader.b[cpInfoOffset:cpInfoOffset+2]),
+			c.key(c.entry(cpInfoOffset+2, symbol.CONSTANT_NAME_AND_TYPE_TAG)))
+	}
+	key := string(rune(tag)) + value
+	c.keys[constantPoolEntryIndex] = key
+	return key
+}
+
+// sortKey returns a key made of the given parts, each one prefixed with its length so that the keys of different
+// parts can't be equal.
+func sortKey(parts ...string) string {
+	key := ""
+	for _, part := range parts {
+		key += strconv.Itoa(len(part)) + ":" + part
+	}
+	return key
+}
+
+// collectReferences records all the constant pool references of the class, outside of the constant pool.
+func (c *constantPoolSorter) collectReferences() {
+	header := c.reader.header
+	c.reference(header + 2)
+	c.optionalReference(header + 4)
+	c.referenceList(header + 6)
+	index := c.reader.Index()
+	for _, members := range [][]MemberIndex{index.Fields, index.Methods} {
+		for _, member := range members {
+			c.reference(member.Start + 2)
+			c.reference(member.Start + 4)
+			c.collectAttributes(member.Attributes)
+		}
+	}
+	c.collectAttributes(index.Attributes)
+}
+
+// collectAttributes records the constant pool references of the given attributes.
+func (c *constantPoolSorter) collectAttributes(attributes []AttributeRange) {
+	for _, attribute := range attributes {
+		c.reference(attribute.Start)
+		offset := attribute.Start + 6
+		switch attribute.Name {
+		case "ConstantValue", "Signature", "SourceFile", "ModuleMainClass", "NestHost":
+			c.reference(offset)
+		case "Exceptions", "ModulePackages", "NestMembers", "PermittedSubclasses":
+			c.referenceList(offset)
+		case "InnerClasses":
+			// Each inner class record has an inner_class_info_index, an outer_class_info_index, an
+			// inner_name_index and an inner_class_access_flags field.
+			for i := c.readU2(offset); i > 0; i-- {
+				offset += 8
+				c.reference(offset - 6)
+				c.optionalReference(offset - 4)
+				c.optionalReference(offset - 2)
+			}
+		case "EnclosingMethod":
+			c.reference(offset)
+			c.optionalReference(offset + 2)
+		case "Code":
+			c.collectCode(offset)
+		case "LocalVariableTable", "LocalVariableTypeTable":
+			// Each entry has a start_pc, a length, a name_index, a descriptor_index (or signature_index) and an
+			// index field.
+			for i := c.readU2(offset); i > 0; i-- {
+				offset += 10
+				c.reference(offset - 4)
+				c.reference(offset - 2)
+			}
+		case "StackMapTable":
+			c.collectStackMapTable(offset)
+		case "StackMap":
+			// Each frame has an offset, and the verification types of its locals and of its stack.
+			currentOffset := offset + 2
+			for i := c.readU2(offset); i > 0; i-- {
+				currentOffset = c.collectVerificationTypes(c.collectVerificationTypes(currentOffset + 2))
+			}
+		case "RuntimeVisibleAnnotations", "RuntimeInvisibleAnnotations":
+			c.collectAnnotations(offset)
+		case "RuntimeVisibleParameterAnnotations", "RuntimeInvisibleParameterAnnotations":
+			offset++
+			for i := c.readU1(offset - 1); i > 0; i-- {
+				offset = c.collectAnnotations(offset)
+			}
+		case "RuntimeVisibleTypeAnnotations", "RuntimeInvisibleTypeAnnotations":
+			c.collectTypeAnnotations(offset)
+		case "AnnotationDefault":
+			c.collectElementValue(offset)
+		case "BootstrapMethods":
+			// Each bootstrap method has a bootstrap_method_ref and a list of bootstrap arguments.
+			offset += 2
+			for i := c.readU2(offset - 2); i > 0; i-- {
+				c.reference(offset)
+				offset = c.referenceList(offset + 2)
+			}
+		case "MethodParameters":
+			// Each parameter has an optional name_index and an access_flags field.
+			offset++
+			for i := c.readU1(offset - 1); i > 0; i-- {
+				c.optionalReference(offset)
+				offset += 4
+			}
+		case "Module":
+			c.collectModule(offset)
+		case "Record":
+			// Each record component has a name_index, a descriptor_index and attributes.
+			offset += 2
+			for i := c.readU2(offset - 2); i > 0 && c.err == nil; i-- {
+				c.reference(offset)
+				c.reference(offset + 2)
+				var componentAttributes []AttributeRange
+				componentAttributes, offset = c.reader.indexAttributes(offset+4, c.charBuffer)
+				c.collectAttributes(componentAttributes)
+			}
+		case "Synthetic", "Deprecated", "SourceDebugExtension", "LineNumberTable", "io.asm.go.Integrity":
+			// The content of the integrity attribute of commons.EmbedIntegrity is self contained.
+		default:
+			c.setError(errors.New("Illegal Argument - the constant pool references of the " + attribute.Name +
+				" attribute are unknown"))
+		}
+	}
+}
+
+// collectCode records the constant pool references of the Code attribute whose content starts at the given
+// offset.
+func (c *constantPoolSorter) collectCode(offset int) {
+	codeLength, err := raw.ReadU4(c.reader.b, offset+4)
+	codeStart := offset + 8
+	if err == nil && codeStart+int(codeLength) > len(c.reader.b) {
+		err = raw.NewParseError(ErrTruncated, codeStart, "truncated code at offset "+strconv.Itoa(codeStart))
+	}
+	if err != nil {
+		c.setError(err)
+		return
+	}
+	code := c.reader.b[codeStart : codeStart+int(codeLength)]
+	for currentOffset := 0; currentOffset < len(code) && c.err == nil; {
+		switch opcode := int(code[currentOffset]); {
+		case opcode == opcodes.LDC:
+			operandOffset := codeStart + currentOffset + 1
+			if constantPoolEntryIndex := c.readU1(operandOffset); c.reader.GetItemTag(constantPoolEntryIndex) != 0 {
+				c.references = append(c.references, constantPoolReference{operandOffset, constantPoolEntryIndex, true})
+				c.ldcKeys[c.key(constantPoolEntryIndex)] = true
+			} else {
+				c.setError(raw.NewParseError(ErrMalformedConstantPool, operandOffset, "invalid constant pool index "+
+					strconv.Itoa(constantPoolEntryIndex)+" at offset "+strconv.Itoa(operandOffset)))
+			}
+		case opcode == constants.LDC_W || opcode == constants.LDC2_W, opcode == opcodes.NEW,
+			opcode >= opcodes.GETSTATIC && opcode <= opcodes.INVOKEDYNAMIC, opcode == opcodes.ANEWARRAY,
+			opcode == opcodes.CHECKCAST || opcode == opcodes.INSTANCEOF || opcode == opcodes.MULTIANEWARRAY:
+			c.reference(codeStart + currentOffset + 1)
+		}
+		size, err := raw.InstructionSize(code, currentOffset)
+		if err != nil {
+			c.setError(err)
+			return
+		}
+		currentOffset += size
+	}
+
+	// Each exception table entry has a start_pc, an end_pc, a handler_pc and an optional catch_type.
+	currentOffset := codeStart + len(code) + 2
+	for i := c.readU2(currentOffset - 2); i > 0; i-- {
+		c.optionalReference(currentOffset + 6)
+		currentOffset += 8
+	}
+	if c.err == nil {
+		attributes, _ := c.reader.indexAttributes(currentOffset, c.charBuffer)
+		c.collectAttributes(attributes)
+	}
+}
+
+// collectStackMapTable records the constant pool references of the StackMapTable attribute whose content starts
+// at the given offset.
+func (c *constantPoolSorter) collectStackMapTable(offset int) {
+	currentOffset := offset + 2
+	for i := c.readU2(offset); i > 0 && c.err == nil; i-- {
+		frameType := c.readU1(currentOffset)
+		currentOffset++
+		switch {
+		case frameType < 64:
+		case frameType < 128:
+			currentOffset = c.collectVerificationType(currentOffset)
+		case frameType == 247:
+			currentOffset = c.collectVerificationType(currentOffset + 2)
+		case frameType >= 248 && frameType <= 251:
+			currentOffset += 2
+		case frameType >= 252 && frameType <= 254:
+			currentOffset += 2
+			for j := frameType - 251; j > 0; j-- {
+				currentOffset = c.collectVerificationType(currentOffset)
+			}
+		case frameType == 255:
+			currentOffset = c.collectVerificationTypes(c.collectVerificationTypes(currentOffset + 2))
+		default:
+			c.setError(raw.NewParseError(ErrMalformedStackMap, currentOffset-1, "unknown frame type "+
+				strconv.Itoa(frameType)+" at offset "+strconv.Itoa(currentOffset-1)))
+		}
+	}
+}
+
+// collectVerificationTypes records the constant pool references of the verification types whose u2 count is
+// stored at the given offset, and returns the offset following them.
+func (c *constantPoolSorter) collectVerificationTypes(offset int) int {
+	currentOffset := offset + 2
+	for i := c.readU2(offset); i > 0 && c.err == nil; i-- {
+		currentOffset = c.collectVerificationType(currentOffset)
+	}
+	return currentOffset
+}
+
+// collectVerificationType records the constant pool reference of the verification type starting at the given
+// offset, if any, and returns the offset following it.
+func (c *constantPoolSorter) collectVerificationType(offset int) int {
+	verificationType, nextOffset, err := raw.DecodeVerificationType(c.reader.b, offset)
+	c.setError(err)
+	if err == nil && verificationType.Tag == frame.ITEM_OBJECT {
+		c.reference(offset + 1)
+	}
+	return nextOffset
+}
+
+// collectAnnotations records the constant pool references of the annotations whose num_annotations field starts
+// at the given offset, and returns the offset following them.
+func (c *constantPoolSorter) collectAnnotations(offset int) int {
+	currentOffset := offset + 2
+	for i := c.readU2(offset); i > 0 && c.err == nil; i-- {
+		currentOffset = c.collectAnnotation(currentOffset)
+	}
+	return currentOffset
+}
+
+// collectTypeAnnotations records the constant pool references of the type annotations whose num_annotations
+// field starts at the given offset.
+func (c *constantPoolSorter) collectTypeAnnotations(offset int) {
+	currentOffset := offset + 2
+	for i := c.readU2(offset); i > 0 && c.err == nil; i-- {
+		// Skips the target_info and the type_path.
+		switch targetType := c.readU1(currentOffset); {
+		case targetType == 0x40 || targetType == 0x41:
+			currentOffset += 3 + c.readU2(currentOffset+1)*6
+		case targetType == 0x13 || targetType == 0x14 || targetType == 0x15:
+			currentOffset++
+		case targetType == 0x00 || targetType == 0x01 || targetType == 0x16:
+			currentOffset += 2
+		case targetType >= 0x47 && targetType <= 0x4B:
+			currentOffset += 4
+		default:
+			currentOffset += 3
+		}
+		currentOffset += 1 + c.readU1(currentOffset)*2
+		currentOffset = c.collectAnnotation(currentOffset)
+	}
+}
+
+// collectAnnotation records the constant pool references of the annotation starting at the given offset, and
+// returns the offset following it.
+func (c *constantPoolSorter) collectAnnotation(offset int) int {
+	c.reference(offset)
+	currentOffset := offset + 4
+	for i := c.readU2(offset + 2); i > 0 && c.err == nil; i-- {
+		c.reference(currentOffset)
+		currentOffset = c.collectElementValue(currentOffset + 2)
+	}
+	return currentOffset
+}
+
+// collectElementValue records the constant pool references of the element_value starting at the given offset,
+// and returns the offset following it.
+func (c *constantPoolSorter) collectElementValue(offset int) int {
+	switch tag := c.readU1(offset); tag {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z', 's', 'c':
+		c.reference(offset + 1)
+		return offset + 3
+	case 'e':
+		c.reference(offset + 1)
+		c.reference(offset + 3)
+		return offset + 5
+	case '@':
+		return c.collectAnnotation(offset + 1)
+	case '[':
+		currentOffset := offset + 3
+		for i := c.readU2(offset + 1); i > 0 && c.err == nil; i-- {
+			currentOffset = c.collectElementValue(currentOffset)
+		}
+		return currentOffset
+	default:
+		c.setError(raw.NewParseError(ErrMalformedAttribute, offset, "unknown element value tag "+
+			strconv.Itoa(tag)+" at offset "+strconv.Itoa(offset)))
+		return offset
+	}
+}
+
+// collectModule records the constant pool references of the Module attribute whose content starts at the given
+// offset.
+func (c *constantPoolSorter) collectModule(offset int) {
+	// The module_name_index, module_flags and optional module_version_index fields.
+	c.reference(offset)
+	c.optionalReference(offset + 4)
+	// Each requires entry has a requires_index, a requires_flags and an optional requires_version_index.
+	currentOffset := offset + 8
+	for i := c.readU2(offset + 6); i > 0; i-- {
+		c.reference(currentOffset)
+		c.optionalReference(currentOffset + 4)
+		currentOffset += 6
+	}
+	// Each exports (or opens) entry has a package index, flags and a list of module indices.
+	for j := 0; j < 2; j++ {
+		currentOffset += 2
+		for i := c.readU2(currentOffset - 2); i > 0 && c.err == nil; i-- {
+			c.reference(currentOffset)
+			currentOffset = c.referenceList(currentOffset + 4)
+		}
+	}
+	currentOffset = c.referenceList(currentOffset)
+	// Each provides entry has a class index and a list of implementation class indices.
+	currentOffset += 2
+	for i := c.readU2(currentOffset - 2); i > 0 && c.err == nil; i-- {
+		c.reference(currentOffset)
+		currentOffset = c.referenceList(currentOffset + 2)
+	}
+}
@@ -0,0 +1,66 @@
+package asm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// constantsClass returns a class A with a static method m()V loading some constants, whose constant pool starts
+// with the given constants, after the names of the class and of its super class.
+func constantsClass(constants ...interface{}) []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	for _, constant := range constants {
+		if _, err := classFile.SymbolTable.AddConstant(constant); err != nil {
+			panic(err)
+		}
+	}
+	writer := classFile.AddMethod(opcodes.ACC_STATIC, "m", "()V", "", nil)
+	writer.VisitCode()
+	writer.VisitLdcInsn("x")
+	writer.VisitInsn(opcodes.POP)
+	writer.VisitLdcInsn(int64(7))
+	writer.VisitInsn(opcodes.POP2)
+	writer.VisitFieldInsn(opcodes.GETSTATIC, "java/lang/System", "out", "Ljava/io/PrintStream;")
+	writer.VisitLdcInsn(int32(123456))
+	writer.VisitMethodInsn(opcodes.INVOKEVIRTUAL, "java/io/PrintStream", "println", "(I)V")
+	writer.VisitInsn(opcodes.RETURN)
+	writer.VisitMaxs(2, 0)
+	writer.VisitEnd()
+	return classFile.Bytes()
+}
+
+func TestSortConstantPool(t *testing.T) {
+	class1 := constantsClass()
+	class2 := constantsClass(int32(123456), int64(7), "x")
+	if bytes.Equal(class1, class2) {
+		t.Fatal("the constant pools of the test classes should have a different order")
+	}
+	sorted1, err := asm.SortConstantPool(class1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted2, err := asm.SortConstantPool(class2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sorted1, sorted2) {
+		t.Errorf("different sorted classes:\n%x\n%x", sorted1, sorted2)
+	}
+	if _, err := analysis.VerifyOutput(sorted1); err != nil {
+		t.Error(err)
+	}
+	if sortedAgain, err := asm.SortConstantPool(sorted1); err != nil || !bytes.Equal(sortedAgain, sorted1) {
+		t.Errorf("sorting a sorted class changed it: %v", err)
+	}
+}
+
+func TestSortConstantPoolUnknownAttribute(t *testing.T) {
+	if _, err := asm.SortConstantPool(customAttributeClass()); err == nil {
+		t.Error("sorted a class with a non standard attribute")
+	}
+}
@@ -0,0 +1,124 @@
+package asm
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// FieldRef a CONSTANT_Fieldref_info entry of the constant pool, resolved by {@link ClassReader#ResolveFieldRef}.
+type FieldRef struct {
+	Owner      string
+	Name       string
+	Descriptor string
+}
+
+// MethodRef a CONSTANT_Methodref_info or CONSTANT_InterfaceMethodref_info entry of the constant pool, resolved by
+// {@link ClassReader#ResolveMethodRef}.
+type MethodRef struct {
+	Owner      string
+	Name       string
+	Descriptor string
+	// IsInterface whether the entry is a CONSTANT_InterfaceMethodref_info.
+	IsInterface bool
+}
+
+// GetItemTag returns the tag of the given constant pool entry (one of the CONSTANT_*_TAG values of the symbol
+// package), or 0 if the index is not the index of a usable entry (e.g. 0, or the index following a Long or Double
+// constant).
+func (c ClassReader) GetItemTag(constantPoolEntryIndex int) int {
+	if constantPoolEntryIndex <= 0 || constantPoolEntryIndex >= len(c.cpInfoOffsets) || c.cpInfoOffsets[constantPoolEntryIndex] == 0 {
+		return 0
+	}
+	return int(c.b[c.cpInfoOffsets[constantPoolEntryIndex]-1])
+}
+
+// item returns the offset of the content of the given constant pool entry, or an error if it is not an entry with
+// one of the given tags.
+func (c ClassReader) item(constantPoolEntryIndex int, tags ...int) (int, error) {
+	tag := c.GetItemTag(constantPoolEntryIndex)
+	if tag == 0 {
+		return 0, errors.New("Illegal Argument - invalid constant pool index " + strconv.Itoa(constantPoolEntryIndex))
+	}
+	for _, expectedTag := range tags {
+		if tag == expectedTag {
+			return c.cpInfoOffsets[constantPoolEntryIndex], nil
+		}
+	}
+	return 0, errors.New("Illegal Argument - unexpected tag " + strconv.Itoa(tag) + " of constant pool entry " +
+		strconv.Itoa(constantPoolEntryIndex))
+}
+
+// ResolveUtf8 returns the value of the given CONSTANT_Utf8_info entry.
+func (c ClassReader) ResolveUtf8(constantPoolEntryIndex int) (string, error) {
+	if _, err := c.item(constantPoolEntryIndex, symbol.CONSTANT_UTF8_TAG); err != nil {
+		return "", err
+	}
+	return c.readUTF(constantPoolEntryIndex, make([]rune, c.maxStringLength)), nil
+}
+
+// ResolveClass returns the internal name of the given CONSTANT_Class_info entry (or the name of the given
+// CONSTANT_Module_info or CONSTANT_Package_info entry).
+func (c ClassReader) ResolveClass(constantPoolEntryIndex int) (string, error) {
+	offset, err := c.item(constantPoolEntryIndex, symbol.CONSTANT_CLASS_TAG, symbol.CONSTANT_MODULE_TAG, symbol.CONSTANT_PACKAGE_TAG)
+	if err != nil {
+		return "", err
+	}
+	return c.ResolveUtf8(c.readUnsignedShort(offset))
+}
+
+// ResolveNameAndType returns the name and the descriptor of the given CONSTANT_NameAndType_info entry.
+func (c ClassReader) ResolveNameAndType(constantPoolEntryIndex int) (string, string, error) {
+	offset, err := c.item(constantPoolEntryIndex, symbol.CONSTANT_NAME_AND_TYPE_TAG)
+	if err != nil {
+		return "", "", err
+	}
+	name, err := c.ResolveUtf8(c.readUnsignedShort(offset))
+	if err != nil {
+		return "", "", err
+	}
+	descriptor, err := c.ResolveUtf8(c.readUnsignedShort(offset + 2))
+	return name, descriptor, err
+}
+
+// resolveMemberRef returns the owner, the name and the descriptor of the given member reference entry, which must
+// have one of the given tags.
+func (c ClassReader) resolveMemberRef(constantPoolEntryIndex int, tags ...int) (string, string, string, error) {
+	offset, err := c.item(constantPoolEntryIndex, tags...)
+	if err != nil {
+		return "", "", "", err
+	}
+	owner, err := c.ResolveClass(c.readUnsignedShort(offset))
+	if err != nil {
+		return "", "", "", err
+	}
+	name, descriptor, err := c.ResolveNameAndType(c.readUnsignedShort(offset + 2))
+	return owner, name, descriptor, err
+}
+
+// ResolveFieldRef returns the field referenced by the given CONSTANT_Fieldref_info entry.
+func (c ClassReader) ResolveFieldRef(constantPoolEntryIndex int) (FieldRef, error) {
+	owner, name, descriptor, err := c.resolveMemberRef(constantPoolEntryIndex, symbol.CONSTANT_FIELDREF_TAG)
+	return FieldRef{Owner: owner, Name: name, Descriptor: descriptor}, err
+}
+
+// ResolveMethodRef returns the method referenced by the given CONSTANT_Methodref_info or
+// CONSTANT_InterfaceMethodref_info entry.
+func (c ClassReader) ResolveMethodRef(constantPoolEntryIndex int) (MethodRef, error) {
+	owner, name, descriptor, err := c.resolveMemberRef(constantPoolEntryIndex, symbol.CONSTANT_METHODREF_TAG,
+		symbol.CONSTANT_INTERFACE_METHODREF_TAG)
+	isInterface := c.GetItemTag(constantPoolEntryIndex) == symbol.CONSTANT_INTERFACE_METHODREF_TAG
+	return MethodRef{Owner: owner, Name: name, Descriptor: descriptor, IsInterface: isInterface}, err
+}
+
+// ResolveConstant returns the value of the given loadable constant pool entry, as an int, float32, int64, float64,
+// string, {@link Type} or {@link Handle} (see {@link MethodVisitor#VisitLdcInsn}).
+func (c ClassReader) ResolveConstant(constantPoolEntryIndex int) (interface{}, error) {
+	if _, err := c.item(constantPoolEntryIndex, symbol.CONSTANT_INTEGER_TAG, symbol.CONSTANT_FLOAT_TAG,
+		symbol.CONSTANT_LONG_TAG, symbol.CONSTANT_DOUBLE_TAG, symbol.CONSTANT_CLASS_TAG, symbol.CONSTANT_STRING_TAG,
+		symbol.CONSTANT_METHOD_TYPE_TAG, symbol.CONSTANT_METHOD_HANDLE_TAG); err != nil {
+		return nil, err
+	}
+	return c.readConst(constantPoolEntryIndex, make([]rune, c.maxStringLength))
+}
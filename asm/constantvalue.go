@@ -0,0 +1,108 @@
+package asm
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// ConstantValue the value of a ConstantValue field attribute, as given to {@link ClassVisitor#VisitField}: an
+// int (for int, short, char, byte and boolean fields), a float32, an int64, a float64 or a string.
+type ConstantValue struct {
+	value interface{}
+}
+
+// NewConstantValue constructs a new {@link ConstantValue}, or returns an error if the given value is not a valid
+// field constant value.
+func NewConstantValue(value interface{}) (*ConstantValue, error) {
+	switch value.(type) {
+	case int, float32, int64, float64, string:
+		return &ConstantValue{value}, nil
+	}
+	return nil, errors.New("Illegal Argument - invalid constant value")
+}
+
+// GetSort returns the sort of this value: {@link typed.INT}, {@link typed.FLOAT}, {@link typed.LONG},
+// {@link typed.DOUBLE}, or {@link typed.OBJECT} for a string.
+func (c ConstantValue) GetSort() int {
+	switch c.value.(type) {
+	case int:
+		return typed.INT
+	case float32:
+		return typed.FLOAT
+	case int64:
+		return typed.LONG
+	case float64:
+		return typed.DOUBLE
+	}
+	return typed.OBJECT
+}
+
+// GetValue returns the underlying value.
+func (c ConstantValue) GetValue() interface{} {
+	return c.value
+}
+
+// IntValue returns the value of an int constant.
+func (c ConstantValue) IntValue() (int, bool) {
+	value, ok := c.value.(int)
+	return value, ok
+}
+
+// FloatValue returns the value of a float constant.
+func (c ConstantValue) FloatValue() (float32, bool) {
+	value, ok := c.value.(float32)
+	return value, ok
+}
+
+// LongValue returns the value of a long constant.
+func (c ConstantValue) LongValue() (int64, bool) {
+	value, ok := c.value.(int64)
+	return value, ok
+}
+
+// DoubleValue returns the value of a double constant.
+func (c ConstantValue) DoubleValue() (float64, bool) {
+	value, ok := c.value.(float64)
+	return value, ok
+}
+
+// StringValue returns the value of a String constant.
+func (c ConstantValue) StringValue() (string, bool) {
+	value, ok := c.value.(string)
+	return value, ok
+}
+
+// ForField returns the value of this constant as a value of the given field descriptor: a bool, int8, uint16
+// (char) or int16 for the boolean, byte, char and short fields, which are stored as int constants, or the
+// underlying value otherwise. Returns an error if the constant does not match the descriptor.
+func (c ConstantValue) ForField(descriptor string) (interface{}, error) {
+	intValue, isInt := c.value.(int)
+	switch descriptor {
+	case "Z", "B", "C", "S", "I":
+		if !isInt {
+			break
+		}
+		switch descriptor {
+		case "Z":
+			return intValue != 0, nil
+		case "B":
+			return int8(intValue), nil
+		case "C":
+			return uint16(intValue), nil
+		case "S":
+			return int16(intValue), nil
+		}
+		return intValue, nil
+	case "F", "J", "D":
+		if sort := c.GetSort(); (descriptor == "F" && sort == typed.FLOAT) || (descriptor == "J" && sort == typed.LONG) ||
+			(descriptor == "D" && sort == typed.DOUBLE) {
+			return c.value, nil
+		}
+	case "Ljava/lang/String;":
+		if _, ok := c.value.(string); ok {
+			return c.value, nil
+		}
+	}
+	return nil, errors.New("Illegal Argument - constant value does not match the field descriptor " + descriptor)
+}
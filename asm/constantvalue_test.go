@@ -0,0 +1,65 @@
+package asm_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// constantFieldsClass returns a class file with an int, a float, a long and a double static field, each with a
+// ConstantValue attribute holding the given value.
+func constantFieldsClass(i int32, f float32, j int64, d float64) []byte {
+	u2 := func(b []byte, v int) []byte { return binary.BigEndian.AppendUint16(b, uint16(v)) }
+	utf8 := func(s string) []byte { return append(u2([]byte{1}, len(s)), s...) }
+	var pool []byte
+	for _, s := range []string{"A", "java/lang/Object", "ConstantValue", "i", "I", "f", "F", "j", "J", "d", "D"} {
+		pool = append(pool, utf8(s)...)
+	}
+	pool = u2(append(pool, 7), 1)
+	pool = u2(append(pool, 7), 2)
+	pool = binary.BigEndian.AppendUint32(append(pool, 3), uint32(i))
+	pool = binary.BigEndian.AppendUint32(append(pool, 4), math.Float32bits(f))
+	pool = binary.BigEndian.AppendUint64(append(pool, 5), uint64(j))
+	pool = binary.BigEndian.AppendUint64(append(pool, 6), math.Float64bits(d))
+
+	b := u2([]byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52}, 20)
+	b = append(b, pool...)
+	b = u2(u2(u2(u2(u2(b, 0x21), 12), 13), 0), 4)
+	for k, value := range []int{14, 15, 16, 18} {
+		b = u2(u2(u2(u2(b, 0x19), 4+2*k), 5+2*k), 1)
+		b = binary.BigEndian.AppendUint32(u2(b, 3), 2)
+		b = u2(b, value)
+	}
+	return u2(u2(b, 0), 0)
+}
+
+func TestReadConstantValues(t *testing.T) {
+	classReader, err := asm.NewClassReader(constantFieldsClass(-5, -1.5, -7, 2.25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]interface{}{}
+	classReader.Accept(&helper.ClassVisitor{
+		OnVisitField: func(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+			values[name] = value
+			return nil
+		},
+	}, 0)
+
+	expected := map[string]interface{}{"i": -5, "f": float32(-1.5), "j": int64(-7), "d": 2.25}
+	for name, value := range expected {
+		if values[name] != value {
+			t.Errorf("field %s: expected %v (%T), got %v (%T)", name, value, value, values[name], values[name])
+		}
+	}
+	constantValue, err := asm.NewConstantValue(values["f"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := constantValue.FloatValue(); !ok || f != -1.5 {
+		t.Errorf("expected float constant -1.5, got %v", constantValue.GetValue())
+	}
+}
@@ -22,4 +22,5 @@ type Context struct {
 	currentFrameLocalTypes                     []interface{}
 	currentFrameStackCount                     int
 	currentFrameStackTypes                     []interface{}
+	filter                                     *Filter
 }
@@ -0,0 +1,493 @@
+// Package decomp reconstructs a readable pseudocode from the bytecode of the methods, for display purposes. The
+// operand stack is simulated symbolically to rebuild the expressions, and the control flow graph is structured into
+// if, if else, while and do while statements, with break and continue. The control flow which can not be
+// structured this way (e.g. the exception handlers and the switch cases) is shown with goto statements. The result
+// is not valid Java source code, and this package is experimental.
+package decomp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// The ways a basic block can end.
+const (
+	exitFallthrough = iota
+	exitGoto
+	exitCondition
+	exitSwitch
+	exitReturn
+)
+
+// block a basic block of a method, with the pseudocode of its instructions.
+type block struct {
+	statements []string
+	exit       int
+	// condition the condition of the conditional jump ending the block, if exit is exitCondition.
+	condition condition
+	// target the index of the block targeted by the jump ending the block, if exit is exitGoto or exitCondition.
+	target int
+	// switchValue, switchKeys, switchTargets and switchDefault the switch ending the block, if exit is exitSwitch.
+	switchValue   string
+	switchKeys    []int
+	switchTargets []int
+	switchDefault int
+	// handler the exception type caught by the block, if it starts an exception handler, or "".
+	handler string
+}
+
+// loop a loop statement being written.
+type loop struct {
+	// start the index of the first block of the loop.
+	start int
+	// continueTarget the index of the block executed by a continue statement, or -1 if it can not be used.
+	continueTarget int
+	// exit the index of the first block after the loop, executed by a break statement.
+	exit int
+}
+
+// line a line of pseudocode, or a placeholder for the label of a block.
+type line struct {
+	depth int
+	text  string
+	// label the index of the block whose label must be written here if it is used by a goto, or -1.
+	label int
+}
+
+// decompiler the state of the decompilation of a method.
+type decompiler struct {
+	owner  string
+	method *tree.MethodNode
+	// localNames the names of the local variables, from the debug information.
+	localNames map[int]string
+	blocks     []*block
+	// implicit the blocks whose final jump must not be written, because it is implied by the enclosing statement.
+	implicit map[int]bool
+	// usedLabels the blocks targeted by a goto statement.
+	usedLabels map[int]bool
+	lines      []line
+}
+
+// Decompile returns the pseudocode of the given method of the given class (an internal name), starting with the
+// method declaration, or an error if the method uses instructions which are not supported (i.e. JSR and RET).
+func Decompile(owner string, method *tree.MethodNode) (string, error) {
+	d := &decompiler{
+		owner:      owner,
+		method:     method,
+		localNames: make(map[int]string),
+		implicit:   make(map[int]bool),
+		usedLabels: make(map[int]bool),
+	}
+	for _, localVariable := range method.LocalVariables {
+		if _, ok := d.localNames[localVariable.Index]; !ok {
+			d.localNames[localVariable.Index] = localVariable.Name
+		}
+	}
+	declaration := d.declaration()
+	if len(method.Instructions) == 0 {
+		return declaration + ";\n", nil
+	}
+	if err := d.buildBlocks(); err != nil {
+		return "", err
+	}
+	d.writeRange(0, len(d.blocks), 1, nil)
+	var result strings.Builder
+	result.WriteString(declaration + " {\n")
+	writtenLabels := make(map[int]bool)
+	for _, line := range d.lines {
+		if line.label >= 0 {
+			if d.usedLabels[line.label] && !writtenLabels[line.label] {
+				result.WriteString(strings.Repeat("    ", line.depth-1) + labelName(line.label) + ":\n")
+				writtenLabels[line.label] = true
+			}
+			continue
+		}
+		result.WriteString(strings.Repeat("    ", line.depth) + line.text + "\n")
+	}
+	result.WriteString("}\n")
+	return result.String(), nil
+}
+
+// DecompileClass returns the pseudocode of all the methods of the given class, inside a class declaration.
+func DecompileClass(class *tree.ClassNode) (string, error) {
+	var result strings.Builder
+	result.WriteString("class " + simpleName(class.Name))
+	if class.SuperName != "" && class.SuperName != "java/lang/Object" {
+		result.WriteString(" extends " + simpleName(class.SuperName))
+	}
+	result.WriteString(" {\n")
+	for i, method := range class.Methods {
+		text, err := Decompile(class.Name, method)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			result.WriteString("\n")
+		}
+		for _, methodLine := range strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n") {
+			result.WriteString("    " + methodLine)
+		}
+		result.WriteString("\n")
+	}
+	result.WriteString("}\n")
+	return result.String(), nil
+}
+
+func labelName(index int) string {
+	return "L" + strconv.Itoa(index)
+}
+
+// localName returns the name of the given local variable.
+func (d *decompiler) localName(index int) string {
+	if name, ok := d.localNames[index]; ok {
+		return name
+	}
+	slot := 0
+	if d.method.Access&opcodes.ACC_STATIC == 0 {
+		if index == 0 {
+			return "this"
+		}
+		slot = 1
+	}
+	for i, argumentType := range asm.GetMethodType(d.method.Descriptor).GetArgumentTypes() {
+		if slot == index {
+			return "arg" + strconv.Itoa(i)
+		}
+		slot += argumentType.GetSize()
+	}
+	return "local" + strconv.Itoa(index)
+}
+
+// declaration returns the declaration of the method, without its body.
+func (d *decompiler) declaration() string {
+	var declaration strings.Builder
+	modifiers := []struct {
+		access int
+		name   string
+	}{
+		{opcodes.ACC_PUBLIC, "public"}, {opcodes.ACC_PRIVATE, "private"}, {opcodes.ACC_PROTECTED, "protected"},
+		{opcodes.ACC_STATIC, "static"}, {opcodes.ACC_FINAL, "final"}, {opcodes.ACC_SYNCHRONIZED, "synchronized"},
+		{opcodes.ACC_NATIVE, "native"}, {opcodes.ACC_ABSTRACT, "abstract"},
+	}
+	for _, modifier := range modifiers {
+		if d.method.Access&modifier.access != 0 {
+			declaration.WriteString(modifier.name + " ")
+		}
+	}
+	methodType := asm.GetMethodType(d.method.Descriptor)
+	if d.method.Name != "<init>" && d.method.Name != "<clinit>" {
+		declaration.WriteString(typeName(methodType.GetReturnType().GetDescriptor()) + " ")
+	}
+	declaration.WriteString(d.method.Name + "(")
+	slot := 0
+	if d.method.Access&opcodes.ACC_STATIC == 0 {
+		slot = 1
+	}
+	for i, argumentType := range methodType.GetArgumentTypes() {
+		if i > 0 {
+			declaration.WriteString(", ")
+		}
+		declaration.WriteString(typeName(argumentType.GetDescriptor()) + " " + d.localName(slot))
+		slot += argumentType.GetSize()
+	}
+	declaration.WriteString(")")
+	return declaration.String()
+}
+
+// buildBlocks splits the instructions into basic blocks, and simulates each of them to compute their statements.
+// The values left on the stack at the end of a block are stored in temporary variables named $0, $1, etc, which
+// are used by the following blocks.
+func (d *decompiler) buildBlocks() error {
+	targets := make(map[*tree.LabelNode]bool)
+	handlers := make(map[*tree.LabelNode]string)
+	for _, tryCatchBlock := range d.method.TryCatchBlocks {
+		targets[tryCatchBlock.Handler] = true
+		if _, ok := handlers[tryCatchBlock.Handler]; !ok {
+			handlers[tryCatchBlock.Handler] = "any"
+			if tryCatchBlock.Type != "" {
+				handlers[tryCatchBlock.Handler] = simpleName(tryCatchBlock.Type)
+			}
+		}
+	}
+	for _, insn := range d.method.Instructions {
+		for _, label := range jumpLabels(insn) {
+			targets[label] = true
+		}
+	}
+	// starts the index of the first instruction of each block.
+	var starts []int
+	blockIndexes := make(map[*tree.LabelNode]int)
+	newBlock := true
+	for i, insn := range d.method.Instructions {
+		if label, ok := insn.(*tree.LabelNode); ok && targets[label] {
+			if newBlock || len(starts) == 0 || !isEmpty(d.method.Instructions[starts[len(starts)-1]:i]) {
+				starts = append(starts, i)
+			}
+			blockIndexes[label] = len(starts) - 1
+			newBlock = false
+			continue
+		}
+		if newBlock && insn.GetOpcode() >= 0 {
+			starts = append(starts, i)
+			newBlock = false
+		}
+		if len(jumpLabels(insn)) > 0 || isReturnOrThrow(insn.GetOpcode()) {
+			newBlock = true
+		}
+	}
+	d.blocks = make([]*block, len(starts))
+	// entryStacks the stacks at the start of the blocks, or nil if not known yet.
+	entryStacks := make([][]*expression, len(starts))
+	for index, start := range starts {
+		end := len(d.method.Instructions)
+		if index+1 < len(starts) {
+			end = starts[index+1]
+		}
+		b := &block{exit: exitFallthrough}
+		d.blocks[index] = b
+		s := &simulator{decompiler: d, stack: entryStacks[index]}
+		for _, insn := range d.method.Instructions[start:end] {
+			if label, ok := insn.(*tree.LabelNode); ok && handlers[label] != "" {
+				b.handler = handlers[label]
+				s.stack = []*expression{value("exception", 1)}
+			}
+			jumpCondition, err := s.execute(insn)
+			if err != nil {
+				return err
+			}
+			switch insn := insn.(type) {
+			case *tree.JumpInsnNode:
+				b.target = blockIndexes[insn.Label]
+				if jumpCondition == nil {
+					b.exit = exitGoto
+				} else {
+					b.exit = exitCondition
+					b.condition = *jumpCondition
+				}
+			case *tree.TableSwitchInsnNode, *tree.LookupSwitchInsnNode:
+				b.exit = exitSwitch
+				b.switchValue = s.pop().text
+				b.switchDefault = blockIndexes[jumpLabels(insn)[0]]
+				for i, label := range jumpLabels(insn)[1:] {
+					b.switchTargets = append(b.switchTargets, blockIndexes[label])
+					if lookupSwitch, ok := insn.(*tree.LookupSwitchInsnNode); ok {
+						b.switchKeys = append(b.switchKeys, lookupSwitch.Keys[i])
+					} else {
+						b.switchKeys = append(b.switchKeys, insn.(*tree.TableSwitchInsnNode).Min+i)
+					}
+				}
+			}
+			if isReturnOrThrow(insn.GetOpcode()) {
+				b.exit = exitReturn
+			}
+		}
+		// Spills the remaining stack values, and propagates them to the successors of the block.
+		var exitStack []*expression
+		if b.exit == exitReturn {
+			s.stack = nil
+		}
+		for i, e := range s.stack {
+			temporary := "$" + strconv.Itoa(i)
+			if e.text != temporary {
+				s.emit(temporary + " = " + e.text + ";")
+			}
+			exitStack = append(exitStack, value(temporary, e.size))
+		}
+		b.statements = s.statements
+		for _, successor := range d.successors(index) {
+			if successor > index && successor < len(starts) && entryStacks[successor] == nil {
+				entryStacks[successor] = exitStack
+			}
+		}
+	}
+	return nil
+}
+
+// successors returns the indexes of the blocks which can be executed after the given one.
+func (d *decompiler) successors(index int) []int {
+	b := d.blocks[index]
+	var successors []int
+	switch b.exit {
+	case exitFallthrough:
+		successors = []int{index + 1}
+	case exitGoto:
+		successors = []int{b.target}
+	case exitCondition:
+		successors = []int{b.target, index + 1}
+	case exitSwitch:
+		successors = append([]int{b.switchDefault}, b.switchTargets...)
+	}
+	return successors
+}
+
+// jumpLabels returns the labels targeted by the given jump or switch instruction (starting with the default label
+// of the switches), or nil for the other instructions.
+func jumpLabels(insn tree.AbstractInsnNode) []*tree.LabelNode {
+	switch insn := insn.(type) {
+	case *tree.JumpInsnNode:
+		return []*tree.LabelNode{insn.Label}
+	case *tree.TableSwitchInsnNode:
+		return append([]*tree.LabelNode{insn.Dflt}, insn.Labels...)
+	case *tree.LookupSwitchInsnNode:
+		return append([]*tree.LabelNode{insn.Dflt}, insn.Labels...)
+	}
+	return nil
+}
+
+func isReturnOrThrow(opcode int) bool {
+	return (opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN) || opcode == opcodes.ATHROW
+}
+
+// isEmpty returns whether the given instructions only contain pseudo instructions.
+func isEmpty(insns []tree.AbstractInsnNode) bool {
+	for _, insn := range insns {
+		if insn.GetOpcode() >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *decompiler) write(depth int, text string) {
+	d.lines = append(d.lines, line{depth: depth, text: text, label: -1})
+}
+
+// writeRange writes the statements of the blocks from index from (inclusive) to index to (exclusive), which are
+// inside the given loops.
+func (d *decompiler) writeRange(from, to, depth int, loops []loop) {
+	for i := from; i < to; {
+		if last := d.lastBackEdge(i, to); last >= 0 && !isLoopStart(loops, i) {
+			i = d.writeLoop(i, last, depth, loops)
+		} else {
+			i = d.writeBlock(i, to, depth, loops)
+		}
+	}
+}
+
+// lastBackEdge returns the index of the last block, before index to, which jumps back to the given block, or -1.
+func (d *decompiler) lastBackEdge(index, to int) int {
+	for i := to - 1; i >= index; i-- {
+		b := d.blocks[i]
+		if (b.exit == exitGoto || b.exit == exitCondition) && b.target == index {
+			return i
+		}
+	}
+	return -1
+}
+
+func isLoopStart(loops []loop, index int) bool {
+	for _, loop := range loops {
+		if loop.start == index {
+			return true
+		}
+	}
+	return false
+}
+
+// writeLoop writes the loop made of the blocks from index start to index last (inclusive), where the last block
+// jumps back to the first one, and returns the index of the block following the loop.
+func (d *decompiler) writeLoop(start, last, depth int, loops []loop) int {
+	d.lines = append(d.lines, line{depth: depth, label: start})
+	first := d.blocks[start]
+	end := d.blocks[last]
+	d.implicit[last] = true
+	switch {
+	case end.exit == exitCondition:
+		// The condition is tested at the end, so continue can not be used.
+		d.write(depth, "do {")
+		d.writeRange(start, last+1, depth+1, append(loops, loop{start: start, continueTarget: -1, exit: last + 1}))
+		d.write(depth, "} while ("+end.condition.String()+");")
+	case len(first.statements) == 0 && first.exit == exitCondition && first.target == last+1 && start < last:
+		d.implicit[start] = true
+		d.write(depth, "while ("+first.condition.negate().String()+") {")
+		d.writeRange(start, last+1, depth+1, append(loops, loop{start: start, continueTarget: start, exit: last + 1}))
+		d.write(depth, "}")
+	default:
+		d.write(depth, "while (true) {")
+		d.writeRange(start, last+1, depth+1, append(loops, loop{start: start, continueTarget: start, exit: last + 1}))
+		d.write(depth, "}")
+	}
+	return last + 1
+}
+
+// writeBlock writes the statements of the given block, as well as the following blocks which belong to the if or
+// while statement it starts, if any, and returns the index of the next block to write.
+func (d *decompiler) writeBlock(index, to, depth int, loops []loop) int {
+	b := d.blocks[index]
+	d.lines = append(d.lines, line{depth: depth, label: index})
+	if b.handler != "" {
+		d.write(depth, "// catch "+b.handler)
+	}
+	for _, statement := range b.statements {
+		d.write(depth, statement)
+	}
+	if d.implicit[index] {
+		return index + 1
+	}
+	switch b.exit {
+	case exitCondition:
+		target := b.target
+		if target <= index+1 || target > to {
+			d.write(depth, "if ("+b.condition.String()+") "+d.jump(target, loops))
+			return index + 1
+		}
+		d.write(depth, "if ("+b.condition.negate().String()+") {")
+		if thenEnd := d.blocks[target-1]; target-1 > index && thenEnd.exit == exitGoto && !d.implicit[target-1] &&
+			thenEnd.target > target && thenEnd.target <= to {
+			d.implicit[target-1] = true
+			d.writeRange(index+1, target, depth+1, loops)
+			d.write(depth, "} else {")
+			d.writeRange(target, thenEnd.target, depth+1, loops)
+			d.write(depth, "}")
+			return thenEnd.target
+		}
+		d.writeRange(index+1, target, depth+1, loops)
+		d.write(depth, "}")
+		return target
+	case exitGoto:
+		// The while loops compiled with the condition at the end: goto condition; body; condition: if (c) goto body.
+		target := b.target
+		if target > index+1 && target < to {
+			if condition := d.blocks[target]; condition.exit == exitCondition && condition.target == index+1 &&
+				len(condition.statements) == 0 && d.lastBackEdge(index+1, to) == target {
+				d.implicit[target] = true
+				d.lines = append(d.lines, line{depth: depth, label: index + 1})
+				d.write(depth, "while ("+condition.condition.String()+") {")
+				d.writeRange(index+1, target, depth+1, append(loops, loop{start: index + 1, continueTarget: target, exit: target + 1}))
+				d.write(depth, "}")
+				d.lines = append(d.lines, line{depth: depth, label: target})
+				return target + 1
+			}
+		}
+		if target != index+1 {
+			d.write(depth, d.jump(target, loops))
+		}
+	case exitSwitch:
+		d.write(depth, "switch ("+b.switchValue+") {")
+		for i, key := range b.switchKeys {
+			d.write(depth+1, "case "+strconv.Itoa(key)+": "+d.jump(b.switchTargets[i], loops))
+		}
+		d.write(depth+1, "default: "+d.jump(b.switchDefault, loops))
+		d.write(depth, "}")
+	}
+	return index + 1
+}
+
+// jump returns the statement jumping to the given block: break or continue if possible, goto otherwise.
+func (d *decompiler) jump(target int, loops []loop) string {
+	if len(loops) > 0 {
+		innermost := loops[len(loops)-1]
+		if target == innermost.exit {
+			return "break;"
+		}
+		if target == innermost.continueTarget {
+			return "continue;"
+		}
+	}
+	d.usedLabels[target] = true
+	return "goto " + labelName(target) + ";"
+}
@@ -0,0 +1,78 @@
+package decomp_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/decomp"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestDecompile(t *testing.T) {
+	// static String m(int[] a) {
+	//   int s = 0;
+	//   for (int i = 0; i < a.length; i++) { if (a[i] > 0) s += a[i]; else s--; }
+	//   return new StringBuilder().append(s).toString();
+	// }
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "([I)Ljava/lang/String;", "", nil)
+	condition, elseLabel, increment, end := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	method.VisitCode()
+	method.VisitInsn(opcodes.ICONST_0)
+	method.VisitVarInsn(opcodes.ISTORE, 1)
+	method.VisitInsn(opcodes.ICONST_0)
+	method.VisitVarInsn(opcodes.ISTORE, 2)
+	method.VisitLabel(condition)
+	method.VisitVarInsn(opcodes.ILOAD, 2)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitInsn(opcodes.ARRAYLENGTH)
+	method.VisitJumpInsn(opcodes.IF_ICMPGE, end)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitVarInsn(opcodes.ILOAD, 2)
+	method.VisitInsn(opcodes.IALOAD)
+	method.VisitJumpInsn(opcodes.IFLE, elseLabel)
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	method.VisitVarInsn(opcodes.ALOAD, 0)
+	method.VisitVarInsn(opcodes.ILOAD, 2)
+	method.VisitInsn(opcodes.IALOAD)
+	method.VisitInsn(opcodes.IADD)
+	method.VisitVarInsn(opcodes.ISTORE, 1)
+	method.VisitJumpInsn(opcodes.GOTO, increment)
+	method.VisitLabel(elseLabel)
+	method.VisitIincInsn(1, -1)
+	method.VisitLabel(increment)
+	method.VisitIincInsn(2, 1)
+	method.VisitJumpInsn(opcodes.GOTO, condition)
+	method.VisitLabel(end)
+	method.VisitTypeInsn(opcodes.NEW, "java/lang/StringBuilder")
+	method.VisitInsn(opcodes.DUP)
+	method.VisitMethodInsn(opcodes.INVOKESPECIAL, "java/lang/StringBuilder", "<init>", "()V")
+	method.VisitVarInsn(opcodes.ILOAD, 1)
+	method.VisitMethodInsn(opcodes.INVOKEVIRTUAL, "java/lang/StringBuilder", "append", "(I)Ljava/lang/StringBuilder;")
+	method.VisitMethodInsn(opcodes.INVOKEVIRTUAL, "java/lang/StringBuilder", "toString", "()Ljava/lang/String;")
+	method.VisitInsn(opcodes.ARETURN)
+	method.VisitMaxs(4, 3)
+	method.VisitEnd()
+
+	text, err := decomp.Decompile("A", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `static String m(int[] arg0) {
+    local1 = 0;
+    local2 = 0;
+    while (local2 < arg0.length) {
+        if (arg0[local2] > 0) {
+            local1 = local1 + arg0[local2];
+        } else {
+            local1--;
+        }
+        local2++;
+    }
+    return new StringBuilder().append(local1).toString();
+}
+`
+	if text != expected {
+		t.Errorf("unexpected pseudocode:\n%s", text)
+	}
+}
@@ -0,0 +1,454 @@
+package decomp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// expression a symbolic value of the operand stack, i.e. the pseudocode of the expression which computes it.
+type expression struct {
+	text string
+	// size the number of stack words of the value (2 for long and double values, 1 otherwise).
+	size int
+	// compound whether the text must be put between parentheses when used as an operand.
+	compound bool
+	// sideEffect whether the expression must be kept as a statement when its value is discarded.
+	sideEffect bool
+	// uninitialized the type created by a NEW instruction whose constructor has not been called yet, or "".
+	uninitialized string
+	// compareLeft and compareRight the operands of a LCMP, FCMPx or DCMPx instruction, or nil.
+	compareLeft, compareRight *expression
+}
+
+// operand returns the text of the given expression, suitable to be used as the operand of another one.
+func operand(e *expression) string {
+	if e.compound {
+		return "(" + e.text + ")"
+	}
+	return e.text
+}
+
+// condition the condition of a conditional jump.
+type condition struct {
+	left, operator, right string
+}
+
+var negatedOperators = map[string]string{"==": "!=", "!=": "==", "<": ">=", ">=": "<", ">": "<=", "<=": ">"}
+
+func (c condition) String() string {
+	return c.left + " " + c.operator + " " + c.right
+}
+
+// negate returns the condition which is true when this one is false.
+func (c condition) negate() condition {
+	return condition{c.left, negatedOperators[c.operator], c.right}
+}
+
+// The symbols of the IF<cond> and IF_<x>CMP<cond> jumps, indexed by (opcode - IFEQ) % 6.
+var conditionOperators = []string{"==", "!=", "<", ">=", ">", "<="}
+
+// The symbols of the arithmetic instructions from IADD to LXOR, indexed by (opcode - IADD) / 4 (the shifts and
+// the logical operators only have I and L variants, hence the extra handling in binaryOperator).
+var arithmeticOperators = []string{"+", "-", "*", "/", "%"}
+
+// The types of the primitive conversions from I2L to I2S, indexed by opcode - I2L.
+var conversionTypes = []string{
+	"long", "float", "double", "int", "float", "double", "int", "long", "double", "int", "long", "float", "byte", "char",
+	"short",
+}
+
+// The element types of the NEWARRAY instructions, indexed by operand - T_BOOLEAN.
+var newArrayTypes = []string{"boolean", "char", "float", "double", "byte", "short", "int", "long"}
+
+// simulator simulates the execution of the instructions of a basic block on a symbolic operand stack, and collects
+// the statements they produce.
+type simulator struct {
+	decompiler *decompiler
+	stack      []*expression
+	statements []string
+}
+
+func (s *simulator) push(e *expression) {
+	s.stack = append(s.stack, e)
+}
+
+// pop pops the top of the stack. An unknown value is returned if the stack is empty, so that invalid bytecode
+// still produces some output.
+func (s *simulator) pop() *expression {
+	if len(s.stack) == 0 {
+		return &expression{text: "?", size: 1}
+	}
+	e := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return e
+}
+
+// popWords pops the top values of the stack which use at least the given number of stack words, and returns them
+// in stack order.
+func (s *simulator) popWords(words int) []*expression {
+	var values []*expression
+	for words > 0 {
+		e := s.pop()
+		values = append([]*expression{e}, values...)
+		words -= e.size
+	}
+	return values
+}
+
+// popArguments pops the arguments of a method with the given descriptor, and returns them separated by commas.
+func (s *simulator) popArguments(descriptor string) string {
+	arguments := make([]string, len(asm.GetMethodType(descriptor).GetArgumentTypes()))
+	for i := len(arguments) - 1; i >= 0; i-- {
+		arguments[i] = s.pop().text
+	}
+	return strings.Join(arguments, ", ")
+}
+
+func (s *simulator) emit(statement string) {
+	s.statements = append(s.statements, statement)
+}
+
+// value returns a non compound expression of the given size.
+func value(text string, size int) *expression {
+	return &expression{text: text, size: size}
+}
+
+// execute simulates the given instruction. It returns the condition of the conditional jumps, or nil.
+func (s *simulator) execute(insn tree.AbstractInsnNode) (*condition, error) {
+	opcode := insn.GetOpcode()
+	switch insn := insn.(type) {
+	case *tree.InsnNode:
+		s.executeInsn(opcode)
+	case *tree.IntInsnNode:
+		if opcode == opcodes.NEWARRAY {
+			s.push(&expression{text: "new " + newArrayTypes[insn.Operand-opcodes.T_BOOLEAN] + "[" + s.pop().text + "]", size: 1})
+		} else {
+			s.push(value(strconv.Itoa(insn.Operand), 1))
+		}
+	case *tree.VarInsnNode:
+		name := s.decompiler.localName(insn.Var)
+		switch {
+		case opcode == opcodes.RET:
+			return nil, errors.New("Illegal Argument - JSR and RET instructions are not supported")
+		case opcode <= opcodes.ALOAD:
+			s.push(value(name, wordSize(opcode == opcodes.LLOAD || opcode == opcodes.DLOAD)))
+		default:
+			s.emit(name + " = " + s.pop().text + ";")
+		}
+	case *tree.IincInsnNode:
+		name := s.decompiler.localName(insn.Var)
+		switch {
+		case insn.Increment == 1:
+			s.emit(name + "++;")
+		case insn.Increment == -1:
+			s.emit(name + "--;")
+		case insn.Increment < 0:
+			s.emit(name + " -= " + strconv.Itoa(-insn.Increment) + ";")
+		default:
+			s.emit(name + " += " + strconv.Itoa(insn.Increment) + ";")
+		}
+	case *tree.LdcInsnNode:
+		s.push(value(constantText(insn.Value), constantSize(insn.Value)))
+	case *tree.TypeInsnNode:
+		s.executeTypeInsn(opcode, insn.Type)
+	case *tree.FieldInsnNode:
+		size := asm.GetType(insn.Descriptor).GetSize()
+		switch opcode {
+		case opcodes.GETSTATIC:
+			s.push(value(simpleName(insn.Owner)+"."+insn.Name, size))
+		case opcodes.PUTSTATIC:
+			s.emit(simpleName(insn.Owner) + "." + insn.Name + " = " + s.pop().text + ";")
+		case opcodes.GETFIELD:
+			s.push(value(operand(s.pop())+"."+insn.Name, size))
+		default:
+			fieldValue := s.pop()
+			s.emit(operand(s.pop()) + "." + insn.Name + " = " + fieldValue.text + ";")
+		}
+	case *tree.MethodInsnNode:
+		s.executeMethodInsn(opcode, insn.Owner, insn.Name, insn.Descriptor)
+	case *tree.InvokeDynamicInsnNode:
+		arguments := s.popArguments(insn.Descriptor)
+		s.pushResult(insn.Descriptor, "invokedynamic "+insn.Name+"("+arguments+")", true)
+	case *tree.JumpInsnNode:
+		return s.executeJumpInsn(opcode)
+	case *tree.MultiANewArrayInsnNode:
+		dimensions := make([]string, insn.NumDimensions)
+		for i := len(dimensions) - 1; i >= 0; i-- {
+			dimensions[i] = s.pop().text
+		}
+		arrayDimensions := strings.LastIndexByte(insn.Descriptor, '[') + 1
+		s.push(value(newArrayText(insn.Descriptor[arrayDimensions:], dimensions, arrayDimensions), 1))
+	}
+	return nil, nil
+}
+
+// executeInsn simulates an instruction without operand.
+func (s *simulator) executeInsn(opcode int) {
+	switch {
+	case opcode == opcodes.NOP:
+	case opcode == opcodes.ACONST_NULL:
+		s.push(value("null", 1))
+	case opcode <= opcodes.ICONST_5:
+		s.push(value(strconv.Itoa(opcode-opcodes.ICONST_0), 1))
+	case opcode <= opcodes.LCONST_1:
+		s.push(value(strconv.Itoa(opcode-opcodes.LCONST_0)+"L", 2))
+	case opcode <= opcodes.FCONST_2:
+		s.push(value(strconv.Itoa(opcode-opcodes.FCONST_0)+".0F", 1))
+	case opcode <= opcodes.DCONST_1:
+		s.push(value(strconv.Itoa(opcode-opcodes.DCONST_0)+".0", 2))
+	case opcode <= opcodes.SALOAD:
+		index := s.pop()
+		s.push(value(operand(s.pop())+"["+index.text+"]", wordSize(opcode == opcodes.LALOAD || opcode == opcodes.DALOAD)))
+	case opcode <= opcodes.SASTORE:
+		arrayValue := s.pop()
+		index := s.pop()
+		s.emit(operand(s.pop()) + "[" + index.text + "] = " + arrayValue.text + ";")
+	case opcode == opcodes.POP || opcode == opcodes.POP2:
+		for _, e := range s.popWords(opcode - opcodes.POP + 1) {
+			if e.sideEffect {
+				s.emit(e.text + ";")
+			}
+		}
+	case opcode <= opcodes.DUP2_X2:
+		// DUP, DUP_X1, DUP_X2, DUP2, DUP2_X1 and DUP2_X2 duplicate 1 or 2 words, below 0, 1 or 2 other words.
+		index := opcode - opcodes.DUP
+		duplicated := s.popWords(index/3 + 1)
+		skipped := s.popWords(index % 3)
+		s.stack = append(append(append(s.stack, duplicated...), skipped...), duplicated...)
+	case opcode == opcodes.SWAP:
+		first := s.pop()
+		second := s.pop()
+		s.push(first)
+		s.push(second)
+	case opcode <= opcodes.LXOR:
+		if opcode >= opcodes.INEG && opcode <= opcodes.DNEG {
+			e := s.pop()
+			s.push(&expression{text: "-" + operand(e), size: e.size, compound: true})
+			return
+		}
+		right := s.pop()
+		left := s.pop()
+		s.push(&expression{text: operand(left) + " " + binaryOperator(opcode) + " " + operand(right), size: left.size, compound: true})
+	case opcode <= opcodes.I2S:
+		targetType := conversionTypes[opcode-opcodes.I2L]
+		s.push(&expression{text: "(" + targetType + ") " + operand(s.pop()), size: wordSize(targetType == "long" || targetType == "double"), compound: true})
+	case opcode <= opcodes.DCMPG:
+		right := s.pop()
+		left := s.pop()
+		s.push(&expression{text: "compare(" + left.text + ", " + right.text + ")", size: 1, compareLeft: left, compareRight: right})
+	case opcode <= opcodes.ARETURN:
+		s.emit("return " + s.pop().text + ";")
+	case opcode == opcodes.RETURN:
+		s.emit("return;")
+	case opcode == opcodes.ARRAYLENGTH:
+		s.push(value(operand(s.pop())+".length", 1))
+	case opcode == opcodes.ATHROW:
+		s.emit("throw " + s.pop().text + ";")
+	case opcode == opcodes.MONITORENTER:
+		s.emit("monitorenter(" + s.pop().text + ");")
+	case opcode == opcodes.MONITOREXIT:
+		s.emit("monitorexit(" + s.pop().text + ");")
+	}
+}
+
+// binaryOperator returns the symbol of the given arithmetic, shift or logical instruction.
+func binaryOperator(opcode int) string {
+	switch {
+	case opcode <= opcodes.DREM:
+		return arithmeticOperators[(opcode-opcodes.IADD)/4]
+	case opcode <= opcodes.LSHL:
+		return "<<"
+	case opcode <= opcodes.LSHR:
+		return ">>"
+	case opcode <= opcodes.LUSHR:
+		return ">>>"
+	case opcode <= opcodes.LAND:
+		return "&"
+	case opcode <= opcodes.LOR:
+		return "|"
+	}
+	return "^"
+}
+
+// executeTypeInsn simulates a NEW, ANEWARRAY, CHECKCAST or INSTANCEOF instruction.
+func (s *simulator) executeTypeInsn(opcode int, internalName string) {
+	switch opcode {
+	case opcodes.NEW:
+		s.push(&expression{text: "new " + simpleName(internalName), size: 1, uninitialized: internalName})
+	case opcodes.ANEWARRAY:
+		dimensions := strings.LastIndexByte(internalName, '[') + 1
+		elementType := internalName[dimensions:]
+		if dimensions == 0 {
+			elementType = "L" + internalName + ";"
+		}
+		s.push(value(newArrayText(elementType, []string{s.pop().text}, dimensions+1), 1))
+	case opcodes.CHECKCAST:
+		s.push(&expression{text: "(" + typeName(internalName) + ") " + operand(s.pop()), size: 1, compound: true})
+	default:
+		s.push(&expression{text: operand(s.pop()) + " instanceof " + typeName(internalName), size: 1, compound: true})
+	}
+}
+
+// executeMethodInsn simulates an INVOKEVIRTUAL, INVOKESPECIAL, INVOKESTATIC or INVOKEINTERFACE instruction.
+func (s *simulator) executeMethodInsn(opcode int, owner, name, descriptor string) {
+	arguments := s.popArguments(descriptor)
+	if opcode == opcodes.INVOKESTATIC {
+		s.pushResult(descriptor, simpleName(owner)+"."+name+"("+arguments+")", true)
+		return
+	}
+	receiver := s.pop()
+	if opcode == opcodes.INVOKESPECIAL && name == "<init>" {
+		switch {
+		case receiver.uninitialized != "":
+			// Replaces the copies of the uninitialized value (pushed by DUP) with the new object.
+			created := &expression{text: "new " + simpleName(receiver.uninitialized) + "(" + arguments + ")", size: 1, sideEffect: true}
+			used := false
+			for i, e := range s.stack {
+				if e == receiver {
+					s.stack[i] = created
+					used = true
+				}
+			}
+			if !used {
+				s.emit(created.text + ";")
+			}
+		case receiver.text == "this" && owner == s.decompiler.owner:
+			s.emit("this(" + arguments + ");")
+		case receiver.text == "this":
+			s.emit("super(" + arguments + ");")
+		default:
+			s.emit(operand(receiver) + ".<init>(" + arguments + ");")
+		}
+		return
+	}
+	target := operand(receiver)
+	if opcode == opcodes.INVOKESPECIAL && receiver.text == "this" && owner != s.decompiler.owner {
+		target = "super"
+	}
+	s.pushResult(descriptor, target+"."+name+"("+arguments+")", true)
+}
+
+// pushResult pushes the result of a method call, or emits it as a statement if the method returns void.
+func (s *simulator) pushResult(descriptor, text string, sideEffect bool) {
+	size := asm.GetMethodType(descriptor).GetReturnType().GetSize()
+	if size == 0 {
+		s.emit(text + ";")
+		return
+	}
+	s.push(&expression{text: text, size: size, sideEffect: sideEffect})
+}
+
+// executeJumpInsn simulates a jump instruction, and returns its condition (nil for GOTO).
+func (s *simulator) executeJumpInsn(opcode int) (*condition, error) {
+	switch {
+	case opcode == opcodes.GOTO:
+		return nil, nil
+	case opcode == opcodes.JSR:
+		return nil, errors.New("Illegal Argument - JSR and RET instructions are not supported")
+	case opcode == opcodes.IFNULL || opcode == opcodes.IFNONNULL:
+		return &condition{operand(s.pop()), conditionOperators[opcode-opcodes.IFNULL], "null"}, nil
+	case opcode <= opcodes.IFLE:
+		e := s.pop()
+		if e.compareLeft != nil {
+			return &condition{operand(e.compareLeft), conditionOperators[opcode-opcodes.IFEQ], operand(e.compareRight)}, nil
+		}
+		return &condition{operand(e), conditionOperators[opcode-opcodes.IFEQ], "0"}, nil
+	}
+	right := s.pop()
+	left := s.pop()
+	return &condition{operand(left), conditionOperators[(opcode-opcodes.IF_ICMPEQ)%6], operand(right)}, nil
+}
+
+func wordSize(large bool) int {
+	if large {
+		return 2
+	}
+	return 1
+}
+
+// constantText returns the pseudocode of the given LDC constant.
+func constantText(constant interface{}) string {
+	switch constant := constant.(type) {
+	case string:
+		return strconv.Quote(constant)
+	case *asm.Type:
+		if constant.GetSort() == typed.METHOD {
+			return "MethodType " + constant.GetDescriptor()
+		}
+		return typeName(constant.GetDescriptor()) + ".class"
+	case int64:
+		return strconv.FormatInt(constant, 10) + "L"
+	case float32:
+		return strconv.FormatFloat(float64(constant), 'g', -1, 32) + "F"
+	case float64:
+		return strconv.FormatFloat(constant, 'g', -1, 64)
+	}
+	return fmt.Sprint(constant)
+}
+
+// constantSize returns the number of stack words of the given LDC constant.
+func constantSize(constant interface{}) int {
+	switch constant.(type) {
+	case int64, float64:
+		return 2
+	}
+	return 1
+}
+
+// simpleName returns the name of the given class without its package.
+func simpleName(internalName string) string {
+	return internalName[strings.LastIndexByte(internalName, '/')+1:]
+}
+
+// typeName returns the pseudocode name of the type with the given descriptor, or of the class with the given
+// internal name.
+func typeName(descriptor string) string {
+	switch descriptor {
+	case "Z":
+		return "boolean"
+	case "C":
+		return "char"
+	case "B":
+		return "byte"
+	case "S":
+		return "short"
+	case "I":
+		return "int"
+	case "F":
+		return "float"
+	case "J":
+		return "long"
+	case "D":
+		return "double"
+	case "V":
+		return "void"
+	}
+	if strings.HasPrefix(descriptor, "[") {
+		return typeName(descriptor[1:]) + "[]"
+	}
+	if strings.HasPrefix(descriptor, "L") && strings.HasSuffix(descriptor, ";") {
+		return simpleName(descriptor[1 : len(descriptor)-1])
+	}
+	return simpleName(descriptor)
+}
+
+// newArrayText returns the pseudocode creating an array with the given element type descriptor, number of
+// dimensions, and dimension lengths (which may be fewer than the dimensions).
+func newArrayText(elementType string, lengths []string, dimensions int) string {
+	text := "new " + typeName(elementType)
+	for i := 0; i < dimensions; i++ {
+		if i < len(lengths) {
+			text += "[" + lengths[i] + "]"
+		} else {
+			text += "[]"
+		}
+	}
+	return text
+}
@@ -0,0 +1,37 @@
+package asm
+
+import "github.com/leaklessgfy/asm/asm/raw"
+
+// The kinds of the errors returned (or, for the visit methods, panicked) when a class file cannot be parsed,
+// to test with errors.Is (see {@link raw.ParseError}).
+var (
+	ErrUnsupportedVersion    = raw.ErrUnsupportedVersion
+	ErrMalformedConstantPool = raw.ErrMalformedConstantPool
+	ErrTruncated             = raw.ErrTruncated
+	ErrUnknownOpcode         = raw.ErrUnknownOpcode
+	ErrInvalidMagic          = raw.ErrInvalidMagic
+	ErrMalformedStackMap     = raw.ErrMalformedStackMap
+	ErrMalformedAttribute    = raw.ErrMalformedAttribute
+	ErrTrailingBytes         = raw.ErrTrailingBytes
+)
+
+// ParseError an error in a class file, at a given offset, to get with errors.As.
+type ParseError = raw.ParseError
+
+// RecoverParseError stops a panic caused by a {@link ParseError}, and stores this error in err. The visit
+// methods of the {@link ClassReader} panic on malformed code (e.g. an unknown opcode) instead of returning an
+// error, so it should be deferred by the callers which need to handle these errors:
+//
+//	defer asm.RecoverParseError(&err)
+//	reader.Accept(classVisitor, 0)
+//
+// The other panics are propagated.
+func RecoverParseError(err *error) {
+	if recovered := recover(); recovered != nil {
+		parseError, ok := recovered.(*ParseError)
+		if !ok {
+			panic(recovered)
+		}
+		*err = parseError
+	}
+}
@@ -0,0 +1,162 @@
+package asm
+
+import (
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// FieldWriter a {@link FieldVisitor} that generates the field_info structure of a field, as defined in the Java
+// Virtual Machine Specification (JVMS), with the constants it refers to added to a {@link SymbolTable}.
+type FieldWriter struct {
+	symbolTable                        *SymbolTable
+	accessFlags                        int
+	nameIndex                          int
+	descriptorIndex                    int
+	signatureIndex                     int
+	constantValueIndex                 int
+	lastRuntimeVisibleAnnotation       *AnnotationWriter
+	lastRuntimeInvisibleAnnotation     *AnnotationWriter
+	lastRuntimeVisibleTypeAnnotation   *AnnotationWriter
+	lastRuntimeInvisibleTypeAnnotation *AnnotationWriter
+	firstAttribute                     *Attribute
+	err                                error
+}
+
+// NewFieldWriter constructs a new {@link FieldWriter}, whose constants are added to the given symbol table. The
+// constant value, which may be nil, must be an int32, float32, int64, float64 or string (see
+// {@link SymbolTable#AddConstant}).
+func NewFieldWriter(symbolTable *SymbolTable, access int, name, descriptor, signature string, constantValue interface{}) *FieldWriter {
+	f := &FieldWriter{
+		symbolTable:     symbolTable,
+		accessFlags:     access,
+		nameIndex:       symbolTable.AddConstantUtf8(name),
+		descriptorIndex: symbolTable.AddConstantUtf8(descriptor),
+	}
+	if signature != "" {
+		f.signatureIndex = symbolTable.AddConstantUtf8(signature)
+	}
+	if constantValue != nil {
+		constant, err := symbolTable.AddConstant(constantValue)
+		if err != nil {
+			f.err = err
+		} else {
+			f.constantValueIndex = constant.index
+		}
+	}
+	return f
+}
+
+// GetError returns the first error which occurred while writing this field (e.g. an unsupported constant value),
+// or an error if a constant of the field could not be added to the symbol table, or nil.
+func (f *FieldWriter) GetError() error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.symbolTable.GetError()
+}
+
+func (f *FieldWriter) VisitAnnotation(descriptor string, visible bool) AnnotationVisitor {
+	if visible {
+		f.lastRuntimeVisibleAnnotation = NewAnnotationWriter(f.symbolTable, descriptor, f.lastRuntimeVisibleAnnotation)
+		return f.lastRuntimeVisibleAnnotation
+	}
+	f.lastRuntimeInvisibleAnnotation = NewAnnotationWriter(f.symbolTable, descriptor, f.lastRuntimeInvisibleAnnotation)
+	return f.lastRuntimeInvisibleAnnotation
+}
+
+func (f *FieldWriter) VisitTypeAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if visible {
+		f.lastRuntimeVisibleTypeAnnotation = NewTypeAnnotationWriter(f.symbolTable, typeRef, typePath, descriptor, f.lastRuntimeVisibleTypeAnnotation)
+		return f.lastRuntimeVisibleTypeAnnotation
+	}
+	f.lastRuntimeInvisibleTypeAnnotation = NewTypeAnnotationWriter(f.symbolTable, typeRef, typePath, descriptor, f.lastRuntimeInvisibleTypeAnnotation)
+	return f.lastRuntimeInvisibleTypeAnnotation
+}
+
+func (f *FieldWriter) VisitAttribute(attribute *Attribute) {
+	if err := attribute.checkConstantPool(f.symbolTable); err != nil && f.err == nil {
+		f.err = err
+	}
+	attribute.nextAttribute = f.firstAttribute
+	f.firstAttribute = attribute
+}
+
+func (f *FieldWriter) VisitEnd() {
+}
+
+// useSyntheticAttribute returns whether the ACC_SYNTHETIC flag must be written as a Synthetic attribute.
+func (f *FieldWriter) useSyntheticAttribute() bool {
+	return f.symbolTable.GetMajorVersion() != 0 && f.symbolTable.GetMajorVersion() < opcodes.V1_5
+}
+
+// ComputeFieldInfoSize returns the size of the field_info structure of this field, and adds the names of its
+// attributes to the constant pool.
+func (f *FieldWriter) ComputeFieldInfoSize() int {
+	size := 8
+	if f.constantValueIndex != 0 {
+		f.symbolTable.AddConstantUtf8("ConstantValue")
+		size += 8
+	}
+	if (f.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && f.useSyntheticAttribute() {
+		f.symbolTable.AddConstantUtf8("Synthetic")
+		size += 6
+	}
+	if f.signatureIndex != 0 {
+		f.symbolTable.AddConstantUtf8("Signature")
+		size += 8
+	}
+	if (f.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		f.symbolTable.AddConstantUtf8("Deprecated")
+		size += 6
+	}
+	size += computeAnnotationAttributesSize(f.lastRuntimeVisibleAnnotation, f.lastRuntimeInvisibleAnnotation,
+		f.lastRuntimeVisibleTypeAnnotation, f.lastRuntimeInvisibleTypeAnnotation)
+	if f.firstAttribute != nil {
+		size += f.firstAttribute.computeAttributesSize(f.symbolTable)
+	}
+	return size
+}
+
+// PutFieldInfo puts the content of the field_info structure of this field into the given vector.
+func (f *FieldWriter) PutFieldInfo(output *ByteVector) {
+	mask := 0
+	if f.useSyntheticAttribute() {
+		mask = opcodes.ACC_SYNTHETIC
+	}
+	output.PutShort(f.accessFlags &^ mask).PutShort(f.nameIndex).PutShort(f.descriptorIndex)
+	attributesCount := 0
+	if f.constantValueIndex != 0 {
+		attributesCount++
+	}
+	if (f.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && f.useSyntheticAttribute() {
+		attributesCount++
+	}
+	if f.signatureIndex != 0 {
+		attributesCount++
+	}
+	if (f.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		attributesCount++
+	}
+	attributesCount += countAnnotationAttributes(f.lastRuntimeVisibleAnnotation, f.lastRuntimeInvisibleAnnotation,
+		f.lastRuntimeVisibleTypeAnnotation, f.lastRuntimeInvisibleTypeAnnotation)
+	if f.firstAttribute != nil {
+		attributesCount += f.firstAttribute.getAttributeCount()
+	}
+	output.PutShort(attributesCount)
+	if f.constantValueIndex != 0 {
+		output.PutShort(f.symbolTable.AddConstantUtf8("ConstantValue")).PutInt(2).PutShort(f.constantValueIndex)
+	}
+	if (f.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && f.useSyntheticAttribute() {
+		output.PutShort(f.symbolTable.AddConstantUtf8("Synthetic")).PutInt(0)
+	}
+	if f.signatureIndex != 0 {
+		output.PutShort(f.symbolTable.AddConstantUtf8("Signature")).PutInt(2).PutShort(f.signatureIndex)
+	}
+	if (f.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		output.PutShort(f.symbolTable.AddConstantUtf8("Deprecated")).PutInt(0)
+	}
+	putAnnotationAttributes(f.symbolTable, f.lastRuntimeVisibleAnnotation, f.lastRuntimeInvisibleAnnotation,
+		f.lastRuntimeVisibleTypeAnnotation, f.lastRuntimeInvisibleTypeAnnotation, output)
+	if f.firstAttribute != nil {
+		f.firstAttribute.putAttribute(f.symbolTable, output)
+	}
+}
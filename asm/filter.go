@@ -0,0 +1,49 @@
+package asm
+
+import "regexp"
+
+// Filter selects the fields and methods visited by {@link ClassReader#AcceptFiltered}. Members
+// rejected by the filter are skipped by the reader without parsing their attributes, which is much
+// cheaper than returning nil visitors from the {@link ClassVisitor}. A zero Filter accepts every
+// member.
+type Filter struct {
+	// MethodNames the names of the methods to visit. If empty, methods are not filtered by name.
+	MethodNames []string
+	// FieldNames the names of the fields to visit. If empty, fields are not filtered by name.
+	FieldNames []string
+	// AccessMask if non zero, only the members whose access flags share at least one bit with this
+	// mask are visited (see {@link Opcodes}).
+	AccessMask int
+	// DescriptorPattern if not nil, only the members whose descriptor matches this pattern are visited.
+	DescriptorPattern *regexp.Regexp
+}
+
+func (f *Filter) acceptField(access int, name, descriptor string) bool {
+	return f.accept(f.FieldNames, access, name, descriptor)
+}
+
+func (f *Filter) acceptMethod(access int, name, descriptor string) bool {
+	return f.accept(f.MethodNames, access, name, descriptor)
+}
+
+func (f *Filter) accept(names []string, access int, name, descriptor string) bool {
+	if len(names) > 0 {
+		found := false
+		for _, n := range names {
+			if n == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.AccessMask != 0 && (access&f.AccessMask) == 0 {
+		return false
+	}
+	if f.DescriptorPattern != nil && !f.DescriptorPattern.MatchString(descriptor) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package asm_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// filterClass returns a class A whose public and private members alternate, the private ones having a
+// ConstantValue, annotations, line numbers and a try catch block, followed by a SourceFile attribute.
+func filterClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "A", "java/lang/Object")
+	classFile.AddField(opcodes.ACC_PUBLIC, "a", "I", "", nil)
+	b := classFile.AddField(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC|opcodes.ACC_FINAL, "b", "J", "", int64(1)<<40)
+	b.VisitAnnotation("LB;", true).VisitEnd()
+	b.VisitEnd()
+	classFile.AddField(opcodes.ACC_PUBLIC, "c", "Ljava/lang/String;", "", nil)
+
+	for _, name := range []string{"m", "n", "o"} {
+		access := opcodes.ACC_PRIVATE
+		if name == "n" {
+			access = opcodes.ACC_PUBLIC
+		}
+		methodVisitor := classFile.AddMethod(access, name, "(I)I", "", nil)
+		methodVisitor.VisitAnnotation("LM;", false).VisitEnd()
+		methodVisitor.VisitCode()
+		start, end, handler := &asm.Label{}, &asm.Label{}, &asm.Label{}
+		methodVisitor.VisitTryCatchBlock(start, end, handler, "java/lang/Exception")
+		methodVisitor.VisitLabel(start)
+		methodVisitor.VisitLineNumber(1, start)
+		methodVisitor.VisitVarInsn(opcodes.ILOAD, 1)
+		methodVisitor.VisitLabel(end)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitLabel(handler)
+		methodVisitor.VisitInsn(opcodes.ICONST_0)
+		methodVisitor.VisitInsn(opcodes.IRETURN)
+		methodVisitor.VisitMaxs(1, 2)
+		methodVisitor.VisitEnd()
+	}
+	sourceFile := classFile.SymbolTable.AddConstantUtf8("A.java")
+	classFile.AddAttribute("SourceFile", []byte{byte(sourceFile >> 8), byte(sourceFile)})
+	return classFile.Bytes()
+}
+
+// labelPattern matches the label names of a trace, which depend on the visited labels.
+var labelPattern = regexp.MustCompile(`\bL[0-9]+\b`)
+
+// filteredTrace returns the trace of the given class, visited with the given filter.
+func filteredTrace(t *testing.T, classFile []byte, filter *asm.Filter) []string {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := asmtest.NewRecorder(nil)
+	if filter == nil {
+		reader.Accept(recorder, 0)
+	} else {
+		reader.AcceptFiltered(recorder, *filter, 0)
+	}
+	return recorder.Trace()
+}
+
+// withoutMembers returns the given trace without the events of the given members, given by their name and
+// descriptor.
+func withoutMembers(trace []string, members ...[2]string) []string {
+	var result []string
+	for _, line := range trace {
+		kept := true
+		for _, member := range members {
+			if strings.Contains(line, `"`+member[0]+`" "`+member[1]+`"`) ||
+				strings.Contains(line, " A."+member[0]+member[1]) || strings.Contains(line, " A."+member[0]+" "+member[1]) {
+				kept = false
+			}
+		}
+		if kept {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func TestAcceptFiltered(t *testing.T) {
+	classFile := filterClass()
+	trace := filteredTrace(t, classFile, nil)
+	for _, test := range []struct {
+		filter  asm.Filter
+		skipped [][2]string
+	}{
+		{asm.Filter{}, nil},
+		{asm.Filter{AccessMask: opcodes.ACC_PUBLIC}, [][2]string{{"b", "J"}, {"m", "(I)I"}, {"o", "(I)I"}}},
+		{asm.Filter{MethodNames: []string{"m", "o"}, FieldNames: []string{"b"}},
+			[][2]string{{"a", "I"}, {"c", "Ljava/lang/String;"}, {"n", "(I)I"}}},
+		{asm.Filter{DescriptorPattern: regexp.MustCompile("^L")},
+			[][2]string{{"a", "I"}, {"b", "J"}, {"m", "(I)I"}, {"n", "(I)I"}, {"o", "(I)I"}}},
+	} {
+		expected := strings.Join(withoutMembers(trace, test.skipped...), "\n")
+		// The skipped members are not visited, and the members and attributes after them are fully visited.
+		actual := strings.Join(filteredTrace(t, classFile, &test.filter), "\n")
+		if labelPattern.ReplaceAllString(actual, "L") != labelPattern.ReplaceAllString(expected, "L") {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", test.filter, expected, actual)
+		}
+	}
+	if !strings.Contains(strings.Join(trace, "\n"), `class visit source A "A.java"`) {
+		t.Errorf("missing source event:\n%s", strings.Join(trace, "\n"))
+	}
+}
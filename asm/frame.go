@@ -1,13 +1,809 @@
 package asm
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/frame"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Frame the input and output stack map frames of a basic block, used by the {@link COMPUTE_FRAMES} option. The
+// types of the frames are abstract types, encoded as in the frame package (see {@link frame.DIM_MASK}): the
+// input frame contains only concrete types (constants, references and uninitialized types), while the output
+// frame may also contain types which are relative to the input frame (LOCAL_KIND and STACK_KIND), because the
+// output frame is computed while the instructions of the block are visited, before the input frame is known.
+// The input frames are then computed with a fix point algorithm on the control flow graph, by merging the
+// output frames of the predecessors of each block (see {@link merge}).
 type Frame struct {
-	owner               *Label
-	inputLocals         []int
-	inputStack          []int
-	outputLocals        []int
-	outputStack         []int
-	outputStackStart    int16
-	outputStackTop      int16
+	// owner the basic block to which these input and output stack map frames correspond.
+	owner       *Label
+	inputLocals []uint32
+	inputStack  []uint32
+	// outputLocals the output local variable types. A 0 value means the local variable was not modified in the
+	// basic block (its type is the input type).
+	outputLocals []uint32
+	// outputStack the output stack types, on top of the input stack elements which are not popped by the block.
+	outputStack []uint32
+	// outputStackStart the start of the output stack, relatively to the input stack (negative or zero: it is the
+	// opposite of the number of input stack elements popped by the block).
+	outputStackStart int16
+	// outputStackTop the number of elements of outputStack.
+	outputStackTop int16
+	// initializations the abstract types which are initialized in the basic block, by a constructor call.
 	initializationCount int
-	initializations     []int
+	initializations     []uint32
+}
+
+// newFrame constructs a new {@link Frame} for the given basic block.
+func newFrame(owner *Label) *Frame {
+	return &Frame{owner: owner}
+}
+
+// frameType an entry of a {@link frameTypeTable}: an internal name, or an uninitialized type created by the
+// given NEW instruction (designated by the index of its event in the visited code).
+type frameType struct {
+	value    string
+	newEvent int
+	label    *Label
+}
+
+// frameTypeTable the table of the internal names referenced by the REFERENCE_KIND and UNINITIALIZED_KIND
+// abstract types, which hold the index of an entry of this table in their VALUE bits.
+type frameTypeTable struct {
+	className string
+	types     []frameType
+	indexes   map[string]int
+	merged    map[[2]int]int
+	// getCommonSuperClass returns the common super class of the two given classes (see
+	// {@link FramesComputer#GetCommonSuperClass}).
+	getCommonSuperClass func(type1, type2 string) string
+}
+
+func newFrameTypeTable(className string, getCommonSuperClass func(type1, type2 string) string) *frameTypeTable {
+	return &frameTypeTable{
+		className:           className,
+		indexes:             make(map[string]int),
+		merged:              make(map[[2]int]int),
+		getCommonSuperClass: getCommonSuperClass,
+	}
+}
+
+// addType returns the index of the given internal name in the table, adding it if necessary.
+func (t *frameTypeTable) addType(internalName string) uint32 {
+	return t.add(internalName, frameType{value: internalName, newEvent: -1})
+}
+
+// addUninitializedType returns the index of the uninitialized type created by the given NEW instruction.
+func (t *frameTypeTable) addUninitializedType(internalName string, newEvent int, label *Label) uint32 {
+	return t.add(internalName+"#"+strconv.Itoa(newEvent), frameType{value: internalName, newEvent: newEvent, label: label})
+}
+
+func (t *frameTypeTable) add(key string, entry frameType) uint32 {
+	index, ok := t.indexes[key]
+	if !ok {
+		index = len(t.types)
+		t.types = append(t.types, entry)
+		t.indexes[key] = index
+	}
+	return uint32(index)
+}
+
+// addMergedType returns the index of the common super class of the two given types of the table.
+func (t *frameTypeTable) addMergedType(typeIndex1, typeIndex2 uint32) uint32 {
+	key := [2]int{int(min(typeIndex1, typeIndex2)), int(max(typeIndex1, typeIndex2))}
+	if index, ok := t.merged[key]; ok {
+		return uint32(index)
+	}
+	commonSuperClass := "java/lang/Object"
+	if t.getCommonSuperClass != nil {
+		commonSuperClass = t.getCommonSuperClass(t.types[typeIndex1].value, t.types[typeIndex2].value)
+	}
+	index := t.addType(commonSuperClass)
+	t.merged[key] = int(index)
+	return index
+}
+
+// getAbstractTypeFromInternalName returns the abstract type corresponding to the given internal name (or array
+// descriptor).
+func getAbstractTypeFromInternalName(typeTable *frameTypeTable, internalName string) uint32 {
+	if internalName[0] == '[' {
+		return getAbstractTypeFromDescriptor(typeTable, internalName)
+	}
+	return frame.REFERENCE_KIND | typeTable.addType(internalName)
+}
+
+// getAbstractTypeFromDescriptor returns the abstract type corresponding to the given field descriptor, or 0 for
+// the void type.
+func getAbstractTypeFromDescriptor(typeTable *frameTypeTable, descriptor string) uint32 {
+	switch descriptor[0] {
+	case 'V':
+		return 0
+	case 'Z', 'C', 'B', 'S', 'I':
+		return frame.INTEGER
+	case 'F':
+		return frame.FLOAT
+	case 'J':
+		return frame.LONG
+	case 'D':
+		return frame.DOUBLE
+	case 'L':
+		return frame.REFERENCE_KIND | typeTable.addType(descriptor[1:len(descriptor)-1])
+	}
+	dimensions := strings.LastIndexByte(descriptor, '[') + 1
+	var elementType uint32
+	switch descriptor[dimensions] {
+	case 'Z':
+		elementType = frame.BOOLEAN
+	case 'C':
+		elementType = frame.CHAR
+	case 'B':
+		elementType = frame.BYTE
+	case 'S':
+		elementType = frame.SHORT
+	case 'I':
+		elementType = frame.INTEGER
+	case 'F':
+		elementType = frame.FLOAT
+	case 'J':
+		elementType = frame.LONG
+	case 'D':
+		elementType = frame.DOUBLE
+	default:
+		elementType = frame.REFERENCE_KIND | typeTable.addType(descriptor[dimensions+1:len(descriptor)-1])
+	}
+	return uint32(dimensions)<<frame.DIM_SHIFT | elementType
+}
+
+// setInputFrameFromDescriptor sets the input frame from the given method description (this is the input frame
+// of the first basic block of the method).
+func (f *Frame) setInputFrameFromDescriptor(typeTable *frameTypeTable, access int, name, descriptor string, maxLocals int) {
+	f.inputLocals = make([]uint32, maxLocals)
+	f.inputStack = []uint32{}
+	inputLocalIndex := 0
+	if (access & opcodes.ACC_STATIC) == 0 {
+		if name == "<init>" {
+			f.inputLocals[inputLocalIndex] = frame.UNINITIALIZED_THIS
+		} else {
+			f.inputLocals[inputLocalIndex] = frame.REFERENCE_KIND | typeTable.addType(typeTable.className)
+		}
+		inputLocalIndex++
+	}
+	for _, argumentType := range GetMethodType(descriptor).GetArgumentTypes() {
+		abstractType := getAbstractTypeFromDescriptor(typeTable, argumentType.GetDescriptor())
+		f.inputLocals[inputLocalIndex] = abstractType
+		inputLocalIndex++
+		if abstractType == frame.LONG || abstractType == frame.DOUBLE {
+			f.inputLocals[inputLocalIndex] = frame.TOP
+			inputLocalIndex++
+		}
+	}
+	for ; inputLocalIndex < maxLocals; inputLocalIndex++ {
+		f.inputLocals[inputLocalIndex] = frame.TOP
+	}
+}
+
+// getInputStackSize returns the size of the input stack of this frame.
+func (f *Frame) getInputStackSize() int {
+	return len(f.inputStack)
+}
+
+// getLocal returns the abstract type stored at the given local variable index in the output frame.
+func (f *Frame) getLocal(localIndex int) uint32 {
+	if localIndex >= len(f.outputLocals) {
+		// The local variable has never been assigned in this basic block, so it is still equal to its value in
+		// the input frame.
+		return frame.LOCAL_KIND | uint32(localIndex)
+	}
+	abstractType := f.outputLocals[localIndex]
+	if abstractType == 0 {
+		abstractType = frame.LOCAL_KIND | uint32(localIndex)
+		f.outputLocals[localIndex] = abstractType
+	}
+	return abstractType
+}
+
+// setLocal replaces the abstract type stored at the given local variable index in the output frame.
+func (f *Frame) setLocal(localIndex int, abstractType uint32) {
+	if localIndex >= len(f.outputLocals) {
+		outputLocals := make([]uint32, max(localIndex+1, 2*len(f.outputLocals)))
+		copy(outputLocals, f.outputLocals)
+		f.outputLocals = outputLocals
+	}
+	f.outputLocals[localIndex] = abstractType
+}
+
+// push pushes the given abstract type on the output frame stack.
+func (f *Frame) push(abstractType uint32) {
+	if int(f.outputStackTop) >= len(f.outputStack) {
+		outputStack := make([]uint32, max(int(f.outputStackTop)+1, 2*len(f.outputStack)))
+		copy(outputStack, f.outputStack)
+		f.outputStack = outputStack
+	}
+	f.outputStack[f.outputStackTop] = abstractType
+	f.outputStackTop++
+	outputStackSize := f.outputStackStart + f.outputStackTop
+	if outputStackSize > f.owner.outputStackMax {
+		f.owner.outputStackMax = outputStackSize
+	}
+}
+
+// pushDescriptor pushes the abstract type corresponding to the given field descriptor, or to the return type
+// of the given method descriptor, on the output frame stack.
+func (f *Frame) pushDescriptor(typeTable *frameTypeTable, descriptor string) {
+	if descriptor[0] == '(' {
+		descriptor = descriptor[strings.IndexByte(descriptor, ')')+1:]
+	}
+	abstractType := getAbstractTypeFromDescriptor(typeTable, descriptor)
+	if abstractType != 0 {
+		f.push(abstractType)
+		if abstractType == frame.LONG || abstractType == frame.DOUBLE {
+			f.push(frame.TOP)
+		}
+	}
+}
+
+// pop pops an abstract type from the output frame stack and returns it.
+func (f *Frame) pop() uint32 {
+	if f.outputStackTop > 0 {
+		f.outputStackTop--
+		return f.outputStack[f.outputStackTop]
+	}
+	// If the output frame stack is empty, pop from the input stack.
+	f.outputStackStart--
+	return frame.STACK_KIND | uint32(-f.outputStackStart)
+}
+
+// popN pops the given number of abstract types from the output frame stack.
+func (f *Frame) popN(elements int) {
+	if int(f.outputStackTop) >= elements {
+		f.outputStackTop -= int16(elements)
+	} else {
+		// If the number of elements to be popped is greater than the number of elements in the output stack,
+		// clear it, and pop the remaining elements from the input stack.
+		f.outputStackStart -= int16(elements) - f.outputStackTop
+		f.outputStackTop = 0
+	}
+}
+
+// popDescriptor pops as many abstract types from the output frame stack as described by the given field
+// descriptor, or by the argument types of the given method descriptor.
+func (f *Frame) popDescriptor(descriptor string) {
+	switch descriptor[0] {
+	case '(':
+		size := 0
+		for _, argumentType := range GetMethodType(descriptor).GetArgumentTypes() {
+			size += argumentType.GetSize()
+		}
+		f.popN(size)
+	case 'J', 'D':
+		f.popN(2)
+	default:
+		f.popN(1)
+	}
+}
+
+// addInitializedType adds an abstract type to the list of types on which a constructor is invoked in the basic
+// block.
+func (f *Frame) addInitializedType(abstractType uint32) {
+	f.initializations = append(f.initializations[:f.initializationCount], abstractType)
+	f.initializationCount++
+}
+
+// getInitializedType returns the "initialized" abstract type corresponding to the given abstract type, if a
+// constructor is invoked on it in the basic block, or the given abstract type otherwise.
+func (f *Frame) getInitializedType(typeTable *frameTypeTable, abstractType uint32) uint32 {
+	if abstractType != frame.UNINITIALIZED_THIS && (abstractType&(frame.DIM_MASK|frame.KIND_MASK)) != frame.UNINITIALIZED_KIND {
+		return abstractType
+	}
+	for i := 0; i < f.initializationCount; i++ {
+		initializedType := f.initializations[i]
+		dim := initializedType & frame.DIM_MASK
+		kind := initializedType & frame.KIND_MASK
+		value := initializedType & frame.VALUE_MASK
+		if kind == frame.LOCAL_KIND {
+			initializedType = dim + f.inputLocals[value]
+		} else if kind == frame.STACK_KIND {
+			initializedType = dim + f.inputStack[len(f.inputStack)-int(value)]
+		}
+		if abstractType == initializedType {
+			if abstractType == frame.UNINITIALIZED_THIS {
+				return frame.REFERENCE_KIND | typeTable.addType(typeTable.className)
+			}
+			return frame.REFERENCE_KIND | typeTable.addType(typeTable.types[abstractType&frame.VALUE_MASK].value)
+		}
+	}
+	return abstractType
+}
+
+// executeStore simulates the store of the given abstract type in the given local variable (and the following
+// one, for long and double values).
+func (f *Frame) executeStore(localIndex int, abstractType uint32, size int) {
+	f.setLocal(localIndex, abstractType)
+	if size == 2 {
+		f.setLocal(localIndex+1, frame.TOP)
+	}
+	if localIndex > 0 {
+		previousLocalType := f.getLocal(localIndex - 1)
+		if previousLocalType == frame.LONG || previousLocalType == frame.DOUBLE {
+			// If the previous local variable holds a long or double value, it is invalidated.
+			f.setLocal(localIndex-1, frame.TOP)
+		} else if (previousLocalType&frame.KIND_MASK) == frame.LOCAL_KIND || (previousLocalType&frame.KIND_MASK) == frame.STACK_KIND {
+			// If it is a relative type, it must be invalidated if it is a long or double value, which is only
+			// known when the input frame is.
+			f.setLocal(localIndex-1, previousLocalType|frame.TOP_IF_LONG_OR_DOUBLE_FLAG)
+		}
+	}
+}
+
+// execute simulates the action of the given instruction on the output stack frame. The argument is the local
+// variable index of the var and iinc instructions, the NEW instruction event index, or the operand of the
+// NEWARRAY and MULTIANEWARRAY instructions. The operand is the LDC constant, or the type descriptor (or
+// internal name) of the field, method and type instructions.
+func (f *Frame) execute(opcode, arg int, operand interface{}, name string, typeTable *frameTypeTable) error {
+	switch opcode {
+	case opcodes.NOP, opcodes.INEG, opcodes.LNEG, opcodes.FNEG, opcodes.DNEG, opcodes.I2B, opcodes.I2C, opcodes.I2S,
+		opcodes.GOTO, opcodes.RETURN:
+	case opcodes.ACONST_NULL:
+		f.push(frame.NULL)
+	case opcodes.ICONST_M1, opcodes.ICONST_0, opcodes.ICONST_1, opcodes.ICONST_2, opcodes.ICONST_3, opcodes.ICONST_4,
+		opcodes.ICONST_5, opcodes.BIPUSH, opcodes.SIPUSH, opcodes.ILOAD:
+		f.push(frame.INTEGER)
+	case opcodes.LCONST_0, opcodes.LCONST_1, opcodes.LLOAD:
+		f.push(frame.LONG)
+		f.push(frame.TOP)
+	case opcodes.FCONST_0, opcodes.FCONST_1, opcodes.FCONST_2, opcodes.FLOAD:
+		f.push(frame.FLOAT)
+	case opcodes.DCONST_0, opcodes.DCONST_1, opcodes.DLOAD:
+		f.push(frame.DOUBLE)
+		f.push(frame.TOP)
+	case opcodes.LDC:
+		switch value := operand.(type) {
+		case int, int32, int8, int16, bool:
+			f.push(frame.INTEGER)
+		case int64:
+			f.push(frame.LONG)
+			f.push(frame.TOP)
+		case float32:
+			f.push(frame.FLOAT)
+		case float64:
+			f.push(frame.DOUBLE)
+			f.push(frame.TOP)
+		case string:
+			f.push(frame.REFERENCE_KIND | typeTable.addType("java/lang/String"))
+		case *Type:
+			if value.GetSort() == typed.METHOD {
+				f.push(frame.REFERENCE_KIND | typeTable.addType("java/lang/invoke/MethodType"))
+			} else {
+				f.push(frame.REFERENCE_KIND | typeTable.addType("java/lang/Class"))
+			}
+		case *Handle:
+			f.push(frame.REFERENCE_KIND | typeTable.addType("java/lang/invoke/MethodHandle"))
+		default:
+			return errors.New("Illegal Argument - unsupported LDC constant type " + fmt.Sprintf("%T", operand))
+		}
+	case opcodes.ALOAD:
+		f.push(f.getLocal(arg))
+	case opcodes.LALOAD, opcodes.D2L:
+		f.popN(2)
+		f.push(frame.LONG)
+		f.push(frame.TOP)
+	case opcodes.DALOAD, opcodes.L2D:
+		f.popN(2)
+		f.push(frame.DOUBLE)
+		f.push(frame.TOP)
+	case opcodes.AALOAD:
+		f.popN(1)
+		abstractType := f.pop()
+		if abstractType != frame.NULL {
+			abstractType -= frame.ARRAY_OF
+		}
+		f.push(abstractType)
+	case opcodes.ISTORE, opcodes.FSTORE, opcodes.ASTORE:
+		f.executeStore(arg, f.pop(), 1)
+	case opcodes.LSTORE, opcodes.DSTORE:
+		f.popN(1)
+		f.executeStore(arg, f.pop(), 2)
+	case opcodes.IASTORE, opcodes.BASTORE, opcodes.CASTORE, opcodes.SASTORE, opcodes.FASTORE, opcodes.AASTORE:
+		f.popN(3)
+	case opcodes.LASTORE, opcodes.DASTORE:
+		f.popN(4)
+	case opcodes.POP, opcodes.IFEQ, opcodes.IFNE, opcodes.IFLT, opcodes.IFGE, opcodes.IFGT, opcodes.IFLE,
+		opcodes.IRETURN, opcodes.FRETURN, opcodes.ARETURN, opcodes.TABLESWITCH, opcodes.LOOKUPSWITCH, opcodes.ATHROW,
+		opcodes.MONITORENTER, opcodes.MONITOREXIT, opcodes.IFNULL, opcodes.IFNONNULL:
+		f.popN(1)
+	case opcodes.POP2, opcodes.IF_ICMPEQ, opcodes.IF_ICMPNE, opcodes.IF_ICMPLT, opcodes.IF_ICMPGE, opcodes.IF_ICMPGT,
+		opcodes.IF_ICMPLE, opcodes.IF_ACMPEQ, opcodes.IF_ACMPNE, opcodes.LRETURN, opcodes.DRETURN:
+		f.popN(2)
+	case opcodes.DUP:
+		abstractType1 := f.pop()
+		f.push(abstractType1)
+		f.push(abstractType1)
+	case opcodes.DUP_X1:
+		abstractType1 := f.pop()
+		abstractType2 := f.pop()
+		f.push(abstractType1)
+		f.push(abstractType2)
+		f.push(abstractType1)
+	case opcodes.DUP_X2:
+		abstractType1 := f.pop()
+		abstractType2 := f.pop()
+		abstractType3 := f.pop()
+		f.push(abstractType1)
+		f.push(abstractType3)
+		f.push(abstractType2)
+		f.push(abstractType1)
+	case opcodes.DUP2:
+		abstractType1 := f.pop()
+		abstractType2 := f.pop()
+		f.push(abstractType2)
+		f.push(abstractType1)
+		f.push(abstractType2)
+		f.push(abstractType1)
+	case opcodes.DUP2_X1:
+		abstractType1 := f.pop()
+		abstractType2 := f.pop()
+		abstractType3 := f.pop()
+		f.push(abstractType2)
+		f.push(abstractType1)
+		f.push(abstractType3)
+		f.push(abstractType2)
+		f.push(abstractType1)
+	case opcodes.DUP2_X2:
+		abstractType1 := f.pop()
+		abstractType2 := f.pop()
+		abstractType3 := f.pop()
+		abstractType4 := f.pop()
+		f.push(abstractType2)
+		f.push(abstractType1)
+		f.push(abstractType4)
+		f.push(abstractType3)
+		f.push(abstractType2)
+		f.push(abstractType1)
+	case opcodes.SWAP:
+		abstractType1 := f.pop()
+		abstractType2 := f.pop()
+		f.push(abstractType1)
+		f.push(abstractType2)
+	case opcodes.IALOAD, opcodes.BALOAD, opcodes.CALOAD, opcodes.SALOAD, opcodes.IADD, opcodes.ISUB, opcodes.IMUL,
+		opcodes.IDIV, opcodes.IREM, opcodes.IAND, opcodes.IOR, opcodes.IXOR, opcodes.ISHL, opcodes.ISHR, opcodes.IUSHR,
+		opcodes.L2I, opcodes.D2I, opcodes.FCMPL, opcodes.FCMPG:
+		f.popN(2)
+		f.push(frame.INTEGER)
+	case opcodes.LADD, opcodes.LSUB, opcodes.LMUL, opcodes.LDIV, opcodes.LREM, opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		f.popN(4)
+		f.push(frame.LONG)
+		f.push(frame.TOP)
+	case opcodes.FALOAD, opcodes.FADD, opcodes.FSUB, opcodes.FMUL, opcodes.FDIV, opcodes.FREM, opcodes.L2F, opcodes.D2F:
+		f.popN(2)
+		f.push(frame.FLOAT)
+	case opcodes.DADD, opcodes.DSUB, opcodes.DMUL, opcodes.DDIV, opcodes.DREM:
+		f.popN(4)
+		f.push(frame.DOUBLE)
+		f.push(frame.TOP)
+	case opcodes.LSHL, opcodes.LSHR, opcodes.LUSHR:
+		f.popN(3)
+		f.push(frame.LONG)
+		f.push(frame.TOP)
+	case opcodes.IINC:
+		f.setLocal(arg, frame.INTEGER)
+	case opcodes.I2L, opcodes.F2L:
+		f.popN(1)
+		f.push(frame.LONG)
+		f.push(frame.TOP)
+	case opcodes.I2F:
+		f.popN(1)
+		f.push(frame.FLOAT)
+	case opcodes.I2D, opcodes.F2D:
+		f.popN(1)
+		f.push(frame.DOUBLE)
+		f.push(frame.TOP)
+	case opcodes.F2I, opcodes.ARRAYLENGTH, opcodes.INSTANCEOF:
+		f.popN(1)
+		f.push(frame.INTEGER)
+	case opcodes.LCMP, opcodes.DCMPL, opcodes.DCMPG:
+		f.popN(4)
+		f.push(frame.INTEGER)
+	case opcodes.JSR, opcodes.RET:
+		return errors.New("Illegal Argument - JSR/RET are not supported with the COMPUTE_FRAMES option")
+	case opcodes.GETSTATIC:
+		f.pushDescriptor(typeTable, operand.(string))
+	case opcodes.PUTSTATIC:
+		f.popDescriptor(operand.(string))
+	case opcodes.GETFIELD:
+		f.popN(1)
+		f.pushDescriptor(typeTable, operand.(string))
+	case opcodes.PUTFIELD:
+		f.popDescriptor(operand.(string))
+		f.pop()
+	case opcodes.INVOKEVIRTUAL, opcodes.INVOKESPECIAL, opcodes.INVOKESTATIC, opcodes.INVOKEINTERFACE:
+		descriptor := operand.(string)
+		f.popDescriptor(descriptor)
+		if opcode != opcodes.INVOKESTATIC {
+			abstractType := f.pop()
+			if opcode == opcodes.INVOKESPECIAL && name == "<init>" {
+				f.addInitializedType(abstractType)
+			}
+		}
+		f.pushDescriptor(typeTable, descriptor)
+	case opcodes.INVOKEDYNAMIC:
+		f.popDescriptor(operand.(string))
+		f.pushDescriptor(typeTable, operand.(string))
+	case opcodes.NEW:
+		f.push(frame.UNINITIALIZED_KIND | typeTable.addUninitializedType(operand.(string), arg, nil))
+	case opcodes.NEWARRAY:
+		f.pop()
+		switch arg {
+		case opcodes.T_BOOLEAN:
+			f.push(frame.ARRAY_OF | frame.BOOLEAN)
+		case opcodes.T_CHAR:
+			f.push(frame.ARRAY_OF | frame.CHAR)
+		case opcodes.T_BYTE:
+			f.push(frame.ARRAY_OF | frame.BYTE)
+		case opcodes.T_SHORT:
+			f.push(frame.ARRAY_OF | frame.SHORT)
+		case opcodes.T_INT:
+			f.push(frame.ARRAY_OF | frame.INTEGER)
+		case opcodes.T_FLOAT:
+			f.push(frame.ARRAY_OF | frame.FLOAT)
+		case opcodes.T_DOUBLE:
+			f.push(frame.ARRAY_OF | frame.DOUBLE)
+		case opcodes.T_LONG:
+			f.push(frame.ARRAY_OF | frame.LONG)
+		default:
+			return errors.New("Illegal Argument - invalid NEWARRAY operand " + strconv.Itoa(arg))
+		}
+	case opcodes.ANEWARRAY:
+		elementType := operand.(string)
+		f.pop()
+		if elementType[0] == '[' {
+			f.pushDescriptor(typeTable, "["+elementType)
+		} else {
+			f.push(frame.ARRAY_OF | frame.REFERENCE_KIND | typeTable.addType(elementType))
+		}
+	case opcodes.CHECKCAST:
+		castType := operand.(string)
+		f.pop()
+		if castType[0] == '[' {
+			f.pushDescriptor(typeTable, castType)
+		} else {
+			f.push(frame.REFERENCE_KIND | typeTable.addType(castType))
+		}
+	case opcodes.MULTIANEWARRAY:
+		f.popN(arg)
+		f.pushDescriptor(typeTable, operand.(string))
+	default:
+		return errors.New("Illegal Argument - invalid opcode " + strconv.Itoa(opcode))
+	}
+	return nil
+}
+
+// getConcreteOutputType returns the concrete type corresponding to the given abstract output type, given the
+// input frame of the block.
+func (f *Frame) getConcreteOutputType(abstractOutputType uint32, numStack int) uint32 {
+	dim := abstractOutputType & frame.DIM_MASK
+	kind := abstractOutputType & frame.KIND_MASK
+	var concreteOutputType uint32
+	switch kind {
+	case frame.LOCAL_KIND:
+		concreteOutputType = dim + f.inputLocals[abstractOutputType&frame.VALUE_MASK]
+	case frame.STACK_KIND:
+		concreteOutputType = dim + f.inputStack[numStack-int(abstractOutputType&frame.VALUE_MASK)]
+	default:
+		return abstractOutputType
+	}
+	if (abstractOutputType&frame.TOP_IF_LONG_OR_DOUBLE_FLAG) != 0 &&
+		(concreteOutputType == frame.LONG || concreteOutputType == frame.DOUBLE) {
+		concreteOutputType = frame.TOP
+	}
+	return concreteOutputType
+}
+
+// merge merges the input frame of the given frame with the input and output frames of this frame, and returns
+// whether the given frame has been changed. The catch type is the abstract type of the exception of the handler
+// block, if the given frame is an exception handler (or 0 for a normal successor).
+func (f *Frame) merge(typeTable *frameTypeTable, dstFrame *Frame, catchType uint32) bool {
+	frameChanged := false
+	numLocal := len(f.inputLocals)
+	numStack := len(f.inputStack)
+	if dstFrame.inputLocals == nil {
+		dstFrame.inputLocals = make([]uint32, numLocal)
+		frameChanged = true
+	}
+	for i := 0; i < numLocal; i++ {
+		concreteOutputType := f.inputLocals[i]
+		if i < len(f.outputLocals) && f.outputLocals[i] != 0 {
+			concreteOutputType = f.getConcreteOutputType(f.outputLocals[i], numStack)
+		}
+		if f.initializationCount > 0 {
+			concreteOutputType = f.getInitializedType(typeTable, concreteOutputType)
+		}
+		frameChanged = mergeType(typeTable, concreteOutputType, dstFrame.inputLocals, i) || frameChanged
+	}
+
+	if catchType > 0 {
+		// The exception can be thrown by any instruction of the block, so the handler frame must be compatible
+		// with its input frame too, and its stack only contains the exception.
+		for i := 0; i < numLocal; i++ {
+			frameChanged = mergeType(typeTable, f.inputLocals[i], dstFrame.inputLocals, i) || frameChanged
+		}
+		if dstFrame.inputStack == nil {
+			dstFrame.inputStack = make([]uint32, 1)
+			frameChanged = true
+		}
+		return mergeType(typeTable, catchType, dstFrame.inputStack, 0) || frameChanged
+	}
+
+	numInputStack := len(f.inputStack) + int(f.outputStackStart)
+	if dstFrame.inputStack == nil {
+		dstFrame.inputStack = make([]uint32, numInputStack+int(f.outputStackTop))
+		frameChanged = true
+	}
+	for i := 0; i < numInputStack; i++ {
+		concreteOutputType := f.inputStack[i]
+		if f.initializationCount > 0 {
+			concreteOutputType = f.getInitializedType(typeTable, concreteOutputType)
+		}
+		frameChanged = mergeType(typeTable, concreteOutputType, dstFrame.inputStack, i) || frameChanged
+	}
+	for i := 0; i < int(f.outputStackTop); i++ {
+		concreteOutputType := f.getConcreteOutputType(f.outputStack[i], numStack)
+		if f.initializationCount > 0 {
+			concreteOutputType = f.getInitializedType(typeTable, concreteOutputType)
+		}
+		frameChanged = mergeType(typeTable, concreteOutputType, dstFrame.inputStack, numInputStack+i) || frameChanged
+	}
+	return frameChanged
+}
+
+// mergeType merges the given concrete type with the type at the given index of the given types, and returns
+// whether this type has been changed.
+func mergeType(typeTable *frameTypeTable, sourceType uint32, dstTypes []uint32, dstIndex int) bool {
+	dstType := dstTypes[dstIndex]
+	if dstType == sourceType {
+		return false
+	}
+	srcType := sourceType
+	if (sourceType &^ frame.DIM_MASK) == frame.NULL {
+		if dstType == frame.NULL {
+			return false
+		}
+		srcType = frame.NULL
+	}
+	if dstType == 0 {
+		dstTypes[dstIndex] = srcType
+		return true
+	}
+	isReference := func(abstractType uint32) bool {
+		return (abstractType&frame.DIM_MASK) != 0 || (abstractType&frame.KIND_MASK) == frame.REFERENCE_KIND
+	}
+	var mergedType uint32
+	if isReference(dstType) {
+		if srcType == frame.NULL {
+			return false
+		} else if (srcType & (frame.DIM_MASK | frame.KIND_MASK)) == (dstType & (frame.DIM_MASK | frame.KIND_MASK)) {
+			if (dstType & frame.KIND_MASK) == frame.REFERENCE_KIND {
+				mergedType = (srcType & frame.DIM_MASK) | frame.REFERENCE_KIND |
+					typeTable.addMergedType(srcType&frame.VALUE_MASK, dstType&frame.VALUE_MASK)
+			} else {
+				// Arrays of the same dimension of different primitive types: their common super type is an
+				// array of Object with one less dimension.
+				mergedType = ((srcType & frame.DIM_MASK) - frame.ARRAY_OF) | frame.REFERENCE_KIND |
+					typeTable.addType("java/lang/Object")
+			}
+		} else if isReference(srcType) {
+			// Arrays of different dimensions, or an array and an object: their common super type is an array of
+			// Object with the smallest of the dimensions (primitive arrays counting for one less dimension).
+			srcDim := srcType & frame.DIM_MASK
+			if srcDim != 0 && (srcType&frame.KIND_MASK) != frame.REFERENCE_KIND {
+				srcDim -= frame.ARRAY_OF
+			}
+			dstDim := dstType & frame.DIM_MASK
+			if dstDim != 0 && (dstType&frame.KIND_MASK) != frame.REFERENCE_KIND {
+				dstDim -= frame.ARRAY_OF
+			}
+			mergedType = min(srcDim, dstDim) | frame.REFERENCE_KIND | typeTable.addType("java/lang/Object")
+		} else {
+			mergedType = frame.TOP
+		}
+	} else if dstType == frame.NULL {
+		if isReference(srcType) {
+			mergedType = srcType
+		} else {
+			mergedType = frame.TOP
+		}
+	} else {
+		mergedType = frame.TOP
+	}
+	if mergedType != dstType {
+		dstTypes[dstIndex] = mergedType
+		return true
+	}
+	return false
+}
+
+// accept returns the input frame in the format of {@link MethodVisitor#VisitFrame} (with the {@link opcodes.F_NEW}
+// type): the number of local variables and their types, followed by the number of stack elements and their
+// types. The trailing TOP local variables are removed. The labels of the NEW instructions designated by the
+// uninitialized types are created by the given function, if necessary.
+func (f *Frame) accept(typeTable *frameTypeTable, newLabel func(typeIndex uint32) *Label) (int, []interface{}, int, []interface{}) {
+	numLocal, numTrailingTop := 0, 0
+	for i := 0; i < len(f.inputLocals); {
+		localType := f.inputLocals[i]
+		if localType == frame.LONG || localType == frame.DOUBLE {
+			i += 2
+		} else {
+			i++
+		}
+		if localType == frame.TOP {
+			numTrailingTop++
+		} else {
+			numLocal += numTrailingTop + 1
+			numTrailingTop = 0
+		}
+	}
+	locals := make([]interface{}, 0, numLocal)
+	for i := 0; len(locals) < numLocal; {
+		localType := f.inputLocals[i]
+		locals = append(locals, getApiType(typeTable, localType, newLabel))
+		if localType == frame.LONG || localType == frame.DOUBLE {
+			i += 2
+		} else {
+			i++
+		}
+	}
+	stack := []interface{}{}
+	for i := 0; i < len(f.inputStack); {
+		stackType := f.inputStack[i]
+		stack = append(stack, getApiType(typeTable, stackType, newLabel))
+		if stackType == frame.LONG || stackType == frame.DOUBLE {
+			i += 2
+		} else {
+			i++
+		}
+	}
+	return len(locals), locals, len(stack), stack
+}
+
+// getApiType returns the given concrete abstract type in the format of {@link MethodVisitor#VisitFrame}.
+func getApiType(typeTable *frameTypeTable, abstractType uint32, newLabel func(typeIndex uint32) *Label) interface{} {
+	arrayDimensions := int(abstractType >> frame.DIM_SHIFT)
+	value := abstractType & frame.VALUE_MASK
+	if arrayDimensions == 0 {
+		switch abstractType & frame.KIND_MASK {
+		case frame.REFERENCE_KIND:
+			return typeTable.types[value].value
+		case frame.UNINITIALIZED_KIND:
+			return newLabel(value)
+		}
+		switch value {
+		case frame.ITEM_ASM_BOOLEAN, frame.ITEM_ASM_BYTE, frame.ITEM_ASM_CHAR, frame.ITEM_ASM_SHORT:
+			return opcodes.INTEGER
+		}
+		return int(value)
+	}
+	descriptor := strings.Repeat("[", arrayDimensions)
+	if (abstractType & frame.KIND_MASK) == frame.REFERENCE_KIND {
+		return descriptor + "L" + typeTable.types[value].value + ";"
+	}
+	switch value {
+	case frame.ITEM_ASM_BOOLEAN:
+		return descriptor + "Z"
+	case frame.ITEM_ASM_BYTE:
+		return descriptor + "B"
+	case frame.ITEM_ASM_CHAR:
+		return descriptor + "C"
+	case frame.ITEM_ASM_SHORT:
+		return descriptor + "S"
+	case frame.ITEM_FLOAT:
+		return descriptor + "F"
+	case frame.ITEM_LONG:
+		return descriptor + "J"
+	case frame.ITEM_DOUBLE:
+		return descriptor + "D"
+	}
+	return descriptor + "I"
 }
@@ -0,0 +1,581 @@
+package asm
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm/constants"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// COMPUTE_FRAMES a flag to automatically compute the stack map frames of methods from scratch. If this flag is
+// set, then the calls to the {@link MethodVisitor#VisitFrame} method are ignored, and the stack map frames are
+// recomputed from the methods bytecode. The arguments of the {@link MethodVisitor#VisitMaxs} method are also
+// ignored and recomputed from the bytecode. In other words, COMPUTE_FRAMES implies {@link COMPUTE_MAXS} (see
+// {@link FramesComputer}).
+const COMPUTE_FRAMES = 2
+
+// framesEvent a code event buffered by a {@link FramesComputer}, replayed once the frames are computed.
+type framesEvent struct {
+	visit func(methodVisitor MethodVisitor)
+	// insn whether the event is an instruction, removed if its basic block is unreachable.
+	insn  bool
+	block *Label
+	// label the label visited by this event, if any.
+	label *Label
+	// handler the handler of the try catch block visited (or annotated) by this event, if any.
+	handler *Label
+}
+
+// framesHandler an exception handler visited by a {@link FramesComputer}.
+type framesHandler struct {
+	start     *Label
+	end       *Label
+	handler   *Label
+	catchType string
+}
+
+// FramesComputer a {@link MethodVisitor} which computes the stack map frames of the visited method, and passes
+// them to the next visitor in the chain (as expanded frames, before the first instruction of each jump target
+// and exception handler), instead of the visited frames. This is the implementation of the
+// {@link COMPUTE_FRAMES} option: the abstract output frame of each basic block is computed while its
+// instructions are visited (see {@link Frame}), and the input frames are then computed with a fix point
+// algorithm on the control flow graph. The code of the method is buffered until {@link VisitMaxs}, which
+// forwards it with the computed frames and maxs. The unreachable instructions (which have no frame) are
+// removed. Methods with JSR and RET instructions are not supported: their code is forwarded unchanged, and the
+// error is reported to the Handler.
+type FramesComputer struct {
+	methodVisitor MethodVisitor
+	// GetCommonSuperClass if not nil, returns the internal name of the common super class of the two given
+	// classes, needed to merge the types of two control flow paths. Otherwise, java/lang/Object is used, which
+	// is correct for the merges of unrelated classes (and of interfaces) only.
+	GetCommonSuperClass func(type1, type2 string) string
+	// Handler if not nil, is called with each error found in the visited code.
+	Handler func(err error)
+	// Errors the errors found so far in the visited code.
+	Errors []error
+
+	owner      string
+	access     int
+	name       string
+	descriptor string
+	typeTable  *frameTypeTable
+	events     []framesEvent
+	handlers   []framesHandler
+	// firstBasicBlock the first basic block of the method. The next ones are linked with Label#nextBasicBlock.
+	firstBasicBlock *Label
+	lastBasicBlock  *Label
+	// currentBasicBlock the basic block of the current instruction, or nil if it is unreachable.
+	currentBasicBlock *Label
+	// currentBasicBlockEmpty whether no instruction has been visited in the current basic block yet (the
+	// labels visited in this case designate the same basic block).
+	currentBasicBlockEmpty bool
+	// lastLabel the label visited since the last instruction, if any.
+	lastLabel *Label
+	maxStack  int
+	maxLocals int
+}
+
+// NewFramesComputer constructs a new {@link FramesComputer} for the given method of the given class (given by
+// its internal name), delegating to the given method visitor (which may be nil).
+func NewFramesComputer(owner string, access int, name, descriptor string, methodVisitor MethodVisitor) *FramesComputer {
+	maxLocals := 0
+	if (access & opcodes.ACC_STATIC) == 0 {
+		maxLocals++
+	}
+	for _, argumentType := range GetMethodType(descriptor).GetArgumentTypes() {
+		maxLocals += argumentType.GetSize()
+	}
+	return &FramesComputer{
+		methodVisitor: methodVisitor,
+		owner:         owner,
+		access:        access,
+		name:          name,
+		descriptor:    descriptor,
+		maxLocals:     maxLocals,
+	}
+}
+
+// GetMaxStack returns the maximum stack size computed by the last {@link VisitMaxs} call.
+func (m *FramesComputer) GetMaxStack() int {
+	return m.maxStack
+}
+
+// GetMaxLocals returns the maximum number of local variables of the visited method, so far.
+func (m *FramesComputer) GetMaxLocals() int {
+	return m.maxLocals
+}
+
+func (m *FramesComputer) error(err error) {
+	m.Errors = append(m.Errors, err)
+	if m.Handler != nil {
+		m.Handler(err)
+	}
+}
+
+// record buffers the given code event.
+func (m *FramesComputer) record(event framesEvent) {
+	m.events = append(m.events, event)
+}
+
+// execute simulates the given instruction on the frame of the current basic block, if any, and buffers it.
+func (m *FramesComputer) execute(opcode, arg int, operand interface{}, name string, visit func(methodVisitor MethodVisitor)) {
+	if m.currentBasicBlock != nil {
+		if err := m.currentBasicBlock.frame.execute(opcode, arg, operand, name, m.typeTable); err != nil {
+			m.error(err)
+		}
+	}
+	m.record(framesEvent{visit: visit, insn: true, block: m.currentBasicBlock})
+	m.currentBasicBlockEmpty = false
+	m.lastLabel = nil
+}
+
+// addSuccessor adds the given block as a successor of the current basic block.
+func (m *FramesComputer) addSuccessor(successor *Label) {
+	m.currentBasicBlock.outgoingEdges = NewEdge(JUMP, successor, m.currentBasicBlock.outgoingEdges)
+}
+
+// startBasicBlock starts a new basic block with the given label, as a successor of the current block, if any. If
+// the current block is empty, the label designates it instead.
+func (m *FramesComputer) startBasicBlock(label *Label) {
+	if m.currentBasicBlock != nil && m.currentBasicBlockEmpty {
+		label.frame = m.currentBasicBlock.frame
+		return
+	}
+	label.frame = newFrame(label)
+	if m.currentBasicBlock != nil {
+		m.addSuccessor(label)
+	}
+	m.currentBasicBlock = label
+	m.currentBasicBlockEmpty = true
+	if m.lastBasicBlock != nil {
+		m.lastBasicBlock.nextBasicBlock = label
+	}
+	m.lastBasicBlock = label
+}
+
+// useLocal updates the maximum number of local variables with a use of the given local variable.
+func (m *FramesComputer) useLocal(vard, size int) {
+	if vard+size > m.maxLocals {
+		m.maxLocals = vard + size
+	}
+}
+
+func (m *FramesComputer) VisitParameter(name string, access int) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitParameter(name, access)
+	}
+}
+
+func (m *FramesComputer) VisitAnnotationDefault() AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitAnnotationDefault()
+	}
+	return nil
+}
+
+func (m *FramesComputer) VisitAnnotation(descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitAnnotation(descriptor, visible)
+	}
+	return nil
+}
+
+func (m *FramesComputer) VisitTypeAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (m *FramesComputer) VisitAnnotableParameterCount(parameterCount int, visible bool) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitAnnotableParameterCount(parameterCount, visible)
+	}
+}
+
+func (m *FramesComputer) VisitParameterAnnotation(parameter int, descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitParameterAnnotation(parameter, descriptor, visible)
+	}
+	return nil
+}
+
+func (m *FramesComputer) VisitAttribute(attribute *Attribute) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitAttribute(attribute)
+	}
+}
+
+func (m *FramesComputer) VisitCode() {
+	m.typeTable = newFrameTypeTable(m.owner, m.GetCommonSuperClass)
+	m.firstBasicBlock = &Label{}
+	m.startBasicBlock(m.firstBasicBlock)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitCode()
+	}
+}
+
+// VisitFrame ignores the given frame, which is recomputed.
+func (m *FramesComputer) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+}
+
+func (m *FramesComputer) VisitInsn(opcode int) {
+	m.execute(opcode, 0, nil, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitInsn(opcode)
+	})
+	if m.currentBasicBlock != nil && ((opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN) || opcode == opcodes.ATHROW) {
+		m.currentBasicBlock = nil
+	}
+}
+
+func (m *FramesComputer) VisitIntInsn(opcode, operand int) {
+	m.execute(opcode, operand, nil, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitIntInsn(opcode, operand)
+	})
+}
+
+func (m *FramesComputer) VisitVarInsn(opcode, vard int) {
+	m.execute(opcode, vard, nil, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitVarInsn(opcode, vard)
+	})
+	if opcode == opcodes.LLOAD || opcode == opcodes.DLOAD || opcode == opcodes.LSTORE || opcode == opcodes.DSTORE {
+		m.useLocal(vard, 2)
+	} else {
+		m.useLocal(vard, 1)
+	}
+}
+
+func (m *FramesComputer) VisitTypeInsn(opcode int, typed string) {
+	if opcode == opcodes.NEW {
+		// The uninitialized type is designated by the label of the NEW instruction (created when the frames
+		// are emitted if there is none).
+		m.typeTable.addUninitializedType(typed, len(m.events), m.lastLabel)
+	}
+	m.execute(opcode, len(m.events), typed, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitTypeInsn(opcode, typed)
+	})
+}
+
+func (m *FramesComputer) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.execute(opcode, 0, descriptor, name, func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitFieldInsn(opcode, owner, name, descriptor)
+	})
+}
+
+func (m *FramesComputer) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
+
+func (m *FramesComputer) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.execute(opcode, 0, descriptor, name, func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+	})
+}
+
+func (m *FramesComputer) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *Handle, bootstrapMethodArguments ...interface{}) {
+	m.execute(opcodes.INVOKEDYNAMIC, 0, descriptor, name, func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitInvokeDynamicInsn(name, descriptor, bootstrapMethodHande, bootstrapMethodArguments...)
+	})
+}
+
+func (m *FramesComputer) VisitJumpInsn(opcode int, label *Label) {
+	// GOTO_W and JSR_W behave like GOTO and JSR.
+	baseOpcode := opcode
+	if opcode >= constants.GOTO_W {
+		baseOpcode = opcode - constants.WIDE_JUMP_OPCODE_DELTA
+	}
+	m.execute(baseOpcode, 0, nil, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitJumpInsn(opcode, label)
+	})
+	label.flags |= FLAG_JUMP_TARGET
+	if m.currentBasicBlock != nil {
+		m.addSuccessor(label)
+		if baseOpcode == opcodes.GOTO {
+			m.currentBasicBlock = nil
+		} else {
+			// The instruction after a conditional jump starts a new basic block.
+			m.startBasicBlock(&Label{})
+		}
+	}
+}
+
+func (m *FramesComputer) VisitLabel(label *Label) {
+	m.startBasicBlock(label)
+	m.record(framesEvent{visit: func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitLabel(label)
+	}, label: label})
+	m.lastLabel = label
+}
+
+func (m *FramesComputer) VisitLdcInsn(value interface{}) {
+	m.execute(opcodes.LDC, 0, value, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitLdcInsn(value)
+	})
+}
+
+func (m *FramesComputer) VisitIincInsn(vard, increment int) {
+	m.execute(opcodes.IINC, vard, nil, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitIincInsn(vard, increment)
+	})
+	m.useLocal(vard, 1)
+}
+
+func (m *FramesComputer) VisitTableSwitchInsn(min, max int, dflt *Label, labels ...*Label) {
+	m.execute(opcodes.TABLESWITCH, 0, nil, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitTableSwitchInsn(min, max, dflt, labels...)
+	})
+	m.visitSwitchInsn(dflt, labels)
+}
+
+func (m *FramesComputer) VisitLookupSwitchInsn(dflt *Label, keys []int, labels []*Label) {
+	m.execute(opcodes.LOOKUPSWITCH, 0, nil, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitLookupSwitchInsn(dflt, keys, labels)
+	})
+	m.visitSwitchInsn(dflt, labels)
+}
+
+func (m *FramesComputer) visitSwitchInsn(dflt *Label, labels []*Label) {
+	for _, label := range append([]*Label{dflt}, labels...) {
+		label.flags |= FLAG_JUMP_TARGET
+		if m.currentBasicBlock != nil {
+			m.addSuccessor(label)
+		}
+	}
+	m.currentBasicBlock = nil
+}
+
+func (m *FramesComputer) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.execute(opcodes.MULTIANEWARRAY, numDimensions, descriptor, "", func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitMultiANewArrayInsn(descriptor, numDimensions)
+	})
+}
+
+func (m *FramesComputer) VisitInsnAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	annotation := &annotationRecorder{}
+	block := m.currentBasicBlock
+	m.record(framesEvent{visit: func(methodVisitor MethodVisitor) {
+		annotation.accept(methodVisitor.VisitInsnAnnotation(typeRef, typePath, descriptor, visible))
+	}, insn: true, block: block})
+	return annotation
+}
+
+func (m *FramesComputer) VisitTryCatchBlock(start, end, handler *Label, typed string) {
+	handler.flags |= FLAG_JUMP_TARGET
+	m.handlers = append(m.handlers, framesHandler{start, end, handler, typed})
+	m.record(framesEvent{visit: func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitTryCatchBlock(start, end, handler, typed)
+	}, handler: handler})
+}
+
+func (m *FramesComputer) VisitTryCatchAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	annotation := &annotationRecorder{}
+	var handler *Label
+	if len(m.handlers) > 0 {
+		handler = m.handlers[len(m.handlers)-1].handler
+	}
+	m.record(framesEvent{visit: func(methodVisitor MethodVisitor) {
+		annotation.accept(methodVisitor.VisitTryCatchAnnotation(typeRef, typePath, descriptor, visible))
+	}, handler: handler})
+	return annotation
+}
+
+func (m *FramesComputer) VisitLocalVariable(name, descriptor, signature string, start, end *Label, index int) {
+	m.record(framesEvent{visit: func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitLocalVariable(name, descriptor, signature, start, end, index)
+	}})
+}
+
+func (m *FramesComputer) VisitLocalVariableAnnotation(typeRef int, typePath *TypePath, start, end []*Label, index []int, descriptor string, visible bool) AnnotationVisitor {
+	annotation := &annotationRecorder{}
+	m.record(framesEvent{visit: func(methodVisitor MethodVisitor) {
+		annotation.accept(methodVisitor.VisitLocalVariableAnnotation(typeRef, typePath, start, end, index, descriptor, visible))
+	}})
+	return annotation
+}
+
+func (m *FramesComputer) VisitLineNumber(line int, start *Label) {
+	m.record(framesEvent{visit: func(methodVisitor MethodVisitor) {
+		methodVisitor.VisitLineNumber(line, start)
+	}})
+}
+
+func (m *FramesComputer) VisitMaxs(maxStack int, maxLocals int) {
+	if m.firstBasicBlock == nil {
+		if m.methodVisitor != nil {
+			m.methodVisitor.VisitMaxs(maxStack, maxLocals)
+		}
+		return
+	}
+	if len(m.Errors) == 0 {
+		if err := m.computeAllFrames(); err != nil {
+			m.error(err)
+		}
+	}
+	if len(m.Errors) > 0 {
+		// Forward the code unchanged.
+		if m.methodVisitor != nil {
+			for _, event := range m.events {
+				event.visit(m.methodVisitor)
+			}
+			m.methodVisitor.VisitMaxs(maxStack, maxLocals)
+		}
+		return
+	}
+	m.emit()
+}
+
+func (m *FramesComputer) VisitEnd() {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitEnd()
+	}
+}
+
+// computeAllFrames completes the control flow graph with the exception handler edges, and computes the input
+// frame of each reachable basic block, and the maximum stack size, with a fix point algorithm.
+func (m *FramesComputer) computeAllFrames() error {
+	for _, handler := range m.handlers {
+		catchType := handler.catchType
+		if catchType == "" {
+			catchType = "java/lang/Throwable"
+		}
+		abstractCatchType := getAbstractTypeFromInternalName(m.typeTable, catchType)
+		if handler.start.frame == nil || handler.end.frame == nil || handler.handler.frame == nil {
+			return errors.New("Illegal State - the labels of a try catch block must be visited")
+		}
+		handlerBlock := handler.handler.getCanonicalInstance()
+		handlerBlock.flags |= FLAG_JUMP_TARGET
+		handlerRangeEnd := handler.end.getCanonicalInstance()
+		for block := handler.start.getCanonicalInstance(); block != nil && block != handlerRangeEnd; block = block.nextBasicBlock {
+			block.outgoingEdges = NewEdge(int(abstractCatchType), handlerBlock, block.outgoingEdges)
+		}
+	}
+	for _, event := range m.events {
+		if event.label != nil {
+			// The jump target flag of a label designating the same basic block as a previous one.
+			event.label.getCanonicalInstance().flags |= event.label.flags & FLAG_JUMP_TARGET
+		}
+	}
+
+	m.firstBasicBlock.frame.setInputFrameFromDescriptor(m.typeTable, m.access, m.name, m.descriptor, m.maxLocals)
+	listOfBlocksToProcess := m.firstBasicBlock
+	listOfBlocksToProcess.nextListElement = EMPTY_LIST
+	maxStack := 0
+	for listOfBlocksToProcess != EMPTY_LIST {
+		basicBlock := listOfBlocksToProcess
+		listOfBlocksToProcess = listOfBlocksToProcess.nextListElement
+		basicBlock.nextListElement = nil
+		basicBlock.flags |= FLAG_REACHABLE
+		maxStack = max(maxStack, basicBlock.frame.getInputStackSize()+int(basicBlock.outputStackMax))
+		for outgoingEdge := basicBlock.outgoingEdges; outgoingEdge != nil; outgoingEdge = outgoingEdge.nextEdge {
+			if outgoingEdge.successor.frame == nil {
+				return errors.New("Illegal State - a jump target label must be visited")
+			}
+			successorBlock := outgoingEdge.successor.getCanonicalInstance()
+			changed := basicBlock.frame.merge(m.typeTable, successorBlock.frame, uint32(outgoingEdge.info))
+			if changed && successorBlock.nextListElement == nil {
+				successorBlock.nextListElement = listOfBlocksToProcess
+				listOfBlocksToProcess = successorBlock
+			}
+		}
+	}
+	m.maxStack = maxStack
+	return nil
+}
+
+// emit forwards the buffered code to the next visitor, with the computed frames and maxs, and without the
+// unreachable instructions.
+func (m *FramesComputer) emit() {
+	if m.methodVisitor == nil {
+		return
+	}
+	newLabels := make(map[int]*Label)
+	newLabel := func(typeIndex uint32) *Label {
+		entry := &m.typeTable.types[typeIndex]
+		if entry.label == nil {
+			entry.label = &Label{}
+			newLabels[entry.newEvent] = entry.label
+		}
+		return entry.label
+	}
+	type apiFrame struct {
+		nLocal int
+		local  []interface{}
+		nStack int
+		stack  []interface{}
+	}
+	frames := make(map[*Label]*apiFrame)
+	for block := m.firstBasicBlock; block != nil; block = block.nextBasicBlock {
+		if (block.flags & (FLAG_JUMP_TARGET | FLAG_REACHABLE)) == (FLAG_JUMP_TARGET | FLAG_REACHABLE) {
+			nLocal, local, nStack, stack := block.frame.accept(m.typeTable, newLabel)
+			frames[block] = &apiFrame{nLocal, local, nStack, stack}
+		}
+	}
+	isReachable := func(block *Label) bool {
+		return block != nil && (block.getCanonicalInstance().flags&FLAG_REACHABLE) != 0
+	}
+	for i, event := range m.events {
+		if (event.insn && !isReachable(event.block)) || (event.handler != nil && !isReachable(event.handler)) {
+			continue
+		}
+		if label := newLabels[i]; label != nil {
+			m.methodVisitor.VisitLabel(label)
+		}
+		event.visit(m.methodVisitor)
+		if event.label != nil {
+			if frame := frames[event.label.getCanonicalInstance()]; frame != nil {
+				m.methodVisitor.VisitFrame(opcodes.F_NEW, frame.nLocal, frame.local, frame.nStack, frame.stack)
+				delete(frames, event.label.getCanonicalInstance())
+			}
+		}
+	}
+	m.methodVisitor.VisitMaxs(m.maxStack, m.maxLocals)
+}
+
+// annotationRecorder an {@link AnnotationVisitor} which buffers the visited values, to replay them later.
+type annotationRecorder struct {
+	events []func(annotationVisitor AnnotationVisitor)
+}
+
+func (a *annotationRecorder) Visit(name string, value interface{}) {
+	a.events = append(a.events, func(annotationVisitor AnnotationVisitor) {
+		annotationVisitor.Visit(name, value)
+	})
+}
+
+func (a *annotationRecorder) VisitEnum(name, descriptor, value string) {
+	a.events = append(a.events, func(annotationVisitor AnnotationVisitor) {
+		annotationVisitor.VisitEnum(name, descriptor, value)
+	})
+}
+
+func (a *annotationRecorder) VisitAnnotation(name, descriptor string) AnnotationVisitor {
+	annotation := &annotationRecorder{}
+	a.events = append(a.events, func(annotationVisitor AnnotationVisitor) {
+		annotation.accept(annotationVisitor.VisitAnnotation(name, descriptor))
+	})
+	return annotation
+}
+
+func (a *annotationRecorder) VisitArray(name string) AnnotationVisitor {
+	annotation := &annotationRecorder{}
+	a.events = append(a.events, func(annotationVisitor AnnotationVisitor) {
+		annotation.accept(annotationVisitor.VisitArray(name))
+	})
+	return annotation
+}
+
+func (a *annotationRecorder) VisitEnd() {
+	a.events = append(a.events, func(annotationVisitor AnnotationVisitor) {
+		annotationVisitor.VisitEnd()
+	})
+}
+
+// accept replays the buffered values to the given visitor, if not nil.
+func (a *annotationRecorder) accept(annotationVisitor AnnotationVisitor) {
+	if annotationVisitor == nil {
+		return
+	}
+	for _, event := range a.events {
+		event(annotationVisitor)
+	}
+}
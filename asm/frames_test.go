@@ -0,0 +1,58 @@
+package asm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestFramesComputer(t *testing.T) {
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "m", "([Ljava/lang/String;[Ljava/lang/Integer;)[I", "", nil)
+	computer := asm.NewFramesComputer("p/A", method.Access, method.Name, method.Descriptor, method)
+	start, end, handler, other, merge := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	computer.VisitCode()
+	computer.VisitTryCatchBlock(start, end, handler, "java/io/IOException")
+	computer.VisitLabel(start)
+	computer.VisitVarInsn(opcodes.ALOAD, 0)
+	computer.VisitJumpInsn(opcodes.IFNULL, other)
+	computer.VisitVarInsn(opcodes.ALOAD, 0)
+	computer.VisitJumpInsn(opcodes.GOTO, merge)
+	computer.VisitInsn(opcodes.ACONST_NULL)
+	computer.VisitInsn(opcodes.ARETURN)
+	computer.VisitLabel(other)
+	computer.VisitVarInsn(opcodes.ALOAD, 1)
+	computer.VisitLabel(merge)
+	computer.VisitVarInsn(opcodes.ASTORE, 2)
+	computer.VisitInsn(opcodes.ICONST_3)
+	computer.VisitIntInsn(opcodes.NEWARRAY, opcodes.T_INT)
+	computer.VisitLabel(end)
+	computer.VisitInsn(opcodes.ARETURN)
+	computer.VisitLabel(handler)
+	computer.VisitVarInsn(opcodes.ASTORE, 3)
+	computer.VisitInsn(opcodes.ACONST_NULL)
+	computer.VisitInsn(opcodes.ARETURN)
+	computer.VisitMaxs(0, 0)
+
+	labelNames := tree.GetLabelNames(method)
+	var frames []string
+	for _, insn := range method.Instructions {
+		if _, ok := insn.(*tree.FrameNode); ok {
+			frames = append(frames, tree.InsnToString(insn, labelNames))
+		}
+		if insn.GetOpcode() == opcodes.ACONST_NULL && len(frames) < 3 {
+			t.Errorf("unreachable instruction not removed")
+		}
+	}
+	expected := "FRAME NEW [[Ljava/lang/String; [Ljava/lang/Integer;] []\n" +
+		"FRAME NEW [[Ljava/lang/String; [Ljava/lang/Integer;] [[Ljava/lang/Object;]\n" +
+		"FRAME NEW [[Ljava/lang/String; [Ljava/lang/Integer;] [java/io/IOException]"
+	if strings.Join(frames, "\n") != expected {
+		t.Errorf("unexpected frames:\n%s", strings.Join(frames, "\n"))
+	}
+	if method.MaxStack != 1 || method.MaxLocals != 4 || len(computer.Errors) != 0 {
+		t.Errorf("unexpected maxs %d %d %v", method.MaxStack, method.MaxLocals, computer.Errors)
+	}
+}
@@ -1,5 +1,14 @@
 package asm
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// Handle a reference to a field or a method.
 type Handle struct {
 	tag         int
 	owner       string
@@ -7,3 +16,116 @@ type Handle struct {
 	descriptor  string
 	isInterface bool
 }
+
+// NewHandle constructs a new field or method handle. The tag is one of the H_* reference kinds of
+// {@link Opcodes}.
+func NewHandle(tag int, owner, name, descriptor string, isInterface bool) *Handle {
+	return &Handle{
+		tag:         tag,
+		owner:       owner,
+		name:        name,
+		descriptor:  descriptor,
+		isInterface: isInterface,
+	}
+}
+
+// GetTag returns the kind of field or method designated by this handle.
+func (h Handle) GetTag() int {
+	return h.tag
+}
+
+// GetOwner returns the internal name of the class that owns the field or method designated by this handle.
+func (h Handle) GetOwner() string {
+	return h.owner
+}
+
+// GetName returns the name of the field or method designated by this handle.
+func (h Handle) GetName() string {
+	return h.name
+}
+
+// GetDesc returns the descriptor of the field or method designated by this handle.
+func (h Handle) GetDesc() string {
+	return h.descriptor
+}
+
+// IsInterface returns true if the owner of the field or method designated by this handle is an interface.
+func (h Handle) IsInterface() bool {
+	return h.isInterface
+}
+
+// IsField returns true if this handle designates a field, i.e. if its tag is H_GETFIELD, H_GETSTATIC, H_PUTFIELD
+// or H_PUTSTATIC.
+func (h Handle) IsField() bool {
+	return h.tag >= opcodes.H_GETFIELD && h.tag <= opcodes.H_PUTSTATIC
+}
+
+// GetOpcode returns the opcode of the instruction which has the same behavior as this handle: GETFIELD,
+// GETSTATIC, PUTFIELD, PUTSTATIC, INVOKEVIRTUAL, INVOKESTATIC, INVOKESPECIAL (for H_INVOKESPECIAL and
+// H_NEWINVOKESPECIAL, which must be preceded by NEW and DUP) or INVOKEINTERFACE, or -1 for an invalid tag.
+func (h Handle) GetOpcode() int {
+	switch h.tag {
+	case opcodes.H_GETFIELD:
+		return opcodes.GETFIELD
+	case opcodes.H_GETSTATIC:
+		return opcodes.GETSTATIC
+	case opcodes.H_PUTFIELD:
+		return opcodes.PUTFIELD
+	case opcodes.H_PUTSTATIC:
+		return opcodes.PUTSTATIC
+	case opcodes.H_INVOKEVIRTUAL:
+		return opcodes.INVOKEVIRTUAL
+	case opcodes.H_INVOKESTATIC:
+		return opcodes.INVOKESTATIC
+	case opcodes.H_INVOKESPECIAL, opcodes.H_NEWINVOKESPECIAL:
+		return opcodes.INVOKESPECIAL
+	case opcodes.H_INVOKEINTERFACE:
+		return opcodes.INVOKEINTERFACE
+	}
+	return -1
+}
+
+// Validate checks that the tag, the owner, the name, the descriptor and the interface flag of this handle are
+// consistent, as required by the JVMS for CONSTANT_MethodHandle_info structures.
+func (h Handle) Validate() error {
+	if h.tag < opcodes.H_GETFIELD || h.tag > opcodes.H_INVOKEINTERFACE {
+		return errors.New("Illegal Argument - invalid handle tag " + strconv.Itoa(h.tag))
+	}
+	if h.owner == "" || h.name == "" || h.descriptor == "" {
+		return errors.New("Illegal Argument - handle with an empty owner, name or descriptor")
+	}
+	if h.IsField() {
+		if h.descriptor[0] == '(' {
+			return errors.New("Illegal Argument - field handle with a method descriptor: " + h.String())
+		}
+		return nil
+	}
+	if h.descriptor[0] != '(' {
+		return errors.New("Illegal Argument - method handle with a field descriptor: " + h.String())
+	}
+	switch {
+	case h.tag == opcodes.H_NEWINVOKESPECIAL:
+		if h.name != "<init>" || !strings.HasSuffix(h.descriptor, ")V") {
+			return errors.New("Illegal Argument - H_NEWINVOKESPECIAL handle must designate a constructor: " + h.String())
+		}
+	case h.name == "<init>" || h.name == "<clinit>":
+		return errors.New("Illegal Argument - only H_NEWINVOKESPECIAL handles can designate " + h.name + ": " + h.String())
+	}
+	if h.tag == opcodes.H_INVOKEINTERFACE && !h.isInterface {
+		return errors.New("Illegal Argument - H_INVOKEINTERFACE handle with a non interface owner: " + h.String())
+	}
+	if (h.tag == opcodes.H_INVOKEVIRTUAL || h.tag == opcodes.H_NEWINVOKESPECIAL) && h.isInterface {
+		return errors.New("Illegal Argument - handle with an interface owner: " + h.String())
+	}
+	return nil
+}
+
+// String returns the textual representation of this handle: owner '.' name descriptor ' (' tag ')', followed
+// by ' itf' if the owner is an interface.
+func (h Handle) String() string {
+	s := h.owner + "." + h.name + h.descriptor + " (" + strconv.Itoa(h.tag)
+	if h.isInterface {
+		s += " itf"
+	}
+	return s + ")"
+}
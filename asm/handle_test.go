@@ -0,0 +1,96 @@
+package asm_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+func TestHandleGetOpcode(t *testing.T) {
+	expected := map[int]int{
+		opcodes.H_GETFIELD:         opcodes.GETFIELD,
+		opcodes.H_GETSTATIC:        opcodes.GETSTATIC,
+		opcodes.H_PUTFIELD:         opcodes.PUTFIELD,
+		opcodes.H_PUTSTATIC:        opcodes.PUTSTATIC,
+		opcodes.H_INVOKEVIRTUAL:    opcodes.INVOKEVIRTUAL,
+		opcodes.H_INVOKESTATIC:     opcodes.INVOKESTATIC,
+		opcodes.H_INVOKESPECIAL:    opcodes.INVOKESPECIAL,
+		opcodes.H_NEWINVOKESPECIAL: opcodes.INVOKESPECIAL,
+		opcodes.H_INVOKEINTERFACE:  opcodes.INVOKEINTERFACE,
+		0:                          -1,
+		10:                         -1,
+	}
+	for tag, opcode := range expected {
+		handle := asm.NewHandle(tag, "p/C", "m", "()V", false)
+		if actual := handle.GetOpcode(); actual != opcode {
+			t.Errorf("tag %d: expected opcode %d, got %d", tag, opcode, actual)
+		}
+		if isField := tag >= opcodes.H_GETFIELD && tag <= opcodes.H_PUTSTATIC; handle.IsField() != isField {
+			t.Errorf("tag %d: expected IsField %v", tag, isField)
+		}
+	}
+}
+
+func TestHandleValidate(t *testing.T) {
+	for _, test := range []struct {
+		handle *asm.Handle
+		valid  bool
+	}{
+		{asm.NewHandle(opcodes.H_GETFIELD, "p/C", "f", "I", false), true},
+		{asm.NewHandle(opcodes.H_GETSTATIC, "p/I", "F", "Ljava/lang/String;", true), true},
+		{asm.NewHandle(opcodes.H_PUTFIELD, "p/C", "f", "[I", false), true},
+		{asm.NewHandle(opcodes.H_PUTSTATIC, "p/C", "f", "()V", false), false},
+		{asm.NewHandle(opcodes.H_INVOKEVIRTUAL, "p/C", "m", "()V", false), true},
+		{asm.NewHandle(opcodes.H_INVOKEVIRTUAL, "p/I", "m", "()V", true), false},
+		{asm.NewHandle(opcodes.H_INVOKEVIRTUAL, "p/C", "m", "I", false), false},
+		{asm.NewHandle(opcodes.H_INVOKESTATIC, "p/C", "m", "(I)J", false), true},
+		{asm.NewHandle(opcodes.H_INVOKESTATIC, "p/I", "m", "(I)J", true), true},
+		{asm.NewHandle(opcodes.H_INVOKESTATIC, "p/C", "<clinit>", "()V", false), false},
+		{asm.NewHandle(opcodes.H_INVOKESPECIAL, "p/C", "m", "()V", false), true},
+		{asm.NewHandle(opcodes.H_INVOKESPECIAL, "p/I", "m", "()V", true), true},
+		{asm.NewHandle(opcodes.H_INVOKESPECIAL, "p/C", "<init>", "()V", false), false},
+		{asm.NewHandle(opcodes.H_NEWINVOKESPECIAL, "p/C", "<init>", "(I)V", false), true},
+		{asm.NewHandle(opcodes.H_NEWINVOKESPECIAL, "p/C", "<init>", "(I)I", false), false},
+		{asm.NewHandle(opcodes.H_NEWINVOKESPECIAL, "p/C", "m", "()V", false), false},
+		{asm.NewHandle(opcodes.H_NEWINVOKESPECIAL, "p/I", "<init>", "()V", true), false},
+		{asm.NewHandle(opcodes.H_INVOKEINTERFACE, "p/I", "m", "()V", true), true},
+		{asm.NewHandle(opcodes.H_INVOKEINTERFACE, "p/C", "m", "()V", false), false},
+		{asm.NewHandle(opcodes.H_INVOKEINTERFACE, "p/I", "<init>", "()V", true), false},
+		{asm.NewHandle(0, "p/C", "m", "()V", false), false},
+		{asm.NewHandle(opcodes.H_INVOKEINTERFACE+1, "p/C", "m", "()V", false), false},
+		{asm.NewHandle(opcodes.H_INVOKESTATIC, "p/C", "", "()V", false), false},
+		{asm.NewHandle(opcodes.H_GETFIELD, "", "f", "I", false), false},
+		{asm.NewHandle(opcodes.H_GETFIELD, "p/C", "f", "", false), false},
+	} {
+		if err := test.handle.Validate(); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %v, got %v", test.handle, test.valid, err)
+		}
+	}
+}
+
+func TestSymbolTableValidatesHandles(t *testing.T) {
+	symbolTable := asm.NewSymbolTable()
+	if _, err := symbolTable.AddConstant(asm.NewHandle(opcodes.H_INVOKEINTERFACE, "p/C", "m", "()V", false)); err == nil {
+		t.Error("expected an error for an H_INVOKEINTERFACE handle with a class owner")
+	}
+	bootstrap := asm.NewHandle(opcodes.H_NEWINVOKESPECIAL, "p/C", "bsm", "()V", false)
+	if _, err := symbolTable.AddBootstrapMethod(bootstrap); err == nil {
+		t.Error("expected an error for a bootstrap method handle which is not a constructor")
+	}
+	if symbolTable.GetError() != nil {
+		t.Fatalf("unexpected symbol table error %v", symbolTable.GetError())
+	}
+
+	// A valid handle refers to a Fieldref or Methodref constant, as its equivalent instruction.
+	field := symbolTable.AddConstantMethodHandle(opcodes.H_GETSTATIC, "p/C", "f", "I", false)
+	method := symbolTable.AddConstantMethodHandle(opcodes.H_INVOKEINTERFACE, "p/I", "m", "()V", true)
+	if field.GetIndex() == method.GetIndex() || symbolTable.GetError() != nil {
+		t.Errorf("unexpected handles %v %v, error %v", field, method, symbolTable.GetError())
+	}
+	// An invalid handle is still added, but its error is recorded in the symbol table.
+	symbolTable.AddConstantMethodHandle(opcodes.H_INVOKEVIRTUAL, "p/C", "<init>", "()V", false)
+	if symbolTable.GetError() == nil {
+		t.Error("expected an error for an H_INVOKEVIRTUAL handle designating a constructor")
+	}
+}
@@ -0,0 +1,461 @@
+package helper
+
+import "github.com/leaklessgfy/asm/asm"
+
+// ClassAdapter a ClassVisitor that delegates every call to Next, if not nil. Embed it in a struct and
+// override the methods of interest to write a class transformation. If Order is not nil (strict mode), the
+// order of the calls forwarded to Next, and to the visitors of its methods, is checked.
+type ClassAdapter struct {
+	Next  asm.ClassVisitor
+	Order *OrderChecker
+}
+
+func (c ClassAdapter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.Order.class("Visit", classStart, classHeader)
+	if c.Next != nil {
+		c.Next.Visit(version, access, name, signature, superName, interfaces)
+	}
+}
+
+func (c ClassAdapter) VisitSource(source, debug string) {
+	c.Order.class("VisitSource", classHeader, classSource)
+	if c.Next != nil {
+		c.Next.VisitSource(source, debug)
+	}
+}
+
+func (c ClassAdapter) VisitModule(name string, access int, version string) asm.ModuleVisitor {
+	c.Order.class("VisitModule", classSource, classModule)
+	if c.Next != nil {
+		return c.Next.VisitModule(name, access, version)
+	}
+	return nil
+}
+
+func (c ClassAdapter) VisitOuterClass(owner, name, descriptor string) {
+	c.Order.class("VisitOuterClass", classModule, classOuterClass)
+	if c.Next != nil {
+		c.Next.VisitOuterClass(owner, name, descriptor)
+	}
+}
+
+func (c ClassAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	c.Order.class("VisitAnnotation", classAnnotations, classAnnotations)
+	if c.Next != nil {
+		return c.Next.VisitAnnotation(descriptor, visible)
+	}
+	return nil
+}
+
+func (c ClassAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	c.Order.class("VisitTypeAnnotation", classAnnotations, classAnnotations)
+	if c.Next != nil {
+		return c.Next.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (c ClassAdapter) VisitAttribute(attribute *asm.Attribute) {
+	c.Order.class("VisitAttribute", classAnnotations, classAnnotations)
+	if c.Next != nil {
+		c.Next.VisitAttribute(attribute)
+	}
+}
+
+func (c ClassAdapter) VisitInnerClass(name, outerName, innerName string, access int) {
+	c.Order.class("VisitInnerClass", classMembers, classMembers)
+	if c.Next != nil {
+		c.Next.VisitInnerClass(name, outerName, innerName, access)
+	}
+}
+
+func (c ClassAdapter) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	c.Order.class("VisitField", classMembers, classMembers)
+	if c.Next != nil {
+		return c.Next.VisitField(access, name, descriptor, signature, value)
+	}
+	return nil
+}
+
+func (c ClassAdapter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	c.Order.class("VisitMethod", classMembers, classMembers)
+	if c.Next != nil {
+		methodVisitor := c.Next.VisitMethod(access, name, descriptor, signature, exceptions)
+		if c.Order != nil && methodVisitor != nil {
+			return MethodAdapter{Next: methodVisitor, Order: c.Order.newMethodChecker()}
+		}
+		return methodVisitor
+	}
+	return nil
+}
+
+func (c ClassAdapter) VisitEnd() {
+	c.Order.class("VisitEnd", classMembers, classEnd)
+	if c.Next != nil {
+		c.Next.VisitEnd()
+	}
+}
+
+// FieldAdapter a FieldVisitor that delegates every call to Next, if not nil.
+type FieldAdapter struct {
+	Next asm.FieldVisitor
+}
+
+func (f FieldAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	if f.Next != nil {
+		return f.Next.VisitAnnotation(descriptor, visible)
+	}
+	return nil
+}
+
+func (f FieldAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	if f.Next != nil {
+		return f.Next.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (f FieldAdapter) VisitAttribute(attribute *asm.Attribute) {
+	if f.Next != nil {
+		f.Next.VisitAttribute(attribute)
+	}
+}
+
+func (f FieldAdapter) VisitEnd() {
+	if f.Next != nil {
+		f.Next.VisitEnd()
+	}
+}
+
+// AnnotationAdapter an AnnotationVisitor that delegates every call to Next, if not nil.
+type AnnotationAdapter struct {
+	Next asm.AnnotationVisitor
+}
+
+func (a AnnotationAdapter) Visit(name string, value interface{}) {
+	if a.Next != nil {
+		a.Next.Visit(name, value)
+	}
+}
+
+func (a AnnotationAdapter) VisitEnum(name, descriptor, value string) {
+	if a.Next != nil {
+		a.Next.VisitEnum(name, descriptor, value)
+	}
+}
+
+func (a AnnotationAdapter) VisitAnnotation(name, descriptor string) asm.AnnotationVisitor {
+	if a.Next != nil {
+		return a.Next.VisitAnnotation(name, descriptor)
+	}
+	return nil
+}
+
+func (a AnnotationAdapter) VisitArray(name string) asm.AnnotationVisitor {
+	if a.Next != nil {
+		return a.Next.VisitArray(name)
+	}
+	return nil
+}
+
+func (a AnnotationAdapter) VisitEnd() {
+	if a.Next != nil {
+		a.Next.VisitEnd()
+	}
+}
+
+// ModuleAdapter a ModuleVisitor that delegates every call to Next, if not nil.
+type ModuleAdapter struct {
+	Next asm.ModuleVisitor
+}
+
+func (m ModuleAdapter) VisitMainClass(mainClass string) {
+	if m.Next != nil {
+		m.Next.VisitMainClass(mainClass)
+	}
+}
+
+func (m ModuleAdapter) VisitPackage(packaze string) {
+	if m.Next != nil {
+		m.Next.VisitPackage(packaze)
+	}
+}
+
+func (m ModuleAdapter) VisitRequire(module string, access int, version string) {
+	if m.Next != nil {
+		m.Next.VisitRequire(module, access, version)
+	}
+}
+
+func (m ModuleAdapter) VisitExport(packaze string, access int, modules ...string) {
+	if m.Next != nil {
+		m.Next.VisitExport(packaze, access, modules...)
+	}
+}
+
+func (m ModuleAdapter) VisitOpen(packaze string, access int, modules ...string) {
+	if m.Next != nil {
+		m.Next.VisitOpen(packaze, access, modules...)
+	}
+}
+
+func (m ModuleAdapter) VisitUse(service string) {
+	if m.Next != nil {
+		m.Next.VisitUse(service)
+	}
+}
+
+func (m ModuleAdapter) VisitProvide(service string, providers ...string) {
+	if m.Next != nil {
+		m.Next.VisitProvide(service, providers...)
+	}
+}
+
+func (m ModuleAdapter) VisitEnd() {
+	if m.Next != nil {
+		m.Next.VisitEnd()
+	}
+}
+
+// MethodAdapter a MethodVisitor that delegates every call to Next, if not nil. If Order is not nil (strict
+// mode), the order of the calls forwarded to Next is checked.
+type MethodAdapter struct {
+	Next  asm.MethodVisitor
+	Order *OrderChecker
+}
+
+func (m MethodAdapter) VisitParameter(name string, access int) {
+	m.Order.method("VisitParameter", methodParameters, methodParameters)
+	if m.Next != nil {
+		m.Next.VisitParameter(name, access)
+	}
+}
+
+func (m MethodAdapter) VisitAnnotationDefault() asm.AnnotationVisitor {
+	m.Order.method("VisitAnnotationDefault", methodParameters, methodAnnotationDefault)
+	if m.Next != nil {
+		return m.Next.VisitAnnotationDefault()
+	}
+	return nil
+}
+
+func (m MethodAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	m.Order.method("VisitAnnotation", methodAnnotations, methodAnnotations)
+	if m.Next != nil {
+		return m.Next.VisitAnnotation(descriptor, visible)
+	}
+	return nil
+}
+
+func (m MethodAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	m.Order.method("VisitTypeAnnotation", methodAnnotations, methodAnnotations)
+	if m.Next != nil {
+		return m.Next.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (m MethodAdapter) VisitAnnotableParameterCount(parameterCount int, visible bool) {
+	m.Order.method("VisitAnnotableParameterCount", methodAnnotations, methodAnnotations)
+	if m.Next != nil {
+		m.Next.VisitAnnotableParameterCount(parameterCount, visible)
+	}
+}
+
+func (m MethodAdapter) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	m.Order.method("VisitParameterAnnotation", methodAnnotations, methodAnnotations)
+	if m.Next != nil {
+		return m.Next.VisitParameterAnnotation(parameter, descriptor, visible)
+	}
+	return nil
+}
+
+func (m MethodAdapter) VisitAttribute(attribute *asm.Attribute) {
+	m.Order.method("VisitAttribute", methodAnnotations, methodAnnotations)
+	if m.Next != nil {
+		m.Next.VisitAttribute(attribute)
+	}
+}
+
+func (m MethodAdapter) VisitCode() {
+	m.Order.method("VisitCode", methodAnnotations, methodCode)
+	if m.Next != nil {
+		m.Next.VisitCode()
+	}
+}
+
+func (m MethodAdapter) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	m.Order.insn("VisitFrame")
+	if m.Next != nil {
+		m.Next.VisitFrame(typed, nLocal, local, nStack, stack)
+	}
+}
+
+func (m MethodAdapter) VisitInsn(opcode int) {
+	m.Order.insn("VisitInsn")
+	if m.Next != nil {
+		m.Next.VisitInsn(opcode)
+	}
+}
+
+func (m MethodAdapter) VisitIntInsn(opcode, operand int) {
+	m.Order.insn("VisitIntInsn")
+	if m.Next != nil {
+		m.Next.VisitIntInsn(opcode, operand)
+	}
+}
+
+func (m MethodAdapter) VisitVarInsn(opcode, vard int) {
+	m.Order.insn("VisitVarInsn")
+	if m.Next != nil {
+		m.Next.VisitVarInsn(opcode, vard)
+	}
+}
+
+func (m MethodAdapter) VisitTypeInsn(opcode int, typed string) {
+	m.Order.insn("VisitTypeInsn")
+	if m.Next != nil {
+		m.Next.VisitTypeInsn(opcode, typed)
+	}
+}
+
+func (m MethodAdapter) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.Order.insn("VisitFieldInsn")
+	if m.Next != nil {
+		m.Next.VisitFieldInsn(opcode, owner, name, descriptor)
+	}
+}
+
+func (m MethodAdapter) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.Order.insn("VisitMethodInsn")
+	if m.Next != nil {
+		m.Next.VisitMethodInsn(opcode, owner, name, descriptor)
+	}
+}
+
+func (m MethodAdapter) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.Order.insn("VisitMethodInsnB")
+	if m.Next != nil {
+		m.Next.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+	}
+}
+
+func (m MethodAdapter) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	m.Order.insn("VisitInvokeDynamicInsn")
+	if m.Next != nil {
+		m.Next.VisitInvokeDynamicInsn(name, descriptor, bootstrapMethodHande, bootstrapMethodArguments...)
+	}
+}
+
+func (m MethodAdapter) VisitJumpInsn(opcode int, label *asm.Label) {
+	m.Order.insn("VisitJumpInsn")
+	if m.Next != nil {
+		m.Next.VisitJumpInsn(opcode, label)
+	}
+}
+
+func (m MethodAdapter) VisitLabel(label *asm.Label) {
+	m.Order.visitLabel(label)
+	if m.Next != nil {
+		m.Next.VisitLabel(label)
+	}
+}
+
+func (m MethodAdapter) VisitLdcInsn(value interface{}) {
+	m.Order.insn("VisitLdcInsn")
+	if m.Next != nil {
+		m.Next.VisitLdcInsn(value)
+	}
+}
+
+func (m MethodAdapter) VisitIincInsn(vard, increment int) {
+	m.Order.insn("VisitIincInsn")
+	if m.Next != nil {
+		m.Next.VisitIincInsn(vard, increment)
+	}
+}
+
+func (m MethodAdapter) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	m.Order.insn("VisitTableSwitchInsn")
+	if m.Next != nil {
+		m.Next.VisitTableSwitchInsn(min, max, dflt, labels...)
+	}
+}
+
+func (m MethodAdapter) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	m.Order.insn("VisitLookupSwitchInsn")
+	if m.Next != nil {
+		m.Next.VisitLookupSwitchInsn(dflt, keys, labels)
+	}
+}
+
+func (m MethodAdapter) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.Order.insn("VisitMultiANewArrayInsn")
+	if m.Next != nil {
+		m.Next.VisitMultiANewArrayInsn(descriptor, numDimensions)
+	}
+}
+
+func (m MethodAdapter) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	m.Order.insn("VisitInsnAnnotation")
+	if m.Next != nil {
+		return m.Next.VisitInsnAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (m MethodAdapter) VisitTryCatchBlock(start, end, handler *asm.Label, typed string) {
+	m.Order.insn("VisitTryCatchBlock")
+	m.Order.label("VisitTryCatchBlock", false, start, end, handler)
+	if m.Next != nil {
+		m.Next.VisitTryCatchBlock(start, end, handler, typed)
+	}
+}
+
+func (m MethodAdapter) VisitTryCatchAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	m.Order.insn("VisitTryCatchAnnotation")
+	if m.Next != nil {
+		return m.Next.VisitTryCatchAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (m MethodAdapter) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	m.Order.insn("VisitLocalVariable")
+	m.Order.label("VisitLocalVariable", true, start, end)
+	if m.Next != nil {
+		m.Next.VisitLocalVariable(name, descriptor, signature, start, end, index)
+	}
+}
+
+func (m MethodAdapter) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	m.Order.insn("VisitLocalVariableAnnotation")
+	m.Order.label("VisitLocalVariableAnnotation", true, start...)
+	m.Order.label("VisitLocalVariableAnnotation", true, end...)
+	if m.Next != nil {
+		return m.Next.VisitLocalVariableAnnotation(typeRef, typePath, start, end, index, descriptor, visible)
+	}
+	return nil
+}
+
+func (m MethodAdapter) VisitLineNumber(line int, start *asm.Label) {
+	m.Order.insn("VisitLineNumber")
+	m.Order.label("VisitLineNumber", true, start)
+	if m.Next != nil {
+		m.Next.VisitLineNumber(line, start)
+	}
+}
+
+func (m MethodAdapter) VisitMaxs(maxStack int, maxLocals int) {
+	m.Order.visitMaxs()
+	if m.Next != nil {
+		m.Next.VisitMaxs(maxStack, maxLocals)
+	}
+}
+
+func (m MethodAdapter) VisitEnd() {
+	m.Order.visitMethodEnd()
+	if m.Next != nil {
+		m.Next.VisitEnd()
+	}
+}
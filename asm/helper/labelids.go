@@ -0,0 +1,100 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+)
+
+// LabelIDs assigns stable sequential IDs to the labels of a method, in the order in which they are first
+// seen, so that the textual representations of the visitor events do not depend on the label addresses, which
+// change from run to run. Two disassemblies of the same method thus give the same label names, and can be
+// diffed. Reset must be called at the start of each method.
+type LabelIDs struct {
+	ids map[*asm.Label]int
+}
+
+// NewLabelIDs constructs a new {@link LabelIDs}.
+func NewLabelIDs() *LabelIDs {
+	return &LabelIDs{ids: make(map[*asm.Label]int)}
+}
+
+// GetID returns the ID of the given label, assigning it the next ID if it has not been seen yet.
+func (l *LabelIDs) GetID(label *asm.Label) int {
+	if l.ids == nil {
+		l.ids = make(map[*asm.Label]int)
+	}
+	id, ok := l.ids[label]
+	if !ok {
+		id = len(l.ids)
+		l.ids[label] = id
+	}
+	return id
+}
+
+// GetName returns the name of the given label, L0, L1, ... (see {@link GetID}).
+func (l *LabelIDs) GetName(label *asm.Label) string {
+	return "L" + strconv.Itoa(l.GetID(label))
+}
+
+// Reset forgets the labels seen so far, so that the IDs of the next method start from 0.
+func (l *LabelIDs) Reset() {
+	l.ids = make(map[*asm.Label]int)
+}
+
+// Format returns a textual representation of the event with its arguments, where the labels are represented by
+// their names in the given {@link LabelIDs}. The IDs of a method are reset on its CLASS_VISIT_METHOD event, so
+// the events must be formatted in order.
+func (e *Event) Format(labels *LabelIDs) string {
+	if e.Kind == CLASS_VISIT_METHOD {
+		labels.Reset()
+	}
+	s := e.String()
+	for _, arg := range e.Args {
+		s += " " + FormatArg(arg, labels)
+	}
+	return s
+}
+
+// FormatArg returns a textual representation of the given visitor event argument, where the labels are
+// represented by their names in the given {@link LabelIDs}.
+func FormatArg(arg interface{}, labels *LabelIDs) string {
+	switch arg := arg.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(arg)
+	case *asm.Label:
+		return labels.GetName(arg)
+	case []*asm.Label:
+		values := make([]string, len(arg))
+		for i, label := range arg {
+			values[i] = labels.GetName(label)
+		}
+		return "[" + strings.Join(values, " ") + "]"
+	case []string:
+		values := make([]string, len(arg))
+		for i, value := range arg {
+			values[i] = strconv.Quote(value)
+		}
+		return "[" + strings.Join(values, " ") + "]"
+	case []interface{}:
+		values := make([]string, len(arg))
+		for i, value := range arg {
+			values[i] = FormatArg(value, labels)
+		}
+		return "[" + strings.Join(values, " ") + "]"
+	case *asm.Type:
+		return "Type(" + arg.GetDescriptor() + ")"
+	case *asm.TypePath:
+		if arg == nil {
+			return "null"
+		}
+		return "TypePath(" + arg.String() + ")"
+	case *asm.Attribute:
+		return arg.GetType() + "(" + fmt.Sprintf("%x", arg.GetContent()) + ")"
+	}
+	return fmt.Sprintf("%T(%v)", arg, arg)
+}
@@ -0,0 +1,111 @@
+package helper_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// labelsClass returns a class A with two methods using labels: "int m(int)", with a branch, line numbers and a
+// try catch block, and "void n()", with a loop.
+func labelsClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "A", "java/lang/Object")
+	start, end, handler, zero := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	m := classFile.AddMethod(0, "m", "(I)I", "", nil)
+	m.VisitCode()
+	m.VisitTryCatchBlock(start, end, handler, "java/lang/Exception")
+	m.VisitLabel(start)
+	m.VisitLineNumber(1, start)
+	m.VisitVarInsn(opcodes.ILOAD, 1)
+	m.VisitJumpInsn(opcodes.IFEQ, zero)
+	m.VisitInsn(opcodes.ICONST_1)
+	m.VisitInsn(opcodes.IRETURN)
+	m.VisitLabel(zero)
+	m.VisitLineNumber(2, zero)
+	m.VisitInsn(opcodes.ICONST_0)
+	m.VisitLabel(end)
+	m.VisitInsn(opcodes.IRETURN)
+	m.VisitLabel(handler)
+	m.VisitInsn(opcodes.POP)
+	m.VisitInsn(opcodes.ICONST_M1)
+	m.VisitInsn(opcodes.IRETURN)
+	m.VisitMaxs(1, 2)
+	m.VisitEnd()
+
+	loop := &asm.Label{}
+	n := classFile.AddMethod(0, "n", "()V", "", nil)
+	n.VisitCode()
+	n.VisitLabel(loop)
+	n.VisitJumpInsn(opcodes.GOTO, loop)
+	n.VisitMaxs(0, 1)
+	n.VisitEnd()
+	return classFile.Bytes()
+}
+
+// formattedEvents returns the events of the given class formatted with a new {@link LabelIDs}.
+func formattedEvents(t *testing.T, classFile []byte) []string {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := helper.NewLabelIDs()
+	var events []string
+	reader.Accept(helper.NewMiddlewareClassAdapter(nil, helper.Middleware{
+		Before: func(event *helper.Event) bool {
+			events = append(events, event.Format(labels))
+			return true
+		},
+	}), 0)
+	return events
+}
+
+func TestLabelIDsStableAcrossReads(t *testing.T) {
+	classFile := labelsClass()
+	// Each read creates new labels, which get the same names.
+	first, second := formattedEvents(t, classFile), formattedEvents(t, classFile)
+	if strings.Join(first, "\n") != strings.Join(second, "\n") {
+		t.Errorf("the label names differ:\n%s\n\n%s", strings.Join(first, "\n"), strings.Join(second, "\n"))
+	}
+	// The IDs are assigned in the order in which the labels are first used, from 0 in each method.
+	var labelEvents []string
+	for _, event := range first {
+		if strings.Contains(event, " L") {
+			labelEvents = append(labelEvents, event)
+		}
+	}
+	expected := []string{
+		`method visit try catch block A.m(I)I L0 L1 L2 "java/lang/Exception"`,
+		`method visit label A.m(I)I L0`,
+		`method visit line number A.m(I)I int(1) L0`,
+		`method visit jump insn A.m(I)I int(153) L3`,
+		`method visit label A.m(I)I L3`,
+		`method visit line number A.m(I)I int(2) L3`,
+		`method visit label A.m(I)I L1`,
+		`method visit label A.m(I)I L2`,
+		`method visit label A.n()V L0`,
+		`method visit jump insn A.n()V int(167) L0`,
+	}
+	if strings.Join(labelEvents, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(labelEvents, "\n"))
+	}
+}
+
+func TestLabelIDs(t *testing.T) {
+	// The zero value can be used.
+	var labels helper.LabelIDs
+	label0, label1 := &asm.Label{}, &asm.Label{}
+	if labels.GetID(label0) != 0 || labels.GetName(label1) != "L1" || labels.GetName(label0) != "L0" {
+		t.Error("unexpected label IDs")
+	}
+	if helper.FormatArg([]*asm.Label{label1, label0}, &labels) != "[L1 L0]" {
+		t.Error("unexpected label list")
+	}
+	labels.Reset()
+	if labels.GetName(label1) != "L0" {
+		t.Error("the label IDs are not reset")
+	}
+}
@@ -0,0 +1,567 @@
+package helper
+
+import "github.com/leaklessgfy/asm/asm"
+
+// Kinds of the visitor events dispatched to the {@link Middleware}s (see {@link Event}).
+const (
+	CLASS_VISIT = iota
+	CLASS_VISIT_SOURCE
+	CLASS_VISIT_MODULE
+	CLASS_VISIT_OUTER_CLASS
+	CLASS_VISIT_ANNOTATION
+	CLASS_VISIT_TYPE_ANNOTATION
+	CLASS_VISIT_ATTRIBUTE
+	CLASS_VISIT_INNER_CLASS
+	CLASS_VISIT_FIELD
+	CLASS_VISIT_METHOD
+	CLASS_VISIT_END
+	FIELD_VISIT_ANNOTATION
+	FIELD_VISIT_TYPE_ANNOTATION
+	FIELD_VISIT_ATTRIBUTE
+	FIELD_VISIT_END
+	METHOD_VISIT_PARAMETER
+	METHOD_VISIT_ANNOTATION_DEFAULT
+	METHOD_VISIT_ANNOTATION
+	METHOD_VISIT_TYPE_ANNOTATION
+	METHOD_VISIT_ANNOTABLE_PARAMETER_COUNT
+	METHOD_VISIT_PARAMETER_ANNOTATION
+	METHOD_VISIT_ATTRIBUTE
+	METHOD_VISIT_CODE
+	METHOD_VISIT_FRAME
+	METHOD_VISIT_INSN
+	METHOD_VISIT_INT_INSN
+	METHOD_VISIT_VAR_INSN
+	METHOD_VISIT_TYPE_INSN
+	METHOD_VISIT_FIELD_INSN
+	METHOD_VISIT_METHOD_INSN
+	METHOD_VISIT_INVOKE_DYNAMIC_INSN
+	METHOD_VISIT_JUMP_INSN
+	METHOD_VISIT_LABEL
+	METHOD_VISIT_LDC_INSN
+	METHOD_VISIT_IINC_INSN
+	METHOD_VISIT_TABLE_SWITCH_INSN
+	METHOD_VISIT_LOOKUP_SWITCH_INSN
+	METHOD_VISIT_MULTI_ANEW_ARRAY_INSN
+	METHOD_VISIT_INSN_ANNOTATION
+	METHOD_VISIT_TRY_CATCH_BLOCK
+	METHOD_VISIT_TRY_CATCH_ANNOTATION
+	METHOD_VISIT_LOCAL_VARIABLE
+	METHOD_VISIT_LOCAL_VARIABLE_ANNOTATION
+	METHOD_VISIT_LINE_NUMBER
+	METHOD_VISIT_MAXS
+	METHOD_VISIT_END
+)
+
+var eventNames = []string{
+	"class visit", "class visit source", "class visit module", "class visit outer class",
+	"class visit annotation", "class visit type annotation", "class visit attribute", "class visit inner class",
+	"class visit field", "class visit method", "class visit end", "field visit annotation",
+	"field visit type annotation", "field visit attribute", "field visit end", "method visit parameter",
+	"method visit annotation default", "method visit annotation", "method visit type annotation",
+	"method visit annotable parameter count", "method visit parameter annotation", "method visit attribute",
+	"method visit code", "method visit frame", "method visit insn", "method visit int insn",
+	"method visit var insn", "method visit type insn", "method visit field insn", "method visit method insn",
+	"method visit invoke dynamic insn", "method visit jump insn", "method visit label", "method visit ldc insn",
+	"method visit iinc insn", "method visit table switch insn", "method visit lookup switch insn",
+	"method visit multi anew array insn", "method visit insn annotation", "method visit try catch block",
+	"method visit try catch annotation", "method visit local variable",
+	"method visit local variable annotation", "method visit line number", "method visit maxs",
+	"method visit end",
+}
+
+// Event a visitor event, given to the {@link Middleware}s of a {@link MiddlewareClassAdapter}. VisitMethodInsn
+// and VisitMethodInsnB events are both reported as {@link METHOD_VISIT_METHOD_INSN}, with 4 or 5 arguments.
+type Event struct {
+	// Kind {@link CLASS_VISIT} to {@link METHOD_VISIT_END}.
+	Kind int
+	// Class the internal name of the visited class.
+	Class string
+	// Member the name and descriptor of the visited field or method, or "" for class events.
+	Member string
+	// Args the arguments of the visit method, in declaration order. Variadic arguments are given as a slice.
+	// They must not be modified.
+	Args []interface{}
+	// Skipped whether the event was not forwarded to the next visitor, because a Before function returned
+	// false.
+	Skipped bool
+}
+
+func (e *Event) String() string {
+	s := eventNames[e.Kind] + " " + e.Class
+	if e.Member != "" {
+		s += "." + e.Member
+	}
+	return s
+}
+
+// Middleware functions called before and after each event of a {@link MiddlewareClassAdapter}, to implement
+// cross cutting concerns (metrics, logging, filtering, dry runs...) without writing a full adapter. Both
+// functions may be nil.
+type Middleware struct {
+	// Before is called before the event is forwarded to the next visitor. If it returns false, the event is not
+	// forwarded (nor given to the Before functions of the following middlewares). Skipping a CLASS_VISIT_FIELD or
+	// CLASS_VISIT_METHOD event removes the whole member, without events for its content.
+	Before func(event *Event) bool
+	// After is called after the event has been forwarded (or skipped), in the reverse order of the middlewares,
+	// for each middleware whose Before function was called (or is nil).
+	After func(event *Event)
+}
+
+// MiddlewareClassAdapter a ClassVisitor that calls its middlewares around each class, field and method event,
+// and forwards the events to Next, if not nil. The events of the fields and methods are reported even if Next
+// does not visit them (i.e. returns a nil visitor). Annotation and module visitors are returned unchanged: their
+// events are not reported.
+type MiddlewareClassAdapter struct {
+	Next        asm.ClassVisitor
+	Middlewares []Middleware
+	className   string
+}
+
+// NewMiddlewareClassAdapter constructs a new {@link MiddlewareClassAdapter}.
+func NewMiddlewareClassAdapter(next asm.ClassVisitor, middlewares ...Middleware) *MiddlewareClassAdapter {
+	return &MiddlewareClassAdapter{Next: next, Middlewares: middlewares}
+}
+
+// dispatch calls the Before functions of the middlewares, then forward if no Before function returned false,
+// then the After functions. Returns whether the event was forwarded.
+func (c *MiddlewareClassAdapter) dispatch(kind int, member string, args []interface{}, forward func()) bool {
+	event := &Event{Kind: kind, Class: c.className, Member: member, Args: args}
+	called := len(c.Middlewares)
+	for i, middleware := range c.Middlewares {
+		if middleware.Before != nil && !middleware.Before(event) {
+			event.Skipped = true
+			called = i + 1
+			break
+		}
+	}
+	if !event.Skipped {
+		forward()
+	}
+	for i := called - 1; i >= 0; i-- {
+		if c.Middlewares[i].After != nil {
+			c.Middlewares[i].After(event)
+		}
+	}
+	return !event.Skipped
+}
+
+func (c *MiddlewareClassAdapter) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.className = name
+	c.dispatch(CLASS_VISIT, "", []interface{}{version, access, name, signature, superName, interfaces}, func() {
+		if c.Next != nil {
+			c.Next.Visit(version, access, name, signature, superName, interfaces)
+		}
+	})
+}
+
+func (c *MiddlewareClassAdapter) VisitSource(source, debug string) {
+	c.dispatch(CLASS_VISIT_SOURCE, "", []interface{}{source, debug}, func() {
+		if c.Next != nil {
+			c.Next.VisitSource(source, debug)
+		}
+	})
+}
+
+func (c *MiddlewareClassAdapter) VisitModule(name string, access int, version string) asm.ModuleVisitor {
+	var result asm.ModuleVisitor
+	c.dispatch(CLASS_VISIT_MODULE, "", []interface{}{name, access, version}, func() {
+		if c.Next != nil {
+			result = c.Next.VisitModule(name, access, version)
+		}
+	})
+	return result
+}
+
+func (c *MiddlewareClassAdapter) VisitOuterClass(owner, name, descriptor string) {
+	c.dispatch(CLASS_VISIT_OUTER_CLASS, "", []interface{}{owner, name, descriptor}, func() {
+		if c.Next != nil {
+			c.Next.VisitOuterClass(owner, name, descriptor)
+		}
+	})
+}
+
+func (c *MiddlewareClassAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	c.dispatch(CLASS_VISIT_ANNOTATION, "", []interface{}{descriptor, visible}, func() {
+		if c.Next != nil {
+			result = c.Next.VisitAnnotation(descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (c *MiddlewareClassAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	c.dispatch(CLASS_VISIT_TYPE_ANNOTATION, "", []interface{}{typeRef, typePath, descriptor, visible}, func() {
+		if c.Next != nil {
+			result = c.Next.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (c *MiddlewareClassAdapter) VisitAttribute(attribute *asm.Attribute) {
+	c.dispatch(CLASS_VISIT_ATTRIBUTE, "", []interface{}{attribute}, func() {
+		if c.Next != nil {
+			c.Next.VisitAttribute(attribute)
+		}
+	})
+}
+
+func (c *MiddlewareClassAdapter) VisitInnerClass(name, outerName, innerName string, access int) {
+	c.dispatch(CLASS_VISIT_INNER_CLASS, "", []interface{}{name, outerName, innerName, access}, func() {
+		if c.Next != nil {
+			c.Next.VisitInnerClass(name, outerName, innerName, access)
+		}
+	})
+}
+
+func (c *MiddlewareClassAdapter) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	var next asm.FieldVisitor
+	if !c.dispatch(CLASS_VISIT_FIELD, "", []interface{}{access, name, descriptor, signature, value}, func() {
+		if c.Next != nil {
+			next = c.Next.VisitField(access, name, descriptor, signature, value)
+		}
+	}) {
+		return nil
+	}
+	return &middlewareFieldAdapter{next: next, parent: c, member: name + " " + descriptor}
+}
+
+func (c *MiddlewareClassAdapter) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	var next asm.MethodVisitor
+	if !c.dispatch(CLASS_VISIT_METHOD, "", []interface{}{access, name, descriptor, signature, exceptions}, func() {
+		if c.Next != nil {
+			next = c.Next.VisitMethod(access, name, descriptor, signature, exceptions)
+		}
+	}) {
+		return nil
+	}
+	return &middlewareMethodAdapter{next: next, parent: c, member: name + descriptor}
+}
+
+func (c *MiddlewareClassAdapter) VisitEnd() {
+	c.dispatch(CLASS_VISIT_END, "", nil, func() {
+		if c.Next != nil {
+			c.Next.VisitEnd()
+		}
+	})
+}
+
+type middlewareFieldAdapter struct {
+	next   asm.FieldVisitor
+	parent *MiddlewareClassAdapter
+	member string
+}
+
+func (f *middlewareFieldAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	f.parent.dispatch(FIELD_VISIT_ANNOTATION, f.member, []interface{}{descriptor, visible}, func() {
+		if f.next != nil {
+			result = f.next.VisitAnnotation(descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (f *middlewareFieldAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	f.parent.dispatch(FIELD_VISIT_TYPE_ANNOTATION, f.member, []interface{}{typeRef, typePath, descriptor, visible}, func() {
+		if f.next != nil {
+			result = f.next.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (f *middlewareFieldAdapter) VisitAttribute(attribute *asm.Attribute) {
+	f.parent.dispatch(FIELD_VISIT_ATTRIBUTE, f.member, []interface{}{attribute}, func() {
+		if f.next != nil {
+			f.next.VisitAttribute(attribute)
+		}
+	})
+}
+
+func (f *middlewareFieldAdapter) VisitEnd() {
+	f.parent.dispatch(FIELD_VISIT_END, f.member, nil, func() {
+		if f.next != nil {
+			f.next.VisitEnd()
+		}
+	})
+}
+
+type middlewareMethodAdapter struct {
+	next   asm.MethodVisitor
+	parent *MiddlewareClassAdapter
+	member string
+}
+
+func (m *middlewareMethodAdapter) VisitParameter(name string, access int) {
+	m.parent.dispatch(METHOD_VISIT_PARAMETER, m.member, []interface{}{name, access}, func() {
+		if m.next != nil {
+			m.next.VisitParameter(name, access)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitAnnotationDefault() asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	m.parent.dispatch(METHOD_VISIT_ANNOTATION_DEFAULT, m.member, nil, func() {
+		if m.next != nil {
+			result = m.next.VisitAnnotationDefault()
+		}
+	})
+	return result
+}
+
+func (m *middlewareMethodAdapter) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	m.parent.dispatch(METHOD_VISIT_ANNOTATION, m.member, []interface{}{descriptor, visible}, func() {
+		if m.next != nil {
+			result = m.next.VisitAnnotation(descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (m *middlewareMethodAdapter) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	m.parent.dispatch(METHOD_VISIT_TYPE_ANNOTATION, m.member, []interface{}{typeRef, typePath, descriptor, visible}, func() {
+		if m.next != nil {
+			result = m.next.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (m *middlewareMethodAdapter) VisitAnnotableParameterCount(parameterCount int, visible bool) {
+	m.parent.dispatch(METHOD_VISIT_ANNOTABLE_PARAMETER_COUNT, m.member, []interface{}{parameterCount, visible}, func() {
+		if m.next != nil {
+			m.next.VisitAnnotableParameterCount(parameterCount, visible)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	m.parent.dispatch(METHOD_VISIT_PARAMETER_ANNOTATION, m.member, []interface{}{parameter, descriptor, visible}, func() {
+		if m.next != nil {
+			result = m.next.VisitParameterAnnotation(parameter, descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (m *middlewareMethodAdapter) VisitAttribute(attribute *asm.Attribute) {
+	m.parent.dispatch(METHOD_VISIT_ATTRIBUTE, m.member, []interface{}{attribute}, func() {
+		if m.next != nil {
+			m.next.VisitAttribute(attribute)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitCode() {
+	m.parent.dispatch(METHOD_VISIT_CODE, m.member, nil, func() {
+		if m.next != nil {
+			m.next.VisitCode()
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	m.parent.dispatch(METHOD_VISIT_FRAME, m.member, []interface{}{typed, nLocal, local, nStack, stack}, func() {
+		if m.next != nil {
+			m.next.VisitFrame(typed, nLocal, local, nStack, stack)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitInsn(opcode int) {
+	m.parent.dispatch(METHOD_VISIT_INSN, m.member, []interface{}{opcode}, func() {
+		if m.next != nil {
+			m.next.VisitInsn(opcode)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitIntInsn(opcode, operand int) {
+	m.parent.dispatch(METHOD_VISIT_INT_INSN, m.member, []interface{}{opcode, operand}, func() {
+		if m.next != nil {
+			m.next.VisitIntInsn(opcode, operand)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitVarInsn(opcode, vard int) {
+	m.parent.dispatch(METHOD_VISIT_VAR_INSN, m.member, []interface{}{opcode, vard}, func() {
+		if m.next != nil {
+			m.next.VisitVarInsn(opcode, vard)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitTypeInsn(opcode int, typed string) {
+	m.parent.dispatch(METHOD_VISIT_TYPE_INSN, m.member, []interface{}{opcode, typed}, func() {
+		if m.next != nil {
+			m.next.VisitTypeInsn(opcode, typed)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.parent.dispatch(METHOD_VISIT_FIELD_INSN, m.member, []interface{}{opcode, owner, name, descriptor}, func() {
+		if m.next != nil {
+			m.next.VisitFieldInsn(opcode, owner, name, descriptor)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.parent.dispatch(METHOD_VISIT_METHOD_INSN, m.member, []interface{}{opcode, owner, name, descriptor}, func() {
+		if m.next != nil {
+			m.next.VisitMethodInsn(opcode, owner, name, descriptor)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.parent.dispatch(METHOD_VISIT_METHOD_INSN, m.member, []interface{}{opcode, owner, name, descriptor, isInterface}, func() {
+		if m.next != nil {
+			m.next.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	m.parent.dispatch(METHOD_VISIT_INVOKE_DYNAMIC_INSN, m.member, []interface{}{name, descriptor, bootstrapMethodHande, bootstrapMethodArguments}, func() {
+		if m.next != nil {
+			m.next.VisitInvokeDynamicInsn(name, descriptor, bootstrapMethodHande, bootstrapMethodArguments...)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitJumpInsn(opcode int, label *asm.Label) {
+	m.parent.dispatch(METHOD_VISIT_JUMP_INSN, m.member, []interface{}{opcode, label}, func() {
+		if m.next != nil {
+			m.next.VisitJumpInsn(opcode, label)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitLabel(label *asm.Label) {
+	m.parent.dispatch(METHOD_VISIT_LABEL, m.member, []interface{}{label}, func() {
+		if m.next != nil {
+			m.next.VisitLabel(label)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitLdcInsn(value interface{}) {
+	m.parent.dispatch(METHOD_VISIT_LDC_INSN, m.member, []interface{}{value}, func() {
+		if m.next != nil {
+			m.next.VisitLdcInsn(value)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitIincInsn(vard, increment int) {
+	m.parent.dispatch(METHOD_VISIT_IINC_INSN, m.member, []interface{}{vard, increment}, func() {
+		if m.next != nil {
+			m.next.VisitIincInsn(vard, increment)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	m.parent.dispatch(METHOD_VISIT_TABLE_SWITCH_INSN, m.member, []interface{}{min, max, dflt, labels}, func() {
+		if m.next != nil {
+			m.next.VisitTableSwitchInsn(min, max, dflt, labels...)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	m.parent.dispatch(METHOD_VISIT_LOOKUP_SWITCH_INSN, m.member, []interface{}{dflt, keys, labels}, func() {
+		if m.next != nil {
+			m.next.VisitLookupSwitchInsn(dflt, keys, labels)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.parent.dispatch(METHOD_VISIT_MULTI_ANEW_ARRAY_INSN, m.member, []interface{}{descriptor, numDimensions}, func() {
+		if m.next != nil {
+			m.next.VisitMultiANewArrayInsn(descriptor, numDimensions)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	m.parent.dispatch(METHOD_VISIT_INSN_ANNOTATION, m.member, []interface{}{typeRef, typePath, descriptor, visible}, func() {
+		if m.next != nil {
+			result = m.next.VisitInsnAnnotation(typeRef, typePath, descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (m *middlewareMethodAdapter) VisitTryCatchBlock(start, end, handler *asm.Label, typed string) {
+	m.parent.dispatch(METHOD_VISIT_TRY_CATCH_BLOCK, m.member, []interface{}{start, end, handler, typed}, func() {
+		if m.next != nil {
+			m.next.VisitTryCatchBlock(start, end, handler, typed)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitTryCatchAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	m.parent.dispatch(METHOD_VISIT_TRY_CATCH_ANNOTATION, m.member, []interface{}{typeRef, typePath, descriptor, visible}, func() {
+		if m.next != nil {
+			result = m.next.VisitTryCatchAnnotation(typeRef, typePath, descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (m *middlewareMethodAdapter) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	m.parent.dispatch(METHOD_VISIT_LOCAL_VARIABLE, m.member, []interface{}{name, descriptor, signature, start, end, index}, func() {
+		if m.next != nil {
+			m.next.VisitLocalVariable(name, descriptor, signature, start, end, index)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	var result asm.AnnotationVisitor
+	m.parent.dispatch(METHOD_VISIT_LOCAL_VARIABLE_ANNOTATION, m.member, []interface{}{typeRef, typePath, start, end, index, descriptor, visible}, func() {
+		if m.next != nil {
+			result = m.next.VisitLocalVariableAnnotation(typeRef, typePath, start, end, index, descriptor, visible)
+		}
+	})
+	return result
+}
+
+func (m *middlewareMethodAdapter) VisitLineNumber(line int, start *asm.Label) {
+	m.parent.dispatch(METHOD_VISIT_LINE_NUMBER, m.member, []interface{}{line, start}, func() {
+		if m.next != nil {
+			m.next.VisitLineNumber(line, start)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitMaxs(maxStack int, maxLocals int) {
+	m.parent.dispatch(METHOD_VISIT_MAXS, m.member, []interface{}{maxStack, maxLocals}, func() {
+		if m.next != nil {
+			m.next.VisitMaxs(maxStack, maxLocals)
+		}
+	})
+}
+
+func (m *middlewareMethodAdapter) VisitEnd() {
+	m.parent.dispatch(METHOD_VISIT_END, m.member, nil, func() {
+		if m.next != nil {
+			m.next.VisitEnd()
+		}
+	})
+}
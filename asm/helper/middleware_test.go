@@ -0,0 +1,118 @@
+package helper_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// middlewareClass returns a class A with a field f and a method m, whose code is "return".
+func middlewareClass() []byte {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "A", "java/lang/Object")
+	classFile.AddField(0, "f", "I", "", nil)
+	methodWriter := classFile.AddMethod(0, "m", "()V", "", nil)
+	methodWriter.VisitCode()
+	methodWriter.VisitInsn(opcodes.RETURN)
+	methodWriter.VisitMaxs(0, 1)
+	methodWriter.VisitEnd()
+	return classFile.Bytes()
+}
+
+// logMiddleware returns a middleware logging its calls, whose Before function returns false for the events for
+// which skip returns true.
+func logMiddleware(name string, log *[]string, skip func(event *helper.Event) bool) helper.Middleware {
+	return helper.Middleware{
+		Before: func(event *helper.Event) bool {
+			*log = append(*log, name+" before "+event.String())
+			return skip == nil || !skip(event)
+		},
+		After: func(event *helper.Event) {
+			*log = append(*log, fmt.Sprintf("%s after %s skipped=%v", name, event, event.Skipped))
+		},
+	}
+}
+
+func acceptMiddlewares(t *testing.T, next asm.ClassVisitor, middlewares ...helper.Middleware) {
+	reader, err := asm.NewClassReader(middlewareClass())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.Accept(helper.NewMiddlewareClassAdapter(next, middlewares...), 0)
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var log []string
+	next := &helper.ClassVisitor{
+		OnVisit: func(version, access int, name, signature, superName string, interfaces []string) {
+			log = append(log, "next visit "+name)
+		},
+	}
+	acceptMiddlewares(t, next, logMiddleware("a", &log, nil), logMiddleware("b", &log, nil))
+	expected := []string{
+		"a before class visit A",
+		"b before class visit A",
+		"next visit A",
+		"b after class visit A skipped=false",
+		"a after class visit A skipped=false",
+	}
+	if strings.Join(log[:5], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected calls:\n%s", strings.Join(log, "\n"))
+	}
+	// The events of the members are reported, although next does not visit them.
+	if !strings.Contains(strings.Join(log, "\n"), "a before method visit insn A.m()V") {
+		t.Errorf("missing method events:\n%s", strings.Join(log, "\n"))
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	var log []string
+	recorder := asmtest.NewRecorder(nil)
+	skipInsns := func(event *helper.Event) bool { return event.Kind == helper.METHOD_VISIT_INSN }
+	acceptMiddlewares(t, recorder, logMiddleware("a", &log, skipInsns), logMiddleware("b", &log, nil))
+	// A skipped event is not given to the following middlewares, nor forwarded.
+	expected := []string{
+		"a before method visit insn A.m()V",
+		"a after method visit insn A.m()V skipped=true",
+	}
+	start := -1
+	for i, line := range log {
+		if strings.Contains(line, "visit insn") {
+			start = i
+			break
+		}
+	}
+	if start < 0 || strings.Join(log[start:start+2], "\n") != strings.Join(expected, "\n") ||
+		strings.Contains(log[start+2], "visit insn") {
+		t.Errorf("unexpected calls:\n%s", strings.Join(log, "\n"))
+	}
+	if len(recorder.GetEvents(helper.METHOD_VISIT_INSN, "m()V")) != 0 {
+		t.Error("the skipped event was forwarded")
+	}
+	if len(recorder.GetEvents(helper.METHOD_VISIT_MAXS, "m()V")) != 1 {
+		t.Error("the other events were not forwarded")
+	}
+}
+
+func TestMiddlewareSkipMembers(t *testing.T) {
+	var log []string
+	recorder := asmtest.NewRecorder(nil)
+	skipMembers := func(event *helper.Event) bool {
+		return event.Kind == helper.CLASS_VISIT_FIELD || event.Kind == helper.CLASS_VISIT_METHOD
+	}
+	acceptMiddlewares(t, recorder, logMiddleware("a", &log, skipMembers))
+	// Skipping a member removes all its events.
+	recorder.AssertTrace(t, []string{
+		`class visit A 52 33 "A" "" "java/lang/Object" []`,
+		`class visit end A`,
+	})
+	for _, line := range log {
+		if strings.Contains(line, "A.m()V") || strings.Contains(line, "A.f I") {
+			t.Errorf("unexpected member event %s", line)
+		}
+	}
+}
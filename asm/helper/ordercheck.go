@@ -0,0 +1,179 @@
+package helper
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm"
+)
+
+// class visitor states, in their documented order.
+const (
+	classStart = iota
+	classHeader
+	classSource
+	classModule
+	classOuterClass
+	classAnnotations
+	classMembers
+	classEnd
+)
+
+// method visitor states, in their documented order.
+const (
+	methodStart = iota
+	methodParameters
+	methodAnnotationDefault
+	methodAnnotations
+	methodCode
+	methodMaxs
+	methodEnd
+)
+
+// OrderChecker enforces the call order documented by {@link ClassVisitor} and {@link MethodVisitor} in the
+// strict mode of {@link ClassAdapter} and {@link MethodAdapter}. It checks the calls forwarded to the Next
+// visitor, including the ones made by the transformation itself, so that an ordering bug is reported where
+// it happens instead of surfacing as a corrupted class. The calls are forwarded even if they are out of order.
+type OrderChecker struct {
+	// Handler if not nil, is called with each violation, for instance to log it or to panic.
+	Handler func(err error)
+	// Violations the violations found so far, for this checker and the checkers of the visited methods.
+	Violations []error
+	root       *OrderChecker
+	state      int
+	labels     map[*asm.Label]bool
+}
+
+// NewOrderChecker constructs a new {@link OrderChecker}.
+func NewOrderChecker(handler func(err error)) *OrderChecker {
+	return &OrderChecker{Handler: handler}
+}
+
+// NewStrictClassAdapter constructs a new {@link ClassAdapter} checking the order of the calls forwarded to
+// next, and to the method visitors it returns.
+func NewStrictClassAdapter(next asm.ClassVisitor, handler func(err error)) *ClassAdapter {
+	return &ClassAdapter{Next: next, Order: NewOrderChecker(handler)}
+}
+
+// NewStrictMethodAdapter constructs a new {@link MethodAdapter} checking the order of the calls forwarded
+// to next.
+func NewStrictMethodAdapter(next asm.MethodVisitor, handler func(err error)) *MethodAdapter {
+	return &MethodAdapter{Next: next, Order: NewOrderChecker(handler)}
+}
+
+// newMethodChecker returns a checker for a method of the class checked by this checker.
+func (o *OrderChecker) newMethodChecker() *OrderChecker {
+	root := o
+	if o.root != nil {
+		root = o.root
+	}
+	return &OrderChecker{root: root}
+}
+
+func (o *OrderChecker) violation(message string) {
+	err := errors.New("Illegal State - " + message)
+	root := o
+	if o.root != nil {
+		root = o.root
+	}
+	root.Violations = append(root.Violations, err)
+	if root.Handler != nil {
+		root.Handler(err)
+	}
+}
+
+// class checks a ClassVisitor call which is valid in the states up to maxState, and moves to the next state.
+func (o *OrderChecker) class(method string, maxState, next int) {
+	if o == nil {
+		return
+	}
+	switch {
+	case o.state == classStart && next != classHeader:
+		o.violation(method + " called before Visit")
+	case o.state == classEnd:
+		o.violation(method + " called after VisitEnd")
+	case o.state > maxState:
+		o.violation(method + " called out of order")
+	}
+	if next > o.state {
+		o.state = next
+	}
+}
+
+// method checks a MethodVisitor call which is valid in the states up to maxState, and moves to the next
+// state.
+func (o *OrderChecker) method(method string, maxState, next int) {
+	if o == nil {
+		return
+	}
+	switch {
+	case o.state == methodEnd:
+		o.violation(method + " called after VisitEnd")
+	case o.state > maxState:
+		o.violation(method + " called out of order")
+	}
+	if next > o.state {
+		o.state = next
+	}
+}
+
+// insn checks a MethodVisitor call which is only valid between VisitCode and VisitMaxs.
+func (o *OrderChecker) insn(method string) {
+	if o == nil {
+		return
+	}
+	switch {
+	case o.state < methodCode:
+		o.violation(method + " called before VisitCode")
+	case o.state > methodCode:
+		o.violation(method + " called after VisitMaxs")
+	}
+}
+
+// label checks that the given labels have already been visited, or not, as expected by method.
+func (o *OrderChecker) label(method string, visited bool, labels ...*asm.Label) {
+	if o == nil {
+		return
+	}
+	for _, label := range labels {
+		if o.labels[label] != visited {
+			if visited {
+				o.violation(method + " called before its labels are visited")
+			} else {
+				o.violation(method + " called after its labels are visited")
+			}
+			return
+		}
+	}
+}
+
+func (o *OrderChecker) visitLabel(label *asm.Label) {
+	if o == nil {
+		return
+	}
+	o.insn("VisitLabel")
+	if o.labels == nil {
+		o.labels = make(map[*asm.Label]bool)
+	}
+	if o.labels[label] {
+		o.violation("VisitLabel called twice with the same label")
+	}
+	o.labels[label] = true
+}
+
+func (o *OrderChecker) visitMaxs() {
+	if o == nil {
+		return
+	}
+	o.insn("VisitMaxs")
+	o.state = methodMaxs
+}
+
+func (o *OrderChecker) visitMethodEnd() {
+	if o == nil {
+		return
+	}
+	if o.state == methodCode {
+		o.violation("VisitEnd called before VisitMaxs")
+	}
+	o.method("VisitEnd", methodMaxs, methodEnd)
+}
@@ -0,0 +1,118 @@
+package helper_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// visitHeader visits the header of a class A.
+func visitHeader(classVisitor asm.ClassVisitor) {
+	classVisitor.Visit(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "A", "", "java/lang/Object", nil)
+}
+
+// visitMethodCode visits the header of a class A and returns the visitor of its method m, after VisitCode.
+func visitMethodCode(classVisitor asm.ClassVisitor) asm.MethodVisitor {
+	visitHeader(classVisitor)
+	methodVisitor := classVisitor.VisitMethod(opcodes.ACC_STATIC, "m", "()V", "", nil)
+	methodVisitor.VisitCode()
+	return methodVisitor
+}
+
+func TestOrderCheckerViolations(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		visit   func(classVisitor asm.ClassVisitor)
+		message string
+	}{
+		{"before Visit", func(classVisitor asm.ClassVisitor) {
+			classVisitor.VisitSource("A.java", "")
+		}, "VisitSource called before Visit"},
+		{"after VisitEnd", func(classVisitor asm.ClassVisitor) {
+			visitHeader(classVisitor)
+			classVisitor.VisitEnd()
+			classVisitor.VisitField(0, "f", "I", "", nil)
+		}, "VisitField called after VisitEnd"},
+		{"members out of order", func(classVisitor asm.ClassVisitor) {
+			visitHeader(classVisitor)
+			classVisitor.VisitField(0, "f", "I", "", nil)
+			classVisitor.VisitAnnotation("LA;", true)
+		}, "VisitAnnotation called out of order"},
+		{"insn before VisitCode", func(classVisitor asm.ClassVisitor) {
+			visitHeader(classVisitor)
+			classVisitor.VisitMethod(opcodes.ACC_STATIC, "m", "()V", "", nil).VisitInsn(opcodes.RETURN)
+		}, "VisitInsn called before VisitCode"},
+		{"line number before its label", func(classVisitor asm.ClassVisitor) {
+			methodVisitor := visitMethodCode(classVisitor)
+			label := &asm.Label{}
+			methodVisitor.VisitLineNumber(1, label)
+			methodVisitor.VisitLabel(label)
+		}, "VisitLineNumber called before its labels are visited"},
+		{"try catch block after its label", func(classVisitor asm.ClassVisitor) {
+			methodVisitor := visitMethodCode(classVisitor)
+			start, end, handler := &asm.Label{}, &asm.Label{}, &asm.Label{}
+			methodVisitor.VisitLabel(start)
+			methodVisitor.VisitTryCatchBlock(start, end, handler, "")
+		}, "VisitTryCatchBlock called after its labels are visited"},
+		{"VisitEnd before VisitMaxs", func(classVisitor asm.ClassVisitor) {
+			methodVisitor := visitMethodCode(classVisitor)
+			methodVisitor.VisitInsn(opcodes.RETURN)
+			methodVisitor.VisitEnd()
+		}, "VisitEnd called before VisitMaxs"},
+	} {
+		var handled []error
+		recorder := asmtest.NewRecorder(nil)
+		adapter := helper.NewStrictClassAdapter(recorder, func(err error) { handled = append(handled, err) })
+		test.visit(adapter)
+		violations := adapter.Order.Violations
+		if len(violations) != 1 || !strings.Contains(violations[0].Error(), test.message) {
+			t.Errorf("%s: expected a %q violation, got %v", test.name, test.message, violations)
+			continue
+		}
+		if len(handled) != 1 || handled[0] != violations[0] {
+			t.Errorf("%s: the violation was not given to the handler: %v", test.name, handled)
+		}
+		// The calls are forwarded even if they are out of order.
+		if len(recorder.Trace()) == 0 {
+			t.Errorf("%s: the calls were not forwarded", test.name)
+		}
+	}
+}
+
+func TestOrderCheckerMethodAdapter(t *testing.T) {
+	adapter := helper.NewStrictMethodAdapter(nil, nil)
+	adapter.VisitCode()
+	adapter.VisitMaxs(0, 0)
+	adapter.VisitInsn(opcodes.RETURN)
+	if violations := adapter.Order.Violations; len(violations) != 1 ||
+		!strings.Contains(violations[0].Error(), "VisitInsn called after VisitMaxs") {
+		t.Errorf("unexpected violations %v", violations)
+	}
+}
+
+func TestOrderCheckerValidClasses(t *testing.T) {
+	exampleClass, err := os.ReadFile("../../ExampleClass.class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, classFile := range [][]byte{exampleClass, middlewareClass()} {
+		reader, err := asm.NewClassReader(classFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := asmtest.NewRecorder(nil)
+		adapter := helper.NewStrictClassAdapter(recorder, nil)
+		reader.Accept(adapter, 0)
+		if len(adapter.Order.Violations) != 0 {
+			t.Errorf("unexpected violations %v", adapter.Order.Violations)
+		}
+		if len(recorder.GetEvents(helper.CLASS_VISIT_END, "")) != 1 {
+			t.Error("the class was not fully visited")
+		}
+	}
+}
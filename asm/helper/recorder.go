@@ -0,0 +1,354 @@
+package helper
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm"
+)
+
+// MethodRecorder a {@link MethodVisitor} that records the events it visits into a buffer of {@link Event}s,
+// with the same Kind and Args as those of a {@link MiddlewareClassAdapter}, without building a full node model.
+// The buffer can then be inspected, edited by index, and replayed to another method visitor, to implement two
+// pass transformations (e.g. inspect the whole code, then rewrite it). The Class and Member of the events are
+// not set. The values of the recorded annotations are replayed, but are not part of the events.
+type MethodRecorder struct {
+	// Events the recorded events, in visit order. It can be modified directly, or with {@link Insert} and {@link
+	// Remove}.
+	Events      []*Event
+	annotations map[*Event]*annotationRecorder
+}
+
+// NewMethodRecorder constructs a new, empty {@link MethodRecorder}.
+func NewMethodRecorder() *MethodRecorder {
+	return &MethodRecorder{annotations: make(map[*Event]*annotationRecorder)}
+}
+
+// NewMethodEvent returns a new method event, to insert in a {@link MethodRecorder}. The arguments must be those
+// of the corresponding visit method, in declaration order, with the variadic arguments given as a slice.
+func NewMethodEvent(kind int, args ...interface{}) *Event {
+	return &Event{Kind: kind, Args: args}
+}
+
+// Insert inserts the given events at the given index.
+func (m *MethodRecorder) Insert(index int, events ...*Event) {
+	m.Events = append(m.Events[:index], append(append([]*Event(nil), events...), m.Events[index:]...)...)
+}
+
+// Remove removes the count events starting at the given index.
+func (m *MethodRecorder) Remove(index, count int) {
+	for _, event := range m.Events[index : index+count] {
+		delete(m.annotations, event)
+	}
+	m.Events = append(m.Events[:index], m.Events[index+count:]...)
+}
+
+// IndexOf returns the index of the first event of the given kind at or after the given index, or -1.
+func (m *MethodRecorder) IndexOf(kind, from int) int {
+	for i := from; i < len(m.Events); i++ {
+		if m.Events[i].Kind == kind {
+			return i
+		}
+	}
+	return -1
+}
+
+// record records an event, and returns the recorder of its annotation values for the annotation events.
+func (m *MethodRecorder) record(kind int, args ...interface{}) *annotationRecorder {
+	event := &Event{Kind: kind, Args: args}
+	m.Events = append(m.Events, event)
+	switch kind {
+	case METHOD_VISIT_ANNOTATION_DEFAULT, METHOD_VISIT_ANNOTATION, METHOD_VISIT_TYPE_ANNOTATION,
+		METHOD_VISIT_PARAMETER_ANNOTATION, METHOD_VISIT_INSN_ANNOTATION, METHOD_VISIT_TRY_CATCH_ANNOTATION,
+		METHOD_VISIT_LOCAL_VARIABLE_ANNOTATION:
+		annotation := &annotationRecorder{}
+		m.annotations[event] = annotation
+		return annotation
+	}
+	return nil
+}
+
+// Replay makes the given method visitor visit the recorded events, in order. Returns an error if an event has
+// an unknown kind or unexpected arguments (e.g. an inserted event built with wrong arguments).
+func (m *MethodRecorder) Replay(methodVisitor asm.MethodVisitor) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			runtimeError, ok := recovered.(runtime.Error)
+			if !ok {
+				panic(recovered)
+			}
+			err = errors.New("Illegal Argument - invalid recorded event: " + runtimeError.Error())
+		}
+	}()
+	for _, event := range m.Events {
+		if err := m.replay(event, methodVisitor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replay makes the given method visitor visit the given event. A type assertion or an index panics if the
+// arguments of the event are invalid.
+func (m *MethodRecorder) replay(event *Event, methodVisitor asm.MethodVisitor) error {
+	args := event.Args
+	var annotationVisitor asm.AnnotationVisitor
+	switch event.Kind {
+	case METHOD_VISIT_PARAMETER:
+		methodVisitor.VisitParameter(args[0].(string), args[1].(int))
+	case METHOD_VISIT_ANNOTATION_DEFAULT:
+		annotationVisitor = methodVisitor.VisitAnnotationDefault()
+	case METHOD_VISIT_ANNOTATION:
+		annotationVisitor = methodVisitor.VisitAnnotation(args[0].(string), args[1].(bool))
+	case METHOD_VISIT_TYPE_ANNOTATION:
+		annotationVisitor = methodVisitor.VisitTypeAnnotation(args[0].(int), args[1].(*asm.TypePath), args[2].(string), args[3].(bool))
+	case METHOD_VISIT_ANNOTABLE_PARAMETER_COUNT:
+		methodVisitor.VisitAnnotableParameterCount(args[0].(int), args[1].(bool))
+	case METHOD_VISIT_PARAMETER_ANNOTATION:
+		annotationVisitor = methodVisitor.VisitParameterAnnotation(args[0].(int), args[1].(string), args[2].(bool))
+	case METHOD_VISIT_ATTRIBUTE:
+		methodVisitor.VisitAttribute(args[0].(*asm.Attribute))
+	case METHOD_VISIT_CODE:
+		methodVisitor.VisitCode()
+	case METHOD_VISIT_FRAME:
+		methodVisitor.VisitFrame(args[0].(int), args[1].(int), args[2], args[3].(int), args[4])
+	case METHOD_VISIT_INSN:
+		methodVisitor.VisitInsn(args[0].(int))
+	case METHOD_VISIT_INT_INSN:
+		methodVisitor.VisitIntInsn(args[0].(int), args[1].(int))
+	case METHOD_VISIT_VAR_INSN:
+		methodVisitor.VisitVarInsn(args[0].(int), args[1].(int))
+	case METHOD_VISIT_TYPE_INSN:
+		methodVisitor.VisitTypeInsn(args[0].(int), args[1].(string))
+	case METHOD_VISIT_FIELD_INSN:
+		methodVisitor.VisitFieldInsn(args[0].(int), args[1].(string), args[2].(string), args[3].(string))
+	case METHOD_VISIT_METHOD_INSN:
+		if len(args) == 4 {
+			methodVisitor.VisitMethodInsn(args[0].(int), args[1].(string), args[2].(string), args[3].(string))
+		} else {
+			methodVisitor.VisitMethodInsnB(args[0].(int), args[1].(string), args[2].(string), args[3].(string), args[4].(bool))
+		}
+	case METHOD_VISIT_INVOKE_DYNAMIC_INSN:
+		methodVisitor.VisitInvokeDynamicInsn(args[0].(string), args[1].(string), args[2].(*asm.Handle), args[3].([]interface{})...)
+	case METHOD_VISIT_JUMP_INSN:
+		methodVisitor.VisitJumpInsn(args[0].(int), args[1].(*asm.Label))
+	case METHOD_VISIT_LABEL:
+		methodVisitor.VisitLabel(args[0].(*asm.Label))
+	case METHOD_VISIT_LDC_INSN:
+		methodVisitor.VisitLdcInsn(args[0])
+	case METHOD_VISIT_IINC_INSN:
+		methodVisitor.VisitIincInsn(args[0].(int), args[1].(int))
+	case METHOD_VISIT_TABLE_SWITCH_INSN:
+		methodVisitor.VisitTableSwitchInsn(args[0].(int), args[1].(int), args[2].(*asm.Label), args[3].([]*asm.Label)...)
+	case METHOD_VISIT_LOOKUP_SWITCH_INSN:
+		methodVisitor.VisitLookupSwitchInsn(args[0].(*asm.Label), args[1].([]int), args[2].([]*asm.Label))
+	case METHOD_VISIT_MULTI_ANEW_ARRAY_INSN:
+		methodVisitor.VisitMultiANewArrayInsn(args[0].(string), args[1].(int))
+	case METHOD_VISIT_INSN_ANNOTATION:
+		annotationVisitor = methodVisitor.VisitInsnAnnotation(args[0].(int), args[1].(*asm.TypePath), args[2].(string), args[3].(bool))
+	case METHOD_VISIT_TRY_CATCH_BLOCK:
+		methodVisitor.VisitTryCatchBlock(args[0].(*asm.Label), args[1].(*asm.Label), args[2].(*asm.Label), args[3].(string))
+	case METHOD_VISIT_TRY_CATCH_ANNOTATION:
+		annotationVisitor = methodVisitor.VisitTryCatchAnnotation(args[0].(int), args[1].(*asm.TypePath), args[2].(string), args[3].(bool))
+	case METHOD_VISIT_LOCAL_VARIABLE:
+		methodVisitor.VisitLocalVariable(args[0].(string), args[1].(string), args[2].(string), args[3].(*asm.Label), args[4].(*asm.Label), args[5].(int))
+	case METHOD_VISIT_LOCAL_VARIABLE_ANNOTATION:
+		annotationVisitor = methodVisitor.VisitLocalVariableAnnotation(args[0].(int), args[1].(*asm.TypePath), args[2].([]*asm.Label),
+			args[3].([]*asm.Label), args[4].([]int), args[5].(string), args[6].(bool))
+	case METHOD_VISIT_LINE_NUMBER:
+		methodVisitor.VisitLineNumber(args[0].(int), args[1].(*asm.Label))
+	case METHOD_VISIT_MAXS:
+		methodVisitor.VisitMaxs(args[0].(int), args[1].(int))
+	case METHOD_VISIT_END:
+		methodVisitor.VisitEnd()
+	default:
+		return errors.New("Illegal Argument - " + strconv.Itoa(event.Kind) + " is not a method event kind")
+	}
+	if annotationVisitor != nil {
+		if annotation, ok := m.annotations[event]; ok {
+			annotation.replay(annotationVisitor)
+		} else {
+			annotationVisitor.VisitEnd()
+		}
+	}
+	return nil
+}
+
+func (m *MethodRecorder) VisitParameter(name string, access int) {
+	m.record(METHOD_VISIT_PARAMETER, name, access)
+}
+
+func (m *MethodRecorder) VisitAnnotationDefault() asm.AnnotationVisitor {
+	return m.record(METHOD_VISIT_ANNOTATION_DEFAULT)
+}
+
+func (m *MethodRecorder) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.record(METHOD_VISIT_ANNOTATION, descriptor, visible)
+}
+
+func (m *MethodRecorder) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.record(METHOD_VISIT_TYPE_ANNOTATION, typeRef, typePath, descriptor, visible)
+}
+
+func (m *MethodRecorder) VisitAnnotableParameterCount(parameterCount int, visible bool) {
+	m.record(METHOD_VISIT_ANNOTABLE_PARAMETER_COUNT, parameterCount, visible)
+}
+
+func (m *MethodRecorder) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.record(METHOD_VISIT_PARAMETER_ANNOTATION, parameter, descriptor, visible)
+}
+
+func (m *MethodRecorder) VisitAttribute(attribute *asm.Attribute) {
+	m.record(METHOD_VISIT_ATTRIBUTE, attribute)
+}
+
+func (m *MethodRecorder) VisitCode() {
+	m.record(METHOD_VISIT_CODE)
+}
+
+func (m *MethodRecorder) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	m.record(METHOD_VISIT_FRAME, typed, nLocal, local, nStack, stack)
+}
+
+func (m *MethodRecorder) VisitInsn(opcode int) {
+	m.record(METHOD_VISIT_INSN, opcode)
+}
+
+func (m *MethodRecorder) VisitIntInsn(opcode, operand int) {
+	m.record(METHOD_VISIT_INT_INSN, opcode, operand)
+}
+
+func (m *MethodRecorder) VisitVarInsn(opcode, vard int) {
+	m.record(METHOD_VISIT_VAR_INSN, opcode, vard)
+}
+
+func (m *MethodRecorder) VisitTypeInsn(opcode int, typed string) {
+	m.record(METHOD_VISIT_TYPE_INSN, opcode, typed)
+}
+
+func (m *MethodRecorder) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.record(METHOD_VISIT_FIELD_INSN, opcode, owner, name, descriptor)
+}
+
+func (m *MethodRecorder) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.record(METHOD_VISIT_METHOD_INSN, opcode, owner, name, descriptor)
+}
+
+func (m *MethodRecorder) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.record(METHOD_VISIT_METHOD_INSN, opcode, owner, name, descriptor, isInterface)
+}
+
+func (m *MethodRecorder) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	m.record(METHOD_VISIT_INVOKE_DYNAMIC_INSN, name, descriptor, bootstrapMethodHande, bootstrapMethodArguments)
+}
+
+func (m *MethodRecorder) VisitJumpInsn(opcode int, label *asm.Label) {
+	m.record(METHOD_VISIT_JUMP_INSN, opcode, label)
+}
+
+func (m *MethodRecorder) VisitLabel(label *asm.Label) {
+	m.record(METHOD_VISIT_LABEL, label)
+}
+
+func (m *MethodRecorder) VisitLdcInsn(value interface{}) {
+	m.record(METHOD_VISIT_LDC_INSN, value)
+}
+
+func (m *MethodRecorder) VisitIincInsn(vard, increment int) {
+	m.record(METHOD_VISIT_IINC_INSN, vard, increment)
+}
+
+func (m *MethodRecorder) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	m.record(METHOD_VISIT_TABLE_SWITCH_INSN, min, max, dflt, labels)
+}
+
+func (m *MethodRecorder) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	m.record(METHOD_VISIT_LOOKUP_SWITCH_INSN, dflt, keys, labels)
+}
+
+func (m *MethodRecorder) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.record(METHOD_VISIT_MULTI_ANEW_ARRAY_INSN, descriptor, numDimensions)
+}
+
+func (m *MethodRecorder) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.record(METHOD_VISIT_INSN_ANNOTATION, typeRef, typePath, descriptor, visible)
+}
+
+func (m *MethodRecorder) VisitTryCatchBlock(start, end, handler *asm.Label, typed string) {
+	m.record(METHOD_VISIT_TRY_CATCH_BLOCK, start, end, handler, typed)
+}
+
+func (m *MethodRecorder) VisitTryCatchAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.record(METHOD_VISIT_TRY_CATCH_ANNOTATION, typeRef, typePath, descriptor, visible)
+}
+
+func (m *MethodRecorder) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	m.record(METHOD_VISIT_LOCAL_VARIABLE, name, descriptor, signature, start, end, index)
+}
+
+func (m *MethodRecorder) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return m.record(METHOD_VISIT_LOCAL_VARIABLE_ANNOTATION, typeRef, typePath, start, end, index, descriptor, visible)
+}
+
+func (m *MethodRecorder) VisitLineNumber(line int, start *asm.Label) {
+	m.record(METHOD_VISIT_LINE_NUMBER, line, start)
+}
+
+func (m *MethodRecorder) VisitMaxs(maxStack int, maxLocals int) {
+	m.record(METHOD_VISIT_MAXS, maxStack, maxLocals)
+}
+
+func (m *MethodRecorder) VisitEnd() {
+	m.record(METHOD_VISIT_END)
+}
+
+// annotationRecorder an AnnotationVisitor that records the values of an annotation, to replay them.
+type annotationRecorder struct {
+	events []func(annotationVisitor asm.AnnotationVisitor)
+}
+
+// replay makes the given annotation visitor visit the recorded values.
+func (a *annotationRecorder) replay(annotationVisitor asm.AnnotationVisitor) {
+	for _, event := range a.events {
+		event(annotationVisitor)
+	}
+}
+
+func (a *annotationRecorder) Visit(name string, value interface{}) {
+	a.events = append(a.events, func(annotationVisitor asm.AnnotationVisitor) {
+		annotationVisitor.Visit(name, value)
+	})
+}
+
+func (a *annotationRecorder) VisitEnum(name, descriptor, value string) {
+	a.events = append(a.events, func(annotationVisitor asm.AnnotationVisitor) {
+		annotationVisitor.VisitEnum(name, descriptor, value)
+	})
+}
+
+func (a *annotationRecorder) VisitAnnotation(name, descriptor string) asm.AnnotationVisitor {
+	nested := &annotationRecorder{}
+	a.events = append(a.events, func(annotationVisitor asm.AnnotationVisitor) {
+		if nestedVisitor := annotationVisitor.VisitAnnotation(name, descriptor); nestedVisitor != nil {
+			nested.replay(nestedVisitor)
+		}
+	})
+	return nested
+}
+
+func (a *annotationRecorder) VisitArray(name string) asm.AnnotationVisitor {
+	nested := &annotationRecorder{}
+	a.events = append(a.events, func(annotationVisitor asm.AnnotationVisitor) {
+		if nestedVisitor := annotationVisitor.VisitArray(name); nestedVisitor != nil {
+			nested.replay(nestedVisitor)
+		}
+	})
+	return nested
+}
+
+func (a *annotationRecorder) VisitEnd() {
+	a.events = append(a.events, func(annotationVisitor asm.AnnotationVisitor) {
+		annotationVisitor.VisitEnd()
+	})
+}
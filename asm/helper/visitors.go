@@ -7,6 +7,8 @@ type ClassVisitor struct {
 	OnVisitField  func(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor
 	OnVisitMethod func(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor
 	OnVisitEnd    func()
+	// OnVisitAttribute is called for the non standard attributes of the class.
+	OnVisitAttribute func(attribute *asm.Attribute)
 }
 
 func (c ClassVisitor) Visit(version, access int, name, signature, superName string, interfaces []string) {
@@ -36,7 +38,9 @@ func (c ClassVisitor) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, d
 }
 
 func (c ClassVisitor) VisitAttribute(attribute *asm.Attribute) {
-
+	if c.OnVisitAttribute != nil {
+		c.OnVisitAttribute(attribute)
+	}
 }
 
 func (c ClassVisitor) VisitInnerClass(name, outerName, innerName string, access int) {
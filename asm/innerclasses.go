@@ -0,0 +1,77 @@
+package asm
+
+// EnclosingMethod the content of the EnclosingMethod attribute of a local or anonymous class, as given to
+// {@link ClassVisitor#VisitOuterClass}.
+type EnclosingMethod struct {
+	// Owner the internal name of the enclosing class of the class.
+	Owner string
+	// Name the name of the method that contains the class, or "" if the class is not enclosed in a method (e.g.
+	// in a field initializer).
+	Name string
+	// Descriptor the descriptor of the method that contains the class, or "".
+	Descriptor string
+}
+
+// InnerClass an entry of the InnerClasses attribute, as given to {@link ClassVisitor#VisitInnerClass}.
+type InnerClass struct {
+	// Name the internal name of the inner class.
+	Name string
+	// OuterName the internal name of the class to which the inner class belongs, or "" for local and anonymous
+	// classes.
+	OuterName string
+	// InnerName the simple name of the inner class inside its enclosing class, or "" for anonymous classes.
+	InnerName string
+	// Access the access flags of the inner class as originally declared in the enclosing class.
+	Access int
+}
+
+// getClassAttributeOffset returns the offset of the content of the given class attribute, or 0 if the class
+// does not have it.
+func (c *ClassReader) getClassAttributeOffset(attributeName string, charBuffer []rune) int {
+	currentAttributeOffset := c.getFirstAttributeOffset()
+	for i := c.readUnsignedShort(currentAttributeOffset - 2); i > 0; i-- {
+		if c.readUTF8(currentAttributeOffset, charBuffer) == attributeName {
+			return currentAttributeOffset + 6
+		}
+		currentAttributeOffset += 6 + c.readInt(currentAttributeOffset+2)
+	}
+	return 0
+}
+
+// GetEnclosingMethod returns the content of the EnclosingMethod attribute of the class, or nil if it does not
+// have one (i.e. if it is not a local or anonymous class).
+func (c *ClassReader) GetEnclosingMethod() *EnclosingMethod {
+	charBuffer := make([]rune, c.maxStringLength)
+	enclosingMethodOffset := c.getClassAttributeOffset("EnclosingMethod", charBuffer)
+	if enclosingMethodOffset == 0 {
+		return nil
+	}
+	enclosingMethod := &EnclosingMethod{Owner: c.readClass(enclosingMethodOffset, charBuffer)}
+	if methodIndex := c.readUnsignedShort(enclosingMethodOffset + 2); methodIndex != 0 {
+		enclosingMethod.Name = c.readUTF8(c.cpInfoOffsets[methodIndex], charBuffer)
+		enclosingMethod.Descriptor = c.readUTF8(c.cpInfoOffsets[methodIndex]+2, charBuffer)
+	}
+	return enclosingMethod
+}
+
+// GetInnerClasses returns the entries of the InnerClasses attribute of the class, i.e. its inner classes, the
+// classes it is an inner class of, and the other inner classes it references.
+func (c *ClassReader) GetInnerClasses() []InnerClass {
+	charBuffer := make([]rune, c.maxStringLength)
+	innerClassesOffset := c.getClassAttributeOffset("InnerClasses", charBuffer)
+	if innerClassesOffset == 0 {
+		return nil
+	}
+	innerClasses := make([]InnerClass, c.readUnsignedShort(innerClassesOffset))
+	currentClassesOffset := innerClassesOffset + 2
+	for i := range innerClasses {
+		innerClasses[i] = InnerClass{
+			Name:      c.readClass(currentClassesOffset, charBuffer),
+			OuterName: c.readClass(currentClassesOffset+2, charBuffer),
+			InnerName: c.readUTF8(currentClassesOffset+4, charBuffer),
+			Access:    c.readUnsignedShort(currentClassesOffset + 6),
+		}
+		currentClassesOffset += 8
+	}
+	return innerClasses
+}
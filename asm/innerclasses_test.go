@@ -0,0 +1,64 @@
+package asm_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// nestedClass returns a class with the given name, enclosing method (ignored if its owner is "") and inner class
+// entries.
+func nestedClass(t *testing.T, name string, enclosingMethod asm.EnclosingMethod, innerClasses ...asm.InnerClass) *asm.ClassReader {
+	classWriter := asm.NewClassWriter(nil)
+	classWriter.Visit(opcodes.V1_8, opcodes.ACC_SUPER, name, "", "java/lang/Object", nil)
+	if enclosingMethod.Owner != "" {
+		classWriter.VisitOuterClass(enclosingMethod.Owner, enclosingMethod.Name, enclosingMethod.Descriptor)
+	}
+	for _, innerClass := range innerClasses {
+		classWriter.VisitInnerClass(innerClass.Name, innerClass.OuterName, innerClass.InnerName, innerClass.Access)
+	}
+	classWriter.VisitEnd()
+	classFile, err := classWriter.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader
+}
+
+func TestGetEnclosingMethodAndInnerClasses(t *testing.T) {
+	anonymous := asm.InnerClass{Name: "p/C$1", Access: 0}
+	local := asm.InnerClass{Name: "p/C$1Local", InnerName: "Local", Access: opcodes.ACC_FINAL}
+	member := asm.InnerClass{Name: "p/C$D", OuterName: "p/C", InnerName: "D", Access: opcodes.ACC_PUBLIC | opcodes.ACC_STATIC}
+	for _, test := range []struct {
+		name            string
+		reader          *asm.ClassReader
+		enclosingMethod *asm.EnclosingMethod
+		innerClasses    []asm.InnerClass
+	}{
+		// The top level class lists all its inner classes.
+		{"top level", nestedClass(t, "p/C", asm.EnclosingMethod{}, anonymous, local, member), nil,
+			[]asm.InnerClass{anonymous, local, member}},
+		// An anonymous class declared in a method.
+		{"anonymous", nestedClass(t, "p/C$1", asm.EnclosingMethod{Owner: "p/C", Name: "m", Descriptor: "(I)V"}, anonymous),
+			&asm.EnclosingMethod{Owner: "p/C", Name: "m", Descriptor: "(I)V"}, []asm.InnerClass{anonymous}},
+		// A local class declared in a field initializer, which has no enclosing method.
+		{"local", nestedClass(t, "p/C$1Local", asm.EnclosingMethod{Owner: "p/C"}, local),
+			&asm.EnclosingMethod{Owner: "p/C"}, []asm.InnerClass{local}},
+		// A member class has no EnclosingMethod attribute.
+		{"member", nestedClass(t, "p/C$D", asm.EnclosingMethod{}, member), nil, []asm.InnerClass{member}},
+		{"none", nestedClass(t, "p/E", asm.EnclosingMethod{}), nil, nil},
+	} {
+		if enclosingMethod := test.reader.GetEnclosingMethod(); !reflect.DeepEqual(enclosingMethod, test.enclosingMethod) {
+			t.Errorf("%s: unexpected enclosing method %+v", test.name, enclosingMethod)
+		}
+		if innerClasses := test.reader.GetInnerClasses(); !reflect.DeepEqual(innerClasses, test.innerClasses) {
+			t.Errorf("%s: unexpected inner classes %+v", test.name, innerClasses)
+		}
+	}
+}
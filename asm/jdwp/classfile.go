@@ -0,0 +1,390 @@
+package jdwp
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/symbol"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// member a field or method of a class, as returned by the JVM.
+type member struct {
+	id                                uint64
+	name, signature, genericSignature string
+	access                            int
+}
+
+// ReadClass returns a class file reconstructed from the class with the given internal name loaded in the JVM,
+// with its constant pool, header, fields and methods, and the bytecode of its methods. If the class is loaded by
+// several class loaders, the first one returned by the JVM is used.
+//
+// JDWP does not give access to the original class file, so the reconstructed one is only suitable for
+// inspection: the methods have no exception table, line numbers, local variables nor stack map frames, their
+// max_locals is computed from their instructions, and their max_stack is unknown (set to 65535). The
+// annotations and the other attributes are not returned by the JVM. The JVM must support the
+// canGetConstantPool and canGetBytecodes capabilities.
+func (c *Client) ReadClass(className string) ([]byte, error) {
+	writer := &packetWriter{}
+	writer.writeString("L" + className + ";")
+	data, err := c.command(virtualMachineCommandSet, classesBySignature, writer.data)
+	if err != nil {
+		return nil, err
+	}
+	reader := &packetReader{data: data}
+	if reader.readInt() < 1 {
+		if reader.err != nil {
+			return nil, reader.err
+		}
+		return nil, errors.New("Illegal Argument - class " + className + " is not loaded")
+	}
+	typeTag := reader.readByte()
+	classID := reader.readID(c.referenceTypeIDSize)
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	reader, err = c.referenceTypeCommand(classFileVersion, classID)
+	if err != nil {
+		return nil, err
+	}
+	majorVersion, minorVersion := reader.readInt(), reader.readInt()
+	reader, err = c.referenceTypeCommand(constantPool, classID)
+	if err != nil {
+		return nil, err
+	}
+	constantPoolCount, constantPoolBytes := reader.readInt(), reader.readBytes()
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	pool, err := newConstantPoolBuilder(constantPoolCount, constantPoolBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err = c.referenceTypeCommand(modifiers, classID)
+	if err != nil {
+		return nil, err
+	}
+	access := reader.readInt() & 0xFFFF
+	superIndex := 0
+	if typeTag == typeTagClass {
+		writer := &packetWriter{}
+		writer.writeID(classID, c.referenceTypeIDSize)
+		data, err := c.command(classTypeCommandSet, superclass, writer.data)
+		if err != nil {
+			return nil, err
+		}
+		if superID := (&packetReader{data: data}).readID(c.referenceTypeIDSize); superID != 0 {
+			superName, err := c.getInternalName(superID)
+			if err != nil {
+				return nil, err
+			}
+			superIndex = pool.classIndex(superName)
+		}
+	}
+	reader, err = c.referenceTypeCommand(interfaces, classID)
+	if err != nil {
+		return nil, err
+	}
+	interfaceIDs := make([]uint64, reader.readInt())
+	for i := range interfaceIDs {
+		interfaceIDs[i] = reader.readID(c.referenceTypeIDSize)
+	}
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	body := binary.BigEndian.AppendUint16(nil, uint16(access))
+	body = binary.BigEndian.AppendUint16(body, uint16(pool.classIndex(className)))
+	body = binary.BigEndian.AppendUint16(body, uint16(superIndex))
+	body = binary.BigEndian.AppendUint16(body, uint16(len(interfaceIDs)))
+	for _, interfaceID := range interfaceIDs {
+		interfaceName, err := c.getInternalName(interfaceID)
+		if err != nil {
+			return nil, err
+		}
+		body = binary.BigEndian.AppendUint16(body, uint16(pool.classIndex(interfaceName)))
+	}
+
+	fields, err := c.getMembers(fieldsWithGeneric, classID, c.fieldIDSize)
+	if err != nil {
+		return nil, err
+	}
+	body = binary.BigEndian.AppendUint16(body, uint16(len(fields)))
+	for _, field := range fields {
+		body = appendMember(body, pool, field)
+		body = appendSignature(body, pool, field)
+	}
+
+	methods, err := c.getMembers(methodsWithGeneric, classID, c.methodIDSize)
+	if err != nil {
+		return nil, err
+	}
+	body = binary.BigEndian.AppendUint16(body, uint16(len(methods)))
+	// The offsets in body of the max_locals fields of the Code attributes.
+	var maxLocalsOffsets []int
+	for _, method := range methods {
+		body = appendMember(body, pool, method)
+		var code []byte
+		if method.access&(opcodes.ACC_ABSTRACT|opcodes.ACC_NATIVE) == 0 {
+			writer := &packetWriter{}
+			writer.writeID(classID, c.referenceTypeIDSize)
+			writer.writeID(method.id, c.methodIDSize)
+			data, err := c.command(methodCommandSet, bytecodes, writer.data)
+			if err != nil {
+				return nil, err
+			}
+			reader := &packetReader{data: data}
+			if code = reader.readBytes(); reader.err != nil {
+				return nil, reader.err
+			}
+		}
+		if len(code) == 0 {
+			body = appendSignature(body, pool, method)
+			continue
+		}
+		attributesCount := 1
+		if method.genericSignature != "" {
+			attributesCount++
+		}
+		body = binary.BigEndian.AppendUint16(body[:len(body)-2], uint16(attributesCount))
+		body = binary.BigEndian.AppendUint16(body, uint16(pool.utf8Index("Code")))
+		body = binary.BigEndian.AppendUint32(body, uint32(12+len(code)))
+		body = binary.BigEndian.AppendUint16(body, 0xFFFF)
+		maxLocalsOffsets = append(maxLocalsOffsets, len(body))
+		body = binary.BigEndian.AppendUint16(body, 0xFFFF)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(code)))
+		body = append(body, code...)
+		body = append(body, 0, 0, 0, 0)
+		if method.genericSignature != "" {
+			body = appendSignatureAttribute(body, pool, method.genericSignature)
+		}
+	}
+
+	reader, err = c.referenceTypeCommand(sourceFile, classID)
+	var replyError *jdwpError
+	switch {
+	case err == nil:
+		source := reader.readString()
+		body = binary.BigEndian.AppendUint16(body, 1)
+		body = binary.BigEndian.AppendUint16(body, uint16(pool.utf8Index("SourceFile")))
+		body = binary.BigEndian.AppendUint32(body, 2)
+		body = binary.BigEndian.AppendUint16(body, uint16(pool.utf8Index(source)))
+	case errors.As(err, &replyError) && replyError.code == errorAbsentInformation:
+		body = binary.BigEndian.AppendUint16(body, 0)
+	default:
+		return nil, err
+	}
+	if pool.count > 0xFFFF {
+		return nil, errors.New("Illegal State - constant pool of " + className + " is too large")
+	}
+
+	classFile := []byte{0xCA, 0xFE, 0xBA, 0xBE}
+	classFile = binary.BigEndian.AppendUint16(classFile, uint16(minorVersion))
+	classFile = binary.BigEndian.AppendUint16(classFile, uint16(majorVersion))
+	classFile = binary.BigEndian.AppendUint16(classFile, uint16(pool.count))
+	classFile = append(classFile, pool.bytes...)
+	header := len(classFile)
+	classFile = append(classFile, body...)
+	return classFile, computeMaxLocals(classFile, header, maxLocalsOffsets)
+}
+
+// NewClassReader returns a {@link ClassReader} of the class with the given internal name loaded in the JVM (see
+// {@link ReadClass}).
+func (c *Client) NewClassReader(className string) (*asm.ClassReader, error) {
+	classFile, err := c.ReadClass(className)
+	if err != nil {
+		return nil, err
+	}
+	return asm.NewClassReader(classFile)
+}
+
+// referenceTypeCommand sends a ReferenceType command for the given reference type, and returns its reply.
+func (c *Client) referenceTypeCommand(command int, referenceTypeID uint64) (*packetReader, error) {
+	writer := &packetWriter{}
+	writer.writeID(referenceTypeID, c.referenceTypeIDSize)
+	data, err := c.command(referenceTypeCommandSet, command, writer.data)
+	if err != nil {
+		return nil, err
+	}
+	return &packetReader{data: data}, nil
+}
+
+// getInternalName returns the internal name of the given class or interface.
+func (c *Client) getInternalName(referenceTypeID uint64) (string, error) {
+	reader, err := c.referenceTypeCommand(referenceTypeSignature, referenceTypeID)
+	if err != nil {
+		return "", err
+	}
+	signature := reader.readString()
+	if reader.err != nil {
+		return "", reader.err
+	}
+	return asm.GetType(signature).GetInternalName(), nil
+}
+
+// getMembers returns the fields or the methods of the given class, with the given ReferenceType command.
+func (c *Client) getMembers(command int, referenceTypeID uint64, idSize int) ([]member, error) {
+	reader, err := c.referenceTypeCommand(command, referenceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]member, reader.readInt())
+	for i := range members {
+		members[i] = member{
+			id:               reader.readID(idSize),
+			name:             reader.readString(),
+			signature:        reader.readString(),
+			genericSignature: reader.readString(),
+			// The JDWP modifiers may contain the 0xF0000000 synthetic flag.
+			access: reader.readInt() & 0xFFFF,
+		}
+	}
+	return members, reader.err
+}
+
+// appendMember appends the access flags, name and descriptor of the given member, followed by its
+// attributes_count, counting its Signature attribute.
+func appendMember(body []byte, pool *constantPoolBuilder, member member) []byte {
+	body = binary.BigEndian.AppendUint16(body, uint16(member.access))
+	body = binary.BigEndian.AppendUint16(body, uint16(pool.utf8Index(member.name)))
+	body = binary.BigEndian.AppendUint16(body, uint16(pool.utf8Index(member.signature)))
+	if member.genericSignature != "" {
+		return binary.BigEndian.AppendUint16(body, 1)
+	}
+	return binary.BigEndian.AppendUint16(body, 0)
+}
+
+// appendSignature appends the Signature attribute of the given member, if it has one.
+func appendSignature(body []byte, pool *constantPoolBuilder, member member) []byte {
+	if member.genericSignature == "" {
+		return body
+	}
+	return appendSignatureAttribute(body, pool, member.genericSignature)
+}
+
+// appendSignatureAttribute appends a Signature attribute with the given signature.
+func appendSignatureAttribute(body []byte, pool *constantPoolBuilder, signature string) []byte {
+	body = binary.BigEndian.AppendUint16(body, uint16(pool.utf8Index("Signature")))
+	body = binary.BigEndian.AppendUint32(body, 2)
+	return binary.BigEndian.AppendUint16(body, uint16(pool.utf8Index(signature)))
+}
+
+// computeMaxLocals sets the max_locals fields at the given offsets (relative to the given header offset) from
+// the arguments and the local variable instructions of the methods with code.
+func computeMaxLocals(classFile []byte, header int, maxLocalsOffsets []int) error {
+	class, err := tree.ReadClassNode(classFile, asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+	if err != nil {
+		return err
+	}
+	i := 0
+	for _, method := range class.Methods {
+		if len(method.Instructions) == 0 {
+			continue
+		}
+		maxLocals := 0
+		if method.Access&opcodes.ACC_STATIC == 0 {
+			maxLocals = 1
+		}
+		for _, argumentType := range asm.GetMethodType(method.Descriptor).GetArgumentTypes() {
+			maxLocals += argumentType.GetSize()
+		}
+		for _, insn := range method.Instructions {
+			switch insn := insn.(type) {
+			case *tree.VarInsnNode:
+				size := 1
+				if insn.Opcode == opcodes.LLOAD || insn.Opcode == opcodes.DLOAD || insn.Opcode == opcodes.LSTORE ||
+					insn.Opcode == opcodes.DSTORE {
+					size = 2
+				}
+				maxLocals = max(maxLocals, insn.Var+size)
+			case *tree.IincInsnNode:
+				maxLocals = max(maxLocals, insn.Var+1)
+			}
+		}
+		if i >= len(maxLocalsOffsets) {
+			return errors.New("Illegal State - unexpected method with code " + method.Name + method.Descriptor)
+		}
+		binary.BigEndian.PutUint16(classFile[header+maxLocalsOffsets[i]:], uint16(maxLocals))
+		i++
+	}
+	return nil
+}
+
+// constantPoolBuilder a constant pool returned by the JVM, to which new entries can be appended.
+type constantPoolBuilder struct {
+	bytes   []byte
+	count   int
+	utf8    map[string]int
+	classes map[string]int
+}
+
+// newConstantPoolBuilder returns a builder containing the given constant pool entries.
+func newConstantPoolBuilder(count int, bytes []byte) (*constantPoolBuilder, error) {
+	// ReadConstantPool expects a class file, whose constant pool starts at offset 10.
+	classFile := binary.BigEndian.AppendUint16(make([]byte, 8), uint16(count))
+	classFile = append(classFile, bytes...)
+	constantPool, err := raw.ReadConstantPool(append(classFile, 0, 0, 0, 0, 0, 0), 0)
+	if err != nil {
+		return nil, err
+	}
+	pool := &constantPoolBuilder{
+		bytes:   append([]byte(nil), bytes...),
+		count:   count,
+		utf8:    make(map[string]int),
+		classes: make(map[string]int),
+	}
+	for i := 1; i < len(constantPool.Offsets); i++ {
+		tag, err := constantPool.GetTag(classFile, i)
+		if err != nil {
+			continue
+		}
+		switch tag {
+		case symbol.CONSTANT_UTF8_TAG:
+			if value, err := constantPool.GetUTF8(classFile, i); err == nil {
+				if _, ok := pool.utf8[value]; !ok {
+					pool.utf8[value] = i
+				}
+			}
+		case symbol.CONSTANT_CLASS_TAG:
+			if nameIndex, err := raw.ReadU2(classFile, constantPool.Offsets[i]); err == nil {
+				if name, err := constantPool.GetUTF8(classFile, nameIndex); err == nil {
+					pool.classes[name] = i
+				}
+			}
+		}
+	}
+	return pool, nil
+}
+
+// utf8Index returns the index of the CONSTANT_Utf8 entry with the given value, appending it if needed. The value
+// is encoded in UTF-8, which is the same as modified UTF-8 for the strings without NUL and supplementary
+// characters.
+func (c *constantPoolBuilder) utf8Index(value string) int {
+	if index, ok := c.utf8[value]; ok {
+		return index
+	}
+	c.bytes = append(c.bytes, symbol.CONSTANT_UTF8_TAG)
+	c.bytes = binary.BigEndian.AppendUint16(c.bytes, uint16(len(value)))
+	c.bytes = append(c.bytes, value...)
+	c.utf8[value] = c.count
+	c.count++
+	return c.count - 1
+}
+
+// classIndex returns the index of the CONSTANT_Class entry with the given internal name, appending it if needed.
+func (c *constantPoolBuilder) classIndex(name string) int {
+	if index, ok := c.classes[name]; ok {
+		return index
+	}
+	nameIndex := c.utf8Index(name)
+	c.bytes = append(c.bytes, symbol.CONSTANT_CLASS_TAG)
+	c.bytes = binary.BigEndian.AppendUint16(c.bytes, uint16(nameIndex))
+	c.classes[name] = c.count
+	c.count++
+	return c.count - 1
+}
@@ -0,0 +1,221 @@
+// Package jdwp reads the classes loaded in a running JVM with the Java Debug Wire Protocol, so that they can be
+// inspected with a {@link ClassReader}. The JVM must be started with a JDWP agent listening on a socket, e.g.
+// -agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=localhost:5005.
+package jdwp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+)
+
+const handshake = "JDWP-Handshake"
+
+// The command sets and commands used by the {@link Client}.
+const (
+	virtualMachineCommandSet = 1
+	classesBySignature       = 2
+	idSizes                  = 7
+
+	referenceTypeCommandSet = 2
+	referenceTypeSignature  = 1
+	modifiers               = 3
+	sourceFile              = 7
+	interfaces              = 10
+	fieldsWithGeneric       = 14
+	methodsWithGeneric      = 15
+	classFileVersion        = 17
+	constantPool            = 18
+
+	classTypeCommandSet = 3
+	superclass          = 1
+
+	methodCommandSet = 6
+	bytecodes        = 3
+)
+
+// errorAbsentInformation the JDWP error code returned when the requested debug information is not available.
+const errorAbsentInformation = 101
+
+// flagReply the flag of the reply packets.
+const flagReply = 0x80
+
+// typeTagClass the JDWP type tag of the classes (as opposed to the interfaces and arrays).
+const typeTagClass = 1
+
+// Client a JDWP connection to a running JVM. A client is safe for concurrent use, but its commands are sent one
+// at a time.
+type Client struct {
+	conn  io.ReadWriteCloser
+	mutex sync.Mutex
+	id    uint32
+	// the sizes in bytes of the IDs of the JVM.
+	fieldIDSize, methodIDSize, objectIDSize, referenceTypeIDSize int
+}
+
+// Dial connects to the JDWP agent listening at the given address (e.g. "localhost:5005").
+func Dial(address string) (*Client, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewClient performs the JDWP handshake on the given connection, and returns a client using it.
+func NewClient(conn io.ReadWriteCloser) (*Client, error) {
+	if _, err := io.WriteString(conn, handshake); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, len(handshake))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, err
+	}
+	if string(reply) != handshake {
+		return nil, errors.New("Illegal State - invalid JDWP handshake " + strconv.Quote(string(reply)))
+	}
+	client := &Client{conn: conn}
+	data, err := client.command(virtualMachineCommandSet, idSizes, nil)
+	if err != nil {
+		return nil, err
+	}
+	reader := &packetReader{data: data}
+	client.fieldIDSize = reader.readInt()
+	client.methodIDSize = reader.readInt()
+	client.objectIDSize = reader.readInt()
+	client.referenceTypeIDSize = reader.readInt()
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	return client, nil
+}
+
+// Close closes the connection. The JVM keeps running.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// jdwpError an error code returned by the JVM.
+type jdwpError struct {
+	code int
+}
+
+func (j *jdwpError) Error() string {
+	return "Illegal State - JDWP error " + strconv.Itoa(j.code)
+}
+
+// command sends a command packet with the given data, and returns the data of its reply. The command packets
+// and the replies to other commands (e.g. the events of the JVM) received in the meantime are ignored.
+func (c *Client) command(commandSet, command int, data []byte) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.id++
+	packet := binary.BigEndian.AppendUint32(nil, uint32(11+len(data)))
+	packet = binary.BigEndian.AppendUint32(packet, c.id)
+	packet = append(packet, 0, byte(commandSet), byte(command))
+	if _, err := c.conn.Write(append(packet, data...)); err != nil {
+		return nil, err
+	}
+	for {
+		header := make([]byte, 11)
+		if _, err := io.ReadFull(c.conn, header); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint32(header)
+		if length < 11 {
+			return nil, errors.New("Illegal State - invalid JDWP packet length " + strconv.Itoa(int(length)))
+		}
+		reply := make([]byte, length-11)
+		if _, err := io.ReadFull(c.conn, reply); err != nil {
+			return nil, err
+		}
+		if header[8]&flagReply == 0 || binary.BigEndian.Uint32(header[4:]) != c.id {
+			continue
+		}
+		if code := int(binary.BigEndian.Uint16(header[9:])); code != 0 {
+			return nil, &jdwpError{code: code}
+		}
+		return reply, nil
+	}
+}
+
+// packetWriter encodes the data of a command packet.
+type packetWriter struct {
+	data []byte
+}
+
+func (p *packetWriter) writeInt(value int) {
+	p.data = binary.BigEndian.AppendUint32(p.data, uint32(value))
+}
+
+func (p *packetWriter) writeString(value string) {
+	p.writeInt(len(value))
+	p.data = append(p.data, value...)
+}
+
+// writeID writes an ID of the given size.
+func (p *packetWriter) writeID(id uint64, size int) {
+	for i := size - 1; i >= 0; i-- {
+		p.data = append(p.data, byte(id>>(8*i)))
+	}
+}
+
+// packetReader decodes the data of a reply packet. The first error is kept in err, and the following reads
+// return zero values.
+type packetReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+// read returns the next length bytes.
+func (p *packetReader) read(length int) []byte {
+	if p.err != nil {
+		return nil
+	}
+	if length < 0 || p.offset+length > len(p.data) {
+		p.err = errors.New("Illegal State - truncated JDWP reply")
+		return nil
+	}
+	p.offset += length
+	return p.data[p.offset-length : p.offset]
+}
+
+func (p *packetReader) readByte() int {
+	if b := p.read(1); b != nil {
+		return int(b[0])
+	}
+	return 0
+}
+
+func (p *packetReader) readInt() int {
+	if b := p.read(4); b != nil {
+		return int(int32(binary.BigEndian.Uint32(b)))
+	}
+	return 0
+}
+
+func (p *packetReader) readString() string {
+	return string(p.read(p.readInt()))
+}
+
+func (p *packetReader) readBytes() []byte {
+	return p.read(p.readInt())
+}
+
+// readID reads an ID of the given size.
+func (p *packetReader) readID(size int) uint64 {
+	var id uint64
+	for _, b := range p.read(size) {
+		id = id<<8 | uint64(b)
+	}
+	return id
+}
@@ -0,0 +1,120 @@
+package jdwp
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// fakeJVM answers the JDWP commands of a {@link Client} for a single class A, with a static method
+// "m(J)I" { LLOAD 0; L2I; IRETURN } and a generic field "f", and no source file.
+func fakeJVM(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	handshakeReply := make([]byte, len(handshake))
+	if _, err := io.ReadFull(conn, handshakeReply); err != nil {
+		return
+	}
+	conn.Write(handshakeReply)
+	for first := true; ; first = false {
+		header := make([]byte, 11)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		data := make([]byte, binary.BigEndian.Uint32(header)-11)
+		io.ReadFull(conn, data)
+		if first {
+			// An event packet, which must be ignored by the client.
+			conn.Write([]byte{0, 0, 0, 11, 0, 0, 0, 1, 0, 64, 100})
+		}
+		reply := &packetWriter{}
+		errorCode := 0
+		switch int(header[9])<<8 | int(header[10]) {
+		case virtualMachineCommandSet<<8 | idSizes:
+			for i := 0; i < 5; i++ {
+				reply.writeInt(8)
+			}
+		case virtualMachineCommandSet<<8 | classesBySignature:
+			reply.writeInt(1)
+			reply.data = append(reply.data, typeTagClass)
+			reply.writeID(1, 8)
+			reply.writeInt(7)
+		case referenceTypeCommandSet<<8 | classFileVersion:
+			reply.writeInt(52)
+			reply.writeInt(0)
+		case referenceTypeCommandSet<<8 | constantPool:
+			pool := []byte{1, 0, 1, 'A', 7, 0, 1, 1, 0, 16}
+			pool = append(append(pool, "java/lang/Object"...), 7, 0, 3)
+			reply.writeInt(5)
+			reply.writeInt(len(pool))
+			reply.data = append(reply.data, pool...)
+		case referenceTypeCommandSet<<8 | modifiers:
+			reply.writeInt(0x21)
+		case classTypeCommandSet<<8 | superclass:
+			reply.writeID(2, 8)
+		case referenceTypeCommandSet<<8 | referenceTypeSignature:
+			reply.writeString("Ljava/lang/Object;")
+		case referenceTypeCommandSet<<8 | interfaces:
+			reply.writeInt(0)
+		case referenceTypeCommandSet<<8 | fieldsWithGeneric:
+			reply.writeInt(1)
+			reply.writeID(1, 8)
+			reply.writeString("f")
+			reply.writeString("Ljava/util/List;")
+			reply.writeString("Ljava/util/List<Ljava/lang/String;>;")
+			reply.writeInt(0x2)
+		case referenceTypeCommandSet<<8 | methodsWithGeneric:
+			reply.writeInt(1)
+			reply.writeID(1, 8)
+			reply.writeString("m")
+			reply.writeString("(J)I")
+			reply.writeString("")
+			reply.writeInt(0xF0000009)
+		case methodCommandSet<<8 | bytecodes:
+			reply.writeInt(3)
+			reply.data = append(reply.data, 0x1E, 0x88, 0xAC)
+		case referenceTypeCommandSet<<8 | sourceFile:
+			errorCode = errorAbsentInformation
+		default:
+			t.Errorf("unexpected command %d %d", header[9], header[10])
+			return
+		}
+		packet := binary.BigEndian.AppendUint32(nil, uint32(11+len(reply.data)))
+		packet = append(append(packet, header[4:8]...), flagReply, byte(errorCode>>8), byte(errorCode))
+		conn.Write(append(packet, reply.data...))
+	}
+}
+
+func TestReadClass(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	go fakeJVM(t, serverConn)
+	client, err := NewClient(clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	classFile, err := client.ReadClass("A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, err := tree.ReadClassNode(classFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class.Name != "A" || class.SuperName != "java/lang/Object" || class.Access != 0x21 || class.Version != 52 {
+		t.Errorf("unexpected class header %s %s 0x%x %d", class.Name, class.SuperName, class.Access, class.Version)
+	}
+	if len(class.Fields) != 1 || class.Fields[0].Signature != "Ljava/util/List<Ljava/lang/String;>;" {
+		t.Errorf("unexpected fields %+v", class.Fields)
+	}
+	if len(class.Methods) != 1 || class.Methods[0].Access != 0x9 || len(class.Methods[0].Instructions) != 3 ||
+		class.Methods[0].MaxLocals != 2 {
+		t.Errorf("unexpected methods %+v", class.Methods)
+	}
+	if _, err := asm.NewClassReader(classFile); err != nil {
+		t.Error(err)
+	}
+}
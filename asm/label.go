@@ -46,9 +46,9 @@ func (l Label) getOffset() (int, error) {
 	return l.bytecodeOffset, nil
 }
 
-func (l Label) getCanonicalInstance() *Label {
+func (l *Label) getCanonicalInstance() *Label {
 	if l.frame == nil {
-		return &l
+		return l
 	}
 	return l.frame.owner
 }
@@ -71,20 +71,39 @@ func (l *Label) addLineNumber(lineNumber int) {
 	}
 }
 
-func (l Label) accept(methodVisitor MethodVisitor, visitLineNumbers bool) {
-	methodVisitor.VisitLabel(&l)
+func (l *Label) accept(methodVisitor MethodVisitor, visitLineNumbers bool) {
+	methodVisitor.VisitLabel(l)
 	if visitLineNumbers && l.lineNumber != 0 {
-		methodVisitor.VisitLineNumber(int(l.lineNumber)&0xFFFF, &l)
+		methodVisitor.VisitLineNumber(int(l.lineNumber)&0xFFFF, l)
 		if l.otherLineNumbers != nil {
 			for i := 1; i <= l.otherLineNumbers[0]; i++ {
-				methodVisitor.VisitLineNumber(l.otherLineNumbers[i], &l)
+				methodVisitor.VisitLineNumber(l.otherLineNumbers[i], l)
 			}
 		}
 	}
 }
 
-func (l Label) put() {
-	//TODO
+// put puts a reference to this label in the bytecode of a method. If the bytecode offset of the label is known,
+// the relative bytecode offset between the label and the instruction referencing it is computed and written
+// directly. Otherwise, a null relative offset is written and a new forward reference is declared for this label.
+// The sourceInsnBytecodeOffset is the bytecode offset of the instruction that contains the reference, and
+// wideReference whether the reference must be stored in 4 bytes (instead of 2).
+func (l *Label) put(code *ByteVector, sourceInsnBytecodeOffset int, wideReference bool) {
+	if (l.flags & FLAG_RESOLVED) == 0 {
+		if wideReference {
+			l.addForwardReference(sourceInsnBytecodeOffset, FORWARD_REFERENCE_TYPE_WIDE, code.Size())
+			code.PutInt(-1)
+		} else {
+			l.addForwardReference(sourceInsnBytecodeOffset, FORWARD_REFERENCE_TYPE_SHORT, code.Size())
+			code.PutShort(-1)
+		}
+	} else {
+		if wideReference {
+			code.PutInt(l.bytecodeOffset - sourceInsnBytecodeOffset)
+		} else {
+			code.PutShort(l.bytecodeOffset - sourceInsnBytecodeOffset)
+		}
+	}
 }
 
 func (l *Label) addForwardReference(sourceInsnBytecodeOffset, referenceType, referenceHandle int) {
@@ -111,7 +130,7 @@ func (l *Label) resolve(code []byte, bytecodeOffset int) bool {
 		reference := l.values[i+1]
 		relativeOffset := bytecodeOffset - sourceInsnBytecodeOffset
 		handle := reference & FORWARD_REFERENCE_HANDLE_MASK
-		if (reference & FORWARD_REFERENCE_HANDLE_MASK) == FORWARD_REFERENCE_TYPE_SHORT {
+		if (reference & FORWARD_REFERENCE_TYPE_MASK) == FORWARD_REFERENCE_TYPE_SHORT {
 			if relativeOffset < math.MinInt16 || relativeOffset > math.MaxInt16 {
 				opcode := code[sourceInsnBytecodeOffset] & 0xFF
 				if opcode < opcodes.IFNULL {
@@ -121,11 +140,11 @@ func (l *Label) resolve(code []byte, bytecodeOffset int) bool {
 				}
 				hasAsmInstructions = true
 			}
-			code[handle] = byte(relativeOffset >> 8) // >>> x3 ?
+			code[handle] = byte(relativeOffset >> 8)
 			handle++
 			code[handle] = byte(relativeOffset)
 		} else {
-			code[handle] = byte(relativeOffset >> 14)
+			code[handle] = byte(relativeOffset >> 24)
 			handle++
 			code[handle] = byte(relativeOffset >> 16)
 			handle++
@@ -178,7 +197,7 @@ func (l *Label) addSubroutineRetSuccessors(subroutineCaller *Label, numSubroutin
 func (l *Label) pushSuccessors(listOfLabelsToProcess *Label) *Label {
 	outgoingEdge := l.outgoingEdges
 	for outgoingEdge != nil {
-		isJsrTarget := (l.flags&FLAG_SUBROUTINE_CALLER) != 0 && outgoingEdge == outgoingEdge.nextEdge
+		isJsrTarget := (l.flags&FLAG_SUBROUTINE_CALLER) != 0 && outgoingEdge == l.outgoingEdges.nextEdge
 		if !isJsrTarget {
 			if outgoingEdge.successor.nextListElement == nil {
 				outgoingEdge.successor.nextListElement = listOfLabelsToProcess
@@ -0,0 +1,342 @@
+package asm
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// ReaderLimits the maximum sizes accepted by a {@link ClassReader} created with
+// {@link NewClassReaderWithLimits}, to bound the resources used to parse untrusted classes. A zero limit means
+// no limit.
+type ReaderLimits struct {
+	// MaxConstantPoolEntries the maximum constant_pool_count of the class.
+	MaxConstantPoolEntries int
+	// MaxCodeLength the maximum code_length of the Code attributes.
+	MaxCodeLength int
+	// MaxAttributeLength the maximum attribute_length of the attributes, at any level.
+	MaxAttributeLength int
+	// MaxParseMemory the maximum estimated memory, in bytes, allocated by the reader to parse the class (its
+	// constant pool tables and char buffer, and the per method tables of its largest method), in addition to
+	// the class content itself.
+	MaxParseMemory int
+}
+
+// Estimated sizes of the elements of the tables allocated by the reader.
+const (
+	intSize       = 8
+	stringSize    = 16
+	runeSize      = 4
+	pointerSize   = 8
+	interfaceSize = 16
+)
+
+// NewClassReaderWithLimits constructs a new {@link ClassReader} object, after checking that the structure of
+// the class is well formed and within the given limits. The checks do not parse the content of the attributes,
+// but guarantee that the reader does not allocate more than the limits, and that the constant pool entries it
+// dereferences to visit the class header, the members and the names of the attributes exist and have the
+// expected tags. A limit which is exceeded is reported with a {@link LimitExceededError}, and a malformed class
+// with a {@link raw.ParseError}.
+func NewClassReaderWithLimits(classFile []byte, limits ReaderLimits) (*ClassReader, error) {
+	if limits.MaxConstantPoolEntries > 0 {
+		constantPoolCount, err := raw.ReadU2(classFile, 8)
+		if err != nil {
+			return nil, err
+		}
+		if constantPoolCount > limits.MaxConstantPoolEntries {
+			return nil, &LimitExceededError{Kind: ErrClassTooLarge, Value: constantPoolCount, Limit: limits.MaxConstantPoolEntries}
+		}
+	}
+	reader, err := classReader(classFile, 0, len(classFile))
+	if err != nil {
+		return nil, err
+	}
+	checker := &limitsChecker{
+		b:            classFile,
+		limits:       limits,
+		constantPool: &raw.ConstantPool{Offsets: reader.cpInfoOffsets, MaxStringLength: reader.maxStringLength, Header: reader.header},
+	}
+	if err := checker.checkClass(reader.header); err != nil {
+		return nil, err
+	}
+	memory := len(reader.cpInfoOffsets)*(intSize+stringSize) + reader.maxStringLength*runeSize + checker.maxMethodMemory
+	if limits.MaxParseMemory > 0 && memory > limits.MaxParseMemory {
+		return nil, &LimitExceededError{Kind: ErrParseMemoryTooLarge, ClassName: checker.className, Value: memory, Limit: limits.MaxParseMemory}
+	}
+	return reader, nil
+}
+
+// limitsChecker checks the structure of a class against {@link ReaderLimits}.
+type limitsChecker struct {
+	b               []byte
+	limits          ReaderLimits
+	constantPool    *raw.ConstantPool
+	maxMethodMemory int
+	// className the internal name of the checked class, methodName and methodDescriptor those of the checked
+	// method, if any, to report the exceeded limits.
+	className        string
+	methodName       string
+	methodDescriptor string
+}
+
+// checkClass checks the class whose access_flags field starts at the given offset, and returns an error if it
+// is truncated, exceeds the limits, or references invalid constant pool entries.
+func (l *limitsChecker) checkClass(header int) error {
+	var err error
+	if l.className, err = l.checkClassIndex(header+2, false); err != nil {
+		return err
+	}
+	if _, err = l.checkClassIndex(header+4, true); err != nil {
+		return err
+	}
+	interfacesCount, err := raw.ReadU2(l.b, header+6)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < interfacesCount; i++ {
+		if _, err = l.checkClassIndex(header+8+i*2, false); err != nil {
+			return err
+		}
+	}
+	currentOffset := header + 8 + interfacesCount*2
+	// The fields, then the methods.
+	for i := 0; i < 2; i++ {
+		membersCount, err := raw.ReadU2(l.b, currentOffset)
+		if err != nil {
+			return err
+		}
+		currentOffset += 2
+		for ; membersCount > 0; membersCount-- {
+			name, err := l.checkUTF8Index(currentOffset + 2)
+			if err != nil {
+				return err
+			}
+			descriptor, err := l.checkUTF8Index(currentOffset + 4)
+			if err != nil {
+				return err
+			}
+			if i == 1 {
+				l.methodName, l.methodDescriptor = name, descriptor
+			}
+			if currentOffset, err = l.checkAttributes(currentOffset+6, i == 1); err != nil {
+				return err
+			}
+		}
+	}
+	l.methodName, l.methodDescriptor = "", ""
+	currentOffset, err = l.checkAttributes(currentOffset, false)
+	if err != nil {
+		return err
+	}
+	if currentOffset != len(l.b) {
+		return raw.NewParseError(raw.ErrTrailingBytes, currentOffset, strconv.Itoa(len(l.b)-currentOffset)+
+			" unexpected bytes at the end of the class")
+	}
+	return nil
+}
+
+// checkUTF8Index checks that the constant pool index stored at the given offset references a CONSTANT_Utf8
+// entry, and returns its value.
+func (l *limitsChecker) checkUTF8Index(offset int) (string, error) {
+	constantPoolEntryIndex, err := raw.ReadU2(l.b, offset)
+	if err != nil {
+		return "", err
+	}
+	value, err := l.constantPool.GetUTF8(l.b, constantPoolEntryIndex)
+	if err != nil {
+		return "", referenceError(offset, "CONSTANT_Utf8", err)
+	}
+	return value, nil
+}
+
+// checkClassIndex checks that the constant pool index stored at the given offset references a CONSTANT_Class
+// entry, or is 0 if optional is true, and returns the internal name of this class, or "".
+func (l *limitsChecker) checkClassIndex(offset int, optional bool) (string, error) {
+	constantPoolEntryIndex, err := raw.ReadU2(l.b, offset)
+	if err != nil {
+		return "", err
+	}
+	if constantPoolEntryIndex == 0 && optional {
+		return "", nil
+	}
+	tag, err := l.constantPool.GetTag(l.b, constantPoolEntryIndex)
+	if err == nil && tag != symbol.CONSTANT_CLASS_TAG {
+		err = raw.NewParseError(raw.ErrMalformedConstantPool, -1, "constant pool entry "+
+			strconv.Itoa(constantPoolEntryIndex)+" is not a CONSTANT_Class")
+	}
+	if err != nil {
+		return "", referenceError(offset, "CONSTANT_Class", err)
+	}
+	return l.checkUTF8Index(l.constantPool.Offsets[constantPoolEntryIndex])
+}
+
+// referenceError returns the given constant pool error, reported at the offset of the invalid reference.
+func referenceError(offset int, tag string, err error) error {
+	message := err.Error()
+	var parseError *raw.ParseError
+	if errors.As(err, &parseError) {
+		message = parseError.Message
+	}
+	return raw.NewParseError(raw.ErrMalformedConstantPool, offset, "invalid "+tag+" reference at offset "+
+		strconv.Itoa(offset)+": "+message)
+}
+
+// checkAttributes checks the attributes_count and attributes fields starting at the given offset, and returns
+// the offset following them. If method is true, the Code attribute is checked too.
+func (l *limitsChecker) checkAttributes(offset int, method bool) (int, error) {
+	attributesCount, err := raw.ReadU2(l.b, offset)
+	if err != nil {
+		return 0, err
+	}
+	currentOffset := offset + 2
+	for ; attributesCount > 0; attributesCount-- {
+		name, err := l.checkUTF8Index(currentOffset)
+		if err != nil {
+			return 0, err
+		}
+		attributeLength, err := raw.ReadU4(l.b, currentOffset+2)
+		if err != nil {
+			return 0, err
+		}
+		if l.limits.MaxAttributeLength > 0 && int64(attributeLength) > int64(l.limits.MaxAttributeLength) {
+			return 0, &LimitExceededError{Kind: ErrAttributeTooLarge, ClassName: l.className, MethodName: l.methodName,
+				Descriptor: l.methodDescriptor, Value: int(attributeLength), Limit: l.limits.MaxAttributeLength}
+		}
+		if int64(attributeLength) > int64(len(l.b)-currentOffset-6) {
+			return 0, raw.NewParseError(raw.ErrTruncated, currentOffset, "attribute at offset "+
+				strconv.Itoa(currentOffset)+" exceeds the class")
+		}
+		if method && name == "Code" {
+			if err := l.checkCode(currentOffset+6, int(attributeLength)); err != nil {
+				return 0, err
+			}
+		}
+		currentOffset += 6 + int(attributeLength)
+	}
+	return currentOffset, nil
+}
+
+// checkCode checks the Code attribute content starting at the given offset.
+func (l *limitsChecker) checkCode(offset int, length int) error {
+	maxStack, err := raw.ReadU2(l.b, offset)
+	if err != nil {
+		return err
+	}
+	maxLocals, err := raw.ReadU2(l.b, offset+2)
+	if err != nil {
+		return err
+	}
+	codeLength, err := raw.ReadU4(l.b, offset+4)
+	if err != nil {
+		return err
+	}
+	if l.limits.MaxCodeLength > 0 && int64(codeLength) > int64(l.limits.MaxCodeLength) {
+		return &LimitExceededError{Kind: ErrMethodTooLarge, ClassName: l.className, MethodName: l.methodName,
+			Descriptor: l.methodDescriptor, Value: int(codeLength), Limit: l.limits.MaxCodeLength}
+	}
+	if int64(codeLength) > int64(length) {
+		return raw.NewParseError(raw.ErrMalformedAttribute, offset, "code length "+
+			strconv.FormatUint(uint64(codeLength), 10)+" exceeds its Code attribute at offset "+strconv.Itoa(offset))
+	}
+	// The labels of the bytecode offsets, and the local variables and stack of the frames.
+	methodMemory := (int(codeLength)+1)*pointerSize + (maxStack+maxLocals)*interfaceSize
+	if methodMemory > l.maxMethodMemory {
+		l.maxMethodMemory = methodMemory
+	}
+	exceptionTableLength, err := raw.ReadU2(l.b, offset+8+int(codeLength))
+	if err != nil {
+		return err
+	}
+	attributesOffset := offset + 10 + int(codeLength) + exceptionTableLength*8
+	endOffset, err := l.checkAttributes(attributesOffset, false)
+	if err != nil {
+		return err
+	}
+	if endOffset != offset+length {
+		return raw.NewParseError(raw.ErrMalformedAttribute, offset, "malformed Code attribute at offset "+strconv.Itoa(offset))
+	}
+	return nil
+}
+
+// The limits of the class file format, which a class must not exceed to be loaded by the JVM.
+const (
+	// MAX_CONSTANT_POOL_ENTRIES the maximum constant_pool_count of a class.
+	MAX_CONSTANT_POOL_ENTRIES = 65535
+	// MAX_CODE_SIZE the maximum code_length of a method.
+	MAX_CODE_SIZE = 65535
+	// MAX_PARAMETER_SLOTS the maximum number of local variable slots of the parameters of a method, including
+	// 'this' for instance methods.
+	MAX_PARAMETER_SLOTS = 255
+)
+
+// The kinds of the {@link LimitExceededError}s, to test with errors.Is. The first three are limits of the
+// class file format, also used for the corresponding {@link ReaderLimits}, and the others are only used for the
+// {@link ReaderLimits}.
+var (
+	// ErrClassTooLarge the constant pool of the class has more than {@link MAX_CONSTANT_POOL_ENTRIES} entries.
+	ErrClassTooLarge = errors.New("class too large")
+	// ErrMethodTooLarge the code of a method has more than {@link MAX_CODE_SIZE} bytes.
+	ErrMethodTooLarge = errors.New("method too large")
+	// ErrTooManyParameters the parameters of a method use more than {@link MAX_PARAMETER_SLOTS} slots.
+	ErrTooManyParameters = errors.New("too many parameters")
+	// ErrAttributeTooLarge an attribute has more than {@link ReaderLimits#MaxAttributeLength} bytes.
+	ErrAttributeTooLarge = errors.New("attribute too large")
+	// ErrParseMemoryTooLarge parsing the class needs more than {@link ReaderLimits#MaxParseMemory} bytes.
+	ErrParseMemoryTooLarge = errors.New("parse memory too large")
+)
+
+// LimitExceededError a class or a method exceeding a limit of the class file format or of the
+// {@link ReaderLimits}, to get with errors.As.
+type LimitExceededError struct {
+	// Kind {@link ErrClassTooLarge}, {@link ErrMethodTooLarge}, {@link ErrTooManyParameters},
+	// {@link ErrAttributeTooLarge} or {@link ErrParseMemoryTooLarge}.
+	Kind error
+	// ClassName the internal name of the class, or "" if it is not known yet.
+	ClassName string
+	// MethodName the name of the method exceeding the limit, or "" if the limit is exceeded by the class.
+	MethodName string
+	// Descriptor the descriptor of the method exceeding the limit, or "".
+	Descriptor string
+	// Value the size which exceeds the limit.
+	Value int
+	// Limit the limit which is exceeded.
+	Limit int
+}
+
+func (l *LimitExceededError) Error() string {
+	member := l.ClassName
+	if member == "" {
+		member = "class"
+	}
+	if l.MethodName != "" {
+		member += "." + l.MethodName + l.Descriptor
+	}
+	return "Illegal Argument - " + l.Kind.Error() + ": " + member + " has " + strconv.Itoa(l.Value) + " " +
+		l.unit() + ", which exceeds the limit of " + strconv.Itoa(l.Limit)
+}
+
+func (l *LimitExceededError) unit() string {
+	switch l.Kind {
+	case ErrClassTooLarge:
+		return "constant pool entries"
+	case ErrMethodTooLarge:
+		return "bytes of code"
+	case ErrAttributeTooLarge:
+		return "attribute bytes"
+	case ErrParseMemoryTooLarge:
+		return "bytes of parse memory"
+	default:
+		return "parameter slots"
+	}
+}
+
+func (l *LimitExceededError) Unwrap() error {
+	return l.Kind
+}
+
+// LimitHandler a function called with the limits exceeded by a class before it is written, to react
+// programmatically (e.g. by splitting the offending method with analysis.ExtractMethod). It returns nil if it
+// fixed the class, or the error to return instead of writing a class which could not be loaded.
+type LimitHandler func(err *LimitExceededError) error
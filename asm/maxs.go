@@ -0,0 +1,552 @@
+package asm
+
+import (
+	"github.com/leaklessgfy/asm/asm/constants"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// COMPUTE_MAXS a flag to automatically compute the maximum stack size and the maximum number of local variables of
+// methods. If this flag is set, then the arguments of the {@link MethodVisitor#VisitMaxs} method are ignored, and
+// computed automatically from the signature and the bytecode of each method (see {@link MaxsComputer}).
+const COMPUTE_MAXS = 1
+
+// STACK_SIZE_DELTA the stack size variation corresponding to each JVM opcode. The stack size variation for opcode
+// 'o' is given by the array element at index 'o'. The opcodes whose variation depends on their operands (e.g.
+// LDC, the field and method instructions, MULTIANEWARRAY) or which are not instruction opcodes have a 0 value.
+var STACK_SIZE_DELTA = [...]int{
+	0,                   // nop = 0 (0x0)
+	1,                   // aconst_null = 1 (0x1)
+	1, 1, 1, 1, 1, 1, 1, // iconst_m1 to iconst_5 = 2 to 8 (0x2 to 0x8)
+	2, 2, // lconst_0, lconst_1 = 9, 10 (0x9, 0xa)
+	1, 1, 1, // fconst_0 to fconst_2 = 11 to 13 (0xb to 0xd)
+	2, 2, // dconst_0, dconst_1 = 14, 15 (0xe, 0xf)
+	1, 1, // bipush, sipush = 16, 17 (0x10, 0x11)
+	0, 0, 0, // ldc, ldc_w, ldc2_w = 18 to 20 (0x12 to 0x14)
+	1, 2, 1, 2, 1, // iload, lload, fload, dload, aload = 21 to 25 (0x15 to 0x19)
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // iload_0 to aload_3 = 26 to 45 (0x1a to 0x2d)
+	-1, 0, -1, 0, -1, -1, -1, -1, // iaload to saload = 46 to 53 (0x2e to 0x35)
+	-1, -2, -1, -2, -1, // istore, lstore, fstore, dstore, astore = 54 to 58 (0x36 to 0x3a)
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // istore_0 to astore_3 = 59 to 78 (0x3b to 0x4e)
+	-3, -4, -3, -4, -3, -3, -3, -3, // iastore to sastore = 79 to 86 (0x4f to 0x56)
+	-1, -2, // pop, pop2 = 87, 88 (0x57, 0x58)
+	1, 1, 1, 2, 2, 2, // dup to dup2_x2 = 89 to 94 (0x59 to 0x5e)
+	0,              // swap = 95 (0x5f)
+	-1, -2, -1, -2, // iadd, ladd, fadd, dadd = 96 to 99 (0x60 to 0x63)
+	-1, -2, -1, -2, // isub, lsub, fsub, dsub = 100 to 103 (0x64 to 0x67)
+	-1, -2, -1, -2, // imul, lmul, fmul, dmul = 104 to 107 (0x68 to 0x6b)
+	-1, -2, -1, -2, // idiv, ldiv, fdiv, ddiv = 108 to 111 (0x6c to 0x6f)
+	-1, -2, -1, -2, // irem, lrem, frem, drem = 112 to 115 (0x70 to 0x73)
+	0, 0, 0, 0, // ineg, lneg, fneg, dneg = 116 to 119 (0x74 to 0x77)
+	-1, -1, -1, -1, -1, -1, // ishl, lshl, ishr, lshr, iushr, lushr = 120 to 125 (0x78 to 0x7d)
+	-1, -2, -1, -2, -1, -2, // iand, land, ior, lor, ixor, lxor = 126 to 131 (0x7e to 0x83)
+	0,       // iinc = 132 (0x84)
+	1, 0, 1, // i2l, i2f, i2d = 133 to 135 (0x85 to 0x87)
+	-1, -1, 0, // l2i, l2f, l2d = 136 to 138 (0x88 to 0x8a)
+	0, 1, 1, // f2i, f2l, f2d = 139 to 141 (0x8b to 0x8d)
+	-1, 0, -1, // d2i, d2l, d2f = 142 to 144 (0x8e to 0x90)
+	0, 0, 0, // i2b, i2c, i2s = 145 to 147 (0x91 to 0x93)
+	-3, -1, -1, -3, -3, // lcmp, fcmpl, fcmpg, dcmpl, dcmpg = 148 to 152 (0x94 to 0x98)
+	-1, -1, -1, -1, -1, -1, // ifeq to ifle = 153 to 158 (0x99 to 0x9e)
+	-2, -2, -2, -2, -2, -2, -2, -2, // if_icmpeq to if_acmpne = 159 to 166 (0x9f to 0xa6)
+	0, 1, 0, // goto, jsr, ret = 167 to 169 (0xa7 to 0xa9)
+	-1, -1, // tableswitch, lookupswitch = 170, 171 (0xaa, 0xab)
+	-1, -2, -1, -2, -1, 0, // ireturn to return = 172 to 177 (0xac to 0xb1)
+	0, 0, 0, 0, // getstatic, putstatic, getfield, putfield = 178 to 181 (0xb2 to 0xb5)
+	0, 0, 0, 0, 0, // invokevirtual to invokedynamic = 182 to 186 (0xb6 to 0xba)
+	1, 0, 0, 0, // new, newarray, anewarray, arraylength = 187 to 190 (0xbb to 0xbe)
+	-1, 0, 0, // athrow, checkcast, instanceof = 191 to 193 (0xbf to 0xc1)
+	-1, -1, // monitorenter, monitorexit = 194, 195 (0xc2, 0xc3)
+	0, 0, // wide, multianewarray = 196, 197 (0xc4, 0xc5)
+	-1, -1, // ifnull, ifnonnull = 198, 199 (0xc6, 0xc7)
+	0, 1, // goto_w, jsr_w = 200, 201 (0xc8, 0xc9)
+}
+
+// maxsHandler an exception handler visited by a {@link MaxsComputer}.
+type maxsHandler struct {
+	start   *Label
+	end     *Label
+	handler *Label
+}
+
+// MaxsComputer a {@link MethodVisitor} which computes the maximum stack size and the maximum number of local
+// variables of the visited method, and passes them to the next visitor in the chain instead of the values given
+// to {@link VisitMaxs}. This is the implementation of the {@link COMPUTE_MAXS} option: the visited code is split
+// in basic blocks (represented with the {@link Label} of their first instruction) linked by {@link Edge}s, the
+// maximum stack size relatively to its input stack size is computed for each block, and a data flow analysis on
+// the control flow graph then computes the absolute stack sizes. The labels of the visited code are used as the
+// nodes of this graph, and thus can't be visited by another MaxsComputer (or writer) afterwards.
+type MaxsComputer struct {
+	methodVisitor MethodVisitor
+	// firstBasicBlock the first basic block of the method. The next ones are linked with Label#nextBasicBlock.
+	firstBasicBlock *Label
+	// lastBasicBlock the last basic block of the method, to which the new basic blocks are appended.
+	lastBasicBlock *Label
+	// currentBasicBlock the basic block of the current instruction, or nil if it is unreachable (i.e. if it
+	// follows an unconditional jump, a return or an athrow instruction, and is not a jump target).
+	currentBasicBlock *Label
+	// relativeStackSize the stack size after the current instruction, relatively to the input stack size of the
+	// current basic block.
+	relativeStackSize int
+	// maxRelativeStackSize the maximum relative stack size reached so far in the current basic block.
+	maxRelativeStackSize int
+	handlers             []maxsHandler
+	hasSubroutines       bool
+	maxStack             int
+	maxLocals            int
+}
+
+// NewMaxsComputer constructs a new {@link MaxsComputer} for a method with the given access flags and descriptor,
+// delegating to the given method visitor (which may be nil).
+func NewMaxsComputer(access int, descriptor string, methodVisitor MethodVisitor) *MaxsComputer {
+	maxLocals := 0
+	if (access & opcodes.ACC_STATIC) == 0 {
+		maxLocals++
+	}
+	for _, argumentType := range GetMethodType(descriptor).GetArgumentTypes() {
+		maxLocals += argumentType.GetSize()
+	}
+	return &MaxsComputer{methodVisitor: methodVisitor, maxLocals: maxLocals}
+}
+
+// GetMaxStack returns the maximum stack size computed by the last {@link VisitMaxs} call.
+func (m *MaxsComputer) GetMaxStack() int {
+	return m.maxStack
+}
+
+// GetMaxLocals returns the maximum number of local variables of the visited method, so far.
+func (m *MaxsComputer) GetMaxLocals() int {
+	return m.maxLocals
+}
+
+// push updates the relative stack size of the current basic block (if any) with the given size variation.
+func (m *MaxsComputer) push(delta int) {
+	if m.currentBasicBlock == nil {
+		return
+	}
+	m.relativeStackSize += delta
+	if m.relativeStackSize > m.maxRelativeStackSize {
+		m.maxRelativeStackSize = m.relativeStackSize
+	}
+}
+
+// addSuccessor adds the given block as a successor of the current basic block, reached with the given relative
+// stack size.
+func (m *MaxsComputer) addSuccessor(info int, successor *Label) {
+	m.currentBasicBlock.outgoingEdges = NewEdge(info, successor, m.currentBasicBlock.outgoingEdges)
+}
+
+// endBasicBlock ends the current basic block, which has no successor other than the ones already added.
+func (m *MaxsComputer) endBasicBlock() {
+	m.currentBasicBlock.outputStackMax = int16(m.maxRelativeStackSize)
+	m.currentBasicBlock = nil
+}
+
+// startBasicBlock starts a new basic block with the given label, as a successor of the current block, if any.
+func (m *MaxsComputer) startBasicBlock(label *Label) {
+	if m.currentBasicBlock != nil {
+		m.currentBasicBlock.outputStackMax = int16(m.maxRelativeStackSize)
+		m.addSuccessor(m.relativeStackSize, label)
+	}
+	m.currentBasicBlock = label
+	m.relativeStackSize = 0
+	m.maxRelativeStackSize = 0
+	if m.lastBasicBlock != nil {
+		m.lastBasicBlock.nextBasicBlock = label
+	}
+	m.lastBasicBlock = label
+}
+
+// useLocal updates the maximum number of local variables with a use of the given local variable.
+func (m *MaxsComputer) useLocal(vard, size int) {
+	if vard+size > m.maxLocals {
+		m.maxLocals = vard + size
+	}
+}
+
+func (m *MaxsComputer) VisitParameter(name string, access int) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitParameter(name, access)
+	}
+}
+
+func (m *MaxsComputer) VisitAnnotationDefault() AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitAnnotationDefault()
+	}
+	return nil
+}
+
+func (m *MaxsComputer) VisitAnnotation(descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitAnnotation(descriptor, visible)
+	}
+	return nil
+}
+
+func (m *MaxsComputer) VisitTypeAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitTypeAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (m *MaxsComputer) VisitAnnotableParameterCount(parameterCount int, visible bool) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitAnnotableParameterCount(parameterCount, visible)
+	}
+}
+
+func (m *MaxsComputer) VisitParameterAnnotation(parameter int, descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitParameterAnnotation(parameter, descriptor, visible)
+	}
+	return nil
+}
+
+func (m *MaxsComputer) VisitAttribute(attribute *Attribute) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitAttribute(attribute)
+	}
+}
+
+func (m *MaxsComputer) VisitCode() {
+	m.firstBasicBlock = &Label{}
+	m.startBasicBlock(m.firstBasicBlock)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitCode()
+	}
+}
+
+func (m *MaxsComputer) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitFrame(typed, nLocal, local, nStack, stack)
+	}
+}
+
+func (m *MaxsComputer) VisitInsn(opcode int) {
+	m.push(STACK_SIZE_DELTA[opcode])
+	if m.currentBasicBlock != nil && ((opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN) || opcode == opcodes.ATHROW) {
+		m.endBasicBlock()
+	}
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitInsn(opcode)
+	}
+}
+
+func (m *MaxsComputer) VisitIntInsn(opcode, operand int) {
+	m.push(STACK_SIZE_DELTA[opcode])
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitIntInsn(opcode, operand)
+	}
+}
+
+func (m *MaxsComputer) VisitVarInsn(opcode, vard int) {
+	if opcode == opcodes.RET {
+		if m.currentBasicBlock != nil {
+			m.currentBasicBlock.flags |= FLAG_SUBROUTINE_END
+			m.currentBasicBlock.outputStackSize = int16(m.relativeStackSize)
+			m.endBasicBlock()
+		}
+	} else {
+		m.push(STACK_SIZE_DELTA[opcode])
+	}
+	if opcode == opcodes.LLOAD || opcode == opcodes.DLOAD || opcode == opcodes.LSTORE || opcode == opcodes.DSTORE {
+		m.useLocal(vard, 2)
+	} else {
+		m.useLocal(vard, 1)
+	}
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitVarInsn(opcode, vard)
+	}
+}
+
+func (m *MaxsComputer) VisitTypeInsn(opcode int, typed string) {
+	m.push(STACK_SIZE_DELTA[opcode])
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitTypeInsn(opcode, typed)
+	}
+}
+
+func (m *MaxsComputer) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	size := GetType(descriptor).GetSize()
+	switch opcode {
+	case opcodes.GETSTATIC:
+		m.push(size)
+	case opcodes.PUTSTATIC:
+		m.push(-size)
+	case opcodes.GETFIELD:
+		m.push(size - 1)
+	default:
+		m.push(-size - 1)
+	}
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitFieldInsn(opcode, owner, name, descriptor)
+	}
+}
+
+func (m *MaxsComputer) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
+
+func (m *MaxsComputer) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	delta := m.invocationDelta(descriptor)
+	if opcode != opcodes.INVOKESTATIC {
+		delta--
+	}
+	m.push(delta)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitMethodInsnB(opcode, owner, name, descriptor, isInterface)
+	}
+}
+
+func (m *MaxsComputer) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *Handle, bootstrapMethodArguments ...interface{}) {
+	m.push(m.invocationDelta(descriptor))
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitInvokeDynamicInsn(name, descriptor, bootstrapMethodHande, bootstrapMethodArguments...)
+	}
+}
+
+// invocationDelta returns the stack size variation of an invocation of a static method with the given descriptor.
+func (m *MaxsComputer) invocationDelta(descriptor string) int {
+	methodType := GetMethodType(descriptor)
+	delta := methodType.GetReturnType().GetSize()
+	for _, argumentType := range methodType.GetArgumentTypes() {
+		delta -= argumentType.GetSize()
+	}
+	return delta
+}
+
+func (m *MaxsComputer) VisitJumpInsn(opcode int, label *Label) {
+	if m.currentBasicBlock != nil {
+		// GOTO_W and JSR_W behave like GOTO and JSR.
+		baseOpcode := opcode
+		if opcode >= constants.GOTO_W {
+			baseOpcode = opcode - constants.WIDE_JUMP_OPCODE_DELTA
+		}
+		var nextBasicBlock *Label
+		if baseOpcode == opcodes.JSR {
+			// The first edge of a subroutine caller leads to the instruction after the jsr (it is only used to
+			// compute the successors of the blocks ending with a ret), and the second one to the subroutine.
+			if (label.flags & FLAG_SUBROUTINE_START) == 0 {
+				label.flags |= FLAG_SUBROUTINE_START
+				m.hasSubroutines = true
+			}
+			m.currentBasicBlock.flags |= FLAG_SUBROUTINE_CALLER
+			m.addSuccessor(m.relativeStackSize+1, label)
+			nextBasicBlock = &Label{}
+		} else {
+			m.relativeStackSize += STACK_SIZE_DELTA[baseOpcode]
+			m.addSuccessor(m.relativeStackSize, label)
+			if baseOpcode != opcodes.GOTO {
+				nextBasicBlock = &Label{}
+			}
+		}
+		label.flags |= FLAG_JUMP_TARGET
+		if nextBasicBlock != nil {
+			m.startBasicBlock(nextBasicBlock)
+		} else {
+			m.endBasicBlock()
+		}
+	}
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitJumpInsn(opcode, label)
+	}
+}
+
+func (m *MaxsComputer) VisitLabel(label *Label) {
+	m.startBasicBlock(label)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitLabel(label)
+	}
+}
+
+func (m *MaxsComputer) VisitLdcInsn(value interface{}) {
+	switch value.(type) {
+	case int64, float64:
+		m.push(2)
+	default:
+		m.push(1)
+	}
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitLdcInsn(value)
+	}
+}
+
+func (m *MaxsComputer) VisitIincInsn(vard, increment int) {
+	m.useLocal(vard, 1)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitIincInsn(vard, increment)
+	}
+}
+
+func (m *MaxsComputer) VisitTableSwitchInsn(min, max int, dflt *Label, labels ...*Label) {
+	m.visitSwitchInsn(dflt, labels)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitTableSwitchInsn(min, max, dflt, labels...)
+	}
+}
+
+func (m *MaxsComputer) VisitLookupSwitchInsn(dflt *Label, keys []int, labels []*Label) {
+	m.visitSwitchInsn(dflt, labels)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitLookupSwitchInsn(dflt, keys, labels)
+	}
+}
+
+func (m *MaxsComputer) visitSwitchInsn(dflt *Label, labels []*Label) {
+	if m.currentBasicBlock == nil {
+		return
+	}
+	m.relativeStackSize--
+	m.addSuccessor(m.relativeStackSize, dflt)
+	dflt.flags |= FLAG_JUMP_TARGET
+	for _, label := range labels {
+		m.addSuccessor(m.relativeStackSize, label)
+		label.flags |= FLAG_JUMP_TARGET
+	}
+	m.endBasicBlock()
+}
+
+func (m *MaxsComputer) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.push(1 - numDimensions)
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitMultiANewArrayInsn(descriptor, numDimensions)
+	}
+}
+
+func (m *MaxsComputer) VisitInsnAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitInsnAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (m *MaxsComputer) VisitTryCatchBlock(start, end, handler *Label, typed string) {
+	handler.flags |= FLAG_JUMP_TARGET
+	m.handlers = append(m.handlers, maxsHandler{start, end, handler})
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitTryCatchBlock(start, end, handler, typed)
+	}
+}
+
+func (m *MaxsComputer) VisitTryCatchAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitTryCatchAnnotation(typeRef, typePath, descriptor, visible)
+	}
+	return nil
+}
+
+func (m *MaxsComputer) VisitLocalVariable(name, descriptor, signature string, start, end *Label, index int) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitLocalVariable(name, descriptor, signature, start, end, index)
+	}
+}
+
+func (m *MaxsComputer) VisitLocalVariableAnnotation(typeRef int, typePath *TypePath, start, end []*Label, index []int, descriptor string, visible bool) AnnotationVisitor {
+	if m.methodVisitor != nil {
+		return m.methodVisitor.VisitLocalVariableAnnotation(typeRef, typePath, start, end, index, descriptor, visible)
+	}
+	return nil
+}
+
+func (m *MaxsComputer) VisitLineNumber(line int, start *Label) {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitLineNumber(line, start)
+	}
+}
+
+func (m *MaxsComputer) VisitMaxs(maxStack int, maxLocals int) {
+	m.computeMaxStack()
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitMaxs(m.maxStack, m.maxLocals)
+	}
+}
+
+func (m *MaxsComputer) VisitEnd() {
+	if m.methodVisitor != nil {
+		m.methodVisitor.VisitEnd()
+	}
+}
+
+// computeMaxStack completes the control flow graph with the exception handler and subroutine edges, and computes
+// the maximum stack size of the method with a data flow analysis on this graph.
+func (m *MaxsComputer) computeMaxStack() {
+	if m.firstBasicBlock == nil {
+		return
+	}
+	if m.currentBasicBlock != nil {
+		m.endBasicBlock()
+	}
+	for _, handler := range m.handlers {
+		for block := handler.start; block != nil && block != handler.end; block = block.nextBasicBlock {
+			if (block.flags&FLAG_SUBROUTINE_CALLER) == 0 || block.outgoingEdges == nil || block.outgoingEdges.nextEdge == nil {
+				block.outgoingEdges = NewEdge(EXCEPTION, handler.handler, block.outgoingEdges)
+			} else {
+				// Keep the two first edges of the subroutine callers in place (see VisitJumpInsn).
+				block.outgoingEdges.nextEdge.nextEdge = NewEdge(EXCEPTION, handler.handler, block.outgoingEdges.nextEdge.nextEdge)
+			}
+		}
+	}
+	if m.hasSubroutines {
+		m.addSubroutineRetSuccessors()
+	}
+
+	maxStack := 0
+	listOfBlocksToProcess := m.firstBasicBlock
+	listOfBlocksToProcess.nextListElement = EMPTY_LIST
+	for listOfBlocksToProcess != EMPTY_LIST {
+		// The processed blocks keep a non nil nextListElement, so that they are not processed again.
+		basicBlock := listOfBlocksToProcess
+		listOfBlocksToProcess = listOfBlocksToProcess.nextListElement
+		inputStackTop := int(basicBlock.inputStackSize)
+		maxStack = max(maxStack, inputStackTop+int(basicBlock.outputStackMax))
+		outgoingEdge := basicBlock.outgoingEdges
+		if (basicBlock.flags & FLAG_SUBROUTINE_CALLER) != 0 {
+			// The first edge of a subroutine caller is virtual (see VisitJumpInsn).
+			outgoingEdge = outgoingEdge.nextEdge
+		}
+		for ; outgoingEdge != nil; outgoingEdge = outgoingEdge.nextEdge {
+			successorBlock := outgoingEdge.successor
+			if successorBlock.nextListElement == nil {
+				if outgoingEdge.info == EXCEPTION {
+					successorBlock.inputStackSize = 1
+				} else {
+					successorBlock.inputStackSize = int16(inputStackTop + outgoingEdge.info)
+				}
+				successorBlock.nextListElement = listOfBlocksToProcess
+				listOfBlocksToProcess = successorBlock
+			}
+		}
+	}
+	m.maxStack = maxStack
+}
+
+// addSubroutineRetSuccessors finds the basic blocks of each subroutine, and adds the blocks following the
+// subroutine callers as successors of the blocks ending with a ret instruction which they can reach.
+func (m *MaxsComputer) addSubroutineRetSuccessors() {
+	numSubroutines := 1
+	for block := m.firstBasicBlock; block != nil; block = block.nextBasicBlock {
+		if (block.flags & FLAG_SUBROUTINE_START) != 0 {
+			numSubroutines++
+		}
+	}
+	// The main "subroutine" has the ID 0, the others are numbered in the order in which they are found.
+	m.firstBasicBlock.markSubroutine(0, numSubroutines)
+	markedSubroutines := 1
+	for currentSubroutine := 0; currentSubroutine < markedSubroutines; currentSubroutine++ {
+		for block := m.firstBasicBlock; block != nil; block = block.nextBasicBlock {
+			if (block.flags&FLAG_SUBROUTINE_CALLER) != 0 && block.isInSubroutine(currentSubroutine) {
+				jsrTarget := block.outgoingEdges.nextEdge.successor
+				if (jsrTarget.flags & FLAG_SUBROUTINE_BODY) == 0 {
+					jsrTarget.markSubroutine(markedSubroutines, numSubroutines)
+					markedSubroutines++
+				}
+			}
+		}
+	}
+	for block := m.firstBasicBlock; block != nil; block = block.nextBasicBlock {
+		if (block.flags & FLAG_SUBROUTINE_CALLER) != 0 {
+			block.outgoingEdges.nextEdge.successor.addSubroutineRetSuccessors(block, numSubroutines)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package asm_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestMaxsComputer(t *testing.T) {
+	method := tree.NewMethodNode(opcodes.ACC_STATIC, "sum", "(I)J", "", nil)
+	computer := asm.NewMaxsComputer(method.Access, method.Descriptor, method)
+	start, loop, end, handler := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	computer.VisitCode()
+	computer.VisitTryCatchBlock(start, end, handler, "java/lang/ArithmeticException")
+	computer.VisitLabel(start)
+	computer.VisitInsn(opcodes.LCONST_0)
+	computer.VisitVarInsn(opcodes.LSTORE, 1)
+	computer.VisitLabel(loop)
+	computer.VisitVarInsn(opcodes.ILOAD, 0)
+	computer.VisitJumpInsn(opcodes.IFLE, end)
+	computer.VisitVarInsn(opcodes.LLOAD, 1)
+	computer.VisitVarInsn(opcodes.ILOAD, 0)
+	computer.VisitInsn(opcodes.I2L)
+	computer.VisitInsn(opcodes.LADD)
+	computer.VisitVarInsn(opcodes.LSTORE, 1)
+	computer.VisitIincInsn(0, -1)
+	computer.VisitJumpInsn(opcodes.GOTO, loop)
+	computer.VisitLabel(end)
+	computer.VisitVarInsn(opcodes.LLOAD, 1)
+	computer.VisitInsn(opcodes.LRETURN)
+	computer.VisitLabel(handler)
+	computer.VisitVarInsn(opcodes.ASTORE, 3)
+	computer.VisitInsn(opcodes.LCONST_0)
+	computer.VisitInsn(opcodes.LRETURN)
+	computer.VisitMaxs(0, 0)
+	computer.VisitEnd()
+	if method.MaxStack != 4 || method.MaxLocals != 4 {
+		t.Errorf("unexpected maxs %d %d", method.MaxStack, method.MaxLocals)
+	}
+}
@@ -0,0 +1,892 @@
+package asm
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm/constants"
+	"github.com/leaklessgfy/asm/asm/frame"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// methodWriterHandler an exception handler of a {@link MethodWriter}, i.e. an entry of its exception_table.
+type methodWriterHandler struct {
+	start     *Label
+	end       *Label
+	handler   *Label
+	catchType int
+}
+
+// MethodWriter a {@link MethodVisitor} that generates the method_info structure of a method, as defined in the Java
+// Virtual Machine Specification (JVMS), with the constants it refers to added to a {@link SymbolTable}. The
+// instructions are written as they are visited, and the {@link Label}s are resolved when they are visited (the
+// forward references to a label are patched at this time). The maxs and the stack map frames are written as
+// visited: to compute them, a {@link MaxsComputer} or a {@link FramesComputer} must be chained in front of the
+// writer. The labels of the method are updated by the writer, and thus can't be visited by another writer
+// afterwards.
+//
+// When a forward jump needs an offset larger than 32767 bytes, the jump instruction is replaced with an ASM
+// specific instruction using an unsigned offset (see {@link Label#resolve}), and {@link HasAsmInstructions}
+// returns true. The class must then be read again with the {@link EXPAND_ASM_INSNS} option, which replaces these
+// instructions with standard ones (using GOTO_W), and written again with its frames recomputed, as done by
+// {@link AddMethod}.
+type MethodWriter struct {
+	symbolTable         *SymbolTable
+	accessFlags         int
+	nameIndex           int
+	name                string
+	descriptorIndex     int
+	descriptor          string
+	signatureIndex      int
+	exceptionIndexTable []int
+	maxStack            int
+	maxLocals           int
+	code                *ByteVector
+	handlers            []methodWriterHandler
+	// lastBytecodeOffset the start offset of the last visited instruction.
+	lastBytecodeOffset           int
+	lineNumberTableLength        int
+	lineNumberTable              *ByteVector
+	localVariableTableLength     int
+	localVariableTable           *ByteVector
+	localVariableTypeTableLength int
+	localVariableTypeTable       *ByteVector
+	stackMapTableNumberOfEntries int
+	stackMapTableEntries         *ByteVector
+	// previousFrameOffset the bytecode offset of the last frame written in stackMapTableEntries.
+	previousFrameOffset int
+	// previousFrameLocals the local variable types of the last visited frame, in the format of
+	// {@link MethodVisitor#VisitFrame}, or nil if no frame has been visited yet.
+	previousFrameLocals []interface{}
+	parametersCount     int
+	parameters          *ByteVector
+	// defaultValue the element_value of the AnnotationDefault attribute, or nil.
+	defaultValue                       *ByteVector
+	lastRuntimeVisibleAnnotation       *AnnotationWriter
+	lastRuntimeInvisibleAnnotation     *AnnotationWriter
+	lastRuntimeVisibleTypeAnnotation   *AnnotationWriter
+	lastRuntimeInvisibleTypeAnnotation *AnnotationWriter
+	// visibleAnnotableParameterCount the number of method parameters that can have runtime visible annotations, or
+	// 0 to use the size of lastRuntimeVisibleParameterAnnotations.
+	visibleAnnotableParameterCount           int
+	lastRuntimeVisibleParameterAnnotations   []*AnnotationWriter
+	invisibleAnnotableParameterCount         int
+	lastRuntimeInvisibleParameterAnnotations []*AnnotationWriter
+	lastCodeRuntimeVisibleTypeAnnotation     *AnnotationWriter
+	lastCodeRuntimeInvisibleTypeAnnotation   *AnnotationWriter
+	firstAttribute                           *Attribute
+	firstCodeAttribute                       *Attribute
+	hasAsmInstructions                       bool
+	err                                      error
+}
+
+// NewMethodWriter constructs a new {@link MethodWriter}, whose constants are added to the given symbol table.
+func NewMethodWriter(symbolTable *SymbolTable, access int, name, descriptor, signature string, exceptions []string) *MethodWriter {
+	m := &MethodWriter{
+		symbolTable:     symbolTable,
+		accessFlags:     access,
+		nameIndex:       symbolTable.AddConstantUtf8(name),
+		name:            name,
+		descriptorIndex: symbolTable.AddConstantUtf8(descriptor),
+		descriptor:      descriptor,
+		code:            NewByteVector(),
+	}
+	if signature != "" {
+		m.signatureIndex = symbolTable.AddConstantUtf8(signature)
+	}
+	for _, exception := range exceptions {
+		m.exceptionIndexTable = append(m.exceptionIndexTable, symbolTable.AddConstantClass(exception).index)
+	}
+	return m
+}
+
+// HasAsmInstructions returns whether the code of this method contains ASM specific instructions, which must be
+// replaced with standard ones (see {@link MethodWriter}).
+func (m *MethodWriter) HasAsmInstructions() bool {
+	return m.hasAsmInstructions
+}
+
+// GetError returns the first error which occurred while writing this method (e.g. a reference to a label which
+// is not visited), an error if a constant of the method could not be added to the symbol table, or a
+// {@link LimitExceededError} if the code of the method is too large, or nil.
+func (m *MethodWriter) GetError() error {
+	if m.err != nil {
+		return m.err
+	}
+	if err := m.symbolTable.GetError(); err != nil {
+		return err
+	}
+	if m.code.Size() > MAX_CODE_SIZE {
+		return &LimitExceededError{
+			Kind:       ErrMethodTooLarge,
+			ClassName:  m.symbolTable.GetClassName(),
+			MethodName: m.name,
+			Descriptor: m.descriptor,
+			Value:      m.code.Size(),
+			Limit:      MAX_CODE_SIZE,
+		}
+	}
+	return nil
+}
+
+// setError records the given error, if it is the first one.
+func (m *MethodWriter) setError(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// offsetOf returns the bytecode offset of the given label, which must have been visited.
+func (m *MethodWriter) offsetOf(label *Label) int {
+	offset, err := label.getOffset()
+	if err != nil {
+		m.setError(err)
+	}
+	return offset
+}
+
+func (m *MethodWriter) VisitParameter(name string, access int) {
+	if m.parameters == nil {
+		m.parameters = NewByteVector()
+	}
+	m.parametersCount++
+	if name == "" {
+		m.parameters.PutShort(0).PutShort(access)
+	} else {
+		m.parameters.PutShort(m.symbolTable.AddConstantUtf8(name)).PutShort(access)
+	}
+}
+
+func (m *MethodWriter) VisitAnnotationDefault() AnnotationVisitor {
+	m.defaultValue = NewByteVector()
+	return newAnnotationDefaultWriter(m.symbolTable, m.defaultValue)
+}
+
+func (m *MethodWriter) VisitAnnotation(descriptor string, visible bool) AnnotationVisitor {
+	if visible {
+		m.lastRuntimeVisibleAnnotation = NewAnnotationWriter(m.symbolTable, descriptor, m.lastRuntimeVisibleAnnotation)
+		return m.lastRuntimeVisibleAnnotation
+	}
+	m.lastRuntimeInvisibleAnnotation = NewAnnotationWriter(m.symbolTable, descriptor, m.lastRuntimeInvisibleAnnotation)
+	return m.lastRuntimeInvisibleAnnotation
+}
+
+func (m *MethodWriter) VisitTypeAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if visible {
+		m.lastRuntimeVisibleTypeAnnotation = NewTypeAnnotationWriter(m.symbolTable, typeRef, typePath, descriptor, m.lastRuntimeVisibleTypeAnnotation)
+		return m.lastRuntimeVisibleTypeAnnotation
+	}
+	m.lastRuntimeInvisibleTypeAnnotation = NewTypeAnnotationWriter(m.symbolTable, typeRef, typePath, descriptor, m.lastRuntimeInvisibleTypeAnnotation)
+	return m.lastRuntimeInvisibleTypeAnnotation
+}
+
+func (m *MethodWriter) VisitAnnotableParameterCount(parameterCount int, visible bool) {
+	if visible {
+		m.visibleAnnotableParameterCount = parameterCount
+	} else {
+		m.invisibleAnnotableParameterCount = parameterCount
+	}
+}
+
+func (m *MethodWriter) VisitParameterAnnotation(parameter int, descriptor string, visible bool) AnnotationVisitor {
+	if parameter < 0 {
+		m.setError(errors.New("Illegal Argument - negative parameter index"))
+		return nil
+	}
+	if visible {
+		m.lastRuntimeVisibleParameterAnnotations = m.growParameterAnnotations(m.lastRuntimeVisibleParameterAnnotations, parameter)
+		annotationWriter := NewAnnotationWriter(m.symbolTable, descriptor, m.lastRuntimeVisibleParameterAnnotations[parameter])
+		m.lastRuntimeVisibleParameterAnnotations[parameter] = annotationWriter
+		return annotationWriter
+	}
+	m.lastRuntimeInvisibleParameterAnnotations = m.growParameterAnnotations(m.lastRuntimeInvisibleParameterAnnotations, parameter)
+	annotationWriter := NewAnnotationWriter(m.symbolTable, descriptor, m.lastRuntimeInvisibleParameterAnnotations[parameter])
+	m.lastRuntimeInvisibleParameterAnnotations[parameter] = annotationWriter
+	return annotationWriter
+}
+
+// growParameterAnnotations returns the given parameter annotations, with at least one element per argument of the
+// method and per parameter up to the given one.
+func (m *MethodWriter) growParameterAnnotations(parameterAnnotations []*AnnotationWriter, parameter int) []*AnnotationWriter {
+	if parameterAnnotations == nil {
+		parameterAnnotations = make([]*AnnotationWriter, len(GetMethodType(m.descriptor).GetArgumentTypes()))
+	}
+	for len(parameterAnnotations) <= parameter {
+		parameterAnnotations = append(parameterAnnotations, nil)
+	}
+	return parameterAnnotations
+}
+
+// annotableParameterCount returns the num_parameters field of a Runtime[In]VisibleParameterAnnotations attribute.
+func annotableParameterCount(annotableParameterCount int, parameterAnnotations []*AnnotationWriter) int {
+	if annotableParameterCount == 0 || annotableParameterCount > len(parameterAnnotations) {
+		return len(parameterAnnotations)
+	}
+	return annotableParameterCount
+}
+
+func (m *MethodWriter) VisitAttribute(attribute *Attribute) {
+	if err := attribute.checkConstantPool(m.symbolTable); err != nil {
+		m.setError(err)
+	}
+	if attribute.isCodeAttribute() {
+		attribute.nextAttribute = m.firstCodeAttribute
+		m.firstCodeAttribute = attribute
+	} else {
+		attribute.nextAttribute = m.firstAttribute
+		m.firstAttribute = attribute
+	}
+}
+
+func (m *MethodWriter) VisitCode() {
+}
+
+func (m *MethodWriter) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	if typed == constants.F_INSERT {
+		// The frames inserted when expanding the ASM specific instructions must be recomputed.
+		return
+	}
+	locals, _ := local.([]interface{})
+	stacks, _ := stack.([]interface{})
+	legacy := m.symbolTable.GetMajorVersion() != 0 && m.symbolTable.GetMajorVersion() < opcodes.V1_6
+	if legacy && typed != opcodes.F_NEW {
+		m.setError(errors.New("Illegal Argument - class versions V1_5 or less must use F_NEW frames"))
+		return
+	}
+	if m.previousFrameLocals == nil {
+		m.previousFrameLocals = m.implicitFirstFrameLocals()
+	}
+	offsetDelta := m.code.Size()
+	if m.stackMapTableEntries == nil {
+		m.stackMapTableEntries = NewByteVector()
+	} else {
+		offsetDelta = m.code.Size() - m.previousFrameOffset - 1
+		if offsetDelta < 0 {
+			if typed != opcodes.F_SAME {
+				m.setError(errors.New("Illegal State - two frames at the same bytecode offset"))
+			}
+			return
+		}
+	}
+	switch typed {
+	case opcodes.F_NEW:
+		if legacy {
+			m.putFullFrame(m.code.Size(), locals[:nLocal], stacks[:nStack])
+		} else {
+			m.putFrame(offsetDelta, locals[:nLocal], stacks[:nStack])
+		}
+	case opcodes.F_FULL:
+		m.putFullFrame(offsetDelta, locals[:nLocal], stacks[:nStack])
+	case opcodes.F_APPEND:
+		m.stackMapTableEntries.PutByte(frame.SAME_FRAME_EXTENDED + nLocal).PutShort(offsetDelta)
+		for _, localType := range locals[:nLocal] {
+			m.putFrameType(localType)
+		}
+		m.previousFrameLocals = append(m.previousFrameLocals[:len(m.previousFrameLocals):len(m.previousFrameLocals)], locals[:nLocal]...)
+	case opcodes.F_CHOP:
+		m.stackMapTableEntries.PutByte(frame.SAME_FRAME_EXTENDED - nLocal).PutShort(offsetDelta)
+		if nLocal > len(m.previousFrameLocals) {
+			nLocal = len(m.previousFrameLocals)
+		}
+		m.previousFrameLocals = m.previousFrameLocals[:len(m.previousFrameLocals)-nLocal]
+	case opcodes.F_SAME:
+		m.putSameFrame(offsetDelta)
+	case opcodes.F_SAME1:
+		m.putSame1Frame(offsetDelta, stacks[0])
+	}
+	m.previousFrameOffset = m.code.Size()
+	m.stackMapTableNumberOfEntries++
+}
+
+// implicitFirstFrameLocals returns the local variable types of the implicit first frame of the method, which is
+// computed from its descriptor.
+func (m *MethodWriter) implicitFirstFrameLocals() []interface{} {
+	argumentsSize := 0
+	if (m.accessFlags & opcodes.ACC_STATIC) == 0 {
+		argumentsSize++
+	}
+	for _, argumentType := range GetMethodType(m.descriptor).GetArgumentTypes() {
+		argumentsSize += argumentType.GetSize()
+	}
+	typeTable := newFrameTypeTable(m.symbolTable.GetClassName(), nil)
+	implicitFirstFrame := newFrame(nil)
+	implicitFirstFrame.setInputFrameFromDescriptor(typeTable, m.accessFlags, m.name, m.descriptor, argumentsSize)
+	_, locals, _, _ := implicitFirstFrame.accept(typeTable, nil)
+	return locals
+}
+
+// putFrame puts the given frame, in the format of {@link MethodVisitor#VisitFrame}, in the StackMapTable, in
+// its most compact form given the previous frame.
+func (m *MethodWriter) putFrame(offsetDelta int, locals, stack []interface{}) {
+	previousLocals := m.previousFrameLocals
+	frameType := frame.FULL_FRAME
+	localsDelta := len(locals) - len(previousLocals)
+	if len(stack) == 0 && localsDelta >= -3 && localsDelta <= 3 {
+		switch {
+		case localsDelta < 0:
+			frameType = frame.CHOP_FRAME
+		case localsDelta == 0:
+			frameType = frame.SAME_FRAME
+		default:
+			frameType = frame.APPEND_FRAME
+		}
+	} else if len(stack) == 1 && localsDelta == 0 {
+		frameType = frame.SAME_LOCALS_1_STACK_ITEM_FRAME
+	}
+	if frameType != frame.FULL_FRAME {
+		commonLocals := len(locals)
+		if len(previousLocals) < commonLocals {
+			commonLocals = len(previousLocals)
+		}
+		for i := 0; i < commonLocals; i++ {
+			if locals[i] != previousLocals[i] {
+				frameType = frame.FULL_FRAME
+				break
+			}
+		}
+	}
+	switch frameType {
+	case frame.SAME_FRAME:
+		m.putSameFrame(offsetDelta)
+	case frame.SAME_LOCALS_1_STACK_ITEM_FRAME:
+		m.putSame1Frame(offsetDelta, stack[0])
+	case frame.CHOP_FRAME:
+		m.stackMapTableEntries.PutByte(frame.SAME_FRAME_EXTENDED + localsDelta).PutShort(offsetDelta)
+	case frame.APPEND_FRAME:
+		m.stackMapTableEntries.PutByte(frame.SAME_FRAME_EXTENDED + localsDelta).PutShort(offsetDelta)
+		for _, localType := range locals[len(previousLocals):] {
+			m.putFrameType(localType)
+		}
+	default:
+		m.putFullFrame(offsetDelta, locals, stack)
+		return
+	}
+	m.previousFrameLocals = append([]interface{}(nil), locals...)
+}
+
+func (m *MethodWriter) putSameFrame(offsetDelta int) {
+	if offsetDelta < frame.SAME_LOCALS_1_STACK_ITEM_FRAME {
+		m.stackMapTableEntries.PutByte(frame.SAME_FRAME + offsetDelta)
+	} else {
+		m.stackMapTableEntries.PutByte(frame.SAME_FRAME_EXTENDED).PutShort(offsetDelta)
+	}
+}
+
+func (m *MethodWriter) putSame1Frame(offsetDelta int, stackType interface{}) {
+	if offsetDelta < frame.SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED-frame.SAME_LOCALS_1_STACK_ITEM_FRAME {
+		m.stackMapTableEntries.PutByte(frame.SAME_LOCALS_1_STACK_ITEM_FRAME + offsetDelta)
+	} else {
+		m.stackMapTableEntries.PutByte(frame.SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED).PutShort(offsetDelta)
+	}
+	m.putFrameType(stackType)
+}
+
+// putFullFrame puts a full_frame in the StackMapTable or, for the classes whose version is less than V1_6, a
+// frame of the StackMap attribute (whose offset is absolute).
+func (m *MethodWriter) putFullFrame(offset int, locals, stack []interface{}) {
+	if m.symbolTable.GetMajorVersion() == 0 || m.symbolTable.GetMajorVersion() >= opcodes.V1_6 {
+		m.stackMapTableEntries.PutByte(frame.FULL_FRAME)
+	}
+	m.stackMapTableEntries.PutShort(offset).PutShort(len(locals))
+	for _, localType := range locals {
+		m.putFrameType(localType)
+	}
+	m.stackMapTableEntries.PutShort(len(stack))
+	for _, stackType := range stack {
+		m.putFrameType(stackType)
+	}
+	m.previousFrameLocals = append([]interface{}(nil), locals...)
+}
+
+// putFrameType puts the given verification type, in the format of {@link MethodVisitor#VisitFrame}, in the
+// StackMapTable.
+func (m *MethodWriter) putFrameType(verificationType interface{}) {
+	switch value := verificationType.(type) {
+	case int:
+		m.stackMapTableEntries.PutByte(value)
+	case string:
+		m.stackMapTableEntries.put12(frame.ITEM_OBJECT, m.symbolTable.AddConstantClass(value).index)
+	case *Label:
+		m.stackMapTableEntries.put12(frame.ITEM_UNINITIALIZED, m.offsetOf(value))
+	default:
+		m.setError(errors.New("Illegal Argument - invalid frame type"))
+		m.stackMapTableEntries.PutByte(frame.ITEM_TOP)
+	}
+}
+
+func (m *MethodWriter) VisitInsn(opcode int) {
+	m.lastBytecodeOffset = m.code.Size()
+	m.code.PutByte(opcode)
+}
+
+func (m *MethodWriter) VisitIntInsn(opcode, operand int) {
+	m.lastBytecodeOffset = m.code.Size()
+	if opcode == opcodes.SIPUSH {
+		m.code.put12(opcode, operand)
+	} else {
+		m.code.put11(opcode, operand)
+	}
+}
+
+func (m *MethodWriter) VisitVarInsn(opcode, vard int) {
+	m.lastBytecodeOffset = m.code.Size()
+	if vard < 4 && opcode != opcodes.RET {
+		if opcode < opcodes.ISTORE {
+			m.code.PutByte(constants.ILOAD_0 + ((opcode - opcodes.ILOAD) << 2) + vard)
+		} else {
+			m.code.PutByte(constants.ISTORE_0 + ((opcode - opcodes.ISTORE) << 2) + vard)
+		}
+	} else if vard >= 256 {
+		m.code.PutByte(constants.WIDE).put12(opcode, vard)
+	} else {
+		m.code.put11(opcode, vard)
+	}
+}
+
+func (m *MethodWriter) VisitTypeInsn(opcode int, typed string) {
+	m.lastBytecodeOffset = m.code.Size()
+	m.code.put12(opcode, m.symbolTable.AddConstantClass(typed).index)
+}
+
+func (m *MethodWriter) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.lastBytecodeOffset = m.code.Size()
+	m.code.put12(opcode, m.symbolTable.AddConstantFieldref(owner, name, descriptor).index)
+}
+
+func (m *MethodWriter) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
+
+func (m *MethodWriter) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.lastBytecodeOffset = m.code.Size()
+	methodref := m.symbolTable.AddConstantMethodref(owner, name, descriptor, isInterface)
+	if opcode == opcodes.INVOKEINTERFACE {
+		argumentsSize := 1
+		for _, argumentType := range GetMethodType(descriptor).GetArgumentTypes() {
+			argumentsSize += argumentType.GetSize()
+		}
+		m.code.put12(opcode, methodref.index).put11(argumentsSize, 0)
+	} else {
+		m.code.put12(opcode, methodref.index)
+	}
+}
+
+func (m *MethodWriter) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *Handle, bootstrapMethodArguments ...interface{}) {
+	m.lastBytecodeOffset = m.code.Size()
+	invokeDynamic, err := m.symbolTable.AddConstantInvokeDynamic(name, descriptor, bootstrapMethodHande, bootstrapMethodArguments...)
+	if err != nil {
+		m.setError(err)
+		m.code.put12(opcodes.INVOKEDYNAMIC, 0).PutShort(0)
+		return
+	}
+	m.code.put12(opcodes.INVOKEDYNAMIC, invokeDynamic.index).PutShort(0)
+}
+
+func (m *MethodWriter) VisitJumpInsn(opcode int, label *Label) {
+	m.lastBytecodeOffset = m.code.Size()
+	// The GOTO_W and JSR_W opcodes are written as is, the other ones are written with the shortest form.
+	baseOpcode := opcode
+	if opcode >= constants.GOTO_W {
+		baseOpcode = opcode - constants.WIDE_JUMP_OPCODE_DELTA
+	}
+	if (label.flags&FLAG_RESOLVED) != 0 && label.bytecodeOffset-m.code.Size() < -32768 {
+		// A backward jump with an offset which does not fit in a signed short.
+		if baseOpcode == opcodes.GOTO {
+			m.code.PutByte(constants.GOTO_W)
+		} else if baseOpcode == opcodes.JSR {
+			m.code.PutByte(constants.JSR_W)
+		} else {
+			// The inverted conditional jump skips an ASM_GOTO_W to the target. An ASM_GOTO_W is used instead of a
+			// GOTO_W so that the frame needed after it is inserted when the ASM specific instructions are expanded.
+			if baseOpcode >= opcodes.IFNULL {
+				m.code.PutByte(baseOpcode ^ 1)
+			} else {
+				m.code.PutByte(((baseOpcode + 1) ^ 1) - 1)
+			}
+			m.code.PutShort(8)
+			m.code.PutByte(constants.ASM_GOTO_W)
+			m.hasAsmInstructions = true
+		}
+		label.put(m.code, m.code.Size()-1, true)
+	} else if baseOpcode != opcode {
+		m.code.PutByte(opcode)
+		label.put(m.code, m.code.Size()-1, true)
+	} else {
+		m.code.PutByte(baseOpcode)
+		label.put(m.code, m.code.Size()-1, false)
+	}
+}
+
+func (m *MethodWriter) VisitLabel(label *Label) {
+	if label.flags&FLAG_RESOLVED != 0 {
+		m.setError(errors.New("Illegal Argument - label already visited"))
+		return
+	}
+	if label.resolve(m.code.data, m.code.Size()) {
+		m.hasAsmInstructions = true
+	}
+}
+
+func (m *MethodWriter) VisitLdcInsn(value interface{}) {
+	m.lastBytecodeOffset = m.code.Size()
+	constant, err := m.symbolTable.AddConstant(value)
+	if err != nil {
+		m.setError(err)
+		m.code.put11(opcodes.LDC, 0)
+		return
+	}
+	if constant.tag == symbol.CONSTANT_LONG_TAG || constant.tag == symbol.CONSTANT_DOUBLE_TAG {
+		m.code.put12(constants.LDC2_W, constant.index)
+	} else if constant.index >= 256 {
+		m.code.put12(constants.LDC_W, constant.index)
+	} else {
+		m.code.put11(opcodes.LDC, constant.index)
+	}
+}
+
+func (m *MethodWriter) VisitIincInsn(vard, increment int) {
+	m.lastBytecodeOffset = m.code.Size()
+	if vard > 255 || increment > 127 || increment < -128 {
+		m.code.PutByte(constants.WIDE).put12(opcodes.IINC, vard).PutShort(increment)
+	} else {
+		m.code.PutByte(opcodes.IINC).put11(vard, increment)
+	}
+}
+
+func (m *MethodWriter) VisitTableSwitchInsn(min, max int, dflt *Label, labels ...*Label) {
+	m.lastBytecodeOffset = m.code.Size()
+	m.code.PutByte(opcodes.TABLESWITCH).PutByteArray(nil, 0, (4-m.code.Size()%4)%4)
+	dflt.put(m.code, m.lastBytecodeOffset, true)
+	m.code.PutInt(min).PutInt(max)
+	for _, label := range labels {
+		label.put(m.code, m.lastBytecodeOffset, true)
+	}
+}
+
+func (m *MethodWriter) VisitLookupSwitchInsn(dflt *Label, keys []int, labels []*Label) {
+	m.lastBytecodeOffset = m.code.Size()
+	m.code.PutByte(opcodes.LOOKUPSWITCH).PutByteArray(nil, 0, (4-m.code.Size()%4)%4)
+	dflt.put(m.code, m.lastBytecodeOffset, true)
+	m.code.PutInt(len(labels))
+	for i, label := range labels {
+		m.code.PutInt(keys[i])
+		label.put(m.code, m.lastBytecodeOffset, true)
+	}
+}
+
+func (m *MethodWriter) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.lastBytecodeOffset = m.code.Size()
+	m.code.put12(opcodes.MULTIANEWARRAY, m.symbolTable.AddConstantClass(descriptor).index).PutByte(numDimensions)
+}
+
+func (m *MethodWriter) VisitInsnAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	// The offset of the annotated instruction is stored in the type reference, in place of its arguments.
+	typeRef = (typeRef & 0xFF0000FF) | (m.lastBytecodeOffset << 8)
+	return m.visitCodeTypeAnnotation(typeRef, typePath, descriptor, visible)
+}
+
+// visitCodeTypeAnnotation adds a type annotation of the Code attribute, whose type reference has its final
+// arguments.
+func (m *MethodWriter) visitCodeTypeAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	if visible {
+		m.lastCodeRuntimeVisibleTypeAnnotation = NewTypeAnnotationWriter(m.symbolTable, typeRef, typePath, descriptor, m.lastCodeRuntimeVisibleTypeAnnotation)
+		return m.lastCodeRuntimeVisibleTypeAnnotation
+	}
+	m.lastCodeRuntimeInvisibleTypeAnnotation = NewTypeAnnotationWriter(m.symbolTable, typeRef, typePath, descriptor, m.lastCodeRuntimeInvisibleTypeAnnotation)
+	return m.lastCodeRuntimeInvisibleTypeAnnotation
+}
+
+func (m *MethodWriter) VisitTryCatchBlock(start, end, handler *Label, typed string) {
+	catchType := 0
+	if typed != "" {
+		catchType = m.symbolTable.AddConstantClass(typed).index
+	}
+	m.handlers = append(m.handlers, methodWriterHandler{start: start, end: end, handler: handler, catchType: catchType})
+}
+
+func (m *MethodWriter) VisitTryCatchAnnotation(typeRef int, typePath *TypePath, descriptor string, visible bool) AnnotationVisitor {
+	return m.visitCodeTypeAnnotation(typeRef, typePath, descriptor, visible)
+}
+
+func (m *MethodWriter) VisitLocalVariable(name, descriptor, signature string, start, end *Label, index int) {
+	startOffset := m.offsetOf(start)
+	length := m.offsetOf(end) - startOffset
+	if signature != "" {
+		if m.localVariableTypeTable == nil {
+			m.localVariableTypeTable = NewByteVector()
+		}
+		m.localVariableTypeTableLength++
+		m.localVariableTypeTable.PutShort(startOffset).PutShort(length).
+			PutShort(m.symbolTable.AddConstantUtf8(name)).PutShort(m.symbolTable.AddConstantUtf8(signature)).
+			PutShort(index)
+	}
+	if m.localVariableTable == nil {
+		m.localVariableTable = NewByteVector()
+	}
+	m.localVariableTableLength++
+	m.localVariableTable.PutShort(startOffset).PutShort(length).
+		PutShort(m.symbolTable.AddConstantUtf8(name)).PutShort(m.symbolTable.AddConstantUtf8(descriptor)).
+		PutShort(index)
+}
+
+func (m *MethodWriter) VisitLocalVariableAnnotation(typeRef int, typePath *TypePath, start, end []*Label, index []int, descriptor string, visible bool) AnnotationVisitor {
+	if len(end) != len(start) || len(index) != len(start) {
+		m.setError(errors.New("Illegal Argument - local variable annotation tables of different lengths"))
+		return nil
+	}
+	// Write target_type, target_info, and target_path.
+	typeAnnotation := NewByteVector()
+	typeAnnotation.PutByte(typeRef >> 24).PutShort(len(start))
+	for i := range start {
+		startOffset := m.offsetOf(start[i])
+		typeAnnotation.PutShort(startOffset).PutShort(m.offsetOf(end[i]) - startOffset).PutShort(index[i])
+	}
+	if err := putTypePath(typePath, typeAnnotation); err != nil {
+		m.setError(err)
+	}
+	// Write type_index and reserve space for num_element_value_pairs.
+	typeAnnotation.PutShort(m.symbolTable.AddConstantUtf8(descriptor)).PutShort(0)
+	if visible {
+		m.lastCodeRuntimeVisibleTypeAnnotation = newAnnotationWriter(m.symbolTable, true, typeAnnotation, m.lastCodeRuntimeVisibleTypeAnnotation)
+		return m.lastCodeRuntimeVisibleTypeAnnotation
+	}
+	m.lastCodeRuntimeInvisibleTypeAnnotation = newAnnotationWriter(m.symbolTable, true, typeAnnotation, m.lastCodeRuntimeInvisibleTypeAnnotation)
+	return m.lastCodeRuntimeInvisibleTypeAnnotation
+}
+
+func (m *MethodWriter) VisitLineNumber(line int, start *Label) {
+	if m.lineNumberTable == nil {
+		m.lineNumberTable = NewByteVector()
+	}
+	m.lineNumberTableLength++
+	m.lineNumberTable.PutShort(m.offsetOf(start)).PutShort(line)
+}
+
+func (m *MethodWriter) VisitMaxs(maxStack int, maxLocals int) {
+	m.maxStack = maxStack
+	m.maxLocals = maxLocals
+}
+
+func (m *MethodWriter) VisitEnd() {
+}
+
+// useSyntheticAttribute returns whether the ACC_SYNTHETIC flag must be written as a Synthetic attribute.
+func (m *MethodWriter) useSyntheticAttribute() bool {
+	return m.symbolTable.GetMajorVersion() != 0 && m.symbolTable.GetMajorVersion() < opcodes.V1_5
+}
+
+// stackMapTableName returns the name of the attribute of the stack map frames.
+func (m *MethodWriter) stackMapTableName() string {
+	if m.symbolTable.GetMajorVersion() != 0 && m.symbolTable.GetMajorVersion() < opcodes.V1_6 {
+		return "StackMap"
+	}
+	return "StackMapTable"
+}
+
+// ComputeMethodInfoSize returns the size of the method_info structure of this method, and adds the names of its
+// attributes to the constant pool.
+func (m *MethodWriter) ComputeMethodInfoSize() int {
+	size := 8
+	if m.code.Size() > 0 {
+		m.symbolTable.AddConstantUtf8("Code")
+		size += 18 + m.code.Size() + 8*len(m.handlers)
+		if m.stackMapTableEntries != nil {
+			m.symbolTable.AddConstantUtf8(m.stackMapTableName())
+			size += 8 + m.stackMapTableEntries.Size()
+		}
+		if m.lineNumberTable != nil {
+			m.symbolTable.AddConstantUtf8("LineNumberTable")
+			size += 8 + m.lineNumberTable.Size()
+		}
+		if m.localVariableTable != nil {
+			m.symbolTable.AddConstantUtf8("LocalVariableTable")
+			size += 8 + m.localVariableTable.Size()
+		}
+		if m.localVariableTypeTable != nil {
+			m.symbolTable.AddConstantUtf8("LocalVariableTypeTable")
+			size += 8 + m.localVariableTypeTable.Size()
+		}
+		size += computeAnnotationAttributesSize(nil, nil, m.lastCodeRuntimeVisibleTypeAnnotation, m.lastCodeRuntimeInvisibleTypeAnnotation)
+		if m.firstCodeAttribute != nil {
+			size += m.firstCodeAttribute._computeAttributesSize(m.symbolTable, m.code.data, m.code.Size(), m.maxStack, m.maxLocals)
+		}
+	}
+	if len(m.exceptionIndexTable) > 0 {
+		m.symbolTable.AddConstantUtf8("Exceptions")
+		size += 8 + 2*len(m.exceptionIndexTable)
+	}
+	if (m.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && m.useSyntheticAttribute() {
+		m.symbolTable.AddConstantUtf8("Synthetic")
+		size += 6
+	}
+	if m.signatureIndex != 0 {
+		m.symbolTable.AddConstantUtf8("Signature")
+		size += 8
+	}
+	if (m.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		m.symbolTable.AddConstantUtf8("Deprecated")
+		size += 6
+	}
+	if m.parameters != nil {
+		m.symbolTable.AddConstantUtf8("MethodParameters")
+		size += 7 + m.parameters.Size()
+	}
+	if m.defaultValue != nil {
+		m.symbolTable.AddConstantUtf8("AnnotationDefault")
+		size += 6 + m.defaultValue.Size()
+	}
+	size += computeAnnotationAttributesSize(m.lastRuntimeVisibleAnnotation, m.lastRuntimeInvisibleAnnotation,
+		m.lastRuntimeVisibleTypeAnnotation, m.lastRuntimeInvisibleTypeAnnotation)
+	if m.lastRuntimeVisibleParameterAnnotations != nil {
+		size += computeParameterAnnotationsSize(m.symbolTable, "RuntimeVisibleParameterAnnotations", m.lastRuntimeVisibleParameterAnnotations,
+			annotableParameterCount(m.visibleAnnotableParameterCount, m.lastRuntimeVisibleParameterAnnotations))
+	}
+	if m.lastRuntimeInvisibleParameterAnnotations != nil {
+		size += computeParameterAnnotationsSize(m.symbolTable, "RuntimeInvisibleParameterAnnotations", m.lastRuntimeInvisibleParameterAnnotations,
+			annotableParameterCount(m.invisibleAnnotableParameterCount, m.lastRuntimeInvisibleParameterAnnotations))
+	}
+	if m.firstAttribute != nil {
+		size += m.firstAttribute.computeAttributesSize(m.symbolTable)
+	}
+	return size
+}
+
+// PutMethodInfo puts the content of the method_info structure of this method into the given vector. The
+// handlers, local variables and frames must refer to visited labels (see {@link GetError}).
+func (m *MethodWriter) PutMethodInfo(output *ByteVector) {
+	mask := 0
+	if m.useSyntheticAttribute() {
+		mask = opcodes.ACC_SYNTHETIC
+	}
+	output.PutShort(m.accessFlags &^ mask).PutShort(m.nameIndex).PutShort(m.descriptorIndex)
+	attributeCount := 0
+	if m.code.Size() > 0 {
+		attributeCount++
+	}
+	if len(m.exceptionIndexTable) > 0 {
+		attributeCount++
+	}
+	if (m.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && m.useSyntheticAttribute() {
+		attributeCount++
+	}
+	if m.signatureIndex != 0 {
+		attributeCount++
+	}
+	if (m.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		attributeCount++
+	}
+	if m.parameters != nil {
+		attributeCount++
+	}
+	if m.defaultValue != nil {
+		attributeCount++
+	}
+	attributeCount += countAnnotationAttributes(m.lastRuntimeVisibleAnnotation, m.lastRuntimeInvisibleAnnotation,
+		m.lastRuntimeVisibleTypeAnnotation, m.lastRuntimeInvisibleTypeAnnotation)
+	if m.lastRuntimeVisibleParameterAnnotations != nil {
+		attributeCount++
+	}
+	if m.lastRuntimeInvisibleParameterAnnotations != nil {
+		attributeCount++
+	}
+	if m.firstAttribute != nil {
+		attributeCount += m.firstAttribute.getAttributeCount()
+	}
+	output.PutShort(attributeCount)
+	if m.code.Size() > 0 {
+		m.putCode(output)
+	}
+	if len(m.exceptionIndexTable) > 0 {
+		output.PutShort(m.symbolTable.AddConstantUtf8("Exceptions")).PutInt(2 + 2*len(m.exceptionIndexTable)).
+			PutShort(len(m.exceptionIndexTable))
+		for _, exceptionIndex := range m.exceptionIndexTable {
+			output.PutShort(exceptionIndex)
+		}
+	}
+	if (m.accessFlags&opcodes.ACC_SYNTHETIC) != 0 && m.useSyntheticAttribute() {
+		output.PutShort(m.symbolTable.AddConstantUtf8("Synthetic")).PutInt(0)
+	}
+	if m.signatureIndex != 0 {
+		output.PutShort(m.symbolTable.AddConstantUtf8("Signature")).PutInt(2).PutShort(m.signatureIndex)
+	}
+	if (m.accessFlags & opcodes.ACC_DEPRECATED) != 0 {
+		output.PutShort(m.symbolTable.AddConstantUtf8("Deprecated")).PutInt(0)
+	}
+	if m.parameters != nil {
+		output.PutShort(m.symbolTable.AddConstantUtf8("MethodParameters")).PutInt(1+m.parameters.Size()).
+			PutByte(m.parametersCount).PutByteArray(m.parameters.data, 0, m.parameters.Size())
+	}
+	if m.defaultValue != nil {
+		output.PutShort(m.symbolTable.AddConstantUtf8("AnnotationDefault")).PutInt(m.defaultValue.Size()).
+			PutByteArray(m.defaultValue.data, 0, m.defaultValue.Size())
+	}
+	putAnnotationAttributes(m.symbolTable, m.lastRuntimeVisibleAnnotation, m.lastRuntimeInvisibleAnnotation,
+		m.lastRuntimeVisibleTypeAnnotation, m.lastRuntimeInvisibleTypeAnnotation, output)
+	if m.lastRuntimeVisibleParameterAnnotations != nil {
+		putParameterAnnotations(m.symbolTable.AddConstantUtf8("RuntimeVisibleParameterAnnotations"), m.lastRuntimeVisibleParameterAnnotations,
+			annotableParameterCount(m.visibleAnnotableParameterCount, m.lastRuntimeVisibleParameterAnnotations), output)
+	}
+	if m.lastRuntimeInvisibleParameterAnnotations != nil {
+		putParameterAnnotations(m.symbolTable.AddConstantUtf8("RuntimeInvisibleParameterAnnotations"), m.lastRuntimeInvisibleParameterAnnotations,
+			annotableParameterCount(m.invisibleAnnotableParameterCount, m.lastRuntimeInvisibleParameterAnnotations), output)
+	}
+	if m.firstAttribute != nil {
+		m.firstAttribute.putAttribute(m.symbolTable, output)
+	}
+}
+
+// putCode puts the Code attribute of this method into the given vector.
+func (m *MethodWriter) putCode(output *ByteVector) {
+	size := 12 + m.code.Size() + 8*len(m.handlers)
+	codeAttributeCount := 0
+	if m.stackMapTableEntries != nil {
+		size += 8 + m.stackMapTableEntries.Size()
+		codeAttributeCount++
+	}
+	if m.lineNumberTable != nil {
+		size += 8 + m.lineNumberTable.Size()
+		codeAttributeCount++
+	}
+	if m.localVariableTable != nil {
+		size += 8 + m.localVariableTable.Size()
+		codeAttributeCount++
+	}
+	if m.localVariableTypeTable != nil {
+		size += 8 + m.localVariableTypeTable.Size()
+		codeAttributeCount++
+	}
+	size += computeAnnotationAttributesSize(nil, nil, m.lastCodeRuntimeVisibleTypeAnnotation, m.lastCodeRuntimeInvisibleTypeAnnotation)
+	codeAttributeCount += countAnnotationAttributes(m.lastCodeRuntimeVisibleTypeAnnotation, m.lastCodeRuntimeInvisibleTypeAnnotation)
+	if m.firstCodeAttribute != nil {
+		size += m.firstCodeAttribute._computeAttributesSize(m.symbolTable, m.code.data, m.code.Size(), m.maxStack, m.maxLocals)
+		codeAttributeCount += m.firstCodeAttribute.getAttributeCount()
+	}
+	output.PutShort(m.symbolTable.AddConstantUtf8("Code")).PutInt(size).PutShort(m.maxStack).PutShort(m.maxLocals).
+		PutInt(m.code.Size()).PutByteArray(m.code.data, 0, m.code.Size())
+	output.PutShort(len(m.handlers))
+	for _, handler := range m.handlers {
+		output.PutShort(m.offsetOf(handler.start)).PutShort(m.offsetOf(handler.end)).
+			PutShort(m.offsetOf(handler.handler)).PutShort(handler.catchType)
+	}
+	output.PutShort(codeAttributeCount)
+	if m.stackMapTableEntries != nil {
+		output.PutShort(m.symbolTable.AddConstantUtf8(m.stackMapTableName())).PutInt(2+m.stackMapTableEntries.Size()).
+			PutShort(m.stackMapTableNumberOfEntries).PutByteArray(m.stackMapTableEntries.data, 0, m.stackMapTableEntries.Size())
+	}
+	if m.lineNumberTable != nil {
+		output.PutShort(m.symbolTable.AddConstantUtf8("LineNumberTable")).PutInt(2+m.lineNumberTable.Size()).
+			PutShort(m.lineNumberTableLength).PutByteArray(m.lineNumberTable.data, 0, m.lineNumberTable.Size())
+	}
+	if m.localVariableTable != nil {
+		output.PutShort(m.symbolTable.AddConstantUtf8("LocalVariableTable")).PutInt(2+m.localVariableTable.Size()).
+			PutShort(m.localVariableTableLength).PutByteArray(m.localVariableTable.data, 0, m.localVariableTable.Size())
+	}
+	if m.localVariableTypeTable != nil {
+		output.PutShort(m.symbolTable.AddConstantUtf8("LocalVariableTypeTable")).PutInt(2+m.localVariableTypeTable.Size()).
+			PutShort(m.localVariableTypeTableLength).PutByteArray(m.localVariableTypeTable.data, 0, m.localVariableTypeTable.Size())
+	}
+	putAnnotationAttributes(m.symbolTable, nil, nil, m.lastCodeRuntimeVisibleTypeAnnotation, m.lastCodeRuntimeInvisibleTypeAnnotation, output)
+	if m.firstCodeAttribute != nil {
+		m.firstCodeAttribute._putAttribute(m.symbolTable, m.code.data, m.code.Size(), m.maxStack, m.maxLocals, output)
+	}
+}
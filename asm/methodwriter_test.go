@@ -0,0 +1,75 @@
+package asm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func TestMethodWriter(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	writer := classFile.AddMethod(opcodes.ACC_STATIC, "m", "(I)I", "", []string{"java/lang/Exception"})
+	computer := asm.NewFramesComputer("A", opcodes.ACC_STATIC, "m", "(I)I", writer)
+	start, end, handler, loop, exit := &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}, &asm.Label{}
+	computer.VisitCode()
+	computer.VisitTryCatchBlock(start, end, handler, "java/lang/RuntimeException")
+	computer.VisitLabel(start)
+	computer.VisitLineNumber(3, start)
+	computer.VisitInsn(opcodes.ICONST_0)
+	computer.VisitVarInsn(opcodes.ISTORE, 1)
+	computer.VisitLabel(loop)
+	computer.VisitVarInsn(opcodes.ILOAD, 0)
+	computer.VisitJumpInsn(opcodes.IFLE, exit)
+	computer.VisitIincInsn(1, 1000)
+	computer.VisitIincInsn(0, -1)
+	computer.VisitJumpInsn(opcodes.GOTO, loop)
+	computer.VisitLabel(exit)
+	computer.VisitLdcInsn(int64(1) << 40)
+	computer.VisitInsn(opcodes.POP2)
+	computer.VisitVarInsn(opcodes.ILOAD, 1)
+	computer.VisitLabel(end)
+	computer.VisitInsn(opcodes.IRETURN)
+	computer.VisitLabel(handler)
+	computer.VisitInsn(opcodes.POP)
+	computer.VisitInsn(opcodes.ICONST_M1)
+	computer.VisitInsn(opcodes.IRETURN)
+	computer.VisitLocalVariable("i", "I", "", loop, end, 1)
+	computer.VisitMaxs(0, 0)
+	computer.VisitEnd()
+	if err := writer.GetError(); err != nil || writer.HasAsmInstructions() {
+		t.Fatalf("unexpected writer state %v %v", err, writer.HasAsmInstructions())
+	}
+
+	reader, err := asm.NewClassReader(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	methodInfo := reader.Index().Methods[0]
+	if methodInfoSize := writer.ComputeMethodInfoSize(); methodInfo.End-methodInfo.Start != methodInfoSize {
+		t.Errorf("expected a method_info of %d bytes, got %d", methodInfoSize, methodInfo.End-methodInfo.Start)
+	}
+	classNode := tree.NewClassNode()
+	reader.Accept(classNode, 0)
+	method := classNode.Methods[0]
+	labelNames := tree.GetLabelNames(method)
+	var insns []string
+	for _, insn := range method.Instructions {
+		insns = append(insns, tree.InsnToString(insn, labelNames))
+	}
+	expected := []string{"L0:", "LINENUMBER 3 L0", "ICONST_0", "ISTORE 1", "L1:", "FRAME APPEND [I] []", "ILOAD 0",
+		"IFLE L2", "IINC 1 1000", "IINC 0 -1", "GOTO L1", "L2:", "FRAME SAME [] []", "LDC 1099511627776L", "POP2",
+		"ILOAD 1", "L3:", "IRETURN", "L4:", "FRAME FULL [I] [java/lang/RuntimeException]", "POP", "ICONST_M1",
+		"IRETURN"}
+	if strings.Join(insns, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected instructions:\n%s", strings.Join(insns, "\n"))
+	}
+	if method.MaxStack != 2 || method.MaxLocals != 2 || len(method.TryCatchBlocks) != 1 ||
+		len(method.LocalVariables) != 1 || len(method.Exceptions) != 1 {
+		t.Errorf("unexpected method %d %d %v %v %v", method.MaxStack, method.MaxLocals, method.TryCatchBlocks,
+			method.LocalVariables, method.Exceptions)
+	}
+}
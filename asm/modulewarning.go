@@ -0,0 +1,110 @@
+package asm
+
+import "github.com/leaklessgfy/asm/asm/opcodes"
+
+// ModuleWarning a problem found by the {@link ClassReader} in a Module attribute, which is not severe enough
+// to prevent the module from being visited. See the Module attribute constraints in the JVMS.
+type ModuleWarning struct {
+	// Module the name of the module.
+	Module string
+	// Directive the directive of the faulty entry ("module", "requires", "exports", "opens" or "provides").
+	Directive string
+	// Name the module, package or service name of the faulty entry.
+	Name string
+	// Message a description of the problem.
+	Message string
+}
+
+func (m ModuleWarning) String() string {
+	return m.Module + ": " + m.Directive + " " + m.Name + ": " + m.Message
+}
+
+const validRequiresFlags = opcodes.ACC_TRANSITIVE | opcodes.ACC_STATIC_PHASE | opcodes.ACC_SYNTHETIC | opcodes.ACC_MANDATED
+
+const validExportsFlags = opcodes.ACC_SYNTHETIC | opcodes.ACC_MANDATED
+
+// moduleChecker collects the entries of a Module attribute to report the constraints it violates.
+type moduleChecker struct {
+	handler      func(ModuleWarning)
+	module       string
+	moduleFlags  int
+	majorVersion int
+	requiresBase bool
+	requires     map[string]bool
+	exports      map[string]bool
+	opens        map[string]bool
+}
+
+func newModuleChecker(handler func(ModuleWarning), module string, moduleFlags, majorVersion int) *moduleChecker {
+	return &moduleChecker{
+		handler:      handler,
+		module:       module,
+		moduleFlags:  moduleFlags,
+		majorVersion: majorVersion,
+		requires:     make(map[string]bool),
+		exports:      make(map[string]bool),
+		opens:        make(map[string]bool),
+	}
+}
+
+func (m *moduleChecker) warn(directive, name, message string) {
+	m.handler(ModuleWarning{Module: m.module, Directive: directive, Name: name, Message: message})
+}
+
+func (m *moduleChecker) checkRequire(module string, access int) {
+	if m.requires[module] {
+		m.warn("requires", module, "duplicate entry")
+	}
+	m.requires[module] = true
+	if (access &^ validRequiresFlags) != 0 {
+		m.warn("requires", module, "invalid flags")
+	}
+	if module == "java.base" {
+		m.requiresBase = true
+		if m.module == "java.base" {
+			m.warn("requires", module, "java.base can't require itself")
+		}
+		// These flags are only forbidden from Java 10 on, see JVMS 4.7.25.
+		if m.majorVersion >= opcodes.V10 && (access&opcodes.ACC_STATIC_PHASE) != 0 {
+			m.warn("requires", module, "java.base can't be required with ACC_STATIC_PHASE")
+		}
+		if m.majorVersion >= opcodes.V10 && (access&opcodes.ACC_TRANSITIVE) != 0 {
+			m.warn("requires", module, "java.base can't be required with ACC_TRANSITIVE")
+		}
+	}
+}
+
+func (m *moduleChecker) checkExport(packaze string, access int) {
+	if m.exports[packaze] {
+		m.warn("exports", packaze, "duplicate entry")
+	}
+	m.exports[packaze] = true
+	if (access &^ validExportsFlags) != 0 {
+		m.warn("exports", packaze, "invalid flags")
+	}
+}
+
+func (m *moduleChecker) checkOpen(packaze string, access int) {
+	if (m.moduleFlags & opcodes.ACC_OPEN) != 0 {
+		m.warn("opens", packaze, "an open module can't have opens entries")
+	}
+	if m.opens[packaze] {
+		m.warn("opens", packaze, "duplicate entry")
+	}
+	m.opens[packaze] = true
+	if (access &^ validExportsFlags) != 0 {
+		m.warn("opens", packaze, "invalid flags")
+	}
+}
+
+func (m *moduleChecker) checkProvide(service string, providers []string) {
+	if len(providers) == 0 {
+		m.warn("provides", service, "no provider")
+	}
+}
+
+func (m *moduleChecker) checkEnd() {
+	if m.module != "java.base" && !m.requiresBase {
+		m.warn("module", m.module, "missing requires java.base")
+	}
+}
@@ -0,0 +1,47 @@
+package asm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// moduleWarnings returns the warnings reported when reading a module-info class of the given version, whose
+// module requires java.base with the given flags and requires the module c twice.
+func moduleWarnings(t *testing.T, version, baseAccess int) []string {
+	classFile := asmtest.NewClassFile(version, opcodes.ACC_MODULE, "module-info", "")
+	moduleWriter := asm.NewModuleWriter(classFile.SymbolTable, "a.b", 0, "")
+	moduleWriter.VisitRequire("java.base", baseAccess, "")
+	moduleWriter.VisitRequire("c", 0, "")
+	moduleWriter.VisitRequire("c", 0, "")
+	moduleWriter.VisitEnd()
+	classFile.AddModule(moduleWriter)
+
+	reader, err := asm.NewClassReader(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	reader.SetModuleWarningHandler(func(warning asm.ModuleWarning) {
+		warnings = append(warnings, warning.String())
+	})
+	reader.Accept(&moduleRecorder{}, 0)
+	return warnings
+}
+
+func TestModuleWarnings(t *testing.T) {
+	flags := opcodes.ACC_STATIC_PHASE | opcodes.ACC_TRANSITIVE
+	// The java.base requires flags are valid before Java 10.
+	if warnings := moduleWarnings(t, opcodes.V9, flags); strings.Join(warnings, "\n") != "a.b: requires c: duplicate entry" {
+		t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+	expected := "a.b: requires java.base: java.base can't be required with ACC_STATIC_PHASE\n" +
+		"a.b: requires java.base: java.base can't be required with ACC_TRANSITIVE\n" +
+		"a.b: requires c: duplicate entry"
+	if warnings := moduleWarnings(t, opcodes.V10, flags); strings.Join(warnings, "\n") != expected {
+		t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+}
@@ -0,0 +1,144 @@
+package asm
+
+// ModuleWriter a {@link ModuleVisitor} that generates the Module, ModulePackages and ModuleMainClass attributes of a
+// module-info class, as defined in the Java Virtual Machine Specification (JVMS), with the constants they refer to
+// added to a {@link SymbolTable}.
+type ModuleWriter struct {
+	symbolTable        *SymbolTable
+	moduleNameIndex    int
+	moduleFlags        int
+	moduleVersionIndex int
+	requiresCount      int
+	requires           *ByteVector
+	exportsCount       int
+	exports            *ByteVector
+	opensCount         int
+	opens              *ByteVector
+	usesCount          int
+	usesIndex          *ByteVector
+	providesCount      int
+	provides           *ByteVector
+	packageCount       int
+	packageIndex       *ByteVector
+	mainClassIndex     int
+}
+
+// NewModuleWriter constructs a new {@link ModuleWriter} for the given module, whose constants are added to the
+// given symbol table. The version may be "".
+func NewModuleWriter(symbolTable *SymbolTable, name string, access int, version string) *ModuleWriter {
+	m := &ModuleWriter{
+		symbolTable:     symbolTable,
+		moduleNameIndex: symbolTable.AddConstantModule(name).index,
+		moduleFlags:     access,
+		requires:        NewByteVector(),
+		exports:         NewByteVector(),
+		opens:           NewByteVector(),
+		usesIndex:       NewByteVector(),
+		provides:        NewByteVector(),
+		packageIndex:    NewByteVector(),
+	}
+	if version != "" {
+		m.moduleVersionIndex = symbolTable.AddConstantUtf8(version)
+	}
+	return m
+}
+
+func (m *ModuleWriter) VisitMainClass(mainClass string) {
+	m.mainClassIndex = m.symbolTable.AddConstantClass(mainClass).index
+}
+
+func (m *ModuleWriter) VisitPackage(packaze string) {
+	m.packageIndex.PutShort(m.symbolTable.AddConstantPackage(packaze).index)
+	m.packageCount++
+}
+
+func (m *ModuleWriter) VisitRequire(module string, access int, version string) {
+	versionIndex := 0
+	if version != "" {
+		versionIndex = m.symbolTable.AddConstantUtf8(version)
+	}
+	m.requires.PutShort(m.symbolTable.AddConstantModule(module).index).PutShort(access).PutShort(versionIndex)
+	m.requiresCount++
+}
+
+func (m *ModuleWriter) VisitExport(packaze string, access int, modules ...string) {
+	m.exports.PutShort(m.symbolTable.AddConstantPackage(packaze).index).PutShort(access).PutShort(len(modules))
+	for _, module := range modules {
+		m.exports.PutShort(m.symbolTable.AddConstantModule(module).index)
+	}
+	m.exportsCount++
+}
+
+func (m *ModuleWriter) VisitOpen(packaze string, access int, modules ...string) {
+	m.opens.PutShort(m.symbolTable.AddConstantPackage(packaze).index).PutShort(access).PutShort(len(modules))
+	for _, module := range modules {
+		m.opens.PutShort(m.symbolTable.AddConstantModule(module).index)
+	}
+	m.opensCount++
+}
+
+func (m *ModuleWriter) VisitUse(service string) {
+	m.usesIndex.PutShort(m.symbolTable.AddConstantClass(service).index)
+	m.usesCount++
+}
+
+func (m *ModuleWriter) VisitProvide(service string, providers ...string) {
+	m.provides.PutShort(m.symbolTable.AddConstantClass(service).index).PutShort(len(providers))
+	for _, provider := range providers {
+		m.provides.PutShort(m.symbolTable.AddConstantClass(provider).index)
+	}
+	m.providesCount++
+}
+
+func (m *ModuleWriter) VisitEnd() {
+}
+
+// GetAttributeCount returns the number of Module, ModulePackages and ModuleMainClass attributes generated by this
+// writer.
+func (m *ModuleWriter) GetAttributeCount() int {
+	count := 1
+	if m.packageCount > 0 {
+		count++
+	}
+	if m.mainClassIndex > 0 {
+		count++
+	}
+	return count
+}
+
+// ComputeAttributesSize returns the size of the Module, ModulePackages and ModuleMainClass attributes generated by
+// this writer, including their 6 bytes headers, and adds their names to the constant pool.
+func (m *ModuleWriter) ComputeAttributesSize() int {
+	m.symbolTable.AddConstantUtf8("Module")
+	// 6 attribute header bytes, 6 bytes for name, flags and version, and 5 * 2 bytes for the counts.
+	size := 22 + m.requires.Size() + m.exports.Size() + m.opens.Size() + m.usesIndex.Size() + m.provides.Size()
+	if m.packageCount > 0 {
+		m.symbolTable.AddConstantUtf8("ModulePackages")
+		size += 8 + m.packageIndex.Size()
+	}
+	if m.mainClassIndex > 0 {
+		m.symbolTable.AddConstantUtf8("ModuleMainClass")
+		size += 8
+	}
+	return size
+}
+
+// PutAttributes puts the Module, ModulePackages and ModuleMainClass attributes generated by this writer into the
+// given vector.
+func (m *ModuleWriter) PutAttributes(output *ByteVector) {
+	moduleAttributeLength := 16 + m.requires.Size() + m.exports.Size() + m.opens.Size() + m.usesIndex.Size() + m.provides.Size()
+	output.PutShort(m.symbolTable.AddConstantUtf8("Module")).PutInt(moduleAttributeLength).
+		PutShort(m.moduleNameIndex).PutShort(m.moduleFlags).PutShort(m.moduleVersionIndex).
+		PutShort(m.requiresCount).PutByteArray(m.requires.data, 0, m.requires.Size()).
+		PutShort(m.exportsCount).PutByteArray(m.exports.data, 0, m.exports.Size()).
+		PutShort(m.opensCount).PutByteArray(m.opens.data, 0, m.opens.Size()).
+		PutShort(m.usesCount).PutByteArray(m.usesIndex.data, 0, m.usesIndex.Size()).
+		PutShort(m.providesCount).PutByteArray(m.provides.data, 0, m.provides.Size())
+	if m.packageCount > 0 {
+		output.PutShort(m.symbolTable.AddConstantUtf8("ModulePackages")).PutInt(2+m.packageIndex.Size()).
+			PutShort(m.packageCount).PutByteArray(m.packageIndex.data, 0, m.packageIndex.Size())
+	}
+	if m.mainClassIndex > 0 {
+		output.PutShort(m.symbolTable.AddConstantUtf8("ModuleMainClass")).PutInt(2).PutShort(m.mainClassIndex)
+	}
+}
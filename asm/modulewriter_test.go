@@ -0,0 +1,90 @@
+package asm_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// moduleRecorder a {@link ClassVisitor} recording the module events of a class.
+type moduleRecorder struct {
+	helper.ClassVisitor
+	events []string
+}
+
+func (m *moduleRecorder) VisitModule(name string, access int, version string) asm.ModuleVisitor {
+	m.events = append(m.events, fmt.Sprint("module ", name, " ", access, " ", version))
+	return m
+}
+
+func (m *moduleRecorder) VisitMainClass(mainClass string) {
+	m.events = append(m.events, "main "+mainClass)
+}
+
+func (m *moduleRecorder) VisitPackage(packaze string) {
+	m.events = append(m.events, "package "+packaze)
+}
+
+func (m *moduleRecorder) VisitRequire(module string, access int, version string) {
+	m.events = append(m.events, fmt.Sprint("requires ", module, " ", access, " ", version))
+}
+
+func (m *moduleRecorder) VisitExport(packaze string, access int, modules ...string) {
+	m.events = append(m.events, fmt.Sprint("exports ", packaze, " ", access, " ", modules))
+}
+
+func (m *moduleRecorder) VisitOpen(packaze string, access int, modules ...string) {
+	m.events = append(m.events, fmt.Sprint("opens ", packaze, " ", access, " ", modules))
+}
+
+func (m *moduleRecorder) VisitUse(service string) {
+	m.events = append(m.events, "uses "+service)
+}
+
+func (m *moduleRecorder) VisitProvide(service string, providers ...string) {
+	m.events = append(m.events, fmt.Sprint("provides ", service, " ", providers))
+}
+
+func (m *moduleRecorder) VisitEnd() {
+}
+
+func TestModuleWriter(t *testing.T) {
+	classFile := asmtest.NewClassFile(opcodes.V9, opcodes.ACC_MODULE, "module-info", "")
+	moduleWriter := asm.NewModuleWriter(classFile.SymbolTable, "a.b", opcodes.ACC_OPEN, "1.0")
+	moduleWriter.VisitMainClass("a/b/Main")
+	moduleWriter.VisitPackage("a/b")
+	moduleWriter.VisitPackage("a/b/spi")
+	moduleWriter.VisitRequire("java.base", opcodes.ACC_MANDATED, "")
+	moduleWriter.VisitRequire("c", opcodes.ACC_TRANSITIVE, "2")
+	moduleWriter.VisitExport("a/b", 0)
+	moduleWriter.VisitExport("a/b/spi", 0, "c", "d")
+	moduleWriter.VisitOpen("a/b/spi", 0, "c")
+	moduleWriter.VisitUse("a/b/spi/Service")
+	moduleWriter.VisitProvide("a/b/spi/Service", "a/b/Impl1", "a/b/Impl2")
+	moduleWriter.VisitEnd()
+	classFile.AddModule(moduleWriter)
+
+	reader, err := asm.NewClassReader(classFile.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := reader.Index().Attributes
+	if attributesSize := moduleWriter.ComputeAttributesSize(); attributes[len(attributes)-1].End-attributes[0].Start != attributesSize {
+		t.Errorf("expected %d bytes of attributes, got %d", attributesSize, attributes[len(attributes)-1].End-attributes[0].Start)
+	}
+	recorder := &moduleRecorder{}
+	reader.Accept(recorder, 0)
+	expected := []string{
+		"module a.b 32 1.0", "main a/b/Main", "package a/b", "package a/b/spi", "requires java.base 32768 ",
+		"requires c 32 2", "exports a/b 0 []", "exports a/b/spi 0 [c d]", "opens a/b/spi 0 [c]",
+		"uses a/b/spi/Service", "provides a/b/spi/Service [a/b/Impl1 a/b/Impl2]",
+	}
+	if strings.Join(recorder.events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected module events:\n%s", strings.Join(recorder.events, "\n"))
+	}
+}
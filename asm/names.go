@@ -0,0 +1,217 @@
+package asm
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// MAX_NAME_LENGTH the maximum length, in bytes of modified UTF-8, of the names and descriptors stored in a
+// CONSTANT_Utf8 constant pool entry.
+const MAX_NAME_LENGTH = 65535
+
+// MAX_ARRAY_DIMENSIONS the maximum number of dimensions of an array type.
+const MAX_ARRAY_DIMENSIONS = 255
+
+func invalidName(kind string, name string, message string) error {
+	return errors.New("Illegal Argument - invalid " + kind + " " + strconv.Quote(name) + ": " + message)
+}
+
+// utf8Length returns the length of the given string encoded in modified UTF-8.
+func utf8Length(s string) int {
+	length := 0
+	for _, r := range s {
+		switch {
+		case r >= 0x01 && r <= 0x7F:
+			length++
+		case r <= 0x7FF:
+			length += 2
+		case r <= 0xFFFF:
+			length += 3
+		default:
+			// A surrogate pair.
+			length += 6
+		}
+	}
+	return length
+}
+
+func checkLength(kind string, name string) error {
+	if name == "" {
+		return invalidName(kind, name, "must not be empty")
+	}
+	if length := utf8Length(name); length > MAX_NAME_LENGTH {
+		return invalidName(kind, name[:32]+"...", strconv.Itoa(length)+" bytes exceed the maximum length of "+strconv.Itoa(MAX_NAME_LENGTH))
+	}
+	return nil
+}
+
+// CheckUnqualifiedName returns an error if the given string is not a valid unqualified name (JVMS 4.2.2), i.e.
+// a valid field name or simple class name.
+func CheckUnqualifiedName(name string) error {
+	if err := checkLength("unqualified name", name); err != nil {
+		return err
+	}
+	if i := strings.IndexAny(name, ".;[/"); i >= 0 {
+		return invalidName("unqualified name", name, "character '"+name[i:i+1]+"' at index "+strconv.Itoa(i)+" is not allowed")
+	}
+	return nil
+}
+
+// CheckMethodName returns an error if the given string is not a valid method name, i.e. "<init>", "<clinit>" or
+// an unqualified name without '<' or '>'.
+func CheckMethodName(name string) error {
+	if name == "<init>" || name == "<clinit>" {
+		return nil
+	}
+	if err := CheckUnqualifiedName(name); err != nil {
+		return err
+	}
+	if i := strings.IndexAny(name, "<>"); i >= 0 {
+		return invalidName("method name", name, "character '"+name[i:i+1]+"' is reserved to <init> and <clinit>")
+	}
+	return nil
+}
+
+// CheckInternalName returns an error if the given string is not a valid internal name (see
+// {@link Type#GetInternalName}), i.e. a class name whose package separators are '/', or an array descriptor.
+func CheckInternalName(name string) error {
+	if err := checkLength("internal name", name); err != nil {
+		return err
+	}
+	if name[0] == '[' {
+		return CheckDescriptor(name)
+	}
+	for i, part := range strings.Split(name, "/") {
+		if part == "" {
+			return invalidName("internal name", name, "empty package or class name at index "+strconv.Itoa(i))
+		}
+		if j := strings.IndexAny(part, ".;["); j >= 0 {
+			return invalidName("internal name", name, "character '"+part[j:j+1]+"' is not allowed")
+		}
+	}
+	return nil
+}
+
+// CheckDescriptor returns an error if the given string is not a valid field descriptor.
+func CheckDescriptor(descriptor string) error {
+	if err := checkLength("descriptor", descriptor); err != nil {
+		return err
+	}
+	end, err := checkFieldType(descriptor, 0)
+	if err != nil {
+		return err
+	}
+	if end != len(descriptor) {
+		return invalidName("descriptor", descriptor, "unexpected characters at index "+strconv.Itoa(end))
+	}
+	return nil
+}
+
+// CheckMethodDescriptor returns an error if the given string is not a valid method descriptor.
+func CheckMethodDescriptor(descriptor string) error {
+	if err := checkLength("method descriptor", descriptor); err != nil {
+		return err
+	}
+	if descriptor[0] != '(' {
+		return invalidName("method descriptor", descriptor, "must start with '('")
+	}
+	offset := 1
+	for offset < len(descriptor) && descriptor[offset] != ')' {
+		var err error
+		if offset, err = checkFieldType(descriptor, offset); err != nil {
+			return err
+		}
+	}
+	if offset >= len(descriptor) {
+		return invalidName("method descriptor", descriptor, "missing ')'")
+	}
+	offset++
+	if offset < len(descriptor) && descriptor[offset] == 'V' {
+		offset++
+	} else {
+		var err error
+		if offset, err = checkFieldType(descriptor, offset); err != nil {
+			return err
+		}
+	}
+	if offset != len(descriptor) {
+		return invalidName("method descriptor", descriptor, "unexpected characters at index "+strconv.Itoa(offset))
+	}
+	return nil
+}
+
+// checkFieldType checks the field type starting at the given offset of the given descriptor, and returns the
+// offset following it.
+func checkFieldType(descriptor string, offset int) (int, error) {
+	dimensions := 0
+	for offset < len(descriptor) && descriptor[offset] == '[' {
+		dimensions++
+		offset++
+	}
+	if dimensions > MAX_ARRAY_DIMENSIONS {
+		return 0, invalidName("descriptor", descriptor, strconv.Itoa(dimensions)+" array dimensions exceed the maximum of "+
+			strconv.Itoa(MAX_ARRAY_DIMENSIONS))
+	}
+	if offset >= len(descriptor) {
+		return 0, invalidName("descriptor", descriptor, "missing type at index "+strconv.Itoa(offset))
+	}
+	switch descriptor[offset] {
+	case 'Z', 'C', 'B', 'S', 'I', 'F', 'J', 'D':
+		return offset + 1, nil
+	case 'L':
+		end := strings.IndexByte(descriptor[offset:], ';')
+		if end < 0 {
+			return 0, invalidName("descriptor", descriptor, "missing ';' after index "+strconv.Itoa(offset))
+		}
+		internalName := descriptor[offset+1 : offset+end]
+		if internalName == "" || internalName[0] == '[' {
+			return 0, invalidName("descriptor", descriptor, "invalid class name at index "+strconv.Itoa(offset+1))
+		}
+		if err := CheckInternalName(internalName); err != nil {
+			return 0, err
+		}
+		return offset + end + 1, nil
+	default:
+		return 0, invalidName("descriptor", descriptor, "invalid type '"+descriptor[offset:offset+1]+"' at index "+strconv.Itoa(offset))
+	}
+}
+
+// CheckModuleName returns an error if the given string is not a valid module name (JVMS 4.2.3), i.e. a name
+// without control characters, in which '\' only escapes '\', ':' and '@'. Package names can be checked with
+// {@link CheckInternalName}.
+func CheckModuleName(name string) error {
+	if err := checkLength("module name", name); err != nil {
+		return err
+	}
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c < 0x20:
+			return invalidName("module name", name, "control character at index "+strconv.Itoa(i))
+		case c == '\\':
+			if i+1 == len(name) || strings.IndexByte("\\:@", name[i+1]) < 0 {
+				return invalidName("module name", name, "'\\' at index "+strconv.Itoa(i)+" must escape '\\', ':' or '@'")
+			}
+			i++
+		case c == ':' || c == '@':
+			return invalidName("module name", name, "character '"+name[i:i+1]+"' must be escaped with '\\'")
+		}
+	}
+	return nil
+}
+
+// CheckDefinableClassName returns an error if a class with the given internal name can't be defined by an
+// application class loader, i.e. if its name is not valid, or if it belongs to the reserved "java" package or to
+// one of its sub packages.
+func CheckDefinableClassName(name string) error {
+	if err := CheckInternalName(name); err != nil {
+		return err
+	}
+	if name[0] == '[' {
+		return invalidName("class name", name, "array classes can't be defined")
+	}
+	if strings.HasPrefix(name, "java/") {
+		return invalidName("class name", name, "the java package is reserved to the boot class loader")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package asm_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+)
+
+func TestNameChecks(t *testing.T) {
+	tests := []struct {
+		check func(string) error
+		name  string
+		ok    bool
+	}{
+		{asm.CheckInternalName, "java/lang/Object", true},
+		{asm.CheckInternalName, "[Ljava/lang/Object;", true},
+		{asm.CheckInternalName, "java.lang.Object", false},
+		{asm.CheckInternalName, "java//Object", false},
+		{asm.CheckMethodName, "<init>", true},
+		{asm.CheckMethodName, "<get>", false},
+		{asm.CheckUnqualifiedName, "a;b", false},
+		{asm.CheckDescriptor, "[[I", true},
+		{asm.CheckDescriptor, "Ljava/lang/String", false},
+		{asm.CheckDescriptor, "V", false},
+		{asm.CheckMethodDescriptor, "(IJ[Ljava/lang/String;)V", true},
+		{asm.CheckMethodDescriptor, "(I)", false},
+		{asm.CheckModuleName, "com.example", true},
+		{asm.CheckModuleName, "a@1", false},
+		{asm.CheckModuleName, "a\\@1", true},
+		{asm.CheckDefinableClassName, "java/lang/Hack", false},
+	}
+	for _, test := range tests {
+		if err := test.check(test.name); (err == nil) != test.ok {
+			t.Errorf("%q: unexpected result %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package opcodes
+
+// NAMES the names of the JVM opcodes, indexed by opcode. The names of the unused opcodes are empty.
+var NAMES = [...]string{
+	NOP:             "NOP",
+	ACONST_NULL:     "ACONST_NULL",
+	ICONST_M1:       "ICONST_M1",
+	ICONST_0:        "ICONST_0",
+	ICONST_1:        "ICONST_1",
+	ICONST_2:        "ICONST_2",
+	ICONST_3:        "ICONST_3",
+	ICONST_4:        "ICONST_4",
+	ICONST_5:        "ICONST_5",
+	LCONST_0:        "LCONST_0",
+	LCONST_1:        "LCONST_1",
+	FCONST_0:        "FCONST_0",
+	FCONST_1:        "FCONST_1",
+	FCONST_2:        "FCONST_2",
+	DCONST_0:        "DCONST_0",
+	DCONST_1:        "DCONST_1",
+	BIPUSH:          "BIPUSH",
+	SIPUSH:          "SIPUSH",
+	LDC:             "LDC",
+	ILOAD:           "ILOAD",
+	LLOAD:           "LLOAD",
+	FLOAD:           "FLOAD",
+	DLOAD:           "DLOAD",
+	ALOAD:           "ALOAD",
+	IALOAD:          "IALOAD",
+	LALOAD:          "LALOAD",
+	FALOAD:          "FALOAD",
+	DALOAD:          "DALOAD",
+	AALOAD:          "AALOAD",
+	BALOAD:          "BALOAD",
+	CALOAD:          "CALOAD",
+	SALOAD:          "SALOAD",
+	ISTORE:          "ISTORE",
+	LSTORE:          "LSTORE",
+	FSTORE:          "FSTORE",
+	DSTORE:          "DSTORE",
+	ASTORE:          "ASTORE",
+	IASTORE:         "IASTORE",
+	LASTORE:         "LASTORE",
+	FASTORE:         "FASTORE",
+	DASTORE:         "DASTORE",
+	AASTORE:         "AASTORE",
+	BASTORE:         "BASTORE",
+	CASTORE:         "CASTORE",
+	SASTORE:         "SASTORE",
+	POP:             "POP",
+	POP2:            "POP2",
+	DUP:             "DUP",
+	DUP_X1:          "DUP_X1",
+	DUP_X2:          "DUP_X2",
+	DUP2:            "DUP2",
+	DUP2_X1:         "DUP2_X1",
+	DUP2_X2:         "DUP2_X2",
+	SWAP:            "SWAP",
+	IADD:            "IADD",
+	LADD:            "LADD",
+	FADD:            "FADD",
+	DADD:            "DADD",
+	ISUB:            "ISUB",
+	LSUB:            "LSUB",
+	FSUB:            "FSUB",
+	DSUB:            "DSUB",
+	IMUL:            "IMUL",
+	LMUL:            "LMUL",
+	FMUL:            "FMUL",
+	DMUL:            "DMUL",
+	IDIV:            "IDIV",
+	LDIV:            "LDIV",
+	FDIV:            "FDIV",
+	DDIV:            "DDIV",
+	IREM:            "IREM",
+	LREM:            "LREM",
+	FREM:            "FREM",
+	DREM:            "DREM",
+	INEG:            "INEG",
+	LNEG:            "LNEG",
+	FNEG:            "FNEG",
+	DNEG:            "DNEG",
+	ISHL:            "ISHL",
+	LSHL:            "LSHL",
+	ISHR:            "ISHR",
+	LSHR:            "LSHR",
+	IUSHR:           "IUSHR",
+	LUSHR:           "LUSHR",
+	IAND:            "IAND",
+	LAND:            "LAND",
+	IOR:             "IOR",
+	LOR:             "LOR",
+	IXOR:            "IXOR",
+	LXOR:            "LXOR",
+	IINC:            "IINC",
+	I2L:             "I2L",
+	I2F:             "I2F",
+	I2D:             "I2D",
+	L2I:             "L2I",
+	L2F:             "L2F",
+	L2D:             "L2D",
+	F2I:             "F2I",
+	F2L:             "F2L",
+	F2D:             "F2D",
+	D2I:             "D2I",
+	D2L:             "D2L",
+	D2F:             "D2F",
+	I2B:             "I2B",
+	I2C:             "I2C",
+	I2S:             "I2S",
+	LCMP:            "LCMP",
+	FCMPL:           "FCMPL",
+	FCMPG:           "FCMPG",
+	DCMPL:           "DCMPL",
+	DCMPG:           "DCMPG",
+	IFEQ:            "IFEQ",
+	IFNE:            "IFNE",
+	IFLT:            "IFLT",
+	IFGE:            "IFGE",
+	IFGT:            "IFGT",
+	IFLE:            "IFLE",
+	IF_ICMPEQ:       "IF_ICMPEQ",
+	IF_ICMPNE:       "IF_ICMPNE",
+	IF_ICMPLT:       "IF_ICMPLT",
+	IF_ICMPGE:       "IF_ICMPGE",
+	IF_ICMPGT:       "IF_ICMPGT",
+	IF_ICMPLE:       "IF_ICMPLE",
+	IF_ACMPEQ:       "IF_ACMPEQ",
+	IF_ACMPNE:       "IF_ACMPNE",
+	GOTO:            "GOTO",
+	JSR:             "JSR",
+	RET:             "RET",
+	TABLESWITCH:     "TABLESWITCH",
+	LOOKUPSWITCH:    "LOOKUPSWITCH",
+	IRETURN:         "IRETURN",
+	LRETURN:         "LRETURN",
+	FRETURN:         "FRETURN",
+	DRETURN:         "DRETURN",
+	ARETURN:         "ARETURN",
+	RETURN:          "RETURN",
+	GETSTATIC:       "GETSTATIC",
+	PUTSTATIC:       "PUTSTATIC",
+	GETFIELD:        "GETFIELD",
+	PUTFIELD:        "PUTFIELD",
+	INVOKEVIRTUAL:   "INVOKEVIRTUAL",
+	INVOKESPECIAL:   "INVOKESPECIAL",
+	INVOKESTATIC:    "INVOKESTATIC",
+	INVOKEINTERFACE: "INVOKEINTERFACE",
+	INVOKEDYNAMIC:   "INVOKEDYNAMIC",
+	NEW:             "NEW",
+	NEWARRAY:        "NEWARRAY",
+	ANEWARRAY:       "ANEWARRAY",
+	ARRAYLENGTH:     "ARRAYLENGTH",
+	ATHROW:          "ATHROW",
+	CHECKCAST:       "CHECKCAST",
+	INSTANCEOF:      "INSTANCEOF",
+	MONITORENTER:    "MONITORENTER",
+	MONITOREXIT:     "MONITOREXIT",
+	MULTIANEWARRAY:  "MULTIANEWARRAY",
+	IFNULL:          "IFNULL",
+	IFNONNULL:       "IFNONNULL",
+}
@@ -20,6 +20,13 @@ const (
 	V1_8 = 0<<16 | 52
 	V9   = 0<<16 | 53
 	V10  = 0<<16 | 54
+	V11  = 0<<16 | 55
+	V12  = 0<<16 | 56
+	V13  = 0<<16 | 57
+	V14  = 0<<16 | 58
+	V15  = 0<<16 | 59
+	V16  = 0<<16 | 60
+	V17  = 0<<16 | 61
 
 	ACC_PUBLIC       = 0x0001  // class, field, method
 	ACC_PRIVATE      = 0x0002  // class, field, method
@@ -0,0 +1,56 @@
+package raw
+
+import (
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm/constants"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// InstructionSize returns the size in bytes of the instruction at the given offset of the given code array (the
+// content of the code field of a Code attribute, whose first instruction is at offset 0). The ASM specific
+// instructions are supported.
+func InstructionSize(code []byte, offset int) (int, error) {
+	opcode, err := ReadU1(code, offset)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case opcode == opcodes.TABLESWITCH:
+		// Skips the opcode and the padding, then the default, low and high fields, and the jump offsets.
+		start := offset + 4 - offset&3
+		low, err := ReadS4(code, start+4)
+		if err != nil {
+			return 0, err
+		}
+		high, err := ReadS4(code, start+8)
+		return start - offset + 12 + 4*(int(high)-int(low)+1), err
+	case opcode == opcodes.LOOKUPSWITCH:
+		start := offset + 4 - offset&3
+		pairs, err := ReadS4(code, start+4)
+		return start - offset + 8 + 8*int(pairs), err
+	case opcode == constants.WIDE:
+		if widenedOpcode, err := ReadU1(code, offset+1); err != nil || widenedOpcode != opcodes.IINC {
+			return 4, err
+		}
+		return 6, nil
+	case opcode == opcodes.BIPUSH || opcode == opcodes.LDC || opcode == opcodes.NEWARRAY || opcode == opcodes.RET,
+		opcode >= opcodes.ILOAD && opcode <= opcodes.ALOAD, opcode >= opcodes.ISTORE && opcode <= opcodes.ASTORE:
+		return 2, nil
+	case opcode == opcodes.SIPUSH || opcode == constants.LDC_W || opcode == constants.LDC2_W || opcode == opcodes.IINC,
+		opcode >= opcodes.IFEQ && opcode <= opcodes.JSR, opcode >= opcodes.GETSTATIC && opcode <= opcodes.INVOKESTATIC,
+		opcode == opcodes.NEW || opcode == opcodes.ANEWARRAY || opcode == opcodes.CHECKCAST,
+		opcode == opcodes.INSTANCEOF || opcode == opcodes.IFNULL || opcode == opcodes.IFNONNULL,
+		opcode >= constants.ASM_IFEQ && opcode <= constants.ASM_IFNONNULL:
+		return 3, nil
+	case opcode == opcodes.MULTIANEWARRAY:
+		return 4, nil
+	case opcode == opcodes.INVOKEINTERFACE || opcode == opcodes.INVOKEDYNAMIC || opcode == constants.GOTO_W,
+		opcode == constants.JSR_W || opcode == constants.ASM_GOTO_W:
+		return 5, nil
+	case opcode > constants.ASM_GOTO_W:
+		return 0, NewParseError(ErrUnknownOpcode, offset, "unknown opcode "+strconv.Itoa(opcode)+" at offset "+
+			strconv.Itoa(offset))
+	}
+	return 1, nil
+}
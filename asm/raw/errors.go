@@ -0,0 +1,54 @@
+package raw
+
+import "errors"
+
+// The kinds of the errors returned when parsing a class file. The returned errors are {@link ParseError}s
+// wrapping one of these errors, so that the callers can test their kind with errors.Is, and get their offset
+// with errors.As, instead of matching their messages.
+var (
+	// ErrUnsupportedVersion the class file version is not supported.
+	ErrUnsupportedVersion = errors.New("unsupported class file version")
+	// ErrMalformedConstantPool the constant pool contains an unknown tag, or an entry is referenced with an
+	// invalid index or with an unexpected tag.
+	ErrMalformedConstantPool = errors.New("malformed constant pool")
+	// ErrTruncated the class file ends before the structure being read.
+	ErrTruncated = errors.New("truncated class file")
+	// ErrUnknownOpcode the code of a method contains an unknown opcode.
+	ErrUnknownOpcode = errors.New("unknown opcode")
+	// ErrInvalidMagic the bytes do not start with the 0xCAFEBABE magic number of the class files.
+	ErrInvalidMagic = errors.New("invalid magic number")
+	// ErrMalformedStackMap a stack map frame contains an unknown frame type or verification type tag.
+	ErrMalformedStackMap = errors.New("malformed stack map frame")
+	// ErrMalformedAttribute the content of an attribute does not match its attribute_length.
+	ErrMalformedAttribute = errors.New("malformed attribute")
+	// ErrTrailingBytes the class file has unexpected bytes after its last attribute.
+	ErrTrailingBytes = errors.New("trailing bytes after the class")
+)
+
+// ParseError an error in a class file, at a given offset. Its message has the "Illegal Argument - " prefix of
+// the other errors of this library.
+type ParseError struct {
+	// Kind {@link ErrUnsupportedVersion}, {@link ErrMalformedConstantPool}, {@link ErrTruncated}, {@link
+	// ErrUnknownOpcode}, {@link ErrInvalidMagic}, {@link ErrMalformedStackMap}, {@link ErrMalformedAttribute}
+	// or {@link ErrTrailingBytes}.
+	Kind error
+	// Offset the offset in the class file of the structure which could not be parsed, or -1 if it is unknown
+	// (e.g. for an invalid constant pool index).
+	Offset int
+	// Message the description of the error, with its offset.
+	Message string
+}
+
+// NewParseError constructs a new {@link ParseError}.
+func NewParseError(kind error, offset int, message string) *ParseError {
+	return &ParseError{Kind: kind, Offset: offset, Message: message}
+}
+
+func (p *ParseError) Error() string {
+	return "Illegal Argument - " + p.Message
+}
+
+// Unwrap returns the kind of the error, so that errors.Is(err, ErrTruncated) works.
+func (p *ParseError) Unwrap() error {
+	return p.Kind
+}
@@ -0,0 +1,196 @@
+// Package raw provides the low level primitives used by the {@link ClassReader} to decode a class file: big
+// endian integers, modified UTF-8 strings and the constant pool layout. Unlike the reader, which assumes a well
+// formed class, these primitives check their offsets and return an error instead of panicking, so that they can
+// be used on arbitrary bytes by tools working below the visitor API (hex annotators, patchers, fuzzers...).
+package raw
+
+import (
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// checkRange returns an error if the length bytes starting at the given offset are not in b.
+func checkRange(b []byte, offset int, length int) error {
+	if offset < 0 || length < 0 || offset > len(b)-length {
+		return NewParseError(ErrTruncated, offset, strconv.Itoa(length)+" bytes at offset "+strconv.Itoa(offset)+
+			" exceed the "+strconv.Itoa(len(b))+" bytes of the class")
+	}
+	return nil
+}
+
+// ReadU1 reads an unsigned byte value in b.
+func ReadU1(b []byte, offset int) (int, error) {
+	if err := checkRange(b, offset, 1); err != nil {
+		return 0, err
+	}
+	return int(b[offset]), nil
+}
+
+// ReadU2 reads an unsigned big endian short value in b.
+func ReadU2(b []byte, offset int) (int, error) {
+	if err := checkRange(b, offset, 2); err != nil {
+		return 0, err
+	}
+	return int(b[offset])<<8 | int(b[offset+1]), nil
+}
+
+// ReadS2 reads a signed big endian short value in b.
+func ReadS2(b []byte, offset int) (int16, error) {
+	value, err := ReadU2(b, offset)
+	return int16(value), err
+}
+
+// ReadU4 reads an unsigned big endian int value in b.
+func ReadU4(b []byte, offset int) (uint32, error) {
+	if err := checkRange(b, offset, 4); err != nil {
+		return 0, err
+	}
+	return uint32(b[offset])<<24 | uint32(b[offset+1])<<16 | uint32(b[offset+2])<<8 | uint32(b[offset+3]), nil
+}
+
+// ReadS4 reads a signed big endian int value in b.
+func ReadS4(b []byte, offset int) (int32, error) {
+	value, err := ReadU4(b, offset)
+	return int32(value), err
+}
+
+// ReadUTF8 reads a CONSTANT_Utf8 content in b, i.e. an unsigned short length followed by this number of bytes of
+// modified UTF-8, and returns the decoded string.
+func ReadUTF8(b []byte, offset int) (string, error) {
+	length, err := ReadU2(b, offset)
+	if err != nil {
+		return "", err
+	}
+	if err := checkRange(b, offset+2, length); err != nil {
+		return "", err
+	}
+	utf := b[offset+2 : offset+2+length]
+	for i := 0; i < len(utf); {
+		switch {
+		case utf[i]&0x80 == 0:
+			i++
+		case utf[i]&0xE0 == 0xC0:
+			i += 2
+		default:
+			i += 3
+		}
+		if i > len(utf) {
+			return "", NewParseError(ErrTruncated, offset, "truncated modified UTF-8 string at offset "+strconv.Itoa(offset))
+		}
+	}
+	return DecodeUTF8(utf, make([]rune, length)), nil
+}
+
+// DecodeUTF8 decodes the given modified UTF-8 bytes, using the given buffer (which must be at least as long as
+// utf). Unlike {@link ReadUTF8}, the bytes are not checked: a truncated multi byte sequence panics.
+func DecodeUTF8(utf []byte, charBuffer []rune) string {
+	currentOffset := 0
+	strLength := 0
+	for currentOffset < len(utf) {
+		currentByte := utf[currentOffset]
+		currentOffset++
+		if (currentByte & 0x80) == 0 {
+			charBuffer[strLength] = rune(currentByte & 0x7F)
+		} else if (currentByte & 0xE0) == 0xC0 {
+			charBuffer[strLength] = rune(currentByte&0x1F)<<6 + rune(utf[currentOffset]&0x3F)
+			currentOffset++
+		} else {
+			charBuffer[strLength] = rune(currentByte&0xF)<<12 + rune(utf[currentOffset]&0x3F)<<6 +
+				rune(utf[currentOffset+1]&0x3F)
+			currentOffset += 2
+		}
+		strLength++
+	}
+	return string(charBuffer[0:strLength])
+}
+
+// ConstantPool the layout of the constant pool of a class.
+type ConstantPool struct {
+	// Offsets the offset in the class of each constant pool entry, plus one (i.e. the offset of the content of
+	// the entry, after its tag), or 0 for the index 0 and for the unused index following a long or double entry.
+	Offsets []int
+	// MaxStringLength a conservative estimate of the maximum length of the strings of the constant pool.
+	MaxStringLength int
+	// Header the offset of the access_flags field of the class, just after the constant pool.
+	Header int
+}
+
+// ReadConstantPool reads the layout of the constant pool of the class starting at the given offset in b.
+func ReadConstantPool(b []byte, offset int) (*ConstantPool, error) {
+	constantPoolCount, err := ReadU2(b, offset+8)
+	if err != nil {
+		return nil, err
+	}
+	constantPool := &ConstantPool{Offsets: make([]int, constantPoolCount)}
+	currentCpInfoOffset := offset + 10
+	for i := 1; i < constantPoolCount; i++ {
+		cpInfoSize, slots, err := EntrySize(b, currentCpInfoOffset)
+		if err != nil {
+			return nil, err
+		}
+		constantPool.Offsets[i] = currentCpInfoOffset + 1
+		i += slots - 1
+		if b[currentCpInfoOffset] == symbol.CONSTANT_UTF8_TAG && cpInfoSize > constantPool.MaxStringLength {
+			constantPool.MaxStringLength = cpInfoSize
+		}
+		currentCpInfoOffset += cpInfoSize
+	}
+	if err := checkRange(b, offset, currentCpInfoOffset-offset); err != nil {
+		return nil, err
+	}
+	constantPool.Header = currentCpInfoOffset
+	return constantPool, nil
+}
+
+// EntrySize returns the size in bytes of the constant pool entry whose tag is at the given offset in b, and the
+// number of constant pool indexes it uses (2 for the Long and Double entries, 1 otherwise). The content of the
+// entry is not checked, except the length of the CONSTANT_Utf8 entries.
+func EntrySize(b []byte, offset int) (int, int, error) {
+	if offset < 0 || offset >= len(b) {
+		return 0, 0, checkRange(b, offset, 1)
+	}
+	tag := int(b[offset])
+	switch tag {
+	case symbol.CONSTANT_FIELDREF_TAG, symbol.CONSTANT_METHODREF_TAG, symbol.CONSTANT_INTERFACE_METHODREF_TAG,
+		symbol.CONSTANT_INTEGER_TAG, symbol.CONSTANT_FLOAT_TAG, symbol.CONSTANT_NAME_AND_TYPE_TAG,
+		symbol.CONSTANT_INVOKE_DYNAMIC_TAG:
+		return 5, 1, nil
+	case symbol.CONSTANT_LONG_TAG, symbol.CONSTANT_DOUBLE_TAG:
+		return 9, 2, nil
+	case symbol.CONSTANT_UTF8_TAG:
+		if offset+3 > len(b) {
+			return 0, 0, checkRange(b, offset+1, 2)
+		}
+		return 3 + (int(b[offset+1])<<8 | int(b[offset+2])), 1, nil
+	case symbol.CONSTANT_METHOD_HANDLE_TAG:
+		return 4, 1, nil
+	case symbol.CONSTANT_CLASS_TAG, symbol.CONSTANT_STRING_TAG, symbol.CONSTANT_METHOD_TYPE_TAG,
+		symbol.CONSTANT_PACKAGE_TAG, symbol.CONSTANT_MODULE_TAG:
+		return 3, 1, nil
+	}
+	return 0, 0, NewParseError(ErrMalformedConstantPool, offset, "unknown constant pool tag "+strconv.Itoa(tag)+
+		" at offset "+strconv.Itoa(offset))
+}
+
+// GetTag returns the tag of the given constant pool entry (see the CONSTANT_*_TAG constants of {@link symbol}).
+func (c *ConstantPool) GetTag(b []byte, constantPoolEntryIndex int) (int, error) {
+	if constantPoolEntryIndex <= 0 || constantPoolEntryIndex >= len(c.Offsets) || c.Offsets[constantPoolEntryIndex] == 0 {
+		return 0, NewParseError(ErrMalformedConstantPool, -1, "invalid constant pool index "+
+			strconv.Itoa(constantPoolEntryIndex))
+	}
+	return ReadU1(b, c.Offsets[constantPoolEntryIndex]-1)
+}
+
+// GetUTF8 returns the value of the given CONSTANT_Utf8 constant pool entry.
+func (c *ConstantPool) GetUTF8(b []byte, constantPoolEntryIndex int) (string, error) {
+	tag, err := c.GetTag(b, constantPoolEntryIndex)
+	if err != nil {
+		return "", err
+	}
+	if tag != symbol.CONSTANT_UTF8_TAG {
+		return "", NewParseError(ErrMalformedConstantPool, c.Offsets[constantPoolEntryIndex]-1, "constant pool entry "+
+			strconv.Itoa(constantPoolEntryIndex)+" is not a CONSTANT_Utf8")
+	}
+	return ReadUTF8(b, c.Offsets[constantPoolEntryIndex])
+}
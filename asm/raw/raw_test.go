@@ -0,0 +1,65 @@
+package raw
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/frame"
+)
+
+func TestReadUTF8(t *testing.T) {
+	// "aé€" in modified UTF-8: 1, 2 and 3 bytes sequences.
+	b := []byte{0, 6, 'a', 0xC3, 0xA9, 0xE2, 0x82, 0xAC}
+	if s, err := ReadUTF8(b, 0); err != nil || s != "aé€" {
+		t.Errorf("ReadUTF8 = %q, %v", s, err)
+	}
+	if _, err := ReadUTF8(b[:7], 0); !errors.Is(err, ErrTruncated) {
+		t.Error("expected an error for a string exceeding the class")
+	}
+	if _, err := ReadUTF8([]byte{0, 2, 'a', 0xE2}, 0); err == nil {
+		t.Error("expected an error for a truncated sequence")
+	}
+}
+
+func TestReadConstantPool(t *testing.T) {
+	b := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52, 0, 4,
+		1, 0, 1, 'A',
+		5, 0, 0, 0, 0, 0, 0, 0, 1,
+		0, 0x21}
+	constantPool, err := ReadConstantPool(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if constantPool.Header != 23 || constantPool.MaxStringLength != 4 || constantPool.Offsets[2] != 15 {
+		t.Errorf("unexpected constant pool %+v", constantPool)
+	}
+	if s, err := constantPool.GetUTF8(b, 1); err != nil || s != "A" {
+		t.Errorf("GetUTF8 = %q, %v", s, err)
+	}
+	if _, err := constantPool.GetTag(b, 3); !errors.Is(err, ErrMalformedConstantPool) {
+		t.Error("expected an error for the index following a long")
+	}
+	var parseError *ParseError
+	if _, err := ReadConstantPool(b[:20], 0); !errors.As(err, &parseError) || parseError.Kind != ErrTruncated {
+		t.Errorf("expected a truncation error for a truncated constant pool, got %v", err)
+	}
+}
+
+func TestDecodeVerificationType(t *testing.T) {
+	b := []byte{frame.ITEM_LONG, frame.ITEM_OBJECT, 0, 5, frame.ITEM_UNINITIALIZED, 1, 2, 42, frame.ITEM_OBJECT, 0}
+	expected := []VerificationType{{Tag: frame.ITEM_LONG}, {Tag: frame.ITEM_OBJECT, ClassIndex: 5}, {Tag: frame.ITEM_UNINITIALIZED, Offset: 258}}
+	offset := 0
+	for _, verificationType := range expected {
+		actual, nextOffset, err := DecodeVerificationType(b, offset)
+		if err != nil || actual != verificationType {
+			t.Fatalf("at offset %d: expected %v, got %v %v", offset, verificationType, actual, err)
+		}
+		offset = nextOffset
+	}
+	if _, _, err := DecodeVerificationType(b, offset); !errors.Is(err, ErrMalformedStackMap) {
+		t.Errorf("expected a malformed stack map error, got %v", err)
+	}
+	if _, _, err := DecodeVerificationType(b, offset+1); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected a truncated error, got %v", err)
+	}
+}
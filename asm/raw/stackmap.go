@@ -0,0 +1,39 @@
+package raw
+
+import (
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm/frame"
+)
+
+// VerificationType a verification_type_info structure of a StackMapTable (or StackMap) attribute.
+type VerificationType struct {
+	// Tag the {@link frame.ITEM_TOP} to {@link frame.ITEM_UNINITIALIZED} tag of the verification type.
+	Tag int
+	// ClassIndex the constant pool index of the CONSTANT_Class_info of a {@link frame.ITEM_OBJECT}, or 0.
+	ClassIndex int
+	// Offset the bytecode offset of the NEW instruction of a {@link frame.ITEM_UNINITIALIZED}, or 0.
+	Offset int
+}
+
+// DecodeVerificationType reads a verification_type_info structure in b, and returns it with the offset of the
+// structure following it.
+func DecodeVerificationType(b []byte, offset int) (VerificationType, int, error) {
+	tag, err := ReadU1(b, offset)
+	if err != nil {
+		return VerificationType{}, offset, err
+	}
+	switch tag {
+	case frame.ITEM_TOP, frame.ITEM_INTEGER, frame.ITEM_FLOAT, frame.ITEM_DOUBLE, frame.ITEM_LONG, frame.ITEM_NULL,
+		frame.ITEM_UNINITIALIZED_THIS:
+		return VerificationType{Tag: tag}, offset + 1, nil
+	case frame.ITEM_OBJECT:
+		classIndex, err := ReadU2(b, offset+1)
+		return VerificationType{Tag: tag, ClassIndex: classIndex}, offset + 3, err
+	case frame.ITEM_UNINITIALIZED:
+		newOffset, err := ReadU2(b, offset+1)
+		return VerificationType{Tag: tag, Offset: newOffset}, offset + 3, err
+	}
+	return VerificationType{}, offset, NewParseError(ErrMalformedStackMap, offset, "unknown verification type tag "+
+		strconv.Itoa(tag)+" at offset "+strconv.Itoa(offset))
+}
@@ -0,0 +1,262 @@
+package asm
+
+import (
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// ClassReferenceMapper the functions used by {@link RemapClassReferences} to remap the class names referenced by
+// a class file. They must return their argument unchanged if it does not reference a remapped class.
+type ClassReferenceMapper struct {
+	// MapType maps an internal name, or an array type descriptor.
+	MapType func(internalName string) string
+	// MapDescriptor maps a field or method descriptor.
+	MapDescriptor func(descriptor string) string
+	// MapSignature maps a class or method signature, or a field or local variable type signature if typeSignature
+	// is true.
+	MapSignature func(signature string, typeSignature bool) string
+	// MapInnerClassName maps the simple name of the inner class with the given internal name, declared in an
+	// InnerClasses attribute.
+	MapInnerClassName func(name, outerName, innerName string) string
+}
+
+// RemapClassReferences returns a copy of the given class file in which the class names are remapped with the
+// given mapper: the CONSTANT_Class entries (and thus this_class, super_class, the interfaces, the owners of the
+// field and method references and the inner class records), the descriptors of the CONSTANT_NameAndType and
+// CONSTANT_MethodType entries, the descriptors and Signature attributes of the class and of its members, the
+// local variable tables, the simple names of the inner classes, and the annotations and type annotations. The
+// string constants are not remapped. The existing constant pool entries are kept, and the remapped names are
+// appended to the constant pool: the references are redirected to them, without changing the layout of the
+// rest of the class.
+func RemapClassReferences(classBytes []byte, mapper ClassReferenceMapper) ([]byte, error) {
+	reader, err := NewClassReader(classBytes)
+	if err != nil {
+		return nil, err
+	}
+	className := reader.GetClassName()
+	symbolTable := NewSymbolTableFromClassReader(reader)
+	symbolTable.SetMajorVersionAndClassName(reader.readUnsignedShort(6), className)
+	patcher := &referencePatcher{
+		reader:      reader,
+		mapper:      mapper,
+		symbolTable: symbolTable,
+		charBuffer:  make([]rune, reader.maxStringLength),
+		patches:     make(map[int]int),
+	}
+	for i := 1; i < len(reader.cpInfoOffsets); i++ {
+		cpInfoOffset := reader.cpInfoOffsets[i]
+		switch reader.GetItemTag(i) {
+		case symbol.CONSTANT_CLASS_TAG:
+			patcher.patch(cpInfoOffset, mapper.MapType)
+		case symbol.CONSTANT_NAME_AND_TYPE_TAG:
+			patcher.patch(cpInfoOffset+2, mapper.MapDescriptor)
+		case symbol.CONSTANT_METHOD_TYPE_TAG:
+			patcher.patch(cpInfoOffset, mapper.MapDescriptor)
+		}
+	}
+	index := reader.Index()
+	for i, members := range [][]MemberIndex{index.Fields, index.Methods} {
+		for _, member := range members {
+			patcher.patch(member.Start+4, mapper.MapDescriptor)
+			patcher.patchAttributes(member.Attributes, i == 0)
+		}
+	}
+	patcher.patchAttributes(index.Attributes, false)
+	if err := symbolTable.GetError(); err != nil {
+		return nil, err
+	}
+	if symbolTable.GetConstantPoolCount() > MAX_CONSTANT_POOL_ENTRIES {
+		return nil, &LimitExceededError{
+			Kind:      ErrClassTooLarge,
+			ClassName: className,
+			Value:     symbolTable.GetConstantPoolCount(),
+			Limit:     MAX_CONSTANT_POOL_ENTRIES,
+		}
+	}
+
+	// The existing constant pool entries keep their offsets, and the rest of the class is shifted by the size of
+	// the new entries.
+	output := NewByteVectorWithCapacity(len(classBytes) + symbolTable.GetConstantPoolLength() - reader.header + 10)
+	output.PutByteArray(classBytes, 0, 8)
+	symbolTable.PutConstantPool(output)
+	shift := output.Size() - reader.header
+	output.PutByteArray(classBytes, reader.header, len(classBytes)-reader.header)
+	result := output.Bytes()
+	for offset, constantPoolEntryIndex := range patches(patcher.patches, reader.header, shift) {
+		result[offset] = byte(constantPoolEntryIndex >> 8)
+		result[offset+1] = byte(constantPoolEntryIndex)
+	}
+	return result, nil
+}
+
+// patches returns the given patches, with the offsets following the constant pool shifted by the given value.
+func patches(patches map[int]int, header, shift int) map[int]int {
+	shifted := make(map[int]int, len(patches))
+	for offset, constantPoolEntryIndex := range patches {
+		if offset >= header {
+			offset += shift
+		}
+		shifted[offset] = constantPoolEntryIndex
+	}
+	return shifted
+}
+
+// referencePatcher computes the patches of the CONSTANT_Utf8 references of a class, for
+// {@link RemapClassReferences}.
+type referencePatcher struct {
+	reader      *ClassReader
+	mapper      ClassReferenceMapper
+	symbolTable *SymbolTable
+	charBuffer  []rune
+	// patches the new constant pool index to store at each offset of the class.
+	patches map[int]int
+}
+
+// patch redirects the CONSTANT_Utf8 reference stored at the given offset to the remapped value of this
+// reference, if it is remapped.
+func (r *referencePatcher) patch(offset int, mapValue func(string) string) {
+	value := r.reader.readUTF8(offset, r.charBuffer)
+	if remapped := mapValue(value); remapped != value {
+		r.patches[offset] = r.symbolTable.AddConstantUtf8(remapped)
+	}
+}
+
+// patchSignature redirects the signature reference stored at the given offset to the remapped signature.
+func (r *referencePatcher) patchSignature(offset int, typeSignature bool) {
+	r.patch(offset, func(signature string) string { return r.mapper.MapSignature(signature, typeSignature) })
+}
+
+// patchAttributes patches the given attributes of the class, of a field (if field is true) or of a method.
+func (r *referencePatcher) patchAttributes(attributes []AttributeRange, field bool) {
+	for _, attribute := range attributes {
+		offset := attribute.Start + 6
+		switch attribute.Name {
+		case "Signature":
+			r.patchSignature(offset, field)
+		case "InnerClasses":
+			// Each inner class record has an inner_class_info_index, an outer_class_info_index, an
+			// inner_name_index and an inner_class_access_flags field.
+			for i := r.reader.readUnsignedShort(offset); i > 0; i-- {
+				offset += 8
+				recordOffset := offset - 6
+				if r.reader.readUnsignedShort(recordOffset+4) == 0 {
+					continue
+				}
+				name := r.reader.readClass(recordOffset, r.charBuffer)
+				outerName := ""
+				if r.reader.readUnsignedShort(recordOffset+2) != 0 {
+					outerName = r.reader.readClass(recordOffset+2, r.charBuffer)
+				}
+				r.patch(recordOffset+4, func(innerName string) string {
+					if r.mapper.MapType(name) == name {
+						return innerName
+					}
+					return r.mapper.MapInnerClassName(name, outerName, innerName)
+				})
+			}
+		case "Code":
+			r.patchCode(offset)
+		case "RuntimeVisibleAnnotations", "RuntimeInvisibleAnnotations":
+			r.patchAnnotations(offset)
+		case "RuntimeVisibleParameterAnnotations", "RuntimeInvisibleParameterAnnotations":
+			offset++
+			for i := int(r.reader.readByte(offset - 1)); i > 0; i-- {
+				offset = r.patchAnnotations(offset)
+			}
+		case "RuntimeVisibleTypeAnnotations", "RuntimeInvisibleTypeAnnotations":
+			r.patchTypeAnnotations(offset)
+		case "AnnotationDefault":
+			r.patchElementValue(offset)
+		}
+	}
+}
+
+// patchCode patches the local variable tables and the type annotations of the Code attribute whose content
+// starts at the given offset.
+func (r *referencePatcher) patchCode(offset int) {
+	offset += 8 + r.reader.readInt(offset+4)
+	offset += 2 + r.reader.readUnsignedShort(offset)*8
+	attributes, _ := r.reader.indexAttributes(offset, r.charBuffer)
+	for _, attribute := range attributes {
+		offset := attribute.Start + 6
+		switch attribute.Name {
+		case "LocalVariableTable", "LocalVariableTypeTable":
+			// Each entry has a start_pc, a length, a name_index, a descriptor_index (or signature_index) and an
+			// index field.
+			for i := r.reader.readUnsignedShort(offset); i > 0; i-- {
+				offset += 10
+				if attribute.Name == "LocalVariableTable" {
+					r.patch(offset-2, r.mapper.MapDescriptor)
+				} else {
+					r.patchSignature(offset-2, true)
+				}
+			}
+		case "RuntimeVisibleTypeAnnotations", "RuntimeInvisibleTypeAnnotations":
+			r.patchTypeAnnotations(offset)
+		}
+	}
+}
+
+// patchAnnotations patches the annotations whose num_annotations field starts at the given offset, and returns
+// the offset following them.
+func (r *referencePatcher) patchAnnotations(offset int) int {
+	currentOffset := offset + 2
+	for i := r.reader.readUnsignedShort(offset); i > 0; i-- {
+		currentOffset = r.patchAnnotation(currentOffset)
+	}
+	return currentOffset
+}
+
+// patchTypeAnnotations patches the type annotations whose num_annotations field starts at the given offset.
+func (r *referencePatcher) patchTypeAnnotations(offset int) {
+	currentOffset := offset + 2
+	for i := r.reader.readUnsignedShort(offset); i > 0; i-- {
+		// Skips the target_info and the type_path.
+		switch targetType := int(r.reader.readByte(currentOffset)); {
+		case targetType == 0x40 || targetType == 0x41:
+			currentOffset += 3 + r.reader.readUnsignedShort(currentOffset+1)*6
+		case targetType == 0x13 || targetType == 0x14 || targetType == 0x15:
+			currentOffset++
+		case targetType == 0x00 || targetType == 0x01 || targetType == 0x16:
+			currentOffset += 2
+		case targetType >= 0x47 && targetType <= 0x4B:
+			currentOffset += 4
+		default:
+			currentOffset += 3
+		}
+		currentOffset += 1 + int(r.reader.readByte(currentOffset))*2
+		currentOffset = r.patchAnnotation(currentOffset)
+	}
+}
+
+// patchAnnotation patches the annotation starting at the given offset, and returns the offset following it.
+func (r *referencePatcher) patchAnnotation(offset int) int {
+	r.patch(offset, r.mapper.MapDescriptor)
+	currentOffset := offset + 4
+	for i := r.reader.readUnsignedShort(offset + 2); i > 0; i-- {
+		currentOffset = r.patchElementValue(currentOffset + 2)
+	}
+	return currentOffset
+}
+
+// patchElementValue patches the element_value starting at the given offset, and returns the offset following
+// it.
+func (r *referencePatcher) patchElementValue(offset int) int {
+	switch r.reader.readByte(offset) {
+	case 'e':
+		r.patch(offset+1, r.mapper.MapDescriptor)
+		return offset + 5
+	case 'c':
+		r.patch(offset+1, r.mapper.MapDescriptor)
+		return offset + 3
+	case '@':
+		return r.patchAnnotation(offset + 1)
+	case '[':
+		currentOffset := offset + 3
+		for i := r.reader.readUnsignedShort(offset + 1); i > 0; i-- {
+			currentOffset = r.patchElementValue(currentOffset)
+		}
+		return currentOffset
+	default:
+		return offset + 3
+	}
+}
@@ -0,0 +1,36 @@
+package signature_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/signature"
+)
+
+func TestSignatureReaderAndWriter(t *testing.T) {
+	signatures := []string{
+		"<T:Ljava/lang/Object;>Ljava/util/AbstractList<TT;>;Ljava/util/List<TT;>;",
+		"<K::Ljava/lang/Comparable<-TK;>;V:Ljava/lang/Object;>Ljava/lang/Object;",
+		"<E:Ljava/lang/Exception;>(I[[TE;Ljava/util/Map<+TE;*>.Entry<Ljava/lang/String;[I>;)TE;^TE;^Ljava/io/IOException;",
+		"()V",
+	}
+	for _, value := range signatures {
+		writer := signature.NewSignatureWriter()
+		signature.NewSignatureReader(value).Accept(writer)
+		if writer.String() != value {
+			t.Errorf("expected %s, got %s", value, writer.String())
+		}
+	}
+
+	writer := signature.NewSignatureWriter()
+	signature.NewSignatureReader("Ljava/util/Map<TK;[TV;>;").AcceptType(writer)
+	if writer.String() != "Ljava/util/Map<TK;[TV;>;" {
+		t.Errorf("unexpected type signature %s", writer.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a malformed signature")
+		}
+	}()
+	signature.NewSignatureReader("Ljava/util/List<TT;").AcceptType(signature.NewSignatureWriter())
+}
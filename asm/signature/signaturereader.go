@@ -0,0 +1,163 @@
+package signature
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SignatureReader a parser for generic signatures, which makes a {@link SignatureVisitor} visit them. The
+// accept methods panic with an "Illegal Argument" error if the signature is malformed.
+type SignatureReader struct {
+	signatureValue string
+}
+
+// NewSignatureReader constructs a new {@link SignatureReader} for the given class, method or type signature.
+func NewSignatureReader(signature string) *SignatureReader {
+	return &SignatureReader{signatureValue: signature}
+}
+
+// Accept makes the given visitor visit the signature of this reader, which must be a class or method signature
+// (see {@link AcceptType} for the field and local variable type signatures).
+func (s *SignatureReader) Accept(signatureVisitor SignatureVisitor) {
+	signature := s.signatureValue
+	length := len(signature)
+	var offset int
+	if charAt(signature, 0) == '<' {
+		// Parse the formal type parameters.
+		offset = 2
+		for {
+			classBoundStartOffset := indexOf(signature, ':', offset)
+			signatureVisitor.VisitFormalTypeParameter(signature[offset-1 : classBoundStartOffset])
+			offset = classBoundStartOffset + 1
+			if currentChar := charAt(signature, offset); currentChar == 'L' || currentChar == '[' || currentChar == 'T' {
+				offset = parseType(signature, offset, signatureVisitor.VisitClassBound())
+			}
+			currentChar := charAt(signature, offset)
+			offset++
+			for currentChar == ':' {
+				offset = parseType(signature, offset, signatureVisitor.VisitInterfaceBound())
+				currentChar = charAt(signature, offset)
+				offset++
+			}
+			if currentChar == '>' {
+				break
+			}
+		}
+	}
+
+	if charAt(signature, offset) == '(' {
+		offset++
+		for charAt(signature, offset) != ')' {
+			offset = parseType(signature, offset, signatureVisitor.VisitParameterType())
+		}
+		offset = parseType(signature, offset+1, signatureVisitor.VisitReturnType())
+		for offset < length {
+			if charAt(signature, offset) != '^' {
+				panic(invalidSignature(signature, offset))
+			}
+			offset = parseType(signature, offset+1, signatureVisitor.VisitExceptionType())
+		}
+	} else {
+		offset = parseType(signature, offset, signatureVisitor.VisitSuperclass())
+		for offset < length {
+			offset = parseType(signature, offset, signatureVisitor.VisitInterface())
+		}
+	}
+}
+
+// AcceptType makes the given visitor visit the signature of this reader, which must be a field or local variable
+// type signature.
+func (s *SignatureReader) AcceptType(signatureVisitor SignatureVisitor) {
+	if offset := parseType(s.signatureValue, 0, signatureVisitor); offset != len(s.signatureValue) {
+		panic(invalidSignature(s.signatureValue, offset))
+	}
+}
+
+// parseType parses the type signature starting at the given offset, makes the given visitor visit it, and
+// returns the offset following it.
+func parseType(signature string, startOffset int, signatureVisitor SignatureVisitor) int {
+	offset := startOffset
+	currentChar := charAt(signature, offset)
+	offset++
+	switch currentChar {
+	case 'Z', 'C', 'B', 'S', 'I', 'F', 'J', 'D', 'V':
+		signatureVisitor.VisitBaseType(rune(currentChar))
+		return offset
+	case '[':
+		return parseType(signature, offset, signatureVisitor.VisitArrayType())
+	case 'T':
+		endOffset := indexOf(signature, ';', offset)
+		signatureVisitor.VisitTypeVariable(signature[offset:endOffset])
+		return endOffset + 1
+	case 'L':
+		// The offset of the class or inner class name.
+		start := offset
+		// Whether the current class or inner class name has already been visited (before its type arguments).
+		visited := false
+		inner := false
+		for {
+			currentChar = charAt(signature, offset)
+			offset++
+			switch currentChar {
+			case '.', ';':
+				if !visited {
+					visitClassType(signature[start:offset-1], inner, signatureVisitor)
+				}
+				if currentChar == ';' {
+					signatureVisitor.VisitEnd()
+					return offset
+				}
+				start = offset
+				visited = false
+				inner = true
+			case '<':
+				visitClassType(signature[start:offset-1], inner, signatureVisitor)
+				visited = true
+				for currentChar = charAt(signature, offset); currentChar != '>'; currentChar = charAt(signature, offset) {
+					switch currentChar {
+					case '*':
+						offset++
+						signatureVisitor.VisitTypeArgument()
+					case EXTENDS, SUPER:
+						offset = parseType(signature, offset+1, signatureVisitor.VisitTypeArgumentB(rune(currentChar)))
+					default:
+						offset = parseType(signature, offset, signatureVisitor.VisitTypeArgumentB(INSTANCEOF))
+					}
+				}
+			}
+		}
+	}
+	panic(invalidSignature(signature, startOffset))
+}
+
+// visitClassType makes the given visitor visit the given class or inner class name.
+func visitClassType(name string, inner bool, signatureVisitor SignatureVisitor) {
+	if inner {
+		signatureVisitor.VisitInnerClassType(name)
+	} else {
+		signatureVisitor.VisitClassType(name)
+	}
+}
+
+// charAt returns the character of the signature at the given offset, and panics if it is out of bounds.
+func charAt(signature string, offset int) byte {
+	if offset >= len(signature) {
+		panic(invalidSignature(signature, offset))
+	}
+	return signature[offset]
+}
+
+// indexOf returns the offset of the first given character of the signature from the given offset, and panics if
+// there is none.
+func indexOf(signature string, c byte, offset int) int {
+	index := strings.IndexByte(signature[offset:], c)
+	if index < 0 {
+		panic(invalidSignature(signature, offset))
+	}
+	return offset + index
+}
+
+func invalidSignature(signature string, offset int) error {
+	return errors.New("Illegal Argument - invalid signature " + signature + " at offset " + strconv.Itoa(offset))
+}
@@ -0,0 +1,61 @@
+// Package signature provides the visitor API for the generic signatures of the classes, fields and methods
+// (JVMS 4.7.9.1), returned as raw strings by {@link ClassVisitor#Visit}, {@link ClassVisitor#VisitField} and
+// {@link ClassVisitor#VisitMethod}: a {@link SignatureReader} decomposes a signature into visit events, and a
+// {@link SignatureWriter} rebuilds a signature from these events.
+package signature
+
+// Wildcard characters to be used with {@link SignatureVisitor#VisitTypeArgumentB}.
+const (
+	EXTENDS    = '+'
+	SUPER      = '-'
+	INSTANCEOF = '='
+)
+
+// SignatureVisitor a visitor to visit a generic signature. The methods of this interface must be called in one
+// of the following orders (the ones defined in the Java Virtual Machine Specification):
+//   - ClassSignature = ( <tt>VisitFormalTypeParameter</tt> <tt>VisitClassBound</tt>?
+//     <tt>VisitInterfaceBound</tt>* )* ( <tt>VisitSuperclass</tt> <tt>VisitInterface</tt>* )
+//   - MethodSignature = ( <tt>VisitFormalTypeParameter</tt> <tt>VisitClassBound</tt>?
+//     <tt>VisitInterfaceBound</tt>* )* ( <tt>VisitParameterType</tt>* <tt>VisitReturnType</tt>
+//     <tt>VisitExceptionType</tt>* )
+//   - TypeSignature = <tt>VisitBaseType</tt> | <tt>VisitTypeVariable</tt> | <tt>VisitArrayType</tt> | (
+//     <tt>VisitClassType</tt> <tt>VisitTypeArgument</tt>* ( <tt>VisitInnerClassType</tt>
+//     <tt>VisitTypeArgument</tt>* )* <tt>VisitEnd</tt> ) )
+type SignatureVisitor interface {
+	// VisitFormalTypeParameter visits a formal type parameter.
+	VisitFormalTypeParameter(name string)
+	// VisitClassBound visits the class bound of the last visited formal type parameter.
+	VisitClassBound() SignatureVisitor
+	// VisitInterfaceBound visits an interface bound of the last visited formal type parameter.
+	VisitInterfaceBound() SignatureVisitor
+	// VisitSuperclass visits the type of the super class.
+	VisitSuperclass() SignatureVisitor
+	// VisitInterface visits the type of an interface implemented by the class.
+	VisitInterface() SignatureVisitor
+	// VisitParameterType visits the type of a method parameter.
+	VisitParameterType() SignatureVisitor
+	// VisitReturnType visits the return type of the method.
+	VisitReturnType() SignatureVisitor
+	// VisitExceptionType visits the type of a method exception.
+	VisitExceptionType() SignatureVisitor
+	// VisitBaseType visits a signature corresponding to a primitive type or void, given by its descriptor
+	// character.
+	VisitBaseType(descriptor rune)
+	// VisitTypeVariable visits a signature corresponding to a type variable.
+	VisitTypeVariable(name string)
+	// VisitArrayType visits a signature corresponding to an array type, and returns the visitor of its element
+	// type.
+	VisitArrayType() SignatureVisitor
+	// VisitClassType starts the visit of a signature corresponding to a class or interface type, given by its
+	// internal name.
+	VisitClassType(name string)
+	// VisitInnerClassType visits an inner class, given by its simple name.
+	VisitInnerClassType(name string)
+	// VisitTypeArgument visits an unbounded type argument of the last visited class or inner class type.
+	VisitTypeArgument()
+	// VisitTypeArgumentB visits a type argument of the last visited class or inner class type, with the
+	// {@link EXTENDS}, {@link SUPER} or {@link INSTANCEOF} wildcard.
+	VisitTypeArgumentB(wildcard rune) SignatureVisitor
+	// VisitEnd ends the visit of a signature corresponding to a class or interface type.
+	VisitEnd()
+}
@@ -0,0 +1,145 @@
+package signature
+
+import "strings"
+
+// SignatureWriter a {@link SignatureVisitor} that generates the generic signature it visits.
+type SignatureWriter struct {
+	stringBuilder *strings.Builder
+	// hasFormals whether the '<' of the formal type parameters has been written, but not the '>'.
+	hasFormals bool
+	// hasParameters whether the '(' of the method parameters has been written.
+	hasParameters bool
+	// argumentStack a stack of bits, one per visited class or inner class type being visited: the bit is set if
+	// the '<' of its type arguments has been written. The top of the stack is the least significant bit.
+	argumentStack int
+}
+
+// NewSignatureWriter constructs a new {@link SignatureWriter}.
+func NewSignatureWriter() *SignatureWriter {
+	return &SignatureWriter{stringBuilder: &strings.Builder{}}
+}
+
+func (s *SignatureWriter) VisitFormalTypeParameter(name string) {
+	if !s.hasFormals {
+		s.hasFormals = true
+		s.stringBuilder.WriteByte('<')
+	}
+	s.stringBuilder.WriteString(name)
+	s.stringBuilder.WriteByte(':')
+}
+
+func (s *SignatureWriter) VisitClassBound() SignatureVisitor {
+	return s
+}
+
+func (s *SignatureWriter) VisitInterfaceBound() SignatureVisitor {
+	s.stringBuilder.WriteByte(':')
+	return s
+}
+
+func (s *SignatureWriter) VisitSuperclass() SignatureVisitor {
+	s.endFormals()
+	return s
+}
+
+func (s *SignatureWriter) VisitInterface() SignatureVisitor {
+	return s
+}
+
+func (s *SignatureWriter) VisitParameterType() SignatureVisitor {
+	s.endFormals()
+	if !s.hasParameters {
+		s.hasParameters = true
+		s.stringBuilder.WriteByte('(')
+	}
+	return s
+}
+
+func (s *SignatureWriter) VisitReturnType() SignatureVisitor {
+	s.endFormals()
+	if !s.hasParameters {
+		s.stringBuilder.WriteByte('(')
+	}
+	s.stringBuilder.WriteByte(')')
+	return s
+}
+
+func (s *SignatureWriter) VisitExceptionType() SignatureVisitor {
+	s.stringBuilder.WriteByte('^')
+	return s
+}
+
+func (s *SignatureWriter) VisitBaseType(descriptor rune) {
+	s.stringBuilder.WriteRune(descriptor)
+}
+
+func (s *SignatureWriter) VisitTypeVariable(name string) {
+	s.stringBuilder.WriteByte('T')
+	s.stringBuilder.WriteString(name)
+	s.stringBuilder.WriteByte(';')
+}
+
+func (s *SignatureWriter) VisitArrayType() SignatureVisitor {
+	s.stringBuilder.WriteByte('[')
+	return s
+}
+
+func (s *SignatureWriter) VisitClassType(name string) {
+	s.stringBuilder.WriteByte('L')
+	s.stringBuilder.WriteString(name)
+	s.argumentStack *= 2
+}
+
+func (s *SignatureWriter) VisitInnerClassType(name string) {
+	s.endArguments()
+	s.stringBuilder.WriteByte('.')
+	s.stringBuilder.WriteString(name)
+	s.argumentStack *= 2
+}
+
+func (s *SignatureWriter) VisitTypeArgument() {
+	s.startArguments()
+	s.stringBuilder.WriteByte('*')
+}
+
+func (s *SignatureWriter) VisitTypeArgumentB(wildcard rune) SignatureVisitor {
+	s.startArguments()
+	if wildcard != INSTANCEOF {
+		s.stringBuilder.WriteRune(wildcard)
+	}
+	return s
+}
+
+func (s *SignatureWriter) VisitEnd() {
+	s.endArguments()
+	s.stringBuilder.WriteByte(';')
+}
+
+// String returns the signature that was built by this signature writer.
+func (s *SignatureWriter) String() string {
+	return s.stringBuilder.String()
+}
+
+// endFormals ends the formal type parameters section of the signature.
+func (s *SignatureWriter) endFormals() {
+	if s.hasFormals {
+		s.hasFormals = false
+		s.stringBuilder.WriteByte('>')
+	}
+}
+
+// startArguments writes the '<' of the type arguments of the current class or inner class type, if needed.
+func (s *SignatureWriter) startArguments() {
+	if s.argumentStack&1 == 0 {
+		s.argumentStack |= 1
+		s.stringBuilder.WriteByte('<')
+	}
+}
+
+// endArguments ends the type arguments of the current class or inner class type.
+func (s *SignatureWriter) endArguments() {
+	if s.argumentStack&1 == 1 {
+		s.stringBuilder.WriteByte('>')
+	}
+	s.argumentStack /= 2
+}
@@ -0,0 +1,275 @@
+package smap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SMAP the source map of a class, as stored in its SourceDebugExtension attribute (see JSR-45). It maps the
+// lines of the generated source file (e.g. a java file generated from a JSP, or an inlined Kotlin function)
+// to the lines of one or more input source files, for each stratum.
+type SMAP struct {
+	// OutputFileName the name of the generated source file.
+	OutputFileName string
+	// DefaultStratum the name of the stratum used by default by debuggers.
+	DefaultStratum string
+	// Strata the strata of this source map, in their declaration order.
+	Strata []*Stratum
+}
+
+// Stratum the line mapping from the generated source file to the files of a source language.
+type Stratum struct {
+	Name  string
+	Files []FileInfo
+	Lines []LineInfo
+	// Vendor the raw lines of the vendor sections of this stratum, without their "*V" header.
+	Vendor []string
+}
+
+// FileInfo an input source file of a {@link Stratum}. Path is empty if absent.
+type FileInfo struct {
+	ID   int
+	Name string
+	Path string
+}
+
+// LineInfo maps RepeatCount input lines starting at InputStartLine in the file FileID to output lines starting
+// at OutputStartLine. Each input line is mapped to OutputLineIncrement output lines.
+type LineInfo struct {
+	InputStartLine      int
+	FileID              int
+	RepeatCount         int
+	OutputStartLine     int
+	OutputLineIncrement int
+}
+
+// NewSMAP constructs a new, empty {@link SMAP}.
+func NewSMAP(outputFileName, defaultStratum string) *SMAP {
+	return &SMAP{
+		OutputFileName: outputFileName,
+		DefaultStratum: defaultStratum,
+	}
+}
+
+// AddStratum adds a new, empty stratum to this source map and returns it.
+func (s *SMAP) AddStratum(name string) *Stratum {
+	stratum := &Stratum{Name: name}
+	s.Strata = append(s.Strata, stratum)
+	return stratum
+}
+
+// GetStratum returns the stratum with the given name, or nil.
+func (s *SMAP) GetStratum(name string) *Stratum {
+	for _, stratum := range s.Strata {
+		if stratum.Name == name {
+			return stratum
+		}
+	}
+	return nil
+}
+
+// AddFile adds an input source file to this stratum and returns its id.
+func (s *Stratum) AddFile(name, path string) int {
+	id := 1
+	for _, file := range s.Files {
+		if file.ID >= id {
+			id = file.ID + 1
+		}
+	}
+	s.Files = append(s.Files, FileInfo{ID: id, Name: name, Path: path})
+	return id
+}
+
+// AddLine maps the given input line range to the output lines starting at outputStartLine, one output line per
+// input line.
+func (s *Stratum) AddLine(fileID, inputStartLine, repeatCount, outputStartLine int) {
+	s.Lines = append(s.Lines, LineInfo{inputStartLine, fileID, repeatCount, outputStartLine, 1})
+}
+
+// GetFile returns the input source file with the given id, or nil.
+func (s *Stratum) GetFile(id int) *FileInfo {
+	for i := range s.Files {
+		if s.Files[i].ID == id {
+			return &s.Files[i]
+		}
+	}
+	return nil
+}
+
+// MapOutputLine returns the input source file and line corresponding to the given output line, or nil and 0 if
+// it is not mapped by this stratum.
+func (s *Stratum) MapOutputLine(outputLine int) (*FileInfo, int) {
+	for _, line := range s.Lines {
+		increment := line.OutputLineIncrement
+		if increment == 0 {
+			// Only the first output line is mapped.
+			if outputLine == line.OutputStartLine {
+				return s.GetFile(line.FileID), line.InputStartLine + line.RepeatCount - 1
+			}
+			continue
+		}
+		delta := outputLine - line.OutputStartLine
+		if delta >= 0 && delta < line.RepeatCount*increment {
+			return s.GetFile(line.FileID), line.InputStartLine + delta/increment
+		}
+	}
+	return nil, 0
+}
+
+// Parse parses the given SourceDebugExtension content. Embedded source maps (*O and *C sections) are not
+// supported. Unknown sections are ignored, as required by JSR-45.
+func Parse(debug string) (*SMAP, error) {
+	lines := strings.Split(strings.Replace(debug, "\r\n", "\n", -1), "\n")
+	if len(lines) < 3 || lines[0] != "SMAP" {
+		return nil, errors.New("Illegal Argument - SMAP header expected")
+	}
+	smap := NewSMAP(lines[1], lines[2])
+	var stratum *Stratum
+	section := ""
+	fileID := 0
+	ended := false
+	for i := 3; i < len(lines) && !ended; i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "*") {
+			section = line
+			if len(line) > 2 {
+				section = line[:2]
+			}
+			switch section {
+			case "*S":
+				stratum = smap.AddStratum(strings.TrimSpace(line[2:]))
+				fileID = 0
+			case "*E":
+				ended = true
+			case "*O", "*C":
+				return nil, errors.New("Illegal Argument - embedded SMAP are not supported")
+			case "*F", "*L", "*V":
+				if stratum == nil {
+					return nil, errors.New("Illegal Argument - " + section + " section outside of a stratum at line " + strconv.Itoa(i+1))
+				}
+			}
+			continue
+		}
+		switch section {
+		case "*F":
+			file := FileInfo{}
+			entry := line
+			withPath := strings.HasPrefix(entry, "+ ")
+			if withPath {
+				entry = entry[2:]
+			}
+			index := strings.IndexByte(entry, ' ')
+			if index == -1 {
+				return nil, errors.New("Illegal Argument - invalid file info at line " + strconv.Itoa(i+1))
+			}
+			id, err := strconv.Atoi(entry[:index])
+			if err != nil {
+				return nil, errors.New("Illegal Argument - invalid file id at line " + strconv.Itoa(i+1))
+			}
+			file.ID = id
+			file.Name = entry[index+1:]
+			if withPath {
+				i++
+				if i == len(lines) {
+					return nil, errors.New("Illegal Argument - missing file path")
+				}
+				file.Path = lines[i]
+			}
+			stratum.Files = append(stratum.Files, file)
+		case "*L":
+			if line == "" {
+				continue
+			}
+			lineInfo, err := parseLineInfo(line, fileID)
+			if err != nil {
+				return nil, errors.New("Illegal Argument - invalid line info at line " + strconv.Itoa(i+1))
+			}
+			fileID = lineInfo.FileID
+			stratum.Lines = append(stratum.Lines, lineInfo)
+		case "*V":
+			stratum.Vendor = append(stratum.Vendor, line)
+		}
+	}
+	if !ended {
+		return nil, errors.New("Illegal Argument - *E expected")
+	}
+	return smap, nil
+}
+
+// parseLineInfo parses "InputStartLine[#LineFileID][,RepeatCount]:OutputStartLine[,OutputLineIncrement]". The
+// file id defaults to the one of the previous line info.
+func parseLineInfo(line string, fileID int) (LineInfo, error) {
+	lineInfo := LineInfo{FileID: fileID, RepeatCount: 1, OutputLineIncrement: 1}
+	index := strings.IndexByte(line, ':')
+	if index == -1 {
+		return lineInfo, errors.New("Illegal Argument - ':' expected")
+	}
+	input, output := line[:index], line[index+1:]
+	var err error
+	if index = strings.IndexByte(input, ','); index != -1 {
+		if lineInfo.RepeatCount, err = strconv.Atoi(input[index+1:]); err != nil {
+			return lineInfo, err
+		}
+		input = input[:index]
+	}
+	if index = strings.IndexByte(input, '#'); index != -1 {
+		if lineInfo.FileID, err = strconv.Atoi(input[index+1:]); err != nil {
+			return lineInfo, err
+		}
+		input = input[:index]
+	}
+	if lineInfo.InputStartLine, err = strconv.Atoi(input); err != nil {
+		return lineInfo, err
+	}
+	if index = strings.IndexByte(output, ','); index != -1 {
+		if lineInfo.OutputLineIncrement, err = strconv.Atoi(output[index+1:]); err != nil {
+			return lineInfo, err
+		}
+		output = output[:index]
+	}
+	lineInfo.OutputStartLine, err = strconv.Atoi(output)
+	return lineInfo, err
+}
+
+// String returns the SourceDebugExtension content corresponding to this source map, to be passed to
+// {@link ClassVisitor#VisitSource}.
+func (s *SMAP) String() string {
+	var builder strings.Builder
+	builder.WriteString("SMAP\n" + s.OutputFileName + "\n" + s.DefaultStratum + "\n")
+	for _, stratum := range s.Strata {
+		builder.WriteString("*S " + stratum.Name + "\n*F\n")
+		for _, file := range stratum.Files {
+			if file.Path != "" {
+				builder.WriteString("+ " + strconv.Itoa(file.ID) + " " + file.Name + "\n" + file.Path + "\n")
+			} else {
+				builder.WriteString(strconv.Itoa(file.ID) + " " + file.Name + "\n")
+			}
+		}
+		builder.WriteString("*L\n")
+		fileID := 0
+		for _, line := range stratum.Lines {
+			builder.WriteString(strconv.Itoa(line.InputStartLine))
+			if line.FileID != fileID {
+				builder.WriteString("#" + strconv.Itoa(line.FileID))
+				fileID = line.FileID
+			}
+			if line.RepeatCount != 1 {
+				builder.WriteString("," + strconv.Itoa(line.RepeatCount))
+			}
+			builder.WriteString(":" + strconv.Itoa(line.OutputStartLine))
+			if line.OutputLineIncrement != 1 {
+				builder.WriteString("," + strconv.Itoa(line.OutputLineIncrement))
+			}
+			builder.WriteString("\n")
+		}
+		if len(stratum.Vendor) > 0 {
+			builder.WriteString("*V\n")
+			for _, vendor := range stratum.Vendor {
+				builder.WriteString(vendor + "\n")
+			}
+		}
+	}
+	builder.WriteString("*E\n")
+	return builder.String()
+}
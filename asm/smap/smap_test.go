@@ -0,0 +1,51 @@
+package smap_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/smap"
+)
+
+const kotlinSMAP = `SMAP
+Main.kt
+Kotlin
+*S Kotlin
+*F
++ 1 Main.kt
+MainKt
++ 2 Util.kt
+UtilKt
+*L
+1#1,10:1
+5#2,3:11
+*E
+`
+
+func TestParse(t *testing.T) {
+	parsed, err := smap.Parse(kotlinSMAP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stratum := parsed.GetStratum("Kotlin")
+	if stratum == nil || len(stratum.Files) != 2 || len(stratum.Lines) != 2 {
+		t.Fatalf("unexpected stratum %+v", stratum)
+	}
+	file, line := stratum.MapOutputLine(12)
+	if file == nil || file.Name != "Util.kt" || file.Path != "UtilKt" || line != 6 {
+		t.Errorf("MapOutputLine(12) = %v, %d", file, line)
+	}
+	if file, _ := stratum.MapOutputLine(14); file != nil {
+		t.Errorf("MapOutputLine(14) = %v, expected nil", file)
+	}
+	if parsed.String() != kotlinSMAP {
+		t.Errorf("String() = %q, expected %q", parsed.String(), kotlinSMAP)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, invalid := range []string{"", "SMAP\nA.java\nJava\n*S Java\n*L\n1:x\n*E\n", "SMAP\nA.java\nJava\n*S Java\n"} {
+		if _, err := smap.Parse(invalid); err == nil {
+			t.Errorf("Parse(%q) should fail", invalid)
+		}
+	}
+}
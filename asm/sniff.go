@@ -0,0 +1,90 @@
+package asm
+
+import (
+	"strconv"
+
+	"github.com/leaklessgfy/asm/asm/raw"
+	"github.com/leaklessgfy/asm/asm/symbol"
+)
+
+// SniffClassName returns the internal name of the given class and its version (minor << 16 | major, as given to
+// {@link ClassVisitor#Visit}), without constructing a {@link ClassReader}. Only the constant pool entries needed
+// to resolve this_class are read, and nothing is allocated but the returned name, which makes it suitable to
+// index the classes of large artifact repositories. The returned errors are {@link ParseError}s, and the bytes
+// must start with the class file magic number.
+func SniffClassName(classFile []byte) (string, int, error) {
+	magic, err := raw.ReadU4(classFile, 0)
+	if err != nil {
+		return "", 0, err
+	}
+	if magic != 0xCAFEBABE {
+		return "", 0, raw.NewParseError(raw.ErrInvalidMagic, 0, "invalid magic number 0x"+strconv.FormatUint(uint64(magic), 16))
+	}
+	version, err := raw.ReadU4(classFile, 4)
+	if err != nil {
+		return "", 0, err
+	}
+	constantPoolCount, err := raw.ReadU2(classFile, 8)
+	if err != nil {
+		return "", 0, err
+	}
+	header, err := sniffConstantPoolEntry(classFile, constantPoolCount, constantPoolCount)
+	if err != nil {
+		return "", 0, err
+	}
+	thisClass, err := raw.ReadU2(classFile, header+2)
+	if err != nil {
+		return "", 0, err
+	}
+	classOffset, err := sniffConstantPoolEntry(classFile, constantPoolCount, thisClass)
+	if err != nil {
+		return "", 0, err
+	}
+	if classFile[classOffset] != symbol.CONSTANT_CLASS_TAG {
+		return "", 0, raw.NewParseError(raw.ErrMalformedConstantPool, header+2, "invalid this_class constant pool index")
+	}
+	nameIndex, err := raw.ReadU2(classFile, classOffset+1)
+	if err != nil {
+		return "", 0, err
+	}
+	nameOffset, err := sniffConstantPoolEntry(classFile, constantPoolCount, nameIndex)
+	if err != nil {
+		return "", 0, err
+	}
+	if classFile[nameOffset] != symbol.CONSTANT_UTF8_TAG {
+		return "", 0, raw.NewParseError(raw.ErrMalformedConstantPool, classOffset+1, "constant pool entry "+
+			strconv.Itoa(nameIndex)+" is not a CONSTANT_Utf8")
+	}
+	name, err := raw.ReadUTF8(classFile, nameOffset+1)
+	return name, int(version), err
+}
+
+// sniffConstantPoolEntry returns the offset of the tag of the given constant pool entry, or the offset following
+// the constant pool if the index is the constant pool count. The entries before it are skipped without being
+// decoded.
+func sniffConstantPoolEntry(classFile []byte, constantPoolCount, constantPoolEntryIndex int) (int, error) {
+	if constantPoolEntryIndex <= 0 || constantPoolEntryIndex > constantPoolCount {
+		return 0, raw.NewParseError(raw.ErrMalformedConstantPool, -1, "invalid constant pool index "+
+			strconv.Itoa(constantPoolEntryIndex))
+	}
+	currentCpInfoOffset := 10
+	for i := 1; i < constantPoolEntryIndex; {
+		cpInfoSize, slots, err := raw.EntrySize(classFile, currentCpInfoOffset)
+		if err != nil {
+			return 0, err
+		}
+		currentCpInfoOffset += cpInfoSize
+		i += slots
+		if i > constantPoolEntryIndex {
+			// The index is the unusable one following a Long or Double entry.
+			return 0, raw.NewParseError(raw.ErrMalformedConstantPool, -1, "invalid constant pool index "+
+				strconv.Itoa(constantPoolEntryIndex))
+		}
+	}
+	if constantPoolEntryIndex < constantPoolCount {
+		if _, err := raw.ReadU1(classFile, currentCpInfoOffset); err != nil {
+			return 0, err
+		}
+	}
+	return currentCpInfoOffset, nil
+}
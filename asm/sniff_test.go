@@ -0,0 +1,65 @@
+package asm_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+)
+
+// sniffedClass returns a class file named "com/example/A", whose constant pool contains its name, as the first
+// entries (like javac does), followed by the given number of other entries (alternately strings and longs).
+func sniffedClass(entries int) []byte {
+	pool := append([]byte{7, 0, 2, 1, 0, 13}, "com/example/A"...)
+	count := 3
+	for i := 0; i < entries; i++ {
+		if i%2 == 0 {
+			s := "entry" + strconv.Itoa(i)
+			pool = append(append(pool, 1, 0, byte(len(s))), s...)
+			count++
+		} else {
+			pool = append(pool, 5, 0, 0, 0, 0, 0, 0, 0, byte(i))
+			count += 2
+		}
+	}
+	classFile := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 3, 0, 52, byte(count >> 8), byte(count)}
+	classFile = append(classFile, pool...)
+	return append(classFile, 0, 0x21, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+func TestSniffClassName(t *testing.T) {
+	name, version, err := asm.SniffClassName(sniffedClass(100))
+	if err != nil || name != "com/example/A" || version != 3<<16|52 {
+		t.Errorf("unexpected sniffed class %q %d %v", name, version, err)
+	}
+	if _, _, err := asm.SniffClassName([]byte("PK\x03\x04 not a class")); !errors.Is(err, asm.ErrInvalidMagic) {
+		t.Errorf("expected an invalid magic error, got %v", err)
+	}
+	classFile := sniffedClass(10)
+	if _, _, err := asm.SniffClassName(classFile[:len(classFile)-20]); !errors.Is(err, asm.ErrTruncated) {
+		t.Errorf("expected a truncated class error, got %v", err)
+	}
+}
+
+func BenchmarkSniffClassName(b *testing.B) {
+	classFile := sniffedClass(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := asm.SniffClassName(classFile); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewClassReaderClassName(b *testing.B) {
+	classFile := sniffedClass(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		reader, err := asm.NewClassReader(classFile)
+		if err != nil {
+			b.Fatal(err)
+		}
+		reader.GetClassName()
+	}
+}
@@ -0,0 +1,47 @@
+package asm
+
+// Variants of the stack map frames of a method (see {@link ClassReader#GetStackMapVariants}).
+const (
+	// NO_STACK_MAP the method has no stack map frames attribute.
+	NO_STACK_MAP = iota
+	// STACK_MAP_TABLE the method has a StackMapTable attribute (Java 6 and later), with compressed frames.
+	STACK_MAP_TABLE
+	// STACK_MAP the method has a legacy StackMap attribute, with uncompressed frames at absolute offsets, as
+	// produced by the CLDC preverifier for J2ME (and some old Android tool chains). The reader visits these
+	// frames as expanded {@link opcodes.F_NEW} frames.
+	STACK_MAP
+)
+
+// GetStackMapVariants returns the variant of the stack map frames of each method with code of the class, keyed
+// by method name and descriptor: {@link NO_STACK_MAP}, {@link STACK_MAP_TABLE} or {@link STACK_MAP}. Methods
+// with both attributes are reported as {@link STACK_MAP_TABLE}, the attribute used by the reader in this case.
+func (c *ClassReader) GetStackMapVariants() map[string]int {
+	variants := make(map[string]int)
+	charBuffer := make([]rune, c.maxStringLength)
+	for _, method := range c.Index().Methods {
+		if code := method.GetAttribute("Code"); code != nil {
+			variants[method.Name+method.Descriptor] = c.getStackMapVariant(code.Start+6, charBuffer)
+		}
+	}
+	return variants
+}
+
+// getStackMapVariant returns the stack map variant of the Code attribute starting at the given offset.
+func (c *ClassReader) getStackMapVariant(codeOffset int, charBuffer []rune) int {
+	currentOffset := codeOffset + 8 + c.readInt(codeOffset+4)
+	currentOffset += 2 + c.readUnsignedShort(currentOffset)*8
+	variant := NO_STACK_MAP
+	attributeOffset := currentOffset + 2
+	for attributesCount := c.readUnsignedShort(currentOffset); attributesCount > 0; attributesCount-- {
+		switch c.readUTF8(attributeOffset, charBuffer) {
+		case "StackMapTable":
+			variant = STACK_MAP_TABLE
+		case "StackMap":
+			if variant == NO_STACK_MAP {
+				variant = STACK_MAP
+			}
+		}
+		attributeOffset += 6 + c.readInt(attributeOffset+2)
+	}
+	return variant
+}
@@ -0,0 +1,476 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/symbol"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Symbol an entry of the constant pool or of the BootstrapMethods attribute of a {@link SymbolTable}.
+type Symbol struct {
+	index int
+	tag   int
+	owner string
+	name  string
+	value string
+	data  int64
+}
+
+// GetIndex returns the index of this symbol in the constant pool, or in the BootstrapMethods attribute for a
+// {@link symbol.BOOTSTRAP_METHOD_TAG} symbol.
+func (s *Symbol) GetIndex() int {
+	return s.index
+}
+
+// GetTag returns the tag of this symbol, i.e. one of the CONSTANT_*_TAG constants of the symbol package, or
+// {@link symbol.BOOTSTRAP_METHOD_TAG}.
+func (s *Symbol) GetTag() int {
+	return s.tag
+}
+
+// symbolKey the values identifying a {@link Symbol}, used to intern the symbols.
+type symbolKey struct {
+	tag   int
+	owner string
+	name  string
+	value string
+	data  int64
+}
+
+// SymbolTable the constant pool entries and the bootstrap methods of a class being written. The add methods
+// intern their constant: they add it (and the constants it refers to) if it is not already in the table, and
+// return its {@link Symbol}. The first constant which can not be encoded (e.g. a too long string) is recorded,
+// and returned by {@link GetError}, instead of being returned by every add method.
+type SymbolTable struct {
+	className            string
+	majorVersion         int
+	symbols              map[symbolKey]*Symbol
+	constantPool         *ByteVector
+	constantPoolCount    int
+	bootstrapMethods     *ByteVector
+	bootstrapMethodCount int
+	// classReader the class whose constant pool has been copied in this table, if any.
+	classReader *ClassReader
+	err         error
+}
+
+// NewSymbolTable constructs a new, empty {@link SymbolTable}.
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		symbols:           make(map[symbolKey]*Symbol),
+		constantPool:      NewByteVector(),
+		constantPoolCount: 1,
+	}
+}
+
+// NewSymbolTableFromClassReader constructs a new {@link SymbolTable} initialized with the constant pool and
+// bootstrap methods of the given class, copied as is. The constant pool indices of the given class are thus
+// still valid, which allows to write its methods and its preserved attributes (see
+// {@link Attribute#IsPreserved}) without parsing and rewriting them.
+func NewSymbolTableFromClassReader(classReader *ClassReader) *SymbolTable {
+	s := NewSymbolTable()
+	s.classReader = classReader
+	b := classReader.b
+	charBuffer := make([]rune, classReader.maxStringLength)
+	constantPoolCount := len(classReader.cpInfoOffsets)
+	if constantPoolCount > 1 {
+		start := classReader.cpInfoOffsets[1] - 1
+		s.constantPool.PutByteArray(b, start, classReader.header-start)
+	}
+	s.constantPoolCount = constantPoolCount
+	for i := 1; i < constantPoolCount; i++ {
+		cpInfoOffset := classReader.cpInfoOffsets[i]
+		if cpInfoOffset == 0 {
+			continue
+		}
+		tag := int(b[cpInfoOffset-1])
+		switch tag {
+		case symbol.CONSTANT_FIELDREF_TAG, symbol.CONSTANT_METHODREF_TAG, symbol.CONSTANT_INTERFACE_METHODREF_TAG:
+			owner, name, descriptor := classReader.readMemberRef(cpInfoOffset, charBuffer)
+			s.addSymbol(i, symbolKey{tag: tag, owner: owner, name: name, value: descriptor})
+		case symbol.CONSTANT_INTEGER_TAG, symbol.CONSTANT_FLOAT_TAG:
+			s.addSymbol(i, symbolKey{tag: tag, data: int64(uint32(classReader.readInt(cpInfoOffset)))})
+		case symbol.CONSTANT_LONG_TAG, symbol.CONSTANT_DOUBLE_TAG:
+			s.addSymbol(i, symbolKey{tag: tag, data: classReader.readLong(cpInfoOffset)})
+		case symbol.CONSTANT_NAME_AND_TYPE_TAG:
+			s.addSymbol(i, symbolKey{
+				tag:   tag,
+				name:  classReader.readUTF8(cpInfoOffset, charBuffer),
+				value: classReader.readUTF8(cpInfoOffset+2, charBuffer),
+			})
+		case symbol.CONSTANT_METHOD_HANDLE_TAG:
+			referenceKind := int(b[cpInfoOffset])
+			referenceOffset := classReader.cpInfoOffsets[classReader.readUnsignedShort(cpInfoOffset+1)]
+			owner, name, descriptor := classReader.readMemberRef(referenceOffset, charBuffer)
+			isInterface := b[referenceOffset-1] == symbol.CONSTANT_INTERFACE_METHODREF_TAG
+			s.addSymbol(i, symbolKey{tag: tag, owner: owner, name: name, value: descriptor, data: handleData(referenceKind, isInterface)})
+		case symbol.CONSTANT_INVOKE_DYNAMIC_TAG:
+			nameAndTypeOffset := classReader.cpInfoOffsets[classReader.readUnsignedShort(cpInfoOffset+2)]
+			s.addSymbol(i, symbolKey{
+				tag:   tag,
+				name:  classReader.readUTF8(nameAndTypeOffset, charBuffer),
+				value: classReader.readUTF8(nameAndTypeOffset+2, charBuffer),
+				data:  int64(classReader.readUnsignedShort(cpInfoOffset)),
+			})
+		case symbol.CONSTANT_UTF8_TAG:
+			s.addSymbol(i, symbolKey{tag: tag, value: classReader.readUTF(i, charBuffer)})
+		case symbol.CONSTANT_CLASS_TAG, symbol.CONSTANT_STRING_TAG, symbol.CONSTANT_METHOD_TYPE_TAG,
+			symbol.CONSTANT_MODULE_TAG, symbol.CONSTANT_PACKAGE_TAG:
+			s.addSymbol(i, symbolKey{tag: tag, value: classReader.readUTF8(cpInfoOffset, charBuffer)})
+		}
+	}
+	s.copyBootstrapMethods(classReader, charBuffer)
+	return s
+}
+
+// readMemberRef returns the owner, name and descriptor of the CONSTANT_Fieldref, CONSTANT_Methodref or
+// CONSTANT_InterfaceMethodref entry whose content starts at the given offset.
+func (c ClassReader) readMemberRef(cpInfoOffset int, charBuffer []rune) (string, string, string) {
+	nameAndTypeOffset := c.cpInfoOffsets[c.readUnsignedShort(cpInfoOffset+2)]
+	return c.readClass(cpInfoOffset, charBuffer), c.readUTF8(nameAndTypeOffset, charBuffer), c.readUTF8(nameAndTypeOffset+2, charBuffer)
+}
+
+// copyBootstrapMethods copies the content of the BootstrapMethods attribute of the given class, if any.
+func (s *SymbolTable) copyBootstrapMethods(classReader *ClassReader, charBuffer []rune) {
+	currentAttributeOffset := classReader.getFirstAttributeOffset()
+	for i := classReader.readUnsignedShort(currentAttributeOffset - 2); i > 0; i-- {
+		attributeName := classReader.readUTF8(currentAttributeOffset, charBuffer)
+		attributeLength := classReader.readInt(currentAttributeOffset + 2)
+		currentAttributeOffset += 6
+		if attributeName == "BootstrapMethods" {
+			s.bootstrapMethodCount = classReader.readUnsignedShort(currentAttributeOffset)
+			s.bootstrapMethods = NewByteVectorWithCapacity(attributeLength)
+			s.bootstrapMethods.PutByteArray(classReader.b, currentAttributeOffset+2, attributeLength-2)
+			currentOffset := 0
+			for j := 0; j < s.bootstrapMethodCount; j++ {
+				numBootstrapArguments := int(s.bootstrapMethods.data[currentOffset+2])<<8 | int(s.bootstrapMethods.data[currentOffset+3])
+				length := 4 + 2*numBootstrapArguments
+				s.addSymbol(j, symbolKey{tag: symbol.BOOTSTRAP_METHOD_TAG, value: string(s.bootstrapMethods.data[currentOffset : currentOffset+length])})
+				currentOffset += length
+			}
+			return
+		}
+		currentAttributeOffset += attributeLength
+	}
+}
+
+// addSymbol registers the symbol of the given key, at the given index, which must already be written.
+func (s *SymbolTable) addSymbol(index int, key symbolKey) *Symbol {
+	entry := &Symbol{index: index, tag: key.tag, owner: key.owner, name: key.name, value: key.value, data: key.data}
+	s.symbols[key] = entry
+	return entry
+}
+
+// GetClassName returns the internal name of the class to which this symbol table belongs.
+func (s *SymbolTable) GetClassName() string {
+	return s.className
+}
+
+// GetMajorVersion returns the major version of the class to which this symbol table belongs.
+func (s *SymbolTable) GetMajorVersion() int {
+	return s.majorVersion
+}
+
+// SetMajorVersionAndClassName sets the major version and the name of the class to which this symbol table
+// belongs, and returns the constant pool index of the class name.
+func (s *SymbolTable) SetMajorVersionAndClassName(majorVersion int, className string) int {
+	s.majorVersion = majorVersion
+	s.className = className
+	return s.AddConstantClass(className).index
+}
+
+// GetError returns the error of the first constant which could not be added to this symbol table, or nil.
+func (s *SymbolTable) GetError() error {
+	return s.err
+}
+
+// GetConstantPoolCount returns the constant_pool_count of this symbol table, i.e. the number of its entries plus
+// one.
+func (s *SymbolTable) GetConstantPoolCount() int {
+	return s.constantPoolCount
+}
+
+// GetConstantPoolLength returns the length in bytes of the constant_pool of this symbol table.
+func (s *SymbolTable) GetConstantPoolLength() int {
+	return s.constantPool.Size()
+}
+
+// PutConstantPool puts the constant_pool_count and constant_pool of this symbol table into the given vector.
+func (s *SymbolTable) PutConstantPool(output *ByteVector) {
+	output.PutShort(s.constantPoolCount).PutByteArray(s.constantPool.data, 0, s.constantPool.Size())
+}
+
+// ComputeBootstrapMethodsSize returns the size in bytes of the BootstrapMethods attribute of this symbol table,
+// including its 6 bytes header, or 0 if it has no bootstrap methods. It also adds the name of this attribute to
+// the constant pool, if needed.
+func (s *SymbolTable) ComputeBootstrapMethodsSize() int {
+	if s.bootstrapMethods == nil {
+		return 0
+	}
+	s.AddConstantUtf8("BootstrapMethods")
+	return 8 + s.bootstrapMethods.Size()
+}
+
+// PutBootstrapMethods puts the BootstrapMethods attribute of this symbol table, if any, into the given vector.
+func (s *SymbolTable) PutBootstrapMethods(output *ByteVector) {
+	if s.bootstrapMethods == nil {
+		return
+	}
+	output.PutShort(s.AddConstantUtf8("BootstrapMethods")).
+		PutInt(s.bootstrapMethods.Size() + 2).
+		PutShort(s.bootstrapMethodCount).
+		PutByteArray(s.bootstrapMethods.data, 0, s.bootstrapMethods.Size())
+}
+
+// AddConstant adds a number, string, {@link Type} or {@link Handle} constant to this symbol table, i.e. the
+// value of an LDC instruction or of a bootstrap method argument.
+func (s *SymbolTable) AddConstant(value interface{}) (*Symbol, error) {
+	switch value := value.(type) {
+	case int:
+		return s.AddConstantInteger(int32(value)), nil
+	case int32:
+		return s.AddConstantInteger(value), nil
+	case int16:
+		return s.AddConstantInteger(int32(value)), nil
+	case int8:
+		return s.AddConstantInteger(int32(value)), nil
+	case bool:
+		if value {
+			return s.AddConstantInteger(1), nil
+		}
+		return s.AddConstantInteger(0), nil
+	case float32:
+		return s.AddConstantFloat(value), nil
+	case int64:
+		return s.AddConstantLong(value), nil
+	case float64:
+		return s.AddConstantDouble(value), nil
+	case string:
+		return s.AddConstantString(value), nil
+	case *Type:
+		switch value.GetSort() {
+		case typed.OBJECT:
+			return s.AddConstantClass(value.GetInternalName()), nil
+		case typed.METHOD:
+			return s.AddConstantMethodType(value.GetDescriptor()), nil
+		default:
+			return s.AddConstantClass(value.GetDescriptor()), nil
+		}
+	case *Handle:
+		if err := value.Validate(); err != nil {
+			return nil, err
+		}
+		return s.AddConstantMethodHandle(value.GetTag(), value.GetOwner(), value.GetName(), value.GetDesc(), value.IsInterface()), nil
+	}
+	return nil, errors.New("Illegal Argument - unsupported constant type " + fmt.Sprintf("%T", value))
+}
+
+// AddConstantUtf8 adds a CONSTANT_Utf8 entry to this symbol table, and returns its index.
+func (s *SymbolTable) AddConstantUtf8(value string) int {
+	key := symbolKey{tag: symbol.CONSTANT_UTF8_TAG, value: value}
+	if entry, ok := s.symbols[key]; ok {
+		return entry.index
+	}
+	s.constantPool.PutByte(symbol.CONSTANT_UTF8_TAG)
+	if _, err := s.constantPool.PutUTF8(value); err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		// Keeps the constant pool well formed.
+		s.constantPool.PutShort(0)
+	}
+	return s.newSymbol(key, 1).index
+}
+
+// newSymbol registers the symbol of the given key, which has just been written and uses the given number of
+// constant pool slots, at the next constant pool index.
+func (s *SymbolTable) newSymbol(key symbolKey, slots int) *Symbol {
+	entry := s.addSymbol(s.constantPoolCount, key)
+	s.constantPoolCount += slots
+	return entry
+}
+
+// addConstantUtf8Reference adds a CONSTANT_Class, CONSTANT_String, CONSTANT_MethodType, CONSTANT_Module or
+// CONSTANT_Package entry to this symbol table.
+func (s *SymbolTable) addConstantUtf8Reference(tag int, value string) *Symbol {
+	key := symbolKey{tag: tag, value: value}
+	if entry, ok := s.symbols[key]; ok {
+		return entry
+	}
+	s.constantPool.put12(tag, s.AddConstantUtf8(value))
+	return s.newSymbol(key, 1)
+}
+
+// AddConstantClass adds a CONSTANT_Class entry to this symbol table.
+func (s *SymbolTable) AddConstantClass(internalName string) *Symbol {
+	return s.addConstantUtf8Reference(symbol.CONSTANT_CLASS_TAG, internalName)
+}
+
+// AddConstantString adds a CONSTANT_String entry to this symbol table.
+func (s *SymbolTable) AddConstantString(value string) *Symbol {
+	return s.addConstantUtf8Reference(symbol.CONSTANT_STRING_TAG, value)
+}
+
+// AddConstantMethodType adds a CONSTANT_MethodType entry to this symbol table.
+func (s *SymbolTable) AddConstantMethodType(methodDescriptor string) *Symbol {
+	return s.addConstantUtf8Reference(symbol.CONSTANT_METHOD_TYPE_TAG, methodDescriptor)
+}
+
+// AddConstantModule adds a CONSTANT_Module entry to this symbol table.
+func (s *SymbolTable) AddConstantModule(moduleName string) *Symbol {
+	return s.addConstantUtf8Reference(symbol.CONSTANT_MODULE_TAG, moduleName)
+}
+
+// AddConstantPackage adds a CONSTANT_Package entry to this symbol table.
+func (s *SymbolTable) AddConstantPackage(packageName string) *Symbol {
+	return s.addConstantUtf8Reference(symbol.CONSTANT_PACKAGE_TAG, packageName)
+}
+
+// addConstantNumber adds a CONSTANT_Integer, CONSTANT_Float, CONSTANT_Long or CONSTANT_Double entry, whose
+// bits are given, to this symbol table.
+func (s *SymbolTable) addConstantNumber(tag int, bits int64) *Symbol {
+	key := symbolKey{tag: tag, data: bits}
+	if entry, ok := s.symbols[key]; ok {
+		return entry
+	}
+	if tag == symbol.CONSTANT_LONG_TAG || tag == symbol.CONSTANT_DOUBLE_TAG {
+		s.constantPool.PutByte(tag).PutLong(bits)
+		return s.newSymbol(key, 2)
+	}
+	s.constantPool.PutByte(tag).PutInt(int(bits))
+	return s.newSymbol(key, 1)
+}
+
+// AddConstantInteger adds a CONSTANT_Integer entry to this symbol table.
+func (s *SymbolTable) AddConstantInteger(value int32) *Symbol {
+	return s.addConstantNumber(symbol.CONSTANT_INTEGER_TAG, int64(uint32(value)))
+}
+
+// AddConstantFloat adds a CONSTANT_Float entry to this symbol table.
+func (s *SymbolTable) AddConstantFloat(value float32) *Symbol {
+	return s.addConstantNumber(symbol.CONSTANT_FLOAT_TAG, int64(math.Float32bits(value)))
+}
+
+// AddConstantLong adds a CONSTANT_Long entry to this symbol table.
+func (s *SymbolTable) AddConstantLong(value int64) *Symbol {
+	return s.addConstantNumber(symbol.CONSTANT_LONG_TAG, value)
+}
+
+// AddConstantDouble adds a CONSTANT_Double entry to this symbol table.
+func (s *SymbolTable) AddConstantDouble(value float64) *Symbol {
+	return s.addConstantNumber(symbol.CONSTANT_DOUBLE_TAG, int64(math.Float64bits(value)))
+}
+
+// AddConstantNameAndType adds a CONSTANT_NameAndType entry to this symbol table, and returns its index.
+func (s *SymbolTable) AddConstantNameAndType(name, descriptor string) int {
+	key := symbolKey{tag: symbol.CONSTANT_NAME_AND_TYPE_TAG, name: name, value: descriptor}
+	if entry, ok := s.symbols[key]; ok {
+		return entry.index
+	}
+	s.constantPool.put122(symbol.CONSTANT_NAME_AND_TYPE_TAG, s.AddConstantUtf8(name), s.AddConstantUtf8(descriptor))
+	return s.newSymbol(key, 1).index
+}
+
+// addConstantMemberReference adds a CONSTANT_Fieldref, CONSTANT_Methodref or CONSTANT_InterfaceMethodref entry
+// to this symbol table.
+func (s *SymbolTable) addConstantMemberReference(tag int, owner, name, descriptor string) *Symbol {
+	key := symbolKey{tag: tag, owner: owner, name: name, value: descriptor}
+	if entry, ok := s.symbols[key]; ok {
+		return entry
+	}
+	s.constantPool.put122(tag, s.AddConstantClass(owner).index, s.AddConstantNameAndType(name, descriptor))
+	return s.newSymbol(key, 1)
+}
+
+// AddConstantFieldref adds a CONSTANT_Fieldref entry to this symbol table.
+func (s *SymbolTable) AddConstantFieldref(owner, name, descriptor string) *Symbol {
+	return s.addConstantMemberReference(symbol.CONSTANT_FIELDREF_TAG, owner, name, descriptor)
+}
+
+// AddConstantMethodref adds a CONSTANT_Methodref or CONSTANT_InterfaceMethodref entry to this symbol table.
+func (s *SymbolTable) AddConstantMethodref(owner, name, descriptor string, isInterface bool) *Symbol {
+	if isInterface {
+		return s.addConstantMemberReference(symbol.CONSTANT_INTERFACE_METHODREF_TAG, owner, name, descriptor)
+	}
+	return s.addConstantMemberReference(symbol.CONSTANT_METHODREF_TAG, owner, name, descriptor)
+}
+
+// handleData returns the data of a CONSTANT_MethodHandle symbol.
+func handleData(referenceKind int, isInterface bool) int64 {
+	if isInterface {
+		return int64(referenceKind) | 1<<8
+	}
+	return int64(referenceKind)
+}
+
+// AddConstantMethodHandle adds a CONSTANT_MethodHandle entry to this symbol table. The reference kind is one of
+// the H_* constants of the opcodes package. An inconsistent handle (see {@link Handle#Validate}) is still added,
+// to keep the constant pool well formed, but its error is returned by {@link GetError}.
+func (s *SymbolTable) AddConstantMethodHandle(referenceKind int, owner, name, descriptor string, isInterface bool) *Symbol {
+	key := symbolKey{tag: symbol.CONSTANT_METHOD_HANDLE_TAG, owner: owner, name: name, value: descriptor, data: handleData(referenceKind, isInterface)}
+	if entry, ok := s.symbols[key]; ok {
+		return entry
+	}
+	handle := NewHandle(referenceKind, owner, name, descriptor, isInterface)
+	if err := handle.Validate(); err != nil && s.err == nil {
+		s.err = err
+	}
+	// The handle refers to the same Fieldref or Methodref constant as its equivalent instruction.
+	switch handle.GetOpcode() {
+	case opcodes.GETFIELD, opcodes.GETSTATIC, opcodes.PUTFIELD, opcodes.PUTSTATIC:
+		s.constantPool.put112(symbol.CONSTANT_METHOD_HANDLE_TAG, referenceKind, s.AddConstantFieldref(owner, name, descriptor).index)
+	default:
+		s.constantPool.put112(symbol.CONSTANT_METHOD_HANDLE_TAG, referenceKind, s.AddConstantMethodref(owner, name, descriptor, isInterface).index)
+	}
+	return s.newSymbol(key, 1)
+}
+
+// AddConstantInvokeDynamic adds a CONSTANT_InvokeDynamic entry, and its bootstrap method, to this symbol table.
+func (s *SymbolTable) AddConstantInvokeDynamic(name, descriptor string, bootstrapMethodHandle *Handle, bootstrapMethodArguments ...interface{}) (*Symbol, error) {
+	bootstrapMethod, err := s.AddBootstrapMethod(bootstrapMethodHandle, bootstrapMethodArguments...)
+	if err != nil {
+		return nil, err
+	}
+	key := symbolKey{tag: symbol.CONSTANT_INVOKE_DYNAMIC_TAG, name: name, value: descriptor, data: int64(bootstrapMethod.index)}
+	if entry, ok := s.symbols[key]; ok {
+		return entry, nil
+	}
+	s.constantPool.put122(symbol.CONSTANT_INVOKE_DYNAMIC_TAG, bootstrapMethod.index, s.AddConstantNameAndType(name, descriptor))
+	return s.newSymbol(key, 1), nil
+}
+
+// AddBootstrapMethod adds a bootstrap method to the BootstrapMethods attribute of this symbol table.
+func (s *SymbolTable) AddBootstrapMethod(bootstrapMethodHandle *Handle, bootstrapMethodArguments ...interface{}) (*Symbol, error) {
+	if bootstrapMethodHandle == nil {
+		return nil, errors.New("Illegal Argument - nil bootstrap method handle")
+	}
+	if err := bootstrapMethodHandle.Validate(); err != nil {
+		return nil, err
+	}
+	// The constants must be added before the bootstrap method content, which refers to their indices.
+	content := NewByteVectorWithCapacity(4 + 2*len(bootstrapMethodArguments))
+	content.PutShort(s.AddConstantMethodHandle(bootstrapMethodHandle.GetTag(), bootstrapMethodHandle.GetOwner(),
+		bootstrapMethodHandle.GetName(), bootstrapMethodHandle.GetDesc(), bootstrapMethodHandle.IsInterface()).index)
+	content.PutShort(len(bootstrapMethodArguments))
+	for _, argument := range bootstrapMethodArguments {
+		constant, err := s.AddConstant(argument)
+		if err != nil {
+			return nil, err
+		}
+		content.PutShort(constant.index)
+	}
+	key := symbolKey{tag: symbol.BOOTSTRAP_METHOD_TAG, value: string(content.data)}
+	if entry, ok := s.symbols[key]; ok {
+		return entry, nil
+	}
+	if s.bootstrapMethods == nil {
+		s.bootstrapMethods = NewByteVector()
+	}
+	s.bootstrapMethods.PutByteArray(content.data, 0, content.Size())
+	s.bootstrapMethodCount++
+	return s.addSymbol(s.bootstrapMethodCount-1, key), nil
+}
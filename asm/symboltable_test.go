@@ -0,0 +1,56 @@
+package asm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+func TestSymbolTable(t *testing.T) {
+	utf8 := func(s string) []byte { return append([]byte{1, 0, byte(len(s))}, s...) }
+	expected := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52, 0, 15}
+	expected = append(expected, utf8("A")...)
+	expected = append(expected, 7, 0, 1)
+	expected = append(expected, utf8("java/lang/Object")...)
+	expected = append(expected, 7, 0, 3)
+	expected = append(expected, utf8("f")...)
+	expected = append(expected, utf8("I")...)
+	expected = append(expected, 12, 0, 5, 0, 6)
+	expected = append(expected, 9, 0, 2, 0, 7)
+	expected = append(expected, utf8("run")...)
+	expected = append(expected, utf8("()V")...)
+	expected = append(expected, 12, 0, 9, 0, 10)
+	expected = append(expected, 11, 0, 4, 0, 11)
+	expected = append(expected, 5, 0, 0, 0, 0, 0, 0, 0, 42)
+	expected = append(expected, 0, 0x21, 0, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	classFile := asmtest.NewClassFile(opcodes.V1_8, 0x21, "A", "java/lang/Object")
+	symbolTable := classFile.SymbolTable
+	symbolTable.AddConstantFieldref("A", "f", "I")
+	symbolTable.AddConstantMethodref("java/lang/Object", "run", "()V", true)
+	if long, err := symbolTable.AddConstant(int64(42)); err != nil || long.GetIndex() != 13 {
+		t.Errorf("unexpected long constant %v %v", long, err)
+	}
+	if symbolTable.AddConstantClass("A").GetIndex() != 2 || symbolTable.AddConstantUtf8("()V") != 10 {
+		t.Error("expected the existing constants to be reused")
+	}
+	if actual := classFile.Bytes(); !bytes.Equal(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	reader, err := asm.NewClassReader(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := asm.NewSymbolTableFromClassReader(reader)
+	if copied.AddConstantFieldref("A", "f", "I").GetIndex() != 8 || copied.AddConstantLong(42).GetIndex() != 13 ||
+		copied.GetConstantPoolCount() != 15 {
+		t.Error("expected the constants of the class reader to be reused")
+	}
+	if index := copied.AddConstantString("f").GetIndex(); index != 15 || copied.GetConstantPoolCount() != 16 {
+		t.Errorf("unexpected new constant index %d", index)
+	}
+}
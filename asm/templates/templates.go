@@ -0,0 +1,352 @@
+// Package templates provides ready-made generators of classes following common patterns. Each generator
+// emits its classes with the visitor API, records them in {@link tree.ClassNode}s, writes them with
+// {@link asm.AddField} and {@link asm.AddMethod} and verifies the resulting class files with
+// {@link analysis.VerifyOutput}, so that the returned class files can be loaded as is. The generators are also
+// examples of how to drive the visitor API.
+package templates
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// VERSION the class file version of the generated classes.
+const VERSION = opcodes.V1_8
+
+const (
+	object      = "java/lang/Object"
+	constructor = "<init>"
+)
+
+// Property a property of the classes generated by {@link Builder}.
+type Property struct {
+	// Name the name of the property, which must be a valid unqualified name.
+	Name string
+	// Descriptor the field descriptor of the property.
+	Descriptor string
+}
+
+// generate records the classes emitted by the given function, which calls emit for each class, writes them with
+// {@link writeClass} and verifies their class files.
+func generate(classes func(emit func() asm.ClassVisitor)) ([][]byte, error) {
+	var classNodes []*tree.ClassNode
+	classes(func() asm.ClassVisitor {
+		classNode := tree.NewClassNode()
+		classNodes = append(classNodes, classNode)
+		return classNode
+	})
+	var classFiles [][]byte
+	var errs []error
+	for _, classNode := range classNodes {
+		classFile, err := writeClass(classNode)
+		if err == nil {
+			classFile, err = analysis.VerifyOutput(classFile)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		classFiles = append(classFiles, classFile)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return classFiles, nil
+}
+
+// writeClass returns the class file of the given class: the class is first written without members, and its
+// fields and methods are then appended with {@link asm.AddField} and {@link asm.AddMethod}, which compute the
+// maximum stack sizes and the stack map frames of the methods. The signatures and exceptions of the methods are
+// not written, since the generators do not use them.
+func writeClass(class *tree.ClassNode) ([]byte, error) {
+	symbolTable := asm.NewSymbolTable()
+	thisClass := symbolTable.SetMajorVersionAndClassName(class.Version, class.Name)
+	superClass := symbolTable.AddConstantClass(class.SuperName).GetIndex()
+	interfaces := make([]int, len(class.Interfaces))
+	for i, interfaceName := range class.Interfaces {
+		interfaces[i] = symbolTable.AddConstantClass(interfaceName).GetIndex()
+	}
+	classFile := asm.NewByteVector().PutInt(0xCAFEBABE).PutInt(class.Version)
+	symbolTable.PutConstantPool(classFile)
+	classFile.PutShort(class.Access).PutShort(thisClass).PutShort(superClass).PutShort(len(interfaces))
+	for _, interfaceIndex := range interfaces {
+		classFile.PutShort(interfaceIndex)
+	}
+	// The fields, methods and attributes counts.
+	classFile.PutShort(0).PutShort(0).PutShort(0)
+
+	result := classFile.Bytes()
+	var err error
+	for _, field := range class.Fields {
+		if result, err = asm.AddField(result, field.Access, field.Name, field.Descriptor, field.Signature, field.Value, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, method := range class.Methods {
+		var buildBody func(methodVisitor asm.MethodVisitor)
+		if len(method.Instructions) > 0 {
+			buildBody = func(methodVisitor asm.MethodVisitor) {
+				method.AcceptMethod(bodyVisitor{methodVisitor})
+			}
+		}
+		if result, err = asm.AddMethod(result, method.Access, method.Name, method.Descriptor, buildBody); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// bodyVisitor a method visitor which ignores VisitEnd, since it is called by {@link asm.AddMethod} after the
+// body of the method has been visited.
+type bodyVisitor struct {
+	asm.MethodVisitor
+}
+
+func (b bodyVisitor) VisitEnd() {
+}
+
+// checkNames returns an error if one of the given internal names is invalid.
+func checkNames(names ...string) error {
+	for _, name := range names {
+		if err := asm.CheckInternalName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visitConstructor emits a constructor with the given parameters, which calls the constructor of the given
+// super class with the given first parameters, and stores the remaining ones in the given fields.
+func visitConstructor(classVisitor asm.ClassVisitor, access int, owner, superName string, superParameters int, parameters []Property) {
+	descriptor, superDescriptor := "(", "("
+	for i, parameter := range parameters {
+		descriptor += parameter.Descriptor
+		if i < superParameters {
+			superDescriptor += parameter.Descriptor
+		}
+	}
+	descriptor, superDescriptor = descriptor+")V", superDescriptor+")V"
+
+	methodVisitor := classVisitor.VisitMethod(access, constructor, descriptor, "", nil)
+	methodVisitor.VisitCode()
+	methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+	local, maxStack := 1, 1
+	for _, parameter := range parameters[:superParameters] {
+		parameterType := asm.GetType(parameter.Descriptor)
+		methodVisitor.VisitVarInsn(parameterType.GetOpcode(opcodes.ILOAD), local)
+		local += parameterType.GetSize()
+		maxStack += parameterType.GetSize()
+	}
+	methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, superName, constructor, superDescriptor, false)
+	for _, parameter := range parameters[superParameters:] {
+		parameterType := asm.GetType(parameter.Descriptor)
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+		methodVisitor.VisitVarInsn(parameterType.GetOpcode(opcodes.ILOAD), local)
+		methodVisitor.VisitFieldInsn(opcodes.PUTFIELD, owner, parameter.Name, parameter.Descriptor)
+		local += parameterType.GetSize()
+		maxStack = max(maxStack, 1+parameterType.GetSize())
+	}
+	methodVisitor.VisitInsn(opcodes.RETURN)
+	methodVisitor.VisitMaxs(maxStack, local)
+	methodVisitor.VisitEnd()
+}
+
+// Singleton returns a class with the given internal name whose unique instance is lazily created by the JVM
+// when its static getInstance method is first called, with the initialization-on-demand holder idiom: the
+// instance is stored in a static field of a second class, named name$Holder, which is initialized on its
+// first access. The class files of the class and of its holder are returned in this order.
+func Singleton(name string) ([][]byte, error) {
+	if err := checkNames(name); err != nil {
+		return nil, err
+	}
+	holder := name + "$Holder"
+	descriptor := "L" + name + ";"
+	return generate(func(emit func() asm.ClassVisitor) {
+		classVisitor := emit()
+		classVisitor.Visit(VERSION, opcodes.ACC_PUBLIC|opcodes.ACC_FINAL|opcodes.ACC_SUPER, name, "", object, nil)
+		// The constructor is package private, so that the holder can call it without nestmate access.
+		visitConstructor(classVisitor, 0, name, object, 0, nil)
+		methodVisitor := classVisitor.VisitMethod(opcodes.ACC_PUBLIC|opcodes.ACC_STATIC, "getInstance", "()"+descriptor, "", nil)
+		methodVisitor.VisitCode()
+		methodVisitor.VisitFieldInsn(opcodes.GETSTATIC, holder, "INSTANCE", descriptor)
+		methodVisitor.VisitInsn(opcodes.ARETURN)
+		methodVisitor.VisitMaxs(1, 0)
+		methodVisitor.VisitEnd()
+		classVisitor.VisitEnd()
+
+		classVisitor = emit()
+		classVisitor.Visit(VERSION, opcodes.ACC_FINAL|opcodes.ACC_SUPER|opcodes.ACC_SYNTHETIC, holder, "", object, nil)
+		fieldVisitor := classVisitor.VisitField(opcodes.ACC_STATIC|opcodes.ACC_FINAL, "INSTANCE", descriptor, "", nil)
+		if fieldVisitor != nil {
+			fieldVisitor.VisitEnd()
+		}
+		methodVisitor = classVisitor.VisitMethod(opcodes.ACC_STATIC, "<clinit>", "()V", "", nil)
+		methodVisitor.VisitCode()
+		methodVisitor.VisitTypeInsn(opcodes.NEW, name)
+		methodVisitor.VisitInsn(opcodes.DUP)
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, name, constructor, "()V", false)
+		methodVisitor.VisitFieldInsn(opcodes.PUTSTATIC, holder, "INSTANCE", descriptor)
+		methodVisitor.VisitInsn(opcodes.RETURN)
+		methodVisitor.VisitMaxs(2, 0)
+		methodVisitor.VisitEnd()
+		classVisitor.VisitEnd()
+	})
+}
+
+// Builder returns the class files of an immutable class with the given internal name and properties, and of
+// its builder class, named name$Builder, in this order. The class has a private final field and a getter
+// (named get followed by the capitalized property name) for each property. The builder has a method named after
+// each property, which sets its value and returns the builder, and a build method which returns a new instance
+// of the class.
+func Builder(name string, properties []Property) ([][]byte, error) {
+	if err := checkNames(name); err != nil {
+		return nil, err
+	}
+	for _, property := range properties {
+		if err := asm.CheckUnqualifiedName(property.Name); err != nil {
+			return nil, err
+		}
+		if err := asm.CheckDescriptor(property.Descriptor); err != nil {
+			return nil, err
+		}
+	}
+	builder := name + "$Builder"
+	return generate(func(emit func() asm.ClassVisitor) {
+		classVisitor := emit()
+		classVisitor.Visit(VERSION, opcodes.ACC_PUBLIC|opcodes.ACC_FINAL|opcodes.ACC_SUPER, name, "", object, nil)
+		for _, property := range properties {
+			fieldVisitor := classVisitor.VisitField(opcodes.ACC_PRIVATE|opcodes.ACC_FINAL, property.Name, property.Descriptor, "", nil)
+			if fieldVisitor != nil {
+				fieldVisitor.VisitEnd()
+			}
+		}
+		visitConstructor(classVisitor, 0, name, object, 0, properties)
+		for _, property := range properties {
+			propertyType := asm.GetType(property.Descriptor)
+			getter := "get" + strings.ToUpper(property.Name[:1]) + property.Name[1:]
+			methodVisitor := classVisitor.VisitMethod(opcodes.ACC_PUBLIC, getter, "()"+property.Descriptor, "", nil)
+			methodVisitor.VisitCode()
+			methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+			methodVisitor.VisitFieldInsn(opcodes.GETFIELD, name, property.Name, property.Descriptor)
+			methodVisitor.VisitInsn(propertyType.GetOpcode(opcodes.IRETURN))
+			methodVisitor.VisitMaxs(propertyType.GetSize(), 1)
+			methodVisitor.VisitEnd()
+		}
+		classVisitor.VisitEnd()
+
+		classVisitor = emit()
+		classVisitor.Visit(VERSION, opcodes.ACC_PUBLIC|opcodes.ACC_FINAL|opcodes.ACC_SUPER, builder, "", object, nil)
+		for _, property := range properties {
+			fieldVisitor := classVisitor.VisitField(opcodes.ACC_PRIVATE, property.Name, property.Descriptor, "", nil)
+			if fieldVisitor != nil {
+				fieldVisitor.VisitEnd()
+			}
+		}
+		visitConstructor(classVisitor, opcodes.ACC_PUBLIC, builder, object, 0, nil)
+		for _, property := range properties {
+			propertyType := asm.GetType(property.Descriptor)
+			methodVisitor := classVisitor.VisitMethod(opcodes.ACC_PUBLIC, property.Name, "("+property.Descriptor+")L"+builder+";", "", nil)
+			methodVisitor.VisitCode()
+			methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+			methodVisitor.VisitVarInsn(propertyType.GetOpcode(opcodes.ILOAD), 1)
+			methodVisitor.VisitFieldInsn(opcodes.PUTFIELD, builder, property.Name, property.Descriptor)
+			methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+			methodVisitor.VisitInsn(opcodes.ARETURN)
+			methodVisitor.VisitMaxs(1+propertyType.GetSize(), 1+propertyType.GetSize())
+			methodVisitor.VisitEnd()
+		}
+		descriptor, maxStack := "(", 2
+		methodVisitor := classVisitor.VisitMethod(opcodes.ACC_PUBLIC, "build", "()L"+name+";", "", nil)
+		methodVisitor.VisitCode()
+		methodVisitor.VisitTypeInsn(opcodes.NEW, name)
+		methodVisitor.VisitInsn(opcodes.DUP)
+		for _, property := range properties {
+			methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+			methodVisitor.VisitFieldInsn(opcodes.GETFIELD, builder, property.Name, property.Descriptor)
+			descriptor += property.Descriptor
+			maxStack += asm.GetType(property.Descriptor).GetSize()
+		}
+		methodVisitor.VisitMethodInsnB(opcodes.INVOKESPECIAL, name, constructor, descriptor+")V", false)
+		methodVisitor.VisitInsn(opcodes.ARETURN)
+		methodVisitor.VisitMaxs(maxStack, 1)
+		methodVisitor.VisitEnd()
+		classVisitor.VisitEnd()
+	})
+}
+
+// Exception returns the class file of an exception class with the given internal name, which extends the given
+// exception class (e.g. java/lang/RuntimeException), with the four usual constructors: (), (String), (String,
+// Throwable) and (Throwable).
+func Exception(name, superName string) ([][]byte, error) {
+	if err := checkNames(name, superName); err != nil {
+		return nil, err
+	}
+	message := Property{Name: "message", Descriptor: "Ljava/lang/String;"}
+	cause := Property{Name: "cause", Descriptor: "Ljava/lang/Throwable;"}
+	return generate(func(emit func() asm.ClassVisitor) {
+		classVisitor := emit()
+		classVisitor.Visit(VERSION, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, name, "", superName, nil)
+		fieldVisitor := classVisitor.VisitField(opcodes.ACC_PRIVATE|opcodes.ACC_STATIC|opcodes.ACC_FINAL, "serialVersionUID", "J", "", int64(1))
+		if fieldVisitor != nil {
+			fieldVisitor.VisitEnd()
+		}
+		for _, parameters := range [][]Property{nil, {message}, {message, cause}, {cause}} {
+			visitConstructor(classVisitor, opcodes.ACC_PUBLIC, name, superName, len(parameters), parameters)
+		}
+		classVisitor.VisitEnd()
+	})
+}
+
+// Adapter returns the class file of a class with the given internal name which implements the given functional
+// interface method by calling the given method of a target object, passed to its constructor. The interface and
+// target methods must have the same descriptor. targetIsInterface must be true if the target class is an
+// interface.
+func Adapter(name, interfaceName, method, descriptor, target, targetMethod string, targetIsInterface bool) ([][]byte, error) {
+	if err := checkNames(name, interfaceName, target); err != nil {
+		return nil, err
+	}
+	if err := asm.CheckMethodName(method); err != nil {
+		return nil, err
+	}
+	if err := asm.CheckMethodName(targetMethod); err != nil {
+		return nil, err
+	}
+	if err := asm.CheckMethodDescriptor(descriptor); err != nil {
+		return nil, err
+	}
+	targetField := Property{Name: "target", Descriptor: "L" + target + ";"}
+	return generate(func(emit func() asm.ClassVisitor) {
+		classVisitor := emit()
+		classVisitor.Visit(VERSION, opcodes.ACC_PUBLIC|opcodes.ACC_FINAL|opcodes.ACC_SUPER, name, "", object, []string{interfaceName})
+		fieldVisitor := classVisitor.VisitField(opcodes.ACC_PRIVATE|opcodes.ACC_FINAL, targetField.Name, targetField.Descriptor, "", nil)
+		if fieldVisitor != nil {
+			fieldVisitor.VisitEnd()
+		}
+		visitConstructor(classVisitor, opcodes.ACC_PUBLIC, name, object, 0, []Property{targetField})
+
+		methodVisitor := classVisitor.VisitMethod(opcodes.ACC_PUBLIC, method, descriptor, "", nil)
+		methodVisitor.VisitCode()
+		methodVisitor.VisitVarInsn(opcodes.ALOAD, 0)
+		methodVisitor.VisitFieldInsn(opcodes.GETFIELD, name, targetField.Name, targetField.Descriptor)
+		local := 1
+		for _, argumentType := range asm.GetMethodType(descriptor).GetArgumentTypes() {
+			methodVisitor.VisitVarInsn(argumentType.GetOpcode(opcodes.ILOAD), local)
+			local += argumentType.GetSize()
+		}
+		if targetIsInterface {
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKEINTERFACE, target, targetMethod, descriptor, true)
+		} else {
+			methodVisitor.VisitMethodInsnB(opcodes.INVOKEVIRTUAL, target, targetMethod, descriptor, false)
+		}
+		returnType := asm.GetMethodType(descriptor).GetReturnType()
+		methodVisitor.VisitInsn(returnType.GetOpcode(opcodes.IRETURN))
+		methodVisitor.VisitMaxs(max(local, returnType.GetSize()), local)
+		methodVisitor.VisitEnd()
+		classVisitor.VisitEnd()
+	})
+}
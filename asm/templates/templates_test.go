@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// readClasses returns the class nodes of the given class files.
+func readClasses(t *testing.T, classFiles [][]byte) []*tree.ClassNode {
+	classes := make([]*tree.ClassNode, len(classFiles))
+	for i, classFile := range classFiles {
+		class, err := tree.ReadClassNode(classFile, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		classes[i] = class
+	}
+	return classes
+}
+
+func TestTemplates(t *testing.T) {
+	generators := map[string]func() ([][]byte, error){
+		"Singleton": func() ([][]byte, error) { return Singleton("a/Service") },
+		"Builder": func() ([][]byte, error) {
+			return Builder("a/Point", []Property{{"x", "I"}, {"y", "J"}, {"label", "Ljava/lang/String;"}})
+		},
+		"Exception": func() ([][]byte, error) { return Exception("a/Failure", "java/lang/RuntimeException") },
+		"Adapter": func() ([][]byte, error) {
+			return Adapter("a/Adapter", "java/util/function/ToLongBiFunction", "applyAsLong",
+				"(Ljava/lang/Object;Ljava/lang/Object;)J", "a/Target", "compute", false)
+		},
+	}
+	for name, generator := range generators {
+		classFiles, err := generator()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, class := range readClasses(t, classFiles) {
+			if len(class.Methods) == 0 {
+				t.Errorf("%s: %s has no methods", name, class.Name)
+			}
+		}
+	}
+
+	classFiles, _ := Builder("a/Point", []Property{{"x", "I"}, {"y", "J"}})
+	classes := readClasses(t, classFiles)
+	if len(classes) != 2 || classes[1].Name != "a/Point$Builder" {
+		t.Fatalf("unexpected builder classes %v", classes)
+	}
+	if classes[0].GetMethod("getY", "()J") == nil || classes[1].GetMethod("build", "()La/Point;") == nil {
+		t.Error("missing builder methods")
+	}
+	if build := classes[1].GetMethod("build", "()La/Point;"); build.MaxStack != 5 {
+		t.Errorf("build max stack = %d, want 5", build.MaxStack)
+	}
+	if _, err := Builder("a/Point", []Property{{"x;", "I"}}); err == nil {
+		t.Error("invalid property name accepted")
+	}
+}
@@ -0,0 +1,249 @@
+package tree
+
+import (
+	"errors"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// ClassModel an immutable snapshot of a class, an alternative to the visitor API for simple edits: the model
+// is read once, inspected with its getters, and transformed with {@link Transform} into new models which share
+// the unchanged fields and methods with the original one. The result is emitted with {@link Accept}, to any
+// class visitor. The model only contains what a {@link ClassNode} records, so it is not serialized back to a class
+// file. The instructions of the methods returned by {@link GetMethods} must not be modified.
+type ClassModel struct {
+	class *ClassNode
+}
+
+// NewClassModel returns the {@link ClassModel} of the given class file.
+func NewClassModel(classFile []byte, parsingOptions int) (*ClassModel, error) {
+	class, err := ReadClassNode(classFile, parsingOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &ClassModel{class: class}, nil
+}
+
+// GetVersion returns the class file version of the class.
+func (c *ClassModel) GetVersion() int {
+	return c.class.Version
+}
+
+// GetAccess returns the access flags of the class.
+func (c *ClassModel) GetAccess() int {
+	return c.class.Access
+}
+
+// GetName returns the internal name of the class.
+func (c *ClassModel) GetName() string {
+	return c.class.Name
+}
+
+// GetSignature returns the generic signature of the class, or "".
+func (c *ClassModel) GetSignature() string {
+	return c.class.Signature
+}
+
+// GetSuperName returns the internal name of the super class, or "".
+func (c *ClassModel) GetSuperName() string {
+	return c.class.SuperName
+}
+
+// GetInterfaces returns the internal names of the interfaces of the class.
+func (c *ClassModel) GetInterfaces() []string {
+	return append([]string(nil), c.class.Interfaces...)
+}
+
+// GetSourceFile returns the name of the source file of the class, or "".
+func (c *ClassModel) GetSourceFile() string {
+	return c.class.SourceFile
+}
+
+// GetFields returns (copies of) the fields of the class.
+func (c *ClassModel) GetFields() []FieldNode {
+	fields := make([]FieldNode, len(c.class.Fields))
+	for i, field := range c.class.Fields {
+		fields[i] = *field
+	}
+	return fields
+}
+
+// GetMethods returns the methods of the class. They are shared with the other models and must not be
+// modified.
+func (c *ClassModel) GetMethods() []*MethodNode {
+	return append([]*MethodNode(nil), c.class.Methods...)
+}
+
+// GetMethod returns the method with the given name and descriptor, or nil. It must not be modified.
+func (c *ClassModel) GetMethod(name, descriptor string) *MethodNode {
+	return c.class.GetMethod(name, descriptor)
+}
+
+// Accept makes the given class visitor visit this class.
+func (c *ClassModel) Accept(classVisitor asm.ClassVisitor) {
+	c.class.Accept(classVisitor)
+}
+
+// ClassEdit a declarative edit of a {@link ClassModel}, applied by {@link Transform}. Apply receives a copy of
+// the class whose Interfaces, Fields and Methods slices can be freely modified, but whose field and method
+// nodes are shared with the original model: they must be replaced with modified copies, not modified in place.
+type ClassEdit interface {
+	Apply(class *ClassNode) error
+}
+
+// Transform returns a new model obtained by applying the given edits, in order, to the given model, which is
+// left unchanged. Returns the first error returned by an edit, if any.
+func Transform(model *ClassModel, edits ...ClassEdit) (*ClassModel, error) {
+	class := *model.class
+	class.Interfaces = append([]string(nil), class.Interfaces...)
+	class.Fields = append([]*FieldNode(nil), class.Fields...)
+	class.Methods = append([]*MethodNode(nil), class.Methods...)
+	for _, edit := range edits {
+		if err := edit.Apply(&class); err != nil {
+			return nil, err
+		}
+	}
+	return &ClassModel{class: &class}, nil
+}
+
+// AddMethod a {@link ClassEdit} which adds a method to the class. The class must not already have a method
+// with the same name and descriptor.
+type AddMethod struct {
+	Method *MethodNode
+}
+
+func (a AddMethod) Apply(class *ClassNode) error {
+	if class.GetMethod(a.Method.Name, a.Method.Descriptor) != nil {
+		return errors.New("Illegal Argument - duplicate method " + a.Method.Name + a.Method.Descriptor)
+	}
+	class.Methods = append(class.Methods, a.Method)
+	return nil
+}
+
+// RenameField a {@link ClassEdit} which renames a field of the class, and the references to this field in the
+// methods of the class. References from other classes are not updated. Descriptor can be "" if the class has a
+// single field with the given name. The class must not already have a field with the new name and the same
+// descriptor.
+type RenameField struct {
+	Name       string
+	Descriptor string
+	NewName    string
+}
+
+func (r RenameField) Apply(class *ClassNode) error {
+	index := -1
+	for i, field := range class.Fields {
+		if field.Name == r.Name && (r.Descriptor == "" || field.Descriptor == r.Descriptor) {
+			if index != -1 {
+				return errors.New("Illegal Argument - ambiguous field " + r.Name)
+			}
+			index = i
+		}
+	}
+	if index == -1 {
+		return errors.New("Illegal Argument - unknown field " + r.Name + r.Descriptor)
+	}
+	field := *class.Fields[index]
+	for _, other := range class.Fields {
+		if other.Name == r.NewName && other.Descriptor == field.Descriptor {
+			return errors.New("Illegal Argument - duplicate field " + r.NewName + " " + field.Descriptor)
+		}
+	}
+	field.Name = r.NewName
+	class.Fields[index] = &field
+	rewriteInstructions(class, func(insn AbstractInsnNode) AbstractInsnNode {
+		if fieldInsn, ok := insn.(*FieldInsnNode); ok && fieldInsn.Owner == class.Name && fieldInsn.Name == r.Name &&
+			fieldInsn.Descriptor == field.Descriptor {
+			return &FieldInsnNode{fieldInsn.Opcode, fieldInsn.Owner, r.NewName, fieldInsn.Descriptor}
+		}
+		return nil
+	})
+	return nil
+}
+
+// ChangeSuperclass a {@link ClassEdit} which changes the super class of the class, and the super constructor
+// calls of its constructors accordingly. The constructor calls on new instances of the old super class are left
+// unchanged. The new super class must have constructors with the same descriptors as the old one.
+type ChangeSuperclass struct {
+	SuperName string
+}
+
+func (c ChangeSuperclass) Apply(class *ClassNode) error {
+	if c.SuperName == "" || (class.Access&opcodes.ACC_INTERFACE) != 0 {
+		return errors.New("Illegal Argument - can't change the super class of " + class.Name)
+	}
+	oldSuperName := class.SuperName
+	class.SuperName = c.SuperName
+	superCalls := make(map[AbstractInsnNode]bool)
+	for _, method := range class.Methods {
+		if method.Name == "<init>" {
+			addThisConstructorCalls(method, superCalls)
+		}
+	}
+	rewriteInstructions(class, func(insn AbstractInsnNode) AbstractInsnNode {
+		if methodInsn, ok := insn.(*MethodInsnNode); ok && superCalls[insn] && methodInsn.Owner == oldSuperName {
+			return &MethodInsnNode{methodInsn.Opcode, c.SuperName, methodInsn.Name, methodInsn.Descriptor, false}
+		}
+		return nil
+	}, "<init>")
+	return nil
+}
+
+// addThisConstructorCalls adds to calls the INVOKESPECIAL <init> instructions of the given constructor which
+// initialize 'this', i.e. which are not paired with a NEW instruction. The <init> calls are paired with the
+// preceding NEW instructions in nesting order, which matches the code generated by compilers (where the creations
+// of new instances are nested, and the super constructor call is not inside any of them).
+func addThisConstructorCalls(method *MethodNode, calls map[AbstractInsnNode]bool) {
+	newInstances := 0
+	for _, insn := range method.Instructions {
+		switch insn := insn.(type) {
+		case *TypeInsnNode:
+			if insn.Opcode == opcodes.NEW {
+				newInstances++
+			}
+		case *MethodInsnNode:
+			if insn.Opcode == opcodes.INVOKESPECIAL && insn.Name == "<init>" {
+				if newInstances > 0 {
+					newInstances--
+				} else {
+					calls[insn] = true
+				}
+			}
+		}
+	}
+}
+
+// rewriteInstructions replaces the instructions of the methods of the given class (or only those with one of
+// the given names, if any) for which rewrite returns a non nil instruction. The methods with replaced
+// instructions are replaced with copies, with a new instruction list.
+func rewriteInstructions(class *ClassNode, rewrite func(insn AbstractInsnNode) AbstractInsnNode, names ...string) {
+	for i, method := range class.Methods {
+		if len(names) > 0 && !containsString(names, method.Name) {
+			continue
+		}
+		var instructions []AbstractInsnNode
+		for j, insn := range method.Instructions {
+			if newInsn := rewrite(insn); newInsn != nil {
+				if instructions == nil {
+					instructions = append([]AbstractInsnNode(nil), method.Instructions...)
+				}
+				instructions[j] = newInsn
+			}
+		}
+		if instructions != nil {
+			newMethod := *method
+			newMethod.Instructions = instructions
+			class.Methods[i] = &newMethod
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
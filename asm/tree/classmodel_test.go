@@ -0,0 +1,115 @@
+package tree_test
+
+import (
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/asmtest"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+// modelClass returns a class "A extends B" with two fields named x, a field y, and a constructor which calls
+// the B() super constructor, creates a new B and reads x.
+func modelClass(t *testing.T) *tree.ClassModel {
+	classFile := asmtest.NewClassFile(opcodes.V1_8, opcodes.ACC_PUBLIC|opcodes.ACC_SUPER, "A", "B")
+	classFile.AddField(0, "x", "I", "", nil)
+	classFile.AddField(0, "x", "J", "", nil)
+	classFile.AddField(0, "y", "I", "", nil)
+	methodWriter := classFile.AddMethod(opcodes.ACC_PUBLIC, "<init>", "()V", "", nil)
+	methodWriter.VisitCode()
+	methodWriter.VisitVarInsn(opcodes.ALOAD, 0)
+	methodWriter.VisitMethodInsnB(opcodes.INVOKESPECIAL, "B", "<init>", "()V", false)
+	methodWriter.VisitTypeInsn(opcodes.NEW, "B")
+	methodWriter.VisitInsn(opcodes.DUP)
+	methodWriter.VisitMethodInsnB(opcodes.INVOKESPECIAL, "B", "<init>", "()V", false)
+	methodWriter.VisitInsn(opcodes.POP)
+	methodWriter.VisitVarInsn(opcodes.ALOAD, 0)
+	methodWriter.VisitFieldInsn(opcodes.GETFIELD, "A", "x", "I")
+	methodWriter.VisitInsn(opcodes.POP)
+	methodWriter.VisitInsn(opcodes.RETURN)
+	methodWriter.VisitMaxs(2, 1)
+	model, err := tree.NewClassModel(classFile.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+func constructorCalls(model *tree.ClassModel) []string {
+	var owners []string
+	for _, insn := range model.GetMethod("<init>", "()V").Instructions {
+		if methodInsn, ok := insn.(*tree.MethodInsnNode); ok {
+			owners = append(owners, methodInsn.Owner)
+		}
+	}
+	return owners
+}
+
+func TestClassModelChangeSuperclass(t *testing.T) {
+	model := modelClass(t)
+	changed, err := tree.Transform(model, tree.ChangeSuperclass{SuperName: "C"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.GetSuperName() != "C" {
+		t.Errorf("unexpected super class %s", changed.GetSuperName())
+	}
+	// Only the super constructor call is changed, not the creation of the new B instance.
+	if owners := constructorCalls(changed); len(owners) != 2 || owners[0] != "C" || owners[1] != "B" {
+		t.Errorf("unexpected constructor calls %v", owners)
+	}
+	if owners := constructorCalls(model); owners[0] != "B" || model.GetSuperName() != "B" {
+		t.Errorf("the original model was modified %v", owners)
+	}
+}
+
+func TestClassModelRenameField(t *testing.T) {
+	model := modelClass(t)
+	renamed, err := tree.Transform(model, tree.RenameField{Name: "x", Descriptor: "I", NewName: "y2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := renamed.GetFields()
+	if fields[0].Name != "y2" || fields[1].Name != "x" || model.GetFields()[0].Name != "x" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	var fieldInsn *tree.FieldInsnNode
+	for _, insn := range renamed.GetMethod("<init>", "()V").Instructions {
+		if insn, ok := insn.(*tree.FieldInsnNode); ok {
+			fieldInsn = insn
+		}
+	}
+	if fieldInsn == nil || fieldInsn.Name != "y2" {
+		t.Errorf("unexpected field instruction %v", fieldInsn)
+	}
+
+	// A field with the same name but another descriptor is not a duplicate.
+	if _, err := tree.Transform(model, tree.RenameField{Name: "x", Descriptor: "J", NewName: "y"}); err != nil {
+		t.Error(err)
+	}
+	for _, edit := range []tree.RenameField{
+		{Name: "x", NewName: "z"},
+		{Name: "y", NewName: "x"},
+		{Name: "z", NewName: "w"},
+	} {
+		if _, err := tree.Transform(model, edit); err == nil {
+			t.Errorf("expected an error for %v", edit)
+		}
+	}
+}
+
+func TestClassModelAddMethod(t *testing.T) {
+	model := modelClass(t)
+	method := tree.NewMethodNode(opcodes.ACC_PUBLIC, "run", "()V", "", nil)
+	method.VisitInsn(opcodes.RETURN)
+	added, err := tree.Transform(model, tree.AddMethod{Method: method})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added.GetMethods()) != 2 || len(model.GetMethods()) != 1 {
+		t.Errorf("unexpected methods %d %d", len(added.GetMethods()), len(model.GetMethods()))
+	}
+	if _, err := tree.Transform(added, tree.AddMethod{Method: method}); err == nil {
+		t.Error("expected an error for a duplicate method")
+	}
+}
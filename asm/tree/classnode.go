@@ -0,0 +1,108 @@
+package tree
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+// FieldNode a node that represents a field.
+type FieldNode struct {
+	Access     int
+	Name       string
+	Descriptor string
+	Signature  string
+	Value      interface{}
+}
+
+// GetConstantValue returns the typed constant value of this field, or nil if it has none.
+func (f *FieldNode) GetConstantValue() *asm.ConstantValue {
+	constantValue, err := asm.NewConstantValue(f.Value)
+	if err != nil {
+		return nil
+	}
+	return constantValue
+}
+
+// ClassNode a node that represents a class. It is a {@link ClassVisitor} which records the header, the fields
+// and the methods it visits (see {@link MethodNode}). Annotations, inner classes, modules and non standard
+// attributes are not recorded.
+type ClassNode struct {
+	helper.ClassVisitor
+	Version    int
+	Access     int
+	Name       string
+	Signature  string
+	SuperName  string
+	Interfaces []string
+	SourceFile string
+	Fields     []*FieldNode
+	Methods    []*MethodNode
+}
+
+// NewClassNode constructs a new, empty {@link ClassNode}.
+func NewClassNode() *ClassNode {
+	return &ClassNode{}
+}
+
+// ReadClassNode returns the {@link ClassNode} of the given class file.
+func ReadClassNode(classFile []byte, parsingOptions int) (*ClassNode, error) {
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return nil, err
+	}
+	classNode := NewClassNode()
+	reader.Accept(classNode, parsingOptions)
+	return classNode, nil
+}
+
+func (c *ClassNode) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	c.Version = version
+	c.Access = access
+	c.Name = name
+	c.Signature = signature
+	c.SuperName = superName
+	c.Interfaces = interfaces
+}
+
+func (c *ClassNode) VisitSource(source, debug string) {
+	c.SourceFile = source
+}
+
+func (c *ClassNode) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	c.Fields = append(c.Fields, &FieldNode{access, name, descriptor, signature, value})
+	return nil
+}
+
+func (c *ClassNode) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	methodNode := NewMethodNode(access, name, descriptor, signature, exceptions)
+	c.Methods = append(c.Methods, methodNode)
+	return methodNode
+}
+
+// GetMethod returns the method with the given name and descriptor, or nil.
+func (c *ClassNode) GetMethod(name, descriptor string) *MethodNode {
+	for _, method := range c.Methods {
+		if method.Name == name && method.Descriptor == descriptor {
+			return method
+		}
+	}
+	return nil
+}
+
+// Accept makes the given class visitor visit this class.
+func (c *ClassNode) Accept(classVisitor asm.ClassVisitor) {
+	classVisitor.Visit(c.Version, c.Access, c.Name, c.Signature, c.SuperName, c.Interfaces)
+	if c.SourceFile != "" {
+		classVisitor.VisitSource(c.SourceFile, "")
+	}
+	for _, field := range c.Fields {
+		fieldVisitor := classVisitor.VisitField(field.Access, field.Name, field.Descriptor, field.Signature, field.Value)
+		if fieldVisitor != nil {
+			fieldVisitor.VisitEnd()
+		}
+	}
+	for _, method := range c.Methods {
+		method.Accept(classVisitor)
+	}
+	classVisitor.VisitEnd()
+}
@@ -0,0 +1,194 @@
+package tree
+
+import "errors"
+
+// InsnList a list of instruction nodes, the type of {@link MethodNode#Instructions}. It is a slice, which can be
+// ranged over and indexed directly, with methods to insert, remove and replace instructions given by reference,
+// and an {@link InsnListIterator} to patch the instructions while iterating over them. The methods panic with
+// an "Illegal Argument" error if a given location is not in the list.
+type InsnList []AbstractInsnNode
+
+// Size returns the number of instructions in this list.
+func (i InsnList) Size() int {
+	return len(i)
+}
+
+// Get returns the instruction whose index is given.
+func (i InsnList) Get(index int) AbstractInsnNode {
+	return i[index]
+}
+
+// GetFirst returns the first instruction of this list, or nil if it is empty.
+func (i InsnList) GetFirst() AbstractInsnNode {
+	if len(i) == 0 {
+		return nil
+	}
+	return i[0]
+}
+
+// GetLast returns the last instruction of this list, or nil if it is empty.
+func (i InsnList) GetLast() AbstractInsnNode {
+	if len(i) == 0 {
+		return nil
+	}
+	return i[len(i)-1]
+}
+
+// IndexOf returns the index of the given instruction in this list, or -1 if it does not contain it.
+func (i InsnList) IndexOf(insn AbstractInsnNode) int {
+	for index, candidate := range i {
+		if candidate == insn {
+			return index
+		}
+	}
+	return -1
+}
+
+// Contains returns whether this list contains the given instruction.
+func (i InsnList) Contains(insn AbstractInsnNode) bool {
+	return i.IndexOf(insn) >= 0
+}
+
+// Add appends the given instructions to the end of this list.
+func (i *InsnList) Add(insns ...AbstractInsnNode) {
+	*i = append(*i, insns...)
+}
+
+// Insert inserts the given instructions at the beginning of this list.
+func (i *InsnList) Insert(insns ...AbstractInsnNode) {
+	i.insertAt(0, insns)
+}
+
+// InsertBefore inserts the given instructions before the location instruction.
+func (i *InsnList) InsertBefore(location AbstractInsnNode, insns ...AbstractInsnNode) {
+	i.insertAt(i.mustIndexOf(location), insns)
+}
+
+// InsertAfter inserts the given instructions after the location instruction.
+func (i *InsnList) InsertAfter(location AbstractInsnNode, insns ...AbstractInsnNode) {
+	i.insertAt(i.mustIndexOf(location)+1, insns)
+}
+
+// Set replaces the location instruction with the given instruction.
+func (i InsnList) Set(location, insn AbstractInsnNode) {
+	i[i.mustIndexOf(location)] = insn
+}
+
+// Remove removes the given instruction from this list.
+func (i *InsnList) Remove(insn AbstractInsnNode) {
+	i.removeAt(i.mustIndexOf(insn))
+}
+
+// Clear removes all the instructions of this list.
+func (i *InsnList) Clear() {
+	*i = nil
+}
+
+// Iterator returns an iterator over the instructions of this list, starting before the instruction whose index
+// is given.
+func (i *InsnList) Iterator(index int) *InsnListIterator {
+	if index < 0 || index > len(*i) {
+		panic(errors.New("Illegal Argument - invalid iterator index"))
+	}
+	return &InsnListIterator{list: i, nextIndex: index, lastIndex: -1}
+}
+
+func (i InsnList) mustIndexOf(insn AbstractInsnNode) int {
+	index := i.IndexOf(insn)
+	if index < 0 {
+		panic(errors.New("Illegal Argument - instruction not in the list"))
+	}
+	return index
+}
+
+func (i *InsnList) insertAt(index int, insns []AbstractInsnNode) {
+	list := append(*i, insns...)
+	copy(list[index+len(insns):], list[index:len(*i)])
+	copy(list[index:], insns)
+	*i = list
+}
+
+func (i *InsnList) removeAt(index int) {
+	list := *i
+	copy(list[index:], list[index+1:])
+	list[len(list)-1] = nil
+	*i = list[:len(list)-1]
+}
+
+// InsnListIterator an iterator over an {@link InsnList}, with a cursor between two instructions, which can
+// remove, replace and add instructions while iterating (like a Java ListIterator).
+type InsnListIterator struct {
+	list *InsnList
+	// nextIndex the index of the instruction returned by {@link Next}.
+	nextIndex int
+	// lastIndex the index of the instruction last returned by {@link Next} or {@link Previous}, or -1 if it
+	// has been removed, or if an instruction has been added since.
+	lastIndex int
+}
+
+// HasNext returns whether there is an instruction after the cursor.
+func (i *InsnListIterator) HasNext() bool {
+	return i.nextIndex < len(*i.list)
+}
+
+// Next returns the instruction after the cursor, and moves the cursor after it.
+func (i *InsnListIterator) Next() AbstractInsnNode {
+	if !i.HasNext() {
+		panic(errors.New("Illegal State - no next instruction"))
+	}
+	i.lastIndex = i.nextIndex
+	i.nextIndex++
+	return (*i.list)[i.lastIndex]
+}
+
+// HasPrevious returns whether there is an instruction before the cursor.
+func (i *InsnListIterator) HasPrevious() bool {
+	return i.nextIndex > 0
+}
+
+// Previous returns the instruction before the cursor, and moves the cursor before it.
+func (i *InsnListIterator) Previous() AbstractInsnNode {
+	if !i.HasPrevious() {
+		panic(errors.New("Illegal State - no previous instruction"))
+	}
+	i.nextIndex--
+	i.lastIndex = i.nextIndex
+	return (*i.list)[i.lastIndex]
+}
+
+// NextIndex returns the index of the instruction after the cursor.
+func (i *InsnListIterator) NextIndex() int {
+	return i.nextIndex
+}
+
+// PreviousIndex returns the index of the instruction before the cursor, or -1.
+func (i *InsnListIterator) PreviousIndex() int {
+	return i.nextIndex - 1
+}
+
+// Remove removes the instruction last returned by {@link Next} or {@link Previous}.
+func (i *InsnListIterator) Remove() {
+	if i.lastIndex < 0 {
+		panic(errors.New("Illegal State - no instruction to remove"))
+	}
+	i.list.removeAt(i.lastIndex)
+	if i.lastIndex < i.nextIndex {
+		i.nextIndex--
+	}
+	i.lastIndex = -1
+}
+
+// Set replaces the instruction last returned by {@link Next} or {@link Previous} with the given instruction.
+func (i *InsnListIterator) Set(insn AbstractInsnNode) {
+	if i.lastIndex < 0 {
+		panic(errors.New("Illegal State - no instruction to replace"))
+	}
+	(*i.list)[i.lastIndex] = insn
+}
+
+// Add inserts the given instruction before the cursor.
+func (i *InsnListIterator) Add(insn AbstractInsnNode) {
+	i.list.insertAt(i.nextIndex, []AbstractInsnNode{insn})
+	i.nextIndex++
+	i.lastIndex = -1
+}
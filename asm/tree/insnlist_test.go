@@ -0,0 +1,51 @@
+package tree_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func checkOpcodes(t *testing.T, insns tree.InsnList, expected ...int) {
+	var actual []int
+	for _, insn := range insns {
+		actual = append(actual, insn.GetOpcode())
+	}
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestInsnList(t *testing.T) {
+	iload := &tree.VarInsnNode{Opcode: opcodes.ILOAD, Var: 0}
+	iadd := &tree.InsnNode{Opcode: opcodes.IADD}
+	ireturn := &tree.InsnNode{Opcode: opcodes.IRETURN}
+	var insns tree.InsnList
+	insns.Add(iload, ireturn)
+	insns.InsertAfter(iload, &tree.InsnNode{Opcode: opcodes.ICONST_1}, iadd)
+	insns.Insert(&tree.InsnNode{Opcode: opcodes.NOP})
+	insns.InsertBefore(ireturn, &tree.InsnNode{Opcode: opcodes.I2L})
+	insns.Set(ireturn, &tree.InsnNode{Opcode: opcodes.LRETURN})
+	insns.Remove(insns.GetFirst())
+	checkOpcodes(t, insns, opcodes.ILOAD, opcodes.ICONST_1, opcodes.IADD, opcodes.I2L, opcodes.LRETURN)
+	if insns.IndexOf(iadd) != 2 || insns.Contains(ireturn) {
+		t.Errorf("unexpected index of IADD %d", insns.IndexOf(iadd))
+	}
+
+	// Replaces ICONST_1 IADD with IINC, and removes I2L.
+	iterator := insns.Iterator(0)
+	for iterator.HasNext() {
+		switch iterator.Next().GetOpcode() {
+		case opcodes.ICONST_1:
+			iterator.Remove()
+			iterator.Next()
+			iterator.Set(&tree.IincInsnNode{Var: 0, Increment: 1})
+			iterator.Add(&tree.VarInsnNode{Opcode: opcodes.ILOAD, Var: 0})
+		case opcodes.I2L:
+			iterator.Remove()
+		}
+	}
+	checkOpcodes(t, insns, opcodes.ILOAD, opcodes.IINC, opcodes.ILOAD, opcodes.LRETURN)
+}
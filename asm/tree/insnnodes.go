@@ -0,0 +1,247 @@
+package tree
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// The types of the instruction nodes (see {@link AbstractInsnNode#GetType}).
+const (
+	INSN                = 0
+	INT_INSN            = 1
+	VAR_INSN            = 2
+	TYPE_INSN           = 3
+	FIELD_INSN          = 4
+	METHOD_INSN         = 5
+	INVOKE_DYNAMIC_INSN = 6
+	JUMP_INSN           = 7
+	LABEL               = 8
+	LDC_INSN            = 9
+	IINC_INSN           = 10
+	TABLESWITCH_INSN    = 11
+	LOOKUPSWITCH_INSN   = 12
+	MULTIANEWARRAY_INSN = 13
+	FRAME               = 14
+	LINE                = 15
+)
+
+// AbstractInsnNode a node that represents a bytecode instruction. The labels, stack map frames and line
+// numbers are represented with pseudo instruction nodes, whose opcode is -1.
+type AbstractInsnNode interface {
+	// GetOpcode returns the opcode of this instruction, or -1 for pseudo instructions.
+	GetOpcode() int
+	// GetType returns the type of this instruction node (see {@link INSN} to {@link LINE}).
+	GetType() int
+	// Accept makes the given method visitor visit this instruction.
+	Accept(methodVisitor asm.MethodVisitor)
+}
+
+// InsnNode a node that represents a zero operand instruction.
+type InsnNode struct {
+	Opcode int
+}
+
+func (i *InsnNode) GetOpcode() int { return i.Opcode }
+func (i *InsnNode) GetType() int   { return INSN }
+func (i *InsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitInsn(i.Opcode)
+}
+
+// IntInsnNode a node that represents an instruction with a single int operand (BIPUSH, SIPUSH or NEWARRAY).
+type IntInsnNode struct {
+	Opcode  int
+	Operand int
+}
+
+func (i *IntInsnNode) GetOpcode() int { return i.Opcode }
+func (i *IntInsnNode) GetType() int   { return INT_INSN }
+func (i *IntInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitIntInsn(i.Opcode, i.Operand)
+}
+
+// VarInsnNode a node that represents a local variable instruction.
+type VarInsnNode struct {
+	Opcode int
+	Var    int
+}
+
+func (v *VarInsnNode) GetOpcode() int { return v.Opcode }
+func (v *VarInsnNode) GetType() int   { return VAR_INSN }
+func (v *VarInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitVarInsn(v.Opcode, v.Var)
+}
+
+// TypeInsnNode a node that represents a type instruction (NEW, ANEWARRAY, CHECKCAST or INSTANCEOF). Type is
+// an internal name.
+type TypeInsnNode struct {
+	Opcode int
+	Type   string
+}
+
+func (t *TypeInsnNode) GetOpcode() int { return t.Opcode }
+func (t *TypeInsnNode) GetType() int   { return TYPE_INSN }
+func (t *TypeInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitTypeInsn(t.Opcode, t.Type)
+}
+
+// FieldInsnNode a node that represents a field instruction.
+type FieldInsnNode struct {
+	Opcode     int
+	Owner      string
+	Name       string
+	Descriptor string
+}
+
+func (f *FieldInsnNode) GetOpcode() int { return f.Opcode }
+func (f *FieldInsnNode) GetType() int   { return FIELD_INSN }
+func (f *FieldInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitFieldInsn(f.Opcode, f.Owner, f.Name, f.Descriptor)
+}
+
+// MethodInsnNode a node that represents a method instruction.
+type MethodInsnNode struct {
+	Opcode      int
+	Owner       string
+	Name        string
+	Descriptor  string
+	IsInterface bool
+}
+
+func (m *MethodInsnNode) GetOpcode() int { return m.Opcode }
+func (m *MethodInsnNode) GetType() int   { return METHOD_INSN }
+func (m *MethodInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitMethodInsnB(m.Opcode, m.Owner, m.Name, m.Descriptor, m.IsInterface)
+}
+
+// InvokeDynamicInsnNode a node that represents an invokedynamic instruction.
+type InvokeDynamicInsnNode struct {
+	Name                     string
+	Descriptor               string
+	BootstrapMethodHandle    *asm.Handle
+	BootstrapMethodArguments []interface{}
+}
+
+func (i *InvokeDynamicInsnNode) GetOpcode() int { return opcodes.INVOKEDYNAMIC }
+func (i *InvokeDynamicInsnNode) GetType() int   { return INVOKE_DYNAMIC_INSN }
+func (i *InvokeDynamicInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitInvokeDynamicInsn(i.Name, i.Descriptor, i.BootstrapMethodHandle, i.BootstrapMethodArguments...)
+}
+
+// JumpInsnNode a node that represents a jump instruction.
+type JumpInsnNode struct {
+	Opcode int
+	Label  *LabelNode
+}
+
+func (j *JumpInsnNode) GetOpcode() int { return j.Opcode }
+func (j *JumpInsnNode) GetType() int   { return JUMP_INSN }
+func (j *JumpInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitJumpInsn(j.Opcode, j.Label.Label)
+}
+
+// LabelNode a pseudo instruction node that marks the position of a {@link Label}.
+type LabelNode struct {
+	Label *asm.Label
+}
+
+func (l *LabelNode) GetOpcode() int { return -1 }
+func (l *LabelNode) GetType() int   { return LABEL }
+func (l *LabelNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitLabel(l.Label)
+}
+
+// LdcInsnNode a node that represents an LDC instruction.
+type LdcInsnNode struct {
+	Value interface{}
+}
+
+func (l *LdcInsnNode) GetOpcode() int { return opcodes.LDC }
+func (l *LdcInsnNode) GetType() int   { return LDC_INSN }
+func (l *LdcInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitLdcInsn(l.Value)
+}
+
+// IincInsnNode a node that represents an IINC instruction.
+type IincInsnNode struct {
+	Var       int
+	Increment int
+}
+
+func (i *IincInsnNode) GetOpcode() int { return opcodes.IINC }
+func (i *IincInsnNode) GetType() int   { return IINC_INSN }
+func (i *IincInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitIincInsn(i.Var, i.Increment)
+}
+
+// TableSwitchInsnNode a node that represents a TABLESWITCH instruction.
+type TableSwitchInsnNode struct {
+	Min    int
+	Max    int
+	Dflt   *LabelNode
+	Labels []*LabelNode
+}
+
+func (t *TableSwitchInsnNode) GetOpcode() int { return opcodes.TABLESWITCH }
+func (t *TableSwitchInsnNode) GetType() int   { return TABLESWITCH_INSN }
+func (t *TableSwitchInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	labels := make([]*asm.Label, len(t.Labels))
+	for i, label := range t.Labels {
+		labels[i] = label.Label
+	}
+	methodVisitor.VisitTableSwitchInsn(t.Min, t.Max, t.Dflt.Label, labels...)
+}
+
+// LookupSwitchInsnNode a node that represents a LOOKUPSWITCH instruction.
+type LookupSwitchInsnNode struct {
+	Dflt   *LabelNode
+	Keys   []int
+	Labels []*LabelNode
+}
+
+func (l *LookupSwitchInsnNode) GetOpcode() int { return opcodes.LOOKUPSWITCH }
+func (l *LookupSwitchInsnNode) GetType() int   { return LOOKUPSWITCH_INSN }
+func (l *LookupSwitchInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	labels := make([]*asm.Label, len(l.Labels))
+	for i, label := range l.Labels {
+		labels[i] = label.Label
+	}
+	methodVisitor.VisitLookupSwitchInsn(l.Dflt.Label, l.Keys, labels)
+}
+
+// MultiANewArrayInsnNode a node that represents a MULTIANEWARRAY instruction.
+type MultiANewArrayInsnNode struct {
+	Descriptor    string
+	NumDimensions int
+}
+
+func (m *MultiANewArrayInsnNode) GetOpcode() int { return opcodes.MULTIANEWARRAY }
+func (m *MultiANewArrayInsnNode) GetType() int   { return MULTIANEWARRAY_INSN }
+func (m *MultiANewArrayInsnNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitMultiANewArrayInsn(m.Descriptor, m.NumDimensions)
+}
+
+// FrameNode a pseudo instruction node that represents a stack map frame, in the format given to
+// {@link MethodVisitor#VisitFrame}.
+type FrameNode struct {
+	Type  int
+	Local []interface{}
+	Stack []interface{}
+}
+
+func (f *FrameNode) GetOpcode() int { return -1 }
+func (f *FrameNode) GetType() int   { return FRAME }
+func (f *FrameNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitFrame(f.Type, len(f.Local), f.Local, len(f.Stack), f.Stack)
+}
+
+// LineNumberNode a pseudo instruction node that represents a line number declaration.
+type LineNumberNode struct {
+	Line  int
+	Start *LabelNode
+}
+
+func (l *LineNumberNode) GetOpcode() int { return -1 }
+func (l *LineNumberNode) GetType() int   { return LINE }
+func (l *LineNumberNode) Accept(methodVisitor asm.MethodVisitor) {
+	methodVisitor.VisitLineNumber(l.Line, l.Start.Label)
+}
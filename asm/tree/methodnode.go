@@ -0,0 +1,262 @@
+package tree
+
+import (
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// TryCatchBlockNode a node that represents a try catch block. Type is the internal name of the caught
+// exceptions, or "" for a "finally" block.
+type TryCatchBlockNode struct {
+	Start   *LabelNode
+	End     *LabelNode
+	Handler *LabelNode
+	Type    string
+}
+
+// ParameterNode a node that represents a parameter of a method.
+type ParameterNode struct {
+	Name   string
+	Access int
+}
+
+// LocalVariableNode a node that represents a local variable declaration.
+type LocalVariableNode struct {
+	Name       string
+	Descriptor string
+	Signature  string
+	Start      *LabelNode
+	End        *LabelNode
+	Index      int
+}
+
+// MethodNode a node that represents a method. It is a {@link MethodVisitor} which records the instructions,
+// try catch blocks, local variables and maxs it visits. Annotations and non standard attributes are not
+// recorded.
+type MethodNode struct {
+	Access         int
+	Name           string
+	Descriptor     string
+	Signature      string
+	Exceptions     []string
+	Parameters     []*ParameterNode
+	Instructions   InsnList
+	TryCatchBlocks []*TryCatchBlockNode
+	LocalVariables []*LocalVariableNode
+	MaxStack       int
+	MaxLocals      int
+	labelNodes     map[*asm.Label]*LabelNode
+}
+
+// NewMethodNode constructs a new, empty {@link MethodNode}.
+func NewMethodNode(access int, name, descriptor, signature string, exceptions []string) *MethodNode {
+	return &MethodNode{
+		Access:     access,
+		Name:       name,
+		Descriptor: descriptor,
+		Signature:  signature,
+		Exceptions: exceptions,
+		labelNodes: make(map[*asm.Label]*LabelNode),
+	}
+}
+
+// GetLabelNode returns the {@link LabelNode} corresponding to the given label, creating it if necessary.
+func (m *MethodNode) GetLabelNode(label *asm.Label) *LabelNode {
+	labelNode, ok := m.labelNodes[label]
+	if !ok {
+		labelNode = &LabelNode{Label: label}
+		m.labelNodes[label] = labelNode
+	}
+	return labelNode
+}
+
+func (m *MethodNode) getLabelNodes(labels []*asm.Label) []*LabelNode {
+	labelNodes := make([]*LabelNode, len(labels))
+	for i, label := range labels {
+		labelNodes[i] = m.GetLabelNode(label)
+	}
+	return labelNodes
+}
+
+func (m *MethodNode) add(insn AbstractInsnNode) {
+	m.Instructions = append(m.Instructions, insn)
+}
+
+func (m *MethodNode) VisitParameter(name string, access int) {
+	m.Parameters = append(m.Parameters, &ParameterNode{Name: name, Access: access})
+}
+
+func (m *MethodNode) VisitAnnotationDefault() asm.AnnotationVisitor {
+	return nil
+}
+
+func (m *MethodNode) VisitAnnotation(descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (m *MethodNode) VisitTypeAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (m *MethodNode) VisitAnnotableParameterCount(parameterCount int, visible bool) {
+}
+
+func (m *MethodNode) VisitParameterAnnotation(parameter int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (m *MethodNode) VisitAttribute(attribute *asm.Attribute) {
+}
+
+func (m *MethodNode) VisitCode() {
+}
+
+func (m *MethodNode) VisitFrame(typed, nLocal int, local interface{}, nStack int, stack interface{}) {
+	m.add(&FrameNode{Type: typed, Local: frameTypes(nLocal, local), Stack: frameTypes(nStack, stack)})
+}
+
+func frameTypes(n int, types interface{}) []interface{} {
+	values, _ := types.([]interface{})
+	if n > len(values) {
+		n = len(values)
+	}
+	return append([]interface{}(nil), values[:n]...)
+}
+
+func (m *MethodNode) VisitInsn(opcode int) {
+	m.add(&InsnNode{Opcode: opcode})
+}
+
+func (m *MethodNode) VisitIntInsn(opcode, operand int) {
+	m.add(&IntInsnNode{Opcode: opcode, Operand: operand})
+}
+
+func (m *MethodNode) VisitVarInsn(opcode, vard int) {
+	m.add(&VarInsnNode{Opcode: opcode, Var: vard})
+}
+
+func (m *MethodNode) VisitTypeInsn(opcode int, typed string) {
+	m.add(&TypeInsnNode{Opcode: opcode, Type: typed})
+}
+
+func (m *MethodNode) VisitFieldInsn(opcode int, owner, name, descriptor string) {
+	m.add(&FieldInsnNode{Opcode: opcode, Owner: owner, Name: name, Descriptor: descriptor})
+}
+
+func (m *MethodNode) VisitMethodInsn(opcode int, owner, name, descriptor string) {
+	m.VisitMethodInsnB(opcode, owner, name, descriptor, opcode == opcodes.INVOKEINTERFACE)
+}
+
+func (m *MethodNode) VisitMethodInsnB(opcode int, owner, name, descriptor string, isInterface bool) {
+	m.add(&MethodInsnNode{Opcode: opcode, Owner: owner, Name: name, Descriptor: descriptor, IsInterface: isInterface})
+}
+
+func (m *MethodNode) VisitInvokeDynamicInsn(name, descriptor string, bootstrapMethodHande *asm.Handle, bootstrapMethodArguments ...interface{}) {
+	m.add(&InvokeDynamicInsnNode{
+		Name:                     name,
+		Descriptor:               descriptor,
+		BootstrapMethodHandle:    bootstrapMethodHande,
+		BootstrapMethodArguments: bootstrapMethodArguments,
+	})
+}
+
+func (m *MethodNode) VisitJumpInsn(opcode int, label *asm.Label) {
+	m.add(&JumpInsnNode{Opcode: opcode, Label: m.GetLabelNode(label)})
+}
+
+func (m *MethodNode) VisitLabel(label *asm.Label) {
+	m.add(m.GetLabelNode(label))
+}
+
+func (m *MethodNode) VisitLdcInsn(value interface{}) {
+	m.add(&LdcInsnNode{Value: value})
+}
+
+func (m *MethodNode) VisitIincInsn(vard, increment int) {
+	m.add(&IincInsnNode{Var: vard, Increment: increment})
+}
+
+func (m *MethodNode) VisitTableSwitchInsn(min, max int, dflt *asm.Label, labels ...*asm.Label) {
+	m.add(&TableSwitchInsnNode{Min: min, Max: max, Dflt: m.GetLabelNode(dflt), Labels: m.getLabelNodes(labels)})
+}
+
+func (m *MethodNode) VisitLookupSwitchInsn(dflt *asm.Label, keys []int, labels []*asm.Label) {
+	m.add(&LookupSwitchInsnNode{Dflt: m.GetLabelNode(dflt), Keys: keys, Labels: m.getLabelNodes(labels)})
+}
+
+func (m *MethodNode) VisitMultiANewArrayInsn(descriptor string, numDimensions int) {
+	m.add(&MultiANewArrayInsnNode{Descriptor: descriptor, NumDimensions: numDimensions})
+}
+
+func (m *MethodNode) VisitInsnAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (m *MethodNode) VisitTryCatchBlock(start, end, handler *asm.Label, typed string) {
+	m.TryCatchBlocks = append(m.TryCatchBlocks, &TryCatchBlockNode{
+		Start:   m.GetLabelNode(start),
+		End:     m.GetLabelNode(end),
+		Handler: m.GetLabelNode(handler),
+		Type:    typed,
+	})
+}
+
+func (m *MethodNode) VisitTryCatchAnnotation(typeRef int, typePath *asm.TypePath, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (m *MethodNode) VisitLocalVariable(name, descriptor, signature string, start, end *asm.Label, index int) {
+	m.LocalVariables = append(m.LocalVariables, &LocalVariableNode{
+		Name:       name,
+		Descriptor: descriptor,
+		Signature:  signature,
+		Start:      m.GetLabelNode(start),
+		End:        m.GetLabelNode(end),
+		Index:      index,
+	})
+}
+
+func (m *MethodNode) VisitLocalVariableAnnotation(typeRef int, typePath *asm.TypePath, start, end []*asm.Label, index []int, descriptor string, visible bool) asm.AnnotationVisitor {
+	return nil
+}
+
+func (m *MethodNode) VisitLineNumber(line int, start *asm.Label) {
+	m.add(&LineNumberNode{Line: line, Start: m.GetLabelNode(start)})
+}
+
+func (m *MethodNode) VisitMaxs(maxStack int, maxLocals int) {
+	m.MaxStack = maxStack
+	m.MaxLocals = maxLocals
+}
+
+func (m *MethodNode) VisitEnd() {
+}
+
+// Accept makes the given class visitor visit this method.
+func (m *MethodNode) Accept(classVisitor asm.ClassVisitor) {
+	methodVisitor := classVisitor.VisitMethod(m.Access, m.Name, m.Descriptor, m.Signature, m.Exceptions)
+	if methodVisitor != nil {
+		m.AcceptMethod(methodVisitor)
+	}
+}
+
+// AcceptMethod makes the given method visitor visit this method.
+func (m *MethodNode) AcceptMethod(methodVisitor asm.MethodVisitor) {
+	for _, parameter := range m.Parameters {
+		methodVisitor.VisitParameter(parameter.Name, parameter.Access)
+	}
+	if len(m.Instructions) > 0 {
+		methodVisitor.VisitCode()
+		for _, tryCatchBlock := range m.TryCatchBlocks {
+			methodVisitor.VisitTryCatchBlock(tryCatchBlock.Start.Label, tryCatchBlock.End.Label, tryCatchBlock.Handler.Label, tryCatchBlock.Type)
+		}
+		for _, insn := range m.Instructions {
+			insn.Accept(methodVisitor)
+		}
+		for _, localVariable := range m.LocalVariables {
+			methodVisitor.VisitLocalVariable(localVariable.Name, localVariable.Descriptor, localVariable.Signature, localVariable.Start.Label, localVariable.End.Label, localVariable.Index)
+		}
+		methodVisitor.VisitMaxs(m.MaxStack, m.MaxLocals)
+	}
+	methodVisitor.VisitEnd()
+}
@@ -0,0 +1,362 @@
+package tree
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+)
+
+// GetLabelIDs returns the IDs of the labels of the given method, 0, 1, ... in the order of their declaration in
+// its instructions. Unlike the label addresses, these IDs are stable from run to run, and are preserved by the
+// changes which do not add or remove labels, so they can be used to diff two versions of a method.
+func GetLabelIDs(method *MethodNode) map[*LabelNode]int {
+	labelIDs := make(map[*LabelNode]int)
+	for _, insn := range method.Instructions {
+		if label, ok := insn.(*LabelNode); ok {
+			labelIDs[label] = len(labelIDs)
+		}
+	}
+	return labelIDs
+}
+
+// GetLabelNames returns the names of the labels of the given method, L0, L1, ... (see {@link GetLabelIDs}).
+func GetLabelNames(method *MethodNode) map[*LabelNode]string {
+	labelNames := make(map[*LabelNode]string)
+	for label, id := range GetLabelIDs(method) {
+		labelNames[label] = "L" + strconv.Itoa(id)
+	}
+	return labelNames
+}
+
+// InsnToString returns a textual representation of the given instruction, in the format of the Java ASM
+// Textifier (e.g. "INVOKEVIRTUAL java/lang/Object.toString ()Ljava/lang/String;"). The labels are
+// represented with the given names (see {@link GetLabelNames}).
+func InsnToString(insn AbstractInsnNode, labelNames map[*LabelNode]string) string {
+	labelName := func(label *LabelNode) string {
+		if name, ok := labelNames[label]; ok {
+			return name
+		}
+		return "L?"
+	}
+	name := ""
+	if opcode := insn.GetOpcode(); opcode >= 0 && opcode < len(opcodes.NAMES) {
+		name = opcodes.NAMES[opcode]
+	}
+	switch insn := insn.(type) {
+	case *LabelNode:
+		return labelName(insn) + ":"
+	case *LineNumberNode:
+		return "LINENUMBER " + strconv.Itoa(insn.Line) + " " + labelName(insn.Start)
+	case *FrameNode:
+		return "FRAME " + frameTypeToString(insn.Type) + " " + frameValuesToString(insn.Local, labelNames) +
+			" " + frameValuesToString(insn.Stack, labelNames)
+	case *InsnNode:
+		return name
+	case *IntInsnNode:
+		return name + " " + strconv.Itoa(insn.Operand)
+	case *VarInsnNode:
+		return name + " " + strconv.Itoa(insn.Var)
+	case *TypeInsnNode:
+		return name + " " + insn.Type
+	case *FieldInsnNode:
+		return name + " " + insn.Owner + "." + insn.Name + " : " + insn.Descriptor
+	case *MethodInsnNode:
+		s := name + " " + insn.Owner + "." + insn.Name + " " + insn.Descriptor
+		if insn.IsInterface && insn.Opcode != opcodes.INVOKEINTERFACE {
+			s += " (itf)"
+		}
+		return s
+	case *InvokeDynamicInsnNode:
+		arguments := make([]string, len(insn.BootstrapMethodArguments))
+		for i, argument := range insn.BootstrapMethodArguments {
+			arguments[i] = constantToString(argument)
+		}
+		bootstrapMethod := ""
+		if insn.BootstrapMethodHandle != nil {
+			bootstrapMethod = insn.BootstrapMethodHandle.String()
+		}
+		return name + " " + insn.Name + insn.Descriptor + " [" + bootstrapMethod + ", " + strings.Join(arguments, ", ") + "]"
+	case *JumpInsnNode:
+		return name + " " + labelName(insn.Label)
+	case *LdcInsnNode:
+		return name + " " + constantToString(insn.Value)
+	case *IincInsnNode:
+		return name + " " + strconv.Itoa(insn.Var) + " " + strconv.Itoa(insn.Increment)
+	case *TableSwitchInsnNode:
+		s := name
+		for i, label := range insn.Labels {
+			s += " " + strconv.Itoa(insn.Min+i) + ": " + labelName(label)
+		}
+		return s + " default: " + labelName(insn.Dflt)
+	case *LookupSwitchInsnNode:
+		s := name
+		for i, label := range insn.Labels {
+			s += " " + strconv.Itoa(insn.Keys[i]) + ": " + labelName(label)
+		}
+		return s + " default: " + labelName(insn.Dflt)
+	case *MultiANewArrayInsnNode:
+		return name + " " + insn.Descriptor + " " + strconv.Itoa(insn.NumDimensions)
+	}
+	return name
+}
+
+// constantToString returns a textual representation of the given LDC or bootstrap method argument constant.
+func constantToString(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return strconv.Quote(value)
+	case *asm.Type:
+		return value.GetDescriptor() + ".class"
+	case int64:
+		return strconv.FormatInt(value, 10) + "L"
+	case float32:
+		return strconv.FormatFloat(float64(value), 'g', -1, 32) + "F"
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64) + "D"
+	}
+	return fmt.Sprint(value)
+}
+
+// frameTypeToString returns the name of the given kind of stack map frame.
+func frameTypeToString(typed int) string {
+	switch typed {
+	case opcodes.F_NEW:
+		return "NEW"
+	case opcodes.F_FULL:
+		return "FULL"
+	case opcodes.F_APPEND:
+		return "APPEND"
+	case opcodes.F_CHOP:
+		return "CHOP"
+	case opcodes.F_SAME:
+		return "SAME"
+	case opcodes.F_SAME1:
+		return "SAME1"
+	}
+	return strconv.Itoa(typed)
+}
+
+// frameValuesToString returns a textual representation of the given stack map frame types.
+func frameValuesToString(values []interface{}, labelNames map[*LabelNode]string) string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		switch value := value.(type) {
+		case string:
+			names[i] = value
+		case *asm.Label:
+			// An uninitialized value, represented by the label of its NEW instruction.
+			names[i] = "L?"
+			for label, name := range labelNames {
+				if label.Label == value {
+					names[i] = name
+				}
+			}
+		case int:
+			switch value {
+			case opcodes.TOP:
+				names[i] = "T"
+			case opcodes.INTEGER:
+				names[i] = "I"
+			case opcodes.FLOAT:
+				names[i] = "F"
+			case opcodes.DOUBLE:
+				names[i] = "D"
+			case opcodes.LONG:
+				names[i] = "J"
+			case opcodes.NULL:
+				names[i] = "N"
+			case opcodes.UNINITIALIZED_THIS:
+				names[i] = "UNINITIALIZED_THIS"
+			}
+		default:
+			names[i] = fmt.Sprint(value)
+		}
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// Verbosity levels of a {@link Textifier}, each one including the previous ones.
+const (
+	// TEXTIFY_PUBLIC_API the class header and its public and protected fields and methods.
+	TEXTIFY_PUBLIC_API = iota
+	// TEXTIFY_CODE all the fields and methods, with the instructions and try catch blocks of the methods.
+	TEXTIFY_CODE
+	// TEXTIFY_FRAMES the stack map frames.
+	TEXTIFY_FRAMES
+	// TEXTIFY_DEBUG the line numbers and the local variables.
+	TEXTIFY_DEBUG
+)
+
+// Textifier a {@link ClassVisitor} that writes a textual representation of the classes it visits to a writer,
+// with the given verbosity: the class header, the fields, and the instructions of the methods (see {@link
+// InsnToString}). The text is streamed: only the method being visited is kept in memory, so that very large
+// classes or jars can be dumped with a bounded memory usage. The first write error stops the output, and is
+// returned by GetError.
+type Textifier struct {
+	helper.ClassVisitor
+	writer    io.Writer
+	verbosity int
+	err       error
+}
+
+// NewTextifier constructs a new {@link Textifier} with one of the {@link TEXTIFY_PUBLIC_API} to {@link
+// TEXTIFY_DEBUG} verbosity levels.
+func NewTextifier(writer io.Writer, verbosity int) *Textifier {
+	return &Textifier{writer: writer, verbosity: verbosity}
+}
+
+// GetParsingOptions returns the options with which the classes should be read for the given verbosity level, so
+// that the class reader skips the parts of the classes which are not written.
+func GetParsingOptions(verbosity int) int {
+	switch verbosity {
+	case TEXTIFY_PUBLIC_API:
+		return asm.SKIP_CODE | asm.SKIP_DEBUG | asm.SKIP_FRAMES
+	case TEXTIFY_CODE:
+		return asm.SKIP_DEBUG | asm.SKIP_FRAMES
+	case TEXTIFY_FRAMES:
+		return asm.SKIP_DEBUG | asm.EXPAND_FRAMS
+	}
+	return asm.EXPAND_FRAMS
+}
+
+// GetError returns the first error returned by the writer, or nil.
+func (t *Textifier) GetError() error {
+	return t.err
+}
+
+// write writes the given strings, unless a previous write failed.
+func (t *Textifier) write(values ...string) {
+	for _, s := range values {
+		if t.err == nil {
+			_, t.err = io.WriteString(t.writer, s)
+		}
+	}
+}
+
+// isVisible returns whether a member with the given access flags is written.
+func (t *Textifier) isVisible(access int) bool {
+	return t.verbosity > TEXTIFY_PUBLIC_API || (access&(opcodes.ACC_PUBLIC|opcodes.ACC_PROTECTED)) != 0
+}
+
+func (t *Textifier) Visit(version, access int, name, signature, superName string, interfaces []string) {
+	t.write("class ", name)
+	if superName != "" {
+		t.write(" extends ", superName)
+	}
+	if len(interfaces) > 0 {
+		t.write(" implements ", strings.Join(interfaces, ", "))
+	}
+	t.write(" // access 0x", strconv.FormatInt(int64(access), 16), "\n")
+}
+
+func (t *Textifier) VisitField(access int, name, descriptor, signature string, value interface{}) asm.FieldVisitor {
+	if t.isVisible(access) {
+		t.write("\n  field ", name, " ", descriptor)
+		if value != nil {
+			t.write(" = ", constantToString(value))
+		}
+		t.write(" // access 0x", strconv.FormatInt(int64(access), 16), "\n")
+	}
+	return nil
+}
+
+func (t *Textifier) VisitMethod(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
+	if !t.isVisible(access) {
+		return nil
+	}
+	t.write("\n  method ", name, descriptor, " // access 0x", strconv.FormatInt(int64(access), 16), "\n")
+	if t.verbosity == TEXTIFY_PUBLIC_API {
+		return nil
+	}
+	return &textifierMethod{MethodNode: NewMethodNode(access, name, descriptor, signature, exceptions), textifier: t}
+}
+
+// textifierMethod records the method being visited by a {@link Textifier}, and writes it at its end.
+type textifierMethod struct {
+	*MethodNode
+	textifier *Textifier
+}
+
+func (t *textifierMethod) VisitEnd() {
+	textifier, method := t.textifier, t.MethodNode
+	labelNames := GetLabelNames(method)
+	for _, insn := range method.Instructions {
+		switch {
+		case insn.GetType() == LABEL:
+			textifier.write("   ", InsnToString(insn, labelNames), "\n")
+		case insn.GetType() == FRAME && textifier.verbosity < TEXTIFY_FRAMES:
+			// Frames are only written with the TEXTIFY_FRAMES verbosity, and line numbers with TEXTIFY_DEBUG.
+		case insn.GetType() == LINE && textifier.verbosity < TEXTIFY_DEBUG:
+		default:
+			textifier.write("    ", InsnToString(insn, labelNames), "\n")
+		}
+	}
+	for _, tryCatchBlock := range method.TryCatchBlocks {
+		textifier.write("    TRYCATCHBLOCK ", labelNames[tryCatchBlock.Start], " ", labelNames[tryCatchBlock.End], " ",
+			labelNames[tryCatchBlock.Handler], " ", tryCatchBlock.Type, "\n")
+	}
+	if textifier.verbosity >= TEXTIFY_DEBUG {
+		for _, localVariable := range method.LocalVariables {
+			textifier.write("    LOCALVARIABLE ", localVariable.Name, " ", localVariable.Descriptor, " ",
+				labelNames[localVariable.Start], " ", labelNames[localVariable.End], " ",
+				strconv.Itoa(localVariable.Index), "\n")
+		}
+	}
+	if len(method.Instructions) > 0 {
+		textifier.write("    MAXSTACK = ", strconv.Itoa(method.MaxStack), "\n    MAXLOCALS = ",
+			strconv.Itoa(method.MaxLocals), "\n")
+	}
+}
+
+// TextifyJar writes the textual representation of each class of the given jar (or zip) file to the given writer,
+// with the given verbosity (see {@link Textifier}), in the order of the jar entries. The classes are read one at
+// a time. Each class is preceded by a blank line.
+func TextifyJar(writer io.Writer, path string, verbosity int) error {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer jar.Close()
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || file.FileInfo().IsDir() {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+		reader, err := asm.NewClassReader(classFile)
+		if err != nil {
+			return errors.New(file.Name + ": " + err.Error())
+		}
+		if _, err := io.WriteString(writer, "\n"); err != nil {
+			return err
+		}
+		textifier := NewTextifier(writer, verbosity)
+		reader.Accept(textifier, GetParsingOptions(verbosity))
+		if textifier.GetError() != nil {
+			return textifier.GetError()
+		}
+	}
+	return nil
+}
+
+// Textify returns a textual representation of the given class, with the {@link TEXTIFY_DEBUG} verbosity (see
+// {@link Textifier}).
+func Textify(class *ClassNode) string {
+	var s strings.Builder
+	class.Accept(NewTextifier(&s, TEXTIFY_DEBUG))
+	return s.String()
+}
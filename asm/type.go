@@ -1,7 +1,14 @@
 package asm
 
-import "github.com/leaklessgfy/asm/asm/typed"
+import (
+	"strings"
 
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typed"
+)
+
+// Type a Java field or method type. This class can be used to make it easier to manipulate type and method
+// descriptors.
 type Type struct {
 	sort        int
 	valueBuffer []rune
@@ -9,7 +16,21 @@ type Type struct {
 	valueLength int
 }
 
-func getType(typeDescriptor string) *Type {
+// The primitive types.
+var (
+	VOID_TYPE    = &Type{typed.VOID, typed.PRIMITIVE_DESCRIPTORS, typed.VOID, 1}
+	BOOLEAN_TYPE = &Type{typed.BOOLEAN, typed.PRIMITIVE_DESCRIPTORS, typed.BOOLEAN, 1}
+	CHAR_TYPE    = &Type{typed.CHAR, typed.PRIMITIVE_DESCRIPTORS, typed.CHAR, 1}
+	BYTE_TYPE    = &Type{typed.BYTE, typed.PRIMITIVE_DESCRIPTORS, typed.BYTE, 1}
+	SHORT_TYPE   = &Type{typed.SHORT, typed.PRIMITIVE_DESCRIPTORS, typed.SHORT, 1}
+	INT_TYPE     = &Type{typed.INT, typed.PRIMITIVE_DESCRIPTORS, typed.INT, 1}
+	FLOAT_TYPE   = &Type{typed.FLOAT, typed.PRIMITIVE_DESCRIPTORS, typed.FLOAT, 1}
+	LONG_TYPE    = &Type{typed.LONG, typed.PRIMITIVE_DESCRIPTORS, typed.LONG, 1}
+	DOUBLE_TYPE  = &Type{typed.DOUBLE, typed.PRIMITIVE_DESCRIPTORS, typed.DOUBLE, 1}
+)
+
+// GetType returns the {@link Type} corresponding to the given type descriptor.
+func GetType(typeDescriptor string) *Type {
 	valueBuffer := []rune(typeDescriptor)
 	return getTypeB(valueBuffer, 0, len(valueBuffer))
 }
@@ -47,7 +68,8 @@ func getTypeB(descriptorBuffer []rune, descriptorOffset int, descriptorLength in
 	return nil
 }
 
-func getObjectType(internalName string) *Type {
+// GetObjectType returns the {@link Type} corresponding to the given internal name.
+func GetObjectType(internalName string) *Type {
 	valueBuffer := []rune(internalName)
 	typ := typed.INTERNAL
 	if valueBuffer[0] == '[' {
@@ -61,7 +83,8 @@ func getObjectType(internalName string) *Type {
 	}
 }
 
-func getMethodType(methodDescriptor string) *Type {
+// GetMethodType returns the {@link Type} corresponding to the given method descriptor.
+func GetMethodType(methodDescriptor string) *Type {
 	valueBuffer := []rune(methodDescriptor)
 	return &Type{
 		typed.METHOD,
@@ -70,3 +93,140 @@ func getMethodType(methodDescriptor string) *Type {
 		len(valueBuffer),
 	}
 }
+
+// GetMethodTypeB returns the method {@link Type} corresponding to the given argument and return types.
+func GetMethodTypeB(returnType *Type, argumentTypes ...*Type) *Type {
+	return GetMethodType(GetMethodDescriptor(returnType, argumentTypes...))
+}
+
+// GetMethodDescriptor returns the descriptor corresponding to the given argument and return types.
+func GetMethodDescriptor(returnType *Type, argumentTypes ...*Type) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, argumentType := range argumentTypes {
+		sb.WriteString(argumentType.GetDescriptor())
+	}
+	sb.WriteByte(')')
+	sb.WriteString(returnType.GetDescriptor())
+	return sb.String()
+}
+
+// GetArrayType returns the array {@link Type} with the given element type and number of dimensions.
+func GetArrayType(elementType *Type, dimensions int) *Type {
+	return GetType(strings.Repeat("[", dimensions) + elementType.GetDescriptor())
+}
+
+// GetSort returns the sort of this type (see the constants of the typed package).
+func (t Type) GetSort() int {
+	if t.sort == typed.INTERNAL {
+		return typed.OBJECT
+	}
+	return t.sort
+}
+
+// GetInternalName returns the internal name of the class corresponding to this object or array type. The
+// internal name of a class is its fully qualified name, where '.' are replaced by '/'. For array types,
+// the internal name is the descriptor of the type.
+func (t Type) GetInternalName() string {
+	return string(t.valueBuffer[t.valueOffset : t.valueOffset+t.valueLength])
+}
+
+// GetDescriptor returns the descriptor corresponding to this type.
+func (t Type) GetDescriptor() string {
+	if t.sort == typed.OBJECT {
+		return string(t.valueBuffer[t.valueOffset-1 : t.valueOffset+t.valueLength+1])
+	}
+	if t.sort == typed.INTERNAL {
+		return "L" + t.GetInternalName() + ";"
+	}
+	return t.GetInternalName()
+}
+
+// GetArgumentTypes returns the argument types of methods of this type. This method should only be used for
+// method types.
+func (t Type) GetArgumentTypes() []*Type {
+	argumentTypes := make([]*Type, 0)
+	currentOffset := t.valueOffset + 1
+	for t.valueBuffer[currentOffset] != ')' {
+		length := t.getTypeLength(currentOffset)
+		argumentTypes = append(argumentTypes, getTypeB(t.valueBuffer, currentOffset, length))
+		currentOffset += length
+	}
+	return argumentTypes
+}
+
+// GetReturnType returns the return type of methods of this type. This method should only be used for
+// method types.
+func (t Type) GetReturnType() *Type {
+	currentOffset := t.valueOffset + 1
+	for t.valueBuffer[currentOffset] != ')' {
+		currentOffset += t.getTypeLength(currentOffset)
+	}
+	currentOffset++
+	return getTypeB(t.valueBuffer, currentOffset, t.getTypeLength(currentOffset))
+}
+
+func (t Type) getTypeLength(offset int) int {
+	currentOffset := offset
+	for t.valueBuffer[currentOffset] == '[' {
+		currentOffset++
+	}
+	if t.valueBuffer[currentOffset] == 'L' {
+		for t.valueBuffer[currentOffset] != ';' {
+			currentOffset++
+		}
+	}
+	return currentOffset + 1 - offset
+}
+
+// GetSize returns the size of values of this type. This method must not be used for method types.
+func (t Type) GetSize() int {
+	switch t.sort {
+	case typed.VOID:
+		return 0
+	case typed.LONG, typed.DOUBLE:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// GetOpcode returns a JVM instruction opcode adapted to this {@link Type}. This method must not be used
+// for method types. The given opcode must be one of ILOAD, ISTORE, IALOAD, IASTORE, IADD, ISUB, IMUL,
+// IDIV, IREM, INEG, ISHL, ISHR, IUSHR, IAND, IOR, IXOR and IRETURN.
+func (t Type) GetOpcode(opcode int) int {
+	if opcode == opcodes.IALOAD || opcode == opcodes.IASTORE {
+		switch t.sort {
+		case typed.BOOLEAN, typed.BYTE:
+			return opcode + (opcodes.BALOAD - opcodes.IALOAD)
+		case typed.CHAR:
+			return opcode + (opcodes.CALOAD - opcodes.IALOAD)
+		case typed.SHORT:
+			return opcode + (opcodes.SALOAD - opcodes.IALOAD)
+		case typed.FLOAT:
+			return opcode + (opcodes.FALOAD - opcodes.IALOAD)
+		case typed.LONG:
+			return opcode + (opcodes.LALOAD - opcodes.IALOAD)
+		case typed.DOUBLE:
+			return opcode + (opcodes.DALOAD - opcodes.IALOAD)
+		case typed.ARRAY, typed.OBJECT, typed.INTERNAL:
+			return opcode + (opcodes.AALOAD - opcodes.IALOAD)
+		default:
+			return opcode
+		}
+	}
+	switch t.sort {
+	case typed.VOID:
+		return opcodes.RETURN
+	case typed.FLOAT:
+		return opcode + (opcodes.FRETURN - opcodes.IRETURN)
+	case typed.LONG:
+		return opcode + (opcodes.LRETURN - opcodes.IRETURN)
+	case typed.DOUBLE:
+		return opcode + (opcodes.DRETURN - opcodes.IRETURN)
+	case typed.ARRAY, typed.OBJECT, typed.INTERNAL:
+		return opcode + (opcodes.ARETURN - opcodes.IRETURN)
+	default:
+		return opcode
+	}
+}
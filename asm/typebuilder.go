@@ -0,0 +1,106 @@
+package asm
+
+import (
+	"errors"
+	"reflect"
+)
+
+// MethodDescriptorBuilder a builder of method descriptors, which composes the argument and return types of a
+// method instead of assembling its descriptor string by hand. The return type is {@link VOID_TYPE} by default.
+type MethodDescriptorBuilder struct {
+	argumentTypes []*Type
+	returnType    *Type
+}
+
+// NewMethodDescriptorBuilder constructs a new {@link MethodDescriptorBuilder} for a method without arguments,
+// returning void.
+func NewMethodDescriptorBuilder() *MethodDescriptorBuilder {
+	return &MethodDescriptorBuilder{returnType: VOID_TYPE}
+}
+
+// AddArgument appends the given types to the argument types of the method.
+func (m *MethodDescriptorBuilder) AddArgument(argumentTypes ...*Type) *MethodDescriptorBuilder {
+	m.argumentTypes = append(m.argumentTypes, argumentTypes...)
+	return m
+}
+
+// AddObjectArgument appends the object types with the given internal names to the argument types of the method.
+func (m *MethodDescriptorBuilder) AddObjectArgument(internalNames ...string) *MethodDescriptorBuilder {
+	for _, internalName := range internalNames {
+		m.argumentTypes = append(m.argumentTypes, GetObjectType(internalName))
+	}
+	return m
+}
+
+// SetReturnType sets the return type of the method.
+func (m *MethodDescriptorBuilder) SetReturnType(returnType *Type) *MethodDescriptorBuilder {
+	m.returnType = returnType
+	return m
+}
+
+// GetDescriptor returns the descriptor of the method.
+func (m *MethodDescriptorBuilder) GetDescriptor() string {
+	return GetMethodDescriptor(m.returnType, m.argumentTypes...)
+}
+
+// GetType returns the method {@link Type} of the method.
+func (m *MethodDescriptorBuilder) GetType() *Type {
+	return GetMethodType(m.GetDescriptor())
+}
+
+// GetTypeFromGoType returns the Java {@link Type} corresponding to the given Go type: bool is boolean, int8 and
+// uint8 are byte, int16 is short, uint16 is char, int and int32 are int, int64 is long, float32 is float, float64
+// is double, string is java.lang.String, and slices and arrays are Java arrays of their element type.
+func GetTypeFromGoType(goType reflect.Type) (*Type, error) {
+	switch goType.Kind() {
+	case reflect.Bool:
+		return BOOLEAN_TYPE, nil
+	case reflect.Int8, reflect.Uint8:
+		return BYTE_TYPE, nil
+	case reflect.Int16:
+		return SHORT_TYPE, nil
+	case reflect.Uint16:
+		return CHAR_TYPE, nil
+	case reflect.Int, reflect.Int32:
+		return INT_TYPE, nil
+	case reflect.Int64:
+		return LONG_TYPE, nil
+	case reflect.Float32:
+		return FLOAT_TYPE, nil
+	case reflect.Float64:
+		return DOUBLE_TYPE, nil
+	case reflect.String:
+		return GetObjectType("java/lang/String"), nil
+	case reflect.Slice, reflect.Array:
+		elementType, err := GetTypeFromGoType(goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return GetArrayType(elementType, 1), nil
+	}
+	return nil, errors.New("Illegal Argument - no Java type for the Go type " + goType.String())
+}
+
+// GetMethodTypeFromGoFunc returns the Java method {@link Type} corresponding to the given Go function type, whose
+// parameters and result are mapped with {@link GetTypeFromGoType}. A function without result returns void.
+func GetMethodTypeFromGoFunc(funcType reflect.Type) (*Type, error) {
+	if funcType.Kind() != reflect.Func || funcType.IsVariadic() || funcType.NumOut() > 1 {
+		return nil, errors.New("Illegal Argument - no Java method type for the Go type " + funcType.String())
+	}
+	builder := NewMethodDescriptorBuilder()
+	for i := 0; i < funcType.NumIn(); i++ {
+		argumentType, err := GetTypeFromGoType(funcType.In(i))
+		if err != nil {
+			return nil, err
+		}
+		builder.AddArgument(argumentType)
+	}
+	if funcType.NumOut() == 1 {
+		returnType, err := GetTypeFromGoType(funcType.Out(0))
+		if err != nil {
+			return nil, err
+		}
+		builder.SetReturnType(returnType)
+	}
+	return builder.GetType(), nil
+}
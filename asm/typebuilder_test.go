@@ -0,0 +1,33 @@
+package asm_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+)
+
+func TestMethodDescriptorBuilder(t *testing.T) {
+	descriptor := asm.NewMethodDescriptorBuilder().
+		AddArgument(asm.INT_TYPE, asm.GetArrayType(asm.LONG_TYPE, 2)).
+		AddObjectArgument("java/lang/String").
+		SetReturnType(asm.BOOLEAN_TYPE).
+		GetDescriptor()
+	if descriptor != "(I[[JLjava/lang/String;)Z" {
+		t.Errorf("unexpected descriptor %s", descriptor)
+	}
+	if asm.GetMethodDescriptor(asm.VOID_TYPE) != "()V" {
+		t.Errorf("unexpected descriptor %s", asm.GetMethodDescriptor(asm.VOID_TYPE))
+	}
+
+	methodType, err := asm.GetMethodTypeFromGoFunc(reflect.TypeOf(func(int, []string, uint16) float64 { return 0 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if methodType.GetDescriptor() != "(I[Ljava/lang/String;C)D" {
+		t.Errorf("unexpected descriptor %s", methodType.GetDescriptor())
+	}
+	if _, err := asm.GetMethodTypeFromGoFunc(reflect.TypeOf(func(map[int]int) {})); err == nil {
+		t.Error("expected an error for a map argument")
+	}
+}
@@ -1,10 +1,40 @@
 package asm
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// The kinds of type path steps (see {@link TypePath#GetStep}).
+const (
+	// ARRAY_ELEMENT a type path step that steps into the element type of an array type.
+	ARRAY_ELEMENT = 0
+	// INNER_TYPE a type path step that steps into the nested type of a class type.
+	INNER_TYPE = 1
+	// WILDCARD_BOUND a type path step that steps into the bound of a wildcard type.
+	WILDCARD_BOUND = 2
+	// TYPE_ARGUMENT a type path step that steps into a type argument of a generic type.
+	TYPE_ARGUMENT = 3
+)
+
+// TypePath the path to a type argument, wildcard bound, array element type, or static inner type within an
+// enclosing type.
 type TypePath struct {
 	typePathContainer []byte
 	typePathOffset    int
 }
 
+// TypePathStep a step of a {@link TypePath}.
+type TypePathStep struct {
+	// Kind {@link ARRAY_ELEMENT}, {@link INNER_TYPE}, {@link WILDCARD_BOUND} or {@link TYPE_ARGUMENT}.
+	Kind int
+	// Argument the index of the type argument for {@link TYPE_ARGUMENT} steps, and 0 otherwise.
+	Argument int
+}
+
+// NewTypePath constructs a new TypePath from the type_path JVMS structure starting at the given offset of the
+// given byte array.
 func NewTypePath(b []byte, offset int) *TypePath {
 	return &TypePath{
 		b,
@@ -12,48 +42,118 @@ func NewTypePath(b []byte, offset int) *TypePath {
 	}
 }
 
-func NewTypePathFromString(typePath string) *TypePath {
-	if typePath == "" || len(typePath) == 0 {
-		return nil
+// NewTypePathFromString converts a type path in string form, in the format used by {@link TypePath#String}, into
+// a TypePath object. Returns nil for an empty string, and an error if a type argument index or the number of steps
+// does not fit in the unsigned byte of the type_path JVMS structure.
+func NewTypePathFromString(typePath string) (*TypePath, error) {
+	if typePath == "" {
+		return nil, nil
+	}
+	output := []byte{0}
+	for i := 0; i < len(typePath); {
+		c := typePath[i]
+		i++
+		switch {
+		case c == '[':
+			output = append(output, ARRAY_ELEMENT, 0)
+		case c == '.':
+			output = append(output, INNER_TYPE, 0)
+		case c == '*':
+			output = append(output, WILDCARD_BOUND, 0)
+		case c >= '0' && c <= '9':
+			typeArg := int(c - '0')
+			for i < len(typePath) && typePath[i] >= '0' && typePath[i] <= '9' {
+				typeArg = typeArg*10 + int(typePath[i]-'0')
+				i++
+				if typeArg > 255 {
+					return nil, errors.New("Illegal Argument - type argument index out of range in type path " + typePath)
+				}
+			}
+			if i < len(typePath) && typePath[i] == ';' {
+				i++
+			}
+			output = append(output, TYPE_ARGUMENT, byte(typeArg))
+		}
 	}
+	if (len(output)-1)/2 > 255 {
+		return nil, errors.New("Illegal Argument - too many steps in type path " + typePath)
+	}
+	output[0] = byte((len(output) - 1) / 2)
+	return &TypePath{output, 0}, nil
+}
+
+// GetLength returns the length of this path, i.e. its number of steps. Steps which are truncated in the
+// underlying byte array are not counted.
+func (t TypePath) GetLength() int {
+	if t.typePathOffset < 0 || t.typePathOffset >= len(t.typePathContainer) {
+		return 0
+	}
+	length := int(t.typePathContainer[t.typePathOffset])
+	return min(length, (len(t.typePathContainer)-t.typePathOffset-1)/2)
+}
 
-	//typePathLength := len(typePath)
-	/*
-			ByteVector output = new ByteVector(typePathLength);
-		    output.putByte(0);
-		    for (int i = 0; i < typePathLength; ) {
-		      char c = typePath.charAt(i++);
-		      if (c == '[') {
-		        output.put11(ARRAY_ELEMENT, 0);
-		      } else if (c == '.') {
-		        output.put11(INNER_TYPE, 0);
-		      } else if (c == '*') {
-		        output.put11(WILDCARD_BOUND, 0);
-		      } else if (c >= '0' && c <= '9') {
-		        int typeArg = c - '0';
-		        while (i < typePathLength && (c = typePath.charAt(i)) >= '0' && c <= '9') {
-		          typeArg = typeArg * 10 + c - '0';
-		          i += 1;
-		        }
-		        if (i < typePathLength && typePath.charAt(i) == ';') {
-		          i += 1;
-		        }
-		        output.put11(TYPE_ARGUMENT, typeArg);
-		      }
-		    }
-		    output.data[0] = (byte) (output.length / 2);
-	*/
-	return &TypePath{}
-}
-
-func (t TypePath) getLength() int {
-	return int(t.typePathContainer[t.typePathOffset])
-}
-
-func (t TypePath) getStep(index int) int {
+// GetStep returns the kind of the given step of this path ({@link ARRAY_ELEMENT}, {@link INNER_TYPE},
+// {@link WILDCARD_BOUND} or {@link TYPE_ARGUMENT}), or -1 if the index is out of bounds.
+func (t TypePath) GetStep(index int) int {
+	if index < 0 || index >= t.GetLength() {
+		return -1
+	}
 	return int(t.typePathContainer[t.typePathOffset+2*index+1])
 }
 
-func (t TypePath) getStepArgument(index int) int {
+// GetStepArgument returns the index of the type argument that the given step is stepping into. This method
+// should only be used for steps whose kind is {@link TYPE_ARGUMENT}. Returns -1 if the index is out of bounds.
+func (t TypePath) GetStepArgument(index int) int {
+	if index < 0 || index >= t.GetLength() {
+		return -1
+	}
 	return int(t.typePathContainer[t.typePathOffset+2*index+2])
 }
+
+// Steps returns the steps of this path.
+func (t TypePath) Steps() []TypePathStep {
+	steps := make([]TypePathStep, t.GetLength())
+	for i := range steps {
+		steps[i] = TypePathStep{t.GetStep(i), t.GetStepArgument(i)}
+	}
+	return steps
+}
+
+// String returns a string representation of this type path. ARRAY_ELEMENT steps are represented with '[',
+// INNER_TYPE steps with '.', WILDCARD_BOUND steps with '*' and TYPE_ARGUMENT steps with their type argument
+// index in decimal form followed by ';'.
+func (t TypePath) String() string {
+	var sb strings.Builder
+	for _, step := range t.Steps() {
+		switch step.Kind {
+		case ARRAY_ELEMENT:
+			sb.WriteByte('[')
+		case INNER_TYPE:
+			sb.WriteByte('.')
+		case WILDCARD_BOUND:
+			sb.WriteByte('*')
+		case TYPE_ARGUMENT:
+			sb.WriteString(strconv.Itoa(step.Argument))
+			sb.WriteByte(';')
+		}
+	}
+	return sb.String()
+}
+
+// putTypePath puts the type_path JVMS structure corresponding to the given TypePath into the given vector. A nil
+// type path is put as an empty path, as well as a truncated one, for which an error is returned.
+func putTypePath(typePath *TypePath, output *ByteVector) error {
+	if typePath == nil {
+		output.PutByte(0)
+		return nil
+	}
+	offset := typePath.typePathOffset
+	if offset < 0 || offset >= len(typePath.typePathContainer) ||
+		len(typePath.typePathContainer)-offset < int(typePath.typePathContainer[offset])*2+1 {
+		output.PutByte(0)
+		return errors.New("Illegal Argument - truncated type path")
+	}
+	length := int(typePath.typePathContainer[offset])*2 + 1
+	output.PutByteArray(typePath.typePathContainer, offset, length)
+	return nil
+}
@@ -0,0 +1,71 @@
+package asm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/opcodes"
+	"github.com/leaklessgfy/asm/asm/typereference"
+)
+
+func TestTypePathFromString(t *testing.T) {
+	typePath, err := asm.NewTypePathFromString("[.*12;0;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typePath.String() != "[.*12;0;" {
+		t.Errorf("expected [.*12;0;, got %s", typePath.String())
+	}
+	steps := typePath.Steps()
+	if len(steps) != 5 || steps[3] != (asm.TypePathStep{Kind: asm.TYPE_ARGUMENT, Argument: 12}) {
+		t.Errorf("unexpected steps %v", steps)
+	}
+}
+
+func TestTypePathFromStringOutOfRange(t *testing.T) {
+	if typePath, err := asm.NewTypePathFromString("255;"); err != nil || typePath.GetStepArgument(0) != 255 {
+		t.Errorf("unexpected type path %v %v", typePath, err)
+	}
+	for _, typePath := range []string{"256;", "0;1000;", "99999999999999999999;", strings.Repeat("[", 256)} {
+		if _, err := asm.NewTypePathFromString(typePath); err == nil {
+			t.Errorf("%s: expected an error", typePath)
+		}
+	}
+}
+
+func TestMalformedTypePath(t *testing.T) {
+	// The length says 3 steps, but only one is present.
+	typePath := asm.NewTypePath([]byte{3, asm.ARRAY_ELEMENT, 0, asm.INNER_TYPE}, 0)
+	if typePath.GetLength() != 1 || typePath.GetStep(1) != -1 || typePath.GetStepArgument(5) != -1 {
+		t.Errorf("unexpected steps %v", typePath.Steps())
+	}
+	if asm.NewTypePath([]byte{1}, 4).GetLength() != 0 {
+		t.Error("expected an empty path")
+	}
+}
+
+func TestWriteTruncatedTypePath(t *testing.T) {
+	for _, typePath := range []*asm.TypePath{
+		asm.NewTypePath([]byte{3, asm.ARRAY_ELEMENT, 0, asm.INNER_TYPE}, 0),
+		asm.NewTypePath([]byte{1}, 4),
+	} {
+		symbolTable := asm.NewSymbolTable()
+		asm.NewTypeAnnotationWriter(symbolTable, typereference.FIELD<<24, typePath, "LT;", nil).VisitEnd()
+		if symbolTable.GetError() == nil {
+			t.Errorf("%v: expected an error for a truncated type path", typePath.Steps())
+		}
+
+		methodWriter := asm.NewMethodWriter(asm.NewSymbolTable(), opcodes.ACC_STATIC, "m", "(I)V", "", nil)
+		methodWriter.VisitCode()
+		start, end := &asm.Label{}, &asm.Label{}
+		methodWriter.VisitLabel(start)
+		methodWriter.VisitInsn(opcodes.RETURN)
+		methodWriter.VisitLabel(end)
+		methodWriter.VisitLocalVariableAnnotation(typereference.LOCAL_VARIABLE<<24, typePath,
+			[]*asm.Label{start}, []*asm.Label{end}, []int{0}, "LT;", true).VisitEnd()
+		if methodWriter.GetError() == nil {
+			t.Errorf("%v: expected an error for a truncated local variable type path", typePath.Steps())
+		}
+	}
+}
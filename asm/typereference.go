@@ -1 +1,23 @@
 package asm
+
+import "github.com/leaklessgfy/asm/asm/typereference"
+
+// putTarget puts the target_type and target_info JVMS structures corresponding to the given type reference into
+// the given vector (the sort is in the most significant byte of the type reference, followed by its arguments).
+// The offsets of the instructions and the start, end and index tables of the local variable targets are not put,
+// since they depend on the code of the method.
+func putTarget(targetTypeAndInfo int, output *ByteVector) {
+	switch targetTypeAndInfo >> 24 & 0xFF {
+	case typereference.CLASS_TYPE_PARAMETER, typereference.METHOD_TYPE_PARAMETER, typereference.METHOD_FORMAL_PARAMETER:
+		output.PutShort(targetTypeAndInfo >> 16)
+	case typereference.FIELD, typereference.METHOD_RETURN, typereference.METHOD_RECEIVER:
+		output.PutByte(targetTypeAndInfo >> 24)
+	case typereference.CAST, typereference.CONSTRUCTOR_INVOCATION_TYPE_ARGUMENT, typereference.METHOD_INVOCATION_TYPE_ARGUMENT,
+		typereference.CONSTRUCTOR_REFERENCE_TYPE_ARGUMENT, typereference.METHOD_REFERENCE_TYPE_ARGUMENT:
+		output.PutInt(targetTypeAndInfo)
+	default:
+		// CLASS_EXTENDS, CLASS_TYPE_PARAMETER_BOUND, METHOD_TYPE_PARAMETER_BOUND, THROWS, EXCEPTION_PARAMETER,
+		// INSTANCEOF, NEW, CONSTRUCTOR_REFERENCE and METHOD_REFERENCE.
+		output.put12(targetTypeAndInfo>>24, (targetTypeAndInfo&0xFFFF00)>>8)
+	}
+}
@@ -0,0 +1,40 @@
+// Command add-timing instruments the methods of a class file with a {@link commons.TimingTransformer}, checks
+// the result with {@link analysis.VerifyClass}, and prints the transformed class with {@link tree.Textify}. The
+// class file itself is left unchanged.
+//
+//	add-timing <file.class>
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "Bad usage: add-timing <file.class>")
+		os.Exit(1)
+	}
+	classFile, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	class := tree.NewClassNode()
+	reader.Accept(commons.NewTimingTransformer(class), asm.EXPAND_FRAMS)
+	for _, verifyError := range analysis.VerifyClass(class) {
+		fmt.Fprintln(os.Stderr, verifyError)
+	}
+	fmt.Print(tree.Textify(class))
+}
@@ -0,0 +1,52 @@
+// Command count-instructions prints the number of instructions of each method of a class file, using a
+// {@link helper.MiddlewareClassAdapter} to observe the visitor events without building a tree.
+//
+//	count-instructions <file.class>
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/helper"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "Bad usage: count-instructions <file.class>")
+		os.Exit(1)
+	}
+	classFile, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var methods []string
+	counts := make(map[string]int)
+	reader.Accept(helper.NewMiddlewareClassAdapter(nil, helper.Middleware{
+		Before: func(event *helper.Event) bool {
+			switch {
+			case event.Kind == helper.CLASS_VISIT_METHOD:
+				methods = append(methods, event.Args[1].(string)+event.Args[2].(string))
+			case event.Kind >= helper.METHOD_VISIT_INSN && event.Kind <= helper.METHOD_VISIT_MULTI_ANEW_ARRAY_INSN &&
+				event.Kind != helper.METHOD_VISIT_LABEL:
+				counts[event.Member]++
+			}
+			return true
+		},
+	}), asm.SKIP_DEBUG|asm.SKIP_FRAMES)
+
+	total := 0
+	for _, method := range methods {
+		fmt.Println(method, counts[method])
+		total += counts[method]
+	}
+	fmt.Println("total", total)
+}
@@ -0,0 +1,120 @@
+// Command dump prints the header, the fields and the instructions of the methods of a class file, or of each
+// class of a jar file, with the given verbosity (api, code, frames or debug). The pseudocode verbosity prints the
+// methods as an experimental pseudocode instead (see the decomp package).
+//
+//	dump [-verbosity debug] <file.class|file.jar>
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/decomp"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+var verbosities = map[string]int{
+	"api":    tree.TEXTIFY_PUBLIC_API,
+	"code":   tree.TEXTIFY_CODE,
+	"frames": tree.TEXTIFY_FRAMES,
+	"debug":  tree.TEXTIFY_DEBUG,
+}
+
+func main() {
+	verbosityName := flag.String("verbosity", "debug", "the verbosity of the dump: api, code, frames, debug or pseudocode")
+	flag.Parse()
+	verbosity, ok := verbosities[*verbosityName]
+	if flag.NArg() != 1 || (!ok && *verbosityName != "pseudocode") {
+		fmt.Fprintln(os.Stderr, "Bad usage: dump [-verbosity api|code|frames|debug|pseudocode] <file.class|file.jar>")
+		os.Exit(1)
+	}
+	output := bufio.NewWriter(os.Stdout)
+	var err error
+	if ok {
+		err = dump(output, flag.Arg(0), verbosity)
+	} else {
+		err = dumpPseudocode(output, flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := output.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// dump writes the textual representation of the given class or jar file to the given writer.
+func dump(output *bufio.Writer, path string, verbosity int) error {
+	if !strings.HasSuffix(path, ".class") {
+		return tree.TextifyJar(output, path, verbosity)
+	}
+	classFile, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	reader, err := asm.NewClassReader(classFile)
+	if err != nil {
+		return err
+	}
+	textifier := tree.NewTextifier(output, verbosity)
+	reader.Accept(textifier, tree.GetParsingOptions(verbosity))
+	return textifier.GetError()
+}
+
+// dumpPseudocode writes the pseudocode of the given class file, or of each class of the given jar file, to the given
+// writer.
+func dumpPseudocode(output *bufio.Writer, path string) error {
+	if strings.HasSuffix(path, ".class") {
+		classFile, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return writePseudocode(output, classFile)
+	}
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer jar.Close()
+	for _, file := range jar.File {
+		if !strings.HasSuffix(file.Name, ".class") || file.FileInfo().IsDir() {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			return err
+		}
+		classFile, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+		output.WriteString("\n")
+		if err := writePseudocode(output, classFile); err != nil {
+			return fmt.Errorf("%s: %v", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// writePseudocode writes the pseudocode of the given class to the given writer.
+func writePseudocode(output *bufio.Writer, classFile []byte) error {
+	class, err := tree.ReadClassNode(classFile, 0)
+	if err != nil {
+		return err
+	}
+	text, err := decomp.DecompileClass(class)
+	if err != nil {
+		return err
+	}
+	_, err = output.WriteString(text)
+	return err
+}
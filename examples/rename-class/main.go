@@ -0,0 +1,48 @@
+// Command rename-class renames a class file with {@link commons.CloneClass}, which updates the references of
+// the class to itself, and prints the renamed class, or writes it to the given output file.
+//
+//	rename-class <file.class> <new internal name> [<output.class>]
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/commons"
+	"github.com/leaklessgfy/asm/asm/tree"
+)
+
+func main() {
+	if len(os.Args) != 3 && len(os.Args) != 4 {
+		fmt.Fprintln(os.Stderr, "Bad usage: rename-class <file.class> <new internal name> [<output.class>]")
+		os.Exit(1)
+	}
+	if err := asm.CheckInternalName(os.Args[2]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	classFile, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	clone, err := commons.CloneClass(classFile, os.Args[2])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(os.Args) == 4 {
+		if err := os.WriteFile(os.Args[3], clone, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	class, err := tree.ReadClassNode(clone, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Print(tree.Textify(class))
+}
@@ -1,25 +1,87 @@
+// Command asm inspects class files:
+//
+//	asm [-provenance] [-events] <file.class>    prints the line numbers of the methods, the provenance
+//	    of the class, or its visitor events (with stable label names, for diffing)
+//	asm -strip <file.class> <out.class>    writes the class without its assertions and debug information
+//	asm method [-json] <file.class> <name><descriptor>    prints the report of a method
+//	asm symbolize <classpath entry>...    resolves the profiler frames read from the standard input
+//	asm graph [-graphml] [-output dir] <file.class|file.jar>...    exports the relationships between the classes,
+//	    as GraphML on the standard output, or as the nodes.csv and relationships.csv files of neo4j-admin import
+//	asm policy [-json] <policy.json> <file.class|file.jar>...    prints the violations of a policy, and exits with
+//	    status 2 if there are some
+//	asm rules <rules.json|rules.yaml> <in.jar> <out.jar>    applies transformation rules to the classes of a jar
+//
+// The programs of the examples directory show how to use the library for other tasks.
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/leaklessgfy/asm/asm"
+	"github.com/leaklessgfy/asm/asm/analysis"
+	"github.com/leaklessgfy/asm/asm/commons"
 	"github.com/leaklessgfy/asm/asm/helper"
+	"github.com/leaklessgfy/asm/asm/tree"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	if len(os.Args) > 1 && os.Args[1] == "method" {
+		method(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "symbolize" {
+		symbolize(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "policy" {
+		policy(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "graph" {
+		graph(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		rules(os.Args[2:])
+		return
+	}
+	strip := flag.Bool("strip", false, "write the class without its assertions and debug information to <out.class>")
+	provenance := flag.Bool("provenance", false, "display the provenance attribute of the class")
+	events := flag.Bool("events", false, "display the visitor events of the class, with stable label names")
+	flag.Parse()
+	if flag.NArg() < 1 {
 		fmt.Fprintln(os.Stderr, "Bad usage")
 		os.Exit(1)
 	}
 
-	bytes, err := ioutil.ReadFile(os.Args[1])
+	bytes, err := ioutil.ReadFile(flag.Arg(0))
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	if *strip {
+		if flag.NArg() != 2 {
+			fmt.Fprintln(os.Stderr, "Bad usage: -strip <file.class> <out.class>")
+			os.Exit(1)
+		}
+		stripped, err := commons.StripClassFile(bytes, commons.STRIP_ALL)
+		if err == nil {
+			err = ioutil.WriteFile(flag.Arg(1), stripped, 0644)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	reader, err := asm.NewClassReader(bytes)
 	if err != nil {
@@ -27,7 +89,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	reader.Accept(&helper.ClassVisitor{
+	var classVisitor asm.ClassVisitor = &helper.ClassVisitor{
 		OnVisitMethod: func(access int, name, descriptor, signature string, exceptions []string) asm.MethodVisitor {
 			return &helper.MethodVisitor{
 				OnVisitLineNumber: func(line int, start *asm.Label) {
@@ -35,5 +97,236 @@ func main() {
 				},
 			}
 		},
-	}, 0)
+	}
+	if *provenance {
+		classVisitor = &helper.ClassVisitor{
+			OnVisitAttribute: func(attribute *asm.Attribute) {
+				if attribute.GetType() != commons.PROVENANCE_ATTRIBUTE {
+					return
+				}
+				provenance, err := commons.ReadProvenance(attribute)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Println(provenance)
+			},
+		}
+	}
+	if *events {
+		labels := helper.NewLabelIDs()
+		classVisitor = helper.NewMiddlewareClassAdapter(nil, helper.Middleware{
+			Before: func(event *helper.Event) bool {
+				fmt.Println(event.Format(labels))
+				return true
+			},
+		})
+	}
+	reader.Accept(classVisitor, 0)
+}
+
+// method prints the report of a single method of a class: method [-json] <file.class> <name><descriptor>.
+func method(args []string) {
+	flags := flag.NewFlagSet("method", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print the report in JSON")
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "Bad usage: method [-json] <file.class> <name><descriptor>")
+		os.Exit(1)
+	}
+
+	bytes, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	class, err := tree.ReadClassNode(bytes, asm.EXPAND_FRAMS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var methodNode *tree.MethodNode
+	for _, candidate := range class.Methods {
+		if candidate.Name+candidate.Descriptor == flags.Arg(1) {
+			methodNode = candidate
+		}
+	}
+	if methodNode == nil {
+		fmt.Fprintln(os.Stderr, "No method "+flags.Arg(1)+" in "+class.Name)
+		os.Exit(1)
+	}
+
+	report := analysis.NewMethodReport(class.Name, methodNode)
+	if !*jsonOutput {
+		fmt.Print(report)
+		return
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// symbolize resolves the profiler stack frames read from the standard input to source locations:
+// symbolize <classpath entry>... where each input line is "<class> <method>[<descriptor>] <bci>".
+func symbolize(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Bad usage: symbolize <classpath entry>...")
+		os.Exit(1)
+	}
+	symbolizer := analysis.NewSymbolizer()
+	for _, path := range args {
+		if err := symbolizer.AddPath(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			fmt.Fprintln(os.Stderr, "Bad frame: "+scanner.Text())
+			continue
+		}
+		bci, err := strconv.Atoi(fields[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Bad frame: "+scanner.Text())
+			continue
+		}
+		name, descriptor := fields[1], ""
+		if i := strings.IndexByte(name, '('); i >= 0 {
+			name, descriptor = name[:i], name[i:]
+		}
+		fmt.Println(symbolizer.Symbolize(fields[0], name, descriptor, bci))
+	}
+}
+
+// graph exports the class graph of the given class and jar files.
+func graph(args []string) {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	graphML := flags.Bool("graphml", false, "write the graph as GraphML on the standard output")
+	output := flags.String("output", ".", "the directory of the nodes.csv and relationships.csv files")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Bad usage: graph [-graphml] [-output dir] <file.class|file.jar>...")
+		os.Exit(1)
+	}
+	classGraph := commons.NewClassGraph()
+	for _, path := range flags.Args() {
+		var err error
+		if strings.HasSuffix(path, ".class") {
+			var classFile []byte
+			if classFile, err = ioutil.ReadFile(path); err == nil {
+				err = classGraph.AddClass(classFile)
+			}
+		} else {
+			err = classGraph.AddJar(path)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, path+": "+err.Error())
+			os.Exit(1)
+		}
+	}
+	if *graphML {
+		if err := classGraph.WriteGraphML(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	nodes, err := os.Create(filepath.Join(*output, "nodes.csv"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer nodes.Close()
+	edges, err := os.Create(filepath.Join(*output, "relationships.csv"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer edges.Close()
+	if err := classGraph.WriteNeo4jCSV(nodes, edges); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// policy checks the given class and jar files against a policy configuration file.
+func policy(args []string) {
+	flags := flag.NewFlagSet("policy", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print the violations as a JSON document")
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "Bad usage: policy [-json] <policy.json> <file.class|file.jar>...")
+		os.Exit(1)
+	}
+	configuration, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	classPolicy, err := commons.ParsePolicy(configuration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var violations []commons.PolicyViolation
+	for _, path := range flags.Args()[1:] {
+		var pathViolations []commons.PolicyViolation
+		if strings.HasSuffix(path, ".class") {
+			var classFile []byte
+			if classFile, err = ioutil.ReadFile(path); err == nil {
+				pathViolations, err = classPolicy.CheckClass(classFile)
+			}
+		} else {
+			pathViolations, err = classPolicy.CheckJar(path)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, path+": "+err.Error())
+			os.Exit(1)
+		}
+		violations = append(violations, pathViolations...)
+	}
+	if *jsonOutput {
+		if err := commons.WritePolicyViolations(os.Stdout, violations); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		for _, violation := range violations {
+			fmt.Println(violation)
+		}
+	}
+	if len(violations) > 0 {
+		os.Exit(2)
+	}
+}
+
+// rules applies the transformation rules of a configuration file to the classes of a jar, and writes the result
+// to another jar: rules <rules.json|rules.yaml> <in.jar> <out.jar>.
+func rules(args []string) {
+	if len(args) != 3 {
+		fmt.Fprintln(os.Stderr, "Bad usage: rules <rules.json|rules.yaml> <in.jar> <out.jar>")
+		os.Exit(1)
+	}
+	configuration, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	rulesEngine, err := commons.ParseRulesEngine(configuration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	count, err := rulesEngine.ApplyJar(args[1], args[2], commons.TransformJarOptions{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(count, "classes transformed")
 }